@@ -10,12 +10,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/boshu2/agentops/cli/internal/formatter"
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 	"github.com/boshu2/agentops/cli/internal/parser"
 	"github.com/boshu2/agentops/cli/internal/storage"
 )
@@ -33,6 +33,7 @@ Examples:
   ao forge batch --dry-run          # List what would be processed
   ao forge batch --dir ~/.claude/projects/my-project
   ao forge batch --max 10           # Process up to 10 transcripts
+  ao forge batch --workers 4        # Forge 4 transcripts at a time
   ao forge batch --extract          # Trigger extraction after forging`,
 	RunE: runForgeBatch,
 }
@@ -41,6 +42,7 @@ var (
 	batchDir     string
 	batchExtract bool
 	batchMax     int
+	batchWorkers int
 )
 
 func init() {
@@ -48,6 +50,7 @@ func init() {
 	forgeBatchCmd.Flags().StringVar(&batchDir, "dir", "", "Specific directory to scan (default: all Claude project dirs)")
 	forgeBatchCmd.Flags().BoolVar(&batchExtract, "extract", false, "Trigger extraction after forging")
 	forgeBatchCmd.Flags().IntVar(&batchMax, "max", 0, "Maximum transcripts to process (0 = all)")
+	forgeBatchCmd.Flags().IntVar(&batchWorkers, "workers", 0, "Transcripts to process in parallel (0 = number of CPUs)")
 }
 
 func runForgeBatch(cmd *cobra.Command, args []string) error {
@@ -102,13 +105,16 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 
 	// Initialize storage
 	baseDir := filepath.Join(cwd, storage.DefaultBaseDir)
-	fs := storage.NewFileStorage(
-		storage.WithBaseDir(baseDir),
-		storage.WithFormatters(
-			formatter.NewMarkdownFormatter(),
-			formatter.NewJSONLFormatter(),
-		),
+	fs, err := openStorage(baseDir,
+		formatter.NewMarkdownFormatter(),
+		formatter.NewJSONLFormatter(),
 	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fs.Close() //nolint:errcheck // writes already synced, close best-effort
+	}()
 
 	if err := fs.Init(); err != nil {
 		return fmt.Errorf("initialize storage: %w", err)
@@ -116,7 +122,10 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 
 	p := parser.NewParser()
 	p.MaxContentLength = 0
-	extractor := parser.NewExtractor()
+	extractor, err := loadForgeExtractor(cwd)
+	if err != nil {
+		return err
+	}
 
 	var (
 		totalProcessed  int
@@ -129,58 +138,39 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 		processedPaths  []string
 	)
 
+	paths := make([]string, len(unforgedTranscripts))
 	for i, t := range unforgedTranscripts {
-		fmt.Printf("[%d/%d] Processing %s...\n", i+1, len(unforgedTranscripts), filepath.Base(t.path))
-
-		session, err := processTranscript(t.path, p, extractor, false, os.Stdout)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: skipping %s: %v\n", t.path, err)
-			totalFailed++
-			continue
-		}
-
-		// Write session
-		sessionPath, err := fs.WriteSession(session)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to write session for %s: %v\n", t.path, err)
-			continue
-		}
-
-		// Write index entry
-		indexEntry := &storage.IndexEntry{
-			SessionID:   session.ID,
-			Date:        session.Date,
-			SessionPath: sessionPath,
-			Summary:     session.Summary,
-		}
-		if err := fs.WriteIndex(indexEntry); err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to index session: %v\n", err)
-		}
+		paths[i] = t.path
+	}
+	pipeline := &forgePipeline{
+		fs:        fs,
+		baseDir:   baseDir,
+		parser:    p,
+		extractor: extractor,
+		workers:   batchWorkers,
+		opts: forgeOptions{
+			checkpointDir: forgeCheckpointDir(baseDir),
+			progress:      batchWorkers == 1,
+			w:             os.Stdout,
+		},
+	}
 
-		// Write provenance
-		provRecord := &storage.ProvenanceRecord{
-			ID:           fmt.Sprintf("prov-%s", session.ID[:7]),
-			ArtifactPath: sessionPath,
-			ArtifactType: "session",
-			SourcePath:   t.path,
-			SourceType:   "transcript",
-			SessionID:    session.ID,
-			CreatedAt:    time.Now(),
-		}
-		if err := fs.WriteProvenance(provRecord); err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to write provenance: %v\n", err)
-		}
+	done := 0
+	results := pipeline.run(paths, func(path string, ft forgedTranscript) {
+		done++
+		session := ft.Session
+		fmt.Printf("[%d/%d] Forged %s\n", done, len(paths), filepath.Base(path))
 
 		totalProcessed++
 		totalDecisions += len(session.Decisions)
 		totalKnowledge += len(session.Knowledge)
 		allKnowledge = append(allKnowledge, session.Knowledge...)
 		allDecisions = append(allDecisions, session.Decisions...)
-		processedPaths = append(processedPaths, t.path)
+		processedPaths = append(processedPaths, path)
 
 		// Record in forged index
 		forgedRecord := ForgedRecord{
-			Path:     t.path,
+			Path:     path,
 			ForgedAt: time.Now(),
 			Session:  session.ID,
 		}
@@ -189,6 +179,12 @@ func runForgeBatch(cmd *cobra.Command, args []string) error {
 		}
 
 		VerbosePrintf("  -> %d decisions, %d learnings\n", len(session.Decisions), len(session.Knowledge))
+	})
+	for i, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: skipping %s: %v\n", paths[i], r.Err)
+			totalFailed++
+		}
 	}
 
 	// Deduplicate across all sessions
@@ -408,30 +404,7 @@ func appendForgedRecord(path string, record ForgedRecord) error {
 		return fmt.Errorf("create directory: %w", err)
 	}
 
-	// Open file for append with exclusive lock
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("open forged index: %w", err)
-	}
-	defer func() {
-		_ = f.Close()
-	}()
-
-	// Acquire exclusive lock
-	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
-		return fmt.Errorf("lock forged index: %w", err)
-	}
-	defer func() {
-		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
-	}()
-
-	// Marshal and write record
-	data, err := json.Marshal(record)
-	if err != nil {
-		return fmt.Errorf("marshal record: %w", err)
-	}
-
-	if _, err := f.Write(append(data, '\n')); err != nil {
+	if err := fsutil.AppendJSONL(path, 0644, record); err != nil {
 		return fmt.Errorf("write record: %w", err)
 	}
 
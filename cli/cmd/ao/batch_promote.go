@@ -93,7 +93,12 @@ func runBatchPromote(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load citations for checking
-	citations, err := ratchet.LoadCitations(cwd)
+	opts, closeStore, err := citationOptions(cwd)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	citations, err := ratchet.LoadCitations(cwd, opts...)
 	if err != nil {
 		VerbosePrintf("Warning: could not load citations: %v\n", err)
 	}
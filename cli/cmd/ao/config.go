@@ -35,7 +35,8 @@ Environment variables:
 
 Examples:
   ao config --show           # Show resolved configuration
-  ao config --show -o json   # Output as JSON`,
+  ao config --show -o json   # Output as JSON
+  ao config taxonomy         # Show scoring weights and tier thresholds`,
 	RunE: runConfig,
 }
 
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// TaxonomyConfigFile is the scoring taxonomy file under .agents/ao.
+const TaxonomyConfigFile = "taxonomy.yaml"
+
+var taxonomyConfig string
+
+var configTaxonomyCmd = &cobra.Command{
+	Use:   "taxonomy",
+	Short: "Show the effective scoring taxonomy",
+	Long: `Print the rubric weights, tier thresholds, human-gate settings and base
+scores used to score knowledge candidates.
+
+The compiled-in defaults can be overridden with .agents/ao/taxonomy.yaml:
+
+  version: 1
+  weights:                  # must sum to 1.0
+    specificity: 0.45
+    actionability: 0.20
+    novelty: 0.15
+    context: 0.10
+    confidence: 0.10
+  tiers:                    # gold, silver, bronze, discard
+    gold:   {min_score: 0.90}
+    bronze: {min_score: 0.55, human_gate: true, sample_rate: 0.10}
+  base_scores:              # decision, solution, learning, failure, reference
+    reference: 0.4
+
+Omitted values keep their defaults; a tier ends where the one above it
+begins. The file is validated whenever it is loaded. After changing it,
+run 'ao pool rescore' to apply it to candidates already in the pool.
+
+Examples:
+  ao config taxonomy
+  ao config taxonomy --config draft-taxonomy.yaml
+  ao config taxonomy -o json`,
+	RunE: runConfigTaxonomy,
+}
+
+func init() {
+	configCmd.AddCommand(configTaxonomyCmd)
+	configTaxonomyCmd.Flags().StringVar(&taxonomyConfig, "config", "", "Taxonomy file (default: .agents/ao/taxonomy.yaml)")
+}
+
+// taxonomyConfigPath returns the taxonomy file for cwd.
+func taxonomyConfigPath(cwd string) string {
+	return filepath.Join(cwd, storage.DefaultBaseDir, TaxonomyConfigFile)
+}
+
+// loadTaxonomy returns the defaults with .agents/ao/taxonomy.yaml applied,
+// if present. An invalid file is an error rather than silently falling
+// back to the defaults.
+func loadTaxonomy(cwd string) (*taxonomy.Taxonomy, error) {
+	return loadTaxonomyFile(taxonomyConfigPath(cwd), false)
+}
+
+// loadTaxonomyFile loads the taxonomy from path. A missing file yields the
+// defaults unless required is set.
+func loadTaxonomyFile(path string, required bool) (*taxonomy.Taxonomy, error) {
+	tax, err := taxonomy.LoadConfigFile(path)
+	if os.IsNotExist(err) && !required {
+		return taxonomy.Default(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load taxonomy: %w", err)
+	}
+	return tax, nil
+}
+
+// taxonomyTier is one tier in the ao config taxonomy output.
+type taxonomyTier struct {
+	Tier       types.Tier `json:"tier"`
+	MinScore   float64    `json:"min_score"`
+	MaxScore   float64    `json:"max_score"`
+	Confidence float64    `json:"confidence"`
+	HumanGate  bool       `json:"human_gate"`
+	SampleRate float64    `json:"sample_rate"`
+}
+
+// taxonomyReport is the output of ao config taxonomy.
+type taxonomyReport struct {
+	Source     string                          `json:"source"`
+	Weights    map[string]float64              `json:"weights"`
+	Tiers      []taxonomyTier                  `json:"tiers"`
+	BaseScores map[types.KnowledgeType]float64 `json:"base_scores"`
+}
+
+func runConfigTaxonomy(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	path, required := taxonomyConfig, true
+	if path == "" {
+		path, required = taxonomyConfigPath(cwd), false
+	}
+	tax, err := loadTaxonomyFile(path, required)
+	if err != nil {
+		return err
+	}
+
+	report := newTaxonomyReport(tax, "defaults")
+	if _, err := os.Stat(path); err == nil {
+		report.Source = path
+	}
+
+	if GetOutput() == "json" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+	printTaxonomyReport(os.Stdout, report)
+	return nil
+}
+
+func newTaxonomyReport(tax *taxonomy.Taxonomy, source string) taxonomyReport {
+	report := taxonomyReport{
+		Source: source,
+		Weights: map[string]float64{
+			"specificity":   tax.Weights.Specificity,
+			"actionability": tax.Weights.Actionability,
+			"novelty":       tax.Weights.Novelty,
+			"context":       tax.Weights.Context,
+			"confidence":    tax.Weights.Confidence,
+		},
+		BaseScores: tax.BaseScores,
+	}
+	for _, tier := range taxonomy.TierOrder {
+		cfg, ok := tax.Tiers[tier]
+		if !ok {
+			continue
+		}
+		report.Tiers = append(report.Tiers, taxonomyTier{
+			Tier:       tier,
+			MinScore:   cfg.MinScore,
+			MaxScore:   min(cfg.MaxScore, 1.0),
+			Confidence: cfg.Confidence,
+			HumanGate:  cfg.HumanGateRequired,
+			SampleRate: cfg.HumanGateSampleRate,
+		})
+	}
+	return report
+}
+
+func printTaxonomyReport(w io.Writer, report taxonomyReport) {
+	fmt.Fprintln(w, "Scoring Taxonomy")
+	fmt.Fprintln(w, "================")
+	fmt.Fprintf(w, "Source: %s\n", report.Source)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Rubric weights:")
+	for _, name := range []string{"specificity", "actionability", "novelty", "context", "confidence"} {
+		fmt.Fprintf(w, "  %-14s %.2f\n", name, report.Weights[name])
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Tiers:")
+	fmt.Fprintf(w, "  %-8s %-11s %-10s %-10s %s\n", "TIER", "SCORE", "CONFIDENCE", "HUMAN GATE", "SAMPLE")
+	for _, t := range report.Tiers {
+		gate := "no"
+		if t.HumanGate {
+			gate = "yes"
+		}
+		fmt.Fprintf(w, "  %-8s %.2f-%.2f   %-10.2f %-10s %.0f%%\n", t.Tier, t.MinScore, t.MaxScore, t.Confidence, gate, t.SampleRate*100)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Base scores:")
+	for _, kt := range []types.KnowledgeType{
+		types.KnowledgeTypeDecision,
+		types.KnowledgeTypeSolution,
+		types.KnowledgeTypeLearning,
+		types.KnowledgeTypeFailure,
+		types.KnowledgeTypeReference,
+	} {
+		fmt.Fprintf(w, "  %-14s %.2f\n", kt, report.BaseScores[kt])
+	}
+}
@@ -0,0 +1,635 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/search"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+var (
+	dedupThreshold float64
+	dedupShingle   int
+	dedupApply     bool
+)
+
+// DefaultDedupThreshold is the minimum estimated Jaccard similarity of
+// word shingles for two learnings to count as near-duplicates.
+const DefaultDedupThreshold = 0.6
+
+// dedupKinds are the .agents subdirectories scanned by `ao dedup`, with the
+// knowledge type of each. Duplicates are only grouped within one directory.
+var dedupKinds = []struct {
+	Dir  string
+	Type types.KnowledgeType
+}{
+	{"learnings", types.KnowledgeTypeLearning},
+	{"patterns", types.KnowledgeTypeDecision},
+}
+
+var dedupCmd = &cobra.Command{
+	Use:   "dedup",
+	Short: "Find and merge near-duplicate learnings and patterns",
+	Long: `Find near-duplicate learnings and patterns and propose merge groups.
+
+Bodies are compared as sets of word shingles (runs of consecutive words)
+using MinHash signatures, so rewording, case and punctuation changes still
+match. Superseded files are ignored.
+
+Each group lists a representative (highest utility) first. With --apply,
+every group becomes one merged learning that supersedes the originals:
+  - the originals get superseded_by set and drop out of inject and search
+  - the merged file keeps the best utility and the union of provenance
+  - citations of the originals are re-recorded against the merged file
+  - provenance records link the merged file back to each original
+
+Examples:
+  ao dedup                        # Show near-duplicate groups
+  ao dedup --threshold 0.8        # Only very close duplicates
+  ao dedup --apply                # Merge every group
+  ao dedup --apply --dry-run      # Show what --apply would write
+  ao dedup -o json`,
+	RunE: runDedup,
+}
+
+func init() {
+	rootCmd.AddCommand(dedupCmd)
+	dedupCmd.Flags().Float64Var(&dedupThreshold, "threshold", DefaultDedupThreshold, "Minimum shingle similarity (0-1) to treat files as duplicates")
+	dedupCmd.Flags().IntVar(&dedupShingle, "shingle", search.DefaultShingleSize, "Words per shingle")
+	dedupCmd.Flags().BoolVar(&dedupApply, "apply", false, "Write merged learnings and supersede the originals")
+}
+
+// dedupDoc is a learning or pattern file considered for deduplication.
+type dedupDoc struct {
+	Path          string
+	ID            string
+	Title         string
+	Body          string
+	Utility       float64
+	Depth         int
+	SessionID     string
+	ProvenanceIDs []string
+}
+
+// dedupMember is one file in a reported duplicate group.
+type dedupMember struct {
+	ID         string  `json:"id"`
+	Path       string  `json:"path"`
+	Title      string  `json:"title"`
+	Utility    float64 `json:"utility"`
+	Similarity float64 `json:"similarity"`
+}
+
+// dedupGroup is a set of near-duplicates; Members[0] is the representative.
+type dedupGroup struct {
+	Kind    string        `json:"kind"`
+	Members []dedupMember `json:"members"`
+	Merged  string        `json:"merged,omitempty"`
+
+	docs []*dedupDoc
+	typ  types.KnowledgeType
+	dir  string
+}
+
+func runDedup(cmd *cobra.Command, args []string) error {
+	if dedupThreshold <= 0 || dedupThreshold > 1 {
+		return fmt.Errorf("--threshold must be in (0, 1], got %v", dedupThreshold)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	var groups []*dedupGroup
+	scanned := 0
+	for _, kind := range dedupKinds {
+		dir := filepath.Join(cwd, ".agents", kind.Dir)
+		docs, err := loadDedupDocs(dir)
+		if err != nil {
+			return err
+		}
+		scanned += len(docs)
+		for _, g := range findDuplicateGroups(docs, dedupThreshold, dedupShingle) {
+			g.Kind = kind.Dir
+			g.typ = kind.Type
+			g.dir = dir
+			groups = append(groups, g)
+		}
+	}
+
+	if dedupApply {
+		now := time.Now()
+		for _, g := range groups {
+			path, err := mergeDuplicateGroup(cwd, g, now, GetDryRun())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping group led by %s: %v\n", g.Members[0].ID, err)
+				continue
+			}
+			g.Merged = path
+		}
+	}
+
+	if GetOutput() == "json" {
+		if groups == nil {
+			groups = []*dedupGroup{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(groups)
+	}
+
+	fmt.Printf("Scanned %d file(s), found %d duplicate group(s)\n", scanned, len(groups))
+	for i, g := range groups {
+		fmt.Printf("\nGroup %d (%s):\n", i+1, g.Kind)
+		for j, m := range g.Members {
+			marker := "  "
+			if j == 0 {
+				marker = "* "
+			}
+			fmt.Printf("  %s%s  utility=%.2f  similarity=%.2f  %s\n",
+				marker, m.ID, m.Utility, m.Similarity, truncateText(m.Title, 60))
+		}
+		if g.Merged != "" {
+			verb := "Merged into"
+			if GetDryRun() {
+				verb = "[dry-run] Would merge into"
+			}
+			fmt.Printf("  %s %s\n", verb, relPath(cwd, g.Merged))
+		}
+	}
+	if len(groups) > 0 && !dedupApply {
+		fmt.Println("\nRun with --apply to merge each group into a new learning.")
+	}
+	return nil
+}
+
+// loadDedupDocs reads the current (not superseded) markdown and JSONL
+// files in dir, in path order. A missing directory yields no documents.
+func loadDedupDocs(dir string) ([]*dedupDoc, error) {
+	md, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("glob %s: %w", dir, err)
+	}
+	jsonl, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	files := append(md, jsonl...)
+	sort.Strings(files)
+
+	var docs []*dedupDoc
+	for _, file := range files {
+		var doc *dedupDoc
+		if strings.HasSuffix(file, ".jsonl") {
+			doc, err = readDedupJSONL(file)
+		} else {
+			doc, err = readDedupMarkdown(file)
+		}
+		if err != nil {
+			VerbosePrintf("Skipping %s: %v\n", file, err)
+			continue
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// readDedupMarkdown reads a markdown learning; nil means it is superseded.
+func readDedupMarkdown(path string) (*dedupDoc, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(content), "\n")
+	fm, start := parseFrontMatter(lines)
+	if fm.SupersededBy != "" && fm.SupersededBy != "null" && fm.SupersededBy != "~" {
+		return nil, nil
+	}
+
+	doc := &dedupDoc{
+		Path:    path,
+		ID:      strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Body:    strings.TrimSpace(strings.Join(lines[start:], "\n")),
+		Utility: types.InitialUtility,
+	}
+	if fm.HasUtility {
+		doc.Utility = fm.Utility
+	}
+	fields := map[string]string{}
+	if start > 0 {
+		if fields, err = parseFrontmatterFields(path, "id", "supersession_depth", "source_session", "provenance_ids"); err != nil {
+			return nil, err
+		}
+	}
+	if id := fields["id"]; id != "" {
+		doc.ID = id
+	}
+	doc.Depth, _ = strconv.Atoi(fields["supersession_depth"]) //nolint:errcheck // missing depth is 0
+	doc.SessionID = fields["source_session"]
+	doc.ProvenanceIDs = parseFrontMatterList(fields["provenance_ids"])
+
+	for _, line := range lines[start:] {
+		if strings.HasPrefix(line, "# ") {
+			doc.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			break
+		}
+	}
+	if doc.Title == "" {
+		doc.Title = doc.ID
+	}
+	return doc, nil
+}
+
+// readDedupJSONL reads the first record of a JSONL learning; nil means it
+// is superseded.
+func readDedupJSONL(path string) (*dedupDoc, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only, close error non-fatal
+	}()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty JSONL file")
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(scanner.Bytes(), &data); err != nil {
+		return nil, fmt.Errorf("parse JSONL: %w", err)
+	}
+	if s, ok := data["superseded_by"].(string); ok && s != "" {
+		return nil, nil
+	}
+
+	doc := &dedupDoc{
+		Path:    path,
+		ID:      strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Utility: types.InitialUtility,
+	}
+	if id, ok := data["id"].(string); ok && id != "" {
+		doc.ID = id
+	}
+	doc.Title, _ = data["title"].(string)
+	body, _ := data["content"].(string)
+	if body == "" {
+		body, _ = data["summary"].(string)
+	}
+	doc.Body = strings.TrimSpace(body)
+	if doc.Title == "" {
+		doc.Title = truncateText(firstLine(doc.Body), 80)
+	}
+	if u, ok := data["utility"].(float64); ok && u > 0 {
+		doc.Utility = u
+	}
+	if d, ok := data["supersession_depth"].(float64); ok {
+		doc.Depth = int(d)
+	}
+	if ids, ok := data["provenance_ids"].([]interface{}); ok {
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				doc.ProvenanceIDs = append(doc.ProvenanceIDs, s)
+			}
+		}
+	}
+	if src, ok := data["source"].(map[string]interface{}); ok {
+		doc.SessionID, _ = src["session_id"].(string)
+	}
+	return doc, nil
+}
+
+// findDuplicateGroups groups docs whose bodies share at least threshold of
+// their k-word shingles (MinHash estimate). Each group's members are ordered
+// by utility, then body length, so the first is the representative.
+func findDuplicateGroups(docs []*dedupDoc, threshold float64, k int) []*dedupGroup {
+	hasher := search.NewMinHasher(search.DefaultMinHashes)
+	shingles := make([][]uint64, len(docs))
+	sigs := make([][]uint64, len(docs))
+	for i, d := range docs {
+		shingles[i] = search.Shingles(d.Title+"\n"+d.Body, k)
+		sigs[i] = hasher.Signature(shingles[i])
+	}
+
+	var groups []*dedupGroup
+	for _, idx := range search.GroupNearDuplicates(sigs, search.DefaultMinHashBands, threshold) {
+		sort.SliceStable(idx, func(a, b int) bool {
+			da, db := docs[idx[a]], docs[idx[b]]
+			if da.Utility != db.Utility {
+				return da.Utility > db.Utility
+			}
+			return len(da.Body) > len(db.Body)
+		})
+		g := &dedupGroup{}
+		for _, i := range idx {
+			g.docs = append(g.docs, docs[i])
+			g.Members = append(g.Members, dedupMember{
+				ID:         docs[i].ID,
+				Path:       docs[i].Path,
+				Title:      docs[i].Title,
+				Utility:    docs[i].Utility,
+				Similarity: search.Jaccard(shingles[idx[0]], shingles[i]),
+			})
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// mergeDuplicateGroup writes a merged learning for g that supersedes every
+// member via types.Supersede, then marks the originals superseded and
+// carries their citations and provenance over. Every file is rendered
+// before any is written, and a failed write restores the files already
+// written, so a group is merged completely or not at all. It returns the
+// merged path; with dryRun nothing is written.
+func mergeDuplicateGroup(cwd string, g *dedupGroup, now time.Time, dryRun bool) (string, error) {
+	ids := make([]string, len(g.docs))
+	for i, d := range g.docs {
+		ids[i] = d.ID
+	}
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	mergedID := "merged-" + hex.EncodeToString(sum[:4])
+
+	merged := &types.Candidate{
+		ID:          mergedID,
+		Type:        g.typ,
+		Content:     g.docs[0].Body,
+		ExtractedAt: now,
+		Metadata:    map[string]interface{}{"merged_from": ids, "supersedes": ids},
+	}
+
+	// Supersede shallowest first so the merged depth ends one past the
+	// deepest original; fail before writing if that exceeds the maximum.
+	// Supersede keeps only the last ID in merged.Supersedes, so the full
+	// list is kept in the "supersedes" metadata.
+	originals := make([]*types.Candidate, len(g.docs))
+	for i, d := range g.docs {
+		originals[i] = &types.Candidate{ID: d.ID, SupersessionDepth: d.Depth, IsCurrent: true}
+	}
+	byDepth := append([]*types.Candidate(nil), originals...)
+	sort.SliceStable(byDepth, func(i, j int) bool {
+		return byDepth[i].SupersessionDepth < byDepth[j].SupersessionDepth
+	})
+	for _, o := range byDepth {
+		if err := types.Supersede(o, merged); err != nil {
+			return "", err
+		}
+	}
+
+	seenProv := make(map[string]bool)
+	for i, d := range g.docs {
+		merged.Utility = math.Max(merged.Utility, d.Utility)
+		provID := fmt.Sprintf("prov-%s-%d", mergedID, i+1)
+		for _, id := range append(append([]string(nil), d.ProvenanceIDs...), provID) {
+			if !seenProv[id] {
+				seenProv[id] = true
+				merged.ProvenanceIDs = append(merged.ProvenanceIDs, id)
+			}
+		}
+	}
+
+	path := filepath.Join(g.dir, fmt.Sprintf("%s-%s.md", now.Format("2006-01-02"), mergedID))
+	if dryRun {
+		return path, nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", relPath(cwd, path))
+	}
+
+	before := make([][]byte, len(g.docs))
+	after := make([][]byte, len(g.docs))
+	for i, d := range g.docs {
+		var err error
+		if before[i], err = os.ReadFile(d.Path); err != nil {
+			return "", fmt.Errorf("read %s: %w", d.ID, err)
+		}
+		if after[i], err = supersededContent(d.Path, before[i], originals[i]); err != nil {
+			return "", fmt.Errorf("mark %s superseded: %w", d.ID, err)
+		}
+	}
+
+	if err := fsutil.WriteFileAtomic(path, []byte(renderMergedLearning(cwd, merged, g.docs)), 0644); err != nil {
+		return "", fmt.Errorf("write merged learning: %w", err)
+	}
+	for i, d := range g.docs {
+		if err := fsutil.WriteFileAtomic(d.Path, after[i], 0644); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if rbErr := fsutil.WriteFileAtomic(g.docs[j].Path, before[j], 0644); rbErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to restore %s: %v\n", relPath(cwd, g.docs[j].Path), rbErr)
+				}
+			}
+			if rbErr := os.Remove(path); rbErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to remove %s: %v\n", relPath(cwd, path), rbErr)
+			}
+			return "", fmt.Errorf("mark %s superseded: %w", d.ID, err)
+		}
+	}
+	if err := carryCitations(cwd, g.docs, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to carry citations to %s: %v\n", mergedID, err)
+	}
+	if err := carryProvenance(cwd, g.docs, path, mergedID, string(g.typ), now); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record provenance for %s: %v\n", mergedID, err)
+	}
+	return path, nil
+}
+
+// renderMergedLearning renders the merged learning as markdown with front
+// matter. The representative's body is kept verbatim; the originals are
+// listed at the end.
+func renderMergedLearning(cwd string, merged *types.Candidate, docs []*dedupDoc) string {
+	ids := make([]string, len(docs))
+	var sessions []string
+	seenSession := make(map[string]bool)
+	for i, d := range docs {
+		ids[i] = d.ID
+		if d.SessionID != "" && !seenSession[d.SessionID] {
+			seenSession[d.SessionID] = true
+			sessions = append(sessions, d.SessionID)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	fmt.Fprintf(&sb, "id: %s\n", merged.ID)
+	fmt.Fprintf(&sb, "type: %s\n", merged.Type)
+	fmt.Fprintf(&sb, "created_at: %s\n", merged.ExtractedAt.Format("2006-01-02"))
+	fmt.Fprintf(&sb, "utility: %.4f\n", merged.Utility)
+	supersedes, _ := merged.Metadata["supersedes"].([]string)
+	fmt.Fprintf(&sb, "supersedes: [%s]\n", strings.Join(supersedes, ", "))
+	fmt.Fprintf(&sb, "merged_from: [%s]\n", strings.Join(ids, ", "))
+	fmt.Fprintf(&sb, "supersession_depth: %d\n", merged.SupersessionDepth)
+	fmt.Fprintf(&sb, "is_current: %t\n", merged.IsCurrent)
+	if len(merged.ProvenanceIDs) > 0 {
+		fmt.Fprintf(&sb, "provenance_ids: [%s]\n", strings.Join(merged.ProvenanceIDs, ", "))
+	}
+	if len(sessions) > 0 {
+		fmt.Fprintf(&sb, "source_session: %s\n", sessions[0])
+		fmt.Fprintf(&sb, "source_sessions: [%s]\n", strings.Join(sessions, ", "))
+	}
+	sb.WriteString("---\n\n")
+
+	body := merged.Content
+	if !strings.HasPrefix(body, "# ") {
+		fmt.Fprintf(&sb, "# %s\n\n", docs[0].Title)
+	}
+	sb.WriteString(body)
+	sb.WriteString("\n\n## Merged From\n\n")
+	for _, d := range docs {
+		fmt.Fprintf(&sb, "- `%s` (%s, utility %.2f)\n", d.ID, relPath(cwd, d.Path), d.Utility)
+	}
+	return sb.String()
+}
+
+// supersededContent returns content, the file at path, with c's
+// supersession fields recorded: front matter for markdown, the first
+// record for JSONL.
+func supersededContent(path string, content []byte, c *types.Candidate) ([]byte, error) {
+	lines := strings.Split(string(content), "\n")
+
+	if strings.HasSuffix(path, ".jsonl") {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &data); err != nil {
+			return nil, fmt.Errorf("parse JSONL: %w", err)
+		}
+		data["superseded_by"] = c.SupersededBy
+		data["is_current"] = c.IsCurrent
+		first, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		lines[0] = string(first)
+		return []byte(strings.Join(lines, "\n")), nil
+	}
+
+	fields := map[string]string{
+		"superseded_by": c.SupersededBy,
+		"is_current":    strconv.FormatBool(c.IsCurrent),
+	}
+	var out []string
+	if _, end := parseFrontMatter(lines); end > 0 {
+		out = append([]string{"---"}, updateFrontMatterFields(lines[1:end-1], fields)...)
+		out = append(out, lines[end-1:]...)
+	} else {
+		out = append([]string{"---"}, updateFrontMatterFields(nil, fields)...)
+		out = append(out, "---")
+		out = append(out, lines...)
+	}
+	return []byte(strings.Join(out, "\n")), nil
+}
+
+// carryCitations re-records every citation of the original files against
+// the merged file, keeping session, time and feedback.
+func carryCitations(cwd string, docs []*dedupDoc, mergedPath string) error {
+	opts, closeStore, err := citationOptions(cwd)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	citations, err := ratchet.LoadCitations(cwd, opts...)
+	if err != nil {
+		return err
+	}
+	originals := make(map[string]bool, len(docs))
+	for _, d := range docs {
+		originals[filepath.Clean(d.Path)] = true
+	}
+	for _, c := range citations {
+		path := c.ArtifactPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		if !originals[filepath.Clean(path)] {
+			continue
+		}
+		c.ArtifactPath = mergedPath
+		if err := ratchet.RecordCitation(cwd, c, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// carryProvenance links the merged file to each original, and copies the
+// originals' own provenance records to the merged file so it still traces
+// back to their transcripts. Every record written gets its own
+// prov-<merged>-<n> ID.
+func carryProvenance(cwd string, docs []*dedupDoc, mergedPath, mergedID, artifactType string, now time.Time) error {
+	fs, err := openStorage(filepath.Join(cwd, storage.DefaultBaseDir))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fs.Close() //nolint:errcheck // writes already synced, close best-effort
+	}()
+	n := len(docs)
+	for i, d := range docs {
+		if err := fs.WriteProvenance(&storage.ProvenanceRecord{
+			ID:           fmt.Sprintf("prov-%s-%d", mergedID, i+1),
+			ArtifactPath: mergedPath,
+			ArtifactType: artifactType,
+			SourcePath:   d.Path,
+			SourceType:   artifactType,
+			SessionID:    d.SessionID,
+			CreatedAt:    now,
+			Metadata:     map[string]interface{}{"operation": "dedup", "source_id": d.ID},
+		}); err != nil {
+			return err
+		}
+
+		records, err := fs.QueryProvenance(d.Path)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			n++
+			r.ID = fmt.Sprintf("prov-%s-%d", mergedID, n)
+			r.ArtifactPath = mergedPath
+			r.CreatedAt = now
+			if err := fs.WriteProvenance(&r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseFrontMatterList parses an inline YAML list such as "[a, b]".
+func parseFrontMatterList(value string) []string {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	if value == "" {
+		return nil
+	}
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.Trim(strings.TrimSpace(item), "\"'"); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// relPath returns path relative to base when possible.
+func relPath(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/search"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func writeDedupFixture(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDedupFindsAndMergesDuplicates(t *testing.T) {
+	cwd := t.TempDir()
+	dir := filepath.Join(cwd, ".agents", "learnings")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	a := writeDedupFixture(t, dir, "a.md", "---\nid: L-a\nutility: 0.4\nsource_session: s1\n---\n\n# Atomic writes\n\nWrite to a temp file and rename it into place so readers never see a torn file after a crash.\n")
+	b := writeDedupFixture(t, dir, "b.md", "---\nid: L-b\nutility: 0.8\n---\n\n# Atomic writes\n\nWrite to a temp file and then rename it into place so readers never see a torn file after a crash.\n")
+	c := writeDedupFixture(t, dir, "c.jsonl", `{"id":"L-c","title":"Atomic writes","content":"Write to a temp file and rename it into place so readers never see a torn file after a crash.","utility":0.6,"supersession_depth":2,"provenance_ids":["prov-x"],"source":{"session_id":"s2"}}`+"\n")
+	writeDedupFixture(t, dir, "d.md", "# Table tests\n\nPrefer table driven tests so each new parser case is a single line.\n")
+	writeDedupFixture(t, dir, "e.md", "---\nsuperseded_by: L-z\n---\n\n# Atomic writes\n\nWrite to a temp file and rename it into place so readers never see a torn file after a crash.\n")
+
+	if err := ratchet.RecordCitation(cwd, types.CitationEvent{ArtifactPath: a, SessionID: "s9", CitationType: "retrieved"}); err != nil {
+		t.Fatal(err)
+	}
+	fs := storage.NewFileStorage(storage.WithBaseDir(filepath.Join(cwd, storage.DefaultBaseDir)))
+	if err := fs.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteProvenance(&storage.ProvenanceRecord{ID: "prov-a1", ArtifactPath: a, SourcePath: "t.jsonl", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := loadDedupDocs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 4 {
+		t.Fatalf("expected 4 current docs (superseded skipped), got %d", len(docs))
+	}
+
+	groups := findDuplicateGroups(docs, DefaultDedupThreshold, search.DefaultShingleSize)
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(groups))
+	}
+	g := groups[0]
+	if len(g.Members) != 3 || g.Members[0].ID != "L-b" {
+		t.Fatalf("members = %+v, want L-b first of 3", g.Members)
+	}
+	g.typ, g.dir = types.KnowledgeTypeLearning, dir
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	dry, err := mergeDuplicateGroup(cwd, g, now, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dry); !os.IsNotExist(err) {
+		t.Fatal("dry run should not write the merged file")
+	}
+
+	merged, err := mergeDuplicateGroup(cwd, g, now, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(content)
+	for _, want := range []string{
+		"utility: 0.8000",
+		"supersession_depth: 3",
+		"supersedes: [L-b, L-c, L-a]",
+		"merged_from: [L-b, L-c, L-a]",
+		"prov-x",
+		"source_sessions: [s2, s1]",
+		"# Atomic writes",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("merged file missing %q:\n%s", want, text)
+		}
+	}
+
+	// Originals drop out of inject.
+	for _, p := range []string{a, b, c} {
+		l, err := parseLearningFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !l.Superseded {
+			t.Errorf("%s should be superseded", filepath.Base(p))
+		}
+	}
+	if l, _ := parseLearningFile(merged); l.Superseded || l.Utility != 0.8 {
+		t.Errorf("merged learning = %+v", l)
+	}
+
+	citations, err := ratchet.LoadCitations(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(citations) != 2 || citations[1].ArtifactPath != merged || citations[1].SessionID != "s9" {
+		t.Errorf("citations = %+v, want the original re-recorded for the merged file", citations)
+	}
+
+	records, err := fs.QueryProvenance(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Errorf("expected 3 provenance links and 1 carried record, got %+v", records)
+	}
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if seen[r.ID] || r.ID == "prov-a1" {
+			t.Errorf("provenance ID %s reused: %+v", r.ID, records)
+		}
+		seen[r.ID] = true
+	}
+	if orig, _ := fs.QueryProvenance(a); len(orig) != 1 || orig[0].ID != "prov-a1" {
+		t.Errorf("original provenance should be kept, got %+v", orig)
+	}
+}
+
+func TestDedupMergeIsAllOrNothing(t *testing.T) {
+	cwd := t.TempDir()
+	dir := filepath.Join(cwd, ".agents", "learnings")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	body := "Write to a temp file and rename it into place so readers never see a torn file after a crash."
+	a := writeDedupFixture(t, dir, "a.md", "---\nid: L-a\nutility: 0.9\n---\n\n# Atomic writes\n\n"+body+"\n")
+	b := writeDedupFixture(t, dir, "b.jsonl", `{"id":"L-b","title":"Atomic writes","content":"`+body+`","utility":0.5}`+"\n")
+
+	docs, err := loadDedupDocs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := findDuplicateGroups(docs, DefaultDedupThreshold, search.DefaultShingleSize)
+	if len(groups) != 1 || len(groups[0].docs) != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	g := groups[0]
+	g.typ, g.dir = types.KnowledgeTypeLearning, dir
+
+	// The second original can no longer be marked superseded
+	writeDedupFixture(t, dir, "b.jsonl", "not json\n")
+	aBefore, err := os.ReadFile(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mergeDuplicateGroup(cwd, g, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), false); err == nil {
+		t.Fatal("expected an error marking b superseded")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("no merged file should be left behind, got %d entries", len(entries))
+	}
+	if aAfter, _ := os.ReadFile(a); string(aAfter) != string(aBefore) {
+		t.Errorf("a.md changed by a failed merge:\n%s", aAfter)
+	}
+	if bAfter, _ := os.ReadFile(b); string(bAfter) != "not json\n" {
+		t.Errorf("b.jsonl changed by a failed merge:\n%s", bAfter)
+	}
+}
+
+func TestDedupRefusesDeepChains(t *testing.T) {
+	dir := t.TempDir()
+	g := &dedupGroup{
+		docs: []*dedupDoc{{ID: "a", Depth: types.MaxSupersessionDepth}, {ID: "b"}},
+		dir:  dir,
+	}
+	if _, err := mergeDuplicateGroup(dir, g, time.Now(), false); err == nil {
+		t.Fatal("expected supersession depth error")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("nothing should be written, got %d entries", len(entries))
+	}
+}
+
+func TestParseFrontMatterList(t *testing.T) {
+	got := parseFrontMatterList(`[a, "b", , c]`)
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("got %v", got)
+	}
+	if parseFrontMatterList("") != nil {
+		t.Error("empty value should be nil")
+	}
+}
@@ -2,16 +2,17 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 	"github.com/boshu2/agentops/cli/internal/storage"
 )
 
@@ -226,35 +227,20 @@ func rewritePendingFile(pendingPath string, entries []PendingExtraction) error {
 		return fmt.Errorf("create directory: %w", err)
 	}
 
-	// Open file with exclusive lock
-	f, err := os.OpenFile(pendingPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("open pending file: %w", err)
-	}
-	defer func() {
-		_ = f.Close()
-	}()
-
-	// Acquire exclusive lock
-	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
-		return fmt.Errorf("lock pending file: %w", err)
-	}
-	defer func() {
-		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
-	}()
-
-	// Write each entry as JSONL
+	var buf bytes.Buffer
 	for _, entry := range entries {
 		line, err := json.Marshal(entry)
 		if err != nil {
 			return fmt.Errorf("marshal entry: %w", err)
 		}
-		if _, err := f.Write(append(line, '\n')); err != nil {
-			return fmt.Errorf("write entry: %w", err)
-		}
+		buf.Write(append(line, '\n'))
 	}
 
-	return nil
+	// Replace atomically under the lock so a crash never leaves the queue
+	// truncated and concurrent appends are not interleaved with the rewrite
+	return fsutil.WithLock(pendingPath, func() error {
+		return fsutil.WriteFileAtomic(pendingPath, buf.Bytes(), 0600)
+	})
 }
 
 func readPendingExtractions(path string) (pending []PendingExtraction, err error) {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 	"github.com/boshu2/agentops/cli/internal/ratchet"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
@@ -71,7 +73,12 @@ func init() {
 
 // loadSessionCitations loads and filters citations for a session.
 func loadSessionCitations(cwd, sessionID, citationType string) ([]types.CitationEvent, error) {
-	allCitations, err := ratchet.LoadCitations(cwd)
+	opts, closeStore, err := citationOptions(cwd)
+	if err != nil {
+		return nil, err
+	}
+	defer closeStore()
+	allCitations, err := ratchet.LoadCitations(cwd, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("load citations: %w", err)
 	}
@@ -232,64 +239,74 @@ func resolveFeedbackLoopSessionID(sessionFlag string) (string, error) {
 }
 
 func markCitationFeedback(baseDir, sessionID string, reward float64, events []FeedbackEvent) error {
-	citations, err := ratchet.LoadCitations(baseDir)
-	if err != nil {
-		return fmt.Errorf("load citations for feedback mark: %w", err)
-	}
-	if len(citations) == 0 {
-		return nil
-	}
+	// Hold the citations lock across load and rewrite so citations recorded
+	// concurrently by other sessions are not lost
+	citationsPath := filepath.Join(baseDir, ratchet.CitationsFilePath)
+	return fsutil.WithLock(citationsPath, func() error {
+		opts, closeStore, err := citationOptions(baseDir)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		citations, err := ratchet.LoadCitations(baseDir, opts...)
+		if err != nil {
+			return fmt.Errorf("load citations for feedback mark: %w", err)
+		}
+		if len(citations) == 0 {
+			return nil
+		}
 
-	eventByPath := make(map[string]FeedbackEvent, len(events))
-	for _, event := range events {
-		eventByPath[event.ArtifactPath] = event
-	}
+		eventByPath := make(map[string]FeedbackEvent, len(events))
+		for _, event := range events {
+			eventByPath[event.ArtifactPath] = event
+		}
 
-	updated := 0
-	now := time.Now()
-	for i := range citations {
-		if citations[i].SessionID != sessionID {
-			continue
+		updated := 0
+		now := time.Now()
+		for i := range citations {
+			if citations[i].SessionID != sessionID {
+				continue
+			}
+			citations[i].FeedbackGiven = true
+			citations[i].FeedbackReward = reward
+			citations[i].FeedbackAt = now
+			if event, ok := eventByPath[citations[i].ArtifactPath]; ok {
+				citations[i].UtilityBefore = event.UtilityBefore
+				citations[i].UtilityAfter = event.UtilityAfter
+			}
+			updated++
 		}
-		citations[i].FeedbackGiven = true
-		citations[i].FeedbackReward = reward
-		citations[i].FeedbackAt = now
-		if event, ok := eventByPath[citations[i].ArtifactPath]; ok {
-			citations[i].UtilityBefore = event.UtilityBefore
-			citations[i].UtilityAfter = event.UtilityAfter
+		if updated == 0 {
+			return nil
 		}
-		updated++
-	}
-	if updated == 0 {
-		return nil
-	}
 
-	return writeCitations(baseDir, citations)
+		return writeCitations(baseDir, citations)
+	})
 }
 
+// writeCitations replaces the citation log. Callers hold the citations
+// lock (see markCitationFeedback).
 func writeCitations(baseDir string, citations []types.CitationEvent) error {
-	citationsPath := filepath.Join(baseDir, ratchet.CitationsFilePath)
-	if err := os.MkdirAll(filepath.Dir(citationsPath), 0755); err != nil {
-		return fmt.Errorf("create citations directory: %w", err)
-	}
-
-	tmpPath := citationsPath + ".tmp"
-	f, err := os.Create(tmpPath)
+	store, err := openCitationStore(baseDir)
 	if err != nil {
-		return fmt.Errorf("create citations temp file: %w", err)
+		return err
+	}
+	if store != nil {
+		defer func() {
+			_ = store.Close() //nolint:errcheck // write already committed, close best-effort
+		}()
+		return store.ReplaceCitations(citations)
 	}
 
-	enc := json.NewEncoder(f)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
 	for _, citation := range citations {
 		if err := enc.Encode(citation); err != nil {
-			_ = f.Close()
 			return fmt.Errorf("write citation event: %w", err)
 		}
 	}
-	if err := f.Close(); err != nil {
-		return fmt.Errorf("close citations temp file: %w", err)
-	}
-	if err := os.Rename(tmpPath, citationsPath); err != nil {
+	citationsPath := filepath.Join(baseDir, ratchet.CitationsFilePath)
+	if err := fsutil.WriteFileAtomic(citationsPath, buf.Bytes(), 0600); err != nil {
 		return fmt.Errorf("replace citations file: %w", err)
 	}
 	return nil
@@ -335,27 +352,13 @@ func writeFeedbackEvents(baseDir string, events []FeedbackEvent) error {
 
 	feedbackPath := filepath.Join(baseDir, FeedbackFilePath)
 
-	// Create parent directory
-	if err := os.MkdirAll(filepath.Dir(feedbackPath), 0755); err != nil {
-		return fmt.Errorf("create feedback directory: %w", err)
-	}
-
-	// Open for append
-	f, err := os.OpenFile(feedbackPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("open feedback file: %w", err)
+	// Locked, fsynced append of all events at once
+	values := make([]interface{}, len(events))
+	for i := range events {
+		values[i] = events[i]
 	}
-	defer f.Close() //nolint:errcheck // write-only file, Close error non-actionable
-
-	// Write each event as JSONL
-	for _, event := range events {
-		data, err := json.Marshal(event)
-		if err != nil {
-			continue
-		}
-		if _, err := f.Write(append(data, '\n')); err != nil {
-			return fmt.Errorf("write feedback event: %w", err)
-		}
+	if err := fsutil.AppendJSONL(feedbackPath, 0644, values...); err != nil {
+		return fmt.Errorf("write feedback event: %w", err)
 	}
 
 	return nil
@@ -391,7 +394,12 @@ func runBatchFeedback(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load all citations
-	citations, err := ratchet.LoadCitations(cwd)
+	opts, closeStore, err := citationOptions(cwd)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	citations, err := ratchet.LoadCitations(cwd, opts...)
 	if err != nil {
 		return fmt.Errorf("load citations: %w", err)
 	}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"time"
 )
@@ -432,27 +431,7 @@ func sendMail(to, body, msgType string) error {
 		Timestamp: time.Now(),
 	}
 
-	mailDir := filepath.Join(cwd, ".agents", "mail")
-	if err := os.MkdirAll(mailDir, 0700); err != nil {
-		return err
-	}
-
-	messagesPath := filepath.Join(mailDir, "messages.jsonl")
-	f, err := os.OpenFile(messagesPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // write already flushed, close best-effort
-	}()
-
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	_, err = f.WriteString(string(data) + "\n")
-	return err
+	return appendMessage(cwd, &msg)
 }
 
 // =============================================================================
@@ -1,18 +1,17 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/boshu2/agentops/cli/internal/pool"
 	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -143,6 +142,10 @@ func ingestPendingFilesToPool(cwd string, files []string) (poolIngestResult, err
 	if len(files) == 0 {
 		return res, nil
 	}
+	tax, err := loadTaxonomy(cwd)
+	if err != nil {
+		return res, err
+	}
 
 	for _, f := range files {
 		data, rerr := os.ReadFile(f)
@@ -154,7 +157,7 @@ func ingestPendingFilesToPool(cwd string, files []string) (poolIngestResult, err
 		blocks := parseLearningBlocks(string(data))
 		res.CandidatesFound += len(blocks)
 		for _, b := range blocks {
-			cand, scoring, ok := buildCandidateFromLearningBlock(b, f, fileDate, sessionHint)
+			cand, scoring, ok := buildCandidateFromLearningBlock(tax, b, f, fileDate, sessionHint)
 			if !ok {
 				res.SkippedMalformed++
 				continue
@@ -278,26 +281,19 @@ func promoteAntiPatternsForCloseLoop(cwd string) (eligible int, promoted int, ch
 // It returns how many paths were (re)indexed and the index path.
 func storeIndexUpsert(baseDir string, paths []string, categorize bool) (int, string, error) {
 	indexPath := filepath.Join(baseDir, IndexDir, IndexFileName)
+	manifestPath := filepath.Join(baseDir, IndexDir, IndexManifestFileName)
 	if len(paths) == 0 {
 		return 0, indexPath, nil
 	}
 
-	// Load existing entries (best-effort).
-	existing := make(map[string]IndexEntry)
-	f, err := os.Open(indexPath)
-	if err == nil {
-		scanner := bufio.NewScanner(f)
-		scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
-		for scanner.Scan() {
-			var e IndexEntry
-			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
-				continue
-			}
-			if e.Path != "" {
-				existing[e.Path] = e
-			}
-		}
-		_ = f.Close() //nolint:errcheck // best-effort
+	// Load existing entries and manifest (best-effort).
+	existing, err := loadStoreIndex(indexPath)
+	if err != nil {
+		existing = make(map[string]IndexEntry)
+	}
+	manifest, err := search.LoadManifest(manifestPath)
+	if err != nil {
+		manifest = search.NewManifest()
 	}
 
 	// Upsert requested paths.
@@ -307,7 +303,8 @@ func storeIndexUpsert(baseDir string, paths []string, categorize bool) (int, str
 			continue
 		}
 		// Only index paths that exist.
-		if _, err := os.Stat(p); err != nil {
+		state, _, err := manifest.Check(p)
+		if err != nil {
 			continue
 		}
 		entry, err := createIndexEntry(p, categorize)
@@ -315,6 +312,7 @@ func storeIndexUpsert(baseDir string, paths []string, categorize bool) (int, str
 			continue
 		}
 		existing[p] = *entry
+		manifest.Record(p, state)
 		indexed++
 	}
 
@@ -323,31 +321,12 @@ func storeIndexUpsert(baseDir string, paths []string, categorize bool) (int, str
 	}
 
 	// Rewrite index deterministically.
-	indexDir := filepath.Dir(indexPath)
-	if err := os.MkdirAll(indexDir, 0755); err != nil {
+	if err := writeStoreIndex(indexPath, existing); err != nil {
 		return indexed, indexPath, err
 	}
-	out, err := os.OpenFile(indexPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
-	if err != nil {
+	if err := search.SaveManifest(manifest, manifestPath); err != nil {
 		return indexed, indexPath, err
 	}
-	defer func() {
-		_ = out.Close() //nolint:errcheck // write completed
-	}()
-
-	pathsSorted := make([]string, 0, len(existing))
-	for p := range existing {
-		pathsSorted = append(pathsSorted, p)
-	}
-	sort.Strings(pathsSorted)
-
-	enc := json.NewEncoder(out)
-	for _, p := range pathsSorted {
-		e := existing[p]
-		if err := enc.Encode(e); err != nil {
-			return indexed, indexPath, err
-		}
-	}
 
 	return indexed, indexPath, nil
 }
@@ -2,7 +2,7 @@ package main
 
 import (
 	"crypto/sha256"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -15,6 +15,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/boshu2/agentops/cli/internal/formatter"
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 	"github.com/boshu2/agentops/cli/internal/parser"
 	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/storage"
@@ -27,6 +28,9 @@ var (
 	forgeQueue       bool
 	forgeMdQuiet     bool
 	forgeMdQueue     bool
+	forgeFormat      string
+	forgeWorkers     int
+	forgeRestart     bool
 )
 
 const (
@@ -50,7 +54,7 @@ var forgeCmd = &cobra.Command{
 	Long: `The forge command extracts knowledge candidates from various sources.
 
 Currently supported forges:
-  transcript    Extract from Claude Code JSONL transcripts
+  transcript    Extract from agent transcripts (Claude Code, Codex, Aider, OpenAI chat)
   markdown      Extract from markdown files (.md)
 
 Example:
@@ -60,8 +64,19 @@ Example:
 
 var forgeTranscriptCmd = &cobra.Command{
 	Use:   "transcript <path-or-glob>",
-	Short: "Extract knowledge from Claude Code transcripts",
-	Long: `Parse Claude Code JSONL transcript files and extract knowledge candidates.
+	Short: "Extract knowledge from agent transcripts",
+	Long: `Parse coding agent transcripts and extract knowledge candidates.
+
+Supported formats (--format, detected from the file by default):
+  claude    Claude Code JSONL (~/.claude/projects/**/*.jsonl)
+  codex     Codex CLI rollout JSONL (~/.codex/sessions/**/rollout-*.jsonl)
+  aider     Aider chat history markdown (.aider.chat.history.md)
+  openai    OpenAI-style chat JSON ({"messages": [...]} or a message array)
+
+Transcripts are streamed in bounded memory and processed in parallel
+(--workers). Progress through large JSONL transcripts is checkpointed under
+.agents/ao/forge/checkpoints, so an interrupted forge resumes where it
+stopped; --restart discards the checkpoints.
 
 The transcript forge identifies:
   - Decisions: Architectural choices with rationale
@@ -75,7 +90,10 @@ Examples:
   ao forge transcript ~/.claude/projects/**/*.jsonl
   ao forge transcript /path/to/*.jsonl --output candidates.json
   ao forge transcript --last-session              # Process most recent transcript
-  ao forge transcript --last-session --quiet      # Silent mode for hooks`,
+  ao forge transcript --last-session --quiet      # Silent mode for hooks
+  ao forge transcript ~/.claude/projects/**/*.jsonl --workers 4
+  ao forge transcript ~/.codex/sessions/2026/03/02/rollout-*.jsonl
+  ao forge transcript .aider.chat.history.md --format aider`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		lastSession, _ := cmd.Flags().GetBool("last-session")
 		if !lastSession && len(args) < 1 {
@@ -112,6 +130,9 @@ func init() {
 	forgeTranscriptCmd.Flags().BoolVar(&forgeLastSession, "last-session", false, "Process only the most recent transcript")
 	forgeTranscriptCmd.Flags().BoolVar(&forgeQuiet, "quiet", false, "Suppress all output (for hooks)")
 	forgeTranscriptCmd.Flags().BoolVar(&forgeQueue, "queue", false, "Queue session for learning extraction at next session start")
+	forgeTranscriptCmd.Flags().IntVar(&forgeWorkers, "workers", 0, "Transcripts to process in parallel (0 = number of CPUs)")
+	forgeTranscriptCmd.Flags().BoolVar(&forgeRestart, "restart", false, "Discard saved checkpoints and reprocess transcripts from the start")
+	forgeTranscriptCmd.Flags().StringVar(&forgeFormat, "format", parser.FormatAuto, "Transcript format: auto, "+strings.Join(parser.FormatNames(), ", "))
 
 	// Markdown flags
 	forgeMarkdownCmd.Flags().BoolVar(&forgeMdQuiet, "quiet", false, "Suppress all output (for hooks)")
@@ -176,13 +197,16 @@ func runForgeTranscript(cmd *cobra.Command, args []string) error {
 	}
 
 	baseDir := filepath.Join(cwd, storage.DefaultBaseDir)
-	fs := storage.NewFileStorage(
-		storage.WithBaseDir(baseDir),
-		storage.WithFormatters(
-			formatter.NewMarkdownFormatter(),
-			formatter.NewJSONLFormatter(),
-		),
+	fs, err := openStorage(baseDir,
+		formatter.NewMarkdownFormatter(),
+		formatter.NewJSONLFormatter(),
 	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fs.Close() //nolint:errcheck // writes already synced, close best-effort
+	}()
 
 	// Ensure directories exist
 	if err := fs.Init(); err != nil {
@@ -192,81 +216,58 @@ func runForgeTranscript(cmd *cobra.Command, args []string) error {
 	// Create parser with no truncation for full content extraction
 	p := parser.NewParser()
 	p.MaxContentLength = 0 // No truncation
+	if p.Format, err = transcriptFormat(forgeFormat); err != nil {
+		return err
+	}
 
 	// Create extractor for knowledge identification
-	extractor := parser.NewExtractor()
+	extractor, err := loadForgeExtractor(cwd)
+	if err != nil {
+		return err
+	}
+
+	// Stream files in parallel; sessions are written one at a time
+	pipeline := &forgePipeline{
+		fs:        fs,
+		baseDir:   baseDir,
+		parser:    p,
+		extractor: extractor,
+		workers:   forgeWorkers,
+		opts: forgeOptions{
+			checkpointDir: forgeCheckpointDir(baseDir),
+			restart:       forgeRestart,
+			progress:      !forgeQuiet && (len(files) == 1 || forgeWorkers == 1),
+			quiet:         forgeQuiet,
+			w:             w,
+		},
+	}
 
-	// Process each file
 	totalSessions := 0
 	totalDecisions := 0
 	totalKnowledge := 0
 
-	for _, filePath := range files {
-		session, err := processTranscript(filePath, p, extractor, forgeQuiet, w)
-		if err != nil {
-			if !forgeQuiet {
-				fmt.Fprintf(os.Stderr, "Warning: failed to process %s: %v\n", filePath, err)
-			}
-			continue
-		}
-
-		// Write session
-		sessionPath, err := fs.WriteSession(session)
-		if err != nil {
-			if !forgeQuiet {
-				fmt.Fprintf(os.Stderr, "Warning: failed to write session for %s: %v\n", filePath, err)
-			}
-			continue
-		}
-
-		// Write to index
-		indexEntry := &storage.IndexEntry{
-			SessionID:   session.ID,
-			Date:        session.Date,
-			SessionPath: sessionPath,
-			Summary:     session.Summary,
-		}
-		if err := fs.WriteIndex(indexEntry); err != nil {
-			if !forgeQuiet {
-				fmt.Fprintf(os.Stderr, "Warning: failed to index session: %v\n", err)
-			}
-		}
-
-		// Write provenance
-		provRecord := &storage.ProvenanceRecord{
-			ID:           fmt.Sprintf("prov-%s", session.ID[:7]),
-			ArtifactPath: sessionPath,
-			ArtifactType: "session",
-			SourcePath:   filePath,
-			SourceType:   "transcript",
-			SessionID:    session.ID,
-			CreatedAt:    time.Now(),
-		}
-		if err := fs.WriteProvenance(provRecord); err != nil {
-			if !forgeQuiet {
-				fmt.Fprintf(os.Stderr, "Warning: failed to write provenance: %v\n", err)
-			}
-		}
-
-		// Update search index with the new session file
-		updateSearchIndexForFile(baseDir, sessionPath, forgeQuiet)
-
+	results := pipeline.run(files, func(filePath string, ft forgedTranscript) {
 		totalSessions++
-		totalDecisions += len(session.Decisions)
-		totalKnowledge += len(session.Knowledge)
+		totalDecisions += len(ft.Session.Decisions)
+		totalKnowledge += len(ft.Session.Knowledge)
 
 		if !forgeQuiet {
-			VerbosePrintf("  ✓ %s → %s\n", filepath.Base(filePath), filepath.Base(sessionPath))
+			VerbosePrintf("  ✓ %s → %s\n", filepath.Base(filePath), filepath.Base(ft.SessionPath))
 		}
 
 		// Queue for extraction if requested
 		if forgeQueue {
-			if err := queueForExtraction(session, sessionPath, filePath, cwd); err != nil {
+			if err := queueForExtraction(ft.Session, ft.SessionPath, filePath, cwd); err != nil {
 				if !forgeQuiet {
 					fmt.Fprintf(os.Stderr, "Warning: failed to queue for extraction: %v\n", err)
 				}
 			}
 		}
+	})
+	for i, r := range results {
+		if r.Err != nil && !forgeQuiet {
+			fmt.Fprintf(os.Stderr, "Warning: failed to process %s: %v\n", files[i], r.Err)
+		}
 	}
 
 	if !forgeQuiet {
@@ -279,90 +280,85 @@ func runForgeTranscript(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// processTranscript parses a transcript and extracts session data.
-func processTranscript(filePath string, p *parser.Parser, extractor *parser.Extractor, quiet bool, w io.Writer) (session *storage.Session, err error) {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("open file: %w", err)
-	}
-	defer func() {
-		if cerr := f.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
+// processTranscript parses a transcript and extracts session data, without
+// checkpoints.
+func processTranscript(filePath string, p *parser.Parser, extractor *parser.Extractor, quiet bool, w io.Writer) (*storage.Session, error) {
+	return streamTranscript(filePath, p, extractor, forgeOptions{progress: !quiet, quiet: quiet, w: w})
+}
 
-	info, err := f.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("stat file: %w", err)
+// transcriptFormat resolves a --format flag value; nil means detect the
+// format of each file.
+func transcriptFormat(name string) (parser.TranscriptFormat, error) {
+	if name == "" || name == parser.FormatAuto {
+		return nil, nil
 	}
-	fileSize := info.Size()
-	totalLines := countLines(filePath)
+	return parser.FormatByName(name)
+}
 
-	if _, err := f.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("seek file: %w", err)
-	}
+// transcriptState holds accumulated state during transcript processing.
+// Every list is deduplicated as it grows and capped (ForgeMaxItems,
+// ForgeMaxRefs), so its size does not depend on the transcript's.
+type transcriptState struct {
+	decisions    []string
+	knowledge    []string
+	filesChanged []string
+	issues       []string
+	seenItems    map[string]bool
+	seenFiles    map[string]bool
+	seenIssues   map[string]bool
+}
 
-	msgCh, errCh := p.ParseChannel(f)
-	session = initSession(filePath)
-	state := &transcriptState{
+// newTranscriptState returns an empty transcriptState.
+func newTranscriptState() *transcriptState {
+	return &transcriptState{
+		seenItems:  make(map[string]bool),
 		seenFiles:  make(map[string]bool),
 		seenIssues: make(map[string]bool),
 	}
+}
 
-	lineCount := 0
-	lastProgress := 0
-
-	for msg := range msgCh {
-		lineCount++
-
-		// Progress output every 1000 lines
-		if !quiet && lineCount-lastProgress >= 1000 {
-			pct := 0
-			if totalLines > 0 {
-				pct = lineCount * 100 / totalLines
-			}
-			fmt.Fprintf(w, "\r[forge] Processing... %d/%d (%d%%)  ", lineCount, totalLines, pct)
-			lastProgress = lineCount
-		}
-
-		updateSessionMeta(session, msg)
-		extractMessageKnowledge(msg, extractor, state)
-		extractMessageRefs(msg, session, state)
+// restore reloads the lists saved in a checkpointed session.
+func (s *transcriptState) restore(session *storage.Session) {
+	for _, d := range session.Decisions {
+		s.decisions = s.addItem(s.decisions, types.KnowledgeTypeDecision, d)
 	}
-
-	if !quiet {
-		fmt.Fprintf(w, "\r%s\r", "                                                    ")
+	for _, k := range session.Knowledge {
+		s.knowledge = s.addItem(s.knowledge, types.KnowledgeTypeLearning, k)
 	}
-
-	select {
-	case err := <-errCh:
-		if err != nil {
-			return nil, err
-		}
-	default:
+	for _, f := range session.FilesChanged {
+		s.addFile(f)
 	}
+	for _, id := range session.Issues {
+		s.addIssue(id)
+	}
+}
 
-	session.Summary = generateSummary(state.decisions, state.knowledge, session.Date)
-	session.Decisions = dedup(state.decisions)
-	session.Knowledge = dedup(state.knowledge)
-	session.FilesChanged = state.filesChanged
-	session.Issues = state.issues
-	session.Tokens = storage.TokenUsage{
-		Total:     int(fileSize / CharsPerToken),
-		Estimated: true,
+// addItem appends text to items unless it is already there or items is full.
+func (s *transcriptState) addItem(items []string, kind types.KnowledgeType, text string) []string {
+	key := string(kind) + "\x00" + text
+	if len(items) >= ForgeMaxItems || s.seenItems[key] {
+		return items
 	}
+	s.seenItems[key] = true
+	return append(items, text)
+}
 
-	return session, nil
+// addFile records a changed file path.
+func (s *transcriptState) addFile(path string) {
+	if len(s.filesChanged) >= ForgeMaxRefs || s.seenFiles[path] {
+		return
+	}
+	s.filesChanged = append(s.filesChanged, path)
+	s.seenFiles[path] = true
 }
 
-// transcriptState holds accumulated state during transcript processing.
-type transcriptState struct {
-	decisions    []string
-	knowledge    []string
-	filesChanged []string
-	issues       []string
-	seenFiles    map[string]bool
-	seenIssues   map[string]bool
+// addIssue records a referenced issue ID.
+func (s *transcriptState) addIssue(id string) {
+	if len(s.issues) >= ForgeMaxRefs || s.seenIssues[id] {
+		return
+	}
+	s.issues = append(s.issues, id)
+	s.seenIssues[id] = true
 }
 
 // initSession creates a new session with default values.
@@ -393,9 +389,9 @@ func extractMessageKnowledge(msg types.TranscriptMessage, extractor *parser.Extr
 		text := extractSnippet(msg.Content, result.StartIndex, SnippetMaxLength)
 		switch result.Type {
 		case types.KnowledgeTypeDecision:
-			state.decisions = append(state.decisions, text)
+			state.decisions = state.addItem(state.decisions, types.KnowledgeTypeDecision, text)
 		case types.KnowledgeTypeSolution, types.KnowledgeTypeLearning:
-			state.knowledge = append(state.knowledge, text)
+			state.knowledge = state.addItem(state.knowledge, types.KnowledgeTypeLearning, text)
 		}
 	}
 }
@@ -421,13 +417,11 @@ func extractFilePathsFromTool(tool types.ToolCall, state *transcriptState) {
 	if tool.Input == nil {
 		return
 	}
-	if fp, ok := tool.Input["file_path"].(string); ok && !state.seenFiles[fp] {
-		state.filesChanged = append(state.filesChanged, fp)
-		state.seenFiles[fp] = true
+	if fp, ok := tool.Input["file_path"].(string); ok {
+		state.addFile(fp)
 	}
-	if fp, ok := tool.Input["path"].(string); ok && !state.seenFiles[fp] {
-		state.filesChanged = append(state.filesChanged, fp)
-		state.seenFiles[fp] = true
+	if fp, ok := tool.Input["path"].(string); ok {
+		state.addFile(fp)
 	}
 }
 
@@ -435,10 +429,7 @@ func extractFilePathsFromTool(tool types.ToolCall, state *transcriptState) {
 func extractIssueRefs(content string, state *transcriptState) {
 	ids := extractIssueIDs(content)
 	for _, id := range ids {
-		if !state.seenIssues[id] {
-			state.issues = append(state.issues, id)
-			state.seenIssues[id] = true
-		}
+		state.addIssue(id)
 	}
 }
 
@@ -453,36 +444,6 @@ func generateSummary(decisions, knowledge []string, date time.Time) string {
 	return fmt.Sprintf("Session from %s", date.Format("2006-01-02"))
 }
 
-// countLines quickly counts lines in a file.
-func countLines(path string) int {
-	f, err := os.Open(path)
-	if err != nil {
-		return 0
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // read-only line count, close error non-fatal
-	}()
-
-	buf := make([]byte, 64*1024)
-	count := 0
-
-	for {
-		n, err := f.Read(buf)
-		if n > 0 {
-			for _, b := range buf[:n] {
-				if b == '\n' {
-					count++
-				}
-			}
-		}
-		if err != nil {
-			break
-		}
-	}
-
-	return count
-}
-
 // extractSnippet extracts a text snippet around a match.
 func extractSnippet(content string, startIdx, maxLen int) string {
 	if startIdx < 0 {
@@ -577,20 +538,7 @@ func queueForExtraction(session *storage.Session, sessionPath, transcriptPath, c
 		QueuedAt:       time.Now(),
 	}
 
-	data, err := json.Marshal(pending)
-	if err != nil {
-		return fmt.Errorf("marshal pending: %w", err)
-	}
-
-	f, err := os.OpenFile(pendingPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("open pending file: %w", err)
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // write complete, close best-effort
-	}()
-
-	if _, err := f.Write(append(data, '\n')); err != nil {
+	if err := fsutil.AppendJSONL(pendingPath, 0644, pending); err != nil {
 		return fmt.Errorf("write pending: %w", err)
 	}
 
@@ -645,19 +593,25 @@ func runForgeMarkdown(cmd *cobra.Command, args []string) error {
 	}
 
 	baseDir := filepath.Join(cwd, storage.DefaultBaseDir)
-	fs := storage.NewFileStorage(
-		storage.WithBaseDir(baseDir),
-		storage.WithFormatters(
-			formatter.NewMarkdownFormatter(),
-			formatter.NewJSONLFormatter(),
-		),
+	fs, err := openStorage(baseDir,
+		formatter.NewMarkdownFormatter(),
+		formatter.NewJSONLFormatter(),
 	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fs.Close() //nolint:errcheck // writes already synced, close best-effort
+	}()
 
 	if err := fs.Init(); err != nil {
 		return fmt.Errorf("initialize storage: %w", err)
 	}
 
-	extractor := parser.NewExtractor()
+	extractor, err := loadForgeExtractor(cwd)
+	if err != nil {
+		return err
+	}
 
 	totalSessions := 0
 	totalDecisions := 0
@@ -771,10 +725,7 @@ func processMarkdown(filePath string, extractor *parser.Extractor, quiet bool) (
 	// Split by headings (## or #) into sections
 	sections := splitMarkdownSections(content)
 
-	state := &transcriptState{
-		seenFiles:  make(map[string]bool),
-		seenIssues: make(map[string]bool),
-	}
+	state := newTranscriptState()
 
 	for i, section := range sections {
 		if len(section) == 0 {
@@ -829,15 +780,21 @@ func splitMarkdownSections(content string) []string {
 }
 
 // updateSearchIndexForFile loads the search index (if it exists), updates the
-// entry for the given file path, and saves it back. If no index exists yet
-// this is a no-op -- the user can create one with `ao search --rebuild-index`.
+// entry for the given file path, and saves it back along with the file's
+// manifest entry, so the next search can use the index as is. If no index
+// exists yet this is a no-op. An index written in an older format is rebuilt
+// from the sessions directory.
 func updateSearchIndexForFile(baseDir, filePath string, quiet bool) {
-	idxPath := filepath.Join(baseDir, "index.jsonl")
+	idxPath := filepath.Join(baseDir, SearchIndexFileName)
 	if _, err := os.Stat(idxPath); os.IsNotExist(err) {
 		return // no index yet -- nothing to update
 	}
 
 	idx, err := search.LoadIndex(idxPath)
+	if errors.Is(err, search.ErrIndexVersion) {
+		VerbosePrintf("Rebuilding search index: %v\n", err)
+		idx, err = search.BuildIndex(filepath.Join(baseDir, storage.SessionsDir))
+	}
 	if err != nil {
 		if !quiet {
 			fmt.Fprintf(os.Stderr, "Warning: failed to load search index: %v\n", err)
@@ -856,6 +813,19 @@ func updateSearchIndexForFile(baseDir, filePath string, quiet bool) {
 		if !quiet {
 			fmt.Fprintf(os.Stderr, "Warning: failed to save search index: %v\n", err)
 		}
+		return
+	}
+
+	// A manifest that cannot be read or updated only costs the next search
+	// a rebuild.
+	manifestPath := filepath.Join(baseDir, SearchManifestFileName)
+	manifest, err := search.LoadManifest(manifestPath)
+	if err != nil {
+		return
+	}
+	if state, _, err := manifest.Check(filePath); err == nil {
+		manifest.Record(filePath, state)
+		_ = search.SaveManifest(manifest, manifestPath) //nolint:errcheck // see above
 	}
 }
 
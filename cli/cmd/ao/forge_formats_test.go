@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/parser"
+)
+
+const transcriptFixtures = "../../testdata/transcripts"
+
+func TestProcessTranscriptFormats(t *testing.T) {
+	tests := []struct {
+		file      string
+		sessionID string
+		files     []string
+		issue     string
+	}{
+		{"codex-rollout.jsonl", "0199a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b", []string{"auth/session.go"}, "ag-x1y2"},
+		{"sample.aider.chat.history.md", "aider-20260303-140510", []string{"cache.go", "cache_test.go"}, "ag-c4ch"},
+		{"openai-chat.json", "chatcmpl-9xYz", []string{"config/load.go"}, ""},
+	}
+
+	p := parser.NewParser()
+	p.MaxContentLength = 0
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			session, err := processTranscript(filepath.Join(transcriptFixtures, tt.file), p, parser.NewExtractor(), true, io.Discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if session.ID != tt.sessionID {
+				t.Errorf("session ID = %q, want %q", session.ID, tt.sessionID)
+			}
+			if strings.Join(session.FilesChanged, ",") != strings.Join(tt.files, ",") {
+				t.Errorf("files changed = %v, want %v", session.FilesChanged, tt.files)
+			}
+			if tt.issue != "" && strings.Join(session.Issues, ",") != tt.issue {
+				t.Errorf("issues = %v, want %s", session.Issues, tt.issue)
+			}
+			if len(session.Decisions)+len(session.Knowledge) == 0 {
+				t.Error("expected extracted decisions or knowledge")
+			}
+		})
+	}
+	if p.Format != nil {
+		t.Error("processTranscript should not pin the detected format on the shared parser")
+	}
+}
+
+func TestProcessTranscriptDerivesSessionID(t *testing.T) {
+	p := parser.NewParser()
+	path := filepath.Join(t.TempDir(), "chat.json")
+	if err := os.WriteFile(path, []byte(`[{"role":"user","content":"hi"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := processTranscript(path, p, parser.NewExtractor(), true, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(session.ID, "openai-") || len(session.ID) != len("openai-")+12 {
+		t.Errorf("session ID = %q, want openai-<hash>", session.ID)
+	}
+}
+
+func TestExtractTaskEventsCodexPlan(t *testing.T) {
+	tasks, err := extractTaskEvents(filepath.Join(transcriptFixtures, "codex-rollout.jsonl"), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2: %+v", len(tasks), tasks)
+	}
+	if tasks[0].Subject != "Reproduce flaky login test" || tasks[1].Subject != "Fix token refresh race" {
+		t.Errorf("subjects = %q, %q", tasks[0].Subject, tasks[1].Subject)
+	}
+	for _, task := range tasks {
+		if task.Status != "completed" || task.SessionID != "0199a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b" {
+			t.Errorf("task = %+v", task)
+		}
+	}
+	if tasks[0].TaskID == tasks[1].TaskID {
+		t.Error("plan steps need distinct task IDs")
+	}
+
+	filtered, err := extractTaskEvents(filepath.Join(transcriptFixtures, "codex-rollout.jsonl"), "other", nil)
+	if err != nil || len(filtered) != 0 {
+		t.Errorf("session filter: got %d tasks, err %v", len(filtered), err)
+	}
+}
+
+func TestExtractTaskEventsClaude(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude.jsonl")
+	line := `{"type":"assistant","sessionId":"s1","uuid":"1","message":{"role":"assistant","content":[{"type":"tool_use","name":"TaskCreate","input":{"subject":"Write docs","description":"Document the formats"}}]}}`
+	if err := os.WriteFile(path, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := extractTaskEvents(path, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Subject != "Write docs" || tasks[0].SessionID != "s1" || tasks[0].Description != "Document the formats" {
+		t.Errorf("tasks = %+v", tasks)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// ExtractionConfigFile is the extraction pattern file under .agents/ao.
+const ExtractionConfigFile = "extraction.yaml"
+
+var (
+	patternsConfig string
+	patternsFormat string
+)
+
+var forgePatternsCmd = &cobra.Command{
+	Use:   "patterns",
+	Short: "Inspect the knowledge extraction patterns",
+	Long: `Forge extracts decisions, solutions, learnings, failures and references
+with keyword and regex patterns. The built-in patterns can be extended or
+replaced with .agents/ao/extraction.yaml:
+
+  version: 1
+  replace: false            # true drops the built-in patterns
+  patterns:
+    - name: root-cause
+      type: failure         # decision, solution, learning, failure, reference
+      regex: ['(?i)root cause:']
+      score: 0.7            # base confidence, 0.0-1.0
+      context: 20           # characters that must follow a match
+    - name: gotcha
+      type: learning
+      keywords: ["gotcha:", "TIL"]
+      score: 0.6
+
+A pattern named like a built-in one (decision, solution, learning, failure,
+reference) replaces it. The file is validated whenever forge loads it.`,
+}
+
+var forgePatternsTestCmd = &cobra.Command{
+	Use:   "test <transcript>",
+	Short: "Show which extraction patterns fire in a transcript",
+	Long: `Run every extraction pattern over a transcript and list each match with
+its message line, pattern, knowledge type and score, followed by a count per
+pattern. Unlike forge, every keyword and regex match is shown, not only the
+best one per type.
+
+Examples:
+  ao forge patterns test session.jsonl
+  ao forge patterns test session.jsonl --config draft-extraction.yaml
+  ao forge patterns test rollout.jsonl -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runForgePatternsTest,
+}
+
+func init() {
+	forgeCmd.AddCommand(forgePatternsCmd)
+	forgePatternsCmd.AddCommand(forgePatternsTestCmd)
+	forgePatternsTestCmd.Flags().StringVar(&patternsConfig, "config", "", "Pattern file (default: .agents/ao/extraction.yaml)")
+	forgePatternsTestCmd.Flags().StringVar(&patternsFormat, "format", parser.FormatAuto, "Transcript format: auto, "+strings.Join(parser.FormatNames(), ", "))
+}
+
+// extractionConfigPath returns the extraction pattern file for cwd.
+func extractionConfigPath(cwd string) string {
+	return filepath.Join(cwd, storage.DefaultBaseDir, ExtractionConfigFile)
+}
+
+// loadForgeExtractor returns an extractor with the built-in patterns merged
+// with .agents/ao/extraction.yaml, if present. An invalid file is an error
+// rather than silently falling back to the defaults.
+func loadForgeExtractor(cwd string) (*parser.Extractor, error) {
+	return loadExtractorFile(extractionConfigPath(cwd), false)
+}
+
+// loadExtractorFile loads patterns from path. A missing file yields the
+// built-in patterns unless required is set.
+func loadExtractorFile(path string, required bool) (*parser.Extractor, error) {
+	patterns, err := parser.LoadPatternFile(path)
+	if os.IsNotExist(err) && !required {
+		return parser.NewExtractor(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load extraction patterns: %w", err)
+	}
+	return &parser.Extractor{Patterns: patterns}, nil
+}
+
+// patternMatch is one pattern firing in a transcript message.
+type patternMatch struct {
+	Line    int                 `json:"line"`
+	Role    string              `json:"role,omitempty"`
+	Pattern string              `json:"pattern"`
+	Type    types.KnowledgeType `json:"type"`
+	Score   float64             `json:"score"`
+	Keyword string              `json:"keyword,omitempty"`
+	Regex   string              `json:"regex,omitempty"`
+	Snippet string              `json:"snippet"`
+}
+
+// patternTestReport is the output of ao forge patterns test.
+type patternTestReport struct {
+	Transcript string         `json:"transcript"`
+	Config     string         `json:"config,omitempty"`
+	Patterns   []string       `json:"patterns"`
+	Messages   int            `json:"messages"`
+	Matches    []patternMatch `json:"matches"`
+	Counts     map[string]int `json:"counts"`
+}
+
+func runForgePatternsTest(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	configPath, required := patternsConfig, true
+	if configPath == "" {
+		configPath, required = extractionConfigPath(cwd), false
+	}
+	extractor, err := loadExtractorFile(configPath, required)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		configPath = ""
+	}
+
+	p := parser.NewParser()
+	p.MaxContentLength = 0
+	if p.Format, err = parser.ResolveFormat(patternsFormat, args[0]); err != nil {
+		return err
+	}
+
+	report, err := testPatterns(args[0], p, extractor)
+	if err != nil {
+		return err
+	}
+	report.Config = configPath
+
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printPatternReport(cmd.OutOrStdout(), report)
+	return nil
+}
+
+// testPatterns streams a transcript and records every pattern match.
+func testPatterns(path string, p *parser.Parser, extractor *parser.Extractor) (report *patternTestReport, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open transcript: %w", err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only, close error non-fatal
+	}()
+
+	report = &patternTestReport{
+		Transcript: path,
+		Matches:    []patternMatch{},
+		Counts:     make(map[string]int),
+	}
+	for _, pattern := range extractor.Patterns {
+		report.Patterns = append(report.Patterns, pattern.Name)
+		report.Counts[pattern.Name] = 0
+	}
+
+	_, err = p.Stream(f, parser.Position{}, func(msg types.TranscriptMessage, _ parser.Position) error {
+		report.Messages++
+		for _, r := range extractor.Explain(msg) {
+			report.Matches = append(report.Matches, patternMatch{
+				Line:    msg.MessageIndex,
+				Role:    msg.Type,
+				Pattern: r.PatternName,
+				Type:    r.Type,
+				Score:   r.Score,
+				Keyword: r.MatchedKeyword,
+				Regex:   r.MatchedPattern,
+				Snippet: extractSnippet(msg.Content, r.StartIndex, SnippetMaxLength),
+			})
+			report.Counts[r.PatternName]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parse transcript: %w", err)
+	}
+	return report, nil
+}
+
+// printPatternReport writes the human-readable pattern test report.
+func printPatternReport(w io.Writer, report *patternTestReport) {
+	source := "built-in"
+	if report.Config != "" {
+		source = report.Config
+	}
+	fmt.Fprintf(w, "Transcript: %s (%d messages)\n", report.Transcript, report.Messages)
+	fmt.Fprintf(w, "Patterns:   %d (%s)\n\n", len(report.Patterns), source)
+
+	for _, m := range report.Matches {
+		trigger := "keyword " + m.Keyword
+		if m.Regex != "" {
+			trigger = "regex " + m.Regex
+		}
+		fmt.Fprintf(w, "line %-6d %-10s %s (%s, %.2f) %s\n", m.Line, m.Role, m.Pattern, m.Type, m.Score, trigger)
+		fmt.Fprintf(w, "    %s\n", strings.ReplaceAll(m.Snippet, "\n", " "))
+	}
+	if len(report.Matches) == 0 {
+		fmt.Fprintln(w, "No patterns matched.")
+	}
+
+	fmt.Fprintf(w, "\nMatches per pattern:\n")
+	for _, name := range report.Patterns {
+		fmt.Fprintf(w, "  %-20s %d\n", name, report.Counts[name])
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/storage"
+)
+
+func TestLoadForgeExtractor(t *testing.T) {
+	cwd := t.TempDir()
+	e, err := loadForgeExtractor(cwd)
+	if err != nil || len(e.Patterns) != len(parser.DefaultPatterns) {
+		t.Fatalf("without a config: %d patterns, %v", len(e.Patterns), err)
+	}
+
+	dir := filepath.Join(cwd, storage.DefaultBaseDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ExtractionConfigFile), []byte("version: 1\npatterns:\n  - name: bad\n    type: learning\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadForgeExtractor(cwd); err == nil || !strings.Contains(err.Error(), "at least one regex or keyword") {
+		t.Errorf("invalid config should fail to load, got %v", err)
+	}
+}
+
+func TestTestPatterns(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "extraction.yaml")
+	if err := os.WriteFile(config, []byte(`version: 1
+patterns:
+  - name: graceful
+    type: learning
+    regex: ['(?i)graceful shutdown']
+    score: 0.6
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	extractor, err := loadExtractorFile(config, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := testPatterns(filepath.Join(transcriptFixtures, "simple-decision.jsonl"), parser.NewParser(), extractor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Messages == 0 || report.Counts["graceful"] == 0 || report.Counts["decision"] == 0 {
+		t.Fatalf("counts = %v over %d messages", report.Counts, report.Messages)
+	}
+	for _, m := range report.Matches {
+		if m.Pattern == "graceful" && (m.Line == 0 || m.Regex == "" || !strings.Contains(strings.ToLower(m.Snippet), "graceful shutdown")) {
+			t.Errorf("match = %+v", m)
+		}
+	}
+
+	if _, err := loadExtractorFile(filepath.Join(t.TempDir(), "missing.yaml"), true); err == nil {
+		t.Error("an explicit --config that does not exist should fail")
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/types"
+	"github.com/boshu2/agentops/cli/internal/worker"
+)
+
+const (
+	// ForgeMaxItems caps the decisions and the knowledge items kept per
+	// session, so forging a transcript of any size uses bounded memory.
+	ForgeMaxItems = 500
+
+	// ForgeMaxRefs caps the changed files and the issue IDs kept per session.
+	ForgeMaxRefs = 2000
+
+	// ForgeCheckpointBytes is how much transcript is consumed between
+	// checkpoints.
+	ForgeCheckpointBytes = 4 * 1024 * 1024
+
+	// forgeProgressBytes is how much transcript is consumed between
+	// progress updates.
+	forgeProgressBytes = 1024 * 1024
+
+	// checkpointHeadBytes is how much of the file start a checkpoint hashes
+	// to notice that a transcript was replaced rather than appended to.
+	checkpointHeadBytes = 4096
+)
+
+// forgeOptions controls how a transcript is streamed.
+type forgeOptions struct {
+	// checkpointDir holds resumable checkpoints; empty disables them.
+	checkpointDir string
+
+	// checkpointBytes is how much transcript is consumed between
+	// checkpoints; zero means ForgeCheckpointBytes.
+	checkpointBytes int64
+
+	// restart discards any saved checkpoint and forges from the start.
+	restart bool
+
+	// progress prints a progress line to w while streaming.
+	progress bool
+
+	quiet bool
+	w     io.Writer
+}
+
+// forgeCheckpoint is the saved progress of a partly forged transcript: the
+// resume position and everything extracted before it.
+type forgeCheckpoint struct {
+	Path      string          `json:"path"`
+	Format    string          `json:"format"`
+	Head      string          `json:"head"`
+	Position  parser.Position `json:"position"`
+	Session   storage.Session `json:"session"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// forgeCheckpointDir returns the checkpoint directory under baseDir.
+func forgeCheckpointDir(baseDir string) string {
+	return filepath.Join(baseDir, "forge", "checkpoints")
+}
+
+// checkpointPath returns the checkpoint file for a transcript.
+func checkpointPath(dir, transcript string) string {
+	if abs, err := filepath.Abs(transcript); err == nil {
+		transcript = abs
+	}
+	sum := sha256.Sum256([]byte(transcript))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// headHash hashes up to checkpointHeadBytes of the first n bytes of f.
+func headHash(f *os.File, n int64) (string, error) {
+	if n > checkpointHeadBytes {
+		n = checkpointHeadBytes
+	}
+	buf := make([]byte, n)
+	if _, err := f.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// loadForgeCheckpoint returns the saved checkpoint for a transcript, or nil
+// if there is none or it no longer matches the file (replaced, truncated or
+// decoded with a different format).
+func loadForgeCheckpoint(dir string, f *os.File, path, format string, size int64) *forgeCheckpoint {
+	data, err := os.ReadFile(checkpointPath(dir, path))
+	if err != nil {
+		return nil
+	}
+	var cp forgeCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil
+	}
+	if cp.Format != format || cp.Position.Offset <= 0 || cp.Position.Offset > size {
+		return nil
+	}
+	if head, err := headHash(f, cp.Position.Offset); err != nil || head != cp.Head {
+		return nil
+	}
+	if cp.Session.ToolCalls == nil {
+		cp.Session.ToolCalls = make(map[string]int)
+	}
+	return &cp
+}
+
+// saveForgeCheckpoint atomically records progress through pos.
+func saveForgeCheckpoint(dir string, f *os.File, path, format string, pos parser.Position, session *storage.Session, state *transcriptState) error {
+	head, err := headHash(f, pos.Offset)
+	if err != nil {
+		return fmt.Errorf("hash transcript head: %w", err)
+	}
+	cp := forgeCheckpoint{
+		Path:      path,
+		Format:    format,
+		Head:      head,
+		Position:  pos,
+		Session:   *session,
+		UpdatedAt: time.Now(),
+	}
+	cp.Session.Decisions = state.decisions
+	cp.Session.Knowledge = state.knowledge
+	cp.Session.FilesChanged = state.filesChanged
+	cp.Session.Issues = state.issues
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create checkpoint dir: %w", err)
+	}
+	return fsutil.WriteFileAtomic(checkpointPath(dir, path), data, 0644)
+}
+
+// clearForgeCheckpoint removes a transcript's checkpoint once its session is
+// written.
+func clearForgeCheckpoint(dir, path string) {
+	if dir == "" {
+		return
+	}
+	_ = os.Remove(checkpointPath(dir, path)) //nolint:errcheck // best-effort, a stale checkpoint is ignored
+}
+
+// streamTranscript streams one transcript through parse → extract → dedupe
+// in bounded memory. With a checkpoint directory, progress is saved every
+// ForgeCheckpointBytes and a later call resumes from the last checkpoint.
+func streamTranscript(filePath string, p *parser.Parser, extractor *parser.Extractor, opts forgeOptions) (session *storage.Session, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+	fileSize := info.Size()
+
+	if p.Format == nil {
+		format, err := parser.DetectFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("detect format: %w", err)
+		}
+		detected := *p
+		detected.Format = format
+		p = &detected
+	}
+	formatName := p.Format.Name()
+
+	session = initSession(filePath)
+	state := newTranscriptState()
+
+	checkpointing := opts.checkpointDir != "" && p.Resumable()
+	var from parser.Position
+	if checkpointing {
+		if opts.restart {
+			clearForgeCheckpoint(opts.checkpointDir, filePath)
+		} else if cp := loadForgeCheckpoint(opts.checkpointDir, f, filePath, formatName, fileSize); cp != nil {
+			from = cp.Position
+			*session = cp.Session
+			session.TranscriptPath = filePath
+			state.restore(&cp.Session)
+			if !opts.quiet {
+				VerbosePrintf("Resuming %s at byte %d (line %d)\n", filepath.Base(filePath), from.Offset, from.Line)
+			}
+		}
+	}
+	if _, err := f.Seek(from.Offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek file: %w", err)
+	}
+
+	every := opts.checkpointBytes
+	if every <= 0 {
+		every = ForgeCheckpointBytes
+	}
+	lastSaved, lastProgress := from.Offset, from.Offset
+	_, err = p.Stream(f, from, func(msg types.TranscriptMessage, pos parser.Position) error {
+		updateSessionMeta(session, msg)
+		extractMessageKnowledge(msg, extractor, state)
+		extractMessageRefs(msg, session, state)
+
+		if opts.progress && pos.Offset-lastProgress >= forgeProgressBytes {
+			pct := 0
+			if fileSize > 0 {
+				pct = int(pos.Offset * 100 / fileSize)
+			}
+			fmt.Fprintf(opts.w, "\r[forge] Processing... %s/%s (%d%%)  ", humanSize(pos.Offset), humanSize(fileSize), pct)
+			lastProgress = pos.Offset
+		}
+		if checkpointing && pos.Offset-lastSaved >= every {
+			if err := saveForgeCheckpoint(opts.checkpointDir, f, filePath, formatName, pos, session, state); err != nil {
+				return fmt.Errorf("save checkpoint: %w", err)
+			}
+			lastSaved = pos.Offset
+		}
+		return nil
+	})
+
+	if opts.progress {
+		fmt.Fprintf(opts.w, "\r%s\r", "                                                    ")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.ID == "" {
+		// Formats without session IDs get a deterministic one from the path
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(filePath)))
+		session.ID = fmt.Sprintf("%s-%s", formatName, hash[:12])
+	}
+
+	session.Summary = generateSummary(state.decisions, state.knowledge, session.Date)
+	session.Decisions = state.decisions
+	session.Knowledge = state.knowledge
+	session.FilesChanged = state.filesChanged
+	session.Issues = state.issues
+	session.Tokens = storage.TokenUsage{
+		Total:     int(fileSize / CharsPerToken),
+		Estimated: true,
+	}
+
+	return session, nil
+}
+
+// forgedTranscript is the outcome of forging one transcript.
+type forgedTranscript struct {
+	Session     *storage.Session
+	SessionPath string
+}
+
+// forgePipeline forges transcripts in parallel across a worker pool and
+// writes each finished session (session file, index, provenance, search
+// index) one at a time.
+type forgePipeline struct {
+	fs        storage.Storage
+	baseDir   string
+	parser    *parser.Parser
+	extractor *parser.Extractor
+	workers   int
+	opts      forgeOptions
+
+	mu sync.Mutex
+}
+
+// run forges files and returns one result per file, in input order. written
+// is called, serialized, after each session is stored.
+func (fp *forgePipeline) run(files []string, written func(path string, ft forgedTranscript)) []worker.Result[forgedTranscript] {
+	pool := worker.NewPool[forgedTranscript](fp.workers)
+	return pool.Process(files, func(path string) (forgedTranscript, error) {
+		session, err := streamTranscript(path, fp.parser, fp.extractor, fp.opts)
+		if err != nil {
+			return forgedTranscript{}, err
+		}
+
+		fp.mu.Lock()
+		defer fp.mu.Unlock()
+
+		sessionPath, err := fp.write(path, session)
+		if err != nil {
+			return forgedTranscript{}, err
+		}
+		clearForgeCheckpoint(fp.opts.checkpointDir, path)
+
+		ft := forgedTranscript{Session: session, SessionPath: sessionPath}
+		if written != nil {
+			written(path, ft)
+		}
+		return ft, nil
+	})
+}
+
+// sessionProvenanceID returns the provenance record ID for a forged session.
+// It keeps the whole session ID: a truncated prefix panics on short IDs and
+// collides for formats whose IDs share one (every aider ID starts "aider-2").
+func sessionProvenanceID(sessionID string) string {
+	return "prov-" + sessionID
+}
+
+// write stores a forged session with its index entry and provenance.
+func (fp *forgePipeline) write(transcript string, session *storage.Session) (string, error) {
+	sessionPath, err := fp.fs.WriteSession(session)
+	if err != nil {
+		return "", fmt.Errorf("write session: %w", err)
+	}
+
+	indexEntry := &storage.IndexEntry{
+		SessionID:   session.ID,
+		Date:        session.Date,
+		SessionPath: sessionPath,
+		Summary:     session.Summary,
+	}
+	if err := fp.fs.WriteIndex(indexEntry); err != nil && !fp.opts.quiet {
+		fmt.Fprintf(os.Stderr, "Warning: failed to index session: %v\n", err)
+	}
+
+	provRecord := &storage.ProvenanceRecord{
+		ID:           sessionProvenanceID(session.ID),
+		ArtifactPath: sessionPath,
+		ArtifactType: "session",
+		SourcePath:   transcript,
+		SourceType:   "transcript",
+		SessionID:    session.ID,
+		CreatedAt:    time.Now(),
+	}
+	if err := fp.fs.WriteProvenance(provRecord); err != nil && !fp.opts.quiet {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write provenance: %v\n", err)
+	}
+
+	updateSearchIndexForFile(fp.baseDir, sessionPath, fp.opts.quiet)
+	return sessionPath, nil
+}
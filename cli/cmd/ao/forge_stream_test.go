@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/formatter"
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/search"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestStreamTranscriptResumesFromCheckpoint(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(transcriptFixtures, "multi-extract.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) < 4 {
+		t.Fatalf("fixture too short: %d lines", len(lines))
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+	checkpoints := filepath.Join(dir, "checkpoints")
+	p := parser.NewParser()
+	p.MaxContentLength = 0
+	opts := forgeOptions{checkpointDir: checkpoints, checkpointBytes: 1, quiet: true, w: io.Discard}
+
+	full, err := streamTranscript(filepath.Join(transcriptFixtures, "multi-extract.jsonl"), p, parser.NewExtractor(), forgeOptions{quiet: true, w: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A forge that stopped halfway leaves a checkpoint behind.
+	half := strings.Join(lines[:len(lines)/2], "")
+	if err := os.WriteFile(path, []byte(half), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := streamTranscript(path, p, parser.NewExtractor(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(checkpointPath(checkpoints, path)); err != nil {
+		t.Fatalf("expected a checkpoint: %v", err)
+	}
+
+	// The rest of the transcript arrives; the next run picks up at the checkpoint.
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cp := openCheckpoint(t, checkpoints, path); cp == nil || cp.Position.Offset == 0 || cp.Position.Offset > int64(len(half)) {
+		t.Fatalf("checkpoint should match the appended transcript, got %+v", cp)
+	}
+	resumed, err := streamTranscript(path, p, parser.NewExtractor(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.ID != full.ID || !resumed.Date.Equal(full.Date) {
+		t.Errorf("resumed session %s@%v, want %s@%v", resumed.ID, resumed.Date, full.ID, full.Date)
+	}
+	if strings.Join(resumed.Decisions, "|") != strings.Join(full.Decisions, "|") ||
+		strings.Join(resumed.Knowledge, "|") != strings.Join(full.Knowledge, "|") {
+		t.Errorf("resumed extraction differs:\n%q\n%q\nwant\n%q\n%q", resumed.Decisions, resumed.Knowledge, full.Decisions, full.Knowledge)
+	}
+
+	// A replaced transcript does not match the checkpoint and starts over.
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), "{", "{ ", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cp := openCheckpoint(t, checkpoints, path); cp != nil {
+		t.Errorf("checkpoint should not match a replaced file: %+v", cp.Position)
+	}
+}
+
+// TestStreamTranscriptResumeIsDeterministic resumes the same interrupted
+// forge repeatedly; every resume must extract exactly the same items in the
+// same order.
+func TestStreamTranscriptResumeIsDeterministic(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(transcriptFixtures, "multi-extract.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimRight(string(data), "\n"), "\n")
+	half := strings.Join(lines[:len(lines)/2], "")
+
+	resume := func() string {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "session.jsonl")
+		p := parser.NewParser()
+		p.MaxContentLength = 0
+		opts := forgeOptions{checkpointDir: filepath.Join(dir, "checkpoints"), checkpointBytes: 1, quiet: true, w: io.Discard}
+		if err := os.WriteFile(path, []byte(half), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := streamTranscript(path, p, parser.NewExtractor(), opts); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		s, err := streamTranscript(path, p, parser.NewExtractor(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprintf("%q\n%q\n%q", s.Decisions, s.Knowledge, s.FilesChanged)
+	}
+
+	first := resume()
+	for i := 0; i < 20; i++ {
+		if again := resume(); again != first {
+			t.Fatalf("resume %d differs:\n%s\nwant\n%s", i+2, again, first)
+		}
+	}
+}
+
+func openCheckpoint(t *testing.T, dir, path string) *forgeCheckpoint {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck // test cleanup
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loadForgeCheckpoint(dir, f, path, parser.FormatClaude, info.Size())
+}
+
+func TestTranscriptStateIsBounded(t *testing.T) {
+	state := newTranscriptState()
+	for i := 0; i < ForgeMaxItems+50; i++ {
+		text := fmt.Sprintf("decision %d", i)
+		state.decisions = state.addItem(state.decisions, types.KnowledgeTypeDecision, text)
+		state.decisions = state.addItem(state.decisions, types.KnowledgeTypeDecision, text)
+		state.knowledge = state.addItem(state.knowledge, types.KnowledgeTypeLearning, text)
+	}
+	for i := 0; i < ForgeMaxRefs+50; i++ {
+		state.addFile(fmt.Sprintf("f%d.go", i))
+		state.addIssue(fmt.Sprintf("ag-%04d", i))
+	}
+	if len(state.decisions) != ForgeMaxItems || len(state.knowledge) != ForgeMaxItems {
+		t.Errorf("decisions = %d, knowledge = %d, want %d each", len(state.decisions), len(state.knowledge), ForgeMaxItems)
+	}
+	if state.decisions[1] != "decision 1" {
+		t.Errorf("duplicates not dropped: %q", state.decisions[:3])
+	}
+	if len(state.filesChanged) != ForgeMaxRefs || len(state.issues) != ForgeMaxRefs {
+		t.Errorf("files = %d, issues = %d, want %d each", len(state.filesChanged), len(state.issues), ForgeMaxRefs)
+	}
+}
+
+func TestForgePipelineWritesInParallel(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), storage.DefaultBaseDir)
+	fs := storage.NewFileStorage(
+		storage.WithBaseDir(baseDir),
+		storage.WithFormatters(formatter.NewMarkdownFormatter(), formatter.NewJSONLFormatter()),
+	)
+	if err := fs.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []string{
+		filepath.Join(transcriptFixtures, "simple-decision.jsonl"),
+		filepath.Join(transcriptFixtures, "tool-heavy.jsonl"),
+		filepath.Join(transcriptFixtures, "codex-rollout.jsonl"),
+		filepath.Join(transcriptFixtures, "missing.jsonl"),
+	}
+	pipeline := &forgePipeline{
+		fs:        fs,
+		baseDir:   baseDir,
+		parser:    parser.NewParser(),
+		extractor: parser.NewExtractor(),
+		workers:   4,
+		opts:      forgeOptions{checkpointDir: forgeCheckpointDir(baseDir), checkpointBytes: 1, quiet: true, w: io.Discard},
+	}
+
+	written := 0
+	results := pipeline.run(files, func(string, forgedTranscript) { written++ })
+	if written != 3 {
+		t.Errorf("written = %d, want 3", written)
+	}
+	for i, r := range results[:3] {
+		if r.Err != nil || r.Value.SessionPath == "" {
+			t.Errorf("%s: %+v", files[i], r)
+		}
+	}
+	if results[3].Err == nil {
+		t.Error("expected an error for a missing transcript")
+	}
+
+	index, err := os.ReadFile(filepath.Join(baseDir, storage.IndexDir, storage.IndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(index), "\n"); n != 3 {
+		t.Errorf("index has %d entries, want 3", n)
+	}
+	if entries, _ := os.ReadDir(forgeCheckpointDir(baseDir)); len(entries) != 0 {
+		t.Errorf("checkpoints should be cleared after writing, found %d", len(entries))
+	}
+}
+
+func TestForgePipelineProvenanceIDs(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), storage.DefaultBaseDir)
+	fs := storage.NewFileStorage(
+		storage.WithBaseDir(baseDir),
+		storage.WithFormatters(formatter.NewMarkdownFormatter(), formatter.NewJSONLFormatter()),
+	)
+	if err := fs.Init(); err != nil {
+		t.Fatal(err)
+	}
+	pipeline := &forgePipeline{fs: fs, baseDir: baseDir, opts: forgeOptions{quiet: true, w: io.Discard}}
+
+	ids := map[string]string{}
+	for _, sessionID := range []string{"s1", "aider-20260101-090000", "aider-20260101-170000"} {
+		session := &storage.Session{ID: sessionID, Summary: "chat " + sessionID, Date: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+		path, err := pipeline.write(sessionID+".jsonl", session)
+		if err != nil {
+			t.Fatalf("write %s: %v", sessionID, err)
+		}
+		records, err := fs.QueryProvenance(path)
+		if err != nil || len(records) != 1 {
+			t.Fatalf("provenance for %s: %v %+v", sessionID, err, records)
+		}
+		if prev, dup := ids[records[0].ID]; dup {
+			t.Errorf("sessions %s and %s share provenance ID %s", prev, sessionID, records[0].ID)
+		}
+		ids[records[0].ID] = sessionID
+	}
+	if _, ok := ids["prov-s1"]; !ok {
+		t.Errorf("short session ID should keep its full provenance ID, got %v", ids)
+	}
+}
+
+func TestUpdateSearchIndexRebuildsLegacyIndex(t *testing.T) {
+	baseDir := t.TempDir()
+	sessionsDir := filepath.Join(baseDir, storage.SessionsDir)
+	if err := os.MkdirAll(sessionsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	older := filepath.Join(sessionsDir, "older.md")
+	newer := filepath.Join(sessionsDir, "newer.md")
+	for path, text := range map[string]string{older: "mutex lock ordering\n", newer: "worktree merge conflict\n"} {
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idxPath := filepath.Join(baseDir, "index.jsonl")
+	if err := os.WriteFile(idxPath, []byte(`{"term":"mutex","paths":["`+older+`"]}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	updateSearchIndexForFile(baseDir, newer, true)
+
+	idx, err := search.LoadIndex(idxPath)
+	if err != nil {
+		t.Fatalf("index should be rebuilt in the current format: %v", err)
+	}
+	if !idx.HasDocument(older) || !idx.HasDocument(newer) {
+		t.Errorf("rebuilt index documents = %v, want both sessions", idx.Documents())
+	}
+}
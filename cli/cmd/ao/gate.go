@@ -23,13 +23,15 @@ var (
 var gateCmd = &cobra.Command{
 	Use:   "gate",
 	Short: "Human review gates",
-	Long: `Manage human review gates for bronze-tier candidates.
+	Long: `Manage human review gates for pool candidates.
 
-Bronze-tier candidates (score 0.50-0.69) require human review
+Candidates in tiers with a human gate (bronze by default, see
+tiers.<tier>.human_gate in the taxonomy) require human review
 before promotion. The gate command provides the review interface.
 
 Examples:
   ao gate pending
+  ao gate review
   ao gate approve <candidate-id>
   ao gate reject <candidate-id> --reason="Too vague"`,
 }
@@ -37,7 +39,7 @@ Examples:
 var gatePendingCmd = &cobra.Command{
 	Use:   "pending",
 	Short: "List candidates pending review",
-	Long: `List bronze-tier candidates awaiting human review.
+	Long: `List candidates awaiting human review, in any gated tier.
 
 Shows age/urgency with oldest items first.
 Highlights items approaching 24h auto-promote threshold.
@@ -137,7 +139,7 @@ func outputGatePending(entries []pool.PoolEntry) error {
 var gateApproveCmd = &cobra.Command{
 	Use:   "approve <candidate-id>",
 	Short: "Approve candidate for promotion",
-	Long: `Approve a bronze-tier candidate for promotion.
+	Long: `Approve a candidate awaiting human review for promotion.
 
 Records reviewer identity and triggers promotion flow.
 
@@ -237,6 +239,8 @@ var gateBulkApproveCmd = &cobra.Command{
 
 Silver candidates auto-promote after 24h if not rejected.
 This command accelerates the process for reviewed batches.
+Candidates awaiting the human gate are skipped; review them
+with 'ao gate review' or 'ao gate approve'.
 
 Examples:
   ao gate bulk-approve
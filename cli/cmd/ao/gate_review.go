@@ -0,0 +1,606 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/boshu2/agentops/cli/internal/parser"
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+var gateReviewAll bool
+
+var gateReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review pending candidates interactively",
+	Long: `Open an interactive review of candidates awaiting the human gate.
+
+The list of candidates is on the left; the selected candidate's content,
+rubric scores and an excerpt of its source transcript are on the right.
+Decisions are buffered: nothing is written to the pool until you press w,
+and u undoes the last decision.
+
+Keys:
+  j/k, ↑/↓     move            space   select / unselect
+  g/G          first / last    v       select all / none
+  a            approve         r       reject (prompts for a reason)
+  s            stage           c       clear decision
+  u            undo            w       write decisions and quit
+  q            quit (asks before discarding decisions)
+
+Decisions apply to the selected candidates, or to the one under the
+cursor when none are selected.
+
+Examples:
+  ao gate review
+  ao gate review --all`,
+	RunE: runGateReview,
+}
+
+func init() {
+	gateCmd.AddCommand(gateReviewCmd)
+	gateReviewCmd.Flags().BoolVar(&gateReviewAll, "all", false, "Review every pending candidate, not just those awaiting the gate")
+}
+
+func runGateReview(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	p := pool.NewPool(cwd)
+
+	var entries []pool.PoolEntry
+	if gateReviewAll {
+		entries, err = p.List(pool.ListOptions{Status: types.PoolStatusPending})
+	} else {
+		entries, err = p.ListPendingReview()
+	}
+	if err != nil {
+		return fmt.Errorf("list pending: %w", err)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No pending reviews")
+		return nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("ao gate review needs an interactive terminal; use ao gate pending/approve/reject in scripts")
+	}
+
+	m := newReviewModel(entries)
+	if err := runReviewTerminal(fd, os.Stdin, os.Stdout, m); err != nil {
+		return err
+	}
+	if !m.commit {
+		if len(m.decisions) > 0 {
+			fmt.Printf("Discarded %d decision(s)\n", len(m.decisions))
+		}
+		return nil
+	}
+
+	if GetDryRun() {
+		for _, d := range m.orderedDecisions() {
+			fmt.Printf("[dry-run] Would %s %s\n", d.Action, d.ID)
+		}
+		return nil
+	}
+	applied, errs := applyReviewDecisions(p, m.orderedDecisions(), GetCurrentUser())
+	fmt.Printf("Applied %d decision(s)\n", applied)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "  %v\n", err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d decision(s) failed", len(errs))
+	}
+	return nil
+}
+
+// runReviewTerminal drives m from raw keyboard input until it is done,
+// redrawing on the alternate screen after every key.
+func runReviewTerminal(fd int, in io.Reader, out io.Writer, m *reviewModel) error {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("enter raw mode: %w", err)
+	}
+	defer func() {
+		_ = term.Restore(fd, state) //nolint:errcheck // best-effort terminal restore
+	}()
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 64)
+	for !m.done {
+		if w, h, err := term.GetSize(fd); err == nil {
+			m.width, m.height = w, h
+		}
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+strings.ReplaceAll(m.view(), "\n", "\r\n"))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return fmt.Errorf("read input: %w", err)
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			m.handleKey(k)
+		}
+	}
+	return nil
+}
+
+// parseKeys splits raw terminal input into key names: "up", "down",
+// "enter", "backspace", "esc", "space", or the typed character.
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch {
+		case len(b) >= 3 && b[0] == 0x1b && b[1] == '[':
+			switch b[2] {
+			case 'A':
+				keys = append(keys, "up")
+			case 'B':
+				keys = append(keys, "down")
+			}
+			b = b[3:]
+			continue
+		case b[0] == 0x1b:
+			keys = append(keys, "esc")
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, "enter")
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, "backspace")
+		case b[0] == 0x03:
+			keys = append(keys, "ctrl+c")
+		case b[0] == ' ':
+			keys = append(keys, "space")
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// reviewAction is a buffered gate decision.
+type reviewAction string
+
+const (
+	reviewApprove reviewAction = "approve"
+	reviewReject  reviewAction = "reject"
+	reviewStage   reviewAction = "stage"
+)
+
+// reviewDecision is what will happen to one candidate on commit.
+type reviewDecision struct {
+	ID     string
+	Action reviewAction
+	Reason string
+}
+
+// reviewMode is what keys currently mean.
+type reviewMode int
+
+const (
+	reviewBrowse reviewMode = iota
+	reviewReason
+	reviewConfirmQuit
+)
+
+// reviewModel is the state of the review screen. It never touches the
+// pool: decisions are buffered until commit and applied by the caller.
+type reviewModel struct {
+	entries   []pool.PoolEntry
+	cursor    int
+	selected  map[string]bool
+	decisions map[string]reviewDecision
+
+	// undo holds, per step, the decisions the step overwrote (a zero
+	// Action means the candidate had none).
+	undo [][]reviewDecision
+
+	mode   reviewMode
+	input  string
+	status string
+
+	width, height int
+
+	// excerpt renders the source transcript around a candidate.
+	excerpt  func(types.Source) []string
+	excerpts map[string][]string
+
+	done, commit bool
+}
+
+func newReviewModel(entries []pool.PoolEntry) *reviewModel {
+	return &reviewModel{
+		entries:   entries,
+		selected:  make(map[string]bool),
+		decisions: make(map[string]reviewDecision),
+		width:     100,
+		height:    30,
+		excerpt:   transcriptExcerpt,
+		excerpts:  make(map[string][]string),
+	}
+}
+
+// handleKey applies one key press.
+func (m *reviewModel) handleKey(k string) {
+	m.status = ""
+	switch m.mode {
+	case reviewReason:
+		m.handleReasonKey(k)
+		return
+	case reviewConfirmQuit:
+		if k == "y" || k == "q" {
+			m.done = true
+		} else {
+			m.mode = reviewBrowse
+		}
+		return
+	}
+
+	switch k {
+	case "j", "down":
+		if m.cursor < len(m.entries)-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "g":
+		m.cursor = 0
+	case "G":
+		m.cursor = len(m.entries) - 1
+	case "space":
+		id := m.entries[m.cursor].Candidate.ID
+		if m.selected[id] {
+			delete(m.selected, id)
+		} else {
+			m.selected[id] = true
+		}
+	case "v":
+		if len(m.selected) == len(m.entries) {
+			m.selected = make(map[string]bool)
+		} else {
+			for _, e := range m.entries {
+				m.selected[e.Candidate.ID] = true
+			}
+		}
+	case "a":
+		m.decide(reviewApprove, "")
+	case "s":
+		m.decide(reviewStage, "")
+	case "c":
+		m.decide("", "")
+	case "r":
+		m.mode = reviewReason
+		m.input = ""
+	case "u":
+		m.undoLast()
+	case "w":
+		if len(m.decisions) == 0 {
+			m.status = "No decisions to write"
+			return
+		}
+		m.commit = true
+		m.done = true
+	case "q", "esc", "ctrl+c":
+		if len(m.decisions) > 0 {
+			m.mode = reviewConfirmQuit
+			return
+		}
+		m.done = true
+	}
+}
+
+func (m *reviewModel) handleReasonKey(k string) {
+	switch k {
+	case "enter":
+		reason := strings.TrimSpace(m.input)
+		if reason == "" {
+			m.status = "A rejection needs a reason"
+			return
+		}
+		if len(reason) > pool.MaxReasonLength {
+			m.status = fmt.Sprintf("Reason is longer than %d characters", pool.MaxReasonLength)
+			return
+		}
+		m.mode = reviewBrowse
+		m.decide(reviewReject, reason)
+	case "esc", "ctrl+c":
+		m.mode = reviewBrowse
+	case "backspace":
+		if m.input != "" {
+			_, size := utf8.DecodeLastRuneInString(m.input)
+			m.input = m.input[:len(m.input)-size]
+		}
+	case "space":
+		m.input += " "
+	default:
+		if utf8.RuneCountInString(k) == 1 {
+			m.input += k
+		}
+	}
+}
+
+// targets returns the candidates a decision applies to: the selection,
+// or the candidate under the cursor.
+func (m *reviewModel) targets() []string {
+	if len(m.selected) == 0 {
+		return []string{m.entries[m.cursor].Candidate.ID}
+	}
+	var ids []string
+	for _, e := range m.entries {
+		if m.selected[e.Candidate.ID] {
+			ids = append(ids, e.Candidate.ID)
+		}
+	}
+	return ids
+}
+
+// decide records action for the targets as one undoable step. An empty
+// action clears their decisions.
+func (m *reviewModel) decide(action reviewAction, reason string) {
+	ids := m.targets()
+	step := make([]reviewDecision, 0, len(ids))
+	for _, id := range ids {
+		prev := m.decisions[id]
+		prev.ID = id
+		step = append(step, prev)
+		if action == "" {
+			delete(m.decisions, id)
+		} else {
+			m.decisions[id] = reviewDecision{ID: id, Action: action, Reason: reason}
+		}
+	}
+	m.undo = append(m.undo, step)
+	m.selected = make(map[string]bool)
+	if len(ids) == 1 && m.cursor < len(m.entries)-1 && action != "" {
+		m.cursor++
+	}
+}
+
+func (m *reviewModel) undoLast() {
+	if len(m.undo) == 0 {
+		m.status = "Nothing to undo"
+		return
+	}
+	step := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+	for _, prev := range step {
+		if prev.Action == "" {
+			delete(m.decisions, prev.ID)
+		} else {
+			m.decisions[prev.ID] = prev
+		}
+	}
+	m.status = fmt.Sprintf("Undid decision on %d candidate(s)", len(step))
+}
+
+// orderedDecisions returns the buffered decisions in list order.
+func (m *reviewModel) orderedDecisions() []reviewDecision {
+	var out []reviewDecision
+	for _, e := range m.entries {
+		if d, ok := m.decisions[e.Candidate.ID]; ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// applyReviewDecisions writes decisions to the pool, continuing past
+// failures so one bad candidate does not block the rest.
+func applyReviewDecisions(p *pool.Pool, decisions []reviewDecision, reviewer string) (int, []error) {
+	applied := 0
+	var errs []error
+	for _, d := range decisions {
+		var err error
+		switch d.Action {
+		case reviewApprove:
+			err = p.Approve(d.ID, "", reviewer)
+		case reviewReject:
+			err = p.Reject(d.ID, d.Reason, reviewer)
+		case reviewStage:
+			err = p.Stage(d.ID, types.TierBronze)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", d.Action, d.ID, err))
+			continue
+		}
+		applied++
+	}
+	return applied, errs
+}
+
+// view renders the screen: candidate list on the left, details on the
+// right, status and key help at the bottom.
+func (m *reviewModel) view() string {
+	width, height := max(m.width, 40), max(m.height, 10)
+	leftWidth := min(max(width/3, 24), 44)
+	rightWidth := width - leftWidth - 3
+	bodyHeight := height - 3
+
+	left := m.listLines(leftWidth, bodyHeight)
+	right := m.detailLines(rightWidth)
+
+	var b strings.Builder
+	b.WriteString(fitWidth(fmt.Sprintf("Gate review — %d candidate(s) · %d selected · %d decided (not yet written)",
+		len(m.entries), len(m.selected), len(m.decisions)), width))
+	b.WriteString("\n")
+	for i := 0; i < bodyHeight; i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		b.WriteString(fitWidth(l, leftWidth) + " │ " + fitWidth(r, rightWidth))
+		b.WriteString("\n")
+	}
+
+	switch m.mode {
+	case reviewReason:
+		b.WriteString(fitWidth("Reject reason: "+m.input+"▏", width) + "\n")
+		b.WriteString(fitWidth("enter confirm · esc cancel", width))
+	case reviewConfirmQuit:
+		b.WriteString(fitWidth(fmt.Sprintf("Discard %d unwritten decision(s)? y/n", len(m.decisions)), width) + "\n")
+		b.WriteString(fitWidth("", width))
+	default:
+		b.WriteString(fitWidth(m.status, width) + "\n")
+		b.WriteString(fitWidth("j/k move · space select · a approve · r reject · s stage · c clear · u undo · w write · q quit", width))
+	}
+	return b.String()
+}
+
+var reviewMarks = map[reviewAction]string{reviewApprove: "A", reviewReject: "R", reviewStage: "S"}
+
+func (m *reviewModel) listLines(width, height int) []string {
+	// Scroll so the cursor stays visible
+	start := 0
+	if m.cursor >= height {
+		start = m.cursor - height + 1
+	}
+	var lines []string
+	for i := start; i < len(m.entries) && len(lines) < height; i++ {
+		e := m.entries[i]
+		cursor, sel, mark := " ", "[ ]", " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		if m.selected[e.Candidate.ID] {
+			sel = "[x]"
+		}
+		if d, ok := m.decisions[e.Candidate.ID]; ok {
+			mark = reviewMarks[d.Action]
+		}
+		lines = append(lines, fitWidth(fmt.Sprintf("%s%s %s %s %.2f %s", cursor, sel, mark,
+			e.Candidate.Tier, e.ScoringResult.RawScore, e.Candidate.ID), width))
+	}
+	return lines
+}
+
+func (m *reviewModel) detailLines(width int) []string {
+	e := m.entries[m.cursor]
+	c := e.Candidate
+	lines := []string{fmt.Sprintf("%s  [%s] %s  age %s", c.ID, c.Tier, c.Type, e.AgeString)}
+	if d, ok := m.decisions[c.ID]; ok {
+		line := "Decision: " + string(d.Action)
+		if d.Reason != "" {
+			line += " — " + d.Reason
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, "", "── Content ──")
+	lines = append(lines, wrapText(c.Content, width)...)
+
+	r := e.ScoringResult.Rubric
+	lines = append(lines, "", "── Rubric ──")
+	for _, s := range []struct {
+		name  string
+		score float64
+	}{
+		{"specificity", r.Specificity},
+		{"actionability", r.Actionability},
+		{"novelty", r.Novelty},
+		{"context", r.Context},
+		{"confidence", r.Confidence},
+	} {
+		bar := strings.Repeat("█", int(s.score*20+0.5))
+		lines = append(lines, fmt.Sprintf("%-13s %.2f %s", s.name, s.score, bar))
+	}
+	lines = append(lines, fmt.Sprintf("%-13s %.2f", "raw score", e.ScoringResult.RawScore))
+
+	lines = append(lines, "", "── Source ──")
+	if c.Source.TranscriptPath == "" {
+		return append(lines, "(no source transcript)")
+	}
+	lines = append(lines, fmt.Sprintf("%s #%d", c.Source.TranscriptPath, c.Source.MessageIndex))
+	excerpt, ok := m.excerpts[c.ID]
+	if !ok {
+		excerpt = m.excerpt(c.Source)
+		m.excerpts[c.ID] = excerpt
+	}
+	for _, line := range excerpt {
+		lines = append(lines, wrapText(line, width)...)
+	}
+	return lines
+}
+
+// transcriptExcerpt returns the source message and its neighbours.
+func transcriptExcerpt(src types.Source) []string {
+	format, err := parser.DetectFile(src.TranscriptPath)
+	if err != nil {
+		return []string{fmt.Sprintf("(cannot read transcript: %v)", err)}
+	}
+	p := parser.NewParser()
+	p.Format = format
+	p.MaxContentLength = 300
+	result, err := p.ParseFile(src.TranscriptPath)
+	if err != nil {
+		return []string{fmt.Sprintf("(cannot read transcript: %v)", err)}
+	}
+
+	msgs := result.Messages
+	at := sort.Search(len(msgs), func(i int) bool { return msgs[i].MessageIndex >= src.MessageIndex })
+	if at == len(msgs) {
+		return []string{"(message not found in transcript)"}
+	}
+	var lines []string
+	for i := max(at-1, 0); i <= min(at+1, len(msgs)-1); i++ {
+		marker := "  "
+		if i == at {
+			marker = "▶ "
+		}
+		content := strings.Join(strings.Fields(msgs[i].Content), " ")
+		lines = append(lines, fmt.Sprintf("%s%s: %s", marker, msgs[i].Role, content))
+	}
+	return lines
+}
+
+// fitWidth truncates or pads s to exactly width runes.
+func fitWidth(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		runes := []rune(s)
+		if width <= 1 {
+			return string(runes[:width])
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// wrapText breaks s into lines of at most width runes at word boundaries.
+func wrapText(s string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
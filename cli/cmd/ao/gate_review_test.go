@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func reviewEntries(ids ...string) []pool.PoolEntry {
+	entries := make([]pool.PoolEntry, len(ids))
+	for i, id := range ids {
+		entries[i].Candidate = types.Candidate{ID: id, Tier: types.TierBronze, Content: "content of " + id}
+	}
+	return entries
+}
+
+func pressKeys(m *reviewModel, keys ...string) {
+	for _, k := range keys {
+		m.handleKey(k)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("j\x1b[A\x1b[B \r\x7f\x1bé"))
+	want := []string{"j", "up", "down", "space", "enter", "backspace", "esc", "é"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("parseKeys = %v, want %v", got, want)
+	}
+}
+
+func TestReviewModel_DecideAndUndo(t *testing.T) {
+	m := newReviewModel(reviewEntries("c1", "c2", "c3"))
+
+	// Single decision advances the cursor
+	pressKeys(m, "a")
+	if m.decisions["c1"].Action != reviewApprove || m.cursor != 1 {
+		t.Fatalf("after approve: decisions=%v cursor=%d", m.decisions, m.cursor)
+	}
+
+	// Batch: select c2 and c3, stage both as one step
+	pressKeys(m, "space", "j", "space", "s")
+	if m.decisions["c2"].Action != reviewStage || m.decisions["c3"].Action != reviewStage || len(m.selected) != 0 {
+		t.Fatalf("after batch stage: %v", m.decisions)
+	}
+
+	// Re-decide c1, then undo twice: back to approve, then c2/c3 undecided
+	pressKeys(m, "g", "r", "t", "o", "o", "space", "v", "a", "g", "u", "e", "enter")
+	if d := m.decisions["c1"]; d.Action != reviewReject || d.Reason != "too vague" {
+		t.Fatalf("reject with reason: %+v", d)
+	}
+	pressKeys(m, "u")
+	if m.decisions["c1"].Action != reviewApprove {
+		t.Errorf("undo should restore approve, got %+v", m.decisions["c1"])
+	}
+	pressKeys(m, "u")
+	if len(m.decisions) != 1 {
+		t.Errorf("undo of batch should clear c2/c3, got %v", m.decisions)
+	}
+}
+
+func TestReviewModel_RejectNeedsReason(t *testing.T) {
+	m := newReviewModel(reviewEntries("c1"))
+	pressKeys(m, "r", "enter")
+	if len(m.decisions) != 0 || m.mode != reviewReason {
+		t.Fatalf("empty reason accepted: %v", m.decisions)
+	}
+	pressKeys(m, "esc")
+	if m.mode != reviewBrowse {
+		t.Error("esc should cancel the reason prompt")
+	}
+}
+
+func TestReviewModel_QuitConfirmsDiscard(t *testing.T) {
+	m := newReviewModel(reviewEntries("c1"))
+	pressKeys(m, "a", "q")
+	if m.done {
+		t.Fatal("quit with decisions should ask first")
+	}
+	pressKeys(m, "n")
+	if m.done || m.mode != reviewBrowse {
+		t.Fatal("n should return to browsing")
+	}
+	pressKeys(m, "w")
+	if !m.done || !m.commit {
+		t.Error("w should commit")
+	}
+}
+
+func TestReviewModel_View(t *testing.T) {
+	entries := reviewEntries("c1")
+	entries[0].ScoringResult.Rubric.Specificity = 0.5
+	entries[0].Candidate.Source = types.Source{TranscriptPath: "/t.jsonl", MessageIndex: 3}
+	m := newReviewModel(entries)
+	m.width, m.height = 120, 30
+	m.excerpt = func(types.Source) []string { return []string{"▶ assistant: the excerpt"} }
+	pressKeys(m, "space")
+
+	view := m.view()
+	for _, want := range []string{">[x]", "content of c1", "specificity   0.50 ██████████", "/t.jsonl #3", "the excerpt"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+	for i, line := range strings.Split(view, "\n") {
+		if n := len([]rune(line)); n != 120 {
+			t.Errorf("line %d is %d runes wide, want 120", i, n)
+		}
+	}
+}
+
+func TestApplyReviewDecisions(t *testing.T) {
+	cwd := chdirTemp(t)
+	p := pool.NewPool(cwd)
+	for _, id := range []string{"c1", "c2", "c3"} {
+		c := types.Candidate{ID: id, Tier: types.TierBronze, Content: id}
+		if err := p.Add(c, types.Scoring{GateRequired: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	applied, errs := applyReviewDecisions(p, []reviewDecision{
+		{ID: "c1", Action: reviewApprove},
+		{ID: "c2", Action: reviewReject, Reason: "dup"},
+		{ID: "c3", Action: reviewStage},
+		{ID: "missing", Action: reviewApprove},
+	}, "tester")
+	if applied != 3 || len(errs) != 1 {
+		t.Fatalf("applied=%d errs=%v", applied, errs)
+	}
+
+	if e, err := p.Get("c1"); err != nil || e.HumanReview == nil || !e.HumanReview.Approved {
+		t.Errorf("c1 not approved: %+v %v", e, err)
+	}
+	if _, err := os.Stat(filepath.Join(p.PoolPath, pool.RejectedDir, "c2.json")); err != nil {
+		t.Errorf("c2 not rejected: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(p.PoolPath, pool.StagedDir, "c3.json")); err != nil {
+		t.Errorf("c3 not staged: %v", err)
+	}
+}
+
+func TestTranscriptExcerpt(t *testing.T) {
+	path := filepath.Join(transcriptFixtures, "simple-decision.jsonl")
+	lines := transcriptExcerpt(types.Source{TranscriptPath: path, MessageIndex: 2})
+	if len(lines) == 0 || !strings.Contains(strings.Join(lines, "\n"), "▶ ") {
+		t.Errorf("excerpt = %q", lines)
+	}
+
+	lines = transcriptExcerpt(types.Source{TranscriptPath: filepath.Join(t.TempDir(), "missing.jsonl")})
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "(cannot read transcript") {
+		t.Errorf("missing transcript excerpt = %q", lines)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/storage"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -65,6 +66,59 @@ func TestGatePendingReturnsBronzeCandidates(t *testing.T) {
 	}
 }
 
+// TestGatePendingFollowsTaxonomyGate verifies that gate pending selects
+// candidates by their human-gate state, so a taxonomy that gates silver
+// instead of bronze changes which candidates await review.
+func TestGatePendingFollowsTaxonomyGate(t *testing.T) {
+	cwd := chdirTemp(t)
+	config := filepath.Join(cwd, storage.DefaultBaseDir, TaxonomyConfigFile)
+	if err := os.MkdirAll(filepath.Dir(config), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config, []byte("version: 1\ntiers:\n  silver: {human_gate: true}\n  bronze: {human_gate: false}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tax, err := loadTaxonomy(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := pool.NewPool(cwd)
+	for _, c := range []types.Candidate{
+		{ID: "silver-gated", Tier: types.TierSilver, Content: "Silver content"},
+		{ID: "bronze-open", Tier: types.TierBronze, Content: "Bronze content"},
+	} {
+		if err := p.AddAt(c, types.Scoring{TierAssignment: c.Tier, GateRequired: tax.RequiresHumanGate(c.Tier)}, time.Now().Add(-48*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pending, err := p.ListPendingReview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Candidate.ID != "silver-gated" {
+		t.Errorf("pending = %+v, want only silver-gated", pending)
+	}
+
+	// Bulk approval leaves the gated silver candidate to a reviewer
+	approved, err := p.BulkApprove(24*time.Hour, "tester", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approved) != 0 {
+		t.Errorf("bulk approve approved gated candidates: %v", approved)
+	}
+
+	// Rescoring into an ungated tier clears the pending review
+	if err := p.Rescore("silver-gated", types.Scoring{TierAssignment: types.TierBronze, GateRequired: tax.RequiresHumanGate(types.TierBronze)}, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := p.ListPendingReview(); len(pending) != 0 {
+		t.Errorf("pending after rescore to bronze = %+v", pending)
+	}
+}
+
 // TestGateApproveRecordsReview verifies that approving a candidate
 // sets HumanReview fields correctly.
 func TestGateApproveRecordsReview(t *testing.T) {
@@ -1,18 +1,19 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
-	"syscall"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 )
 
 const (
@@ -262,40 +263,13 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 
 func loadMessages(cwd string) (messages []Message, corruptedCount int, err error) {
 	messagesPath := filepath.Join(cwd, ".agents", "mail", "messages.jsonl")
-	file, err := os.Open(messagesPath)
-	if err != nil {
+	if _, err := os.Stat(messagesPath); err != nil {
 		return nil, 0, err
 	}
-	defer func() {
-		if cerr := file.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
 
-	// Acquire shared lock for reading
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_SH); err != nil {
-		return nil, 0, fmt.Errorf("lock messages file: %w", err)
-	}
-	defer func() {
-		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) //nolint:errcheck // unlock best-effort
-	}()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		// Skip empty lines
-		if len(line) == 0 {
-			continue
-		}
-		var msg Message
-		if err := json.Unmarshal(line, &msg); err != nil {
-			corruptedCount++
-			continue
-		}
-		messages = append(messages, msg)
-	}
-
-	return messages, corruptedCount, scanner.Err()
+	// Torn lines from a crashed writer count as corrupted
+	messages, corruptedCount, err = fsutil.LoadJSONL[Message](messagesPath)
+	return messages, corruptedCount, err
 }
 
 func filterMessages(messages []Message, since, from string, unreadOnly bool) ([]Message, string) {
@@ -341,117 +315,46 @@ func filterMessages(messages []Message, since, from string, unreadOnly bool) ([]
 	return filtered, durationWarning
 }
 
-func appendMessage(cwd string, msg *Message) (err error) {
-	mailDir := filepath.Join(cwd, ".agents", "mail")
-	if err := os.MkdirAll(mailDir, 0700); err != nil {
-		return err
-	}
-
-	messagesPath := filepath.Join(mailDir, "messages.jsonl")
-
-	// Open file for append with exclusive lock
-	file, err := os.OpenFile(messagesPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if cerr := file.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
-
-	// Acquire exclusive lock to prevent concurrent write corruption
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
-		return fmt.Errorf("lock messages file: %w", err)
-	}
-	defer func() {
-		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) //nolint:errcheck // unlock best-effort
-	}()
-
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	if _, err := file.WriteString(string(data) + "\n"); err != nil {
-		return err
-	}
+func appendMessage(cwd string, msg *Message) error {
+	messagesPath := filepath.Join(cwd, ".agents", "mail", "messages.jsonl")
 
-	return nil
+	// Locked, fsynced append; safe against concurrent writers
+	return fsutil.AppendJSONL(messagesPath, 0600, msg)
 }
 
-func markMessagesRead(cwd string, messages []Message) (err error) {
+func markMessagesRead(cwd string, messages []Message) error {
 	messagesPath := filepath.Join(cwd, ".agents", "mail", "messages.jsonl")
 
-	// Open file with exclusive lock for read-modify-write
-	file, err := os.OpenFile(messagesPath, os.O_RDWR, 0600)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if cerr := file.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
-
-	// Acquire exclusive lock
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
-		return fmt.Errorf("lock messages file: %w", err)
-	}
-	defer func() {
-		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN) //nolint:errcheck // unlock best-effort
-	}()
-
-	// Read all messages while holding lock
-	var allMessages []Message
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		var msg Message
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue // Skip corrupted
-		}
-		allMessages = append(allMessages, msg)
-	}
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
 	// Create a set of IDs to mark
 	toMark := make(map[string]bool)
 	for _, msg := range messages {
 		toMark[msg.ID] = true
 	}
 
-	// Update messages
-	for i := range allMessages {
-		if toMark[allMessages[i].ID] {
-			allMessages[i].Read = true
+	// Read-modify-write under the lock, replacing the file atomically
+	return fsutil.WithLock(messagesPath, func() error {
+		if _, err := os.Stat(messagesPath); err != nil {
+			return err
 		}
-	}
-
-	// Truncate and rewrite file while still holding lock
-	if err := file.Truncate(0); err != nil {
-		return err
-	}
-	if _, err := file.Seek(0, 0); err != nil {
-		return err
-	}
-
-	for _, msg := range allMessages {
-		data, err := json.Marshal(msg)
+		allMessages, _, err := fsutil.LoadJSONL[Message](messagesPath)
 		if err != nil {
-			continue
-		}
-		if _, werr := file.WriteString(string(data) + "\n"); werr != nil {
-			return werr
+			return err
 		}
-	}
 
-	return nil
+		var buf bytes.Buffer
+		for i := range allMessages {
+			if toMark[allMessages[i].ID] {
+				allMessages[i].Read = true
+			}
+			data, err := json.Marshal(allMessages[i])
+			if err != nil {
+				continue
+			}
+			buf.Write(data)
+			buf.WriteByte('\n')
+		}
+		return fsutil.WriteFileAtomic(messagesPath, buf.Bytes(), 0600)
+	})
 }
 
 func generateMessageID() string {
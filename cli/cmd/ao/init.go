@@ -10,6 +10,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/ratchet"
 	"github.com/boshu2/agentops/cli/internal/storage"
 )
 
@@ -33,6 +34,7 @@ var (
 	initStealth bool
 	initHooks   bool
 	initFull    bool
+	initSignKey bool
 )
 
 var initCmd = &cobra.Command{
@@ -58,6 +60,9 @@ Git protection:
   .gitignore              - .agents/ entry appended (or --stealth for .git/info/exclude)
   .agents/.gitignore      - Belt-and-suspenders deny-all
 
+With --signing-key, an ed25519 key pair is created in .agents/ao/keys and
+every new ratchet chain entry is signed (see ao ratchet verify).
+
 Run in your project root. Safe to run multiple times (idempotent).`,
 	RunE: runInit,
 }
@@ -66,6 +71,7 @@ func init() {
 	initCmd.Flags().BoolVar(&initStealth, "stealth", false, "Use .git/info/exclude instead of .gitignore")
 	initCmd.Flags().BoolVar(&initHooks, "hooks", false, "Also register hooks (equivalent to ao hooks install)")
 	initCmd.Flags().BoolVar(&initFull, "full", false, "With --hooks, install all events (equivalent to ao hooks install --full)")
+	initCmd.Flags().BoolVar(&initSignKey, "signing-key", false, "Create a key for signing ratchet chain entries")
 	rootCmd.AddCommand(initCmd)
 }
 
@@ -99,9 +105,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if dryRun {
 		fmt.Println("[dry-run] Would create .agents/ao/{sessions,index,provenance}")
 	} else {
-		fs := storage.NewFileStorage(storage.WithBaseDir(baseDir))
-		if err := fs.Init(); err != nil {
-			return fmt.Errorf("initialize storage: %w", err)
+		fs, err := openStorage(baseDir)
+		if err != nil {
+			return err
+		}
+		initErr := fs.Init()
+		_ = fs.Close() //nolint:errcheck // nothing written beyond the schema
+		if initErr != nil {
+			return fmt.Errorf("initialize storage: %w", initErr)
 		}
 	}
 
@@ -149,6 +160,21 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Phase 3b: Chain signing key (optional)
+	keyDir := filepath.Join(baseDir, ratchet.KeyDir)
+	var keyID string
+	if initSignKey {
+		if dryRun {
+			fmt.Printf("[dry-run] Would create signing key in %s/%s\n", storage.DefaultBaseDir, ratchet.KeyDir)
+		} else {
+			pub, _, err := ratchet.GenerateSigningKey(keyDir)
+			if err != nil {
+				return fmt.Errorf("create signing key: %w", err)
+			}
+			keyID = ratchet.KeyID(pub)
+		}
+	}
+
 	// Summary
 	if !dryRun {
 		fmt.Printf("✓ Initialized AgentOps in %s\n", cwd)
@@ -169,6 +195,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 		if initHooks {
 			fmt.Println("  hooks registered")
 		}
+		if keyID != "" {
+			fmt.Printf("  %s/%s/ (signing key %s)\n", storage.DefaultBaseDir, ratchet.KeyDir, keyID)
+		}
 		fmt.Println()
 		fmt.Println("Next steps:")
 		if !initHooks {
@@ -11,7 +11,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	aocontext "github.com/boshu2/agentops/cli/internal/context"
 	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -19,9 +21,6 @@ const (
 	// DefaultInjectMaxTokens is the default token budget for injection (~1500 tokens ≈ 6KB)
 	DefaultInjectMaxTokens = 1500
 
-	// InjectCharsPerToken is the approximate characters per token (conservative estimate)
-	InjectCharsPerToken = 4
-
 	// MaxLearningsToInject is the maximum number of learnings to include
 	MaxLearningsToInject = 10
 
@@ -42,6 +41,8 @@ var (
 	injectSessionID  string
 	injectNoCite     bool
 	injectApplyDecay bool
+	injectTokenizer  string
+	injectDiversity  float64
 )
 
 type olConstraint struct {
@@ -59,28 +60,31 @@ type injectedKnowledge struct {
 	OLConstraints []olConstraint `json:"ol_constraints,omitempty"`
 	Timestamp     time.Time      `json:"timestamp"`
 	Query         string         `json:"query,omitempty"`
+	Budget        *injectBudget  `json:"budget,omitempty"`
 }
 
 type learning struct {
-	ID             string  `json:"id"`
-	Title          string  `json:"title"`
-	Summary        string  `json:"summary"`
-	Source         string  `json:"source,omitempty"`
-	FreshnessScore float64 `json:"freshness_score,omitempty"`
-	AgeWeeks       float64 `json:"age_weeks,omitempty"`
-	Utility        float64 `json:"utility,omitempty"`         // MemRL utility value
-	CompositeScore float64 `json:"composite_score,omitempty"` // Two-Phase ranking score
-	Superseded     bool    `json:"-"`                         // Internal flag - not serialized
+	ID             string   `json:"id"`
+	Title          string   `json:"title"`
+	Summary        string   `json:"summary"`
+	Source         string   `json:"source,omitempty"`
+	FreshnessScore float64  `json:"freshness_score,omitempty"`
+	AgeWeeks       float64  `json:"age_weeks,omitempty"`
+	Utility        float64  `json:"utility,omitempty"`         // MemRL utility value
+	CompositeScore float64  `json:"composite_score,omitempty"` // Two-Phase ranking score
+	Related        []string `json:"related,omitempty"`         // IDs of clustered near-duplicates
+	Superseded     bool     `json:"-"`                         // Internal flag - not serialized
 }
 
 type pattern struct {
-	Name           string  `json:"name"`
-	Description    string  `json:"description"`
-	FilePath       string  `json:"file_path,omitempty"`
-	FreshnessScore float64 `json:"freshness_score,omitempty"`
-	AgeWeeks       float64 `json:"age_weeks,omitempty"`
-	Utility        float64 `json:"utility,omitempty"`
-	CompositeScore float64 `json:"composite_score,omitempty"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	FilePath       string   `json:"file_path,omitempty"`
+	FreshnessScore float64  `json:"freshness_score,omitempty"`
+	AgeWeeks       float64  `json:"age_weeks,omitempty"`
+	Utility        float64  `json:"utility,omitempty"`
+	CompositeScore float64  `json:"composite_score,omitempty"`
+	Related        []string `json:"related,omitempty"`
 }
 
 type session struct {
@@ -104,13 +108,25 @@ Searches:
 Uses file-based search with Two-Phase retrieval (freshness + utility scoring).
 CASS integration adds maturity weighting and confidence decay.
 
+Learnings and patterns are re-ranked by maximal marginal relevance so the
+injection covers distinct topics: near-duplicates are clustered and only the
+best of each cluster is shown, marked "+N related". --diversity 1 turns this
+off and ranks by score alone.
+
+--max-tokens is counted with a BPE tokenizer (a vocabulary is bundled, so no
+network is needed). Whole learnings, patterns, sessions and constraints are
+selected to maximize their scores within the budget; nothing is cut mid-item.
+With --format json, the "budget" field lists what was dropped and why.
+
 Examples:
   ao inject                     # Inject general knowledge
   ao inject "authentication"    # Inject knowledge about auth
+  ao inject --context 'tag:auth maturity:established -deprecated'
   ao inject --max-tokens 2000   # Larger budget
   ao inject --format json       # JSON output
   ao inject --no-cite           # Skip citation recording
-  ao inject --apply-decay       # Apply confidence decay before ranking`,
+  ao inject --apply-decay       # Apply confidence decay before ranking
+  ao inject --diversity 0.5     # Favour breadth over top scores`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runInject,
 }
@@ -123,6 +139,8 @@ func init() {
 	injectCmd.Flags().StringVar(&injectSessionID, "session", "", "Session ID for citation tracking (auto-generated if empty)")
 	injectCmd.Flags().BoolVar(&injectNoCite, "no-cite", false, "Disable citation recording")
 	injectCmd.Flags().BoolVar(&injectApplyDecay, "apply-decay", false, "Apply confidence decay before ranking")
+	injectCmd.Flags().Float64Var(&injectDiversity, "diversity", DefaultInjectDiversity, "MMR lambda: 1 ranks by score only; lower values favour diverse learnings and patterns")
+	injectCmd.Flags().StringVar(&injectTokenizer, "tokenizer", "bpe", "Token counter for --max-tokens: bpe (bundled vocabulary), chars, or a path to a BPE merges file")
 }
 
 func runInject(cmd *cobra.Command, args []string) error {
@@ -141,13 +159,22 @@ func runInject(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if _, err := search.ParseFilterQuery(query, time.Now()); err != nil {
+		return err
+	}
+	if injectDiversity < 0 || injectDiversity > 1 {
+		return fmt.Errorf("--diversity must be between 0 and 1, got %g", injectDiversity)
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	// Calculate character budget from tokens
-	charBudget := injectMaxTokens * InjectCharsPerToken
+	tokenizer, err := aocontext.NewTokenizer(injectTokenizer)
+	if err != nil {
+		return fmt.Errorf("load tokenizer: %w", err)
+	}
 
 	// Get or generate session ID for citation tracking
 	sessionID := canonicalSessionID(injectSessionID)
@@ -159,36 +186,18 @@ func runInject(cmd *cobra.Command, args []string) error {
 	}
 
 	// Search learnings
-	learnings, err := collectLearnings(cwd, query, MaxLearningsToInject)
+	learnings, err := collectLearnings(cwd, query, diversityPoolSize(MaxLearningsToInject))
 	if err != nil {
 		VerbosePrintf("Warning: failed to collect learnings: %v\n", err)
 	}
-	knowledge.Learnings = learnings
-
-	// Record citations for retrieved learnings (Phase 0: Critical for MemRL feedback loop)
-	if !injectNoCite && len(learnings) > 0 {
-		if err := recordCitations(cwd, learnings, sessionID, query); err != nil {
-			VerbosePrintf("Warning: failed to record citations: %v\n", err)
-		} else {
-			VerbosePrintf("Recorded %d citations for session %s\n", len(learnings), sessionID)
-		}
-	}
+	knowledge.Learnings = selectLearnings(learnings, injectDiversity, MaxLearningsToInject)
 
 	// Search patterns
-	patterns, err := collectPatterns(cwd, query, MaxPatternsToInject)
+	patterns, err := collectPatterns(cwd, query, diversityPoolSize(MaxPatternsToInject))
 	if err != nil {
 		VerbosePrintf("Warning: failed to collect patterns: %v\n", err)
 	}
-	knowledge.Patterns = patterns
-
-	// Record citations for retrieved patterns (closes σ gap: patterns were retrieved but never cited)
-	if !injectNoCite && len(patterns) > 0 {
-		if err := recordPatternCitations(cwd, patterns, sessionID, query); err != nil {
-			VerbosePrintf("Warning: failed to record pattern citations: %v\n", err)
-		} else {
-			VerbosePrintf("Recorded %d pattern citations for session %s\n", len(patterns), sessionID)
-		}
-	}
+	knowledge.Patterns = selectPatterns(patterns, injectDiversity, MaxPatternsToInject)
 
 	// Search recent sessions
 	sessions, err := collectRecentSessions(cwd, query, MaxSessionsToInject)
@@ -204,6 +213,30 @@ func runInject(cmd *cobra.Command, args []string) error {
 	}
 	knowledge.OLConstraints = olConstraints
 
+	// Fit whole items into the token budget
+	knowledge.Budget = packKnowledge(knowledge, tokenizer, injectMaxTokens)
+	for _, d := range knowledge.Budget.Dropped {
+		VerbosePrintf("Dropped %s %s (%d tokens): %s\n", d.Kind, d.ID, d.Tokens, d.Reason)
+	}
+
+	// Record citations for injected learnings (Phase 0: Critical for MemRL feedback loop)
+	if !injectNoCite && len(knowledge.Learnings) > 0 {
+		if err := recordCitations(cwd, knowledge.Learnings, sessionID, query); err != nil {
+			VerbosePrintf("Warning: failed to record citations: %v\n", err)
+		} else {
+			VerbosePrintf("Recorded %d citations for session %s\n", len(knowledge.Learnings), sessionID)
+		}
+	}
+
+	// Record citations for injected patterns (closes σ gap: patterns were retrieved but never cited)
+	if !injectNoCite && len(knowledge.Patterns) > 0 {
+		if err := recordPatternCitations(cwd, knowledge.Patterns, sessionID, query); err != nil {
+			VerbosePrintf("Warning: failed to record pattern citations: %v\n", err)
+		} else {
+			VerbosePrintf("Recorded %d pattern citations for session %s\n", len(knowledge.Patterns), sessionID)
+		}
+	}
+
 	// Format output
 	var output string
 	if injectFormat == "json" {
@@ -216,11 +249,6 @@ func runInject(cmd *cobra.Command, args []string) error {
 		output = formatKnowledgeMarkdown(knowledge)
 	}
 
-	// Trim to budget if needed
-	if len(output) > charBudget {
-		output = trimToCharBudget(output, charBudget)
-	}
-
 	fmt.Println(output)
 	return nil
 }
@@ -256,80 +284,92 @@ func findAgentsSubdir(startDir, subdir string) string {
 	return ""
 }
 
+// Markdown scaffolding around injected items.
+const (
+	knowledgeHeader      = "## Injected Knowledge (ol inject)\n\n"
+	learningsHeading     = "### Recent Learnings\n"
+	patternsHeading      = "### Active Patterns\n"
+	sessionsHeading      = "### Recent Sessions\n"
+	olConstraintsHeading = "### Olympus Constraints\n"
+	noKnowledgeLine      = "*No prior knowledge found.*\n\n"
+)
+
 // formatKnowledgeMarkdown formats knowledge as markdown
 func formatKnowledgeMarkdown(k *injectedKnowledge) string {
 	var sb strings.Builder
 
-	sb.WriteString("## Injected Knowledge (ol inject)\n\n")
+	sb.WriteString(knowledgeHeader)
 
 	if len(k.Learnings) > 0 {
-		sb.WriteString("### Recent Learnings\n")
+		sb.WriteString(learningsHeading)
 		for _, l := range k.Learnings {
-			if l.Summary != "" {
-				sb.WriteString(fmt.Sprintf("- **%s**: %s\n", l.ID, l.Summary))
-			} else {
-				sb.WriteString(fmt.Sprintf("- **%s**: %s\n", l.ID, l.Title))
-			}
+			sb.WriteString(learningLine(l))
 		}
 		sb.WriteString("\n")
 	}
 
 	if len(k.Patterns) > 0 {
-		sb.WriteString("### Active Patterns\n")
+		sb.WriteString(patternsHeading)
 		for _, p := range k.Patterns {
-			if p.Description != "" {
-				sb.WriteString(fmt.Sprintf("- **%s**: %s\n", p.Name, p.Description))
-			} else {
-				sb.WriteString(fmt.Sprintf("- **%s**\n", p.Name))
-			}
+			sb.WriteString(patternLine(p))
 		}
 		sb.WriteString("\n")
 	}
 
 	if len(k.Sessions) > 0 {
-		sb.WriteString("### Recent Sessions\n")
+		sb.WriteString(sessionsHeading)
 		for _, s := range k.Sessions {
-			sb.WriteString(fmt.Sprintf("- [%s] %s\n", s.Date, s.Summary))
+			sb.WriteString(sessionLine(s))
 		}
 		sb.WriteString("\n")
 	}
 
 	if len(k.OLConstraints) > 0 {
-		sb.WriteString("### Olympus Constraints\n")
+		sb.WriteString(olConstraintsHeading)
 		for _, c := range k.OLConstraints {
-			sb.WriteString(fmt.Sprintf("- **[olympus constraint]** %s: %s\n", c.Pattern, c.Detection))
+			sb.WriteString(olConstraintLine(c))
 		}
 		sb.WriteString("\n")
 	}
 
 	if len(k.Learnings) == 0 && len(k.Patterns) == 0 && len(k.Sessions) == 0 && len(k.OLConstraints) == 0 {
-		sb.WriteString("*No prior knowledge found.*\n\n")
+		sb.WriteString(noKnowledgeLine)
 	}
 
-	sb.WriteString(fmt.Sprintf("*Last injection: %s*\n", k.Timestamp.Format(time.RFC3339)))
+	sb.WriteString(knowledgeFooter(k))
 
 	return sb.String()
 }
 
-// trimToCharBudget truncates output to fit character budget
-func trimToCharBudget(output string, budget int) string {
-	if len(output) <= budget {
-		return output
+// knowledgeFooter is the closing line of the markdown rendering.
+func knowledgeFooter(k *injectedKnowledge) string {
+	return fmt.Sprintf("*Last injection: %s*\n", k.Timestamp.Format(time.RFC3339))
+}
+
+// learningLine renders one learning as a markdown list item.
+func learningLine(l learning) string {
+	if l.Summary != "" {
+		return fmt.Sprintf("- **%s**: %s%s\n", l.ID, l.Summary, relatedSuffix(l.Related))
 	}
+	return fmt.Sprintf("- **%s**: %s%s\n", l.ID, l.Title, relatedSuffix(l.Related))
+}
 
-	// Try to truncate at a section boundary
-	lines := strings.Split(output, "\n")
-	var result strings.Builder
-	for _, line := range lines {
-		if result.Len()+len(line)+1 > budget-50 { // Leave room for truncation marker
-			break
-		}
-		result.WriteString(line)
-		result.WriteString("\n")
+// patternLine renders one pattern as a markdown list item.
+func patternLine(p pattern) string {
+	if p.Description != "" {
+		return fmt.Sprintf("- **%s**: %s%s\n", p.Name, p.Description, relatedSuffix(p.Related))
 	}
+	return fmt.Sprintf("- **%s**%s\n", p.Name, relatedSuffix(p.Related))
+}
+
+// sessionLine renders one session summary as a markdown list item.
+func sessionLine(s session) string {
+	return fmt.Sprintf("- [%s] %s\n", s.Date, s.Summary)
+}
 
-	result.WriteString("\n*[truncated to fit token budget]*\n")
-	return result.String()
+// olConstraintLine renders one Olympus constraint as a markdown list item.
+func olConstraintLine(c olConstraint) string {
+	return fmt.Sprintf("- **[olympus constraint]** %s: %s\n", c.Pattern, c.Detection)
 }
 
 // truncateText truncates a string to max length with ellipsis
@@ -393,11 +433,15 @@ func collectOLConstraints(cwd, query string) ([]olConstraint, error) {
 		return raw, nil
 	}
 
-	queryLower := strings.ToLower(query)
+	fq, err := search.ParseFilterQuery(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
 	var filtered []olConstraint
 	for _, c := range raw {
-		content := strings.ToLower(c.Pattern + " " + c.Detection)
-		if strings.Contains(content, queryLower) {
+		content := c.Pattern + " " + c.Detection
+		fields := search.Fields{Type: "constraint", Status: c.Status, Confidence: c.Confidence, Text: content}
+		if fq.MatchText(content) && fq.Match(fields) {
 			filtered = append(filtered, c)
 		}
 	}
@@ -408,6 +452,11 @@ func collectOLConstraints(cwd, query string) ([]olConstraint, error) {
 // This is critical for closing the MemRL feedback loop (Phase 0).
 // Citations link: session → learning → feedback → utility update.
 func recordCitations(baseDir string, learnings []learning, sessionID, query string) error {
+	opts, closeStore, err := citationOptions(baseDir)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
 	for _, l := range learnings {
 		event := types.CitationEvent{
 			ArtifactPath: l.Source,
@@ -417,7 +466,7 @@ func recordCitations(baseDir string, learnings []learning, sessionID, query stri
 			Query:        query,
 		}
 
-		if err := ratchet.RecordCitation(baseDir, event); err != nil {
+		if err := ratchet.RecordCitation(baseDir, event, opts...); err != nil {
 			return fmt.Errorf("record citation for %s: %w", l.ID, err)
 		}
 	}
@@ -426,6 +475,11 @@ func recordCitations(baseDir string, learnings []learning, sessionID, query stri
 
 // recordPatternCitations records citation events for retrieved patterns.
 func recordPatternCitations(baseDir string, patterns []pattern, sessionID, query string) error {
+	opts, closeStore, err := citationOptions(baseDir)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
 	for _, p := range patterns {
 		if p.FilePath == "" {
 			continue
@@ -437,7 +491,7 @@ func recordPatternCitations(baseDir string, patterns []pattern, sessionID, query
 			CitationType: "retrieved",
 			Query:        query,
 		}
-		if err := ratchet.RecordCitation(baseDir, event); err != nil {
+		if err := ratchet.RecordCitation(baseDir, event, opts...); err != nil {
 			return fmt.Errorf("record citation for pattern %s: %w", p.Name, err)
 		}
 	}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	aocontext "github.com/boshu2/agentops/cli/internal/context"
+)
+
+// injectBudget reports how --max-tokens was applied to an injection.
+type injectBudget struct {
+	MaxTokens  int                     `json:"max_tokens"`
+	UsedTokens int                     `json:"used_tokens"`
+	Tokenizer  string                  `json:"tokenizer"`
+	Dropped    []aocontext.DroppedItem `json:"dropped,omitempty"`
+}
+
+// Item kinds reported in injectBudget.Dropped.
+const (
+	kindLearning     = "learning"
+	kindPattern      = "pattern"
+	kindSession      = "session"
+	kindOLConstraint = "ol_constraint"
+)
+
+// packKnowledge removes whole items from k until its markdown rendering
+// fits maxTokens as counted by tok, keeping the highest-value selection.
+//
+// Item values: learnings and patterns map their composite score through a
+// logistic curve into (0, 1); sessions use freshness from their date; Olympus
+// constraints score 1 + confidence so guardrails outrank plain knowledge.
+// Headings of every non-empty section are reserved up front; a final exact
+// count of the rendering catches token merges across line boundaries.
+func packKnowledge(k *injectedKnowledge, tok aocontext.Tokenizer, maxTokens int) *injectBudget {
+	budget := &injectBudget{MaxTokens: maxTokens, Tokenizer: tok.Name()}
+
+	type entry struct {
+		item  aocontext.PackItem
+		index int
+	}
+	var entries []entry
+	add := func(kind, id, line string, value float64, index int) {
+		entries = append(entries, entry{
+			item:  aocontext.PackItem{Kind: kind, ID: id, Tokens: tok.Count(line), Score: value},
+			index: index,
+		})
+	}
+
+	reserved := tok.Count(knowledgeHeader) + tok.Count(knowledgeFooter(k))
+	section := func(heading string, n int) {
+		if n > 0 {
+			reserved += tok.Count(heading) + tok.Count("\n")
+		}
+	}
+	section(learningsHeading, len(k.Learnings))
+	section(patternsHeading, len(k.Patterns))
+	section(sessionsHeading, len(k.Sessions))
+	section(olConstraintsHeading, len(k.OLConstraints))
+
+	for i, l := range k.Learnings {
+		add(kindLearning, l.ID, learningLine(l), logistic(l.CompositeScore), i)
+	}
+	for i, p := range k.Patterns {
+		add(kindPattern, p.Name, patternLine(p), logistic(p.CompositeScore), i)
+	}
+	now := time.Now()
+	for i, s := range k.Sessions {
+		add(kindSession, s.Date, sessionLine(s), sessionValue(s, now), i)
+	}
+	for i, c := range k.OLConstraints {
+		add(kindOLConstraint, c.Pattern, olConstraintLine(c), 1+c.Confidence, i)
+	}
+
+	items := make([]aocontext.PackItem, len(entries))
+	for i, e := range entries {
+		items[i] = e.item
+	}
+	result := aocontext.Pack(items, maxTokens-reserved)
+	budget.Dropped = result.Dropped
+
+	keep := make(map[string]map[int]bool)
+	for _, idx := range result.Selected {
+		e := entries[idx]
+		if keep[e.item.Kind] == nil {
+			keep[e.item.Kind] = make(map[int]bool)
+		}
+		keep[e.item.Kind][e.index] = true
+	}
+	orig := *k
+	applyKeep := func() {
+		k.Learnings = keepIndexed(orig.Learnings, keep[kindLearning])
+		k.Patterns = keepIndexed(orig.Patterns, keep[kindPattern])
+		k.Sessions = keepIndexed(orig.Sessions, keep[kindSession])
+		k.OLConstraints = keepIndexed(orig.OLConstraints, keep[kindOLConstraint])
+	}
+	applyKeep()
+
+	// Boundary effects between lines can shift the exact count slightly;
+	// drop the lowest-value survivors until the rendering fits.
+	used := tok.Count(formatKnowledgeMarkdown(k))
+	for used > maxTokens && len(result.Selected) > 0 {
+		lowest := 0
+		for i, idx := range result.Selected {
+			if items[idx].Score < items[result.Selected[lowest]].Score {
+				lowest = i
+			}
+		}
+		e := entries[result.Selected[lowest]]
+		delete(keep[e.item.Kind], e.index)
+		result.Selected = append(result.Selected[:lowest], result.Selected[lowest+1:]...)
+		budget.Dropped = append(budget.Dropped, aocontext.DroppedItem{
+			Kind: e.item.Kind, ID: e.item.ID, Tokens: e.item.Tokens, Score: e.item.Score, Reason: aocontext.DropBudget,
+		})
+		applyKeep()
+		used = tok.Count(formatKnowledgeMarkdown(k))
+	}
+	budget.UsedTokens = used
+
+	sort.SliceStable(budget.Dropped, func(i, j int) bool {
+		return budget.Dropped[i].Score > budget.Dropped[j].Score
+	})
+	return budget
+}
+
+// keepIndexed returns the items whose index is in keep, preserving order.
+func keepIndexed[T any](items []T, keep map[int]bool) []T {
+	var kept []T
+	for i, item := range items {
+		if keep[i] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// logistic maps a z-normalized composite score into (0, 1).
+func logistic(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// sessionValue scores a session summary by the freshness of its date.
+func sessionValue(s session, now time.Time) float64 {
+	date, err := time.Parse("2006-01-02", s.Date)
+	if err != nil {
+		return freshnessScore(0) / 2
+	}
+	return freshnessScore(now.Sub(date).Hours() / (24 * 7))
+}
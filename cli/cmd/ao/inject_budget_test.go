@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	aocontext "github.com/boshu2/agentops/cli/internal/context"
+)
+
+func budgetTestKnowledge() *injectedKnowledge {
+	return &injectedKnowledge{
+		Learnings: []learning{
+			{ID: "L1", Summary: "Acquire locks in a fixed order", CompositeScore: 1.5},
+			{ID: "L2", Summary: strings.Repeat("very long rambling learning text ", 40), CompositeScore: 0.2},
+			{ID: "L3", Summary: "Retry with jitter", CompositeScore: -0.5},
+		},
+		Patterns: []pattern{
+			{Name: "worktree-merge", Description: "Merge worktrees serially", CompositeScore: 0.5},
+		},
+		Sessions: []session{
+			{Date: time.Now().Format("2006-01-02"), Summary: "Fixed the auth refresh bug"},
+		},
+		OLConstraints: []olConstraint{
+			{Pattern: "no-eval", Detection: "eval() usage", Confidence: 0.9},
+		},
+		Timestamp: time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestPackKnowledgeFitsBudget(t *testing.T) {
+	tok := aocontext.BundledTokenizer()
+	k := budgetTestKnowledge()
+
+	b := packKnowledge(k, tok, 100)
+
+	out := formatKnowledgeMarkdown(k)
+	if got := tok.Count(out); got > 100 || got != b.UsedTokens {
+		t.Errorf("rendered %d tokens, reported %d, budget 100", got, b.UsedTokens)
+	}
+	if strings.Contains(out, "rambling") {
+		t.Error("oversized learning should have been dropped whole")
+	}
+	if len(k.OLConstraints) != 1 || len(k.Learnings) == 0 || k.Learnings[0].ID != "L1" {
+		t.Errorf("expected constraint and top learning kept, got %+v", k)
+	}
+
+	var found bool
+	for _, d := range b.Dropped {
+		if d.Kind == kindLearning && d.ID == "L2" {
+			found = true
+			if d.Reason != aocontext.DropBudget && d.Reason != aocontext.DropTooLarge {
+				t.Errorf("unexpected reason %q", d.Reason)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("L2 not reported as dropped: %+v", b.Dropped)
+	}
+	if b.Tokenizer != tok.Name() || b.MaxTokens != 100 {
+		t.Errorf("budget = %+v", b)
+	}
+}
+
+func TestPackKnowledgeEverythingFits(t *testing.T) {
+	k := budgetTestKnowledge()
+	b := packKnowledge(k, aocontext.BundledTokenizer(), 10000)
+	if len(b.Dropped) != 0 || len(k.Learnings) != 3 || len(k.Patterns) != 1 || len(k.Sessions) != 1 {
+		t.Errorf("nothing should be dropped under a large budget: %+v", b.Dropped)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/boshu2/agentops/cli/internal/search"
+)
+
+const (
+	// DefaultInjectDiversity is the default MMR lambda for `ao inject`:
+	// mostly relevance, with enough weight on novelty to avoid repeats.
+	DefaultInjectDiversity = 0.7
+
+	// diversityPoolFactor is how many times the inject limit is collected
+	// before clustering and MMR pick the final set.
+	diversityPoolFactor = 3
+
+	// nearDuplicateThreshold is the cosine similarity above which two items
+	// are treated as the same knowledge and clustered together.
+	nearDuplicateThreshold = 0.75
+)
+
+// diversityPoolSize is how many candidates to collect for a final limit.
+func diversityPoolSize(limit int) int {
+	if injectDiversity >= 1 {
+		return limit
+	}
+	return limit * diversityPoolFactor
+}
+
+// selectLearnings picks the learnings to inject from score-ranked
+// candidates: by diversify when lambda < 1, otherwise the top limit.
+func selectLearnings(ls []learning, lambda float64, limit int) []learning {
+	if lambda >= 1 {
+		if len(ls) > limit {
+			ls = ls[:limit]
+		}
+		return ls
+	}
+	return diversifyLearnings(ls, lambda, limit)
+}
+
+// selectPatterns is selectLearnings for patterns.
+func selectPatterns(ps []pattern, lambda float64, limit int) []pattern {
+	if lambda >= 1 {
+		if len(ps) > limit {
+			ps = ps[:limit]
+		}
+		return ps
+	}
+	return diversifyPatterns(ps, lambda, limit)
+}
+
+// diversify clusters near-duplicate items, then picks up to limit cluster
+// representatives by MMR. texts are embedded offline with the hashed n-gram
+// embedder, so similarity reflects shared terms and spelling variants.
+// scores are ranking scores (any scale). It returns the picked item indices
+// in order, and for each the indices of the near-duplicates it stands for.
+func diversify(texts []string, scores []float64, lambda float64, limit int) ([]int, map[int][]int) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := search.NewHashEmbedder(search.DefaultHashDims).Embed(texts)
+	if err != nil {
+		vectors = make([][]float32, len(texts)) // zero vectors: no similarity
+	}
+	relevance := scaleUnit(scores)
+
+	clusters := search.ClusterNearDuplicates(relevance, vectors, nearDuplicateThreshold)
+	leaderRel := make([]float64, len(clusters))
+	leaderVec := make([][]float32, len(clusters))
+	for c, members := range clusters {
+		leaderRel[c] = relevance[members[0]]
+		leaderVec[c] = vectors[members[0]]
+	}
+
+	var picked []int
+	related := make(map[int][]int)
+	for _, c := range search.MMR(leaderRel, leaderVec, lambda, limit) {
+		leader := clusters[c][0]
+		picked = append(picked, leader)
+		if len(clusters[c]) > 1 {
+			related[leader] = clusters[c][1:]
+		}
+	}
+	return picked, related
+}
+
+// scaleUnit min-max scales scores into [0, 1]; equal scores all map to 1.
+func scaleUnit(scores []float64) []float64 {
+	out := make([]float64, len(scores))
+	if len(scores) == 0 {
+		return out
+	}
+	lo, hi := scores[0], scores[0]
+	for _, s := range scores {
+		lo = math.Min(lo, s)
+		hi = math.Max(hi, s)
+	}
+	for i, s := range scores {
+		if hi > lo {
+			out[i] = (s - lo) / (hi - lo)
+		} else {
+			out[i] = 1
+		}
+	}
+	return out
+}
+
+// diversifyLearnings keeps up to limit learnings chosen by diversify and
+// records each representative's clustered near-duplicates in Related.
+func diversifyLearnings(ls []learning, lambda float64, limit int) []learning {
+	texts := make([]string, len(ls))
+	scores := make([]float64, len(ls))
+	for i, l := range ls {
+		texts[i] = l.Title + " " + l.Summary
+		scores[i] = l.CompositeScore
+	}
+
+	picked, related := diversify(texts, scores, lambda, limit)
+	out := make([]learning, 0, len(picked))
+	for _, i := range picked {
+		l := ls[i]
+		for _, r := range related[i] {
+			l.Related = append(l.Related, ls[r].ID)
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// diversifyPatterns is diversifyLearnings for patterns.
+func diversifyPatterns(ps []pattern, lambda float64, limit int) []pattern {
+	texts := make([]string, len(ps))
+	scores := make([]float64, len(ps))
+	for i, p := range ps {
+		texts[i] = p.Name + " " + p.Description
+		scores[i] = p.CompositeScore
+	}
+
+	picked, related := diversify(texts, scores, lambda, limit)
+	out := make([]pattern, 0, len(picked))
+	for _, i := range picked {
+		p := ps[i]
+		for _, r := range related[i] {
+			p.Related = append(p.Related, ps[r].Name)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// relatedSuffix renders the "+N related" pointer for a clustered item.
+func relatedSuffix(related []string) string {
+	if len(related) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" *(+%d related)*", len(related))
+}
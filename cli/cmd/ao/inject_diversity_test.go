@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func diversityTestLearnings() []learning {
+	return []learning{
+		{ID: "L1", Title: "Flaky auth test", Summary: "Fix flaky auth test: token refresh race in session middleware", CompositeScore: 2.0},
+		{ID: "L2", Title: "Flaky auth test", Summary: "Token refresh race in auth session middleware caused flaky test", CompositeScore: 1.9},
+		{ID: "L3", Title: "Flaky auth test", Summary: "Fix flaky auth test: token refresh race in the session middleware", CompositeScore: 1.8},
+		{ID: "L4", Title: "Lock ordering", Summary: "Acquire mutexes in a fixed order to avoid deadlock", CompositeScore: 0.5},
+		{ID: "L5", Title: "Worktrees", Summary: "Use worktrees for parallel agent runs", CompositeScore: 0.1},
+	}
+}
+
+func TestDiversifyLearningsClustersNearDuplicates(t *testing.T) {
+	got := diversifyLearnings(diversityTestLearnings(), DefaultInjectDiversity, 3)
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 learnings, got %d: %+v", len(got), got)
+	}
+	if got[0].ID != "L1" {
+		t.Errorf("top learning should lead, got %s", got[0].ID)
+	}
+	if strings.Join(got[0].Related, ",") != "L2,L3" {
+		t.Errorf("L1 related = %v, want [L2 L3]", got[0].Related)
+	}
+	ids := got[1].ID + "," + got[2].ID
+	if ids != "L4,L5" {
+		t.Errorf("expected distinct topics after the cluster, got %s", ids)
+	}
+	if line := learningLine(got[0]); !strings.Contains(line, "(+2 related)") {
+		t.Errorf("learningLine = %q, want +2 related pointer", line)
+	}
+}
+
+func TestSelectLearningsLambdaOneKeepsRanking(t *testing.T) {
+	got := selectLearnings(diversityTestLearnings(), 1, 3)
+	if len(got) != 3 || got[0].ID != "L1" || got[1].ID != "L2" || got[2].ID != "L3" {
+		t.Errorf("lambda=1 should keep score order, got %+v", got)
+	}
+	if len(got[0].Related) != 0 {
+		t.Error("lambda=1 should not cluster")
+	}
+}
+
+func TestDiversifyPatterns(t *testing.T) {
+	patterns := []pattern{
+		{Name: "retry-jitter", Description: "Add jitter to retries", CompositeScore: 1},
+		{Name: "retry-jitter-2", Description: "Add jitter to retries", CompositeScore: 0.9},
+		{Name: "worktree-merge", Description: "Merge worktrees serially", CompositeScore: 0.2},
+	}
+	got := diversifyPatterns(patterns, DefaultInjectDiversity, 5)
+	if len(got) != 2 || got[0].Name != "retry-jitter" || len(got[0].Related) != 1 {
+		t.Errorf("got %+v", got)
+	}
+	if line := patternLine(got[0]); !strings.HasSuffix(line, "(+1 related)*\n") {
+		t.Errorf("patternLine = %q", line)
+	}
+}
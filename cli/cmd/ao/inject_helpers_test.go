@@ -148,49 +148,6 @@ func TestFormatKnowledgeMarkdown(t *testing.T) {
 	})
 }
 
-func TestTrimToCharBudget(t *testing.T) {
-	tests := []struct {
-		name   string
-		input  string
-		budget int
-		check  func(string) bool
-		desc   string
-	}{
-		{
-			name:   "under budget passes through",
-			input:  "short",
-			budget: 100,
-			check:  func(s string) bool { return s == "short" },
-			desc:   "should return unchanged",
-		},
-		{
-			name:   "over budget truncated",
-			input:  "line1\nline2\nline3\nline4\nline5\nline6\nline7\nline8\nline9\nline10",
-			budget: 30,
-			check: func(s string) bool {
-				return len(s) <= 80 && strings.Contains(s, "truncated")
-			},
-			desc: "should be truncated with marker",
-		},
-		{
-			name:   "exact budget",
-			input:  "exact",
-			budget: 5,
-			check:  func(s string) bool { return s == "exact" },
-			desc:   "should return unchanged at exact budget",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := trimToCharBudget(tt.input, tt.budget)
-			if !tt.check(got) {
-				t.Errorf("trimToCharBudget(): %s; got %q", tt.desc, got)
-			}
-		})
-	}
-}
-
 func TestTruncateText(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -37,8 +38,11 @@ func collectLearnings(cwd, query string, limit int) ([]learning, error) {
 	files = append(files, jsonlFiles...)
 
 	var learnings []learning
-	queryLower := strings.ToLower(query)
 	now := time.Now()
+	fq, err := search.ParseFilterQuery(query, now)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, file := range files {
 		l, err := parseLearningFile(file)
@@ -53,9 +57,12 @@ func collectLearnings(cwd, query string, limit int) ([]learning, error) {
 		}
 
 		// Filter by query if provided
-		if query != "" {
-			content := strings.ToLower(l.Title + " " + l.Summary)
-			if !strings.Contains(content, queryLower) {
+		if !fq.MatchText(l.Title + " " + l.Summary) {
+			continue
+		}
+		if fq.HasFilters() {
+			fields, err := queryFields(file)
+			if err != nil || !fq.Match(fields) {
 				continue
 			}
 		}
@@ -185,6 +192,10 @@ type frontMatter struct {
 	SupersededBy string
 	Utility      float64
 	HasUtility   bool
+	Maturity     string
+	Confidence   float64
+	Type         string
+	Date         time.Time
 }
 
 // parseFrontMatter extracts YAML front matter from markdown content
@@ -212,10 +223,38 @@ func parseFrontMatter(lines []string) (frontMatter, int) {
 				fm.HasUtility = true
 			}
 		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), "\"'")
+		switch key {
+		case "maturity":
+			fm.Maturity = value
+		case "confidence":
+			if c, err := strconv.ParseFloat(value, 64); err == nil {
+				fm.Confidence = c
+			}
+		case "type":
+			fm.Type = value
+		case "date", "created", "created_at":
+			fm.Date = parseFrontMatterDate(value)
+		}
 	}
 	return fm, endLine
 }
 
+// parseFrontMatterDate accepts RFC 3339 timestamps and plain YYYY-MM-DD dates.
+func parseFrontMatterDate(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
 // extractSummary finds the first paragraph after headings
 func extractSummary(lines []string, startIdx int) string {
 	for i := startIdx; i < len(lines); i++ {
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -29,8 +30,11 @@ func collectPatterns(cwd, query string, limit int) ([]pattern, error) {
 	}
 
 	var patterns []pattern
-	queryLower := strings.ToLower(query)
 	now := time.Now()
+	fq, err := search.ParseFilterQuery(query, now)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, file := range files {
 		p, err := parsePatternFile(file)
@@ -52,9 +56,12 @@ func collectPatterns(cwd, query string, limit int) ([]pattern, error) {
 		}
 
 		// Filter by query
-		if query != "" {
-			content := strings.ToLower(p.Name + " " + p.Description)
-			if !strings.Contains(content, queryLower) {
+		if !fq.MatchText(p.Name + " " + p.Description) {
+			continue
+		}
+		if fq.HasFilters() {
+			fields, err := queryFields(file)
+			if err != nil || !fq.Match(fields) {
 				continue
 			}
 		}
@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/search"
 )
 
 // collectRecentSessions finds recent session summaries
@@ -36,7 +39,10 @@ func collectRecentSessions(cwd, query string, limit int) ([]session, error) {
 	})
 
 	var sessions []session
-	queryLower := strings.ToLower(query)
+	fq, err := search.ParseFilterQuery(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
 
 	for _, file := range files {
 		if len(sessions) >= limit {
@@ -49,9 +55,15 @@ func collectRecentSessions(cwd, query string, limit int) ([]session, error) {
 		}
 
 		// Filter by query
-		if query != "" && !strings.Contains(strings.ToLower(s.Summary), queryLower) {
+		if !fq.MatchText(s.Summary) {
 			continue
 		}
+		if fq.HasFilters() {
+			fields, err := queryFields(file)
+			if err != nil || !fq.Match(fields) {
+				continue
+			}
+		}
 
 		sessions = append(sessions, s)
 	}
@@ -228,7 +228,12 @@ func computeMetrics(baseDir string, days int) (*types.FlywheelMetrics, error) {
 	metrics.TierCounts = tierCounts
 
 	// Load and filter citations
-	citations, err := ratchet.LoadCitations(baseDir)
+	opts, closeStore, err := citationOptions(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	defer closeStore()
+	citations, err := ratchet.LoadCitations(baseDir, opts...)
 	if err != nil {
 		VerbosePrintf("Warning: load citations: %v\n", err)
 	}
@@ -56,7 +56,12 @@ func runMetricsCite(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	if err := ratchet.RecordCitation(cwd, event); err != nil {
+	opts, closeStore, err := citationOptions(cwd)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	if err := ratchet.RecordCitation(cwd, event, opts...); err != nil {
 		return fmt.Errorf("record citation: %w", err)
 	}
 
@@ -73,7 +73,12 @@ func runMetricsCiteReport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	allCitations, err := ratchet.LoadCitations(baseDir)
+	opts, closeStore, err := citationOptions(baseDir)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	allCitations, err := ratchet.LoadCitations(baseDir, opts...)
 	if err != nil {
 		VerbosePrintf("Warning: load citations: %v\n", err)
 	}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -162,20 +164,36 @@ func createPlanEntry(absPath string, modTime time.Time, projectPath, name, beads
 
 // appendManifestEntry appends an entry to the manifest file
 func appendManifestEntry(manifestPath string, entry types.PlanManifestEntry) error {
-	f, err := os.OpenFile(manifestPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // write complete, close best-effort
-	}()
+	return fsutil.AppendJSONL(manifestPath, 0644, entry)
+}
 
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return err
-	}
-	_, err = f.WriteString(string(data) + "\n")
-	return err
+// registerManifestEntry replaces the manifest entry for the same plan path,
+// or appends entry if there is none, under the manifest lock.
+func registerManifestEntry(manifestPath string, entry types.PlanManifestEntry) (updated bool, err error) {
+	err = fsutil.WithLock(manifestPath, func() error {
+		existing, err := loadManifest(manifestPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("load manifest: %w", err)
+		}
+
+		// Check for existing entry to update
+		for i, e := range existing {
+			if e.Path == entry.Path {
+				existing[i] = entry
+				updated = true
+				if err := saveManifest(manifestPath, existing); err != nil {
+					return fmt.Errorf("save manifest: %w", err)
+				}
+				return nil
+			}
+		}
+
+		if err := fsutil.AppendJSONLLocked(manifestPath, 0644, entry); err != nil {
+			return fmt.Errorf("append entry: %w", err)
+		}
+		return nil
+	})
+	return updated, err
 }
 
 func runPlansRegister(cmd *cobra.Command, args []string) error {
@@ -220,25 +238,13 @@ func runPlansRegister(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("create manifest dir: %w", err)
 	}
 
-	existing, err := loadManifest(manifestPath)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("load manifest: %w", err)
-	}
-
-	// Check for existing entry to update
-	for i, e := range existing {
-		if e.Path == absPath {
-			existing[i] = entry
-			if err := saveManifest(manifestPath, existing); err != nil {
-				return fmt.Errorf("save manifest: %w", err)
-			}
-			fmt.Printf("✓ Updated plan in manifest: %s\n", absPath)
-			return nil
-		}
+	updated, err := registerManifestEntry(manifestPath, entry)
+	if err != nil {
+		return err
 	}
-
-	if err := appendManifestEntry(manifestPath, entry); err != nil {
-		return fmt.Errorf("append entry: %w", err)
+	if updated {
+		fmt.Printf("✓ Updated plan in manifest: %s\n", absPath)
+		return nil
 	}
 
 	fmt.Printf("✓ Registered plan: %s\n", name)
@@ -369,32 +375,38 @@ func runPlansUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get manifest path: %w", err)
 	}
 
-	entries, err := loadManifest(manifestPath)
-	if err != nil {
-		return fmt.Errorf("load manifest: %w", err)
-	}
+	err = fsutil.WithLock(manifestPath, func() error {
+		entries, err := loadManifest(manifestPath)
+		if err != nil {
+			return fmt.Errorf("load manifest: %w", err)
+		}
 
-	found := false
-	for i, e := range entries {
-		if e.Path == absPath {
-			if planStatus != "" {
-				entries[i].Status = types.PlanStatus(planStatus)
-			}
-			if planBeadsID != "" {
-				entries[i].BeadsID = planBeadsID
+		found := false
+		for i, e := range entries {
+			if e.Path == absPath {
+				if planStatus != "" {
+					entries[i].Status = types.PlanStatus(planStatus)
+				}
+				if planBeadsID != "" {
+					entries[i].BeadsID = planBeadsID
+				}
+				entries[i].UpdatedAt = time.Now()
+				found = true
+				break
 			}
-			entries[i].UpdatedAt = time.Now()
-			found = true
-			break
 		}
-	}
 
-	if !found {
-		return fmt.Errorf("plan not found in manifest: %s", absPath)
-	}
+		if !found {
+			return fmt.Errorf("plan not found in manifest: %s", absPath)
+		}
 
-	if err := saveManifest(manifestPath, entries); err != nil {
-		return fmt.Errorf("save manifest: %w", err)
+		if err := saveManifest(manifestPath, entries); err != nil {
+			return fmt.Errorf("save manifest: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("✓ Updated plan: %s\n", absPath)
@@ -476,27 +488,18 @@ func loadManifest(path string) ([]types.PlanManifestEntry, error) {
 	return entries, scanner.Err()
 }
 
-// saveManifest writes all entries to the manifest file.
+// saveManifest atomically replaces the manifest file with entries.
 func saveManifest(path string, entries []types.PlanManifestEntry) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // write complete, close best-effort
-	}()
-
+	var buf bytes.Buffer
 	for _, e := range entries {
 		data, err := json.Marshal(e)
 		if err != nil {
 			continue
 		}
-		if _, err := f.WriteString(string(data) + "\n"); err != nil {
-			return err
-		}
+		buf.Write(data)
+		buf.WriteByte('\n')
 	}
-
-	return nil
+	return fsutil.WriteFileAtomic(path, buf.Bytes(), 0644)
 }
 
 // detectProjectPath attempts to find the project path for a plan file.
@@ -11,6 +11,7 @@ import (
 
 	"github.com/boshu2/agentops/cli/internal/formatter"
 	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -40,20 +41,37 @@ Examples:
   ao pool list --tier=gold
   ao pool show <candidate-id>
   ao pool stage <candidate-id>
-  ao pool promote <candidate-id>`,
+  ao pool promote <candidate-id>
+  ao pool undo --last 5
+  ao pool replay`,
 }
 
 var poolListCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [query]",
 	Short: "List candidates in pools",
 	Long: `List knowledge candidates filtered by tier and/or status.
 
+An optional query uses the same syntax as 'ao search': free text and
+"phrases" match candidate content, and field filters (type:, maturity:,
+tier:, status:, tag:, utility:, confidence:, since:, until:) match candidate
+metadata. Prefix a filter or word with - to exclude it.
+
 Examples:
   ao pool list
   ao pool list --tier=gold
   ao pool list --status=pending
-  ao pool list --tier=bronze --status=staged`,
+  ao pool list --tier=bronze --status=staged
+  ao pool list 'utility:>0.6 type:learning since:7d -flaky'`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		var fq *search.FilterQuery
+		if len(args) > 0 {
+			var err error
+			if fq, err = search.ParseFilterQuery(args[0], time.Now()); err != nil {
+				return err
+			}
+		}
+
 		if GetDryRun() {
 			fmt.Printf("[dry-run] Would list pool entries")
 			if poolTier != "" {
@@ -62,6 +80,9 @@ Examples:
 			if poolStatus != "" {
 				fmt.Printf(" with status=%s", poolStatus)
 			}
+			if fq != nil {
+				fmt.Printf(" matching %q", args[0])
+			}
 			fmt.Println()
 			return nil
 		}
@@ -84,6 +105,11 @@ Examples:
 		if poolStatus != "" {
 			opts.Status = types.PoolStatus(poolStatus)
 		}
+		if fq != nil {
+			opts.Match = func(e pool.PoolEntry) bool {
+				return fq.MatchText(e.Candidate.Content+" "+e.Candidate.Context) && fq.Match(poolEntryFields(e))
+			}
+		}
 
 		result, err := p.ListPaginated(opts)
 		if err != nil {
@@ -94,6 +120,33 @@ Examples:
 	},
 }
 
+// poolEntryFields maps a pool entry onto the fields query filters match.
+// Tags come from the candidate's "tags" metadata, when present.
+func poolEntryFields(e pool.PoolEntry) search.Fields {
+	c := e.Candidate
+	fields := search.Fields{
+		Type:       string(c.Type),
+		Maturity:   string(c.Maturity),
+		Tier:       string(c.Tier),
+		Status:     string(e.Status),
+		Utility:    c.Utility,
+		Confidence: c.Confidence,
+		Date:       e.AddedAt,
+		Text:       c.Content + " " + c.Context,
+	}
+	switch tags := c.Metadata["tags"].(type) {
+	case []interface{}:
+		for _, t := range tags {
+			if s, ok := t.(string); ok {
+				fields.Tags = append(fields.Tags, s)
+			}
+		}
+	case []string:
+		fields.Tags = tags
+	}
+	return fields
+}
+
 func outputPoolList(entries []pool.PoolEntry, offset, limit, total int) error {
 	switch GetOutput() {
 	case "json":
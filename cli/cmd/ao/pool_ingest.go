@@ -61,6 +61,10 @@ func runPoolIngest(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 	p := pool.NewPool(cwd)
+	tax, err := loadTaxonomy(cwd)
+	if err != nil {
+		return err
+	}
 
 	files, err := resolveIngestFiles(cwd, poolIngestDir, args)
 	if err != nil {
@@ -90,7 +94,7 @@ func runPoolIngest(cmd *cobra.Command, args []string) error {
 
 		fileHadError := false
 		for _, b := range blocks {
-			cand, scoring, ok := buildCandidateFromLearningBlock(b, f, fileDate, sessionHint)
+			cand, scoring, ok := buildCandidateFromLearningBlock(tax, b, f, fileDate, sessionHint)
 			if !ok {
 				res.SkippedMalformed++
 				continue
@@ -305,7 +309,7 @@ func parsePendingFileHeader(md, path string) (fileDate time.Time, sessionHint st
 	return fileDate, sessionHint
 }
 
-func buildCandidateFromLearningBlock(b learningBlock, srcPath string, fileDate time.Time, sessionHint string) (types.Candidate, types.Scoring, bool) {
+func buildCandidateFromLearningBlock(tax *taxonomy.Taxonomy, b learningBlock, srcPath string, fileDate time.Time, sessionHint string) (types.Candidate, types.Scoring, bool) {
 	if strings.TrimSpace(b.Title) == "" || strings.TrimSpace(b.Body) == "" {
 		return types.Candidate{}, types.Scoring{}, false
 	}
@@ -326,27 +330,10 @@ func buildCandidateFromLearningBlock(b learningBlock, srcPath string, fileDate t
 
 	confDim := confidenceToScore(b.Confidence)
 	rubric := computeRubricScores(b.Body, confDim)
-	weighted := rubricWeightedSum(rubric, taxonomy.DefaultRubricWeights)
-	raw := (taxonomy.GetBaseScore(types.KnowledgeTypeLearning) + weighted) / 2.0
+	raw := scorePendingLearning(tax, types.KnowledgeTypeLearning, rubric, b.Confidence)
 
-	// Pending learnings already reflect some human/LLM filtering (they were written intentionally),
-	// so bias score upwards based on the declared confidence to reduce false "bronze" assignments.
-	switch strings.ToLower(strings.TrimSpace(b.Confidence)) {
-	case "high":
-		raw += 0.15
-	case "medium":
-		raw += 0.07
-	}
-
-	if raw > 1.0 {
-		raw = 1.0
-	}
-	if raw < 0.0 {
-		raw = 0.0
-	}
-
-	tier := taxonomy.AssignTier(raw, taxonomy.DefaultTierConfigs)
-	gateRequired := taxonomy.RequiresHumanGate(tier, taxonomy.DefaultTierConfigs)
+	tier := tax.AssignTier(raw)
+	gateRequired := tax.RequiresHumanGate(tier)
 
 	cand := types.Candidate{
 		ID:         id,
@@ -365,7 +352,7 @@ func buildCandidateFromLearningBlock(b learningBlock, srcPath string, fileDate t
 		ExpiryStatus:  types.ExpiryStatusActive,
 		Utility:       types.InitialUtility,
 		Maturity:      types.MaturityProvisional,
-		Confidence:    taxonomy.GetConfidence(tier, taxonomy.DefaultTierConfigs),
+		Confidence:    tax.Confidence(tier),
 		LastDecayAt:   fileDate,
 		DecayCount:    0,
 		HelpfulCount:  0,
@@ -470,12 +457,27 @@ func computeRubricScores(body string, confidence float64) types.RubricScores {
 	}
 }
 
-func rubricWeightedSum(r types.RubricScores, w taxonomy.RubricWeights) float64 {
-	return r.Specificity*w.Specificity +
-		r.Actionability*w.Actionability +
-		r.Novelty*w.Novelty +
-		r.Context*w.Context +
-		r.Confidence*w.Confidence
+// scorePendingLearning returns the composite score of a pending learning
+// with the given rubric and declared confidence (high, medium, low).
+func scorePendingLearning(tax *taxonomy.Taxonomy, kt types.KnowledgeType, rubric types.RubricScores, declaredConfidence string) float64 {
+	raw := tax.Score(kt, rubric)
+
+	// Pending learnings already reflect some human/LLM filtering (they were written intentionally),
+	// so bias score upwards based on the declared confidence to reduce false "bronze" assignments.
+	switch strings.ToLower(strings.TrimSpace(declaredConfidence)) {
+	case "high":
+		raw += 0.15
+	case "medium":
+		raw += 0.07
+	}
+
+	if raw > 1.0 {
+		raw = 1.0
+	}
+	if raw < 0.0 {
+		raw = 0.0
+	}
+	return raw
 }
 
 func slugify(s string) string {
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+var poolRescoreStatus string
+
+var poolRescoreCmd = &cobra.Command{
+	Use:   "rescore",
+	Short: "Rescore pool entries with the current taxonomy",
+	Long: `Recompute the score, tier, confidence and human-gate requirement of pool
+entries from their stored rubric scores, using the rubric weights, tier
+thresholds and base scores of the current taxonomy (see 'ao config
+taxonomy').
+
+Pending and staged entries are rescored unless --status selects one
+status. Entries keep their status; a staged entry that drops to discard
+is reported but not moved. Entries scored without a rubric are skipped.
+Each change is recorded in the chain and can be undone with 'ao pool
+undo'.
+
+Examples:
+  ao pool rescore --dry-run
+  ao pool rescore
+  ao pool rescore --status pending -o json`,
+	RunE: runPoolRescore,
+}
+
+func init() {
+	poolCmd.AddCommand(poolRescoreCmd)
+	poolRescoreCmd.Flags().StringVar(&poolRescoreStatus, "status", "", "Only rescore entries with this status (pending, staged)")
+}
+
+// rescoreChange is one entry whose scoring changed.
+type rescoreChange struct {
+	ID        string           `json:"id"`
+	Status    types.PoolStatus `json:"status"`
+	OldScore  float64          `json:"old_score"`
+	NewScore  float64          `json:"new_score"`
+	OldTier   types.Tier       `json:"old_tier"`
+	NewTier   types.Tier       `json:"new_tier"`
+	GateAdded bool             `json:"gate_added,omitempty"`
+}
+
+// rescoreResult is the output of ao pool rescore.
+type rescoreResult struct {
+	Scanned   int             `json:"scanned"`
+	Unchanged int             `json:"unchanged"`
+	Skipped   int             `json:"skipped_no_rubric"`
+	Changes   []rescoreChange `json:"changes"`
+}
+
+func runPoolRescore(cmd *cobra.Command, args []string) error {
+	statuses := []types.PoolStatus{types.PoolStatusPending, types.PoolStatusStaged}
+	switch types.PoolStatus(poolRescoreStatus) {
+	case "":
+	case types.PoolStatusPending, types.PoolStatusStaged:
+		statuses = []types.PoolStatus{types.PoolStatus(poolRescoreStatus)}
+	default:
+		return fmt.Errorf("invalid --status %q (expected pending or staged)", poolRescoreStatus)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	tax, err := loadTaxonomy(cwd)
+	if err != nil {
+		return err
+	}
+	p := pool.NewPool(cwd)
+
+	var entries []pool.PoolEntry
+	for _, status := range statuses {
+		list, err := p.List(pool.ListOptions{Status: status})
+		if err != nil {
+			return fmt.Errorf("list pool: %w", err)
+		}
+		entries = append(entries, list...)
+	}
+
+	res := rescoreResult{Scanned: len(entries)}
+	for _, entry := range entries {
+		scoring, ok := rescoreEntry(tax, entry.PoolEntry)
+		if !ok {
+			res.Skipped++
+			continue
+		}
+		old := entry.ScoringResult
+		if scoring.TierAssignment == old.TierAssignment && scoring.RawScore == old.RawScore && scoring.GateRequired == old.GateRequired {
+			res.Unchanged++
+			continue
+		}
+		if !GetDryRun() {
+			if err := p.Rescore(entry.Candidate.ID, scoring, tax.Confidence(scoring.TierAssignment)); err != nil {
+				return fmt.Errorf("rescore %s: %w", entry.Candidate.ID, err)
+			}
+		}
+		res.Changes = append(res.Changes, rescoreChange{
+			ID:        entry.Candidate.ID,
+			Status:    entry.Status,
+			OldScore:  old.RawScore,
+			NewScore:  scoring.RawScore,
+			OldTier:   old.TierAssignment,
+			NewTier:   scoring.TierAssignment,
+			GateAdded: scoring.GateRequired && !old.GateRequired,
+		})
+	}
+
+	if GetOutput() == "json" {
+		data, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+	printRescoreResult(os.Stdout, res, GetDryRun())
+	return nil
+}
+
+// rescoreEntry recomputes an entry's scoring from its stored rubric. It
+// reports false for entries scored without a rubric.
+func rescoreEntry(tax *taxonomy.Taxonomy, entry types.PoolEntry) (types.Scoring, bool) {
+	rubric := entry.ScoringResult.Rubric
+	if rubric == (types.RubricScores{}) {
+		return types.Scoring{}, false
+	}
+
+	declared, _ := entry.Candidate.Metadata["pending_confidence"].(string)
+	raw := scorePendingLearning(tax, entry.Candidate.Type, rubric, declared)
+	tier := tax.AssignTier(raw)
+	return types.Scoring{
+		RawScore:       raw,
+		TierAssignment: tier,
+		Rubric:         rubric,
+		GateRequired:   tax.RequiresHumanGate(tier),
+		ScoredAt:       time.Now(),
+	}, true
+}
+
+func printRescoreResult(w io.Writer, res rescoreResult, dryRun bool) {
+	verb := "Rescored"
+	if dryRun {
+		verb = "[dry-run] Would rescore"
+	}
+	fmt.Fprintf(w, "%s %d of %d entries (%d unchanged, %d without a rubric)\n", verb, len(res.Changes), res.Scanned, res.Unchanged, res.Skipped)
+	for _, c := range res.Changes {
+		note := ""
+		if c.GateAdded {
+			note = "  (now needs human review)"
+		}
+		if c.Status == types.PoolStatusStaged && c.NewTier == types.TierDiscard {
+			note += "  (staged but now discard)"
+		}
+		fmt.Fprintf(w, "  %-40s %-7s %.2f → %.2f  %s → %s%s\n", truncateID(c.ID, 40), c.Status, c.OldScore, c.NewScore, c.OldTier, c.NewTier, note)
+	}
+}
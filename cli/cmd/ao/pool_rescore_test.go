@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/taxonomy"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestRescoreEntry(t *testing.T) {
+	rubric := types.RubricScores{Specificity: 1, Actionability: 0.6, Novelty: 0.5, Context: 0.5, Confidence: 0.7}
+	entry := types.PoolEntry{
+		Candidate: types.Candidate{
+			Type:     types.KnowledgeTypeLearning,
+			Metadata: map[string]interface{}{"pending_confidence": "medium"},
+		},
+		ScoringResult: types.Scoring{Rubric: rubric},
+	}
+
+	def, ok := rescoreEntry(taxonomy.Default(), entry)
+	if !ok {
+		t.Fatal("entry with a rubric should be rescored")
+	}
+	if want := scorePendingLearning(taxonomy.Default(), types.KnowledgeTypeLearning, rubric, "medium"); def.RawScore != want {
+		t.Errorf("RawScore = %v, want %v", def.RawScore, want)
+	}
+
+	strict := taxonomy.Default()
+	strict.Weights = taxonomy.RubricWeights{Specificity: 0.6, Actionability: 0.1, Novelty: 0.1, Context: 0.1, Confidence: 0.1}
+	if got, _ := rescoreEntry(strict, entry); got.RawScore <= def.RawScore {
+		t.Errorf("specificity-heavy weights should raise the score: %v <= %v", got.RawScore, def.RawScore)
+	}
+
+	entry.ScoringResult.Rubric = types.RubricScores{}
+	if _, ok := rescoreEntry(taxonomy.Default(), entry); ok {
+		t.Error("entry without a rubric should be skipped")
+	}
+}
+
+func TestPoolRescoreAppliesTaxonomy(t *testing.T) {
+	cwd := chdirTemp(t)
+	p := pool.NewPool(cwd)
+	rubric := types.RubricScores{Specificity: 0.8, Actionability: 0.8, Novelty: 0.8, Context: 0.8, Confidence: 0.8}
+	raw := scorePendingLearning(taxonomy.Default(), types.KnowledgeTypeLearning, rubric, "")
+	c := types.Candidate{ID: "c1", Type: types.KnowledgeTypeLearning, Tier: taxonomy.Default().AssignTier(raw), RawScore: raw, Content: "c1"}
+	if err := p.Add(c, types.Scoring{RawScore: raw, TierAssignment: c.Tier, Rubric: rubric}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Tier != types.TierSilver {
+		t.Fatalf("fixture should start silver, got %s", c.Tier)
+	}
+
+	// Raise the silver threshold above the candidate's score
+	config := filepath.Join(cwd, storage.DefaultBaseDir, TaxonomyConfigFile)
+	if err := os.MkdirAll(filepath.Dir(config), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config, []byte("version: 1\ntiers:\n  silver: {min_score: 0.8}\n  bronze: {human_gate: false}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runPoolRescore(poolRescoreCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	e, err := p.Get("c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Candidate.Tier != types.TierBronze || e.ScoringResult.TierAssignment != types.TierBronze || e.ScoringResult.GateRequired {
+		t.Errorf("rescored entry = %+v / %+v", e.Candidate.Tier, e.ScoringResult)
+	}
+
+	// The rescore is in the chain, so replay still matches and undo reverts it
+	report, err := p.Replay("")
+	if err != nil || len(report.Drift) != 0 {
+		t.Errorf("replay after rescore: %+v %v", report, err)
+	}
+	undoable, _ := p.Undoable()
+	if len(undoable) == 0 || undoable[0].Operation != pool.OpRescore {
+		t.Fatalf("newest undoable = %+v", undoable)
+	}
+	if _, err := p.Undo(undoable[0].ID, "tester"); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := p.Get("c1"); e.Candidate.Tier != types.TierSilver {
+		t.Errorf("undo rescore: tier = %s", e.Candidate.Tier)
+	}
+}
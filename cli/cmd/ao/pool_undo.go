@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+)
+
+var (
+	poolUndoLast  int
+	poolUndoTo    string
+	poolReplayOut string
+)
+
+var poolUndoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo recent pool operations",
+	Long: `Reverse recent pool operations using the chain log.
+
+Every add, stage, approve, reject, rescore and promote records the entry
+before and after the change, so it can be reversed: the entry is restored
+to its previous state and directory, and a promoted artifact is deleted.
+Undos are recorded in the chain too. Operations are undone newest first.
+
+Operations recorded before the chain carried entry snapshots cannot be
+undone.
+
+Examples:
+  ao pool undo                                  # the last operation
+  ao pool undo --last 20                        # the last 20 operations
+  ao pool undo --to 2026-02-01T09:00:00Z        # everything after that time
+  ao pool undo --to 2026-02-01T09:00:00Z --dry-run`,
+	RunE: runPoolUndo,
+}
+
+var poolReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Rebuild the pool from the chain log and report drift",
+	Long: `Rebuild the pool purely from the chain log and compare it with the pool
+on disk. Entries that are missing, unexpected, in the wrong directory or
+edited outside ao are reported as drift, and the command fails if any is
+found.
+
+With --out, the rebuilt pool is written to that directory in the usual
+pending/staged/rejected layout.
+
+Examples:
+  ao pool replay
+  ao pool replay --out /tmp/pool-rebuilt
+  ao pool replay -o json`,
+	RunE: runPoolReplay,
+}
+
+func init() {
+	poolCmd.AddCommand(poolUndoCmd)
+	poolCmd.AddCommand(poolReplayCmd)
+
+	poolUndoCmd.Flags().IntVar(&poolUndoLast, "last", 0, "Undo the last N operations (default 1)")
+	poolUndoCmd.Flags().StringVar(&poolUndoTo, "to", "", "Undo every operation after this RFC 3339 timestamp")
+	poolReplayCmd.Flags().StringVar(&poolReplayOut, "out", "", "Write the rebuilt pool to this directory")
+}
+
+func runPoolUndo(cmd *cobra.Command, args []string) error {
+	if poolUndoLast != 0 && poolUndoTo != "" {
+		return fmt.Errorf("--last and --to are mutually exclusive")
+	}
+	if poolUndoLast < 0 {
+		return fmt.Errorf("--last must be positive")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	p := pool.NewPool(cwd)
+
+	undoable, err := p.Undoable()
+	if err != nil {
+		return fmt.Errorf("read chain: %w", err)
+	}
+	events, err := selectUndoEvents(undoable, poolUndoLast, poolUndoTo)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		fmt.Println("Nothing to undo")
+		return nil
+	}
+
+	if GetDryRun() {
+		fmt.Printf("[dry-run] Would undo %d operation(s):\n", len(events))
+		printUndoEvents(os.Stdout, events)
+		return nil
+	}
+
+	reviewer := GetCurrentUser()
+	for i, event := range events {
+		if _, err := p.Undo(event.ID, reviewer); err != nil {
+			if i > 0 {
+				fmt.Printf("Undid %d operation(s):\n", i)
+				printUndoEvents(os.Stdout, events[:i])
+			}
+			return fmt.Errorf("undo: %w", err)
+		}
+	}
+	fmt.Printf("Undid %d operation(s):\n", len(events))
+	printUndoEvents(os.Stdout, events)
+	return nil
+}
+
+// selectUndoEvents picks, from undoable events (newest first), the last n
+// or those after the to timestamp. With neither, it picks the newest one.
+func selectUndoEvents(undoable []pool.ChainEvent, n int, to string) ([]pool.ChainEvent, error) {
+	if to != "" {
+		cutoff, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --to timestamp %q: %w", to, err)
+		}
+		var events []pool.ChainEvent
+		for _, e := range undoable {
+			if e.Timestamp.After(cutoff) {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	}
+	if n == 0 {
+		n = 1
+	}
+	return undoable[:min(n, len(undoable))], nil
+}
+
+func printUndoEvents(w io.Writer, events []pool.ChainEvent) {
+	for _, e := range events {
+		fmt.Fprintf(w, "  %s  %-8s %s\n", e.Timestamp.Format(time.RFC3339), e.Operation, e.CandidateID)
+	}
+}
+
+func runPoolReplay(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	report, err := pool.NewPool(cwd).Replay(poolReplayOut)
+	if err != nil {
+		return fmt.Errorf("replay chain: %w", err)
+	}
+
+	if GetOutput() == "json" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printReplayReport(os.Stdout, report)
+	}
+	if len(report.Drift) > 0 {
+		return fmt.Errorf("pool has drifted from the chain: %d difference(s)", len(report.Drift))
+	}
+	return nil
+}
+
+func printReplayReport(w io.Writer, report *pool.ReplayReport) {
+	fmt.Fprintf(w, "Replayed %d event(s): %d entries expected in the pool\n", report.Events, report.Entries)
+	if report.Unreplayable > 0 {
+		fmt.Fprintf(w, "%d event(s) predate chain snapshots; their candidates were not checked\n", report.Unreplayable)
+	}
+	if len(report.Drift) == 0 {
+		fmt.Fprintln(w, "No drift: pool matches the chain")
+		return
+	}
+	fmt.Fprintf(w, "\nDrift (%d):\n", len(report.Drift))
+	for _, d := range report.Drift {
+		expected, actual := string(d.Expected), string(d.Actual)
+		if expected == "" {
+			expected = "absent"
+		}
+		if actual == "" {
+			actual = "absent"
+		}
+		fmt.Fprintf(w, "  %-32s %-16s chain: %-9s disk: %s\n", d.CandidateID, d.Detail, expected, actual)
+	}
+}
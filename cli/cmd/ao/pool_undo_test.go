@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+)
+
+func TestSelectUndoEvents(t *testing.T) {
+	base := time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)
+	// Newest first, as returned by Pool.Undoable
+	undoable := []pool.ChainEvent{
+		{ID: "e3", Timestamp: base.Add(2 * time.Hour)},
+		{ID: "e2", Timestamp: base.Add(time.Hour)},
+		{ID: "e1", Timestamp: base},
+	}
+	tests := []struct {
+		name string
+		n    int
+		to   string
+		want int
+	}{
+		{"default newest", 0, "", 1},
+		{"last 2", 2, "", 2},
+		{"last beyond chain", 10, "", 3},
+		{"after timestamp", 0, "2026-02-01T09:30:00Z", 2},
+		{"after everything", 0, "2026-02-02T00:00:00Z", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectUndoEvents(undoable, tt.n, tt.to)
+			if err != nil || len(got) != tt.want {
+				t.Errorf("got %d events, %v; want %d", len(got), err, tt.want)
+			}
+		})
+	}
+	if _, err := selectUndoEvents(undoable, 0, "yesterday"); err == nil {
+		t.Error("expected error for invalid timestamp")
+	}
+}
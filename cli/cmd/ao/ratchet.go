@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/boshu2/agentops/cli/internal/ratchet"
@@ -20,6 +23,8 @@ Inspection:
   next (n)      Show next pending RPI step
   spec          Get current spec path
   validate      Validate step requirements
+  verify        Verify chain integrity
+  workflows     List available workflows
 
 Progression:
   record        Record step completion
@@ -34,7 +39,11 @@ Management:
   migrate            Migrate legacy chain format
   migrate-artifacts  Add schema_version to artifacts
 
-The ratchet chain is stored in .agents/ao/chain.jsonl`,
+The ratchet chain is stored in .agents/ao/chain.jsonl.
+
+Steps come from a workflow. The built-in RPI workflow is used unless
+--workflow names another, defined in .agents/ao/workflows/<name>.yaml
+and tracked in .agents/ao/chains/<name>.jsonl. See 'ao ratchet workflows'.`,
 }
 
 // Ratchet command flags (shared across subcommands)
@@ -51,6 +60,8 @@ var (
 	ratchetLenientDays int
 	ratchetCycle       int
 	ratchetParentEpic  string
+	ratchetWorkflow    string
+	ratchetNeeds       []string
 )
 
 // ratchetStepInfo holds step information for status output.
@@ -63,15 +74,17 @@ type ratchetStepInfo struct {
 	Location    string             `json:"location,omitempty"`
 	Cycle       int                `json:"cycle,omitempty"`
 	ParentEpic  string             `json:"parent_epic,omitempty"`
+	Needs       []ratchet.Step     `json:"needs,omitempty"`
 }
 
 // ratchetStatusOutput holds the full status output structure.
 type ratchetStatusOutput struct {
-	ChainID string            `json:"chain_id"`
-	Started string            `json:"started"`
-	EpicID  string            `json:"epic_id,omitempty"`
-	Steps   []ratchetStepInfo `json:"steps"`
-	Path    string            `json:"path"`
+	ChainID  string            `json:"chain_id"`
+	Started  string            `json:"started"`
+	EpicID   string            `json:"epic_id,omitempty"`
+	Workflow string            `json:"workflow,omitempty"`
+	Steps    []ratchetStepInfo `json:"steps"`
+	Path     string            `json:"path"`
 }
 
 func init() {
@@ -81,9 +94,41 @@ func init() {
 		&cobra.Group{ID: "search", Title: "Search & Trace:"},
 		&cobra.Group{ID: "management", Title: "Management:"},
 	)
+	ratchetCmd.PersistentFlags().StringVar(&ratchetWorkflow, "workflow", "", "Workflow to use (default: rpi, or .agents/ao/workflows/<name>.yaml)")
 	rootCmd.AddCommand(ratchetCmd)
 }
 
+// loadRatchetWorkflow loads the --workflow workflow and its chain.
+func loadRatchetWorkflow(cwd string) (*ratchet.Workflow, *ratchet.Chain, error) {
+	w, err := ratchet.LoadWorkflow(cwd, ratchetWorkflow)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load workflow: %w", err)
+	}
+	chain, err := ratchet.LoadWorkflowChain(cwd, w)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load chain: %w", err)
+	}
+	return w, chain, nil
+}
+
+// resolveWorkflowStep returns the step of w named by name or an alias.
+func resolveWorkflowStep(w *ratchet.Workflow, name string) (*ratchet.WorkflowStep, error) {
+	step := w.Step(name)
+	if step == nil {
+		return nil, fmt.Errorf("unknown step: %s (workflow %s has %s)", name, w.Name, joinSteps(w.StepNames()))
+	}
+	return step, nil
+}
+
+// joinSteps formats step names as a comma-separated list.
+func joinSteps(steps []ratchet.Step) string {
+	names := make([]string, len(steps))
+	for i, s := range steps {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
 func statusIcon(status ratchet.StepStatus) string {
 	switch status {
 	case ratchet.StatusLocked:
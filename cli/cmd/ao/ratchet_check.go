@@ -23,10 +23,15 @@ Returns exit code 0 if gate passes, 1 if not.
 Steps: research, pre-mortem, plan, implement, crank, vibe, post-mortem
 Aliases: premortem, postmortem, autopilot, validate, review
 
+With --workflow, steps come from that workflow. A step without a built-in
+gate passes once the steps it needs are locked or skipped and its inputs
+exist.
+
 Examples:
   ao ratchet check research
   ao ratchet check plan
-  ao ratchet check implement || echo "Run /plan first"`,
+  ao ratchet check implement || echo "Run /plan first"
+  ao ratchet check mitigate --workflow incident`,
 		Args: cobra.ExactArgs(1),
 		RunE: runRatchetCheck,
 	}
@@ -35,23 +40,26 @@ Examples:
 
 // runRatchetCheck validates a step gate.
 func runRatchetCheck(cmd *cobra.Command, args []string) error {
-	stepName := args[0]
-	step := ratchet.ParseStep(stepName)
-	if step == "" {
-		return fmt.Errorf("unknown step: %s", stepName)
-	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
+	wf, chain, err := loadRatchetWorkflow(cwd)
+	if err != nil {
+		return err
+	}
+	step, err := resolveWorkflowStep(wf, args[0])
+	if err != nil {
+		return err
+	}
+
 	checker, err := ratchet.NewGateChecker(cwd)
 	if err != nil {
 		return fmt.Errorf("create gate checker: %w", err)
 	}
 
-	result, err := checker.Check(step)
+	result, err := checker.CheckStep(wf, step, chain)
 	if err != nil {
 		return fmt.Errorf("check gate: %w", err)
 	}
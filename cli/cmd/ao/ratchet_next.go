@@ -19,17 +19,16 @@ type NextResult struct {
 	LastArtifact string `json:"last_artifact" yaml:"last_artifact"`
 	Skill        string `json:"skill" yaml:"skill"`
 	Complete     bool   `json:"complete" yaml:"complete"`
+
+	// Ready lists every step that can run now, Next first. More than one
+	// means the steps can run in parallel.
+	Ready []ReadyStep `json:"ready,omitempty" yaml:"ready,omitempty"`
 }
 
-// stepSkillMap maps ratchet steps to their corresponding skill commands.
-var stepSkillMap = map[string]string{
-	"research":    "/research",
-	"pre-mortem":  "/pre-mortem",
-	"plan":        "/plan",
-	"implement":   "/implement or /crank",
-	"crank":       "/implement or /crank",
-	"vibe":        "/vibe",
-	"post-mortem": "/post-mortem",
+// ReadyStep is an unblocked step in a NextResult.
+type ReadyStep struct {
+	Step  string `json:"step" yaml:"step"`
+	Skill string `json:"skill" yaml:"skill"`
 }
 
 func init() {
@@ -43,10 +42,16 @@ func init() {
 Returns structured output indicating what to do next based on the current
 ratchet chain state. Returns "complete" if all steps are locked.
 
+A step is unblocked once every step it needs is locked or skipped. When
+several are, all are listed (ready) and can run in parallel; next is the
+first. With --workflow, the named workflow and its chain are used instead
+of RPI.
+
 Examples:
   ao ratchet next
   ao ratchet next -o json
-  ao ratchet next --epic ol-0001`,
+  ao ratchet next --epic ol-0001
+  ao ratchet next --workflow incident`,
 		RunE: runRatchetNext,
 	}
 	ratchetNextCmd.Flags().StringVar(&ratchetEpicID, "epic", "", "Filter by epic ID")
@@ -60,9 +65,9 @@ func runRatchetNext(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	chain, err := ratchet.LoadChain(cwd)
+	w, chain, err := loadRatchetWorkflow(cwd)
 	if err != nil {
-		return fmt.Errorf("load chain: %w", err)
+		return err
 	}
 
 	// Filter by epic if requested
@@ -70,115 +75,52 @@ func runRatchetNext(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no chain found for epic %s", ratchetEpicID)
 	}
 
-	result := computeNextStep(chain)
+	result := computeWorkflowNextStep(w, chain)
 	return outputNextResult(&result)
 }
 
-// computeNextStep analyzes the chain and determines the next step.
+// computeNextStep determines the next step of the default RPI workflow.
 func computeNextStep(chain *ratchet.Chain) NextResult {
-	allSteps := ratchet.AllSteps()
-
-	// If chain is empty, start at the beginning
-	if len(chain.Entries) == 0 {
-		return NextResult{
-			Next:     string(allSteps[0]),
-			Reason:   "no steps completed yet",
-			Skill:    stepSkillMap[string(allSteps[0])],
-			Complete: false,
-		}
-	}
+	return computeWorkflowNextStep(ratchet.DefaultWorkflow(), chain)
+}
 
+// computeWorkflowNextStep analyzes the chain and determines the next step
+// of workflow w.
+func computeWorkflowNextStep(w *ratchet.Workflow, chain *ratchet.Chain) NextResult {
 	// Find the last locked or skipped step
-	var lastStep ratchet.Step
 	var lastEntry *ratchet.ChainEntry
-
 	for i := len(chain.Entries) - 1; i >= 0; i-- {
 		entry := &chain.Entries[i]
 		if entry.Locked || entry.Skipped {
-			lastStep = entry.Step
 			lastEntry = entry
 			break
 		}
 	}
 
-	// If no locked/skipped steps found, start at the beginning
-	if lastEntry == nil {
-		return NextResult{
-			Next:     string(allSteps[0]),
-			Reason:   "no steps locked yet",
-			Skill:    stepSkillMap[string(allSteps[0])],
-			Complete: false,
-		}
-	}
-
-	// Find position of last step in AllSteps
-	var lastStepIndex = -1
-	for i, step := range allSteps {
-		if step == lastStep {
-			lastStepIndex = i
-			break
-		}
-	}
-
-	// Check if we're complete (last locked step is post-mortem)
-	if lastStep == ratchet.StepPostMortem {
-		return NextResult{
-			Next:         "",
-			Reason:       "all steps completed",
-			LastStep:     string(lastStep),
-			LastArtifact: lastEntry.Output,
-			Skill:        "",
-			Complete:     true,
-		}
-	}
-
-	// Handle implement/crank special case
-	// Either implement or crank satisfies that position in the chain
-	// If we just completed implement, next is vibe (skip crank)
-	// If we just completed crank, next is also vibe
-	if lastStep == ratchet.StepImplement || lastStep == ratchet.StepCrank {
-		// Next step after implement/crank is vibe
-		return NextResult{
-			Next:         string(ratchet.StepVibe),
-			Reason:       fmt.Sprintf("%s locked", lastStep),
-			LastStep:     string(lastStep),
-			LastArtifact: lastEntry.Output,
-			Skill:        stepSkillMap[string(ratchet.StepVibe)],
-			Complete:     false,
-		}
+	var result NextResult
+	switch {
+	case len(chain.Entries) == 0:
+		result.Reason = "no steps completed yet"
+	case lastEntry == nil:
+		result.Reason = "no steps locked yet"
+	default:
+		result.LastStep = string(lastEntry.Step)
+		result.LastArtifact = lastEntry.Output
+		result.Reason = fmt.Sprintf("%s locked", lastEntry.Step)
 	}
 
-	// For all other cases, next step is the one after the last locked step
-	if lastStepIndex >= 0 && lastStepIndex < len(allSteps)-1 {
-		nextStep := allSteps[lastStepIndex+1]
-
-		// Skip crank if we just completed plan (go straight to implement)
-		// Actually, according to AllSteps order, after plan comes implement, then crank
-		// But the requirement says either implement or crank satisfies that position
-		// So we should suggest implement first
-		if lastStep == ratchet.StepPlan {
-			nextStep = ratchet.StepImplement
-		}
-
-		return NextResult{
-			Next:         string(nextStep),
-			Reason:       fmt.Sprintf("%s locked", lastStep),
-			LastStep:     string(lastStep),
-			LastArtifact: lastEntry.Output,
-			Skill:        stepSkillMap[string(nextStep)],
-			Complete:     false,
-		}
+	ready := w.Ready(chain)
+	if len(ready) == 0 {
+		result.Reason = "all steps completed"
+		result.Complete = true
+		return result
 	}
-
-	// Shouldn't reach here, but handle gracefully
-	return NextResult{
-		Next:         "",
-		Reason:       "unexpected state",
-		LastStep:     string(lastStep),
-		LastArtifact: lastEntry.Output,
-		Skill:        "",
-		Complete:     false,
+	result.Next = string(ready[0].Name)
+	result.Skill = ready[0].Skill
+	for _, s := range ready {
+		result.Ready = append(result.Ready, ReadyStep{Step: string(s.Name), Skill: s.Skill})
 	}
+	return result
 }
 
 // outputNextResult formats and outputs the result based on output format.
@@ -210,6 +152,12 @@ func outputNextResult(result *NextResult) error {
 		if result.Skill != "" {
 			fmt.Printf("Suggested skill: %s\n", result.Skill)
 		}
+		if len(result.Ready) > 1 {
+			fmt.Println("\nReady in parallel:")
+			for _, s := range result.Ready {
+				fmt.Printf("  %-15s %s\n", s.Step, s.Skill)
+			}
+		}
 		if result.LastStep != "" {
 			fmt.Printf("\nLast step: %s\n", result.LastStep)
 		}
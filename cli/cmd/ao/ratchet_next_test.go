@@ -322,3 +322,59 @@ func TestComputeNextStep(t *testing.T) {
 		})
 	}
 }
+
+func TestComputeWorkflowNextStep(t *testing.T) {
+	w, err := ratchet.ParseWorkflow([]byte(`name: incident
+steps:
+  - name: triage
+    skill: /research
+  - name: mitigate
+    skill: /implement
+  - name: retro
+    skill: /post-mortem
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain := &ratchet.Chain{Workflow: "incident", Entries: []ratchet.ChainEntry{
+		{Step: "triage", Timestamp: time.Now(), Output: ".agents/incidents/db.md", Locked: true},
+	}}
+	result := computeWorkflowNextStep(w, chain)
+	if result.Next != "mitigate" || result.Skill != "/implement" || result.LastStep != "triage" {
+		t.Errorf("result = %+v", result)
+	}
+
+	chain.Entries = append(chain.Entries, ratchet.ChainEntry{Step: "retro", Timestamp: time.Now(), Locked: true})
+	if result := computeWorkflowNextStep(w, chain); !result.Complete || result.Skill != "" {
+		t.Errorf("locking the last step should complete the workflow: %+v", result)
+	}
+}
+
+func TestComputeWorkflowNextStep_Parallel(t *testing.T) {
+	w, err := ratchet.ParseWorkflow([]byte(`name: swarm
+steps:
+  - name: research
+    skill: /research
+  - name: pre-mortem
+    skill: /pre-mortem
+    needs: []
+  - name: plan
+    skill: /plan
+    needs: [research, pre-mortem]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := computeWorkflowNextStep(w, &ratchet.Chain{})
+	if result.Next != "research" || len(result.Ready) != 2 || result.Ready[1].Step != "pre-mortem" || result.Ready[1].Skill != "/pre-mortem" {
+		t.Errorf("result = %+v, want research and pre-mortem ready", result)
+	}
+
+	// crank is an alternative to implement, not a parallel step
+	plan := &ratchet.Chain{Entries: []ratchet.ChainEntry{{Step: ratchet.StepPlan, Timestamp: time.Now(), Locked: true}}}
+	if result := computeNextStep(plan); len(result.Ready) != 1 || result.Ready[0].Step != "implement" {
+		t.Errorf("after plan, ready = %+v", result.Ready)
+	}
+}
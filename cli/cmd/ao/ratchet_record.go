@@ -19,10 +19,17 @@ func init() {
 
 This locks progress - the ratchet engages.
 
+The entry records the steps it built on (the workflow's dependencies, or
+--needs), so steps run in parallel show up as branches of the chain.
+An empty --needs= records a step that depended on nothing.
+
 Examples:
   ao ratchet record research --output .agents/research/topic.md
   ao ratchet record plan --input .agents/specs/spec-v2.md --output epic:ol-0001
-  ao ratchet record implement --output issue:ol-0002 --tier 1`,
+  ao ratchet record implement --output issue:ol-0002 --tier 1
+  ao ratchet record pre-mortem --output .agents/specs/x-v2.md
+  ao ratchet record plan --output epic:ol-0001 --needs research,pre-mortem
+  ao ratchet record triage --output .agents/incidents/db.md --workflow incident`,
 		Args: cobra.ExactArgs(1),
 		RunE: runRatchetRecord,
 	}
@@ -32,36 +39,42 @@ Examples:
 	recordSubCmd.Flags().BoolVar(&ratchetLock, "lock", true, "Lock the step (engage ratchet)")
 	recordSubCmd.Flags().IntVar(&ratchetCycle, "cycle", 0, "RPI cycle number (1 for first, 2+ for iterations)")
 	recordSubCmd.Flags().StringVar(&ratchetParentEpic, "parent-epic", "", "Parent epic ID from prior RPI cycle")
+	recordSubCmd.Flags().StringSliceVar(&ratchetNeeds, "needs", nil, "Steps this one built on (default: the workflow's dependencies)")
 	_ = recordSubCmd.MarkFlagRequired("output") //nolint:errcheck
 	ratchetCmd.AddCommand(recordSubCmd)
 }
 
 // runRatchetRecord records step completion.
 func runRatchetRecord(cmd *cobra.Command, args []string) error {
-	stepName := args[0]
-	step := ratchet.ParseStep(stepName)
-	if step == "" {
-		return fmt.Errorf("unknown step: %s", stepName)
-	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
+	wf, chain, err := loadRatchetWorkflow(cwd)
+	if err != nil {
+		return err
+	}
+	ws, err := resolveWorkflowStep(wf, args[0])
+	if err != nil {
+		return err
+	}
+	step := ws.Name
+
+	needs, err := resolveRecordNeeds(wf, ws, cmd.Flags().Changed("needs"))
+	if err != nil {
+		return err
+	}
+
 	if GetDryRun() {
 		fmt.Printf("Would record step: %s\n", step)
 		fmt.Printf("  Input: %s\n", ratchetInput)
 		fmt.Printf("  Output: %s\n", ratchetOutput)
 		fmt.Printf("  Locked: %v\n", ratchetLock)
+		fmt.Printf("  Needs: %s\n", joinSteps(needs))
 		return nil
 	}
 
-	chain, err := ratchet.LoadChain(cwd)
-	if err != nil {
-		return fmt.Errorf("load chain: %w", err)
-	}
-
 	entry := ratchet.ChainEntry{
 		Step:       step,
 		Timestamp:  time.Now(),
@@ -70,6 +83,7 @@ func runRatchetRecord(cmd *cobra.Command, args []string) error {
 		Locked:     ratchetLock,
 		Cycle:      ratchetCycle,
 		ParentEpic: ratchetParentEpic,
+		Needs:      needs,
 	}
 
 	if ratchetTier >= 0 && ratchetTier <= 4 {
@@ -88,3 +102,23 @@ func runRatchetRecord(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// resolveRecordNeeds returns the dependencies to record for step: the
+// --needs steps if given, otherwise the workflow's.
+func resolveRecordNeeds(wf *ratchet.Workflow, step *ratchet.WorkflowStep, override bool) ([]ratchet.Step, error) {
+	if !override {
+		return step.Needs, nil
+	}
+	needs := []ratchet.Step{}
+	for _, name := range ratchetNeeds {
+		dep, err := resolveWorkflowStep(wf, name)
+		if err != nil {
+			return nil, fmt.Errorf("--needs: %w", err)
+		}
+		if dep.Name == step.Name {
+			return nil, fmt.Errorf("--needs: %s cannot depend on itself", step.Name)
+		}
+		needs = append(needs, dep.Name)
+	}
+	return needs, nil
+}
@@ -32,28 +32,27 @@ Examples:
 
 // runRatchetSkip records an intentional skip.
 func runRatchetSkip(cmd *cobra.Command, args []string) error {
-	stepName := args[0]
-	step := ratchet.ParseStep(stepName)
-	if step == "" {
-		return fmt.Errorf("unknown step: %s", stepName)
-	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
+	wf, chain, err := loadRatchetWorkflow(cwd)
+	if err != nil {
+		return err
+	}
+	ws, err := resolveWorkflowStep(wf, args[0])
+	if err != nil {
+		return err
+	}
+	step := ws.Name
+
 	if GetDryRun() {
 		fmt.Printf("Would skip step: %s\n", step)
 		fmt.Printf("  Reason: %s\n", ratchetReason)
 		return nil
 	}
 
-	chain, err := ratchet.LoadChain(cwd)
-	if err != nil {
-		return fmt.Errorf("load chain: %w", err)
-	}
-
 	entry := ratchet.ChainEntry{
 		Step:      step,
 		Timestamp: time.Now(),
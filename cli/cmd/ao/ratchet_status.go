@@ -22,11 +22,13 @@ func init() {
 		Long: `Display the current state of the ratchet chain.
 
 Shows all steps and their status (pending, in_progress, locked, skipped).
+A step counts as locked once a step that depends on it is locked.
 
 Examples:
   ao ratchet status
   ao ratchet status --epic ol-0001
-  ao ratchet status -o json`,
+  ao ratchet status -o json
+  ao ratchet status --workflow incident`,
 		RunE: runRatchetStatus,
 	}
 	statusSubCmd.Flags().StringVar(&ratchetEpicID, "epic", "", "Filter by epic ID")
@@ -41,27 +43,30 @@ func runRatchetStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	chain, err := ratchet.LoadChain(cwd)
+	wf, chain, err := loadRatchetWorkflow(cwd)
 	if err != nil {
-		return fmt.Errorf("load chain: %w", err)
+		return err
 	}
 
 	// Get status for all steps
-	allStatus := chain.GetAllStatus()
+	allStatus := wf.Status(chain)
 
 	// Build output structure
 	output := ratchetStatusOutput{
-		ChainID: chain.ID,
-		Started: chain.Started.Format(time.RFC3339),
-		EpicID:  chain.EpicID,
-		Path:    chain.Path(),
-		Steps:   make([]ratchetStepInfo, 0),
+		ChainID:  chain.ID,
+		Started:  chain.Started.Format(time.RFC3339),
+		EpicID:   chain.EpicID,
+		Workflow: wf.Name,
+		Path:     chain.Path(),
+		Steps:    make([]ratchetStepInfo, 0),
 	}
 
-	for _, step := range ratchet.AllSteps() {
+	needs := wf.Needs(chain)
+	for _, step := range wf.StepNames() {
 		info := ratchetStepInfo{
 			Step:   step,
 			Status: allStatus[step],
+			Needs:  needs[step],
 		}
 
 		// Get details from latest entry
@@ -97,6 +102,9 @@ func outputRatchetStatus(w io.Writer, data *ratchetStatusOutput) error {
 		fmt.Fprintln(w, "====================")
 		fmt.Fprintf(w, "Chain: %s\n", data.ChainID)
 		fmt.Fprintf(w, "Started: %s\n", data.Started)
+		if data.Workflow != "" && data.Workflow != ratchet.DefaultWorkflowName {
+			fmt.Fprintf(w, "Workflow: %s\n", data.Workflow)
+		}
 		if data.EpicID != "" {
 			fmt.Fprintf(w, "Epic: %s\n", data.EpicID)
 		}
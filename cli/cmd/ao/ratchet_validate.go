@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -19,7 +18,29 @@ func init() {
 		Short:   "Validate step requirements",
 		Long: `Validate that an artifact meets quality requirements.
 
-Checks for required sections, formatting, and tier criteria.
+Checks for required sections, formatting, and tier criteria. Workflow
+steps are checked with their validator and rules; without --changes the
+first existing match for the step's outputs is validated.
+
+Rules are declared per step in a workflow file (copy the built-in rpi
+workflow to .agents/ao/workflows/rpi.yaml to tighten the RPI steps):
+
+  rules:
+    severity: error             # default for the rules below; or warning
+    sections:
+      - "## Summary"
+      - {any: ["## Goal", "## Objective"], message: "State the goal"}
+    frontmatter:                # types: string, int, number, bool, date, list
+      - schema_version
+      - {name: status, type: string, values: [draft, final]}
+      - {name: reviewed, type: date, required: false}
+    citations: {min: 3}         # URLs, markdown and [[wiki]] links, or a pattern
+    patterns:
+      - {regex: "TODO|TBD", forbid: true, severity: warning}
+    words: {min: 200, max: 3000}
+
+Errors make the artifact invalid; warnings lower its tier. Each finding
+names its line where there is one (-o json lists them under findings).
 
 Legacy artifacts without schema_version can use --lenient mode (expires in 90 days by default).
 Default mode is STRICT (requires explicit --lenient flag).
@@ -40,17 +61,20 @@ Examples:
 
 // runRatchetValidate validates step requirements.
 func runRatchetValidate(cmd *cobra.Command, args []string) error {
-	stepName := args[0]
-	step := ratchet.ParseStep(stepName)
-	if step == "" {
-		return fmt.Errorf("unknown step: %s", stepName)
-	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
+	wf, err := ratchet.LoadWorkflow(cwd, ratchetWorkflow)
+	if err != nil {
+		return fmt.Errorf("load workflow: %w", err)
+	}
+	step, err := resolveWorkflowStep(wf, args[0])
+	if err != nil {
+		return err
+	}
+
 	validator, err := ratchet.NewValidator(cwd)
 	if err != nil {
 		return fmt.Errorf("create validator: %w", err)
@@ -65,7 +89,7 @@ func runRatchetValidate(cmd *cobra.Command, args []string) error {
 
 	allValid := true
 	for _, file := range files {
-		result, err := validator.ValidateWithOptions(step, file, opts)
+		result, err := validator.ValidateWorkflowStep(step, file, opts)
 		if err != nil {
 			return fmt.Errorf("validate %s: %w", file, err)
 		}
@@ -88,19 +112,21 @@ func runRatchetValidate(cmd *cobra.Command, args []string) error {
 
 // resolveValidationFiles determines which files to validate.
 // Uses explicit --changes files if provided, otherwise locates expected output.
-func resolveValidationFiles(cwd string, step ratchet.Step) []string {
+func resolveValidationFiles(cwd string, step *ratchet.WorkflowStep) []string {
 	if len(ratchetFiles) > 0 {
 		return ratchetFiles
 	}
 
-	locator, _ := ratchet.NewLocator(cwd)
-	pattern := ratchet.GetExpectedOutput(step)
-	if strings.HasPrefix(pattern, "epic:") || strings.HasPrefix(pattern, "issue:") {
+	locator, err := ratchet.NewLocator(cwd)
+	if err != nil {
 		return nil
 	}
-
-	if path, _, err := locator.FindFirst(pattern); err == nil {
-		return []string{path}
+	for _, output := range step.Outputs {
+		for _, pattern := range ratchet.ArtifactPatterns(output) {
+			if path, _, err := locator.FindFirst(pattern); err == nil {
+				return []string{path}
+			}
+		}
 	}
 	return nil
 }
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+)
+
+var errRatchetVerificationFailed = errors.New("ratchet chain verification failed")
+
+var (
+	ratchetVerifyPubKey        string
+	ratchetVerifyRequireSigned bool
+)
+
+func init() {
+	verifySubCmd := &cobra.Command{
+		Use:     "verify",
+		GroupID: "inspection",
+		Short:   "Verify chain integrity",
+		Long: `Verify that the ratchet chain has not been tampered with.
+
+Each entry records the hash of the entry before it, so an edited entry
+fails its own hash, and a removed, inserted or reordered entry breaks the
+link of the entry after it. The first hashed entry also covers the chain's
+metadata line. The first bad entry is reported with its line in the chain
+file.
+
+Entries are signed when a key was created with 'ao init --signing-key'.
+Signatures are checked against .agents/ao/keys/ratchet_ed25519.pub, or
+the key given with --pubkey. Entries recorded before hashing was added
+are reported as unsealed, and entries recorded before the key existed
+may be unsigned; every entry after the first signed one must be signed.
+With --require-signed, every entry must carry a hash and a signature.
+
+Entries removed from the end leave no broken link; compare the printed
+head hash with one recorded earlier to detect them.
+
+Examples:
+  ao ratchet verify
+  ao ratchet verify --require-signed
+  ao ratchet verify --pubkey ci/ratchet_ed25519.pub -o json`,
+		RunE: runRatchetVerify,
+	}
+	verifySubCmd.Flags().StringVar(&ratchetVerifyPubKey, "pubkey", "", "Public key to check signatures against")
+	verifySubCmd.Flags().BoolVar(&ratchetVerifyRequireSigned, "require-signed", false, "Fail on entries without a hash or signature")
+	ratchetCmd.AddCommand(verifySubCmd)
+}
+
+type ratchetVerifyOutput struct {
+	Status string `json:"status"`
+	Path   string `json:"path"`
+	*ratchet.VerifyResult
+}
+
+// runRatchetVerify checks the hash links and signatures of the chain.
+func runRatchetVerify(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	_, chain, err := loadRatchetWorkflow(cwd)
+	if err != nil {
+		return err
+	}
+
+	opts := ratchet.VerifyOptions{RequireSigned: ratchetVerifyRequireSigned}
+	pubPath := ratchetVerifyPubKey
+	if pubPath == "" && chain.KeyDir() != "" {
+		pubPath = filepath.Join(chain.KeyDir(), ratchet.PublicKeyFile)
+		if _, err := os.Stat(pubPath); err != nil {
+			pubPath = ""
+		}
+	}
+	if pubPath != "" {
+		if opts.PublicKey, err = ratchet.LoadPublicKey(pubPath); err != nil {
+			return fmt.Errorf("load public key: %w", err)
+		}
+	}
+
+	// No chain yet is an empty, intact chain
+	result := &ratchet.VerifyResult{Pass: true, FirstBrokenIndex: -1, SignaturesChecked: opts.PublicKey != nil}
+	if chain.Path() != "" {
+		verified, err := ratchet.VerifyChainFile(chain.Path(), opts)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("verify chain: %w", err)
+		}
+		if err == nil {
+			result = verified
+		}
+	}
+
+	status := "FAIL"
+	if result.Pass {
+		status = "PASS"
+	}
+
+	w := cmd.OutOrStdout()
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(ratchetVerifyOutput{Status: status, Path: chain.Path(), VerifyResult: result}); err != nil {
+			return fmt.Errorf("encode JSON output: %w", err)
+		}
+	} else {
+		printRatchetVerify(w, result)
+	}
+
+	if !result.Pass {
+		return errRatchetVerificationFailed
+	}
+	return nil
+}
+
+func printRatchetVerify(w io.Writer, r *ratchet.VerifyResult) {
+	if !r.Pass {
+		fmt.Fprintf(w, "FAIL entries=%d first_broken_index=%d line=%d message=%s\n",
+			r.EntryCount, r.FirstBrokenIndex, r.FirstBrokenLine, r.Message)
+		return
+	}
+	signatures := "unchecked"
+	if r.SignaturesChecked {
+		signatures = "checked"
+	}
+	fmt.Fprintf(w, "PASS entries=%d signed=%d signatures=%s\n", r.EntryCount, r.Signed, signatures)
+	if r.Unsealed > 0 {
+		fmt.Fprintf(w, "Warning: %d entries predate hashing and are not covered\n", r.Unsealed)
+	}
+	if r.Head != "" {
+		fmt.Fprintf(w, "head=%s\n", r.Head)
+	}
+}
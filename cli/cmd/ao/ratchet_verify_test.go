@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+)
+
+func TestRatchetVerify_DetectsEdit(t *testing.T) {
+	cwd := chdirTempDir(t)
+	if _, _, err := ratchet.GenerateSigningKey(filepath.Join(cwd, ".agents", "ao", ratchet.KeyDir)); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := ratchet.LoadChain(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []ratchet.Step{ratchet.StepResearch, ratchet.StepPlan} {
+		if err := chain.Append(ratchet.ChainEntry{Step: step, Timestamp: time.Now(), Output: string(step) + ".md", Locked: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldOutput := output
+	output = "table"
+	t.Cleanup(func() { output = oldOutput })
+
+	run := func() (string, error) {
+		var buf bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.SetOut(&buf)
+		err := runRatchetVerify(cmd, nil)
+		return buf.String(), err
+	}
+
+	out, err := run()
+	if err != nil {
+		t.Fatalf("verify intact chain: %v", err)
+	}
+	if !strings.Contains(out, "PASS entries=2 signed=2 signatures=checked") {
+		t.Errorf("output = %q", out)
+	}
+
+	data, err := os.ReadFile(chain.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), "research.md", "other.md", 1)
+	if err := os.WriteFile(chain.Path(), []byte(tampered), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = run()
+	if !errors.Is(err, errRatchetVerificationFailed) {
+		t.Fatalf("err = %v, want verification failure", err)
+	}
+	if !strings.Contains(out, "first_broken_index=1 line=2") {
+		t.Errorf("output = %q", out)
+	}
+}
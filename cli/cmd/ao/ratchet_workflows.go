@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+)
+
+func init() {
+	workflowsSubCmd := &cobra.Command{
+		Use:     "workflows",
+		GroupID: "inspection",
+		Short:   "List available workflows",
+		Long: `List the built-in RPI workflow and those defined in .agents/ao/workflows.
+
+A workflow is a YAML file naming its steps, their dependencies, and the
+gate and validator for each:
+
+  name: incident
+  description: Incident response
+  steps:
+    - name: triage
+      skill: /research
+      outputs: [incidents/*.md]
+      rules:                    # see 'ao ratchet validate --help'
+        sections: ["## Impact"]
+    - name: mitigate            # needs the step before it by default
+    - name: retro
+      needs: [mitigate]
+      validator: post-mortem    # built-in: research, pre-mortem, plan, post-mortem
+      outputs: [retros/*.md]
+
+A step may set gate to one of the RPI steps to use its built-in gate, and
+satisfies to stand in for another step. Select a workflow with --workflow
+on any ratchet command; its chain is kept in .agents/ao/chains/<name>.jsonl.
+
+Examples:
+  ao ratchet workflows
+  ao ratchet workflows -o json`,
+		RunE: runRatchetWorkflows,
+	}
+	ratchetCmd.AddCommand(workflowsSubCmd)
+}
+
+// runRatchetWorkflows lists the available workflows.
+func runRatchetWorkflows(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+
+	workflows, err := ratchet.ListWorkflows(cwd)
+	if err != nil {
+		return fmt.Errorf("list workflows: %w", err)
+	}
+
+	w := cmd.OutOrStdout()
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(workflows)
+	}
+	printWorkflows(w, workflows)
+	return nil
+}
+
+func printWorkflows(w io.Writer, workflows []*ratchet.Workflow) {
+	for i, wf := range workflows {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		source := wf.Source
+		if source == "" {
+			source = "built-in"
+		}
+		fmt.Fprintf(w, "%s (%s)\n", wf.Name, source)
+		if wf.Description != "" {
+			fmt.Fprintf(w, "  %s\n", wf.Description)
+		}
+		for _, s := range wf.Steps {
+			var deps []string
+			if len(s.Needs) > 0 {
+				deps = append(deps, "needs "+joinSteps(s.Needs))
+			}
+			if s.Satisfies != "" {
+				deps = append(deps, "satisfies "+string(s.Satisfies))
+			}
+			fmt.Fprintf(w, "  %-15s %s\n", s.Name, strings.Join(deps, "; "))
+		}
+	}
+}
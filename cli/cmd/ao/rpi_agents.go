@@ -0,0 +1,590 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/config"
+)
+
+// defaultAgent runs phases through the claude executors (direct, ntm or
+// stream) unless the configuration redefines it.
+const defaultAgent = "claude"
+
+// Agent progress modes (rpi.agents.<name>.progress).
+const (
+	agentProgressLines      = "lines"
+	agentProgressStreamJSON = "stream-json"
+	agentProgressNone       = "none"
+)
+
+// builtinAgents are the agents ao can drive without configuration.
+var builtinAgents = map[string]config.AgentConfig{
+	"claude": {
+		Command:  []string{"claude", "-p", "{{.Prompt}}", "--output-format", "stream-json", "--verbose"},
+		Progress: agentProgressStreamJSON,
+	},
+	"codex": {
+		Command: []string{"codex", "exec", "--full-auto", "{{.Prompt}}"},
+	},
+	"aider": {
+		Command: []string{"aider", "--yes-always", "--no-pretty", "--message-file", "{{.PromptFile}}"},
+	},
+}
+
+// agentBackend describes how to drive a coding agent for one phase: the
+// command that launches a prompt, how its output reports progress, and
+// what marks the phase as completed.
+type agentBackend struct {
+	Name     string
+	Source   string // "built-in" or "config"
+	Command  []string
+	Stdin    bool
+	Progress string
+	Done     *regexp.Regexp
+
+	args []*template.Template
+}
+
+// agentInvocation is the template data for an agent command.
+type agentInvocation struct {
+	Prompt     string
+	PromptFile string
+	Cwd        string
+	RunID      string
+	Phase      int
+	PhaseName  string
+}
+
+// newAgentBackend validates an agent definition.
+func newAgentBackend(name, source string, ac config.AgentConfig) (*agentBackend, error) {
+	if len(ac.Command) == 0 {
+		return nil, fmt.Errorf("agent %s: command is empty", name)
+	}
+	b := &agentBackend{Name: name, Source: source, Command: ac.Command, Stdin: ac.Stdin, Progress: ac.Progress}
+	switch b.Progress {
+	case "":
+		b.Progress = agentProgressLines
+	case agentProgressLines, agentProgressStreamJSON, agentProgressNone:
+	default:
+		return nil, fmt.Errorf("agent %s: unknown progress %q (valid: lines, stream-json, none)", name, ac.Progress)
+	}
+	if ac.Done != "" {
+		re, err := regexp.Compile(ac.Done)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: done: %w", name, err)
+		}
+		b.Done = re
+	}
+	for i, arg := range ac.Command {
+		tmpl, err := template.New(fmt.Sprintf("%s[%d]", name, i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: command: %w", name, err)
+		}
+		b.args = append(b.args, tmpl)
+	}
+	return b, nil
+}
+
+// needsPromptFile reports whether the command refers to .PromptFile.
+func (b *agentBackend) needsPromptFile() bool {
+	for _, arg := range b.Command {
+		if strings.Contains(arg, ".PromptFile") {
+			return true
+		}
+	}
+	return false
+}
+
+// argv expands the command templates for one invocation.
+func (b *agentBackend) argv(inv agentInvocation) ([]string, error) {
+	args := make([]string, len(b.args))
+	for i, tmpl := range b.args {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, inv); err != nil {
+			return nil, fmt.Errorf("agent %s: expand command: %w", b.Name, err)
+		}
+		args[i] = buf.String()
+	}
+	return args, nil
+}
+
+// lookupAgent resolves an agent name against the configuration and the
+// built-in agents.
+func lookupAgent(name string, cfg config.RPIConfig) (*agentBackend, error) {
+	if ac, ok := cfg.Agents[name]; ok {
+		return newAgentBackend(name, "config", ac)
+	}
+	if ac, ok := builtinAgents[name]; ok {
+		return newAgentBackend(name, "built-in", ac)
+	}
+	return nil, fmt.Errorf("unknown agent %q (available: %s)", name, strings.Join(agentNames(cfg), ", "))
+}
+
+// agentNames lists the built-in and configured agent names.
+func agentNames(cfg config.RPIConfig) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for name := range builtinAgents {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for name := range cfg.Agents {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadRPIConfig returns the rpi section of the configuration.
+func loadRPIConfig() (config.RPIConfig, error) {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return config.RPIConfig{}, fmt.Errorf("load config: %w", err)
+	}
+	return cfg.RPI, nil
+}
+
+// phaseAgentName returns the agent for phase p. Flags win over the
+// configuration, and a per-phase setting over the default for all phases.
+func phaseAgentName(p phase, opts phasedEngineOptions, cfg config.RPIConfig) string {
+	for _, name := range []string{
+		opts.PhaseAgents[p.Name],
+		opts.Agent,
+		cfg.PhaseAgents[p.Name],
+		cfg.Agent,
+	} {
+		if name != "" {
+			return name
+		}
+	}
+	return defaultAgent
+}
+
+// resolvePhaseAgents returns the agent backend of each phase by number. A
+// nil backend means the phase runs through the claude executors.
+func resolvePhaseAgents(opts phasedEngineOptions, cfg config.RPIConfig) (map[int]*agentBackend, error) {
+	var err error
+	if opts.PhaseAgents, err = normalizePhaseAgents(opts.PhaseAgents); err != nil {
+		return nil, fmt.Errorf("--phase-agent: %w", err)
+	}
+	if cfg.PhaseAgents, err = normalizePhaseAgents(cfg.PhaseAgents); err != nil {
+		return nil, fmt.Errorf("rpi.phase_agents: %w", err)
+	}
+
+	agents := make(map[int]*agentBackend)
+	for _, p := range phases {
+		name := phaseAgentName(p, opts, cfg)
+		if _, custom := cfg.Agents[name]; name == defaultAgent && !custom {
+			agents[p.Num] = nil
+			continue
+		}
+		b, err := lookupAgent(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("phase %s: %w", p.Name, err)
+		}
+		agents[p.Num] = b
+	}
+	return agents, nil
+}
+
+// checkPhaseAgentsAvailable verifies that every phase's agent binary is on
+// PATH before any session is spawned.
+func checkPhaseAgentsAvailable(agents map[int]*agentBackend) error {
+	for _, p := range phases {
+		b := agents[p.Num]
+		if b == nil {
+			if _, err := exec.LookPath("claude"); err != nil {
+				return fmt.Errorf("claude CLI not found on PATH (required for spawning phase sessions)")
+			}
+			continue
+		}
+		if bin := b.Command[0]; !strings.Contains(bin, "{{") {
+			if _, err := exec.LookPath(bin); err != nil {
+				return fmt.Errorf("agent %s for phase %s: %s not found on PATH", b.Name, p.Name, bin)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizePhaseAgents keys phase agents by canonical phase name,
+// accepting the same aliases as --from.
+func normalizePhaseAgents(values map[string]string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(values))
+	for name, agent := range values {
+		num := phaseNameToNum(name)
+		if num == 0 {
+			return nil, fmt.Errorf("unknown phase %q (valid: discovery, implementation, validation)", name)
+		}
+		out[phases[num-1].Name] = agent
+	}
+	return out, nil
+}
+
+// withPhaseAgents wraps base, the claude executor, so that phases with
+// another agent run through an agentExecutor.
+func withPhaseAgents(base PhaseExecutor, agents map[int]*agentBackend, statusPath string, allPhases []PhaseProgress, opts phasedEngineOptions) PhaseExecutor {
+	byPhase := make(map[int]PhaseExecutor)
+	for num, b := range agents {
+		if b != nil {
+			byPhase[num] = &agentExecutor{
+				ctx:                opts.Ctx,
+				backend:            b,
+				statusPath:         statusPath,
+				allPhases:          allPhases,
+				phaseTimeout:       opts.PhaseTimeout,
+				stallTimeout:       opts.StallTimeout,
+				stallCheckInterval: opts.StallCheckInterval,
+			}
+		}
+	}
+	if len(byPhase) == 0 {
+		return base
+	}
+	return &phaseAgentExecutor{byPhase: byPhase, fallback: base}
+}
+
+// phaseAgentExecutor runs each phase with the executor chosen for it.
+type phaseAgentExecutor struct {
+	byPhase  map[int]PhaseExecutor
+	fallback PhaseExecutor
+}
+
+func (m *phaseAgentExecutor) forPhase(phaseNum int) PhaseExecutor {
+	if e, ok := m.byPhase[phaseNum]; ok {
+		return e
+	}
+	return m.fallback
+}
+
+// Name returns the single backend name when all phases share one, and
+// phase=backend pairs otherwise.
+func (m *phaseAgentExecutor) Name() string {
+	names := make([]string, len(phases))
+	same := true
+	for i, p := range phases {
+		names[i] = m.forPhase(p.Num).Name()
+		same = same && names[i] == names[0]
+	}
+	if same {
+		return names[0]
+	}
+	for i, p := range phases {
+		names[i] = p.Name + "=" + names[i]
+	}
+	return strings.Join(names, ",")
+}
+
+func (m *phaseAgentExecutor) Execute(prompt, cwd, runID string, phaseNum int) error {
+	return m.forPhase(phaseNum).Execute(prompt, cwd, runID, phaseNum)
+}
+
+// spawnLabel names what a phase is spawned with, for progress output.
+func spawnLabel(executor PhaseExecutor, phaseNum int) string {
+	if m, ok := executor.(*phaseAgentExecutor); ok {
+		executor = m.forPhase(phaseNum)
+	}
+	switch e := executor.(type) {
+	case *agentExecutor:
+		return e.backend.Name
+	case *replayExecutor:
+		return e.Name()
+	}
+	return "claude -p"
+}
+
+// agentExecutor runs a phase through an agent command template.
+type agentExecutor struct {
+	ctx                context.Context
+	backend            *agentBackend
+	statusPath         string
+	allPhases          []PhaseProgress
+	phaseTimeout       time.Duration
+	stallTimeout       time.Duration
+	stallCheckInterval time.Duration
+}
+
+func (a *agentExecutor) Name() string { return a.backend.Name }
+
+// Execute runs the agent until it exits. Output lines (or stream events)
+// count as activity for stall detection and update the live status; with
+// a done pattern the output must match it for the phase to succeed.
+func (a *agentExecutor) Execute(prompt, cwd, runID string, phaseNum int) error {
+	b := a.backend
+	inv := agentInvocation{Prompt: prompt, Cwd: cwd, RunID: runID, Phase: phaseNum}
+	if phaseNum >= 1 && phaseNum <= len(phases) {
+		inv.PhaseName = phases[phaseNum-1].Name
+	} else if phaseNum == resolvePhaseNum {
+		inv.PhaseName = resolvePhaseName
+	}
+	if b.needsPromptFile() {
+		f, err := os.CreateTemp("", "ao-rpi-prompt-*.md")
+		if err != nil {
+			return fmt.Errorf("write prompt file: %w", err)
+		}
+		defer os.Remove(f.Name()) //nolint:errcheck // temp file cleanup
+		_, werr := f.WriteString(prompt)
+		if cerr := f.Close(); werr == nil {
+			werr = cerr
+		}
+		if werr != nil {
+			return fmt.Errorf("write prompt file: %w", werr)
+		}
+		inv.PromptFile = f.Name()
+	}
+	args, err := b.argv(inv)
+	if err != nil {
+		return err
+	}
+
+	ctx := runContext(a.ctx)
+	cancel := func() {}
+	if a.phaseTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, a.phaseTimeout)
+	}
+	defer cancel()
+
+	var lastActivityUnix atomic.Int64
+	lastActivityUnix.Store(time.Now().UnixNano())
+	stallCtx, stallCancel := context.WithCancelCause(ctx)
+	defer stallCancel(nil)
+	if a.stallTimeout > 0 && b.Progress != agentProgressNone {
+		go watchPhaseStall(stallCtx, stallCancel, &lastActivityUnix, a.stallTimeout, a.stallCheckInterval, "output")
+	}
+
+	cmd := exec.CommandContext(stallCtx, args[0], args[1:]...)
+	cmd.Dir = cwd
+	cmd.Stderr = os.Stderr
+	cmd.Env = cleanEnvNoClaude()
+	if b.Stdin {
+		cmd.Stdin = strings.NewReader(prompt)
+	}
+	// Output goes through an io.Pipe rather than StdoutPipe so that
+	// WaitDelay ends the read even if a child of the agent keeps stdout open.
+	stdout, stdoutW := io.Pipe()
+	cmd.Stdout = stdoutW
+	cmd.WaitDelay = agentWaitDelay
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", b.Name, err)
+	}
+	waitCh := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		stdoutW.Close() //nolint:errcheck // unblocks the reader
+		waitCh <- err
+	}()
+
+	phaseIdx := phaseNum - 1
+	onUpdate := func(p PhaseProgress) {
+		lastActivityUnix.Store(time.Now().UnixNano())
+		if len(a.allPhases) == 0 {
+			return
+		}
+		mergePhaseProgress(a.allPhases, phaseIdx, p)
+		if writeErr := WriteLiveStatus(a.statusPath, a.allPhases, phaseIdx); writeErr != nil {
+			VerbosePrintf("Warning: could not write live status: %v\n", writeErr)
+		}
+	}
+
+	var done atomic.Bool
+	watch := &lineWriter{onLine: func(line string) {
+		if b.Done != nil && b.Done.MatchString(line) {
+			done.Store(true)
+		}
+		if b.Progress == agentProgressLines && strings.TrimSpace(line) != "" {
+			onUpdate(PhaseProgress{CurrentAction: summarizeStatusAction(line), LastUpdate: time.Now()})
+		}
+	}}
+
+	var readErr error
+	switch b.Progress {
+	case agentProgressStreamJSON:
+		_, readErr = ParseStreamEvents(io.TeeReader(stdout, watch), onUpdate)
+	default:
+		_, readErr = io.Copy(io.MultiWriter(os.Stdout, watch), stdout)
+	}
+	watch.Flush()
+	io.Copy(io.Discard, stdout) //nolint:errcheck // drain so the command can exit
+	waitErr := <-waitCh
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("phase %d (%s) timed out after %s (set --phase-timeout to increase)", phaseNum, failReasonTimeout, a.phaseTimeout)
+	}
+	if cause := context.Cause(stallCtx); cause != nil && stallCtx.Err() != nil && ctx.Err() == nil {
+		return fmt.Errorf("phase %d (%s): %w", phaseNum, failReasonStall, cause)
+	}
+	if waitErr != nil {
+		if exitErr, ok := waitErr.(*exec.ExitError); ok {
+			return fmt.Errorf("%s exited with code %d (%s): %w", b.Name, exitErr.ExitCode(), failReasonExit, waitErr)
+		}
+		return fmt.Errorf("%s execution failed (%s): %w", b.Name, failReasonUnknown, waitErr)
+	}
+	if readErr != nil {
+		return fmt.Errorf("read %s output: %w", b.Name, readErr)
+	}
+	if b.Done != nil && !done.Load() {
+		return fmt.Errorf("phase %d (%s): %s exited without printing its completion marker %q", phaseNum, failReasonExit, b.Name, b.Done.String())
+	}
+	return nil
+}
+
+// agentWaitDelay bounds how long an agent's output is read after it exits
+// or is killed. Package-level for testability.
+var agentWaitDelay = 5 * time.Second
+
+// lineWriter calls onLine for each complete line written to it.
+type lineWriter struct {
+	onLine func(string)
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(string(bytes.TrimRight(w.buf[:i], "\r")))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush passes on a final line without a trailing newline.
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.onLine(string(w.buf))
+		w.buf = nil
+	}
+}
+
+func init() {
+	agentsCmd := &cobra.Command{
+		Use:   "agents",
+		Short: "List coding agents available to phased runs",
+		Long: `List the coding agents that can run RPI phases and which phase uses which.
+
+Built-in agents are claude, codex and aider. Others are defined in
+.agentops/config.yaml as command templates:
+
+  rpi:
+    agent: claude                  # default for every phase
+    phase_agents:
+      discovery: local
+      implementation: codex
+    agents:
+      local:
+        command: [my-agent, run, --cwd, "{{.Cwd}}", "{{.Prompt}}"]
+        progress: lines            # lines (default), stream-json or none
+        done: "^TASK COMPLETE"     # optional completion marker
+        stdin: false               # true sends the prompt on stdin
+
+Templates can use .Prompt, .PromptFile, .Cwd, .RunID, .Phase and
+.PhaseName. The --agent and --phase-agent flags of ao rpi phased
+override the configuration.
+
+Examples:
+  ao rpi agents
+  ao rpi agents -o json`,
+		Args: cobra.NoArgs,
+		RunE: runRPIAgents,
+	}
+	rpiCmd.AddCommand(agentsCmd)
+}
+
+// rpiAgentInfo is one row of ao rpi agents.
+type rpiAgentInfo struct {
+	Name      string   `json:"name"`
+	Source    string   `json:"source"`
+	Command   []string `json:"command,omitempty"`
+	Progress  string   `json:"progress,omitempty"`
+	Available bool     `json:"available"`
+	Phases    []string `json:"phases,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func runRPIAgents(cmd *cobra.Command, args []string) error {
+	cfg, err := loadRPIConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.PhaseAgents, err = normalizePhaseAgents(cfg.PhaseAgents); err != nil {
+		return fmt.Errorf("rpi.phase_agents: %w", err)
+	}
+	phaseAgents := make(map[string][]string)
+	for _, p := range phases {
+		name := phaseAgentName(p, phasedEngineOptions{}, cfg)
+		phaseAgents[name] = append(phaseAgents[name], p.Name)
+	}
+
+	var infos []rpiAgentInfo
+	for _, name := range agentNames(cfg) {
+		info := rpiAgentInfo{Name: name, Phases: phaseAgents[name]}
+		b, err := lookupAgent(name, cfg)
+		if err != nil {
+			info.Source = "config"
+			info.Error = err.Error()
+			infos = append(infos, info)
+			continue
+		}
+		info.Source, info.Command, info.Progress = b.Source, b.Command, b.Progress
+		if _, err := exec.LookPath(b.Command[0]); err == nil {
+			info.Available = true
+		}
+		infos = append(infos, info)
+	}
+	used := make([]string, 0, len(phaseAgents))
+	for name := range phaseAgents {
+		used = append(used, name)
+	}
+	sort.Strings(used)
+	for _, name := range used {
+		if _, err := lookupAgent(name, cfg); err != nil {
+			infos = append(infos, rpiAgentInfo{Name: name, Phases: phaseAgents[name], Error: err.Error()})
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+	for _, info := range infos {
+		status := "available"
+		switch {
+		case info.Error != "":
+			status = "error: " + info.Error
+		case !info.Available:
+			status = "not on PATH"
+		}
+		fmt.Fprintf(w, "%-10s %-9s %s\n", info.Name, info.Source, status)
+		if len(info.Command) > 0 {
+			fmt.Fprintf(w, "  command: %s\n", strings.Join(info.Command, " "))
+		}
+		if len(info.Phases) > 0 {
+			fmt.Fprintf(w, "  phases:  %s\n", strings.Join(info.Phases, ", "))
+		}
+	}
+	return nil
+}
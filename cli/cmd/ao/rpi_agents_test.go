@@ -0,0 +1,210 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/config"
+)
+
+func shAgent(t *testing.T, script string, ac config.AgentConfig) *agentBackend {
+	t.Helper()
+	ac.Command = []string{"sh", "-c", script, "agent"}
+	b, err := newAgentBackend("fake", "config", ac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestResolvePhaseAgents(t *testing.T) {
+	cfg := config.RPIConfig{
+		Agent:       "aider",
+		PhaseAgents: map[string]string{"crank": "local"},
+		Agents:      map[string]config.AgentConfig{"local": {Command: []string{"local-agent", "{{.Prompt}}"}}},
+	}
+	opts := phasedEngineOptions{PhaseAgents: map[string]string{"research": "claude"}}
+
+	agents, err := resolvePhaseAgents(opts, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agents[1] != nil {
+		t.Errorf("discovery should use the claude executors, got %+v", agents[1])
+	}
+	if agents[2] == nil || agents[2].Name != "local" || agents[2].Source != "config" {
+		t.Errorf("implementation agent = %+v, want local from config", agents[2])
+	}
+	if agents[3] == nil || agents[3].Name != "aider" || agents[3].Source != "built-in" {
+		t.Errorf("validation agent = %+v, want the aider default", agents[3])
+	}
+
+	// --agent overrides the configured per-phase agents
+	agents, err = resolvePhaseAgents(phasedEngineOptions{Agent: "codex"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for num, b := range agents {
+		if b == nil || b.Name != "codex" {
+			t.Errorf("phase %d agent = %+v, want codex", num, b)
+		}
+	}
+
+	if _, err := resolvePhaseAgents(phasedEngineOptions{Agent: "gpt-cli"}, cfg); err == nil || !strings.Contains(err.Error(), "unknown agent \"gpt-cli\"") {
+		t.Errorf("unknown agent err = %v", err)
+	}
+	if _, err := resolvePhaseAgents(phasedEngineOptions{PhaseAgents: map[string]string{"deploy": "codex"}}, cfg); err == nil || !strings.Contains(err.Error(), "--phase-agent: unknown phase") {
+		t.Errorf("unknown phase err = %v", err)
+	}
+}
+
+func TestNewAgentBackend_Invalid(t *testing.T) {
+	for _, tt := range []struct {
+		ac   config.AgentConfig
+		want string
+	}{
+		{config.AgentConfig{}, "command is empty"},
+		{config.AgentConfig{Command: []string{"x"}, Progress: "json"}, "unknown progress"},
+		{config.AgentConfig{Command: []string{"x"}, Done: "("}, "done:"},
+		{config.AgentConfig{Command: []string{"x", "{{.Prompt"}}, "command:"},
+	} {
+		if _, err := newAgentBackend("bad", "config", tt.ac); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("newAgentBackend(%+v) err = %v, want %q", tt.ac, err, tt.want)
+		}
+	}
+}
+
+func TestAgentExecutor_Execute(t *testing.T) {
+	dir := t.TempDir()
+	statusPath := filepath.Join(dir, "live-status.md")
+	allPhases := buildAllPhases(phases)
+
+	// The prompt arrives on stdin and via the prompt file; the phase name
+	// is expanded into the command
+	script := `cat > "$PWD/stdin.txt"; cp "$1" "$PWD/file.txt"; echo "editing $2"; echo ALL DONE`
+	b, err := newAgentBackend("fake", "config", config.AgentConfig{
+		Command: []string{"sh", "-c", script, "agent", "{{.PromptFile}}", "{{.PhaseName}}"},
+		Stdin:   true,
+		Done:    "^ALL DONE$",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := &agentExecutor{backend: b, statusPath: statusPath, allPhases: allPhases, stallTimeout: time.Minute, stallCheckInterval: time.Second}
+
+	if err := exec.Execute("build the thing", dir, "run1", 2); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, name := range []string{"stdin.txt", "file.txt"} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(data) != "build the thing" {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+	}
+	if got := allPhases[1].CurrentAction; got != "ALL DONE" {
+		t.Errorf("live status action = %q, want the last output line", got)
+	}
+	if _, err := os.Stat(statusPath); err != nil {
+		t.Errorf("live status not written: %v", err)
+	}
+}
+
+func TestAgentExecutor_Completion(t *testing.T) {
+	dir := t.TempDir()
+	oldDelay := agentWaitDelay
+	agentWaitDelay = 100 * time.Millisecond
+	t.Cleanup(func() { agentWaitDelay = oldDelay })
+
+	tests := []struct {
+		name   string
+		script string
+		ac     config.AgentConfig
+		want   string
+	}{
+		{"exit code", "echo oops; exit 3", config.AgentConfig{}, "fake exited with code 3 (exit_error)"},
+		{"missing marker", "echo finished", config.AgentConfig{Done: "TASK COMPLETE"}, "without printing its completion marker"},
+		{"marker without newline", "printf 'TASK COMPLETE'", config.AgentConfig{Done: "TASK COMPLETE"}, ""},
+		{"stall", "sleep 1 & wait", config.AgentConfig{}, "(stall): stall detected: no output activity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &agentExecutor{
+				backend:            shAgent(t, tt.script, tt.ac),
+				stallTimeout:       50 * time.Millisecond,
+				stallCheckInterval: 10 * time.Millisecond,
+			}
+			err := exec.Execute("p", dir, "run1", 1)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("err = %v, want success", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhaseAgentExecutor(t *testing.T) {
+	base := &directExecutor{}
+	if got := withPhaseAgents(base, map[int]*agentBackend{1: nil, 2: nil, 3: nil}, "", nil, defaultPhasedEngineOptions()); got != base {
+		t.Errorf("without agents the base executor should be used, got %s", got.Name())
+	}
+
+	codex, err := lookupAgent("codex", config.RPIConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed := withPhaseAgents(base, map[int]*agentBackend{1: codex, 2: nil, 3: nil}, "", nil, defaultPhasedEngineOptions())
+	if got := mixed.Name(); got != "discovery=codex,implementation=direct,validation=direct" {
+		t.Errorf("Name() = %q", got)
+	}
+	if spawnLabel(mixed, 1) != "codex" || spawnLabel(mixed, 2) != "claude -p" {
+		t.Errorf("spawn labels = %q, %q", spawnLabel(mixed, 1), spawnLabel(mixed, 2))
+	}
+
+	all := withPhaseAgents(base, map[int]*agentBackend{1: codex, 2: codex, 3: codex}, "", nil, defaultPhasedEngineOptions())
+	if got := all.Name(); got != "codex" {
+		t.Errorf("Name() = %q, want codex", got)
+	}
+}
+
+func TestRunRPIAgents_Config(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // keep user config out of the test
+	t.Setenv("AGENTOPS_RPI_AGENT", "")
+	cwd := chdirTemp(t)
+	configPath := filepath.Join(cwd, ".agentops", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Unknown phase agents are reported in name order
+	writeConfig("rpi:\n  phase_agents:\n    validation: zeta\n    discovery: alpha\n    implementation: mid\n")
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	if err := runRPIAgents(cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	alpha, mid, zeta := strings.Index(out.String(), "alpha "), strings.Index(out.String(), "mid "), strings.Index(out.String(), "zeta ")
+	if alpha < 0 || !(alpha < mid && mid < zeta) {
+		t.Errorf("error rows not sorted by name:\n%s", out.String())
+	}
+
+	// An invalid config is an error, not the defaults
+	writeConfig("rpi: [\n")
+	if err := runRPIAgents(cmd, nil); err == nil || !strings.Contains(err.Error(), "load config") {
+		t.Errorf("invalid config err = %v", err)
+	}
+}
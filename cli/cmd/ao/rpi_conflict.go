@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// resolvePhaseNum numbers the merge-conflict resolution session. It runs
+// after the lifecycle phases and is not one of phases.
+const resolvePhaseNum = 4
+
+// resolvePhaseName names the resolution session in logs, the ledger and
+// replay fixtures.
+const resolvePhaseName = "resolve"
+
+// mergeConflictReportFile is written to the worktree's .agents/rpi when a
+// merge conflicts, for whoever resolves it.
+const mergeConflictReportFile = "merge-conflict.md"
+
+// maxConflictHunkLines caps each side of a hunk in prompts and reports.
+const maxConflictHunkLines = 60
+
+// conflictHunk is one conflicted region of a file.
+type conflictHunk struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Ours   string `json:"ours"`
+	Theirs string `json:"theirs"`
+}
+
+// mergeConflictError reports a merge that stopped on conflicts. Ours and
+// Theirs name the two sides of each hunk.
+type mergeConflictError struct {
+	Branch   string
+	RepoRoot string
+	Ours     string
+	Theirs   string
+	Files    []string
+	Hunks    []conflictHunk
+}
+
+func (e *mergeConflictError) Error() string {
+	return fmt.Sprintf("merge conflict in %s.\nConflicting files:\n%s\nResolve manually: cd %s && git merge %s",
+		e.Branch, strings.Join(e.Files, "\n"), e.RepoRoot, e.Branch)
+}
+
+// unmergedFiles lists the files git reports as conflicted in dir.
+func unmergedFiles(dir string) []string {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = dir
+	out, _ := cmd.Output() //nolint:errcheck // no output means no conflicts
+	var files []string
+	for _, f := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// captureConflictHunks reads the conflict markers git left in files.
+func captureConflictHunks(dir string, files []string) []conflictHunk {
+	var hunks []conflictHunk
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			continue
+		}
+		hunks = append(hunks, parseConflictHunks(f, data)...)
+	}
+	return hunks
+}
+
+// parseConflictHunks splits data on <<<<<<< / ======= / >>>>>>> markers,
+// skipping the merge base section of diff3-style conflicts.
+func parseConflictHunks(file string, data []byte) []conflictHunk {
+	var hunks []conflictHunk
+	var cur *conflictHunk
+	var ours, theirs []string
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "<<<<<<<"):
+			cur = &conflictHunk{File: file, Line: n}
+			ours, theirs = nil, nil
+			section = "ours"
+		case cur != nil && strings.HasPrefix(line, "|||||||"):
+			section = "base"
+		case cur != nil && line == "=======":
+			section = "theirs"
+		case cur != nil && strings.HasPrefix(line, ">>>>>>>"):
+			cur.Ours = strings.Join(ours, "\n")
+			cur.Theirs = strings.Join(theirs, "\n")
+			hunks = append(hunks, *cur)
+			cur = nil
+		case section == "ours" && cur != nil:
+			ours = append(ours, line)
+		case section == "theirs" && cur != nil:
+			theirs = append(theirs, line)
+		}
+	}
+	return hunks
+}
+
+// hasConflictMarkers reports whether any of files in dir still holds a
+// conflict hunk.
+func hasConflictMarkers(dir string, files []string) []string {
+	var left []string
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f))
+		if err == nil && len(parseConflictHunks(f, data)) > 0 {
+			left = append(left, f)
+		}
+	}
+	return left
+}
+
+// clipLines keeps the first max lines of s.
+func clipLines(s string, max int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= max {
+		return s
+	}
+	return strings.Join(lines[:max], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-max)
+}
+
+// conflictTemplateFuncs are shared by the report and the resolve prompt.
+var conflictTemplateFuncs = template.FuncMap{
+	"clip": func(s string) string { return clipLines(s, maxConflictHunkLines) },
+}
+
+const mergeConflictReportTemplate = `# Merge conflict: {{.Conflict.Branch}}
+
+Goal: {{.Goal}}
+
+Merging {{.Conflict.Theirs}} into {{.Conflict.Ours}} conflicts in:
+{{range .Conflict.Files}}- {{.}}
+{{end}}
+{{range .Conflict.Hunks}}## {{.File}}:{{.Line}}
+
+{{$.Conflict.Ours}}:
+` + "```" + `
+{{clip .Ours}}
+` + "```" + `
+
+{{$.Conflict.Theirs}}:
+` + "```" + `
+{{clip .Theirs}}
+` + "```" + `
+
+{{end}}`
+
+const resolvePromptTemplate = `MERGE CONFLICT RESOLUTION: This worktree ran ao rpi phased for the goal below. Its branch {{.Conflict.Ours}} now conflicts with {{.Conflict.Theirs}}, which moved while the run was in progress. A merge of {{.Conflict.Theirs}} is in progress in this worktree with conflict markers left in the files listed below.
+
+Goal of this run: {{.Goal}}
+{{if .EpicID}}Epic: {{.EpicID}}
+{{end}}
+Resolve every conflict so the result keeps the intent of both sides:
+- {{.Conflict.Ours}} is this run's work toward the goal.
+- {{.Conflict.Theirs}} is work merged by others since the run started; do not drop it.
+- Remove all conflict markers, then run the project's tests.
+- Stage the resolved files and conclude the merge with git commit --no-edit.
+- Do NOT run git merge --abort, reset, or rebase, and do not touch unrelated files.
+
+Conflicting files:
+{{range .Conflict.Files}}- {{.}}
+{{end}}
+{{range .Conflict.Hunks}}--- {{.File}}:{{.Line}} ---
+<<<<<<< {{$.Conflict.Ours}}
+{{clip .Ours}}
+=======
+{{clip .Theirs}}
+>>>>>>> {{$.Conflict.Theirs}}
+
+{{end}}`
+
+// renderConflict executes one of the conflict templates.
+func renderConflict(name, tmplStr string, state *phasedState, conflict *mergeConflictError) (string, error) {
+	tmpl, err := template.New(name).Funcs(conflictTemplateFuncs).Parse(tmplStr)
+	if err != nil {
+		return "", fmt.Errorf("parse %s template: %w", name, err)
+	}
+	data := struct {
+		Goal     string
+		EpicID   string
+		Conflict *mergeConflictError
+	}{state.Goal, state.EpicID, conflict}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// recordConflictEvent appends a merge-conflict event to the ledger of the
+// original repository, which outlives the run's worktree.
+func recordConflictEvent(repoRoot, runID, action string, details map[string]any) {
+	if runID == "" {
+		return
+	}
+	if _, err := appendRPILedgerEvent(repoRoot, rpiLedgerEvent{RunID: runID, Phase: resolvePhaseName, Action: action, Details: details}); err != nil {
+		VerbosePrintf("Warning: could not append RPI ledger event: %v\n", err)
+		return
+	}
+	if err := materializeRPIRunCache(repoRoot, runID); err != nil {
+		VerbosePrintf("Warning: could not materialize RPI run cache: %v\n", err)
+	}
+}
+
+// recordMergeConflict logs a conflicted merge to the ledger and writes the
+// captured hunks to the worktree's merge-conflict report.
+func recordMergeConflict(repoRoot, worktreePath, runID string, state *phasedState, conflict *mergeConflictError) {
+	hunks := append([]conflictHunk(nil), conflict.Hunks...)
+	for i := range hunks {
+		hunks[i].Ours = clipLines(hunks[i].Ours, maxConflictHunkLines)
+		hunks[i].Theirs = clipLines(hunks[i].Theirs, maxConflictHunkLines)
+	}
+	recordConflictEvent(repoRoot, runID, "conflict", map[string]any{
+		"branch": conflict.Branch,
+		"files":  conflict.Files,
+		"hunks":  hunks,
+	})
+
+	report, err := renderConflict("report", mergeConflictReportTemplate, state, conflict)
+	if err != nil {
+		VerbosePrintf("Warning: %v\n", err)
+		return
+	}
+	path := filepath.Join(worktreePath, ".agents", "rpi", mergeConflictReportFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		VerbosePrintf("Warning: could not write merge conflict report: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+		VerbosePrintf("Warning: could not write merge conflict report: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Conflicting hunks written to %s\n", path)
+}
+
+// conflictResolver resolves a conflicted worktree merge with a dedicated
+// resolve phase, then re-runs validation.
+type conflictResolver struct {
+	repoRoot string
+	worktree string
+	state    *phasedState
+	executor PhaseExecutor
+	logPath  string
+	// revalidate re-runs the validation phase on the resolved tree.
+	revalidate func() error
+}
+
+// resolve merges the base branch into the worktree, has the executor
+// resolve the conflicts, checks the result and re-validates it. On failure
+// the worktree is reset to the commit the run finished on, dropping the
+// merge whether or not it was already committed.
+func (r *conflictResolver) resolve(conflict *mergeConflictError) (retErr error) {
+	runID := r.state.RunID
+	base, err := getCurrentBranch(r.repoRoot)
+	if err != nil {
+		return err
+	}
+	out, err := r.git("rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("read worktree HEAD: %w (%s)", err, strings.TrimSpace(out))
+	}
+	head := strings.TrimSpace(out)
+	logPhaseTransition(r.logPath, runID, resolvePhaseName, fmt.Sprintf("started: merging %s into %s", base, conflict.Branch))
+	defer func() {
+		if retErr != nil {
+			if out, err := r.git("reset", "--hard", head); err != nil {
+				VerbosePrintf("Warning: could not reset worktree to %s: %v (%s)\n", head, err, strings.TrimSpace(out))
+			}
+			logPhaseTransition(r.logPath, runID, resolvePhaseName, fmt.Sprintf("FAILED: %v", retErr))
+			recordConflictEvent(r.repoRoot, runID, "unresolved", map[string]any{"error": retErr.Error()})
+		}
+	}()
+
+	if _, err := r.git("merge", "--no-ff", "--no-edit", base); err == nil {
+		// The base merges cleanly from this side; nothing for an agent to do.
+		recordConflictEvent(r.repoRoot, runID, "resolved", map[string]any{"agent": false})
+		return nil
+	}
+	files := unmergedFiles(r.worktree)
+	if len(files) == 0 {
+		return fmt.Errorf("merging %s into the worktree failed without conflicts", base)
+	}
+	local := &mergeConflictError{
+		Branch:   conflict.Branch,
+		RepoRoot: r.worktree,
+		Ours:     conflict.Branch,
+		Theirs:   base,
+		Files:    files,
+		Hunks:    captureConflictHunks(r.worktree, files),
+	}
+	prompt, err := renderConflict("resolve", resolvePromptTemplate, r.state, local)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n--- Resolve: %d conflicting file(s) ---\n", len(files))
+	fmt.Printf("Spawning: %s '%s'\n", spawnLabel(r.executor, resolvePhaseNum), prompt)
+	if err := r.executor.Execute(prompt, r.worktree, runID, resolvePhaseNum); err != nil {
+		return fmt.Errorf("resolve phase: %w", err)
+	}
+
+	if left := hasConflictMarkers(r.worktree, files); len(left) > 0 {
+		return fmt.Errorf("resolve phase left conflict markers in %s", strings.Join(left, ", "))
+	}
+	if _, err := r.git(append([]string{"add", "--"}, files...)...); err != nil {
+		return fmt.Errorf("stage resolved files: %w", err)
+	}
+	if r.mergeInProgress() {
+		if out, err := r.git("commit", "--no-edit"); err != nil {
+			return fmt.Errorf("commit resolved merge: %w (%s)", err, strings.TrimSpace(out))
+		}
+	}
+	logPhaseTransition(r.logPath, runID, resolvePhaseName, fmt.Sprintf("completed: resolved %s", strings.Join(files, ", ")))
+
+	if err := r.revalidate(); err != nil {
+		return fmt.Errorf("validation after resolve: %w", err)
+	}
+	recordConflictEvent(r.repoRoot, runID, "resolved", map[string]any{
+		"agent":    r.executor.Name(),
+		"files":    files,
+		"verdicts": r.state.Verdicts,
+	})
+	return nil
+}
+
+// git runs git in the worktree and returns its combined output.
+func (r *conflictResolver) git(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), worktreeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.worktree
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// mergeInProgress reports whether the worktree has an uncommitted merge.
+func (r *conflictResolver) mergeInProgress() bool {
+	_, err := r.git("rev-parse", "-q", "--verify", "MERGE_HEAD")
+	return err == nil
+}
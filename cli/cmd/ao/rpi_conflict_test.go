@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// conflictingWorktree creates a run worktree whose branch and the base
+// branch both commit different content to conflict.txt.
+func conflictingWorktree(t *testing.T) (repo, worktreePath, runID string) {
+	t.Helper()
+	repo = initTestRepo(t)
+	worktreePath, runID, err := createWorktree(repo)
+	if err != nil {
+		t.Fatalf("createWorktree: %v", err)
+	}
+	t.Cleanup(func() {
+		exec.Command("git", "-C", repo, "worktree", "remove", "--force", worktreePath).Run() //nolint:errcheck // test cleanup
+	})
+	commitConflictingVersions(t, repo, worktreePath)
+	return repo, worktreePath, runID
+}
+
+// commitConflictingVersions commits different content to conflict.txt in
+// the repo and in the run worktree.
+func commitConflictingVersions(t *testing.T, repo, worktreePath string) {
+	t.Helper()
+	for dir, content := range map[string]string{worktreePath: "worktree version\n", repo: "original version\n"} {
+		if err := os.WriteFile(filepath.Join(dir, "conflict.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", "conflict.txt"}, {"commit", "-m", content}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+}
+
+// ledgerActions lists the resolve-phase actions recorded for runID.
+func ledgerActions(t *testing.T, repo, runID string) []string {
+	t.Helper()
+	records, err := LoadRPILedgerRecords(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, r := range records {
+		if r.RunID == runID && r.Phase == resolvePhaseName {
+			actions = append(actions, r.Action)
+		}
+	}
+	return actions
+}
+
+// replayResolver returns a conflictResolver driven by a replay scenario
+// scripting the resolve phase.
+func replayResolver(t *testing.T, repo, worktreePath string, state *phasedState, scenario string, revalidate func() error) *conflictResolver {
+	t.Helper()
+	sc, err := loadReplayScenario(writeScenario(t, scenario, map[string]string{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &conflictResolver{
+		repoRoot:   repo,
+		worktree:   worktreePath,
+		state:      state,
+		executor:   newReplayExecutor(sc, "", nil, defaultPhasedEngineOptions()),
+		logPath:    filepath.Join(t.TempDir(), "phased-orchestration.log"),
+		revalidate: revalidate,
+	}
+}
+
+func TestWorktreeCleanup_ConflictWithoutResolver(t *testing.T) {
+	repo, worktreePath, runID := conflictingWorktree(t)
+	state := &phasedState{RunID: runID, Goal: "add rate limiting"}
+
+	err := worktreeCleanup(repo, worktreePath, runID, "", state, true, nil)
+	var conflict *mergeConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("err = %v, want a merge conflict", err)
+	}
+	if len(conflict.Hunks) != 1 || conflict.Hunks[0].Ours != "original version" || conflict.Hunks[0].Theirs != "worktree version" {
+		t.Errorf("hunks = %+v", conflict.Hunks)
+	}
+	if _, err := os.Stat(worktreePath); err != nil {
+		t.Errorf("worktree should be preserved: %v", err)
+	}
+	report, err := os.ReadFile(filepath.Join(worktreePath, ".agents", "rpi", mergeConflictReportFile))
+	if err != nil || !strings.Contains(string(report), "add rate limiting") || !strings.Contains(string(report), "## conflict.txt:1") {
+		t.Errorf("conflict report = %q (%v)", report, err)
+	}
+	if got := ledgerActions(t, repo, runID); strings.Join(got, " ") != "conflict" {
+		t.Errorf("ledger actions = %v", got)
+	}
+}
+
+func TestWorktreeCleanup_ResolvesConflict(t *testing.T) {
+	repo, worktreePath, runID := conflictingWorktree(t)
+	state := &phasedState{RunID: runID, Goal: "add rate limiting"}
+	revalidated := 0
+	r := replayResolver(t, repo, worktreePath, state,
+		"phases:\n  resolve:\n    - files:\n        - {path: conflict.txt, content: \"both versions\\n\"}\n",
+		func() error { revalidated++; return nil })
+
+	if err := worktreeCleanup(repo, worktreePath, runID, "", state, true, r.resolve); err != nil {
+		t.Fatalf("worktreeCleanup: %v", err)
+	}
+	if revalidated != 1 {
+		t.Errorf("validation re-ran %d times, want 1", revalidated)
+	}
+	data, err := os.ReadFile(filepath.Join(repo, "conflict.txt"))
+	if err != nil || string(data) != "both versions\n" {
+		t.Errorf("merged conflict.txt = %q (%v)", data, err)
+	}
+	if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+		t.Errorf("worktree not removed after merge")
+	}
+	if got := ledgerActions(t, repo, runID); strings.Join(got, " ") != "conflict resolved merged" {
+		t.Errorf("ledger actions = %v", got)
+	}
+
+	if calls := r.executor.(*replayExecutor).calls; calls[resolvePhaseNum] != 1 {
+		t.Errorf("resolve invocations = %v", calls)
+	}
+}
+
+// conflictScenario passes every gate. Discovery runs long enough for the
+// test to commit conflicting changes; the resolve phase then merges them
+// and validation passes again.
+const conflictScenario = `bd:
+  list: "ag-demo  [EPIC]  add rate limiting  open"
+  children: "ag-demo.1  closed  add limiter"
+phases:
+  discovery:
+    - delay: 1s
+      files:
+        - {path: .agents/council/2026-01-01-pre-mortem-ag-demo.md, content: "## Council Verdict: PASS\n"}
+  implementation:
+    - stdout: closed ag-demo.1
+  validation:
+    - files:
+        - {path: .agents/council/2026-01-01-vibe-ag-demo.md, content: "## Council Verdict: PASS\n"}
+    - stdout: revalidated after resolve
+  resolve:
+    - delay: 10ms
+      files:
+        - {path: conflict.txt, content: "both versions\n"}
+`
+
+func TestRunPhasedEngine_ResolvesConflict(t *testing.T) {
+	repo := initTestRepo(t)
+	opts := replayOpts(writeScenario(t, conflictScenario, map[string]string{}))
+	opts.NoWorktree = false
+	opts.ResolveConflicts = true
+	errc := make(chan error, 1)
+	go func() { errc <- runPhasedEngine(repo, "add rate limiting", opts) }()
+
+	dir := waitForEngine(t, repo)
+	runID := filepath.Base(dir)
+	worktree := filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(dir))))
+	waitFor(t, "discovery to start", func() bool {
+		_, err := os.Stat(filepath.Join(worktree, ".agents", "rpi", replayTranscriptFile))
+		return err == nil
+	})
+	commitConflictingVersions(t, repo, worktree)
+
+	if err := <-errc; err != nil {
+		t.Fatalf("runPhasedEngine: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(repo, "conflict.txt"))
+	if err != nil || string(data) != "both versions\n" {
+		t.Errorf("merged conflict.txt = %q (%v)", data, err)
+	}
+	if got := ledgerActions(t, repo, runID); strings.Join(got, " ") != "conflict resolved merged" {
+		t.Errorf("ledger actions = %v", got)
+	}
+	if _, err := os.Stat(worktree); !os.IsNotExist(err) {
+		t.Errorf("worktree %s not removed after merge", worktree)
+	}
+}
+
+func TestConflictResolver_Failures(t *testing.T) {
+	tests := []struct {
+		name       string
+		scenario   string
+		revalidate error
+		want       string
+	}{
+		{"markers left", "phases:\n  resolve:\n    - stdout: done\n", nil, "left conflict markers in conflict.txt"},
+		{"agent fails", "phases:\n  resolve:\n    - exit_code: 1\n", nil, "resolve phase: "},
+		{"validation fails", "phases:\n  resolve:\n    - files:\n        - {path: conflict.txt, content: \"x\\n\"}\n", errors.New("vibe FAIL"), "validation after resolve: vibe FAIL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, worktreePath, runID := conflictingWorktree(t)
+			state := &phasedState{RunID: runID, Goal: "goal"}
+			r := replayResolver(t, repo, worktreePath, state, tt.scenario, func() error { return tt.revalidate })
+			head, err := r.git("rev-parse", "HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = worktreeCleanup(repo, worktreePath, runID, "", state, true, r.resolve)
+			if err == nil || !strings.Contains(err.Error(), "merge conflict") {
+				t.Fatalf("err = %v, want the original conflict", err)
+			}
+			if got := ledgerActions(t, repo, runID); strings.Join(got, " ") != "conflict unresolved" {
+				t.Errorf("ledger actions = %v", got)
+			}
+			records, _ := LoadRPILedgerRecords(repo) //nolint:errcheck // checked by ledgerActions
+			if last := records[len(records)-1]; !strings.Contains(string(last.Details), tt.want) {
+				t.Errorf("unresolved details = %s, want %q", last.Details, tt.want)
+			}
+			if r.mergeInProgress() {
+				t.Error("failed resolution left a merge in progress")
+			}
+			if after, _ := r.git("rev-parse", "HEAD"); after != head { //nolint:errcheck // compared below
+				t.Errorf("HEAD moved from %s to %s", strings.TrimSpace(head), strings.TrimSpace(after))
+			}
+			if data, _ := os.ReadFile(filepath.Join(worktreePath, "conflict.txt")); string(data) != "worktree version\n" { //nolint:errcheck // compared
+				t.Errorf("conflict.txt = %q, want the run's version", data)
+			}
+		})
+	}
+}
+
+func TestParseConflictHunks(t *testing.T) {
+	data := "keep\n<<<<<<< HEAD\na\nb\n||||||| base\nold\n=======\nc\n>>>>>>> rpi/x\nmid\n<<<<<<< HEAD\n=======\nd\n>>>>>>> rpi/x\n"
+	hunks := parseConflictHunks("f.go", []byte(data))
+	if len(hunks) != 2 {
+		t.Fatalf("hunks = %+v", hunks)
+	}
+	if h := hunks[0]; h.Line != 2 || h.Ours != "a\nb" || h.Theirs != "c" {
+		t.Errorf("first hunk = %+v", h)
+	}
+	if h := hunks[1]; h.Line != 11 || h.Ours != "" || h.Theirs != "d" {
+		t.Errorf("second hunk = %+v", h)
+	}
+	if got := clipLines("1\n2\n3", 2); got != "1\n2\n... (1 more lines)" {
+		t.Errorf("clipLines = %q", got)
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
+)
+
+// Control actions requested by ao rpi pause|resume|cancel.
+const (
+	controlPause  = "pause"
+	controlResume = "resume"
+	controlCancel = "cancel"
+)
+
+// Engine states published in a run's engine file.
+const (
+	engineRunning   = "running"
+	enginePaused    = "paused"
+	engineCancelled = "cancelled"
+	engineExited    = "exited"
+)
+
+const (
+	// runControlFile holds the latest control request for a run. It is
+	// written by the control commands and only read by the engine.
+	runControlFile = "control.json"
+	// runEngineFile is written by the engine so the control commands and
+	// ao rpi status can see whether it is alive and what it is doing.
+	runEngineFile = "engine.json"
+)
+
+// controlPollInterval is how often a running engine checks for control
+// requests. Package-level so tests can shorten it.
+var controlPollInterval = time.Second
+
+// runContext returns ctx, or context.Background() when it is nil, as it is
+// for executors built without engine options.
+func runContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// errRunCancelled is returned by the engine when a run is cancelled.
+var errRunCancelled = errors.New("run cancelled")
+
+// runControlRequest is a control request written to a run's registry
+// directory. Only the latest request counts.
+type runControlRequest struct {
+	Action      string `json:"action"`
+	RequestedAt string `json:"requested_at"`
+}
+
+// runEngineStatus is a running engine's view of itself.
+type runEngineStatus struct {
+	PID       int    `json:"pid"`
+	State     string `json:"state"`
+	Phase     int    `json:"phase,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// alive reports whether the engine process still exists.
+func (s *runEngineStatus) alive() bool {
+	return s.PID > 0 && syscall.Kill(s.PID, 0) == nil
+}
+
+func init() {
+	for _, c := range []struct{ action, short, long string }{
+		{controlPause, "Pause a running RPI run before its next phase",
+			"The run finishes its current phase, then waits until it is resumed or\ncancelled."},
+		{controlResume, "Resume a paused RPI run",
+			"The run continues with its next phase."},
+		{controlCancel, "Cancel a running RPI run",
+			"The running phase is stopped, its ntm/tmux session killed and, for a\nworktree run, the worktree and its branch removed."},
+	} {
+		action := c.action
+		rpiCmd.AddCommand(&cobra.Command{
+			Use:   action + " <run-id>",
+			Short: c.short,
+			Long: c.long + `
+
+The request is written to the run's registry directory
+(.agents/rpi/runs/<run-id>/control.json) in this repository or one of its
+rpi worktrees, where the run's engine picks it up. Each action the engine
+takes is recorded in the RPI ledger. Run IDs are listed by ao rpi status.
+
+Example:
+  ao rpi ` + action + ` 3f9a1c2e7b4d`,
+			Args: cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runRPIControl(action, args[0])
+			},
+		})
+	}
+}
+
+func runRPIControl(action, runID string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	root, err := getRepoRoot(cwd)
+	if err != nil {
+		root = cwd
+	}
+	dir, engine := findRunEngine(root, runID)
+	if dir == "" {
+		return fmt.Errorf("run %s not found (see ao rpi status)", runID)
+	}
+	if engine == nil || !engine.alive() {
+		return fmt.Errorf("run %s is not running; restart it with ao rpi phased --from <phase>", runID)
+	}
+	switch {
+	case engine.State == engineCancelled:
+		return fmt.Errorf("run %s is already cancelled", runID)
+	case action == controlPause && engine.State == enginePaused:
+		fmt.Printf("Run %s is already paused\n", runID)
+		return nil
+	case action == controlResume && engine.State != enginePaused && readRunControl(dir) != controlPause:
+		return fmt.Errorf("run %s is not paused", runID)
+	}
+
+	if GetDryRun() {
+		fmt.Printf("[dry-run] Would request %s of run %s (pid %d)\n", action, runID, engine.PID)
+		return nil
+	}
+	if err := writeRunControl(dir, action); err != nil {
+		return err
+	}
+	switch action {
+	case controlPause:
+		fmt.Printf("Pause requested: run %s pauses before its next phase\n", runID)
+	case controlResume:
+		fmt.Printf("Resume requested: run %s continues with its next phase\n", runID)
+	case controlCancel:
+		fmt.Printf("Cancel requested: run %s stops now\n", runID)
+	}
+	return nil
+}
+
+// findRunEngine locates runID's registry directory in root or one of its
+// sibling rpi worktrees, and the engine status recorded there.
+func findRunEngine(root, runID string) (string, *runEngineStatus) {
+	for _, r := range collectSearchRoots(root) {
+		dir := rpiRunRegistryDir(r, runID)
+		if dir == "" {
+			return "", nil
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, readRunEngine(dir)
+		}
+	}
+	return "", nil
+}
+
+// readRunEngine reads the engine status in a run registry directory, or
+// nil if there is none.
+func readRunEngine(dir string) *runEngineStatus {
+	data, err := os.ReadFile(filepath.Join(dir, runEngineFile))
+	if err != nil {
+		return nil
+	}
+	var s runEngineStatus
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil
+	}
+	return &s
+}
+
+// readRunControl returns the action of the latest control request in dir.
+func readRunControl(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, runControlFile))
+	if err != nil {
+		return ""
+	}
+	var req runControlRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return ""
+	}
+	return req.Action
+}
+
+// writeRunControl records a control request for the engine in dir.
+func writeRunControl(dir, action string) error {
+	data, err := json.Marshal(runControlRequest{Action: action, RequestedAt: time.Now().UTC().Format(time.RFC3339)})
+	if err != nil {
+		return fmt.Errorf("marshal control request: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, runControlFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write control request: %w", err)
+	}
+	return nil
+}
+
+// runController applies control requests to a running phased engine:
+// pause and resume between phases, cancel at any time.
+type runController struct {
+	root       string // run's working directory (the worktree, if any)
+	ledgerRoot string // original repository, which outlives the worktree
+	dir        string
+	runID      string
+	logPath    string
+
+	// ctx is the run's context, cancelled when the run is cancelled. The
+	// engine passes it to its executors through phasedEngineOptions.Ctx.
+	ctx       context.Context
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+	done      chan struct{}
+	wg        sync.WaitGroup
+
+	mu    sync.Mutex
+	state string
+	phase int
+}
+
+// startRunController publishes the engine in the run registry, derives the
+// run's cancellable context from parent and watches for cancel requests.
+// stop marks the engine exited and release cancels the context.
+func startRunController(parent context.Context, root, ledgerRoot, runID, logPath string) *runController {
+	dir := rpiRunRegistryDir(root, runID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		VerbosePrintf("Warning: create run registry dir: %v\n", err)
+	}
+	// Requests addressed to an earlier engine for this run do not apply.
+	_ = os.Remove(filepath.Join(dir, runControlFile)) //nolint:errcheck // absent is fine
+
+	ctx, cancel := context.WithCancel(parent)
+	c := &runController{
+		root:       root,
+		ledgerRoot: ledgerRoot,
+		dir:        dir,
+		runID:      runID,
+		logPath:    logPath,
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+		state:      engineRunning,
+	}
+	c.publish()
+
+	c.wg.Add(1)
+	go c.watch()
+	return c
+}
+
+// stop ends the watcher and marks the engine exited. The run's context
+// stays live until release, so the worktree merge and conflict resolution
+// that follow the phases can still run agents on it.
+func (c *runController) stop() {
+	if c == nil {
+		return
+	}
+	close(c.done)
+	c.wg.Wait()
+	if !c.cancelled.Load() {
+		c.setState(engineExited)
+	}
+}
+
+// release cancels the run's context once nothing runs on it.
+func (c *runController) release() {
+	if c == nil {
+		return
+	}
+	c.cancel()
+}
+
+// watch cancels the run as soon as a cancel request appears, so a
+// running phase does not have to finish first.
+func (c *runController) watch() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(controlPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if readRunControl(c.dir) == controlCancel {
+				c.cancelRun()
+			}
+		}
+	}
+}
+
+// isCancelled reports whether the run has been cancelled.
+func (c *runController) isCancelled() bool {
+	return c != nil && c.cancelled.Load()
+}
+
+// checkpoint is called before each phase. It returns errRunCancelled if
+// the run was cancelled, and blocks while the run is paused.
+func (c *runController) checkpoint(phaseNum int) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	c.phase = phaseNum
+	c.mu.Unlock()
+	for {
+		if c.cancelled.Load() {
+			return c.cancelErr()
+		}
+		switch readRunControl(c.dir) {
+		case controlCancel:
+			c.cancelRun()
+			return c.cancelErr()
+		case controlPause:
+			if c.setState(enginePaused) {
+				fmt.Printf("Run paused before phase %d; resume with: ao rpi resume %s\n", phaseNum, c.runID)
+				c.record("paused", fmt.Sprintf("paused before phase %d", phaseNum))
+			}
+			updateRunHeartbeat(c.root, c.runID)
+			time.Sleep(controlPollInterval)
+		default:
+			if c.setState(engineRunning) {
+				fmt.Printf("Run resumed at phase %d\n", phaseNum)
+				c.record("resumed", fmt.Sprintf("resumed at phase %d", phaseNum))
+			}
+			return nil
+		}
+	}
+}
+
+func (c *runController) cancelErr() error {
+	return fmt.Errorf("%w: %s", errRunCancelled, c.runID)
+}
+
+// cancelRun stops the run's agent processes and ntm/tmux sessions.
+func (c *runController) cancelRun() {
+	if !c.cancelled.CompareAndSwap(false, true) {
+		return
+	}
+	c.cancel()
+	killRunSessions(c.runID)
+	c.setState(engineCancelled)
+	c.mu.Lock()
+	phaseNum := c.phase
+	c.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "\nRun %s cancelled during phase %d\n", c.runID, phaseNum)
+	c.record("cancelled", fmt.Sprintf("cancelled during phase %d", phaseNum))
+}
+
+// setState publishes a new engine state and reports whether it changed.
+func (c *runController) setState(state string) bool {
+	c.mu.Lock()
+	changed := c.state != state
+	c.state = state
+	c.mu.Unlock()
+	if changed {
+		c.publish()
+	}
+	return changed
+}
+
+// publish writes the engine status file.
+func (c *runController) publish() {
+	c.mu.Lock()
+	s := runEngineStatus{PID: os.Getpid(), State: c.state, Phase: c.phase, UpdatedAt: time.Now().UTC().Format(time.RFC3339)}
+	c.mu.Unlock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(c.dir, runEngineFile), append(data, '\n'), 0644); err != nil {
+		VerbosePrintf("Warning: write engine status: %v\n", err)
+	}
+}
+
+// removeWorktree discards a cancelled run's worktree and branch.
+func (c *runController) removeWorktree(repoRoot, worktreePath, worktreeRunID string) {
+	if err := removeWorktree(repoRoot, worktreePath, worktreeRunID); err != nil {
+		fmt.Fprintf(os.Stderr, "Cleanup failed: %v\nWorktree may require manual removal: %s\n", err, worktreePath)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Worktree removed: %s\n", worktreePath)
+	c.appendLedger("worktree-removed", "removed "+worktreePath)
+}
+
+// record logs a control action to the orchestration log, which mirrors it
+// to the run's ledger, and to the original repository's ledger when the
+// run works in a worktree that may be removed.
+func (c *runController) record(action, details string) {
+	logPhaseTransition(c.logPath, c.runID, "control", details)
+	if c.ledgerRoot != c.root {
+		c.appendLedger(action, details)
+	}
+}
+
+// appendLedger records a control event in the original repository's ledger.
+func (c *runController) appendLedger(action, details string) {
+	if _, err := appendRPILedgerEvent(c.ledgerRoot, rpiLedgerEvent{
+		RunID:   c.runID,
+		Phase:   "control",
+		Action:  action,
+		Details: map[string]any{"details": details, "pid": os.Getpid()},
+	}); err != nil {
+		VerbosePrintf("Warning: could not append RPI ledger event: %v\n", err)
+		return
+	}
+	if err := materializeRPIRunCache(c.ledgerRoot, c.runID); err != nil {
+		VerbosePrintf("Warning: could not materialize RPI run cache: %v\n", err)
+	}
+}
+
+// killRunSessions kills the run's phase sessions (ao-rpi-<runID>-p<N>).
+func killRunSessions(runID string) {
+	for i := 1; i <= resolvePhaseNum; i++ {
+		name := fmt.Sprintf("ao-rpi-%s-p%d", runID, i)
+		ctx, cancel := context.WithTimeout(context.Background(), tmuxProbeTimeout)
+		_ = exec.CommandContext(ctx, "tmux", "kill-session", "-t", name).Run() //nolint:errcheck // most phases have no session
+		cancel()
+	}
+}
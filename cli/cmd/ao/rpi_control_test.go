@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fastControlPoll(t *testing.T) {
+	t.Helper()
+	old := controlPollInterval
+	controlPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { controlPollInterval = old })
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitForEngine returns the registry directory of the first run that
+// publishes an engine status in root or its sibling worktrees.
+func waitForEngine(t *testing.T, root string) string {
+	t.Helper()
+	var dir string
+	waitFor(t, "engine status", func() bool {
+		for _, r := range collectSearchRoots(root) {
+			matches, _ := filepath.Glob(filepath.Join(r, ".agents", "rpi", "runs", "*", runEngineFile))
+			if len(matches) > 0 {
+				dir = filepath.Dir(matches[0])
+				return true
+			}
+		}
+		return false
+	})
+	return dir
+}
+
+func controlActions(t *testing.T, root, runID string) []string {
+	t.Helper()
+	records, err := LoadRPILedgerRecords(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, r := range records {
+		if r.RunID == runID && r.Phase == "control" {
+			actions = append(actions, r.Action)
+		}
+	}
+	return actions
+}
+
+// pausableScenario passes every gate, with a discovery phase slow enough
+// to request a pause while it runs.
+const pausableScenario = `bd:
+  list: "ag-demo  [EPIC]  add rate limiting  open"
+  children: "ag-demo.1  closed  add limiter"
+phases:
+  discovery:
+    - delay: 300ms
+      files:
+        - {path: .agents/council/2026-01-01-pre-mortem-ag-demo.md, content: "## Council Verdict: PASS\n"}
+  implementation:
+    - stdout: closed ag-demo.1
+  validation:
+    - files:
+        - {path: .agents/council/2026-01-01-vibe-ag-demo.md, content: "## Council Verdict: PASS\n"}
+`
+
+func TestRunController_PauseResume(t *testing.T) {
+	fastControlPoll(t)
+	cwd := t.TempDir()
+	opts := replayOpts(writeScenario(t, pausableScenario, map[string]string{}))
+	errc := make(chan error, 1)
+	go func() { errc <- runPhasedEngine(cwd, "add rate limiting", opts) }()
+
+	dir := waitForEngine(t, cwd)
+	runID := filepath.Base(dir)
+	if err := writeRunControl(dir, controlPause); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "pause", func() bool {
+		e := readRunEngine(dir)
+		return e != nil && e.State == enginePaused
+	})
+	// The pause lands between phases: before the first one or after the
+	// slow discovery phase, and no phase starts while paused.
+	e := readRunEngine(dir)
+	if e.PID != os.Getpid() || e.Phase > 2 {
+		t.Errorf("paused engine = %+v, want this process before phase 1 or 2", e)
+	}
+	time.Sleep(50 * time.Millisecond)
+	played := 0
+	if _, err := os.Stat(filepath.Join(cwd, ".agents", "rpi", replayTranscriptFile)); err == nil {
+		played = len(readTranscript(t, cwd))
+	}
+	if played != e.Phase-1 {
+		t.Errorf("%d phase(s) played while paused before phase %d", played, e.Phase)
+	}
+	// Phase state, and with it the status row, exists once a phase ran.
+	if runs := scanRegistryRuns(cwd); played > 0 && (len(runs) != 1 || runs[0].Status != enginePaused) {
+		t.Errorf("status of paused run = %+v", runs)
+	}
+
+	if err := writeRunControl(dir, controlResume); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("runPhasedEngine: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("run did not finish after resume")
+	}
+	if len(readTranscript(t, cwd)) != 3 {
+		t.Errorf("resumed run did not play every phase")
+	}
+	if e := readRunEngine(dir); e == nil || e.State != engineExited {
+		t.Errorf("engine after run = %+v", e)
+	}
+	if got := controlActions(t, cwd, runID); strings.Join(got, " ") != "paused resumed" {
+		t.Errorf("ledger control actions = %v", got)
+	}
+}
+
+func TestRunController_CancelRemovesWorktree(t *testing.T) {
+	fastControlPoll(t)
+	repo := initTestRepo(t)
+	opts := replayOpts(writeScenario(t, "phases:\n  discovery:\n    - delay: 1m\n", map[string]string{}))
+	opts.NoWorktree = false
+	errc := make(chan error, 1)
+	go func() { errc <- runPhasedEngine(repo, "goal", opts) }()
+
+	dir := waitForEngine(t, repo)
+	runID := filepath.Base(dir)
+	worktree := filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(dir))))
+	waitFor(t, "discovery to start", func() bool {
+		_, err := os.Stat(filepath.Join(worktree, ".agents", "rpi", replayTranscriptFile))
+		return err == nil
+	})
+	if err := writeRunControl(dir, controlCancel); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, errRunCancelled) {
+			t.Fatalf("err = %v, want a cancelled run", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("cancel did not stop the running phase")
+	}
+	if _, err := os.Stat(worktree); !os.IsNotExist(err) {
+		t.Errorf("worktree %s not removed", worktree)
+	}
+	out, err := exec.Command("git", "-C", repo, "branch", "--list", "rpi/*").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "" {
+		t.Errorf("run branch left behind: %q (%v)", out, err)
+	}
+	if got := controlActions(t, repo, runID); strings.Join(got, " ") != "cancelled worktree-removed" {
+		t.Errorf("ledger control actions = %v", got)
+	}
+}
+
+func TestRunRPIControl(t *testing.T) {
+	cwd := t.TempDir()
+	origDir, _ := os.Getwd()
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(origDir) //nolint:errcheck
+
+	dir := rpiRunRegistryDir(cwd, "abc123")
+	setEngine := func(pid int, state string) {
+		t.Helper()
+		data, _ := json.Marshal(runEngineStatus{PID: pid, State: state}) //nolint:errcheck // test
+		if err := os.WriteFile(filepath.Join(dir, runEngineFile), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectErr := func(action, want string) {
+		t.Helper()
+		if err := runRPIControl(action, "abc123"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", action, err, want)
+		}
+	}
+
+	expectErr(controlPause, "not found")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	expectErr(controlPause, "not running")
+	setEngine(0, engineRunning)
+	expectErr(controlCancel, "not running")
+
+	setEngine(os.Getpid(), engineRunning)
+	expectErr(controlResume, "not paused")
+	for _, action := range []string{controlPause, controlResume, controlCancel} {
+		if err := runRPIControl(action, "abc123"); err != nil {
+			t.Fatalf("%s: %v", action, err)
+		}
+		if got := readRunControl(dir); got != action {
+			t.Errorf("control request = %q, want %q", got, action)
+		}
+	}
+	setEngine(os.Getpid(), engineCancelled)
+	expectErr(controlResume, "already cancelled")
+}
+
+// TestRunController_ContextIsPerRun verifies that each run's executors get
+// that run's context, so cancelling one run in a process leaves the others
+// running.
+func TestRunController_ContextIsPerRun(t *testing.T) {
+	dir := t.TempDir()
+	a := startRunController(context.Background(), dir, dir, "run-a", "")
+	defer a.release()
+	defer a.stop()
+	b := startRunController(context.Background(), dir, dir, "run-b", "")
+	defer b.release()
+	defer b.stop()
+
+	optsA, optsB := defaultPhasedEngineOptions(), defaultPhasedEngineOptions()
+	optsA.Ctx, optsB.Ctx = a.ctx, b.ctx
+	replayA := newReplayExecutor(&replayScenario{}, "", nil, optsA)
+	replayB := newReplayExecutor(&replayScenario{}, "", nil, optsB)
+	if direct, _ := selectExecutorFromCaps(backendCapabilities{}, "", nil, optsA); direct.(*directExecutor).ctx != a.ctx {
+		t.Error("direct executor should carry the run's context")
+	}
+
+	a.cancelRun()
+	done := make(chan error, 1)
+	go func() { done <- replayA.sleep(time.Hour) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled run sleep = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled run did not stop its executor")
+	}
+	if err := replayB.sleep(time.Millisecond); err != nil {
+		t.Errorf("other run was cancelled too: %v", err)
+	}
+}
@@ -7,14 +7,14 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 )
 
 const (
@@ -108,17 +108,12 @@ func AppendRPILedgerRecord(rootDir string, input RPILedgerAppendInput) (RPILedge
 		return RPILedgerRecord{}, fmt.Errorf("create ledger dir: %w", err)
 	}
 
-	lockFile, err := os.OpenFile(ledgerPath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	lock, err := fsutil.LockFile(ledgerPath)
 	if err != nil {
-		return RPILedgerRecord{}, fmt.Errorf("open ledger lock: %w", err)
-	}
-	defer lockFile.Close()
-
-	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
 		return RPILedgerRecord{}, fmt.Errorf("lock ledger: %w", err)
 	}
 	defer func() {
-		_ = syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)
+		_ = lock.Unlock() //nolint:errcheck // released on close anyway
 	}()
 
 	ledgerFile, err := os.OpenFile(ledgerPath, os.O_CREATE|os.O_RDWR, 0644)
@@ -169,7 +164,7 @@ func AppendRPILedgerRecord(rootDir string, input RPILedgerAppendInput) (RPILedge
 	if err := ledgerFile.Sync(); err != nil {
 		return RPILedgerRecord{}, fmt.Errorf("fsync ledger: %w", err)
 	}
-	if err := syncDirectory(ledgerDir); err != nil {
+	if err := fsutil.SyncDir(ledgerDir); err != nil {
 		return RPILedgerRecord{}, err
 	}
 
@@ -327,7 +322,7 @@ func MaterializeRPIRunCache(rootDir, runID string) error {
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return fmt.Errorf("create run cache dir: %w", err)
 	}
-	return writeFileAtomic(cachePath, cacheBytes, 0644)
+	return fsutil.WriteFileAtomic(cachePath, cacheBytes, 0644)
 }
 
 func readLastLedgerHash(file *os.File) (string, error) {
@@ -490,60 +485,6 @@ func normalizeDetails(details any) (json.RawMessage, error) {
 	}
 }
 
-func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
-	dir := filepath.Dir(path)
-	tempFile, err := os.CreateTemp(dir, ".tmp-*.json")
-	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
-	}
-	tempPath := tempFile.Name()
-	cleanup := true
-	defer func() {
-		_ = tempFile.Close()
-		if cleanup {
-			_ = os.Remove(tempPath)
-		}
-	}()
-
-	if _, err := tempFile.Write(data); err != nil {
-		return fmt.Errorf("write temp file: %w", err)
-	}
-	if err := tempFile.Chmod(mode); err != nil {
-		return fmt.Errorf("chmod temp file: %w", err)
-	}
-	if err := tempFile.Sync(); err != nil {
-		return fmt.Errorf("fsync temp file: %w", err)
-	}
-	if err := tempFile.Close(); err != nil {
-		return fmt.Errorf("close temp file: %w", err)
-	}
-
-	if err := os.Rename(tempPath, path); err != nil {
-		return fmt.Errorf("rename temp file: %w", err)
-	}
-	if err := syncDirectory(dir); err != nil {
-		return err
-	}
-
-	cleanup = false
-	return nil
-}
-
-func syncDirectory(dir string) error {
-	f, err := os.Open(dir)
-	if err != nil {
-		return fmt.Errorf("open directory for fsync: %w", err)
-	}
-	defer f.Close()
-	if err := f.Sync(); err != nil {
-		if errors.Is(err, syscall.EINVAL) {
-			return nil
-		}
-		return fmt.Errorf("fsync directory: %w", err)
-	}
-	return nil
-}
-
 func newRPILedgerEventID() string {
 	var b [16]byte
 	if _, err := rand.Read(b[:]); err != nil {
@@ -63,6 +63,10 @@ type nextWorkEntry struct {
 	ConsumedBy *string        `json:"consumed_by"`
 	ConsumedAt *string        `json:"consumed_at"`
 	FailedAt   *string        `json:"failed_at,omitempty"`
+
+	// fileIndex is the entry's index among the parseable lines of the file,
+	// as expected by markEntryConsumed and markEntryFailed.
+	fileIndex int
 }
 
 // nextWorkItem represents a single harvested work item.
@@ -223,6 +227,7 @@ func readQueueEntries(path string) ([]nextWorkEntry, error) {
 	defer f.Close()
 
 	var entries []nextWorkEntry
+	parseable := -1
 	scanner := bufio.NewScanner(f)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
@@ -238,6 +243,8 @@ func readQueueEntries(path string) ([]nextWorkEntry, error) {
 			VerbosePrintf("Skipping malformed line: %v\n", err)
 			continue
 		}
+		parseable++
+		entry.fileIndex = parseable
 
 		// Skip entries that are already consumed or previously failed.
 		if entry.Consumed || entry.FailedAt != nil {
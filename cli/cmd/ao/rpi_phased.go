@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -34,6 +35,11 @@ var (
 	phasedNoWorktree   bool
 	phasedLiveStatus   bool
 	phasedSwarmFirst   bool
+	phasedAgent        string
+	phasedPhaseAgents  map[string]string
+	phasedBackend      string
+	phasedFixture      string
+	phasedResolve      bool
 )
 
 // phaseFailureReason classifies why a phase spawn failed.
@@ -62,6 +68,23 @@ type phasedEngineOptions struct {
 	SwarmFirst         bool
 	NtmPollInterval    time.Duration
 	StallCheckInterval time.Duration
+	// Agent runs every phase (see ao rpi agents); empty uses rpi.agent
+	// from the configuration, then claude.
+	Agent string
+	// PhaseAgents overrides Agent per phase, keyed by phase name.
+	PhaseAgents map[string]string
+	// Backend forces an executor backend (direct, ntm, stream, replay);
+	// empty or "auto" selects one from the environment.
+	Backend string
+	// Fixture is the scenario directory played by the replay backend.
+	Fixture string
+	// ResolveConflicts runs a resolve phase when merging the worktree back
+	// conflicts, instead of leaving the conflict for a human.
+	ResolveConflicts bool
+	// Ctx is the parent context of the agent processes the run spawns;
+	// cancelling it stops them. Nil means context.Background(). The run
+	// controller derives the run's cancellable context from it.
+	Ctx context.Context `json:"-"`
 }
 
 // defaultPhasedEngineOptions returns options matching the default cobra flag values.
@@ -127,7 +150,18 @@ Examples:
   ao rpi phased --from=implementation "add auth" # skip to crank (needs epic)
   ao rpi phased --from=validation                # just vibe + post-mortem
   ao rpi phased --dry-run "add auth"             # show prompts without spawning
-  ao rpi phased --fast-path "fix typo"           # force --quick for gates`,
+  ao rpi phased --fast-path "fix typo"           # force --quick for gates
+  ao rpi phased --phase-agent discovery=codex "add auth"  # cheaper agent for discovery
+  ao rpi phased --backend replay --fixture ./scenario "add auth"  # scripted run, no agent
+
+Phases run with claude unless --agent, --phase-agent or rpi.agent and
+rpi.phase_agents in .agentops/config.yaml choose another agent. See
+'ao rpi agents' for the available agents and how to define your own.
+
+--backend replay plays back phase outputs scripted in <fixture>/scenario.yaml
+(artifacts, council reports, stream-json events, exit codes, delays and
+stalls) instead of spawning an agent, so gate retries and failures can be
+reproduced offline in CI.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: runRPIPhased,
 	}
@@ -142,6 +176,11 @@ Examples:
 	phasedCmd.Flags().BoolVar(&phasedNoWorktree, "no-worktree", false, "Disable worktree isolation (run in current directory)")
 	phasedCmd.Flags().BoolVar(&phasedLiveStatus, "live-status", false, "Stream phase progress to a live-status.md file")
 	phasedCmd.Flags().BoolVar(&phasedSwarmFirst, "swarm-first", true, "Default each phase to swarm/agent-team execution; fall back to direct execution if swarm runtime is unavailable")
+	phasedCmd.Flags().StringVar(&phasedAgent, "agent", "", "Coding agent for every phase (claude, codex, aider or one from rpi.agents)")
+	phasedCmd.Flags().StringToStringVar(&phasedPhaseAgents, "phase-agent", nil, "Coding agent for one phase, as phase=agent (repeatable)")
+	phasedCmd.Flags().StringVar(&phasedBackend, "backend", "auto", "Executor backend: auto, direct, ntm, stream or replay")
+	phasedCmd.Flags().StringVar(&phasedFixture, "fixture", "", "Scenario directory for --backend replay")
+	phasedCmd.Flags().BoolVar(&phasedResolve, "resolve-conflicts", false, "On a worktree merge conflict, spawn a resolve phase and re-run validation before merging")
 
 	rpiCmd.AddCommand(phasedCmd)
 }
@@ -213,15 +252,17 @@ type PhaseExecutor interface {
 }
 
 type directExecutor struct {
+	ctx          context.Context
 	phaseTimeout time.Duration
 }
 
 func (d *directExecutor) Name() string { return "direct" }
 func (d *directExecutor) Execute(prompt, cwd, runID string, phaseNum int) error {
-	return spawnClaudeDirectImpl(prompt, cwd, phaseNum, d.phaseTimeout)
+	return spawnClaudeDirectImpl(runContext(d.ctx), prompt, cwd, phaseNum, d.phaseTimeout)
 }
 
 type ntmExecutor struct {
+	ctx             context.Context
 	ntmPath         string
 	phaseTimeout    time.Duration
 	stallTimeout    time.Duration
@@ -230,10 +271,11 @@ type ntmExecutor struct {
 
 func (n *ntmExecutor) Name() string { return "ntm" }
 func (n *ntmExecutor) Execute(prompt, cwd, runID string, phaseNum int) error {
-	return spawnClaudePhaseNtm(n.ntmPath, prompt, cwd, runID, phaseNum, n.phaseTimeout, n.stallTimeout, n.ntmPollInterval)
+	return spawnClaudePhaseNtm(runContext(n.ctx), n.ntmPath, prompt, cwd, runID, phaseNum, n.phaseTimeout, n.stallTimeout, n.ntmPollInterval)
 }
 
 type streamExecutor struct {
+	ctx                context.Context
 	statusPath         string
 	allPhases          []PhaseProgress
 	phaseTimeout       time.Duration
@@ -243,7 +285,7 @@ type streamExecutor struct {
 
 func (s *streamExecutor) Name() string { return "stream" }
 func (s *streamExecutor) Execute(prompt, cwd, runID string, phaseNum int) error {
-	return spawnClaudePhaseWithStream(prompt, cwd, runID, phaseNum, s.statusPath, s.allPhases, s.phaseTimeout, s.stallTimeout, s.stallCheckInterval)
+	return spawnClaudePhaseWithStream(runContext(s.ctx), prompt, cwd, runID, phaseNum, s.statusPath, s.allPhases, s.phaseTimeout, s.stallTimeout, s.stallCheckInterval)
 }
 
 // backendCapabilities probes the runtime environment for executor prerequisites.
@@ -276,13 +318,40 @@ func probeBackendCapabilities(liveStatus bool) backendCapabilities {
 // opts provides timeout/interval values for the executors; use defaultPhasedEngineOptions()
 // when calling from tests that do not have a full opts available.
 //
-// Selection order (first match wins):
+// opts.Backend forces direct, ntm or stream. Otherwise the selection order
+// is (first match wins):
 //  1. stream  — caps.LiveStatusEnabled
 //  2. ntm     — caps.NtmPath non-empty and not in agent session
 //  3. direct  — unconditional fallback
 func selectExecutorFromCaps(caps backendCapabilities, statusPath string, allPhases []PhaseProgress, opts phasedEngineOptions) (PhaseExecutor, string) {
+	switch opts.Backend {
+	case "direct":
+		return &directExecutor{ctx: opts.Ctx, phaseTimeout: opts.PhaseTimeout}, "forced by --backend"
+	case "ntm":
+		ntmPath := caps.NtmPath
+		if ntmPath == "" {
+			ntmPath, _ = lookPath("ntm")
+		}
+		return &ntmExecutor{
+			ctx:             opts.Ctx,
+			ntmPath:         ntmPath,
+			phaseTimeout:    opts.PhaseTimeout,
+			stallTimeout:    opts.StallTimeout,
+			ntmPollInterval: opts.NtmPollInterval,
+		}, "forced by --backend"
+	case "stream":
+		return &streamExecutor{
+			ctx:                opts.Ctx,
+			statusPath:         statusPath,
+			allPhases:          allPhases,
+			phaseTimeout:       opts.PhaseTimeout,
+			stallTimeout:       opts.StallTimeout,
+			stallCheckInterval: opts.StallCheckInterval,
+		}, "forced by --backend"
+	}
 	if caps.LiveStatusEnabled {
 		return &streamExecutor{
+			ctx:                opts.Ctx,
 			statusPath:         statusPath,
 			allPhases:          allPhases,
 			phaseTimeout:       opts.PhaseTimeout,
@@ -291,17 +360,18 @@ func selectExecutorFromCaps(caps backendCapabilities, statusPath string, allPhas
 		}, "live-status enabled"
 	}
 	if caps.InAgentSession {
-		return &directExecutor{phaseTimeout: opts.PhaseTimeout}, "agent session detected (CLAUDECODE/CLAUDE_CODE_ENTRYPOINT set) — ntm suppressed"
+		return &directExecutor{ctx: opts.Ctx, phaseTimeout: opts.PhaseTimeout}, "agent session detected (CLAUDECODE/CLAUDE_CODE_ENTRYPOINT set) — ntm suppressed"
 	}
 	if caps.NtmPath != "" {
 		return &ntmExecutor{
+			ctx:             opts.Ctx,
 			ntmPath:         caps.NtmPath,
 			phaseTimeout:    opts.PhaseTimeout,
 			stallTimeout:    opts.StallTimeout,
 			ntmPollInterval: opts.NtmPollInterval,
 		}, fmt.Sprintf("ntm found at %s", caps.NtmPath)
 	}
-	return &directExecutor{phaseTimeout: opts.PhaseTimeout}, "ntm not found on PATH"
+	return &directExecutor{ctx: opts.Ctx, phaseTimeout: opts.PhaseTimeout}, "ntm not found on PATH"
 }
 
 // selectExecutor resolves the executor backend based on flags and environment.
@@ -439,6 +509,19 @@ func runRPIPhased(cmd *cobra.Command, args []string) error {
 		SwarmFirst:         phasedSwarmFirst,
 		NtmPollInterval:    ntmPollInterval,
 		StallCheckInterval: stallCheckInterval,
+		Agent:              phasedAgent,
+		PhaseAgents:        phasedPhaseAgents,
+		Backend:            phasedBackend,
+		Fixture:            phasedFixture,
+		ResolveConflicts:   phasedResolve,
+	}
+	if opts.Fixture != "" {
+		// runPhasedEngine may change directory; pin the fixture first.
+		abs, err := filepath.Abs(opts.Fixture)
+		if err != nil {
+			return fmt.Errorf("resolve fixture: %w", err)
+		}
+		opts.Fixture = abs
 	}
 	return runRPIPhasedWithOpts(opts, args)
 }
@@ -450,12 +533,34 @@ func validatePhasedInput(args []string, opts phasedEngineOptions) (cwd, goal str
 		return "", "", 0, fmt.Errorf("get working directory: %w", err)
 	}
 
-	// Pre-flight: check claude on PATH (skip in dry-run mode).
+	switch opts.Backend {
+	case "", "auto", "direct", "stream":
+	case "ntm":
+		if _, err := lookPath("ntm"); err != nil && !GetDryRun() {
+			return "", "", 0, fmt.Errorf("--backend ntm: ntm not found on PATH")
+		}
+	case "replay":
+		if _, err := loadReplayScenario(opts.Fixture); err != nil {
+			return "", "", 0, err
+		}
+	default:
+		return "", "", 0, fmt.Errorf("unknown backend %q (valid: auto, direct, ntm, stream, replay)", opts.Backend)
+	}
+
+	// Pre-flight: check each phase's agent on PATH (skip in dry-run mode).
 	// Dry-run never spawns phase sessions and should remain CI-safe even when
-	// claude CLI is unavailable.
-	if !GetDryRun() {
-		if _, err := exec.LookPath("claude"); err != nil {
-			return "", "", 0, fmt.Errorf("claude CLI not found on PATH (required for spawning phase sessions)")
+	// the agent CLIs are unavailable. Replay never spawns an agent either.
+	rpiCfg, err := loadRPIConfig()
+	if err != nil {
+		return "", "", 0, err
+	}
+	agents, err := resolvePhaseAgents(opts, rpiCfg)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if !GetDryRun() && opts.Backend != "replay" {
+		if err := checkPhaseAgentsAvailable(agents); err != nil {
+			return "", "", 0, err
 		}
 	}
 
@@ -574,13 +679,28 @@ func setupRuntimeEnv(spawnCwd string, startPhase int, state *phasedState, opts p
 
 // worktreeCleanup merges and removes a worktree on success, or preserves it
 // for debugging on failure. Returns an error only if merge or removal fails.
-func worktreeCleanup(originalCwd, worktreePath, worktreeRunID, logPath, runID string, cleanupSuccess bool) error {
+// A merge conflict is recorded to the ledger and, when resolve is non-nil,
+// handed to it before the merge is retried once.
+func worktreeCleanup(originalCwd, worktreePath, worktreeRunID, logPath string, state *phasedState, cleanupSuccess bool, resolve func(*mergeConflictError) error) error {
 	if !cleanupSuccess {
 		fmt.Fprintf(os.Stderr, "Worktree preserved for debugging: %s\n", worktreePath)
 		return nil
 	}
-
-	if mergeErr := mergeWorktree(originalCwd, worktreeRunID); mergeErr != nil {
+	runID := state.RunID
+
+	mergeErr := mergeWorktree(originalCwd, worktreeRunID)
+	var conflict *mergeConflictError
+	if errors.As(mergeErr, &conflict) {
+		recordMergeConflict(originalCwd, worktreePath, runID, state, conflict)
+		if resolve != nil {
+			if resolveErr := resolve(conflict); resolveErr != nil {
+				fmt.Fprintf(os.Stderr, "Conflict resolution failed: %v\n", resolveErr)
+			} else if mergeErr = mergeWorktree(originalCwd, worktreeRunID); mergeErr == nil {
+				recordConflictEvent(originalCwd, runID, "merged", map[string]any{"branch": conflict.Branch})
+			}
+		}
+	}
+	if mergeErr != nil {
 		fmt.Fprintf(os.Stderr, "Merge failed: %v\nWorktree preserved at: %s\n", mergeErr, worktreePath)
 		return fmt.Errorf("worktree merge failed: %w", mergeErr)
 	}
@@ -596,8 +716,8 @@ func worktreeCleanup(originalCwd, worktreePath, worktreeRunID, logPath, runID st
 
 // executeSinglePhase runs one phase of the RPI lifecycle: builds prompt,
 // executes via the backend, handles errors/retries, writes artifacts, and saves
-// state. Returns (true, nil) when a gate retry succeeded and the caller should
-// skip to the next iteration.
+// state. Returns (true, nil) when the phase's gate failed and then passed on
+// retry; the phase is finished the same way as a first-try pass.
 func executeSinglePhase(
 	p phase, i, startPhase int,
 	cwd, spawnCwd string,
@@ -621,7 +741,7 @@ func executeSinglePhase(
 	}
 
 	if GetDryRun() {
-		fmt.Printf("[dry-run] Would spawn: claude -p '%s'\n", prompt)
+		fmt.Printf("[dry-run] Would spawn: %s '%s'\n", spawnLabel(executor, i), prompt)
 		if !opts.NoWorktree && i == startPhase {
 			runID := generateRunID()
 			fmt.Printf("[dry-run] Would create worktree: ../%s-rpi-%s/ (branch: rpi/%s)\n",
@@ -631,7 +751,7 @@ func executeSinglePhase(
 		return false, nil
 	}
 
-	fmt.Printf("Spawning: claude -p '%s'\n", prompt)
+	fmt.Printf("Spawning: %s '%s'\n", spawnLabel(executor, i), prompt)
 	start := time.Now()
 	updateRunHeartbeat(spawnCwd, state.RunID)
 
@@ -673,17 +793,20 @@ func executeSinglePhase(
 	updateRunHeartbeat(spawnCwd, state.RunID)
 
 	if err := postPhaseProcessing(spawnCwd, state, i, logPath); err != nil {
-		if retryErr, ok := err.(*gateFailError); ok {
-			retried, retryErr2 := handleGateRetry(spawnCwd, state, i, retryErr, logPath, spawnCwd, statusPath, allPhases, executor)
-			if retryErr2 != nil {
-				return false, logAndFail(p.Name, retryErr2)
-			}
-			if !retried {
-				return false, logAndFail(p.Name, fmt.Errorf("phase %d (%s): gate failed after max retries", i, p.Name))
-			}
-			return true, nil
+		gateErr, ok := err.(*gateFailError)
+		if !ok {
+			return false, logAndFail(p.Name, err)
+		}
+		retried, retryErr := handleGateRetry(spawnCwd, state, i, gateErr, logPath, spawnCwd, statusPath, allPhases, executor)
+		if retryErr != nil {
+			return false, logAndFail(p.Name, retryErr)
+		}
+		if !retried {
+			return false, logAndFail(p.Name, fmt.Errorf("phase %d (%s): gate failed after max retries", i, p.Name))
 		}
-		return false, logAndFail(p.Name, err)
+		// The gate passed on retry; finish the phase like a first-try pass
+		// so its summary, checkpoint and verdicts are recorded.
+		retry = true
 	}
 
 	if handoffDetected(spawnCwd, i) {
@@ -698,7 +821,7 @@ func executeSinglePhase(
 		VerbosePrintf("Warning: could not save state: %v\n", err)
 	}
 
-	return false, nil
+	return retry, nil
 }
 
 func runRPIPhasedWithOpts(opts phasedEngineOptions, args []string) (retErr error) {
@@ -717,6 +840,16 @@ func runRPIPhasedWithOpts(opts phasedEngineOptions, args []string) (retErr error
 	// logPath is declared here so it is in scope for the deferred worktree cleanup.
 	var logPath string
 
+	// resolveConflict is set once the executor is known; the deferred
+	// worktree cleanup hands merge conflicts to it.
+	var resolveConflict func(*mergeConflictError) error
+	// ctl applies pause/resume/cancel requests; a cancelled run's worktree
+	// is removed rather than merged or preserved. Its context is released
+	// only after the worktree cleanup below, whose conflict resolver and
+	// revalidation run phases on it.
+	var ctl *runController
+	defer func() { ctl.release() }()
+
 	// Create worktree for isolation (unless resuming into existing one, or opted out).
 	cleanupSuccess := false
 	var worktreeRunID string
@@ -743,7 +876,9 @@ func runRPIPhasedWithOpts(opts phasedEngineOptions, args []string) (retErr error
 		defer func() {
 			signal.Stop(sigCh)
 			close(sigCh)
-			if cleanupErr := worktreeCleanup(originalCwd, worktreePath, worktreeRunID, logPath, state.RunID, cleanupSuccess); cleanupErr != nil && retErr == nil {
+			if ctl.isCancelled() {
+				ctl.removeWorktree(originalCwd, worktreePath, worktreeRunID)
+			} else if cleanupErr := worktreeCleanup(originalCwd, worktreePath, worktreeRunID, logPath, state, cleanupSuccess, resolveConflict); cleanupErr != nil && retErr == nil {
 				retErr = cleanupErr
 			}
 		}()
@@ -767,22 +902,84 @@ func runRPIPhasedWithOpts(opts phasedEngineOptions, args []string) (retErr error
 		return err
 	}
 
+	if !GetDryRun() && state.RunID != "" {
+		ctl = startRunController(runContext(opts.Ctx), spawnCwd, originalCwd, state.RunID, logPath)
+		defer ctl.stop()
+		opts.Ctx = ctl.ctx
+	}
+
 	// Resolve executor backend once for the entire run.
 	// selectExecutorWithLog records the selection and reason to the orchestration log.
-	executor := selectExecutorWithLog(statusPath, allPhases, logPath, state.RunID, opts.LiveStatus, opts)
+	var executor PhaseExecutor
+	if opts.Backend == "replay" {
+		scenario, err := loadReplayScenario(opts.Fixture)
+		if err != nil {
+			return err
+		}
+		replay := newReplayExecutor(scenario, statusPath, allPhases, opts)
+		oldBD := bdOutput
+		bdOutput = replay.bdOutput
+		defer func() { bdOutput = oldBD }()
+		executor = replay
+		reason := fmt.Sprintf("fixture %s", opts.Fixture)
+		fmt.Printf("Executor backend: %s (%s)\n", executor.Name(), reason)
+		logPhaseTransition(logPath, state.RunID, "backend-selection", fmt.Sprintf("backend=%s reason=%q", executor.Name(), reason))
+	} else {
+		executor = selectExecutorWithLog(statusPath, allPhases, logPath, state.RunID, opts.LiveStatus, opts)
+	}
+	rpiCfg, err := loadRPIConfig()
+	if err != nil {
+		return err
+	}
+	agents, err := resolvePhaseAgents(opts, rpiCfg)
+	if err != nil {
+		return err
+	}
+	// replay stands in for every agent, so phase agents only wrap real backends
+	if wrapped := withPhaseAgents(executor, agents, statusPath, allPhases, opts); opts.Backend != "replay" && wrapped != executor {
+		executor = wrapped
+		fmt.Printf("Phase agents: %s\n", executor.Name())
+		logPhaseTransition(logPath, state.RunID, "backend-selection", fmt.Sprintf("backend=%s reason=%q", executor.Name(), "phase agents"))
+	}
 	state.Backend = executor.Name()
 
+	if opts.ResolveConflicts && state.WorktreePath != "" {
+		validation := phases[len(phases)-1]
+		resolver := &conflictResolver{
+			repoRoot: originalCwd,
+			worktree: spawnCwd,
+			state:    state,
+			executor: executor,
+			logPath:  logPath,
+			revalidate: func() error {
+				state.Phase = validation.Num
+				_, err := executeSinglePhase(validation, validation.Num, startPhase, cwd, spawnCwd, state, opts, executor, logPath, statusPath, allPhases, logAndFail)
+				return err
+			},
+		}
+		resolveConflict = resolver.resolve
+	}
+
 	// Execute phases sequentially
 	for i := startPhase; i <= len(phases); i++ {
 		p := phases[i-1]
 		fmt.Printf("\n--- Phase %d: %s ---\n", p.Num, p.Name)
 		state.Phase = i
+		if err := ctl.checkpoint(i); err != nil {
+			return err
+		}
 
 		_, err := executeSinglePhase(p, i, startPhase, cwd, spawnCwd, state, opts, executor, logPath, statusPath, allPhases, logAndFail)
+		if ctl.isCancelled() {
+			return ctl.cancelErr()
+		}
 		if err != nil {
 			return err
 		}
 	}
+	if ctl.isCancelled() {
+		return ctl.cancelErr()
+	}
 
 	// All phases completed — mark worktree for merge+cleanup.
 	cleanupSuccess = true
@@ -1042,12 +1239,12 @@ func handleGateRetry(cwd string, state *phasedState, phaseNum int, gateErr *gate
 	}
 
 	if GetDryRun() {
-		fmt.Printf("[dry-run] Would spawn retry: claude -p '%s'\n", retryPrompt)
+		fmt.Printf("[dry-run] Would spawn retry: %s '%s'\n", spawnLabel(executor, phaseNum), retryPrompt)
 		return false, nil
 	}
 
 	// Spawn retry session
-	fmt.Printf("Spawning retry: claude -p '%s'\n", retryPrompt)
+	fmt.Printf("Spawning retry: %s '%s'\n", spawnLabel(executor, phaseNum), retryPrompt)
 	if state.Opts.LiveStatus {
 		updateLivePhaseStatus(statusPath, allPhases, phaseNum, "running retry prompt", attempt, "")
 	}
@@ -1321,23 +1518,24 @@ func spawnClaudePhase(prompt, cwd, runID string, phaseNum int) error {
 	// ntm is for human-observable tmux sessions; agent-spawned runs should
 	// use direct exec to avoid interactive prompts and stdin issues.
 	if os.Getenv("CLAUDECODE") != "" || os.Getenv("CLAUDE_CODE_ENTRYPOINT") != "" {
-		return spawnDirectFn(prompt, cwd, phaseNum)
+		return spawnDirectFn(context.Background(), prompt, cwd, phaseNum)
 	}
 	// Check if ntm is available for observable sessions
 	ntmPath, ntmErr := lookPath("ntm")
 	if ntmErr == nil {
-		return spawnClaudePhaseNtm(ntmPath, prompt, cwd, runID, phaseNum, phasedPhaseTimeout, phasedStallTimeout, ntmPollInterval)
+		return spawnClaudePhaseNtm(context.Background(), ntmPath, prompt, cwd, runID, phaseNum, phasedPhaseTimeout, phasedStallTimeout, ntmPollInterval)
 	}
-	return spawnDirectFn(prompt, cwd, phaseNum)
+	return spawnDirectFn(context.Background(), prompt, cwd, phaseNum)
 }
 
 // spawnClaudeDirectImpl runs claude -p directly (fallback when ntm unavailable).
 // phaseTimeout controls the maximum runtime; pass 0 to disable the timeout.
-func spawnClaudeDirectImpl(prompt, cwd string, phaseNum int, phaseTimeout time.Duration) error {
-	ctx := context.Background()
+// Cancelling parent stops the process.
+func spawnClaudeDirectImpl(parent context.Context, prompt, cwd string, phaseNum int, phaseTimeout time.Duration) error {
+	ctx := parent
 	cancel := func() {}
 	if phaseTimeout > 0 {
-		ctx, cancel = context.WithTimeout(context.Background(), phaseTimeout)
+		ctx, cancel = context.WithTimeout(parent, phaseTimeout)
 	}
 	defer cancel()
 
@@ -1363,8 +1561,8 @@ func spawnClaudeDirectImpl(prompt, cwd string, phaseNum int, phaseTimeout time.D
 // spawnClaudePhaseNtm wraps a claude session inside an ntm-managed tmux pane.
 // Session name: ao-rpi-<runID>-p<phaseNum>. Attach with: ntm attach <name>.
 // phaseTimeout, stallTimeout, and pollInterval are passed explicitly so the function
-// does not read package-level globals.
-func spawnClaudePhaseNtm(ntmPath, prompt, cwd, runID string, phaseNum int, phaseTimeout, stallTimeout, pollInterval time.Duration) error {
+// does not read package-level globals. Cancelling ctx kills the session.
+func spawnClaudePhaseNtm(ctx context.Context, ntmPath, prompt, cwd, runID string, phaseNum int, phaseTimeout, stallTimeout, pollInterval time.Duration) error {
 	sessionName := fmt.Sprintf("ao-rpi-%s-p%d", runID, phaseNum)
 	fmt.Printf("ntm session: %s (attach with: ntm attach %s)\n", sessionName, sessionName)
 
@@ -1374,7 +1572,7 @@ func spawnClaudePhaseNtm(ntmPath, prompt, cwd, runID string, phaseNum int, phase
 	spawnCmd.Env = cleanEnvNoClaude()
 	if out, err := spawnCmd.CombinedOutput(); err != nil {
 		fmt.Printf("ntm spawn failed, falling back to direct exec: %s\n", string(out))
-		return spawnDirectFn(prompt, cwd, phaseNum)
+		return spawnDirectFn(ctx, prompt, cwd, phaseNum)
 	}
 
 	// Send the prompt to the claude agent
@@ -1384,7 +1582,7 @@ func spawnClaudePhaseNtm(ntmPath, prompt, cwd, runID string, phaseNum int, phase
 		fmt.Printf("ntm send failed, falling back to direct exec: %s\n", string(out))
 		// Clean up session on failure and fall back
 		_ = exec.Command(ntmPath, "kill", sessionName).Run()
-		return spawnDirectFn(prompt, cwd, phaseNum)
+		return spawnDirectFn(ctx, prompt, cwd, phaseNum)
 	}
 
 	// Poll for session completion (agent exits when prompt completes)
@@ -1405,6 +1603,9 @@ func spawnClaudePhaseNtm(ntmPath, prompt, cwd, runID string, phaseNum int, phase
 		case <-timeout:
 			_ = exec.Command(ntmPath, "kill", sessionName).Run() //nolint:errcheck
 			return fmt.Errorf("phase %d (%s) timed out after %s (set --phase-timeout to increase)", phaseNum, failReasonTimeout, phaseTimeout)
+		case <-ctx.Done():
+			_ = exec.Command(ntmPath, "kill", sessionName).Run() //nolint:errcheck
+			return fmt.Errorf("phase %d: %w", phaseNum, ctx.Err())
 		case <-time.After(pollInterval):
 			checkCmd := exec.Command("tmux", "has-session", "-t", sessionName)
 			if err := checkCmd.Run(); err != nil {
@@ -1442,12 +1643,12 @@ func spawnClaudePhaseNtm(ntmPath, prompt, cwd, runID string, phaseNum int, phase
 // external watchers (e.g. ao status) can tail the status file.
 // Stderr is passed through to os.Stderr for real-time error visibility.
 // phaseTimeout, stallTimeout, and checkInterval are passed explicitly so the function
-// does not read package-level globals.
-func spawnClaudePhaseWithStream(prompt, cwd, runID string, phaseNum int, statusPath string, allPhases []PhaseProgress, phaseTimeout, stallTimeout, checkInterval time.Duration) error {
-	ctx := context.Background()
+// does not read package-level globals. Cancelling parent stops the process.
+func spawnClaudePhaseWithStream(parent context.Context, prompt, cwd, runID string, phaseNum int, statusPath string, allPhases []PhaseProgress, phaseTimeout, stallTimeout, checkInterval time.Duration) error {
+	ctx := parent
 	cancel := func() {}
 	if phaseTimeout > 0 {
-		ctx, cancel = context.WithTimeout(context.Background(), phaseTimeout)
+		ctx, cancel = context.WithTimeout(parent, phaseTimeout)
 	}
 	defer cancel()
 
@@ -1462,22 +1663,7 @@ func spawnClaudePhaseWithStream(prompt, cwd, runID string, phaseNum int, statusP
 
 	// Start stall watchdog goroutine (if stall timeout is configured).
 	if stallTimeout > 0 {
-		go func() {
-			ticker := time.NewTicker(checkInterval)
-			defer ticker.Stop()
-			for {
-				select {
-				case <-stallCtx.Done():
-					return
-				case <-ticker.C:
-					last := time.Unix(0, lastActivityUnix.Load())
-					if time.Since(last) > stallTimeout {
-						stallCancel(fmt.Errorf("stall detected: no stream activity for %s", stallTimeout))
-						return
-					}
-				}
-			}
-		}()
+		go watchPhaseStall(stallCtx, stallCancel, &lastActivityUnix, stallTimeout, checkInterval, "stream")
 	}
 
 	cmd := exec.CommandContext(stallCtx, "claude", "-p", prompt, "--output-format", "stream-json", "--verbose")
@@ -1501,22 +1687,7 @@ func spawnClaudePhaseWithStream(prompt, cwd, runID string, phaseNum int, statusP
 		// Record activity for stall detection.
 		lastActivityUnix.Store(time.Now().UnixNano())
 
-		if phaseIdx >= 0 && phaseIdx < len(allPhases) {
-			existing := allPhases[phaseIdx]
-			if p.Name == "" {
-				p.Name = existing.Name
-			}
-			if p.CurrentAction == "" {
-				p.CurrentAction = existing.CurrentAction
-			}
-			if p.RetryCount == 0 {
-				p.RetryCount = existing.RetryCount
-			}
-			if p.LastError == "" {
-				p.LastError = existing.LastError
-			}
-			allPhases[phaseIdx] = p
-		}
+		mergePhaseProgress(allPhases, phaseIdx, p)
 		if writeErr := WriteLiveStatus(statusPath, allPhases, phaseIdx); writeErr != nil {
 			VerbosePrintf("Warning: could not write live status: %v\n", writeErr)
 		}
@@ -1547,6 +1718,47 @@ func spawnClaudePhaseWithStream(prompt, cwd, runID string, phaseNum int, statusP
 	return nil
 }
 
+// watchPhaseStall cancels ctx with a stall error once lastActivity (unix
+// nanoseconds) is older than stallTimeout. kind names the activity watched.
+func watchPhaseStall(ctx context.Context, cancel context.CancelCauseFunc, lastActivity *atomic.Int64, stallTimeout, checkInterval time.Duration, kind string) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			last := time.Unix(0, lastActivity.Load())
+			if time.Since(last) > stallTimeout {
+				cancel(fmt.Errorf("stall detected: no %s activity for %s", kind, stallTimeout))
+				return
+			}
+		}
+	}
+}
+
+// mergePhaseProgress stores p as the progress of phase phaseIdx, keeping
+// the fields p leaves empty.
+func mergePhaseProgress(allPhases []PhaseProgress, phaseIdx int, p PhaseProgress) {
+	if phaseIdx < 0 || phaseIdx >= len(allPhases) {
+		return
+	}
+	existing := allPhases[phaseIdx]
+	if p.Name == "" {
+		p.Name = existing.Name
+	}
+	if p.CurrentAction == "" {
+		p.CurrentAction = existing.CurrentAction
+	}
+	if p.RetryCount == 0 {
+		p.RetryCount = existing.RetryCount
+	}
+	if p.LastError == "" {
+		p.LastError = existing.LastError
+	}
+	allPhases[phaseIdx] = p
+}
+
 func updateLivePhaseStatus(statusPath string, allPhases []PhaseProgress, phaseNum int, action string, retries int, lastErr string) {
 	phaseIdx := phaseNum - 1
 	if phaseIdx < 0 || phaseIdx >= len(allPhases) {
@@ -1704,8 +1916,7 @@ func extractCouncilFindings(reportPath string, max int) ([]finding, error) {
 // bd list returns epics in creation order; we take the LAST match so that
 // the epic just created by the plan phase is selected over older ones.
 func extractEpicID() (string, error) {
-	cmd := exec.Command("bd", "list", "--type", "epic", "--status", "open")
-	out, err := cmd.Output()
+	out, err := bdOutput("list", "--type", "epic", "--status", "open")
 	if err != nil {
 		return "", fmt.Errorf("bd list: %w", err)
 	}
@@ -1721,8 +1932,7 @@ func extractEpicID() (string, error) {
 
 // detectFastPath checks if an epic is a micro-epic (≤2 issues, no blockers).
 func detectFastPath(epicID string) (bool, error) {
-	cmd := exec.Command("bd", "children", epicID)
-	out, err := cmd.Output()
+	out, err := bdOutput("children", epicID)
 	if err != nil {
 		return false, fmt.Errorf("bd children: %w", err)
 	}
@@ -1749,8 +1959,7 @@ func parseFastPath(output string) bool {
 // checkCrankCompletion checks epic completion via bd children statuses.
 // Returns "DONE", "BLOCKED", or "PARTIAL".
 func checkCrankCompletion(epicID string) (string, error) {
-	cmd := exec.Command("bd", "children", epicID)
-	out, err := cmd.Output()
+	out, err := bdOutput("children", epicID)
 	if err != nil {
 		return "", fmt.Errorf("bd children: %w", err)
 	}
@@ -2017,18 +2226,26 @@ func mergeWorktree(repoRoot, runID string) error {
 		if ctx.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("git merge timed out after %s", worktreeTimeout)
 		}
-		// Detect conflict files.
-		conflictCmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
-		conflictCmd.Dir = repoRoot
-		conflictOut, _ := conflictCmd.Output()
+		// Detect conflict files and capture their hunks before aborting.
+		files := unmergedFiles(repoRoot)
+		var hunks []conflictHunk
+		if len(files) > 0 {
+			hunks = captureConflictHunks(repoRoot, files)
+		}
 		// Abort the merge to leave repo clean.
 		abortCmd := exec.Command("git", "merge", "--abort")
 		abortCmd.Dir = repoRoot
 		_ = abortCmd.Run() //nolint:errcheck
-		files := strings.TrimSpace(string(conflictOut))
-		if files != "" {
-			return fmt.Errorf("merge conflict in %s.\nConflicting files:\n%s\nResolve manually: cd %s && git merge %s",
-				branchName, files, repoRoot, branchName)
+		if len(files) > 0 {
+			ours, _ := getCurrentBranch(repoRoot) //nolint:errcheck // label only
+			return &mergeConflictError{
+				Branch:   branchName,
+				RepoRoot: repoRoot,
+				Ours:     ours,
+				Theirs:   branchName,
+				Files:    files,
+				Hunks:    hunks,
+			}
 		}
 		return fmt.Errorf("git merge failed: %w", err)
 	}
@@ -2482,10 +2699,16 @@ func logFailureContext(logPath, runID, phase string, err error) {
 // lookPath is the function used to resolve binary paths. Package-level for testability.
 var lookPath = exec.LookPath
 
+// bdOutput runs bd with args and returns its stdout. Package-level so tests
+// and the replay backend can script the issue tracker.
+var bdOutput = func(args ...string) ([]byte, error) {
+	return exec.Command("bd", args...).Output()
+}
+
 // spawnClaudeDirectGlobal is the package-level wrapper for spawnClaudeDirectImpl that
 // reads phasedPhaseTimeout from the global (for spawnClaudePhase / spawnDirectFn fallback paths).
-func spawnClaudeDirectGlobal(prompt, cwd string, phaseNum int) error {
-	return spawnClaudeDirectImpl(prompt, cwd, phaseNum, phasedPhaseTimeout)
+func spawnClaudeDirectGlobal(ctx context.Context, prompt, cwd string, phaseNum int) error {
+	return spawnClaudeDirectImpl(ctx, prompt, cwd, phaseNum, phasedPhaseTimeout)
 }
 
 // spawnDirectFn is the function used to spawn claude directly. Package-level for testability.
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -24,7 +25,7 @@ func TestSpawnClaudePhase_FallbackNoNtm(t *testing.T) {
 	directCalled := false
 	var capturedPrompt, capturedCwd string
 	var capturedPhase int
-	spawnDirectFn = func(prompt, cwd string, phaseNum int) error {
+	spawnDirectFn = func(_ context.Context, prompt, cwd string, phaseNum int) error {
 		directCalled = true
 		capturedPrompt = prompt
 		capturedCwd = cwd
@@ -72,7 +73,7 @@ func TestSpawnClaudePhase_NtmAvailable(t *testing.T) {
 
 	// Track whether the direct path is called (it should be, as ntm spawn will fail).
 	directCalled := false
-	spawnDirectFn = func(prompt, cwd string, phaseNum int) error {
+	spawnDirectFn = func(_ context.Context, prompt, cwd string, phaseNum int) error {
 		directCalled = true
 		return nil
 	}
@@ -103,7 +104,7 @@ func TestSpawnClaudePhase_DirectError(t *testing.T) {
 	}
 
 	expectedErr := fmt.Errorf("claude process crashed")
-	spawnDirectFn = func(prompt, cwd string, phaseNum int) error {
+	spawnDirectFn = func(_ context.Context, prompt, cwd string, phaseNum int) error {
 		return expectedErr
 	}
 
@@ -540,3 +540,59 @@ func (m *mockExecutor) Execute(prompt, cwd, runID string, phaseNum int) error {
 	}
 	return nil
 }
+
+// TestExecuteSinglePhase_GateRetryFinishesPhase verifies that a phase whose
+// gate fails once and then passes on retry is finished like a first-try
+// pass: the verdict, summary and state are all recorded.
+func TestExecuteSinglePhase_GateRetryFinishesPhase(t *testing.T) {
+	dir := t.TempDir()
+	councilDir := filepath.Join(dir, ".agents", "council")
+	if err := os.MkdirAll(councilDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	exec := &mockExecutor{
+		name: "mock",
+		executeFn: func(prompt, cwd, runID string, phaseNum int) error {
+			calls++
+			switch calls {
+			case 1:
+				return os.WriteFile(filepath.Join(councilDir, "2026-01-01a-vibe-ag-test1.md"),
+					[]byte("## Council Verdict: FAIL\n"), 0644)
+			case 3:
+				return os.WriteFile(filepath.Join(councilDir, "2026-01-01b-vibe-ag-test1.md"),
+					[]byte("## Council Verdict: PASS\n"), 0644)
+			}
+			return nil
+		},
+	}
+
+	opts := defaultPhasedEngineOptions()
+	opts.LiveStatus = false
+	state := newTestPhasedState().WithEpicID("ag-test1").WithPhase(3).WithRunID("run-retry").WithOpts(opts)
+	logPath := filepath.Join(dir, "phased-orchestration.log")
+	logAndFail := func(_ string, err error) error { return err }
+
+	retry, err := executeSinglePhase(phases[2], 3, 3, dir, dir, state, opts, exec, logPath, "", nil, logAndFail)
+	if err != nil {
+		t.Fatalf("executeSinglePhase: %v", err)
+	}
+	if !retry {
+		t.Error("expected retry=true after the gate passed on retry")
+	}
+	if calls != 3 {
+		t.Errorf("executor calls = %d, want 3 (phase, retry, rerun)", calls)
+	}
+
+	saved, err := loadPhasedState(dir)
+	if err != nil {
+		t.Fatalf("loadPhasedState: %v", err)
+	}
+	if got := saved.Verdicts["vibe"]; got != "PASS" {
+		t.Errorf("saved vibe verdict = %q, want PASS", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".agents", "rpi", "phase-3-summary.md")); err != nil {
+		t.Errorf("phase summary not written after retry pass: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -23,7 +24,7 @@ func TestSpawnClaudeDirectImpl_TimesOut(t *testing.T) {
 	binDir := writeFakeClaude(t, "#!/bin/sh\nsleep 5\n")
 	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
 
-	err := spawnClaudeDirectImpl("test prompt", t.TempDir(), 2, 150*time.Millisecond)
+	err := spawnClaudeDirectImpl(context.Background(), "test prompt", t.TempDir(), 2, 150*time.Millisecond)
 	if err == nil {
 		t.Fatal("expected timeout error")
 	}
@@ -40,7 +41,7 @@ func TestSpawnClaudePhaseWithStream_TimesOut(t *testing.T) {
 	statusPath := filepath.Join(tmpDir, "live-status.md")
 	allPhases := []PhaseProgress{{Name: "discovery", CurrentAction: "starting"}}
 
-	err := spawnClaudePhaseWithStream("test prompt", tmpDir, "run-1", 1, statusPath, allPhases, 200*time.Millisecond, 0, 30*time.Second)
+	err := spawnClaudePhaseWithStream(context.Background(), "test prompt", tmpDir, "run-1", 1, statusPath, allPhases, 200*time.Millisecond, 0, 30*time.Second)
 	if err == nil {
 		t.Fatal("expected timeout error")
 	}
@@ -64,7 +65,7 @@ func TestSpawnClaudePhaseWithStream_StallDetected(t *testing.T) {
 	allPhases := []PhaseProgress{{Name: "discovery", CurrentAction: "starting"}}
 
 	// phaseTimeout=0 disables hard timeout; stallTimeout=100ms with checkInterval=50ms fires quickly.
-	err := spawnClaudePhaseWithStream("test prompt", tmpDir, "run-stall", 1, statusPath, allPhases, 0, 100*time.Millisecond, 50*time.Millisecond)
+	err := spawnClaudePhaseWithStream(context.Background(), "test prompt", tmpDir, "run-stall", 1, statusPath, allPhases, 0, 100*time.Millisecond, 50*time.Millisecond)
 	if err == nil {
 		t.Fatal("expected stall error")
 	}
@@ -84,7 +85,7 @@ func TestSpawnClaudePhaseNtm_TimesOut(t *testing.T) {
 	}()
 
 	// spawnDirectFn must not be called — if the ntm path falls back we'd miss the test.
-	spawnDirectFn = func(prompt, cwd string, phaseNum int) error {
+	spawnDirectFn = func(_ context.Context, prompt, cwd string, phaseNum int) error {
 		t.Error("unexpected fallback to spawnDirectFn")
 		return nil
 	}
@@ -111,7 +112,7 @@ func TestSpawnClaudePhaseNtm_TimesOut(t *testing.T) {
 	t.Setenv("PATH", tmpBin+":"+os.Getenv("PATH"))
 
 	// phaseTimeout=150ms, stallTimeout=0 (disabled), pollInterval=50ms.
-	err := spawnClaudePhaseNtm(fakentm, "test prompt", t.TempDir(), "run-ntm-timeout", 2, 150*time.Millisecond, 0, 50*time.Millisecond)
+	err := spawnClaudePhaseNtm(context.Background(), fakentm, "test prompt", t.TempDir(), "run-ntm-timeout", 2, 150*time.Millisecond, 0, 50*time.Millisecond)
 	if err == nil {
 		t.Fatal("expected timeout error from ntm executor")
 	}
@@ -131,7 +132,7 @@ func TestSpawnClaudePhaseNtm_StallDetected(t *testing.T) {
 		spawnDirectFn = origDirect
 	}()
 
-	spawnDirectFn = func(prompt, cwd string, phaseNum int) error {
+	spawnDirectFn = func(_ context.Context, prompt, cwd string, phaseNum int) error {
 		t.Error("unexpected fallback to spawnDirectFn")
 		return nil
 	}
@@ -160,7 +161,7 @@ esac
 	t.Setenv("PATH", tmpBin+":"+os.Getenv("PATH"))
 
 	// phaseTimeout=0 (disabled), stallTimeout=80ms, pollInterval=40ms.
-	err := spawnClaudePhaseNtm(fakentm, "test prompt", t.TempDir(), "run-ntm-stall", 3, 0, 80*time.Millisecond, 40*time.Millisecond)
+	err := spawnClaudePhaseNtm(context.Background(), fakentm, "test prompt", t.TempDir(), "run-ntm-stall", 3, 0, 80*time.Millisecond, 40*time.Millisecond)
 	if err == nil {
 		t.Fatal("expected stall error from ntm executor")
 	}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// replayScenarioFile is the scenario description inside a fixture directory.
+const replayScenarioFile = "scenario.yaml"
+
+// replayTranscriptFile records each replayed invocation, under .agents/rpi.
+const replayTranscriptFile = "replay-transcript.jsonl"
+
+// replayScenario scripts a phased run for the replay backend. Each phase
+// lists one step per invocation: the first run of a phase plays its first
+// step, a retry or re-run the next one.
+//
+//	bd:
+//	  list: "ag-demo  Epic: add auth  open"
+//	  children: "ag-demo.1  closed"
+//	phases:
+//	  discovery:
+//	    - files:
+//	        - path: .agents/council/2026-01-01-pre-mortem-ag-demo.md
+//	          from: reports/pre-mortem-pass.md
+//	      events: streams/discovery.jsonl
+//	  validation:
+//	    - exit_code: 1
+//	    - stall: true
+type replayScenario struct {
+	Name string `yaml:"name"`
+	// BD maps a bd subcommand (list, children) to its scripted output.
+	BD     map[string]string       `yaml:"bd"`
+	Phases map[string][]replayStep `yaml:"phases"`
+
+	dir   string
+	steps map[int][]replayStep
+}
+
+// replayStep is one scripted phase invocation, played in field order:
+// events, files, stdout, delay, then stall or exit.
+type replayStep struct {
+	// Events is a stream-json file (Claude stream events) fed to the live
+	// status, one line every EventInterval.
+	Events        string        `yaml:"events"`
+	EventInterval time.Duration `yaml:"event_interval"`
+
+	// Files are artifacts the phase writes, relative to the run directory.
+	Files []replayFile `yaml:"files"`
+
+	// Stdout is printed as the agent's output.
+	Stdout string `yaml:"stdout"`
+
+	// Delay is how long the phase runs; past --phase-timeout it times out.
+	Delay time.Duration `yaml:"delay"`
+
+	// Stall goes silent until --stall-timeout (or --phase-timeout) fires.
+	Stall bool `yaml:"stall"`
+
+	// ExitCode fails the phase when non-zero.
+	ExitCode int `yaml:"exit_code"`
+
+	// BD replaces scripted bd output from this step on.
+	BD map[string]string `yaml:"bd"`
+}
+
+// replayFile is an artifact written by a replayed phase.
+type replayFile struct {
+	Path    string `yaml:"path"`
+	From    string `yaml:"from"`
+	Content string `yaml:"content"`
+}
+
+// loadReplayScenario reads and validates dir/scenario.yaml.
+func loadReplayScenario(dir string) (*replayScenario, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("replay backend needs --fixture <dir>")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve fixture: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(abs, replayScenarioFile))
+	if err != nil {
+		return nil, fmt.Errorf("read replay fixture: %w", err)
+	}
+	var sc replayScenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", replayScenarioFile, err)
+	}
+	sc.dir = abs
+	if err := sc.validate(); err != nil {
+		return nil, fmt.Errorf("replay fixture %s: %w", dir, err)
+	}
+	return &sc, nil
+}
+
+func (sc *replayScenario) validate() error {
+	var errs []error
+	sc.steps = make(map[int][]replayStep)
+	for name, steps := range sc.Phases {
+		num := phaseNameToNum(name)
+		if name == resolvePhaseName {
+			num = resolvePhaseNum
+		}
+		if num == 0 {
+			errs = append(errs, fmt.Errorf("unknown phase %q (valid: discovery, implementation, validation, resolve)", name))
+			continue
+		}
+		sc.steps[num] = append(sc.steps[num], steps...)
+		for i, st := range steps {
+			where := fmt.Sprintf("%s[%d]", name, i)
+			if st.Events != "" {
+				if _, err := os.Stat(filepath.Join(sc.dir, st.Events)); err != nil {
+					errs = append(errs, fmt.Errorf("%s: events: %w", where, err))
+				}
+			}
+			if st.ExitCode < 0 {
+				errs = append(errs, fmt.Errorf("%s: exit_code must not be negative", where))
+			}
+			if st.Stall && st.ExitCode != 0 {
+				errs = append(errs, fmt.Errorf("%s: stall and exit_code are exclusive", where))
+			}
+			for j, f := range st.Files {
+				if err := sc.validateFile(f); err != nil {
+					errs = append(errs, fmt.Errorf("%s: files[%d]: %w", where, j, err))
+				}
+			}
+		}
+	}
+	if len(sc.steps) == 0 {
+		errs = append(errs, fmt.Errorf("no phases scripted"))
+	}
+	return errors.Join(errs...)
+}
+
+func (sc *replayScenario) validateFile(f replayFile) error {
+	if f.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+	if filepath.IsAbs(f.Path) || strings.HasPrefix(filepath.Clean(f.Path), "..") {
+		return fmt.Errorf("path %s must stay inside the run directory", f.Path)
+	}
+	if (f.From == "") == (f.Content == "") {
+		return fmt.Errorf("%s: set exactly one of from and content", f.Path)
+	}
+	if f.From != "" {
+		if _, err := os.Stat(filepath.Join(sc.dir, f.From)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayExecutor plays back a replayScenario instead of running an agent.
+type replayExecutor struct {
+	ctx          context.Context
+	scenario     *replayScenario
+	statusPath   string
+	allPhases    []PhaseProgress
+	phaseTimeout time.Duration
+	stallTimeout time.Duration
+
+	mu    sync.Mutex
+	calls map[int]int
+	bd    map[string]string
+}
+
+func newReplayExecutor(sc *replayScenario, statusPath string, allPhases []PhaseProgress, opts phasedEngineOptions) *replayExecutor {
+	bd := make(map[string]string, len(sc.BD))
+	for k, v := range sc.BD {
+		bd[k] = v
+	}
+	return &replayExecutor{
+		ctx:          opts.Ctx,
+		scenario:     sc,
+		statusPath:   statusPath,
+		allPhases:    allPhases,
+		phaseTimeout: opts.PhaseTimeout,
+		stallTimeout: opts.StallTimeout,
+		calls:        make(map[int]int),
+		bd:           bd,
+	}
+}
+
+func (r *replayExecutor) Name() string { return "replay" }
+
+// Execute plays the next scripted step of phaseNum.
+func (r *replayExecutor) Execute(prompt, cwd, runID string, phaseNum int) error {
+	r.mu.Lock()
+	attempt := r.calls[phaseNum]
+	r.calls[phaseNum]++
+	r.mu.Unlock()
+
+	steps := r.scenario.steps[phaseNum]
+	if attempt >= len(steps) {
+		return fmt.Errorf("replay: fixture has %d step(s) for phase %d, invocation %d not scripted", len(steps), phaseNum, attempt+1)
+	}
+	step := steps[attempt]
+	r.recordInvocation(cwd, runID, phaseNum, attempt+1, prompt)
+
+	if step.Events != "" {
+		if err := r.playEvents(filepath.Join(r.scenario.dir, step.Events), step.EventInterval, phaseNum); err != nil {
+			return fmt.Errorf("replay events: %w", err)
+		}
+	}
+	for _, f := range step.Files {
+		if err := r.writeFile(cwd, f); err != nil {
+			return fmt.Errorf("replay: write %s: %w", f.Path, err)
+		}
+	}
+	if step.Stdout != "" {
+		fmt.Print(step.Stdout)
+		if !strings.HasSuffix(step.Stdout, "\n") {
+			fmt.Println()
+		}
+	}
+	r.mu.Lock()
+	for k, v := range step.BD {
+		r.bd[k] = v
+	}
+	r.mu.Unlock()
+
+	if r.phaseTimeout > 0 && step.Delay > r.phaseTimeout {
+		if err := r.sleep(r.phaseTimeout); err != nil {
+			return err
+		}
+		return fmt.Errorf("phase %d (%s) timed out after %s (set --phase-timeout to increase)", phaseNum, failReasonTimeout, r.phaseTimeout)
+	}
+	if err := r.sleep(step.Delay); err != nil {
+		return err
+	}
+
+	if step.Stall {
+		switch {
+		case r.stallTimeout > 0:
+			if err := r.sleep(r.stallTimeout); err != nil {
+				return err
+			}
+			return fmt.Errorf("phase %d (%s): stall detected: no replay activity for %s", phaseNum, failReasonStall, r.stallTimeout)
+		case r.phaseTimeout > 0:
+			if err := r.sleep(r.phaseTimeout); err != nil {
+				return err
+			}
+			return fmt.Errorf("phase %d (%s) timed out after %s (set --phase-timeout to increase)", phaseNum, failReasonTimeout, r.phaseTimeout)
+		default:
+			return fmt.Errorf("replay: phase %d scripts a stall but --stall-timeout and --phase-timeout are both 0", phaseNum)
+		}
+	}
+	if step.ExitCode != 0 {
+		return fmt.Errorf("replay exited with code %d (%s)", step.ExitCode, failReasonExit)
+	}
+	return nil
+}
+
+// sleep waits for d like a running agent, returning early when the run is
+// cancelled.
+func (r *replayExecutor) sleep(d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-runContext(r.ctx).Done():
+		return fmt.Errorf("replay: %w", runContext(r.ctx).Err())
+	}
+}
+
+// playEvents feeds a stream-json file through the stream parser, updating
+// the live status as the stream backend does.
+func (r *replayExecutor) playEvents(path string, interval time.Duration, phaseNum int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck // read-only
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			if err := r.sleep(interval); err != nil {
+				pw.CloseWithError(err) //nolint:errcheck // always nil
+				return
+			}
+			if _, err := pw.Write(append(scanner.Bytes(), '\n')); err != nil {
+				return // the parser stopped reading
+			}
+		}
+		pw.CloseWithError(scanner.Err()) //nolint:errcheck // always nil
+	}()
+
+	phaseIdx := phaseNum - 1
+	_, err = ParseStreamEvents(pr, func(p PhaseProgress) {
+		if len(r.allPhases) == 0 {
+			return
+		}
+		mergePhaseProgress(r.allPhases, phaseIdx, p)
+		if writeErr := WriteLiveStatus(r.statusPath, r.allPhases, phaseIdx); writeErr != nil {
+			VerbosePrintf("Warning: could not write live status: %v\n", writeErr)
+		}
+	})
+	// Unblock the writer if the parser returned before the end of the file.
+	pr.CloseWithError(err) //nolint:errcheck // always nil
+	<-done
+	return err
+}
+
+func (r *replayExecutor) writeFile(cwd string, f replayFile) error {
+	data := []byte(f.Content)
+	if f.From != "" {
+		var err error
+		if data, err = os.ReadFile(filepath.Join(r.scenario.dir, f.From)); err != nil {
+			return err
+		}
+	}
+	target := filepath.Join(cwd, f.Path)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+// replayInvocation is one line of the replay transcript.
+type replayInvocation struct {
+	RunID   string `json:"run_id"`
+	Phase   int    `json:"phase"`
+	Attempt int    `json:"attempt"`
+	Prompt  string `json:"prompt"`
+}
+
+// recordInvocation appends the prompt to .agents/rpi/replay-transcript.jsonl
+// so tests can assert on what each phase was asked to do.
+func (r *replayExecutor) recordInvocation(cwd, runID string, phaseNum, attempt int, prompt string) {
+	line, err := json.Marshal(replayInvocation{RunID: runID, Phase: phaseNum, Attempt: attempt, Prompt: prompt})
+	if err != nil {
+		return
+	}
+	path := filepath.Join(cwd, ".agents", "rpi", replayTranscriptFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		VerbosePrintf("Warning: could not record replay transcript: %v\n", err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		VerbosePrintf("Warning: could not record replay transcript: %v\n", err)
+		return
+	}
+	defer f.Close()             //nolint:errcheck // best-effort transcript
+	f.Write(append(line, '\n')) //nolint:errcheck // best-effort transcript
+}
+
+// bdOutput returns the scripted output of a bd subcommand.
+func (r *replayExecutor) bdOutput(args ...string) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("replay: bd called without arguments")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out, ok := r.bd[args[0]]
+	if !ok {
+		return nil, fmt.Errorf("replay: no scripted output for bd %s", strings.Join(args, " "))
+	}
+	return []byte(out), nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// replayFixtures holds the example scenarios shipped with the CLI.
+var replayFixtures = filepath.Join("..", "..", "testdata", "rpi-replay")
+
+// writeScenario writes scenario.yaml (and any extra files) into a new
+// fixture directory.
+func writeScenario(t *testing.T, scenario string, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files[replayScenarioFile] = scenario
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func replayOpts(fixture string) phasedEngineOptions {
+	opts := defaultPhasedEngineOptions()
+	opts.NoWorktree = true
+	opts.SwarmFirst = false
+	opts.Backend = "replay"
+	opts.Fixture = fixture
+	return opts
+}
+
+func readTranscript(t *testing.T, cwd string) []replayInvocation {
+	t.Helper()
+	f, err := os.Open(filepath.Join(cwd, ".agents", "rpi", replayTranscriptFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck // test
+	var out []replayInvocation
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var inv replayInvocation
+		if err := json.Unmarshal(scanner.Bytes(), &inv); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, inv)
+	}
+	return out
+}
+
+func TestReplay_VibeRetry(t *testing.T) {
+	fixture, err := filepath.Abs(filepath.Join(replayFixtures, "vibe-retry"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd := t.TempDir()
+	opts := replayOpts(fixture)
+	opts.LiveStatus = true
+
+	if err := runPhasedEngine(cwd, "add rate limiting", opts); err != nil {
+		t.Fatalf("runPhasedEngine: %v", err)
+	}
+
+	state, err := loadPhasedState(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.EpicID != "ag-demo" || state.Backend != "replay" {
+		t.Errorf("epic = %q, backend = %q", state.EpicID, state.Backend)
+	}
+	if state.Verdicts["pre_mortem"] != "PASS" || state.Verdicts["vibe"] != "PASS" {
+		t.Errorf("verdicts = %v", state.Verdicts)
+	}
+	if state.Attempts["phase_3"] != 1 {
+		t.Errorf("validation attempts = %d, want 1 retry", state.Attempts["phase_3"])
+	}
+
+	var got []string
+	for _, inv := range readTranscript(t, cwd) {
+		got = append(got, fmt.Sprintf("%d.%d", inv.Phase, inv.Attempt))
+	}
+	if strings.Join(got, " ") != "1.1 2.1 3.1 3.2 3.3" {
+		t.Errorf("invocations = %v", got)
+	}
+	retry := readTranscript(t, cwd)[3].Prompt
+	if !strings.Contains(retry, "Limiter middleware has no test") {
+		t.Errorf("retry prompt lacks the vibe finding: %s", retry)
+	}
+
+	// The discovery stream events reached the live status
+	data, err := os.ReadFile(filepath.Join(cwd, ".agents", "rpi", "live-status.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "| discovery | done | 1s | 2 | 0 | $0.0100 |") {
+		t.Errorf("live status does not show the replayed tool calls:\n%s", data)
+	}
+}
+
+func TestReplay_Failures(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		stall    time.Duration
+		want     string
+	}{
+		{
+			name:     "exit code",
+			scenario: "phases:\n  discovery:\n    - exit_code: 2\n",
+			want:     "replay exited with code 2 (exit_error)",
+		},
+		{
+			name:     "stall",
+			scenario: "phases:\n  discovery:\n    - stall: true\n",
+			stall:    20 * time.Millisecond,
+			want:     "(stall): stall detected: no replay activity",
+		},
+		{
+			name:     "unscripted phase",
+			scenario: "bd:\n  list: ag-x\nphases:\n  discovery:\n    - stdout: ok\n",
+			want:     "fixture has 0 step(s) for phase 2",
+		},
+		{
+			name:     "missing epic",
+			scenario: "phases:\n  discovery:\n    - stdout: ok\n",
+			want:     "no scripted output for bd list",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := replayOpts(writeScenario(t, tt.scenario, map[string]string{}))
+			opts.StallTimeout = tt.stall
+			err := runPhasedEngine(t.TempDir(), "goal", opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadReplayScenario_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		want     string
+	}{
+		{"empty", "name: nothing\n", "no phases scripted"},
+		{"unknown phase", "phases:\n  deploy:\n    - stdout: x\n", `unknown phase "deploy"`},
+		{"escaping path", "phases:\n  vibe:\n    - files:\n        - {path: ../x.md, content: x}\n", "must stay inside the run directory"},
+		{"both sources", "phases:\n  vibe:\n    - files:\n        - {path: x.md, content: x, from: y.md}\n", "exactly one of from and content"},
+		{"missing events", "phases:\n  research:\n    - events: none.jsonl\n", "research[0]: events"},
+		{"stall and exit", "phases:\n  crank:\n    - {stall: true, exit_code: 1}\n", "exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadReplayScenario(writeScenario(t, tt.scenario, map[string]string{}))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadReplayScenario(""); err == nil || !strings.Contains(err.Error(), "--fixture") {
+		t.Errorf("no fixture err = %v", err)
+	}
+	if _, err := loadReplayScenario(filepath.Join(replayFixtures, "stall")); err != nil {
+		t.Errorf("shipped stall fixture: %v", err)
+	}
+}
+
+func TestReplayPlayEvents_Cancel(t *testing.T) {
+	events := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := os.WriteFile(events, []byte("{\"type\":\"system\"}\n{\"type\":\"result\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := defaultPhasedEngineOptions()
+	opts.Ctx = ctx
+	r := newReplayExecutor(&replayScenario{}, "", nil, opts)
+
+	done := make(chan error, 1)
+	go func() { done <- r.playEvents(events, time.Hour, 1) }()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled run kept waiting out the event interval")
+	}
+}
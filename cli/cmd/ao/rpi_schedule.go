@@ -0,0 +1,565 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
+)
+
+var (
+	scheduleParallel     int
+	scheduleTestCmd      string
+	scheduleMergeRetries int
+	scheduleMaxGoals     int
+	scheduleRepoFilter   string
+)
+
+// scheduleStateFile is the scheduler snapshot under .agents/rpi, read by
+// ao rpi status.
+const scheduleStateFile = "schedule.json"
+
+// Scheduled job statuses, in lifecycle order.
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobReady   = "ready" // phased run done, waiting for the merge queue
+	jobMerging = "merging"
+	jobMerged  = "merged"
+	jobFailed  = "failed"
+)
+
+// scheduleSlotPoll is how often a job waiting for a repo slot retries.
+// Package-level so tests can shorten it.
+var scheduleSlotPoll = 2 * time.Second
+
+// runScheduledGoal runs one phased run inside its worktree, writing the
+// run's output to out. Package-level so tests can substitute the engine.
+var runScheduledGoal = func(ctx context.Context, job *scheduledJob, phasedArgs []string, out io.Writer) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate ao binary: %w", err)
+	}
+	args := append([]string{"rpi", "phased", "--no-worktree"}, phasedArgs...)
+	args = append(args, job.Goal)
+	cmd := exec.CommandContext(ctx, self, args...)
+	cmd.Dir = job.Worktree
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// mergeScheduledRun merges a run's branch into the base branch.
+// Package-level so tests can inject merge failures.
+var mergeScheduledRun = mergeWorktree
+
+func init() {
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule [goal...] [-- phased flags]",
+		Short: "Run many phased RPI goals in parallel worktrees",
+		Long: `Run several independent goals through the phased RPI engine at once.
+
+Each goal gets its own worktree (as ao rpi phased does) and runs
+'ao rpi phased --no-worktree <goal>' there. Without goal arguments, every
+unconsumed next-work.jsonl entry is scheduled, highest severity first, and
+marked consumed or failed as its run finishes.
+
+Concurrency:
+  --parallel sets how many runs this scheduler starts at once. rpi.max_parallel
+  in .agentops/config.yaml (default 2) caps the runs working in the repository
+  across all schedulers; extra runs wait for a free slot.
+
+Merging:
+  Finished runs enter a merge queue that merges one branch at a time. When a
+  merge fails (the base branch moved), the run's branch is rebased onto the
+  base, --test-cmd (or rpi.test_command) is run in its worktree, and the
+  merge is retried up to --merge-retries times. Runs that fail, conflict on
+  rebase or fail their tests keep their worktree for inspection.
+
+Progress is recorded in .agents/rpi/schedule.json and shown by ao rpi status.
+Each run's output goes to .agents/rpi/schedule/<run-id>.log.
+
+Examples:
+  ao rpi schedule                                   # drain the next-work queue
+  ao rpi schedule --parallel 4 "fix flaky test" "add --json to ao pool list"
+  ao rpi schedule --test-cmd "go test ./..." --max-goals 10
+  ao rpi schedule "add auth" -- --agent codex --max-retries 1`,
+		RunE: runRPISchedule,
+	}
+	scheduleCmd.Flags().IntVar(&scheduleParallel, "parallel", 0, "Runs to start at once (0 = rpi.max_parallel)")
+	scheduleCmd.Flags().StringVar(&scheduleTestCmd, "test-cmd", "", "Command run in a worktree after rebasing it (default: rpi.test_command)")
+	scheduleCmd.Flags().IntVar(&scheduleMergeRetries, "merge-retries", 2, "Rebase-and-retest attempts when a merge fails")
+	scheduleCmd.Flags().IntVar(&scheduleMaxGoals, "max-goals", 0, "Maximum queue goals to schedule (0 = all)")
+	scheduleCmd.Flags().StringVar(&scheduleRepoFilter, "repo-filter", "", "Only schedule queue items targeting this repo (empty = all)")
+	rpiCmd.AddCommand(scheduleCmd)
+}
+
+// scheduledJob is one goal in a scheduler run.
+type scheduledJob struct {
+	Goal          string `json:"goal"`
+	Status        string `json:"status"`
+	RunID         string `json:"run_id,omitempty"`
+	Worktree      string `json:"worktree,omitempty"`
+	Log           string `json:"log,omitempty"`
+	Error         string `json:"error,omitempty"`
+	MergeAttempts int    `json:"merge_attempts,omitempty"`
+	StartedAt     string `json:"started_at,omitempty"`
+	FinishedAt    string `json:"finished_at,omitempty"`
+
+	// queueIndex is the next-work.jsonl entry to mark, or -1 for goals
+	// given on the command line.
+	queueIndex int
+}
+
+// label names the job in progress output.
+func (j *scheduledJob) label() string {
+	if j.RunID == "" {
+		return "-"
+	}
+	return j.RunID
+}
+
+// scheduleState is the snapshot written to .agents/rpi/schedule.json.
+type scheduleState struct {
+	PID        int             `json:"pid"`
+	StartedAt  string          `json:"started_at"`
+	UpdatedAt  string          `json:"updated_at"`
+	BaseBranch string          `json:"base_branch"`
+	Parallel   int             `json:"parallel"`
+	RepoCap    int             `json:"repo_cap"`
+	Jobs       []*scheduledJob `json:"jobs"`
+
+	// Alive is set by loadScheduleState when the scheduler process exists.
+	Alive bool `json:"alive"`
+}
+
+// counts tallies jobs by status.
+func (s *scheduleState) counts() map[string]int {
+	c := make(map[string]int)
+	for _, j := range s.Jobs {
+		c[j.Status]++
+	}
+	return c
+}
+
+// summary renders the job counts, e.g. "2 running, 1 merging, 3 queued".
+func (s *scheduleState) summary() string {
+	c := s.counts()
+	var parts []string
+	for _, st := range []string{jobRunning, jobReady, jobMerging, jobQueued, jobMerged, jobFailed} {
+		if c[st] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", c[st], st))
+		}
+	}
+	if len(parts) == 0 {
+		return "no jobs"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// scheduler runs jobs in parallel worktrees and merges them one at a time.
+type scheduler struct {
+	repoRoot     string
+	queuePath    string
+	testCmd      string
+	mergeRetries int
+	phasedArgs   []string
+
+	mu    sync.Mutex
+	state scheduleState
+}
+
+func runRPISchedule(cmd *cobra.Command, args []string) error {
+	var phasedArgs []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, phasedArgs = args[:dash], args[dash:]
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	repoRoot, err := getRepoRoot(cwd)
+	if err != nil {
+		return err
+	}
+	baseBranch, err := getCurrentBranch(repoRoot)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadRPIConfig()
+	if err != nil {
+		return err
+	}
+	repoCap := cfg.MaxParallel
+	if repoCap <= 0 {
+		repoCap = 1
+	}
+	parallel := scheduleParallel
+	if parallel <= 0 {
+		parallel = repoCap
+	}
+	testCmd := scheduleTestCmd
+	if testCmd == "" {
+		testCmd = cfg.TestCommand
+	}
+
+	queuePath := filepath.Join(repoRoot, ".agents", "rpi", "next-work.jsonl")
+	jobs, err := scheduleJobs(args, queuePath, scheduleRepoFilter, scheduleMaxGoals)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No goals given and no unconsumed work in queue. Nothing to schedule.")
+		return nil
+	}
+
+	fmt.Printf("Scheduling %d goal(s): %d at once, repo cap %d, base branch %s\n", len(jobs), parallel, repoCap, baseBranch)
+	if GetDryRun() {
+		for _, j := range jobs {
+			fmt.Printf("[dry-run] Would run in a new worktree: ao rpi phased --no-worktree %s %q\n", strings.Join(phasedArgs, " "), j.Goal)
+		}
+		return nil
+	}
+
+	s := &scheduler{
+		repoRoot:     repoRoot,
+		queuePath:    queuePath,
+		testCmd:      testCmd,
+		mergeRetries: scheduleMergeRetries,
+		phasedArgs:   phasedArgs,
+		state: scheduleState{
+			PID:        os.Getpid(),
+			StartedAt:  time.Now().UTC().Format(time.RFC3339),
+			BaseBranch: baseBranch,
+			Parallel:   parallel,
+			RepoCap:    repoCap,
+			Jobs:       jobs,
+		},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s.run(ctx)
+
+	fmt.Printf("\nSchedule finished: %s\n", s.state.summary())
+	if failed := s.state.counts()[jobFailed]; failed > 0 {
+		return fmt.Errorf("%d of %d scheduled goal(s) failed (see ao rpi status)", failed, len(jobs))
+	}
+	return nil
+}
+
+// scheduleJobs builds jobs from explicit goals or, without any, from every
+// eligible next-work.jsonl entry ordered by severity.
+func scheduleJobs(goals []string, queuePath, repoFilter string, maxGoals int) ([]*scheduledJob, error) {
+	var jobs []*scheduledJob
+	for _, g := range goals {
+		jobs = append(jobs, &scheduledJob{Goal: g, Status: jobQueued, queueIndex: -1})
+	}
+	if len(jobs) > 0 {
+		return jobs, nil
+	}
+
+	entries, err := readQueueEntries(queuePath)
+	if err != nil {
+		return nil, err
+	}
+	ranks := make(map[*scheduledJob]int)
+	for _, e := range entries {
+		sel := selectHighestSeverityEntry([]nextWorkEntry{e}, repoFilter)
+		if sel == nil {
+			continue
+		}
+		j := &scheduledJob{Goal: sel.Item.Title, Status: jobQueued, queueIndex: e.fileIndex}
+		ranks[j] = severityRank(sel.Item.Severity)
+		jobs = append(jobs, j)
+	}
+	sort.SliceStable(jobs, func(a, b int) bool { return ranks[jobs[a]] > ranks[jobs[b]] })
+	if maxGoals > 0 && len(jobs) > maxGoals {
+		jobs = jobs[:maxGoals]
+	}
+	return jobs, nil
+}
+
+// run starts the jobs on state.Parallel workers and feeds finished runs to
+// a single merge goroutine. It returns when every job is merged or failed.
+func (s *scheduler) run(ctx context.Context) {
+	s.save()
+
+	pending := make(chan *scheduledJob)
+	merges := make(chan *scheduledJob, len(s.state.Jobs))
+	var workers sync.WaitGroup
+	for i := 0; i < s.state.Parallel; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range pending {
+				if s.runJob(ctx, job) {
+					merges <- job
+				}
+			}
+		}()
+	}
+	mergeDone := make(chan struct{})
+	go func() {
+		defer close(mergeDone)
+		for job := range merges {
+			s.mergeJob(ctx, job)
+		}
+	}()
+
+	for _, job := range s.state.Jobs {
+		if ctx.Err() != nil {
+			s.finish(job, jobFailed, "not started: scheduler interrupted")
+			continue
+		}
+		pending <- job
+	}
+	close(pending)
+	workers.Wait()
+	close(merges)
+	<-mergeDone
+}
+
+// runJob runs job's phased run in a new worktree while holding a repo slot.
+// It reports whether the run is ready to merge.
+func (s *scheduler) runJob(ctx context.Context, job *scheduledJob) bool {
+	slot, err := s.acquireSlot(ctx)
+	if err != nil {
+		s.finish(job, jobFailed, fmt.Sprintf("waiting for a repo slot: %v", err))
+		return false
+	}
+	defer slot.Unlock() //nolint:errcheck // closing releases the slot
+
+	var worktree, runID string
+	err = s.withWorktreeLock(func() (err error) {
+		worktree, runID, err = createWorktree(s.repoRoot)
+		return err
+	})
+	if err != nil {
+		s.finish(job, jobFailed, fmt.Sprintf("create worktree: %v", err))
+		return false
+	}
+	logPath := filepath.Join(s.repoRoot, ".agents", "rpi", "schedule", runID+".log")
+	s.update(job, func() {
+		job.Status = jobRunning
+		job.RunID = runID
+		job.Worktree = worktree
+		job.Log = logPath
+		job.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	})
+	fmt.Printf("[%s] running: %s\n", runID, job.Goal)
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		s.finish(job, jobFailed, fmt.Sprintf("create log directory: %v", err))
+		return false
+	}
+	out, err := os.Create(logPath)
+	if err != nil {
+		s.finish(job, jobFailed, fmt.Sprintf("create log: %v", err))
+		return false
+	}
+	runErr := runScheduledGoal(ctx, job, s.phasedArgs, out)
+	_ = out.Close() //nolint:errcheck // log is best-effort
+	if runErr != nil {
+		s.finish(job, jobFailed, fmt.Sprintf("phased run failed: %v (log: %s, worktree preserved)", runErr, logPath))
+		return false
+	}
+
+	s.update(job, func() { job.Status = jobReady })
+	fmt.Printf("[%s] ready to merge: %s\n", runID, job.Goal)
+	return true
+}
+
+// acquireSlot takes one of the repository's rpi.max_parallel slot locks,
+// waiting while all are held by this or other schedulers.
+func (s *scheduler) acquireSlot(ctx context.Context) (*fsutil.Lock, error) {
+	dir := filepath.Join(s.repoRoot, ".agents", "rpi", "slots")
+	for {
+		for i := 0; i < s.state.RepoCap; i++ {
+			l, err := fsutil.TryLockFile(filepath.Join(dir, fmt.Sprintf("slot-%d", i)))
+			if err != nil {
+				return nil, err
+			}
+			if l != nil {
+				return l, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(scheduleSlotPoll):
+		}
+	}
+}
+
+// withWorktreeLock runs fn holding the repository's worktree lock: git
+// corrupts its worktree metadata when worktrees are added concurrently.
+func (s *scheduler) withWorktreeLock(fn func() error) error {
+	return fsutil.WithLock(filepath.Join(s.repoRoot, ".agents", "rpi", "worktree"), fn)
+}
+
+// mergeJob merges job's branch under the repository merge lock, then
+// removes its worktree.
+func (s *scheduler) mergeJob(ctx context.Context, job *scheduledJob) {
+	s.update(job, func() { job.Status = jobMerging })
+	mergeLock := filepath.Join(s.repoRoot, ".agents", "rpi", "merge")
+	if err := fsutil.WithLock(mergeLock, func() error { return s.mergeWithRebase(ctx, job) }); err != nil {
+		s.finish(job, jobFailed, fmt.Sprintf("%v (worktree preserved: %s)", err, job.Worktree))
+		return
+	}
+	if err := s.withWorktreeLock(func() error { return removeWorktree(s.repoRoot, job.Worktree, job.RunID) }); err != nil {
+		VerbosePrintf("Warning: could not remove worktree %s: %v\n", job.Worktree, err)
+	}
+	s.finish(job, jobMerged, "")
+}
+
+// mergeWithRebase merges job's branch; when that fails it rebases the
+// branch onto the base branch, re-runs the tests and tries again.
+func (s *scheduler) mergeWithRebase(ctx context.Context, job *scheduledJob) error {
+	for attempt := 0; ; attempt++ {
+		s.update(job, func() { job.MergeAttempts++ })
+		err := mergeScheduledRun(s.repoRoot, job.RunID)
+		if err == nil {
+			return nil
+		}
+		if attempt >= s.mergeRetries {
+			return fmt.Errorf("merge failed after %d attempt(s): %w", attempt+1, err)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("merge interrupted: %w", err)
+		}
+		fmt.Printf("[%s] merge failed, rebasing onto %s and retesting\n", job.RunID, s.state.BaseBranch)
+		if err := rebaseWorktree(ctx, job.Worktree, s.state.BaseBranch); err != nil {
+			return err
+		}
+		if err := s.retest(ctx, job); err != nil {
+			return err
+		}
+	}
+}
+
+// rebaseWorktree rebases the branch checked out in worktree onto onto,
+// aborting and reporting the conflict when it does not apply cleanly.
+func rebaseWorktree(ctx context.Context, worktree, onto string) error {
+	cmd := exec.CommandContext(ctx, "git", "rebase", "--autostash", onto)
+	cmd.Dir = worktree
+	if out, err := cmd.CombinedOutput(); err != nil {
+		abort := exec.Command("git", "rebase", "--abort")
+		abort.Dir = worktree
+		_ = abort.Run() //nolint:errcheck // nothing to abort when rebase failed to start
+		return fmt.Errorf("rebase onto %s failed: %s", onto, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// retest runs the test command in job's worktree, appending its output to
+// the run's log.
+func (s *scheduler) retest(ctx context.Context, job *scheduledJob) error {
+	if s.testCmd == "" {
+		return nil
+	}
+	out, err := os.OpenFile(job.Log, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	defer out.Close() //nolint:errcheck // log is best-effort
+
+	fmt.Fprintf(out, "\n--- retest after rebase: %s ---\n", s.testCmd) //nolint:errcheck // log is best-effort
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.testCmd)
+	cmd.Dir = job.Worktree
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tests failed after rebase: %w (log: %s)", err, job.Log)
+	}
+	return nil
+}
+
+// finish records a job's final status and marks its queue entry.
+func (s *scheduler) finish(job *scheduledJob, status, msg string) {
+	s.update(job, func() {
+		job.Status = status
+		job.Error = msg
+		job.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+		if job.queueIndex < 0 {
+			return
+		}
+		var err error
+		if status == jobMerged {
+			err = markEntryConsumed(s.queuePath, job.queueIndex, "ao-rpi-schedule")
+		} else {
+			err = markEntryFailed(s.queuePath, job.queueIndex)
+		}
+		if err != nil {
+			VerbosePrintf("Warning: could not update queue entry for %q: %v\n", job.Goal, err)
+		}
+	})
+	if status == jobFailed {
+		fmt.Printf("[%s] failed: %s: %s\n", job.label(), job.Goal, msg)
+	} else {
+		fmt.Printf("[%s] %s: %s\n", job.label(), status, job.Goal)
+	}
+}
+
+// update applies fn to a job under the scheduler lock and saves the state.
+func (s *scheduler) update(job *scheduledJob, fn func()) {
+	s.mu.Lock()
+	fn()
+	s.mu.Unlock()
+	s.save()
+}
+
+// save writes the scheduler snapshot for ao rpi status.
+func (s *scheduler) save() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(&s.state, "", "  ")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(s.repoRoot, ".agents", "rpi", scheduleStateFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		VerbosePrintf("Warning: could not save schedule state: %v\n", err)
+		return
+	}
+	if err := fsutil.WriteFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		VerbosePrintf("Warning: could not save schedule state: %v\n", err)
+	}
+}
+
+// loadScheduleState reads the last scheduler snapshot of the repository
+// containing cwd. It returns nil when no scheduler has run there.
+func loadScheduleState(cwd string) *scheduleState {
+	root, err := getRepoRoot(cwd)
+	if err != nil {
+		root = cwd
+	}
+	data, err := os.ReadFile(filepath.Join(root, ".agents", "rpi", scheduleStateFile))
+	if err != nil {
+		return nil
+	}
+	var st scheduleState
+	if err := json.Unmarshal(data, &st); err != nil {
+		VerbosePrintf("Warning: could not parse %s: %v\n", scheduleStateFile, err)
+		return nil
+	}
+	st.Alive = st.PID > 0 && syscall.Kill(st.PID, 0) == nil
+	return &st
+}
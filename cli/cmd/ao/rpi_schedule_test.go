@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// commitGoal returns a runScheduledGoal stub that writes content to file in
+// the job's worktree and commits it, tracking how many runs overlap.
+func commitGoal(t *testing.T, file func(goal string) (string, string), running, peak *atomic.Int32) func(context.Context, *scheduledJob, []string, io.Writer) error {
+	t.Helper()
+	return func(_ context.Context, job *scheduledJob, _ []string, _ io.Writer) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		name, content := file(job.Goal)
+		if err := os.WriteFile(filepath.Join(job.Worktree, name), []byte(content), 0644); err != nil {
+			return err
+		}
+		for _, args := range [][]string{{"add", name}, {"commit", "-m", job.Goal}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = job.Worktree
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return errors.New(string(out))
+			}
+		}
+		return nil
+	}
+}
+
+func newTestScheduler(t *testing.T, repo string, repoCap, parallel int, goals ...string) *scheduler {
+	t.Helper()
+	branch, err := getCurrentBranch(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := scheduleJobs(goals, "", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &scheduler{
+		repoRoot:     repo,
+		queuePath:    filepath.Join(repo, ".agents", "rpi", "next-work.jsonl"),
+		mergeRetries: 2,
+		state:        scheduleState{PID: os.Getpid(), BaseBranch: branch, Parallel: parallel, RepoCap: repoCap, Jobs: jobs},
+	}
+}
+
+func stubSchedule(t *testing.T, run func(context.Context, *scheduledJob, []string, io.Writer) error) {
+	t.Helper()
+	oldRun, oldMerge, oldPoll := runScheduledGoal, mergeScheduledRun, scheduleSlotPoll
+	runScheduledGoal = run
+	scheduleSlotPoll = 10 * time.Millisecond
+	t.Cleanup(func() { runScheduledGoal, mergeScheduledRun, scheduleSlotPoll = oldRun, oldMerge, oldPoll })
+}
+
+func TestScheduler_RunsInParallelUnderRepoCap(t *testing.T) {
+	repo := initTestRepo(t)
+	var running, peak atomic.Int32
+	stubSchedule(t, commitGoal(t, func(goal string) (string, string) { return goal + ".txt", goal + "\n" }, &running, &peak))
+
+	s := newTestScheduler(t, repo, 2, 4, "one", "two", "three", "four")
+	s.run(context.Background())
+
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrent runs = %d, want the repo cap of 2", got)
+	}
+	for _, j := range s.state.Jobs {
+		if j.Status != jobMerged {
+			t.Errorf("%s: status = %s (%s)", j.Goal, j.Status, j.Error)
+		}
+		if _, err := os.Stat(filepath.Join(repo, j.Goal+".txt")); err != nil {
+			t.Errorf("%s not merged: %v", j.Goal, err)
+		}
+		if _, err := os.Stat(j.Worktree); !os.IsNotExist(err) {
+			t.Errorf("%s: worktree not removed after merge", j.Goal)
+		}
+	}
+
+	st := loadScheduleState(repo)
+	if st == nil || st.summary() != "4 merged" || !st.Alive {
+		t.Errorf("schedule state = %+v", st)
+	}
+}
+
+func TestScheduler_RebaseAndRetest(t *testing.T) {
+	repo := initTestRepo(t)
+	var running, peak atomic.Int32
+	stubSchedule(t, commitGoal(t, func(goal string) (string, string) { return goal + ".txt", goal + "\n" }, &running, &peak))
+
+	// The first merge attempt fails as if the base branch moved underneath
+	var calls atomic.Int32
+	mergeScheduledRun = func(repoRoot, runID string) error {
+		if calls.Add(1) == 1 {
+			return errors.New("merge conflict")
+		}
+		return mergeWorktree(repoRoot, runID)
+	}
+	marker := filepath.Join(t.TempDir(), "retested")
+
+	s := newTestScheduler(t, repo, 1, 1, "only")
+	s.testCmd = "touch " + marker
+	s.run(context.Background())
+
+	j := s.state.Jobs[0]
+	if j.Status != jobMerged || j.MergeAttempts != 2 {
+		t.Errorf("job = %+v, want merged on the second attempt", j)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("tests were not re-run after the rebase: %v", err)
+	}
+
+	// A failing retest stops the merge and keeps the worktree
+	calls.Store(0)
+	s = newTestScheduler(t, repo, 1, 1, "again")
+	s.testCmd = "exit 1"
+	s.run(context.Background())
+	j = s.state.Jobs[0]
+	if j.Status != jobFailed || !strings.Contains(j.Error, "tests failed after rebase") {
+		t.Errorf("job = %+v, want a retest failure", j)
+	}
+	if _, err := os.Stat(j.Worktree); err != nil {
+		t.Errorf("worktree should be preserved: %v", err)
+	}
+}
+
+func TestScheduler_RebaseConflict(t *testing.T) {
+	repo := initTestRepo(t)
+	var running, peak atomic.Int32
+	stubSchedule(t, commitGoal(t, func(goal string) (string, string) { return "shared.txt", goal + "\n" }, &running, &peak))
+
+	s := newTestScheduler(t, repo, 2, 2, "left", "right")
+	s.run(context.Background())
+
+	c := s.state.counts()
+	if c[jobMerged] != 1 || c[jobFailed] != 1 {
+		t.Fatalf("statuses = %v, want one merged and one failed", c)
+	}
+	for _, j := range s.state.Jobs {
+		if j.Status != jobFailed {
+			continue
+		}
+		if !strings.Contains(j.Error, "rebase onto") || j.MergeAttempts != 1 {
+			t.Errorf("failed job = %+v", j)
+		}
+		if _, err := os.Stat(j.Worktree); err != nil {
+			t.Errorf("conflicting worktree should be preserved: %v", err)
+		}
+	}
+	out, _ := exec.Command("git", "-C", repo, "status", "--porcelain", "--untracked-files=no").Output()
+	if strings.TrimSpace(string(out)) != "" {
+		t.Errorf("repo left dirty: %s", out)
+	}
+}
+
+func TestScheduleJobs_FromQueue(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "next-work.jsonl")
+	lines := []string{
+		`{"source_epic":"ag-1","items":[{"title":"done already","severity":"high"}],"consumed":true}`,
+		`{"source_epic":"ag-2","items":[{"title":"low one","severity":"low"}],"consumed":false}`,
+		`{"source_epic":"ag-3","items":[{"title":"high one","severity":"high"},{"title":"medium one","severity":"medium"}],"consumed":false}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := scheduleJobs(nil, path, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].Goal != "high one" || jobs[1].Goal != "low one" {
+		t.Fatalf("jobs = %+v, want one per entry, highest severity first", jobs)
+	}
+	if jobs[0].queueIndex != 2 || jobs[1].queueIndex != 1 {
+		t.Errorf("queue indexes = %d, %d, want file positions 2, 1", jobs[0].queueIndex, jobs[1].queueIndex)
+	}
+
+	s := &scheduler{queuePath: path, repoRoot: dir, state: scheduleState{Jobs: jobs}}
+	s.finish(jobs[0], jobMerged, "")
+	s.finish(jobs[1], jobFailed, "boom")
+	entries, err := readQueueEntries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("entries left in queue = %+v", entries)
+	}
+
+	if jobs, _ := scheduleJobs([]string{"explicit"}, path, "", 0); len(jobs) != 1 || jobs[0].queueIndex != -1 {
+		t.Errorf("explicit goals = %+v", jobs)
+	}
+}
@@ -88,6 +88,7 @@ type rpiStatusOutput struct {
 	Runs         []rpiRunInfo         `json:"runs"` // combined, kept for back-compat
 	LogRuns      []rpiRun             `json:"log_runs,omitempty"`
 	LiveStatuses []liveStatusSnapshot `json:"live_statuses,omitempty"`
+	Schedule     *scheduleState       `json:"schedule,omitempty"`
 	Count        int                  `json:"count"`
 }
 
@@ -122,6 +123,7 @@ func runRPIStatusOnce() error {
 	// Parse orchestration logs for enriched data
 	logRuns := discoverLogRuns(cwd)
 	liveStatuses := discoverLiveStatuses(cwd)
+	schedule := loadScheduleState(cwd)
 
 	output := rpiStatusOutput{
 		Active:       active,
@@ -129,6 +131,7 @@ func runRPIStatusOnce() error {
 		Runs:         allRuns,
 		LogRuns:      logRuns,
 		LiveStatuses: liveStatuses,
+		Schedule:     schedule,
 		Count:        len(allRuns),
 	}
 
@@ -139,11 +142,15 @@ func runRPIStatusOnce() error {
 	}
 
 	// Table output: state-file runs
-	if len(allRuns) == 0 && len(logRuns) == 0 && len(liveStatuses) == 0 {
+	if len(allRuns) == 0 && len(logRuns) == 0 && len(liveStatuses) == 0 && schedule == nil {
 		fmt.Println("No active RPI runs found.")
 		return nil
 	}
 
+	if schedule != nil {
+		printScheduleStatus(schedule)
+	}
+
 	// Active runs section
 	if len(active) > 0 {
 		fmt.Println("Active Runs")
@@ -233,6 +240,31 @@ func runRPIStatusOnce() error {
 	return nil
 }
 
+// printScheduleStatus prints the aggregate progress of the last
+// ao rpi schedule run and one line per goal.
+func printScheduleStatus(st *scheduleState) {
+	state := "finished"
+	if st.Alive {
+		state = "running"
+	}
+	fmt.Printf("Schedule (%s, pid %d, %d at once, repo cap %d, base %s)\n", state, st.PID, st.Parallel, st.RepoCap, st.BaseBranch)
+	fmt.Printf("%s\n", st.summary())
+	fmt.Printf("%-14s %-30s %-10s %-7s %s\n", "RUN-ID", "GOAL", "STATUS", "MERGES", "NOTE")
+	fmt.Println(strings.Repeat("─", 82))
+	for _, j := range st.Jobs {
+		goal := j.Goal
+		if len(goal) > 28 {
+			goal = goal[:25] + "..."
+		}
+		runID := j.RunID
+		if runID == "" {
+			runID = "-"
+		}
+		fmt.Printf("%-14s %-30s %-10s %-7d %s\n", runID, goal, j.Status, j.MergeAttempts, j.Error)
+	}
+	fmt.Println()
+}
+
 // runRPIStatusWatch polls every 5s and redraws the display.
 func runRPIStatusWatch() error {
 	sigCh := make(chan os.Signal, 1)
@@ -642,6 +674,12 @@ func scanRegistryRuns(root string) []rpiRunInfo {
 
 		phaseName := displayPhaseName(*state)
 		status := classifyRunStatus(*state, isActive)
+		// A paused or cancelled engine reports its control state.
+		if engine := readRunEngine(filepath.Join(runsDir, runID)); engine != nil {
+			if engine.State == engineCancelled || (engine.State == enginePaused && isActive) {
+				status = engine.State
+			}
+		}
 
 		elapsed := ""
 		if state.StartedAt != "" {
@@ -15,6 +15,8 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/config"
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/storage"
 	"github.com/boshu2/agentops/cli/pkg/vault"
 )
@@ -25,13 +27,32 @@ const (
 
 	// MaxContextLines is the maximum number of context lines to show per result.
 	MaxContextLines = 3
+
+	// SearchIndexFileName is the BM25 index of the sessions directory, kept
+	// next to it and updated by forge.
+	SearchIndexFileName = "index.jsonl"
+
+	// SearchManifestFileName records the state of every file in the BM25
+	// index, so a query can tell whether the saved index is current.
+	SearchManifestFileName = "index-manifest.json"
+
+	// VectorsFileName is the vector store for --semantic/--hybrid search,
+	// kept in IndexDir next to the keyword index.
+	VectorsFileName = "vectors.jsonl"
+
+	// hybridSemanticWeight is the share of the hybrid score taken from
+	// vector similarity; the rest comes from BM25.
+	hybridSemanticWeight = 0.5
 )
 
 var (
-	searchLimit   int
-	searchType    string
-	searchUseSC   bool
-	searchUseCASS bool
+	searchLimit    int
+	searchType     string
+	searchUseSC    bool
+	searchUseCASS  bool
+	searchSemantic bool
+	searchHybrid   bool
+	searchEmbedder string
 )
 
 var searchCmd = &cobra.Command{
@@ -39,15 +60,38 @@ var searchCmd = &cobra.Command{
 	Short: "Search knowledge base",
 	Long: `Search AgentOps knowledge using file-based search.
 
-By default, searches markdown and JSONL files in .agents/ao/sessions/.
+By default, ranks markdown and JSONL files in .agents/ao/sessions/ with
+BM25 over a positional index. Wrap words in double quotes to match an exact
+phrase, and end a word with * to match any term with that prefix.
+Use --semantic to rank by embedding similarity instead, or --hybrid to
+blend embedding and keyword scores. The default embedder is offline (hashed
+word and character n-grams); set search.embedder: http and search.embed_url
+in .agentops/config.yaml (or AGENTOPS_EMBEDDER / AGENTOPS_EMBED_URL) to use a
+local embedding server. Vectors are cached in .agents/ao/index/vectors.jsonl
+and only re-computed for changed files.
+
+Narrow results with field filters, evaluated against document front matter
+(and the **Utility**/**Maturity**/**Tags** lines of forged learnings):
+  type:learning  maturity:established  tag:auth  tier:gold  status:pending
+  utility:>0.6  confidence:<=0.3  since:30d  until:2026-01-31
+Prefix a filter or word with - to exclude it (-deprecated, -tag:legacy).
+A filtered query searches learnings, patterns, retros, research and
+decisions as well as sessions; with only filters, results are newest first.
+
 Optionally use Smart Connections for semantic search if Obsidian is running.
 Use --cass to enable CASS (Contextual Agent Session Search) which includes
 session context and maturity-weighted ranking.
 
 Examples:
   ao search "mutex pattern"
+  ao search '"worktree merge"'  # Exact phrase
+  ao search "migrat*"           # Prefix match
   ao search "authentication" --limit 20
   ao search "database migration" --type decisions
+  ao search 'maturity:established utility:>0.6 tag:auth "token refresh"'
+  ao search 'type:learning since:30d -deprecated'
+  ao search "lock ordering" --semantic
+  ao search "flaky tests" --hybrid
   ao search "config" --use-sc   # Enable Smart Connections semantic search
   ao search "auth" --cass       # Enable CASS session-aware search`,
 	Args: cobra.ExactArgs(1),
@@ -60,6 +104,9 @@ func init() {
 	searchCmd.Flags().StringVar(&searchType, "type", "", "Filter by type: decisions, knowledge, sessions")
 	searchCmd.Flags().BoolVar(&searchUseSC, "use-sc", false, "Enable Smart Connections semantic search (requires Obsidian)")
 	searchCmd.Flags().BoolVar(&searchUseCASS, "cass", false, "Enable CASS session-aware search with maturity weighting")
+	searchCmd.Flags().BoolVar(&searchSemantic, "semantic", false, "Rank by embedding similarity (local, no Obsidian needed)")
+	searchCmd.Flags().BoolVar(&searchHybrid, "hybrid", false, "Blend embedding similarity with keyword (BM25) scores")
+	searchCmd.Flags().StringVar(&searchEmbedder, "embedder", "", "Embedder for --semantic/--hybrid: hash or http (default from config)")
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
@@ -85,7 +132,28 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	results, err := selectAndSearch(query, sessionsDir, searchLimit)
+	fq, err := search.ParseFilterQuery(query, time.Now())
+	if err != nil {
+		return err
+	}
+	// --type is a type: filter, so it applies before the limit and reaches
+	// the knowledge directories as well as sessions.
+	if searchType != "" {
+		if err := fq.AddFilter("type", searchType, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	var results []searchResult
+	switch {
+	case fq.HasFilters() && (fq.Text == "" || !usesAlternateBackend()):
+		results, err = searchWithFilters(fq, sessionsDir, searchLimit)
+	case fq.HasFilters():
+		results, err = selectAndSearch(fq.Text, sessionsDir, searchLimit*filterOverfetch)
+		results = applyQueryFilters(results, fq)
+	default:
+		results, err = selectAndSearch(query, sessionsDir, searchLimit)
+	}
 	if err != nil {
 		return fmt.Errorf("search failed: %w", err)
 	}
@@ -95,11 +163,6 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Filter by type if specified
-	if searchType != "" {
-		results = filterByType(results, searchType)
-	}
-
 	// Limit results
 	if len(results) > searchLimit {
 		results = results[:searchLimit]
@@ -116,8 +179,14 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// usesAlternateBackend reports whether a flag selects a backend other than
+// the default BM25 file search.
+func usesAlternateBackend() bool {
+	return searchUseCASS || searchSemantic || searchHybrid || searchUseSC
+}
+
 // selectAndSearch chooses the search backend and executes the search.
-// Default: file-based search. Optional: Smart Connections with --use-sc flag.
+// Default: BM25-ranked file search. Optional: Smart Connections with --use-sc flag.
 // CASS mode (--cass) adds session context and maturity-weighted ranking.
 func selectAndSearch(query, sessionsDir string, limit int) ([]searchResult, error) {
 	// CASS mode: search with session context and maturity weighting
@@ -126,6 +195,12 @@ func selectAndSearch(query, sessionsDir string, limit int) ([]searchResult, erro
 		return searchCASS(query, sessionsDir, limit)
 	}
 
+	// Local embedding search: --semantic or --hybrid
+	if searchSemantic || searchHybrid {
+		VerbosePrintf("Using local embedding search...\n")
+		return searchVectors(query, sessionsDir, limit, searchHybrid)
+	}
+
 	// Only use Smart Connections if explicitly requested with --use-sc
 	if searchUseSC {
 		vaultPath := vault.DetectVault("")
@@ -171,40 +246,203 @@ type searchResult struct {
 	Type    string  `json:"type,omitempty"`
 }
 
-// searchFiles performs grep-based search on markdown and JSONL files.
+// searchFiles ranks markdown and JSONL files under dir with the BM25 index
+// from internal/search. Supports phrase ("a b") and prefix (term*) queries.
 func searchFiles(query string, dir string, limit int) ([]searchResult, error) {
-	var results []searchResult
+	idx, err := loadSearchIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := search.Search(idx, query, limit)
+	results := make([]searchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, searchResult{
+			Path:    hit.Path,
+			Score:   hit.Score,
+			Context: rankedResultContext(hit.Path, query),
+			Type:    classifyResultType(hit.Path),
+		})
+	}
+
+	return results, nil
+}
 
-	// Search markdown files
-	mdResults, err := grepFiles(query, dir, "*.md", limit)
+// loadSearchIndex returns the BM25 index of dir persisted next to it (the
+// index forge keeps current for the sessions directory). When the index is
+// missing, in an older format, or its manifest shows files added, changed or
+// removed since it was saved, it is rebuilt from dir and saved again.
+func loadSearchIndex(dir string) (*search.Index, error) {
+	files, err := search.IndexableFiles(dir)
 	if err != nil {
 		return nil, err
 	}
-	results = append(results, mdResults...)
+	if len(files) == 0 {
+		return search.NewIndex(), nil
+	}
+
+	idxPath := filepath.Join(filepath.Dir(dir), SearchIndexFileName)
+	manifestPath := filepath.Join(filepath.Dir(dir), SearchManifestFileName)
+	manifest, err := search.LoadManifest(manifestPath)
+	if err != nil {
+		VerbosePrintf("Warning: %v; rebuilding search index\n", err)
+		manifest = search.NewManifest()
+	}
+	changes, states := manifest.Diff(files, true)
+	if changes.Empty() {
+		idx, err := search.LoadIndex(idxPath)
+		if err == nil {
+			return idx, nil
+		}
+		VerbosePrintf("Rebuilding search index: %v\n", err)
+	} else {
+		VerbosePrintf("Rebuilding search index: %d added, %d changed, %d removed\n", len(changes.Added), len(changes.Modified), len(changes.Removed))
+	}
 
-	// Search JSONL files
-	jsonlResults, err := searchJSONL(query, dir, limit)
+	idx, err := search.BuildIndex(dir)
 	if err != nil {
 		return nil, err
 	}
-	results = append(results, jsonlResults...)
+	manifest = search.NewManifest()
+	for path, state := range states {
+		manifest.Record(path, state)
+	}
+	if err := search.SaveIndex(idx, idxPath); err != nil {
+		VerbosePrintf("Warning: save search index: %v\n", err)
+	} else if err := search.SaveManifest(manifest, manifestPath); err != nil {
+		VerbosePrintf("Warning: save search manifest: %v\n", err)
+	}
+	return idx, nil
+}
+
+// rankedResultContext picks display context for an index hit. JSONL files
+// show the summary of the first matching record; other files show matching
+// lines, trying the whole query first and then its individual terms.
+func rankedResultContext(path, query string) string {
+	terms := search.QueryTerms(query)
+	if filepath.Ext(path) == ".jsonl" {
+		return jsonlSummaryContext(path, terms)
+	}
 
-	// Dedupe by path
-	seen := make(map[string]bool)
-	unique := make([]searchResult, 0)
-	for _, r := range results {
-		if !seen[r.Path] {
-			seen[r.Path] = true
-			unique = append(unique, r)
+	if context := getFileContext(path, strings.Join(terms, " ")); context != "" {
+		return context
+	}
+	for _, term := range terms {
+		if context := getFileContext(path, term); context != "" {
+			return context
 		}
 	}
+	return ""
+}
 
-	// Enforce combined result limit after deduplication
-	if limit > 0 && len(unique) > limit {
-		unique = unique[:limit]
+// jsonlSummaryContext returns the summary of the first JSONL record that
+// mentions any of terms.
+func jsonlSummaryContext(path string, terms []string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
 	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only context extraction, close error non-fatal
+	}()
 
-	return unique, nil
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.ToLower(scanner.Text())
+		matched := false
+		for _, term := range terms {
+			if strings.Contains(line, term) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &data); err != nil {
+			continue
+		}
+		if summary, ok := data["summary"].(string); ok {
+			if len(summary) > ContextLineMaxLength {
+				summary = summary[:ContextLineMaxLength] + "..."
+			}
+			return summary
+		}
+		return ""
+	}
+	return ""
+}
+
+// searchVectors ranks files under dir by embedding similarity to query. The
+// vector store is synced first, so only new or changed files are embedded.
+// With hybrid, similarity is blended with BM25 keyword scores.
+func searchVectors(query, dir string, limit int, hybrid bool) ([]searchResult, error) {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	kind := cfg.Search.Embedder
+	if searchEmbedder != "" {
+		kind = searchEmbedder
+	}
+	embedder, err := search.NewEmbedder(kind, cfg.Search.EmbedURL, cfg.Search.EmbedModel)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := search.IndexableFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	storePath := filepath.Join(filepath.Dir(dir), "index", VectorsFileName)
+	store, err := search.LoadVectorStore(storePath, embedder.Name())
+	if err != nil {
+		VerbosePrintf("Warning: %v; re-embedding all files\n", err)
+		store = search.NewVectorStore(embedder.Name())
+	}
+	changed, err := store.Sync(embedder, dir, files)
+	if err != nil {
+		return nil, err
+	}
+	if changed > 0 {
+		VerbosePrintf("Embedded %d changed file(s) with %s\n", changed, embedder.Name())
+		if err := store.Save(storePath); err != nil {
+			VerbosePrintf("Warning: save vector store: %v\n", err)
+		}
+	}
+
+	queryVecs, err := embedder.Embed([]string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+
+	var hits []search.IndexResult
+	if hybrid {
+		idx, err := loadSearchIndex(dir)
+		if err != nil {
+			return nil, err
+		}
+		keyword := search.Search(idx, query, 0)
+		hits = search.MergeHybrid(keyword, store.Search(queryVecs[0], dir, 0), hybridSemanticWeight, limit)
+	} else {
+		hits = store.Search(queryVecs[0], dir, limit)
+	}
+
+	results := make([]searchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, searchResult{
+			Path:    hit.Path,
+			Score:   hit.Score,
+			Context: rankedResultContext(hit.Path, query),
+			Type:    classifyResultType(hit.Path),
+		})
+	}
+	return results, nil
 }
 
 // grepFiles uses grep to search files.
@@ -283,7 +521,7 @@ func parseGrepResults(output []byte, pattern, query string, useRipgrep bool) []s
 		results = append(results, searchResult{
 			Path:    line,
 			Context: context,
-			Type:    "session",
+			Type:    classifyResultType(line),
 		})
 	}
 
@@ -322,59 +560,6 @@ func getFileContext(path, query string) string {
 	return strings.Join(context, "\n")
 }
 
-// searchJSONL searches JSONL files using jq-like parsing.
-func searchJSONL(query string, dir string, limit int) ([]searchResult, error) {
-	var results []searchResult
-
-	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
-	if err != nil {
-		return nil, err
-	}
-
-	queryLower := strings.ToLower(query)
-
-	for _, file := range files {
-		f, err := os.Open(file)
-		if err != nil {
-			continue
-		}
-
-		scanner := bufio.NewScanner(f)
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, 1024*1024)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.Contains(strings.ToLower(line), queryLower) {
-				// Parse JSON to get meaningful context
-				var data map[string]interface{}
-				if err := json.Unmarshal([]byte(line), &data); err == nil {
-					context := ""
-					if summary, ok := data["summary"].(string); ok {
-						context = summary
-						if len(context) > ContextLineMaxLength {
-							context = context[:ContextLineMaxLength] + "..."
-						}
-					}
-					results = append(results, searchResult{
-						Path:    file,
-						Context: context,
-						Type:    "session",
-					})
-					break // One match per file
-				}
-			}
-		}
-		_ = f.Close() //nolint:errcheck // read-only search, close error non-fatal
-
-		if len(results) >= limit {
-			break
-		}
-	}
-
-	return results, nil
-}
-
 // searchSmartConnections uses Smart Connections HTTP API for semantic search.
 // Smart Connections exposes an HTTP API at localhost:37042 when Obsidian is running.
 // Falls back to file-based search if not available.
@@ -623,14 +808,3 @@ func calculateCASSScore(data map[string]interface{}) float64 {
 
 	return utility * maturityWeight * confidenceWeight
 }
-
-// filterByType filters results by knowledge type.
-func filterByType(results []searchResult, filterType string) []searchResult {
-	var filtered []searchResult
-	for _, r := range results {
-		if r.Type == filterType || filterType == "" {
-			filtered = append(filtered, r)
-		}
-	}
-	return filtered
-}
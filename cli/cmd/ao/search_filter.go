@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/search"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// filterKnowledgeDirs are the .agents/ subdirectories searched, alongside
+// sessions, when an `ao search` query carries field filters.
+var filterKnowledgeDirs = []string{"learnings", "patterns", "retros", "research", "decisions"}
+
+// filterOverfetch is how many times the limit a non-default backend is asked
+// for when its results will be narrowed by field filters afterwards.
+const filterOverfetch = 5
+
+// queryFields reads the metadata field filters are evaluated against from a
+// knowledge file.
+func queryFields(path string) (search.Fields, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return search.Fields{}, err
+	}
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+	return queryFieldsFromContent(path, string(content), modTime), nil
+}
+
+// queryFieldsFromContent extracts filter metadata from file content. JSONL
+// files use their first record; markdown uses front matter plus the
+// **Utility**/**Maturity**/**Tags** lines written by `ao forge`. The type
+// comes from front matter or, failing that, the file's directory, and the
+// date from front matter or, failing that, modTime.
+func queryFieldsFromContent(path, content string, modTime time.Time) search.Fields {
+	fields := search.Fields{
+		Type: classifyResultType(path),
+		Date: modTime,
+		Text: content,
+	}
+
+	if strings.HasSuffix(path, ".jsonl") {
+		fields.Utility = types.InitialUtility
+		first, _, _ := strings.Cut(content, "\n")
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(first), &data); err != nil {
+			return fields
+		}
+		if v, ok := data["type"].(string); ok && v != "" {
+			fields.Type = v
+		}
+		if v, ok := data["maturity"].(string); ok {
+			fields.Maturity = v
+		}
+		if v, ok := data["utility"].(float64); ok && v > 0 {
+			fields.Utility = v
+		}
+		if v, ok := data["confidence"].(float64); ok {
+			fields.Confidence = v
+		}
+		if tags, ok := data["tags"].([]interface{}); ok {
+			for _, t := range tags {
+				if s, ok := t.(string); ok {
+					fields.Tags = append(fields.Tags, s)
+				}
+			}
+		}
+		for _, key := range []string{"date", "created_at", "extracted_at"} {
+			if v, ok := data[key].(string); ok {
+				if t := parseFrontMatterDate(v); !t.IsZero() {
+					fields.Date = t
+					break
+				}
+			}
+		}
+		return fields
+	}
+
+	fields.Utility, fields.Maturity = parseMemRLMetadata(content)
+	_, fields.Tags = extractCategoryAndTags(content)
+
+	fm, _ := parseFrontMatter(strings.Split(content, "\n"))
+	if fm.HasUtility {
+		fields.Utility = fm.Utility
+	}
+	if fm.Maturity != "" {
+		fields.Maturity = fm.Maturity
+	}
+	if fm.Confidence > 0 {
+		fields.Confidence = fm.Confidence
+	}
+	if fm.Type != "" {
+		fields.Type = fm.Type
+	}
+	if !fm.Date.IsZero() {
+		fields.Date = fm.Date
+	}
+	return fields
+}
+
+// searchWithFilters runs a field-filtered query over sessions and the
+// knowledge directories next to them. Free text is ranked with BM25; a
+// filter-only query lists matching documents newest first.
+func searchWithFilters(fq *search.FilterQuery, sessionsDir string, limit int) ([]searchResult, error) {
+	agentsDir := filepath.Dir(filepath.Dir(sessionsDir))
+	dirs := []string{sessionsDir}
+	for _, sub := range filterKnowledgeDirs {
+		dirs = append(dirs, filepath.Join(agentsDir, sub))
+	}
+
+	var files []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		found, err := search.IndexableFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+
+	var candidates []search.IndexResult
+	if fq.Text != "" {
+		idx := search.NewIndex()
+		for _, path := range files {
+			if err := search.UpdateIndex(idx, path); err != nil {
+				continue // unreadable: skip
+			}
+		}
+		candidates = search.Search(idx, fq.Text, 0)
+	} else {
+		for _, path := range files {
+			candidates = append(candidates, search.IndexResult{Path: path})
+		}
+	}
+
+	type match struct {
+		result searchResult
+		date   time.Time
+	}
+	var matches []match
+	for _, c := range candidates {
+		fields, err := queryFields(c.Path)
+		if err != nil || !fq.Match(fields) {
+			continue
+		}
+		r := searchResult{Path: c.Path, Score: c.Score, Type: fields.Type}
+		if fq.Text != "" {
+			r.Context = rankedResultContext(c.Path, fq.Text)
+		}
+		matches = append(matches, match{result: r, date: fields.Date})
+	}
+
+	if fq.Text == "" {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].date.After(matches[j].date)
+		})
+	}
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	results := make([]searchResult, len(matches))
+	for i, m := range matches {
+		results[i] = m.result
+	}
+	return results, nil
+}
+
+// applyQueryFilters keeps the results whose files satisfy fq's field filters.
+func applyQueryFilters(results []searchResult, fq *search.FilterQuery) []searchResult {
+	var filtered []searchResult
+	for _, r := range results {
+		fields, err := queryFields(r.Path)
+		if err == nil && fq.Match(fields) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
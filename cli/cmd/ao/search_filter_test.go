@@ -0,0 +1,188 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/search"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestQueryFieldsFromContent(t *testing.T) {
+	mtime := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("markdown front matter", func(t *testing.T) {
+		content := "---\nmaturity: established\nutility: 0.82\ntags: [auth, tokens]\ndate: 2026-02-01\n---\n# Refresh tokens\n"
+		f := queryFieldsFromContent("/repo/.agents/learnings/L1.md", content, mtime)
+		if f.Type != "learning" || f.Maturity != "established" || f.Utility != 0.82 {
+			t.Errorf("fields = %+v", f)
+		}
+		if len(f.Tags) != 2 || f.Tags[0] != "auth" {
+			t.Errorf("tags = %v", f.Tags)
+		}
+		if want := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC); !f.Date.Equal(want) {
+			t.Errorf("date = %v, want %v", f.Date, want)
+		}
+	})
+
+	t.Run("forged markdown metadata lines", func(t *testing.T) {
+		content := "# Learning\n\n**Maturity**: candidate\n**Utility**: 0.7\n**Tags**: db, migrations\n"
+		f := queryFieldsFromContent("/repo/.agents/learnings/L2.md", content, mtime)
+		if f.Maturity != "candidate" || f.Utility != 0.7 || len(f.Tags) != 2 || !f.Date.Equal(mtime) {
+			t.Errorf("fields = %+v", f)
+		}
+	})
+
+	t.Run("jsonl first record", func(t *testing.T) {
+		content := `{"id":"L3","maturity":"provisional","utility":0.4,"confidence":0.9,"tags":["ci"]}` + "\n"
+		f := queryFieldsFromContent("/repo/.agents/learnings/L3.jsonl", content, mtime)
+		if f.Maturity != "provisional" || f.Utility != 0.4 || f.Confidence != 0.9 || len(f.Tags) != 1 {
+			t.Errorf("fields = %+v", f)
+		}
+	})
+}
+
+func TestSearchWithFilters(t *testing.T) {
+	tmp := t.TempDir()
+	sessDir := filepath.Join(tmp, ".agents", "ao", "sessions")
+	learnDir := filepath.Join(tmp, ".agents", "learnings")
+	for _, dir := range []string{sessDir, learnDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(sessDir, "s1.md"):    "# Session\nDebugged auth token refresh.\n",
+		filepath.Join(learnDir, "good.md"): "---\nmaturity: established\nutility: 0.9\ntags: [auth]\n---\n# Token refresh\nRefresh auth tokens early.\n",
+		filepath.Join(learnDir, "old.md"):  "---\nmaturity: established\nutility: 0.9\ntags: [auth]\n---\n# Deprecated auth flow\nauth via cookies (deprecated).\n",
+		filepath.Join(learnDir, "low.md"):  "---\nmaturity: provisional\nutility: 0.2\ntags: [auth]\n---\n# Auth guess\nauth maybe.\n",
+	}
+	for path, body := range files {
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fq, err := search.ParseFilterQuery("auth maturity:established utility:>0.6 tag:auth type:learning -deprecated", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := searchWithFilters(fq, sessDir, 10)
+	if err != nil {
+		t.Fatalf("searchWithFilters: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "good.md" || results[0].Type != "learning" {
+		t.Fatalf("results = %+v, want only good.md", results)
+	}
+
+	fq, _ = search.ParseFilterQuery("type:session", time.Now())
+	results, err = searchWithFilters(fq, sessDir, 10)
+	if err != nil {
+		t.Fatalf("searchWithFilters: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "s1.md" {
+		t.Errorf("filter-only results = %+v, want s1.md", results)
+	}
+}
+
+func TestSearchWithFiltersTypeAndUntil(t *testing.T) {
+	tmp := t.TempDir()
+	sessDir := filepath.Join(tmp, ".agents", "ao", "sessions")
+	decDir := filepath.Join(tmp, ".agents", "decisions")
+	for _, dir := range []string{sessDir, decDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(sessDir, "s1.md"):     "---\ndate: 2026-01-30\n---\n# Session\nauth auth auth token refresh.\n",
+		filepath.Join(decDir, "rotate.md"):  "---\ndate: 2026-01-31T15:04:00Z\n---\n# Rotate auth keys\nRotate auth keys monthly.\n",
+		filepath.Join(decDir, "cookies.md"): "---\ndate: 2026-02-01\n---\n# Drop cookie auth\nauth via cookies is gone.\n",
+	}
+	for path, body := range files {
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// --type applies before the limit, and accepts the plural
+	fq, err := search.ParseFilterQuery("auth", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fq.AddFilter("type", "decisions", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	results, err := searchWithFilters(fq, sessDir, 1)
+	if err != nil {
+		t.Fatalf("searchWithFilters: %v", err)
+	}
+	if len(results) != 1 || results[0].Type != "decision" {
+		t.Fatalf("results = %+v, want one decision", results)
+	}
+
+	// A document dated during the until day is included
+	fq, err = search.ParseFilterQuery("type:decision until:2026-01-31", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err = searchWithFilters(fq, sessDir, 10)
+	if err != nil {
+		t.Fatalf("searchWithFilters: %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "rotate.md" {
+		t.Fatalf("results = %+v, want rotate.md dated on the until day", results)
+	}
+}
+
+func TestCollectLearningsFieldFilters(t *testing.T) {
+	tmp := t.TempDir()
+	learnDir := filepath.Join(tmp, ".agents", "learnings")
+	if err := os.MkdirAll(learnDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"a.md": "---\nmaturity: established\n---\n# Retry budget\nCap retries at three.\n",
+		"b.md": "---\nmaturity: provisional\n---\n# Retry jitter\nAdd jitter to retries.\n",
+	} {
+		if err := os.WriteFile(filepath.Join(learnDir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	learnings, err := collectLearnings(tmp, "retry maturity:established", 10)
+	if err != nil {
+		t.Fatalf("collectLearnings: %v", err)
+	}
+	if len(learnings) != 1 || learnings[0].Title != "Retry budget" {
+		t.Errorf("learnings = %+v, want only Retry budget", learnings)
+	}
+
+	if _, err := collectLearnings(tmp, "utility:>lots", 10); err == nil {
+		t.Error("expected parse error for invalid filter")
+	}
+}
+
+func TestPoolEntryFields(t *testing.T) {
+	var e pool.PoolEntry
+	e.Candidate = types.Candidate{
+		Type:     types.KnowledgeTypeLearning,
+		Tier:     types.TierGold,
+		Utility:  0.7,
+		Content:  "use advisory locks",
+		Metadata: map[string]interface{}{"tags": []interface{}{"db"}},
+	}
+	e.Status = types.PoolStatusPending
+
+	fq, err := search.ParseFilterQuery("locks type:learning tier:gold status:pending tag:db utility:>0.5", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := poolEntryFields(e)
+	if !fq.MatchText(fields.Text) || !fq.Match(fields) {
+		t.Errorf("expected pool entry to match, fields = %+v", fields)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/search"
 )
 
 func TestClassifyResultType(t *testing.T) {
@@ -35,36 +37,6 @@ func TestClassifyResultType(t *testing.T) {
 	}
 }
 
-func TestFilterByType(t *testing.T) {
-	results := []searchResult{
-		{Path: "a.md", Type: "session"},
-		{Path: "b.md", Type: "learning"},
-		{Path: "c.md", Type: "session"},
-		{Path: "d.md", Type: "pattern"},
-	}
-
-	tests := []struct {
-		name       string
-		filterType string
-		wantCount  int
-	}{
-		{"filter sessions", "session", 2},
-		{"filter learnings", "learning", 1},
-		{"filter patterns", "pattern", 1},
-		{"filter nonexistent", "retro", 0},
-		{"empty filter returns all", "", 4},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := filterByType(results, tt.filterType)
-			if len(got) != tt.wantCount {
-				t.Errorf("filterByType(%q) returned %d results, want %d", tt.filterType, len(got), tt.wantCount)
-			}
-		})
-	}
-}
-
 func TestCalculateCASSScore(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -238,7 +210,7 @@ func splitLines(s string) []string {
 	return lines
 }
 
-func TestSearchJSONL(t *testing.T) {
+func TestSearchFilesJSONL(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Create JSONL files
@@ -263,9 +235,9 @@ func TestSearchJSONL(t *testing.T) {
 	}
 
 	t.Run("finds matching JSONL", func(t *testing.T) {
-		results, err := searchJSONL("auth", tmpDir, 10)
+		results, err := searchFiles("auth", tmpDir, 10)
 		if err != nil {
-			t.Fatalf("searchJSONL() error = %v", err)
+			t.Fatalf("searchFiles() error = %v", err)
 		}
 		if len(results) != 1 {
 			t.Errorf("got %d results, want 1", len(results))
@@ -276,9 +248,9 @@ func TestSearchJSONL(t *testing.T) {
 	})
 
 	t.Run("no match", func(t *testing.T) {
-		results, err := searchJSONL("kubernetes", tmpDir, 10)
+		results, err := searchFiles("kubernetes", tmpDir, 10)
 		if err != nil {
-			t.Fatalf("searchJSONL() error = %v", err)
+			t.Fatalf("searchFiles() error = %v", err)
 		}
 		if len(results) != 0 {
 			t.Errorf("got %d results, want 0", len(results))
@@ -287,9 +259,9 @@ func TestSearchJSONL(t *testing.T) {
 
 	t.Run("respects limit", func(t *testing.T) {
 		// Both files contain common words
-		results, err := searchJSONL("for", tmpDir, 1)
+		results, err := searchFiles("for", tmpDir, 1)
 		if err != nil {
-			t.Fatalf("searchJSONL() error = %v", err)
+			t.Fatalf("searchFiles() error = %v", err)
 		}
 		if len(results) > 1 {
 			t.Errorf("got %d results, want at most 1", len(results))
@@ -298,9 +270,9 @@ func TestSearchJSONL(t *testing.T) {
 
 	t.Run("empty directory", func(t *testing.T) {
 		emptyDir := t.TempDir()
-		results, err := searchJSONL("test", emptyDir, 10)
+		results, err := searchFiles("test", emptyDir, 10)
 		if err != nil {
-			t.Fatalf("searchJSONL() error = %v", err)
+			t.Fatalf("searchFiles() error = %v", err)
 		}
 		if len(results) != 0 {
 			t.Errorf("got %d results from empty dir, want 0", len(results))
@@ -372,6 +344,61 @@ Database migration patterns for PostgreSQL.
 	})
 }
 
+func TestSearchFilesUsesSavedIndex(t *testing.T) {
+	baseDir := t.TempDir()
+	sessDir := filepath.Join(baseDir, "sessions")
+	if err := os.MkdirAll(sessDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	session := filepath.Join(sessDir, "s1.md")
+	if err := os.WriteFile(session, []byte("mutex lock ordering\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idxPath := filepath.Join(baseDir, SearchIndexFileName)
+	count := func(query string) int {
+		t.Helper()
+		results, err := searchFiles(query, sessDir, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(results)
+	}
+
+	if count("mutex") != 1 {
+		t.Fatal("first query should build the index")
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, SearchManifestFileName)); err != nil {
+		t.Fatalf("manifest not saved: %v", err)
+	}
+
+	// An empty saved index proves queries read it instead of rebuilding
+	if err := search.SaveIndex(search.NewIndex(), idxPath); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("mutex"); n != 0 {
+		t.Errorf("query with a current manifest found %d result(s) outside the saved index", n)
+	}
+
+	// A changed file makes the manifest stale and the index is rebuilt
+	if err := os.WriteFile(session, []byte("mutex and worktree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if count("worktree") != 1 {
+		t.Error("changed file should trigger a rebuild")
+	}
+
+	// So does an index in an older format
+	if err := os.WriteFile(idxPath, []byte(`{"term":"mutex","paths":["`+session+`"]}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if count("worktree") != 1 {
+		t.Error("legacy index should be rebuilt")
+	}
+	if _, err := search.LoadIndex(idxPath); err != nil {
+		t.Errorf("rebuilt index not saved in the current format: %v", err)
+	}
+}
+
 func TestSearchFilesNoData(t *testing.T) {
 	tmp := t.TempDir()
 	// Use an empty (but existing) directory — grep returns error for nonexistent dirs
@@ -400,7 +427,7 @@ func TestParseGrepResults(t *testing.T) {
 	}{
 		{
 			name:       "ripgrep output (no filtering needed)",
-			output:     "/tmp/test/a.md\n/tmp/test/b.md\n",
+			output:     "/tmp/test/sessions/a.md\n/tmp/test/sessions/b.md\n",
 			pattern:    "*.md",
 			query:      "test",
 			useRipgrep: true,
@@ -408,7 +435,7 @@ func TestParseGrepResults(t *testing.T) {
 		},
 		{
 			name:       "grep output filtered by pattern",
-			output:     "/tmp/test/a.md\n/tmp/test/b.txt\n/tmp/test/c.md\n",
+			output:     "/tmp/test/sessions/a.md\n/tmp/test/sessions/b.txt\n/tmp/test/sessions/c.md\n",
 			pattern:    "*.md",
 			query:      "test",
 			useRipgrep: false,
@@ -432,7 +459,7 @@ func TestParseGrepResults(t *testing.T) {
 		},
 		{
 			name:       "grep no pattern filter",
-			output:     "/tmp/test/a.md\n",
+			output:     "/tmp/test/sessions/a.md\n",
 			pattern:    "",
 			query:      "test",
 			useRipgrep: false,
@@ -446,7 +473,7 @@ func TestParseGrepResults(t *testing.T) {
 			if len(got) != tt.wantCount {
 				t.Errorf("parseGrepResults() returned %d results, want %d", len(got), tt.wantCount)
 			}
-			// All results should have Type = "session"
+			// Files under sessions/ are classified as sessions
 			for _, r := range got {
 				if r.Type != "session" {
 					t.Errorf("result Type = %q, want %q", r.Type, "session")
@@ -524,3 +551,55 @@ func TestSearchFilesCombinedLimitEnforcement(t *testing.T) {
 		}
 	})
 }
+
+func TestSearchVectors(t *testing.T) {
+	tmp := t.TempDir()
+	sessDir := filepath.Join(tmp, "ao", "sessions")
+	if err := os.MkdirAll(sessDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"locks.md": "# Session\nAcquire mutexes in a fixed order to avoid deadlock.\n",
+		"auth.md":  "# Session\nConfigure the OAuth token refresh interval.\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(sessDir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("HOME", tmp) // keep user config out of the test
+	origEmbedder := searchEmbedder
+	searchEmbedder = "hash"
+	defer func() { searchEmbedder = origEmbedder }()
+
+	t.Run("semantic ranks by similarity and caches vectors", func(t *testing.T) {
+		results, err := searchVectors("deadlocked mutexes", sessDir, 10, false)
+		if err != nil {
+			t.Fatalf("searchVectors: %v", err)
+		}
+		if len(results) == 0 || filepath.Base(results[0].Path) != "locks.md" {
+			t.Fatalf("results = %+v, want locks.md first", results)
+		}
+		if _, err := os.Stat(filepath.Join(tmp, "ao", "index", VectorsFileName)); err != nil {
+			t.Errorf("vector store not persisted: %v", err)
+		}
+	})
+
+	t.Run("hybrid respects limit", func(t *testing.T) {
+		results, err := searchVectors("session", sessDir, 1, true)
+		if err != nil {
+			t.Fatalf("searchVectors: %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("got %d results, want 1", len(results))
+		}
+	})
+
+	t.Run("unknown embedder", func(t *testing.T) {
+		searchEmbedder = "bogus"
+		defer func() { searchEmbedder = "hash" }()
+		if _, err := searchVectors("mutex", sessDir, 10, false); err == nil {
+			t.Error("expected error for unknown embedder")
+		}
+	})
+}
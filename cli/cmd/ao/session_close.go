@@ -31,7 +31,11 @@ Examples:
   ao session close -o json`,
 }
 
-var sessionCloseSessionID string
+var (
+	sessionCloseSessionID  string
+	sessionCloseTranscript string
+	sessionCloseFormat     string
+)
 
 var sessionCloseCmd = &cobra.Command{
 	Use:   "close",
@@ -40,7 +44,7 @@ var sessionCloseCmd = &cobra.Command{
 measuring the flywheel delta, and reporting impact.
 
 Pipeline:
-  1. Find transcript (--session flag or most recent)
+  1. Find transcript (--transcript, --session, or most recent)
   2. Forge: extract knowledge from transcript
   3. Extract: queue and process learnings
   4. Measure: compute flywheel metrics delta
@@ -50,7 +54,9 @@ Examples:
   ao session close                        # Close most recent session
   ao session close --session abc123       # Close specific session
   ao session close --dry-run              # Preview what would happen
-  ao session close -o json                # Structured output`,
+  ao session close -o json                # Structured output
+  ao session close --transcript ~/.codex/sessions/2026/03/02/rollout-abc.jsonl
+  ao session close --transcript .aider.chat.history.md --format aider`,
 	RunE: runSessionClose,
 }
 
@@ -59,6 +65,8 @@ func init() {
 	sessionCmd.AddCommand(sessionCloseCmd)
 
 	sessionCloseCmd.Flags().StringVar(&sessionCloseSessionID, "session", "", "Session ID to close (default: most recent transcript)")
+	sessionCloseCmd.Flags().StringVar(&sessionCloseTranscript, "transcript", "", "Transcript file to close (any supported format)")
+	sessionCloseCmd.Flags().StringVar(&sessionCloseFormat, "format", parser.FormatAuto, "Transcript format: auto, "+strings.Join(parser.FormatNames(), ", "))
 }
 
 // SessionCloseResult holds the result of a session close operation.
@@ -75,10 +83,18 @@ type SessionCloseResult struct {
 }
 
 func runSessionClose(cmd *cobra.Command, args []string) error {
-	// Step 1: Find transcript
-	transcriptPath, usedFallback, err := resolveTranscript(sessionCloseSessionID)
+	format, err := transcriptFormat(sessionCloseFormat)
 	if err != nil {
-		return fmt.Errorf("find transcript: %w", err)
+		return err
+	}
+
+	// Step 1: Find transcript
+	transcriptPath, usedFallback := sessionCloseTranscript, false
+	if transcriptPath == "" {
+		transcriptPath, usedFallback, err = resolveTranscript(sessionCloseSessionID)
+		if err != nil {
+			return fmt.Errorf("find transcript: %w", err)
+		}
 	}
 
 	if usedFallback {
@@ -107,7 +123,7 @@ func runSessionClose(cmd *cobra.Command, args []string) error {
 	}
 
 	// Step 4: Forge the transcript
-	session, err := forgeTranscriptForClose(transcriptPath, cwd)
+	session, err := forgeTranscriptForClose(transcriptPath, cwd, format)
 	if err != nil {
 		return fmt.Errorf("forge transcript: %w", err)
 	}
@@ -202,16 +218,20 @@ func findTranscriptBySessionID(sessionID string) (string, error) {
 	return "", fmt.Errorf("no transcript found for session %s", sessionID)
 }
 
-// forgeTranscriptForClose runs the forge pipeline on a transcript.
-func forgeTranscriptForClose(transcriptPath, cwd string) (*storage.Session, error) {
+// forgeTranscriptForClose runs the forge pipeline on a transcript. A nil
+// format is detected from the file.
+func forgeTranscriptForClose(transcriptPath, cwd string, format parser.TranscriptFormat) (*storage.Session, error) {
 	baseDir := filepath.Join(cwd, storage.DefaultBaseDir)
-	fs := storage.NewFileStorage(
-		storage.WithBaseDir(baseDir),
-		storage.WithFormatters(
-			formatter.NewMarkdownFormatter(),
-			formatter.NewJSONLFormatter(),
-		),
+	fs, err := openStorage(baseDir,
+		formatter.NewMarkdownFormatter(),
+		formatter.NewJSONLFormatter(),
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fs.Close() //nolint:errcheck // writes already synced, close best-effort
+	}()
 
 	if err := fs.Init(); err != nil {
 		return nil, fmt.Errorf("initialize storage: %w", err)
@@ -219,8 +239,12 @@ func forgeTranscriptForClose(transcriptPath, cwd string) (*storage.Session, erro
 
 	p := parser.NewParser()
 	p.MaxContentLength = 0
+	p.Format = format
 
-	extractor := parser.NewExtractor()
+	extractor, err := loadForgeExtractor(cwd)
+	if err != nil {
+		return nil, err
+	}
 
 	session, err := processTranscript(transcriptPath, p, extractor, true, os.Stdout)
 	if err != nil {
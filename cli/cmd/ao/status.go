@@ -10,7 +10,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/boshu2/agentops/cli/internal/provenance"
 	"github.com/boshu2/agentops/cli/internal/storage"
 )
 
@@ -86,8 +85,12 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	status.Initialized = true
 
 	// Load sessions from index
-	fs := storage.NewFileStorage(storage.WithBaseDir(baseDir))
-	sessions, err := fs.ListSessions()
+	var sessions []storage.IndexEntry
+	fs, err := openStorage(baseDir)
+	if err == nil {
+		sessions, err = fs.ListSessions()
+		_ = fs.Close() //nolint:errcheck // read-only, close error non-fatal
+	}
 	if err == nil {
 		status.SessionCount = len(sessions)
 
@@ -115,8 +118,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load provenance stats
-	provPath := filepath.Join(baseDir, storage.ProvenanceDir, storage.ProvenanceFile)
-	graph, err := provenance.NewGraph(provPath)
+	graph, err := loadProvenanceGraph(baseDir)
 	if err == nil {
 		stats := graph.GetStats()
 		status.ProvenanceStats = &provStats{
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/config"
+	"github.com/boshu2/agentops/cli/internal/provenance"
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/storage"
+)
+
+// commandBackend is the storage backend of the running command, resolved
+// once before it runs so reads and writes agree and config is loaded once.
+// commandBackendErr is the error resolving it, reported by the first
+// command that touches storage.
+var (
+	commandBackend    string
+	commandBackendErr error
+)
+
+func init() {
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		commandBackend, commandBackendErr = resolveStorageBackend()
+	}
+}
+
+// storageBackend returns the storage backend of the running command, or
+// resolves it when called outside one.
+func storageBackend() (string, error) {
+	if commandBackend != "" || commandBackendErr != nil {
+		return commandBackend, commandBackendErr
+	}
+	return resolveStorageBackend()
+}
+
+// resolveStorageBackend reads the configured storage backend (storage.backend
+// in .agentops/config.yaml or AGENTOPS_STORAGE). config.Load rejects unknown
+// backends, so the result is always files or sqlite.
+func resolveStorageBackend() (string, error) {
+	cfg, err := config.Load(nil)
+	if err != nil {
+		return "", fmt.Errorf("load config: %w", err)
+	}
+	if cfg.Storage.Backend == "" {
+		return storage.BackendFiles, nil
+	}
+	return cfg.Storage.Backend, nil
+}
+
+// openStorage returns session storage under baseDir for the configured
+// backend. Callers must Close it.
+func openStorage(baseDir string, formatters ...storage.Formatter) (storage.Storage, error) {
+	backend, err := storageBackend()
+	if err != nil {
+		return nil, err
+	}
+	s, err := storage.Open(backend, baseDir, formatters...)
+	if err != nil {
+		return nil, fmt.Errorf("open storage: %w", err)
+	}
+	return s, nil
+}
+
+// openCitationStore returns the SQLite citation store under cwd when that
+// backend is configured, or nil for the JSONL log. Callers must Close a
+// non-nil store.
+func openCitationStore(cwd string) (ratchet.CitationStore, error) {
+	backend, err := storageBackend()
+	if err != nil || backend != storage.BackendSQLite {
+		return nil, err
+	}
+	return storage.NewSQLiteStorage(storage.WithBaseDir(filepath.Join(cwd, storage.DefaultBaseDir))), nil
+}
+
+// citationOptions opens the citation store under cwd and returns the
+// ratchet options that route citations to it, with a func closing it.
+func citationOptions(cwd string) ([]ratchet.CitationOption, func(), error) {
+	store, err := openCitationStore(cwd)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	if store == nil {
+		return nil, func() {}, nil
+	}
+	return []ratchet.CitationOption{ratchet.WithCitationStore(store)}, func() {
+		_ = store.Close() //nolint:errcheck // writes already committed, close best-effort
+	}, nil
+}
+
+// loadProvenanceGraph loads the provenance graph under baseDir from the
+// configured backend.
+func loadProvenanceGraph(baseDir string) (*provenance.Graph, error) {
+	backend, err := storageBackend()
+	if err != nil {
+		return nil, err
+	}
+	provPath := filepath.Join(baseDir, storage.ProvenanceDir, storage.ProvenanceFile)
+	if backend != storage.BackendSQLite {
+		return provenance.NewGraph(provPath)
+	}
+
+	s := storage.NewSQLiteStorage(storage.WithBaseDir(baseDir))
+	defer func() {
+		_ = s.Close() //nolint:errcheck // read-only, close error non-fatal
+	}()
+	records, err := s.ListProvenance()
+	if err != nil {
+		return nil, err
+	}
+	graph := &provenance.Graph{Path: s.Path()}
+	for _, r := range records {
+		graph.Records = append(graph.Records, provenance.Record(r))
+	}
+	return graph, nil
+}
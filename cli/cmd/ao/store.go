@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -14,18 +15,26 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
+	"github.com/boshu2/agentops/cli/internal/fsutil"
+	"github.com/boshu2/agentops/cli/internal/search"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
 var (
 	storeLimit      int
 	storeCategorize bool
+	storeForce      bool
 )
 
 const (
 	// IndexFileName is the name of the search index file.
 	IndexFileName = "search-index.jsonl"
 
+	// IndexManifestFileName is the name of the file manifest kept next to the
+	// search index. It records size, mtime and content hash per indexed file
+	// so index and rebuild only reprocess files that changed.
+	IndexManifestFileName = "manifest.json"
+
 	// IndexDir is the directory for index files.
 	IndexDir = ".agents/ao/index"
 )
@@ -92,7 +101,8 @@ semantic search across all artifacts.
 Commands:
   index    Add files to the search index
   search   Query the index
-  rebuild  Rebuild index from .agents/`,
+  rebuild  Rebuild index from .agents/
+  migrate  Convert session storage between files and SQLite`,
 }
 
 func init() {
@@ -110,6 +120,9 @@ Indexes:
   - MemRL utility scores
   - CASS maturity levels
 
+Files whose size, mtime and content hash match the manifest are skipped.
+Use --force to reprocess them anyway.
+
 Examples:
   ao store index .agents/learnings/*.md
   ao store index .agents/patterns/error-handling.md
@@ -118,6 +131,7 @@ Examples:
 			RunE: runStoreIndex,
 		}
 		indexCmd.Flags().BoolVar(&storeCategorize, "categorize", false, "Extract and store category/tags for retrieval")
+		indexCmd.Flags().BoolVar(&storeForce, "force", false, "Reprocess files even if unchanged since last indexed")
 		storeCmd.AddCommand(indexCmd)
 
 	// search subcommand
@@ -126,10 +140,13 @@ Examples:
 		Short: "Search the index",
 		Long: `Search for artifacts matching a query.
 
-Returns results ranked by relevance with snippets.
+Returns results ranked by BM25 relevance (boosted by MemRL utility) with
+snippets. Double-quoted words match as a phrase; a trailing * matches by prefix.
 
 Examples:
   ao store search "mutex pattern"
+  ao store search '"worktree merge"'
+
   ao store search "error handling" --limit 5
   ao store search "authentication" -o json`,
 		Args: cobra.ExactArgs(1),
@@ -142,20 +159,26 @@ Examples:
 		rebuildCmd := &cobra.Command{
 		Use:   "rebuild",
 		Short: "Rebuild search index",
-		Long: `Rebuild the search index from scratch.
+		Long: `Bring the search index up to date with .agents/.
 
-Scans all .agents/ directories and re-indexes:
+Scans all .agents/ directories:
   - learnings/
   - patterns/
   - research/
   - retros/
+  - candidates/
+
+Only files added, modified or deleted since the last run (per the index
+manifest) are reprocessed. Use --force to rebuild from scratch.
 
 Examples:
   ao store rebuild
+  ao store rebuild --force
   ao store rebuild --verbose`,
 			RunE: runStoreRebuild,
 		}
 		rebuildCmd.Flags().BoolVar(&storeCategorize, "categorize", false, "Extract and store category/tags for retrieval")
+		rebuildCmd.Flags().BoolVar(&storeForce, "force", false, "Discard the index and manifest and reprocess every file")
 		storeCmd.AddCommand(rebuildCmd)
 
 	// stats subcommand
@@ -202,24 +225,16 @@ func runStoreIndex(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	indexed := 0
-	for _, path := range files {
-		entry, err := createIndexEntry(path, storeCategorize)
-		if err != nil {
-			VerbosePrintf("Warning: skip %s: %v\n", filepath.Base(path), err)
-			continue
-		}
-
-		if err := appendToIndex(cwd, entry); err != nil {
-			VerbosePrintf("Warning: index %s: %v\n", filepath.Base(path), err)
-			continue
-		}
-
-		indexed++
-		VerbosePrintf("Indexed: %s\n", filepath.Base(path))
+	res, err := syncStoreIndex(cwd, files, false, storeForce, storeCategorize)
+	if err != nil {
+		return fmt.Errorf("update index: %w", err)
 	}
 
-	fmt.Printf("Indexed %d artifact(s)\n", indexed)
+	fmt.Printf("Indexed %d artifact(s)", res.Indexed)
+	if res.Unchanged > 0 {
+		fmt.Printf(", %d unchanged", res.Unchanged)
+	}
+	fmt.Println()
 	return nil
 }
 
@@ -264,12 +279,6 @@ func runStoreRebuild(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Remove existing index
-	indexPath := filepath.Join(cwd, IndexDir, IndexFileName)
-	if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
-		VerbosePrintf("Warning: remove old index: %v\n", err)
-	}
-
 	// Scan all artifact directories
 	dirs := []string{
 		filepath.Join(cwd, ".agents", "learnings"),
@@ -299,23 +308,13 @@ func runStoreRebuild(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	indexed := 0
-	for _, path := range files {
-		entry, err := createIndexEntry(path, storeCategorize)
-		if err != nil {
-			VerbosePrintf("Warning: skip %s: %v\n", filepath.Base(path), err)
-			continue
-		}
-
-		if err := appendToIndex(cwd, entry); err != nil {
-			VerbosePrintf("Warning: index %s: %v\n", filepath.Base(path), err)
-			continue
-		}
-
-		indexed++
+	res, err := syncStoreIndex(cwd, files, true, storeForce, storeCategorize)
+	if err != nil {
+		return fmt.Errorf("rebuild index: %w", err)
 	}
 
-	fmt.Printf("Rebuilt index: %d artifacts\n", indexed)
+	fmt.Printf("Rebuilt index: %d artifacts (%d reindexed, %d removed, %d unchanged)\n",
+		res.Total, res.Indexed, res.Removed, res.Unchanged)
 	return nil
 }
 
@@ -417,71 +416,180 @@ func createIndexEntry(path string, categorize bool) (*IndexEntry, error) {
 	return entry, nil
 }
 
-// appendToIndex adds an entry to the index file.
-func appendToIndex(baseDir string, entry *IndexEntry) error {
-	indexDir := filepath.Join(baseDir, IndexDir)
-	if err := os.MkdirAll(indexDir, 0755); err != nil {
-		return err
-	}
+// storeIndexResult summarises an incremental store index update.
+type storeIndexResult struct {
+	Indexed   int // files (re)processed
+	Removed   int // entries dropped because their file is gone
+	Unchanged int // files skipped because the manifest matched
+	Total     int // entries in the index afterwards
+}
 
-	indexPath := filepath.Join(indexDir, IndexFileName)
+// syncStoreIndex brings the store index up to date for files, reprocessing
+// only files whose content changed since the manifest was last written.
+// When complete is true, files is the whole corpus and entries for files no
+// longer present are dropped. force ignores the manifest (and, with complete,
+// the existing index) so every file is reprocessed.
+func syncStoreIndex(baseDir string, files []string, complete, force, categorize bool) (storeIndexResult, error) {
+	var res storeIndexResult
+	indexPath := filepath.Join(baseDir, IndexDir, IndexFileName)
+	manifestPath := filepath.Join(baseDir, IndexDir, IndexManifestFileName)
 
-	data, err := json.Marshal(entry)
+	entries, err := loadStoreIndex(indexPath)
 	if err != nil {
-		return err
+		return res, err
 	}
-
-	f, err := os.OpenFile(indexPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	manifest, err := search.LoadManifest(manifestPath)
 	if err != nil {
-		return err
+		VerbosePrintf("Warning: %v; reprocessing all files\n", err)
+		manifest = search.NewManifest()
+	}
+	if force {
+		manifest = search.NewManifest()
+		if complete {
+			entries = make(map[string]IndexEntry)
+		}
+	}
+
+	changes, states := manifest.Diff(files, complete)
+	for _, path := range changes.Removed {
+		delete(entries, path)
+		manifest.Forget(path)
+		res.Removed++
+		VerbosePrintf("Removed: %s\n", filepath.Base(path))
+	}
+	// Entries whose file vanished without ever reaching the manifest (e.g.
+	// an index written before manifests existed) are dropped too.
+	if complete {
+		present := make(map[string]bool, len(files))
+		for _, path := range files {
+			present[path] = true
+		}
+		for path := range entries {
+			if !present[path] {
+				delete(entries, path)
+				res.Removed++
+			}
+		}
 	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // write complete, close best-effort
-	}()
 
-	_, err = f.Write(append(data, '\n'))
-	return err
+	for _, path := range files {
+		state, ok := states[path]
+		if !ok {
+			continue
+		}
+		if _, have := entries[path]; have && manifest.Files[path].Hash == state.Hash {
+			manifest.Record(path, state) // refresh mtime of touched files
+			res.Unchanged++
+			continue
+		}
+
+		entry, err := createIndexEntry(path, categorize)
+		if err != nil {
+			VerbosePrintf("Warning: skip %s: %v\n", filepath.Base(path), err)
+			continue
+		}
+		entries[path] = *entry
+		manifest.Record(path, state)
+		res.Indexed++
+		VerbosePrintf("Indexed: %s\n", filepath.Base(path))
+	}
+	res.Total = len(entries)
+
+	if res.Indexed > 0 || res.Removed > 0 || force {
+		if err := writeStoreIndex(indexPath, entries); err != nil {
+			return res, err
+		}
+	}
+	if err := search.SaveManifest(manifest, manifestPath); err != nil {
+		return res, err
+	}
+	return res, nil
 }
 
-// searchIndex searches the index for matching entries.
-func searchIndex(baseDir, query string, limit int) ([]SearchResult, error) {
-	indexPath := filepath.Join(baseDir, IndexDir, IndexFileName)
+// loadStoreIndex reads the store index keyed by path. A missing index yields
+// an empty map; when a path appears more than once the last line wins.
+func loadStoreIndex(indexPath string) (map[string]IndexEntry, error) {
+	entries := make(map[string]IndexEntry)
 
 	f, err := os.Open(indexPath)
 	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("index not found - run 'ao store rebuild' first")
+		return entries, nil
 	}
 	if err != nil {
 		return nil, err
 	}
 	defer func() {
-		_ = f.Close() //nolint:errcheck // read-only index search, close error non-fatal
+		_ = f.Close() //nolint:errcheck // read-only index load, close error non-fatal
 	}()
 
-	queryTerms := strings.Fields(strings.ToLower(query))
-	var results []SearchResult
-
 	scanner := bufio.NewScanner(f)
-	// Increase buffer size for large entries
 	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
-
 	for scanner.Scan() {
-		var entry IndexEntry
-		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+		var e IndexEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
 			continue
 		}
+		if e.Path != "" {
+			entries[e.Path] = e
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// writeStoreIndex rewrites the store index sorted by path, replacing the
+// previous file atomically.
+func writeStoreIndex(indexPath string, entries map[string]IndexEntry) error {
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0755); err != nil {
+		return err
+	}
 
-		score := computeSearchScore(entry, queryTerms)
-		if score > 0 {
-			snippet := createSearchSnippet(entry.Content, query, 150)
-			results = append(results, SearchResult{
-				Entry:   entry,
-				Score:   score,
-				Snippet: snippet,
-			})
+	paths := make([]string, 0, len(entries))
+	for p := range entries {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, p := range paths {
+		if err := enc.Encode(entries[p]); err != nil {
+			return err
 		}
 	}
 
+	return fsutil.WriteFileAtomic(indexPath, buf.Bytes(), 0644)
+}
+
+// searchIndex searches the index for matching entries. Entries are ranked
+// with BM25 from internal/search and then boosted by MemRL utility.
+func searchIndex(baseDir, query string, limit int) ([]SearchResult, error) {
+	indexPath := filepath.Join(baseDir, IndexDir, IndexFileName)
+	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("index not found - run 'ao store rebuild' first")
+	}
+
+	entries, err := loadStoreIndex(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := search.NewIndex()
+	for path, entry := range entries {
+		idx.AddDocument(path, storeDocumentText(entry))
+	}
+
+	snippetQuery := strings.Join(search.QueryTerms(query), " ")
+	var results []SearchResult
+	for _, hit := range search.Search(idx, query, 0) {
+		entry := entries[hit.Path]
+		results = append(results, SearchResult{
+			Entry:   entry,
+			Score:   applyUtilityBoost(hit.Score, entry.Utility),
+			Snippet: createSearchSnippet(entry.Content, snippetQuery, 150),
+		})
+	}
+
 	// Sort by score (descending) then by utility (descending)
 	sort.Slice(results, func(i, j int) bool {
 		if results[i].Score != results[j].Score {
@@ -495,43 +603,22 @@ func searchIndex(baseDir, query string, limit int) ([]SearchResult, error) {
 		results = results[:limit]
 	}
 
-	return results, scanner.Err()
+	return results, nil
 }
 
-// computeSearchScore calculates relevance score for a query.
-func computeSearchScore(entry IndexEntry, queryTerms []string) float64 {
-	var score float64
-
-	lowerContent := strings.ToLower(entry.Content)
-	lowerTitle := strings.ToLower(entry.Title)
-
-	for _, term := range queryTerms {
-		// Title matches are worth more
-		if strings.Contains(lowerTitle, term) {
-			score += 3.0
-		}
-
-		// Content matches
-		if strings.Contains(lowerContent, term) {
-			score += 1.0
-		}
-
-		// Keyword matches
-		for _, kw := range entry.Keywords {
-			if strings.Contains(strings.ToLower(kw), term) {
-				score += 2.0
-				break
-			}
-		}
-	}
+// storeDocumentText is the text indexed for an entry. Title and keywords are
+// prepended so matches there count in addition to the body.
+func storeDocumentText(entry IndexEntry) string {
+	return entry.Title + "\n" + strings.Join(entry.Keywords, " ") + "\n" + entry.Content
+}
 
-	// Boost by utility (MemRL integration)
-	// Lambda = 0.5 (balanced weighting)
+// applyUtilityBoost blends a relevance score with MemRL utility.
+// Lambda = 0.5 (balanced weighting)
+func applyUtilityBoost(score, utility float64) float64 {
 	lambda := types.DefaultLambda
-	if entry.Utility > 0 {
-		score = (1-lambda)*score + lambda*entry.Utility*score
+	if utility > 0 {
+		score = (1-lambda)*score + lambda*utility*score
 	}
-
 	return score
 }
 
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/boshu2/agentops/cli/internal/storage"
+)
+
+var migrateTo string
+
+var storeMigrateCmd = &cobra.Command{
+	Use:   "migrate --to sqlite|files",
+	Short: "Convert session storage between files and SQLite",
+	Long: `Copy sessions, the session index, provenance and citations from one
+storage backend to the other:
+
+  files   JSONL under .agents/ao (index/, provenance/, sessions/, citations.jsonl)
+  sqlite  .agents/ao/ao.db, an embedded SQLite database
+
+Records are copied verbatim, so migrating back and forth is lossless. The
+destination is replaced entirely; the source is left untouched. Lines that
+are not valid JSON cannot be converted and are reported as skipped.
+
+After migrating, select the backend in .agentops/config.yaml:
+
+  storage:
+    backend: sqlite
+
+or with AGENTOPS_STORAGE=sqlite.
+
+Examples:
+  ao store migrate --to sqlite
+  ao store migrate --to files
+  ao store migrate --to sqlite --dry-run -o json`,
+	Args: cobra.NoArgs,
+	RunE: runStoreMigrate,
+}
+
+func init() {
+	storeCmd.AddCommand(storeMigrateCmd)
+	storeMigrateCmd.Flags().StringVar(&migrateTo, "to", "", "Destination backend: sqlite or files")
+	_ = storeMigrateCmd.MarkFlagRequired("to")
+}
+
+func runStoreMigrate(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("get working directory: %w", err)
+	}
+	backend, err := storageBackend()
+	if err != nil {
+		return err
+	}
+
+	report, err := storage.Migrate(filepath.Join(cwd, storage.DefaultBaseDir), migrateTo, GetDryRun())
+	if err != nil {
+		return fmt.Errorf("migrate storage: %w", err)
+	}
+
+	if GetOutput() == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printMigrateReport(cmd.OutOrStdout(), report, backend)
+	return nil
+}
+
+// printMigrateReport writes the human-readable migration summary.
+func printMigrateReport(w io.Writer, report *storage.MigrateReport, backend string) {
+	verb := "Migrated"
+	if report.DryRun {
+		verb = "[dry-run] Would migrate"
+	}
+	fmt.Fprintf(w, "%s %s → %s:\n", verb, report.From, report.To)
+	fmt.Fprintf(w, "  Sessions:      %d\n", report.Sessions)
+	fmt.Fprintf(w, "  Index entries: %d\n", report.IndexEntries)
+	fmt.Fprintf(w, "  Provenance:    %d\n", report.Provenance)
+	fmt.Fprintf(w, "  Citations:     %d\n", report.Citations)
+	if report.Skipped > 0 {
+		fmt.Fprintf(w, "  Skipped:       %d malformed line(s)\n", report.Skipped)
+	}
+	if !report.DryRun && backend != report.To {
+		fmt.Fprintf(w, "\nThe active backend is %s. Set storage.backend: %s in .agentops/config.yaml\n", backend, report.To)
+		fmt.Fprintf(w, "(or AGENTOPS_STORAGE=%s) to use the migrated data.\n", report.To)
+	}
+}
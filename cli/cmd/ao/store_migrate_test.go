@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestSQLiteBackend_Citations(t *testing.T) {
+	t.Setenv("AGENTOPS_STORAGE", storage.BackendSQLite)
+	cwd := chdirTemp(t)
+	opts, closeStore, err := citationOptions(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeStore()
+
+	if err := ratchet.RecordCitation(cwd, types.CitationEvent{ArtifactPath: "/l.md", SessionID: "s1"}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if err := markCitationFeedback(cwd, "s1", 0.8, nil); err != nil {
+		t.Fatal(err)
+	}
+	citations, err := ratchet.LoadCitations(cwd, opts...)
+	if err != nil || len(citations) != 1 || !citations[0].FeedbackGiven {
+		t.Fatalf("LoadCitations = %+v, %v", citations, err)
+	}
+	if _, err := os.Stat(filepath.Join(cwd, ratchet.CitationsFilePath)); !os.IsNotExist(err) {
+		t.Error("citations.jsonl should not be written with the sqlite backend")
+	}
+	if _, err := os.Stat(filepath.Join(cwd, storage.DefaultBaseDir, storage.SQLiteFile)); err != nil {
+		t.Errorf("database not created: %v", err)
+	}
+}
+
+func TestStoreMigrate(t *testing.T) {
+	t.Setenv("AGENTOPS_STORAGE", "")
+	cwd := chdirTemp(t)
+	baseDir := filepath.Join(cwd, storage.DefaultBaseDir)
+
+	fs, err := openStorage(baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteProvenance(&storage.ProvenanceRecord{ID: "prov-1", ArtifactPath: "a.md", SourcePath: "t.jsonl"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ratchet.RecordCitation(cwd, types.CitationEvent{ArtifactPath: "a.md", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := storage.Migrate(baseDir, storage.BackendSQLite, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, err := storageBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	printMigrateReport(&out, report, backend)
+	if !strings.Contains(out.String(), "Provenance:    1") || !strings.Contains(out.String(), "AGENTOPS_STORAGE=sqlite") {
+		t.Errorf("report:\n%s", out.String())
+	}
+
+	t.Setenv("AGENTOPS_STORAGE", storage.BackendSQLite)
+	graph, err := loadProvenanceGraph(baseDir)
+	if err != nil || len(graph.Records) != 1 || graph.Records[0].SourcePath != "t.jsonl" {
+		t.Fatalf("graph from sqlite = %+v, %v", graph, err)
+	}
+	opts, closeStore, err := citationOptions(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeStore()
+	if citations, _ := ratchet.LoadCitations(cwd, opts...); len(citations) != 1 {
+		t.Errorf("citations after migrate = %+v", citations)
+	}
+}
+
+func TestStorageBackendResolvedOncePerCommand(t *testing.T) {
+	t.Setenv("AGENTOPS_STORAGE", storage.BackendSQLite)
+	chdirTemp(t)
+	t.Cleanup(func() { commandBackend, commandBackendErr = "", nil })
+
+	rootCmd.PersistentPreRun(storeMigrateCmd, nil)
+	t.Setenv("AGENTOPS_STORAGE", storage.BackendFiles)
+	if got, err := storageBackend(); err != nil || got != storage.BackendSQLite {
+		t.Errorf("storageBackend() = %q, %v, want the backend resolved when the command started", got, err)
+	}
+}
+
+func TestStorageBackendInvalid(t *testing.T) {
+	t.Setenv("AGENTOPS_STORAGE", "postgres")
+	cwd := chdirTemp(t)
+	t.Cleanup(func() { commandBackend, commandBackendErr = "", nil })
+
+	rootCmd.PersistentPreRun(storeMigrateCmd, nil)
+	if _, err := openStorage(filepath.Join(cwd, storage.DefaultBaseDir)); err == nil || !strings.Contains(err.Error(), "storage.backend") {
+		t.Errorf("openStorage with an unknown backend = %v", err)
+	}
+	if _, _, err := citationOptions(cwd); err == nil {
+		t.Error("citationOptions with an unknown backend should fail, not fall back to files")
+	}
+	if _, err := loadProvenanceGraph(filepath.Join(cwd, storage.DefaultBaseDir)); err == nil {
+		t.Error("loadProvenanceGraph with an unknown backend should fail, not fall back to files")
+	}
+}
@@ -1,110 +1,184 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
-func TestComputeSearchScore(t *testing.T) {
-	tests := []struct {
-		name       string
-		entry      IndexEntry
-		queryTerms []string
-		wantMin    float64
-	}{
-		{
-			name: "title match scores highest",
-			entry: IndexEntry{
-				Title:   "Mutex Pattern",
-				Content: "unrelated content",
-			},
-			queryTerms: []string{"mutex"},
-			wantMin:    3.0, // title match = 3.0
-		},
-		{
-			name: "content match",
-			entry: IndexEntry{
-				Title:   "Some Title",
-				Content: "Use mutex for shared state",
-			},
-			queryTerms: []string{"mutex"},
-			wantMin:    1.0, // content match = 1.0
-		},
-		{
-			name: "keyword match",
-			entry: IndexEntry{
-				Title:    "Title",
-				Content:  "content",
-				Keywords: []string{"mutex", "concurrency"},
-			},
-			queryTerms: []string{"mutex"},
-			wantMin:    2.0, // keyword match = 2.0
-		},
+func TestSearchIndexRanking(t *testing.T) {
+	tmp := t.TempDir()
+	entries := []*IndexEntry{
 		{
-			name: "all three match",
-			entry: IndexEntry{
-				Title:    "Mutex Pattern",
-				Content:  "Use mutex for shared state",
-				Keywords: []string{"mutex"},
-			},
-			queryTerms: []string{"mutex"},
-			wantMin:    6.0, // 3 + 1 + 2 = 6.0
+			Path:    "/k/learnings/short.md",
+			Title:   "Mutex Pattern",
+			Type:    "learning",
+			Content: "# Mutex Pattern\nGuard shared maps with a mutex.",
 		},
 		{
-			name: "no match",
-			entry: IndexEntry{
-				Title:   "Database Pattern",
-				Content: "pooling connections",
-			},
-			queryTerms: []string{"auth"},
-			wantMin:    0.0,
+			Path:    "/k/retros/long.md",
+			Title:   "Sprint Retro",
+			Type:    "retro",
+			Content: "# Sprint Retro\nWe used a mutex once.\n" + strings.Repeat("Long unrelated retro discussion.\n", 80),
 		},
 		{
-			name: "utility boost",
-			entry: IndexEntry{
-				Title:   "Mutex Pattern",
-				Content: "content",
-				Utility: 0.9,
-			},
-			queryTerms: []string{"mutex"},
-			// title match (3.0) weighted by utility: (1-0.5)*3 + 0.5*0.9*3 = 1.5 + 1.35 = 2.85
-			wantMin: 2.8,
-		},
-		{
-			name: "multiple query terms",
-			entry: IndexEntry{
-				Title:   "Mutex Pattern for Go",
-				Content: "Use mutex and channels for concurrency",
-			},
-			queryTerms: []string{"mutex", "channels"},
-			wantMin:    4.0, // mutex: title(3) + content(1), channels: content(1) = 5.0
+			Path:    "/k/patterns/db.md",
+			Title:   "Database Pattern",
+			Type:    "pattern",
+			Content: "pooling connections",
 		},
 	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := computeSearchScore(tt.entry, tt.queryTerms)
-			if got < tt.wantMin {
-				t.Errorf("computeSearchScore() = %v, want >= %v", got, tt.wantMin)
-			}
-		})
+	byPath := make(map[string]IndexEntry)
+	for _, e := range entries {
+		byPath[e.Path] = *e
+	}
+	if err := writeStoreIndex(filepath.Join(tmp, IndexDir, IndexFileName), byPath); err != nil {
+		t.Fatalf("writeStoreIndex: %v", err)
 	}
 
-	// Test relative ordering
-	t.Run("title match ranks higher than content match", func(t *testing.T) {
-		titleEntry := IndexEntry{Title: "Mutex Pattern", Content: "something else"}
-		contentEntry := IndexEntry{Title: "Something", Content: "mutex is useful"}
-
-		titleScore := computeSearchScore(titleEntry, []string{"mutex"})
-		contentScore := computeSearchScore(contentEntry, []string{"mutex"})
+	t.Run("short focused entry outranks long one", func(t *testing.T) {
+		results, err := searchIndex(tmp, "mutex", 10)
+		if err != nil {
+			t.Fatalf("searchIndex: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("got %d results, want 2", len(results))
+		}
+		if results[0].Entry.Path != "/k/learnings/short.md" {
+			t.Errorf("first result = %s, want short.md", results[0].Entry.Path)
+		}
+		if results[0].Score <= results[1].Score {
+			t.Errorf("expected distinct scores, got %.3f and %.3f", results[0].Score, results[1].Score)
+		}
+	})
 
-		if titleScore <= contentScore {
-			t.Errorf("title match (%.2f) should rank higher than content match (%.2f)",
-				titleScore, contentScore)
+	t.Run("phrase query", func(t *testing.T) {
+		results, err := searchIndex(tmp, `"shared maps"`, 10)
+		if err != nil {
+			t.Fatalf("searchIndex: %v", err)
+		}
+		if len(results) != 1 || results[0].Entry.Path != "/k/learnings/short.md" {
+			t.Errorf("phrase results = %+v, want only short.md", results)
 		}
 	})
 }
 
+func TestLoadStoreIndexLastLineWins(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), IndexFileName)
+	lines := `{"path":"/k/a.md","content":"old"}
+not json
+{"path":"/k/a.md","content":"new"}
+{"path":"/k/b.md","content":"other"}
+`
+	if err := os.WriteFile(indexPath, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := loadStoreIndex(indexPath)
+	if err != nil {
+		t.Fatalf("loadStoreIndex: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries["/k/a.md"].Content != "new" {
+		t.Errorf("a.md content = %q, want the later line", entries["/k/a.md"].Content)
+	}
+}
+
+func TestSyncStoreIndexIncremental(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, ".agents", "learnings")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	for path, body := range map[string]string{a: "# Alpha\nmutex", b: "# Beta\nchannels"} {
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := syncStoreIndex(tmp, []string{a, b}, true, false, false)
+	if err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	if res.Indexed != 2 || res.Total != 2 {
+		t.Fatalf("first sync = %+v, want 2 indexed", res)
+	}
+
+	// Nothing changed: nothing is reprocessed.
+	res, err = syncStoreIndex(tmp, []string{a, b}, true, false, false)
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if res.Indexed != 0 || res.Unchanged != 2 {
+		t.Fatalf("second sync = %+v, want 2 unchanged", res)
+	}
+
+	// Touching a file without changing content is still unchanged.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(a, later, later); err != nil {
+		t.Fatal(err)
+	}
+	res, err = syncStoreIndex(tmp, []string{a, b}, true, false, false)
+	if err != nil {
+		t.Fatalf("touch sync: %v", err)
+	}
+	if res.Indexed != 0 || res.Unchanged != 2 {
+		t.Fatalf("touch sync = %+v, want 2 unchanged", res)
+	}
+
+	// Modify one file and delete the other.
+	if err := os.WriteFile(a, []byte("# Alpha\nmutex and rwmutex"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	res, err = syncStoreIndex(tmp, []string{a}, true, false, false)
+	if err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if res.Indexed != 1 || res.Removed != 1 || res.Total != 1 {
+		t.Fatalf("third sync = %+v, want 1 indexed, 1 removed", res)
+	}
+
+	entries, err := loadStoreIndex(filepath.Join(tmp, IndexDir, IndexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entries[b]; ok {
+		t.Error("deleted file still in index")
+	}
+	if !strings.Contains(entries[a].Content, "rwmutex") {
+		t.Errorf("modified file not reindexed: %q", entries[a].Content)
+	}
+
+	// --force reprocesses everything.
+	res, err = syncStoreIndex(tmp, []string{a}, true, true, false)
+	if err != nil {
+		t.Fatalf("force sync: %v", err)
+	}
+	if res.Indexed != 1 || res.Unchanged != 0 {
+		t.Fatalf("force sync = %+v, want 1 indexed", res)
+	}
+}
+
+func TestApplyUtilityBoost(t *testing.T) {
+	// (1-0.5)*3 + 0.5*0.9*3 = 1.5 + 1.35 = 2.85
+	if got := applyUtilityBoost(3.0, 0.9); got < 2.84 || got > 2.86 {
+		t.Errorf("applyUtilityBoost(3, 0.9) = %v, want 2.85", got)
+	}
+	if got := applyUtilityBoost(3.0, 0); got != 3.0 {
+		t.Errorf("applyUtilityBoost(3, 0) = %v, want 3 (no utility, no boost)", got)
+	}
+}
+
 func TestExtractTitle(t *testing.T) {
 	tests := []struct {
 		name    string
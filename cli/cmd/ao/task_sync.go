@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -11,6 +13,8 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/boshu2/agentops/cli/internal/fsutil"
+	"github.com/boshu2/agentops/cli/internal/parser"
 	"github.com/boshu2/agentops/cli/internal/ratchet"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
@@ -76,7 +80,8 @@ with the CASS (Contextual Agent Session Search) system, enabling:
   - Feedback loop closure: Task completion signals update learning utilities
 
 The sync process:
-  1. Reads Claude Code transcript for TaskCreate/TaskUpdate tool calls
+  1. Reads the transcript for TaskCreate/TaskUpdate tool calls (Claude Code)
+     or update_plan steps (Codex CLI); other formats use the same tool names
   2. Extracts task events and stores them in .agents/ao/tasks.jsonl
   3. Maps task status to CASS maturity levels
   4. Links task completion to the feedback loop
@@ -84,6 +89,7 @@ The sync process:
 Examples:
   ao task-sync                                # Sync from most recent transcript
   ao task-sync --transcript ~/.claude/projects/*/abc.jsonl
+  ao task-sync --transcript ~/.codex/sessions/2026/03/02/rollout-abc.jsonl
   ao task-sync --session session-20260125    # Filter by session
   ao task-sync --promote                     # Promote completed tasks to learnings`,
 	RunE: runTaskSync,
@@ -93,13 +99,15 @@ var (
 	taskSyncTranscript string
 	taskSyncSessionID  string
 	taskSyncPromote    bool
+	taskSyncFormat     string
 )
 
 func init() {
 	rootCmd.AddCommand(taskSyncCmd)
-	taskSyncCmd.Flags().StringVar(&taskSyncTranscript, "transcript", "", "Path to Claude Code transcript")
+	taskSyncCmd.Flags().StringVar(&taskSyncTranscript, "transcript", "", "Path to a transcript (any supported format)")
 	taskSyncCmd.Flags().StringVar(&taskSyncSessionID, "session", "", "Filter tasks by session ID")
 	taskSyncCmd.Flags().BoolVar(&taskSyncPromote, "promote", false, "Promote completed tasks to learnings")
+	taskSyncCmd.Flags().StringVar(&taskSyncFormat, "format", parser.FormatAuto, "Transcript format: auto, "+strings.Join(parser.FormatNames(), ", "))
 }
 
 func runTaskSync(cmd *cobra.Command, args []string) error {
@@ -126,7 +134,11 @@ func runTaskSync(cmd *cobra.Command, args []string) error {
 	}
 
 	// Extract task events from transcript
-	tasks, err := extractTaskEvents(transcriptPath, taskSyncSessionID)
+	format, err := transcriptFormat(taskSyncFormat)
+	if err != nil {
+		return err
+	}
+	tasks, err := extractTaskEvents(transcriptPath, taskSyncSessionID, format)
 	if err != nil {
 		return fmt.Errorf("extract tasks: %w", err)
 	}
@@ -198,8 +210,10 @@ func runTaskSync(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// extractTaskEvents parses a Claude Code transcript for Task tool calls.
-func extractTaskEvents(transcriptPath, filterSession string) ([]TaskEvent, error) {
+// extractTaskEvents parses a transcript for task tool calls: Claude Code's
+// TaskCreate/TaskUpdate and Codex's update_plan. A nil format is detected
+// from the file.
+func extractTaskEvents(transcriptPath, filterSession string, format parser.TranscriptFormat) ([]TaskEvent, error) {
 	f, err := os.Open(transcriptPath)
 	if err != nil {
 		return nil, fmt.Errorf("open transcript: %w", err)
@@ -208,26 +222,25 @@ func extractTaskEvents(transcriptPath, filterSession string) ([]TaskEvent, error
 		_ = f.Close() //nolint:errcheck // read-only transcript extraction, close error non-fatal
 	}()
 
-	var tasks []TaskEvent
+	if format == nil {
+		if format, err = parser.DetectFile(transcriptPath); err != nil {
+			return nil, err
+		}
+	}
+	p := parser.NewParser()
+	p.MaxContentLength = 0
+	p.Format = format
+
+	var order []*TaskEvent
 	taskMap := make(map[string]*TaskEvent) // Track by task ID for updates
+	planMap := make(map[string]*TaskEvent) // Plan steps by step text
 	var currentSessionID string
 
-	scanner := bufio.NewScanner(f)
-	// Use larger buffer for long lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		var data map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &data); err != nil {
-			continue
-		}
-
-		// Extract session ID if present
-		if sid, ok := data["sessionId"].(string); ok && sid != "" {
-			currentSessionID = sid
+	msgCh, errCh := p.ParseChannel(f)
+	for msg := range msgCh {
+		// Track the session the message belongs to
+		if msg.SessionID != "" {
+			currentSessionID = msg.SessionID
 		}
 
 		// Filter by session if requested
@@ -235,42 +248,24 @@ func extractTaskEvents(transcriptPath, filterSession string) ([]TaskEvent, error
 			continue
 		}
 
-		// Look for tool calls
-		message, ok := data["message"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		content, ok := message["content"].([]interface{})
-		if !ok {
-			continue
-		}
-
-		for _, item := range content {
-			block, ok := item.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			blockType, _ := block["type"].(string)
-			if blockType != "tool_use" {
-				continue
-			}
-
-			toolName, _ := block["name"].(string)
-			input, _ := block["input"].(map[string]interface{})
-
-			switch toolName {
+		for _, tool := range msg.Tools {
+			switch tool.Name {
 			case "TaskCreate":
-				task := parseTaskCreate(input, currentSessionID)
+				task := parseTaskCreate(tool.Input, currentSessionID)
 				if task != nil {
 					taskMap[task.TaskID] = task
+					order = append(order, task)
 				}
 
 			case "TaskUpdate":
-				taskID, _ := input["taskId"].(string)
+				taskID, _ := tool.Input["taskId"].(string)
 				if existing, ok := taskMap[taskID]; ok {
-					updateTask(existing, input)
+					updateTask(existing, tool.Input)
+				}
+
+			case "update_plan":
+				for _, task := range applyPlanUpdate(planMap, tool.Input, currentSessionID) {
+					order = append(order, task)
 				}
 
 			case "TaskList":
@@ -279,15 +274,44 @@ func extractTaskEvents(transcriptPath, filterSession string) ([]TaskEvent, error
 			}
 		}
 	}
+	if err := <-errCh; err != nil {
+		return nil, fmt.Errorf("parse transcript: %w", err)
+	}
 
-	// Convert map to slice
-	for _, t := range taskMap {
+	tasks := make([]TaskEvent, 0, len(order))
+	for _, t := range order {
 		tasks = append(tasks, *t)
 	}
-
 	return tasks, nil
 }
 
+// applyPlanUpdate applies a Codex update_plan call ({"plan": [{"step",
+// "status"}]}) to the plan steps seen so far, keyed by step text. It
+// returns the steps that are new.
+func applyPlanUpdate(planMap map[string]*TaskEvent, input map[string]interface{}, sessionID string) []*TaskEvent {
+	plan, _ := input["plan"].([]interface{})
+	var created []*TaskEvent
+	for _, item := range plan {
+		step, _ := item.(map[string]interface{})
+		subject, _ := step["step"].(string)
+		if subject == "" {
+			continue
+		}
+		task, ok := planMap[subject]
+		if !ok {
+			task = parseTaskCreate(map[string]interface{}{"subject": subject}, sessionID)
+			hash := sha256.Sum256([]byte(sessionID + "\x00" + subject))
+			task.TaskID = "task-plan-" + hex.EncodeToString(hash[:4])
+			planMap[subject] = task
+			created = append(created, task)
+		}
+		if status, ok := step["status"].(string); ok && status != task.Status {
+			updateTask(task, map[string]interface{}{"status": status})
+		}
+	}
+	return created
+}
+
 // parseTaskCreate extracts a TaskEvent from TaskCreate input.
 func parseTaskCreate(input map[string]interface{}, sessionID string) *TaskEvent {
 	subject, _ := input["subject"].(string)
@@ -374,40 +398,31 @@ func writeTaskEvents(baseDir string, tasks []TaskEvent) error {
 		return fmt.Errorf("create task directory: %w", err)
 	}
 
-	// Load existing tasks to merge
-	existing, _ := loadTaskEvents(baseDir)
-	existingMap := make(map[string]bool)
-	for _, t := range existing {
-		existingMap[t.TaskID] = true
-	}
-
-	// Open for append
-	f, err := os.OpenFile(taskPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("open task file: %w", err)
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // write complete, close best-effort
-	}()
-
-	// Write only new tasks
-	written := 0
-	for _, task := range tasks {
-		if existingMap[task.TaskID] {
-			continue
+	// Hold the lock across the dedup read and the append so concurrent
+	// syncs cannot both write the same task
+	return fsutil.WithLock(taskPath, func() error {
+		existing, _ := loadTaskEvents(baseDir)
+		existingMap := make(map[string]bool)
+		for _, t := range existing {
+			existingMap[t.TaskID] = true
 		}
-		data, err := json.Marshal(task)
-		if err != nil {
-			continue
+
+		// Write only new tasks
+		var newTasks []interface{}
+		for _, task := range tasks {
+			if existingMap[task.TaskID] {
+				continue
+			}
+			existingMap[task.TaskID] = true
+			newTasks = append(newTasks, task)
 		}
-		if _, err := f.Write(append(data, '\n')); err != nil {
+		if err := fsutil.AppendJSONLLocked(taskPath, 0644, newTasks...); err != nil {
 			return fmt.Errorf("write task event: %w", err)
 		}
-		written++
-	}
 
-	VerbosePrintf("Wrote %d new task events\n", written)
-	return nil
+		VerbosePrintf("Wrote %d new task events\n", len(newTasks))
+		return nil
+	})
 }
 
 // loadTaskEvents reads all task events from the log.
@@ -651,11 +666,11 @@ func runTaskStatus(cmd *cobra.Command, args []string) error {
 
 	if GetOutput() == "json" {
 		result := map[string]interface{}{
-			"total":          len(tasks),
-			"status_counts":  statusCounts,
+			"total":           len(tasks),
+			"status_counts":   statusCounts,
 			"maturity_counts": maturityCounts,
-			"with_learnings": withLearnings,
-			"tasks":          tasks,
+			"with_learnings":  withLearnings,
+			"tasks":           tasks,
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
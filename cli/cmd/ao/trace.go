@@ -13,7 +13,10 @@ import (
 )
 
 var (
-	traceGraph bool
+	traceGraph      bool
+	traceSources    bool
+	traceDependents bool
+	traceFormat     string
 )
 
 var traceCmd = &cobra.Command{
@@ -24,17 +27,30 @@ var traceCmd = &cobra.Command{
 Shows the lineage from the session file to the original JSONL transcript
 that was processed to create it.
 
+--graph, --sources, --dependents and --format query the full lineage graph,
+which joins provenance records, citations and pool chain events across any
+number of hops: transcript → candidate → promoted learning → session that
+cited it → transcript → ... Arguments may be paths, file names, candidate
+IDs or session IDs.
+
 Examples:
   ao trace .agents/ao/sessions/2026-01-20-my-session.md
   ao trace .agents/ao/sessions/*.md --graph
-  ao trace session-abc123 -o json`,
+  ao trace session-abc123 -o json
+  ao trace .agents/patterns/retry-backoff.md --sources
+  ao trace .agents/learnings/L12.md --dependents
+  ao trace .agents/learnings/L12.md --format dot | dot -Tsvg > lineage.svg
+  ao trace .agents/learnings/L12.md --dependents --format mermaid`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runTrace,
 }
 
 func init() {
 	rootCmd.AddCommand(traceCmd)
-	traceCmd.Flags().BoolVar(&traceGraph, "graph", false, "Show ASCII provenance graph")
+	traceCmd.Flags().BoolVar(&traceGraph, "graph", false, "Show ASCII lineage tree")
+	traceCmd.Flags().BoolVar(&traceSources, "sources", false, "List the transcripts that ultimately produced the artifact")
+	traceCmd.Flags().BoolVar(&traceDependents, "dependents", false, "Walk downstream: what depends on the artifact")
+	traceCmd.Flags().StringVar(&traceFormat, "format", "", "Export lineage as dot, mermaid or json")
 }
 
 func runTrace(cmd *cobra.Command, args []string) error {
@@ -49,10 +65,12 @@ func runTrace(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	provPath := filepath.Join(cwd, storage.DefaultBaseDir, storage.ProvenanceDir, storage.ProvenanceFile)
+	if traceGraph || traceSources || traceDependents || traceFormat != "" {
+		return runTraceLineage(cmd.OutOrStdout(), cwd, args)
+	}
 
 	// Load provenance graph
-	graph, err := provenance.NewGraph(provPath)
+	graph, err := loadProvenanceGraph(filepath.Join(cwd, storage.DefaultBaseDir))
 	if err != nil {
 		return fmt.Errorf("load provenance: %w", err)
 	}
@@ -81,11 +99,7 @@ func runTrace(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		if traceGraph {
-			printTraceGraph(result)
-		} else {
-			printTraceTable(result)
-		}
+		printTraceTable(result)
 	}
 
 	return nil
@@ -114,31 +128,6 @@ func printTraceTable(result *provenance.TraceResult) {
 	}
 }
 
-func printTraceGraph(result *provenance.TraceResult) {
-	fmt.Printf("\nProvenance Graph for: %s\n\n", result.Artifact)
-
-	for i, record := range result.Chain {
-		// Print the artifact node
-		if i == 0 {
-			fmt.Printf("  ┌─ %s\n", filepath.Base(result.Artifact))
-			fmt.Printf("  │  [%s]\n", record.ArtifactType)
-		}
-
-		// Print the source connection
-		fmt.Printf("  │\n")
-		fmt.Printf("  │  ← %s\n", record.ID)
-		fmt.Printf("  │\n")
-		fmt.Printf("  └─ %s\n", filepath.Base(record.SourcePath))
-		fmt.Printf("     [%s]\n", record.SourceType)
-
-		if record.SessionID != "" {
-			fmt.Printf("     session: %s\n", record.SessionID[:min(12, len(record.SessionID))])
-		}
-	}
-
-	fmt.Println()
-}
-
 func repeatString(s string, n int) string {
 	result := ""
 	for i := 0; i < n; i++ {
@@ -146,10 +135,3 @@ func repeatString(s string, n int) string {
 	}
 	return result
 }
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/provenance"
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/storage"
+)
+
+// traceLineageResult is the JSON output of a lineage query for one node.
+type traceLineageResult struct {
+	Query      string             `json:"query"`
+	Node       *provenance.Node   `json:"node"`
+	Sources    []*provenance.Node `json:"sources,omitempty"`
+	Ancestors  []*provenance.Node `json:"ancestors,omitempty"`
+	Dependents []*provenance.Node `json:"dependents,omitempty"`
+}
+
+// loadLineage builds the lineage graph for the project at cwd from
+// provenance records, citations and the pool chain.
+func loadLineage(cwd string) (*provenance.Lineage, error) {
+	lineage := provenance.NewLineage()
+
+	graph, err := loadProvenanceGraph(filepath.Join(cwd, storage.DefaultBaseDir))
+	if err != nil {
+		return nil, fmt.Errorf("load provenance: %w", err)
+	}
+	for _, record := range graph.Records {
+		lineage.AddRecord(record)
+	}
+
+	opts, closeStore, err := citationOptions(cwd)
+	if err != nil {
+		return nil, err
+	}
+	defer closeStore()
+	citations, err := ratchet.LoadCitations(cwd, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load citations: %w", err)
+	}
+	for _, citation := range citations {
+		lineage.AddCitation(citation)
+	}
+
+	p := pool.NewPool(cwd)
+	events, err := p.GetChain()
+	if err != nil {
+		return nil, fmt.Errorf("load pool chain: %w", err)
+	}
+	reverted := make(map[string]bool)
+	for _, event := range events {
+		if event.Operation == pool.OpUndo {
+			reverted[event.Reverts] = true
+		}
+	}
+	for _, event := range events {
+		if event.ID != "" && reverted[event.ID] {
+			continue
+		}
+		switch event.Operation {
+		case pool.OpAdd:
+			lineage.AddCandidate(event.CandidateID, event.SourcePath, event.SessionID)
+		case pool.OpPromote:
+			lineage.AddPromotion(event.CandidateID, event.ArtifactPath)
+		}
+	}
+
+	// Chains written before add events carried their source still have
+	// the source on the pool entry itself
+	entries, err := p.List(pool.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list pool: %w", err)
+	}
+	for _, entry := range entries {
+		source := entry.Candidate.Source
+		lineage.AddCandidate(entry.Candidate.ID, source.TranscriptPath, source.SessionID)
+	}
+
+	return lineage, nil
+}
+
+// runTraceLineage answers --graph, --sources, --dependents and --format.
+func runTraceLineage(w io.Writer, cwd string, args []string) error {
+	lineage, err := loadLineage(cwd)
+	if err != nil {
+		return err
+	}
+
+	dir := provenance.Upstream
+	if traceDependents {
+		dir = provenance.Downstream
+	}
+
+	var results []traceLineageResult
+	for _, query := range args {
+		nodes := lineage.Resolve(query)
+		if len(nodes) == 0 {
+			if traceFormat == "" && GetOutput() != "json" {
+				fmt.Fprintf(w, "No provenance found for: %s\n", query)
+			}
+			continue
+		}
+		for _, node := range nodes {
+			results = append(results, traceLineageResult{Query: query, Node: node})
+		}
+	}
+
+	if traceFormat != "" {
+		ids := make([]string, len(results))
+		for i, r := range results {
+			ids[i] = r.Node.ID
+		}
+		return writeLineage(w, lineage.Subgraph(ids, dir), traceFormat)
+	}
+
+	for i := range results {
+		r := &results[i]
+		switch {
+		case traceSources:
+			r.Sources = lineage.Sources(r.Node.ID)
+		case traceDependents:
+			r.Dependents = lineage.Dependents(r.Node.ID)
+		default:
+			r.Ancestors = lineage.Ancestors(r.Node.ID)
+		}
+	}
+
+	if GetOutput() == "json" {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	for _, r := range results {
+		switch {
+		case traceGraph:
+			printLineageTree(w, lineage, r.Node, dir)
+		case traceSources:
+			printLineageList(w, fmt.Sprintf("Transcripts that produced %s", r.Node.Label), r.Sources)
+		case traceDependents:
+			printLineageList(w, fmt.Sprintf("Depends on %s", r.Node.Label), r.Dependents)
+		}
+	}
+	return nil
+}
+
+// writeLineage exports a lineage graph in the given format.
+func writeLineage(w io.Writer, lineage *provenance.Lineage, format string) error {
+	switch format {
+	case "dot":
+		return lineage.WriteDOT(w)
+	case "mermaid":
+		return lineage.WriteMermaid(w)
+	case "json":
+		return lineage.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown lineage format %q (expected dot, mermaid or json)", format)
+	}
+}
+
+func printLineageList(w io.Writer, title string, nodes []*provenance.Node) {
+	fmt.Fprintf(w, "\n%s (%d):\n", title, len(nodes))
+	for _, n := range nodes {
+		fmt.Fprintf(w, "  • [%s] %s\n", n.Kind, n.ID)
+	}
+	fmt.Fprintln(w)
+}
+
+// printLineageTree draws the lineage of root as an indented tree. A node
+// reached twice is drawn once; later occurrences are marked.
+func printLineageTree(w io.Writer, lineage *provenance.Lineage, root *provenance.Node, dir provenance.Direction) {
+	fmt.Fprintf(w, "\nLineage for: %s\n\n", root.ID)
+	fmt.Fprintf(w, "  %s [%s]\n", root.Label, root.Kind)
+	printLineageBranch(w, lineage, root.ID, dir, "  ", map[string]bool{root.ID: true})
+	fmt.Fprintln(w)
+}
+
+func printLineageBranch(w io.Writer, lineage *provenance.Lineage, id string, dir provenance.Direction, prefix string, visited map[string]bool) {
+	edges := lineage.Inputs(id)
+	arrow := "←"
+	if dir == provenance.Downstream {
+		edges = lineage.Outputs(id)
+		arrow = "→"
+	}
+	for i, e := range edges {
+		next := e.From
+		if dir == provenance.Downstream {
+			next = e.To
+		}
+		node, _ := lineage.Node(next)
+
+		branch, indent := "├─ ", "│  "
+		if i == len(edges)-1 {
+			branch, indent = "└─ ", "   "
+		}
+		seen := ""
+		if visited[next] {
+			seen = " (shown above)"
+		}
+		fmt.Fprintf(w, "%s%s%s %s %s [%s]%s\n", prefix, branch, e.Kind, arrow, node.Label, node.Kind, seen)
+		if visited[next] {
+			continue
+		}
+		visited[next] = true
+		printLineageBranch(w, lineage, next, dir, prefix+indent, visited)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/pool"
+	"github.com/boshu2/agentops/cli/internal/ratchet"
+	"github.com/boshu2/agentops/cli/internal/storage"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestTraceLineage(t *testing.T) {
+	t.Setenv("AGENTOPS_STORAGE", "")
+	cwd := chdirTemp(t)
+
+	// transcript → candidate → promoted learning → cited by a session whose
+	// transcript produced a session summary
+	p := pool.NewPool(cwd)
+	candidate := types.Candidate{
+		ID:      "cand-1",
+		Type:    types.KnowledgeTypeLearning,
+		Content: "Retry with backoff",
+		Source:  types.Source{TranscriptPath: "/in/t1.jsonl", SessionID: "sess-1"},
+	}
+	if err := p.Add(candidate, types.Scoring{}); err != nil {
+		t.Fatal(err)
+	}
+	learning, err := p.Promote("cand-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ratchet.RecordCitation(cwd, types.CitationEvent{ArtifactPath: learning, SessionID: "sess-2"}); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := openStorage(filepath.Join(cwd, storage.DefaultBaseDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteProvenance(&storage.ProvenanceRecord{ID: "prov-2", ArtifactPath: "/out/S2.md", ArtifactType: "session", SourcePath: "/in/t2.jsonl", SourceType: "transcript", SessionID: "sess-2"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = fs.Close()
+
+	run := func(t *testing.T, sources, dependents, graph bool, format string, query string) string {
+		t.Helper()
+		prevSources, prevDeps, prevGraph, prevFormat := traceSources, traceDependents, traceGraph, traceFormat
+		traceSources, traceDependents, traceGraph, traceFormat = sources, dependents, graph, format
+		t.Cleanup(func() {
+			traceSources, traceDependents, traceGraph, traceFormat = prevSources, prevDeps, prevGraph, prevFormat
+		})
+		var out bytes.Buffer
+		if err := runTraceLineage(&out, cwd, []string{query}); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	t.Run("sources", func(t *testing.T) {
+		out := run(t, true, false, false, "", "S2.md")
+		if !strings.Contains(out, "/in/t2.jsonl") || !strings.Contains(out, "/in/t1.jsonl") {
+			t.Errorf("sources of S2 should reach t1 through the cited learning:\n%s", out)
+		}
+	})
+
+	t.Run("dependents", func(t *testing.T) {
+		out := run(t, false, true, false, "", learning)
+		if !strings.Contains(out, "session:sess-2") || !strings.Contains(out, "/out/S2.md") {
+			t.Errorf("dependents:\n%s", out)
+		}
+	})
+
+	t.Run("graph", func(t *testing.T) {
+		out := run(t, false, false, true, "", "cand-1")
+		if !strings.Contains(out, "extracted ← t1.jsonl [transcript]") {
+			t.Errorf("tree:\n%s", out)
+		}
+	})
+
+	t.Run("dot", func(t *testing.T) {
+		out := run(t, false, true, false, "dot", learning)
+		if !strings.HasPrefix(out, "digraph lineage {") || !strings.Contains(out, `[label="cited"]`) {
+			t.Errorf("dot:\n%s", out)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		traceFormat = "svg"
+		t.Cleanup(func() { traceFormat = "" })
+		if err := runTraceLineage(&bytes.Buffer{}, cwd, []string{learning}); err == nil {
+			t.Error("expected error for unknown format")
+		}
+	})
+}
@@ -8,6 +8,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -33,6 +34,62 @@ type Config struct {
 
 	// Paths settings for artifact locations (configurable, not hardcoded)
 	Paths PathsConfig `yaml:"paths" json:"paths"`
+
+	// Storage settings
+	Storage StorageConfig `yaml:"storage" json:"storage"`
+
+	// RPI settings
+	RPI RPIConfig `yaml:"rpi" json:"rpi"`
+}
+
+// RPIConfig holds ao rpi settings.
+type RPIConfig struct {
+	// Agent is the coding agent that runs each phase: "claude" (default),
+	// "codex", "aider" or a name defined under Agents.
+	Agent string `yaml:"agent" json:"agent"`
+
+	// PhaseAgents overrides Agent per phase (discovery, implementation,
+	// validation).
+	PhaseAgents map[string]string `yaml:"phase_agents" json:"phase_agents,omitempty"`
+
+	// Agents defines command-template agents by name. A definition with the
+	// name of a built-in agent replaces it.
+	Agents map[string]AgentConfig `yaml:"agents" json:"agents,omitempty"`
+
+	// MaxParallel caps how many scheduled phased runs may work in one
+	// repository at once, across all ao rpi schedule processes.
+	MaxParallel int `yaml:"max_parallel" json:"max_parallel"`
+
+	// TestCommand is run in a run's worktree after it is rebased onto a
+	// moved base branch, before the merge is retried.
+	TestCommand string `yaml:"test_command" json:"test_command,omitempty"`
+}
+
+// AgentConfig describes how to run a coding agent on a phase prompt.
+type AgentConfig struct {
+	// Command is the argv to run. Each element is a Go template with
+	// .Prompt, .PromptFile, .Cwd, .RunID, .Phase and .PhaseName.
+	Command []string `yaml:"command" json:"command"`
+
+	// Stdin sends the prompt on standard input.
+	Stdin bool `yaml:"stdin" json:"stdin,omitempty"`
+
+	// Progress is how output is read for progress: "lines" (default, each
+	// output line is activity), "stream-json" (Claude stream events) or
+	// "none".
+	Progress string `yaml:"progress" json:"progress,omitempty"`
+
+	// Done is a regexp the output must match for the phase to count as
+	// completed; without it a zero exit status is enough.
+	Done string `yaml:"done" json:"done,omitempty"`
+}
+
+// StorageConfig holds storage backend settings.
+type StorageConfig struct {
+	// Backend selects where sessions, the index, provenance and citations
+	// are kept: "files" (JSONL under .agents/ao, default) or "sqlite"
+	// (.agents/ao/ao.db). Convert existing data with ao store migrate.
+	Backend string `yaml:"backend" json:"backend"`
 }
 
 // PathsConfig holds configurable paths for artifact locations.
@@ -91,6 +148,17 @@ type SearchConfig struct {
 	// UseSmartConnectionsSet tracks whether UseSmartConnections was explicitly set.
 	// This allows distinguishing between "not set" and "explicitly set to false".
 	UseSmartConnectionsSet bool `yaml:"-" json:"-"`
+
+	// Embedder selects the semantic search backend: "hash" (offline, default)
+	// or "http" (local embedding server at EmbedURL).
+	Embedder string `yaml:"embedder" json:"embedder"`
+
+	// EmbedURL is the embeddings endpoint for the http embedder,
+	// e.g. http://localhost:11434/v1/embeddings.
+	EmbedURL string `yaml:"embed_url" json:"embed_url"`
+
+	// EmbedModel is the model name sent to the http embedder.
+	EmbedModel string `yaml:"embed_model" json:"embed_model"`
 }
 
 // Default config values (used in resolution and validation).
@@ -113,6 +181,7 @@ func Default() *Config {
 		Search: SearchConfig{
 			DefaultLimit:        10,
 			UseSmartConnections: true,
+			Embedder:            "hash",
 		},
 		Paths: PathsConfig{
 			LearningsDir:   ".agents/learnings",
@@ -124,22 +193,37 @@ func Default() *Config {
 			CitationsFile:  ".agents/ao/citations.jsonl",
 			TranscriptsDir: filepath.Join(homeDir, ".claude", "projects"),
 		},
+		Storage: StorageConfig{
+			Backend: "files",
+		},
+		RPI: RPIConfig{
+			Agent:       "claude",
+			MaxParallel: 2,
+		},
 	}
 }
 
 // Load loads configuration with proper precedence.
 // Priority: flags > env > project > home > defaults
+// Missing config files are skipped; one that cannot be parsed, or an
+// unknown storage backend, is an error.
 func Load(flagOverrides *Config) (*Config, error) {
 	cfg := Default()
 
 	// Load home config
-	homeConfig, _ := loadFromPath(homeConfigPath())
+	homeConfig, err := loadExisting(homeConfigPath())
+	if err != nil {
+		return nil, err
+	}
 	if homeConfig != nil {
 		cfg = merge(cfg, homeConfig)
 	}
 
 	// Load project config
-	projectConfig, _ := loadFromPath(projectConfigPath())
+	projectConfig, err := loadExisting(projectConfigPath())
+	if err != nil {
+		return nil, err
+	}
 	if projectConfig != nil {
 		cfg = merge(cfg, projectConfig)
 	}
@@ -152,9 +236,22 @@ func Load(flagOverrides *Config) (*Config, error) {
 		cfg = merge(cfg, flagOverrides)
 	}
 
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// validate rejects settings that name something that does not exist.
+func validate(cfg *Config) error {
+	switch cfg.Storage.Backend {
+	case "", "files", "sqlite":
+	default:
+		return fmt.Errorf("storage.backend: unknown backend %q (expected files or sqlite)", cfg.Storage.Backend)
+	}
+	return nil
+}
+
 // homeConfigPath returns the home config path.
 func homeConfigPath() string {
 	home, err := os.UserHomeDir()
@@ -192,6 +289,18 @@ func loadFromPath(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// loadExisting loads the config at path, or returns nil if there is none.
+func loadExisting(path string) (*Config, error) {
+	cfg, err := loadFromPath(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
 // applyEnv applies environment variable overrides.
 func applyEnv(cfg *Config) *Config {
 	if v := os.Getenv("AGENTOPS_OUTPUT"); v != "" {
@@ -207,6 +316,21 @@ func applyEnv(cfg *Config) *Config {
 		cfg.Search.UseSmartConnections = false
 		cfg.Search.UseSmartConnectionsSet = true
 	}
+	if v := os.Getenv("AGENTOPS_EMBEDDER"); v != "" {
+		cfg.Search.Embedder = v
+	}
+	if v := os.Getenv("AGENTOPS_EMBED_URL"); v != "" {
+		cfg.Search.EmbedURL = v
+	}
+	if v := os.Getenv("AGENTOPS_EMBED_MODEL"); v != "" {
+		cfg.Search.EmbedModel = v
+	}
+	if v := os.Getenv("AGENTOPS_STORAGE"); v != "" {
+		cfg.Storage.Backend = v
+	}
+	if v := os.Getenv("AGENTOPS_RPI_AGENT"); v != "" {
+		cfg.RPI.Agent = v
+	}
 	return cfg
 }
 
@@ -236,6 +360,15 @@ func merge(dst, src *Config) *Config {
 		dst.Search.UseSmartConnections = src.Search.UseSmartConnections
 		dst.Search.UseSmartConnectionsSet = true
 	}
+	if src.Search.Embedder != "" {
+		dst.Search.Embedder = src.Search.Embedder
+	}
+	if src.Search.EmbedURL != "" {
+		dst.Search.EmbedURL = src.Search.EmbedURL
+	}
+	if src.Search.EmbedModel != "" {
+		dst.Search.EmbedModel = src.Search.EmbedModel
+	}
 
 	// Merge paths (G5: configurable paths, not hardcoded)
 	if src.Paths.LearningsDir != "" {
@@ -263,6 +396,32 @@ func merge(dst, src *Config) *Config {
 		dst.Paths.TranscriptsDir = src.Paths.TranscriptsDir
 	}
 
+	if src.Storage.Backend != "" {
+		dst.Storage.Backend = src.Storage.Backend
+	}
+
+	if src.RPI.Agent != "" {
+		dst.RPI.Agent = src.RPI.Agent
+	}
+	if src.RPI.MaxParallel != 0 {
+		dst.RPI.MaxParallel = src.RPI.MaxParallel
+	}
+	if src.RPI.TestCommand != "" {
+		dst.RPI.TestCommand = src.RPI.TestCommand
+	}
+	for phase, agent := range src.RPI.PhaseAgents {
+		if dst.RPI.PhaseAgents == nil {
+			dst.RPI.PhaseAgents = make(map[string]string)
+		}
+		dst.RPI.PhaseAgents[phase] = agent
+	}
+	for name, agent := range src.RPI.Agents {
+		if dst.RPI.Agents == nil {
+			dst.RPI.Agents = make(map[string]AgentConfig)
+		}
+		dst.RPI.Agents[name] = agent
+	}
+
 	return dst
 }
 
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -529,6 +530,91 @@ func TestMerge_SearchDefaultLimit(t *testing.T) {
 	}
 }
 
+func TestMerge_SearchEmbedder(t *testing.T) {
+	dst := Default()
+	if dst.Search.Embedder != "hash" {
+		t.Errorf("Default Search.Embedder = %q, want %q", dst.Search.Embedder, "hash")
+	}
+
+	src := &Config{
+		Search: SearchConfig{Embedder: "http", EmbedURL: "http://localhost:11434/v1/embeddings", EmbedModel: "nomic-embed-text"},
+	}
+	result := merge(dst, src)
+
+	if result.Search.Embedder != "http" || result.Search.EmbedURL != src.Search.EmbedURL || result.Search.EmbedModel != "nomic-embed-text" {
+		t.Errorf("merge Search embedder = %+v", result.Search)
+	}
+}
+
+func TestApplyEnv_Embedder(t *testing.T) {
+	t.Setenv("AGENTOPS_EMBEDDER", "http")
+	t.Setenv("AGENTOPS_EMBED_URL", "http://127.0.0.1:8080/v1/embeddings")
+	t.Setenv("AGENTOPS_EMBED_MODEL", "bge-small")
+
+	cfg := applyEnv(Default())
+
+	if cfg.Search.Embedder != "http" {
+		t.Errorf("applyEnv Search.Embedder = %q, want http", cfg.Search.Embedder)
+	}
+	if cfg.Search.EmbedURL != "http://127.0.0.1:8080/v1/embeddings" {
+		t.Errorf("applyEnv Search.EmbedURL = %q", cfg.Search.EmbedURL)
+	}
+	if cfg.Search.EmbedModel != "bge-small" {
+		t.Errorf("applyEnv Search.EmbedModel = %q", cfg.Search.EmbedModel)
+	}
+}
+
+func TestStorageBackend(t *testing.T) {
+	if got := Default().Storage.Backend; got != "files" {
+		t.Errorf("Default Storage.Backend = %q, want files", got)
+	}
+
+	result := merge(Default(), &Config{Storage: StorageConfig{Backend: "sqlite"}})
+	if result.Storage.Backend != "sqlite" {
+		t.Errorf("merge Storage.Backend = %q, want sqlite", result.Storage.Backend)
+	}
+
+	t.Setenv("AGENTOPS_STORAGE", "sqlite")
+	if cfg := applyEnv(Default()); cfg.Storage.Backend != "sqlite" {
+		t.Errorf("applyEnv Storage.Backend = %q, want sqlite", cfg.Storage.Backend)
+	}
+}
+
+func TestRPIAgents(t *testing.T) {
+	if got := Default().RPI; got.Agent != "claude" || got.MaxParallel != 2 {
+		t.Errorf("Default RPI = %+v, want claude with 2 parallel runs", got)
+	}
+
+	home := &Config{RPI: RPIConfig{
+		PhaseAgents: map[string]string{"discovery": "codex"},
+		Agents:      map[string]AgentConfig{"local": {Command: []string{"local-agent", "{{.Prompt}}"}}},
+	}}
+	project := &Config{RPI: RPIConfig{
+		Agent:       "aider",
+		PhaseAgents: map[string]string{"validation": "local"},
+		MaxParallel: 4,
+		TestCommand: "go test ./...",
+	}}
+	result := merge(merge(Default(), home), project)
+	if result.RPI.MaxParallel != 4 || result.RPI.TestCommand != "go test ./..." {
+		t.Errorf("merge RPI.MaxParallel = %d, TestCommand = %q", result.RPI.MaxParallel, result.RPI.TestCommand)
+	}
+	if result.RPI.Agent != "aider" {
+		t.Errorf("merge RPI.Agent = %q, want aider", result.RPI.Agent)
+	}
+	if result.RPI.PhaseAgents["discovery"] != "codex" || result.RPI.PhaseAgents["validation"] != "local" {
+		t.Errorf("phase agents should merge by phase, got %v", result.RPI.PhaseAgents)
+	}
+	if len(result.RPI.Agents["local"].Command) != 2 {
+		t.Errorf("agents = %v", result.RPI.Agents)
+	}
+
+	t.Setenv("AGENTOPS_RPI_AGENT", "codex")
+	if cfg := applyEnv(Default()); cfg.RPI.Agent != "codex" {
+		t.Errorf("applyEnv RPI.Agent = %q, want codex", cfg.RPI.Agent)
+	}
+}
+
 func TestLoad_WithFlagOverrides(t *testing.T) {
 	// Clear env vars to avoid interference
 	t.Setenv("AGENTOPS_OUTPUT", "")
@@ -600,6 +686,55 @@ func TestLoad_EnvOverrides(t *testing.T) {
 	}
 }
 
+func TestLoad_InvalidProjectConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	prev, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(prev) })
+
+	if _, err := Load(nil); err != nil {
+		t.Fatalf("Load() without config files error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".agentops"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".agentops", "config.yaml"), []byte("rpi: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := Load(nil); err == nil || cfg != nil || !strings.Contains(err.Error(), "config.yaml") {
+		t.Errorf("Load() with invalid project config = %v, %v; want an error naming the file", cfg, err)
+	}
+}
+
+func TestLoad_UnknownStorageBackend(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	prev, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(prev) })
+
+	for _, backend := range []string{"files", "sqlite"} {
+		t.Setenv("AGENTOPS_STORAGE", backend)
+		if cfg, err := Load(nil); err != nil || cfg.Storage.Backend != backend {
+			t.Errorf("Load() with backend %q = %v, %v", backend, cfg, err)
+		}
+	}
+	t.Setenv("AGENTOPS_STORAGE", "sqlit")
+	if _, err := Load(nil); err == nil || !strings.Contains(err.Error(), `unknown backend "sqlit"`) {
+		t.Errorf("Load() with an unknown backend error = %v", err)
+	}
+}
+
 func TestLoadFromPath_InvalidYAML(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.yaml")
@@ -268,10 +268,9 @@ func (b *BudgetTracker) GetReport() BudgetReport {
 	}
 }
 
-// EstimateTokens estimates tokens from text length.
-// Uses rough 4 chars per token approximation.
+// EstimateTokens counts the tokens in text with the bundled BPE tokenizer.
 func EstimateTokens(text string) int {
-	return len(text) / 4
+	return BundledTokenizer().Count(text)
 }
 
 // EstimateFileTokens estimates tokens for reading a file.
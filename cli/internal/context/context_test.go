@@ -139,13 +139,23 @@ func TestBudgetTrackerReport(t *testing.T) {
 }
 
 func TestEstimateTokens(t *testing.T) {
-	text := "This is a test string with some words"
-	tokens := EstimateTokens(text)
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"hello", 1},
+		{"This is a test string with some words", 8},
+		{"func main() {}\n", 5},
+		{"héllo wörld", 8},
+		{"日本語のテキスト", 8},
+		{"🙂", 1},
+	}
 
-	// Rough 4 chars per token
-	expected := len(text) / 4
-	if tokens != expected {
-		t.Errorf("expected %d tokens, got %d", expected, tokens)
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.expected {
+			t.Errorf("EstimateTokens(%q) = %d, expected %d", tt.text, got, tt.expected)
+		}
 	}
 }
 
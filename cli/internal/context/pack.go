@@ -0,0 +1,157 @@
+package context
+
+import "sort"
+
+// Reasons reported for items left out by Pack.
+const (
+	// DropTooLarge means the item alone exceeds the budget.
+	DropTooLarge = "too_large"
+
+	// DropBudget means the item fits on its own but the budget went to a
+	// higher-scoring combination of other items.
+	DropBudget = "budget"
+)
+
+// maxPackCells bounds the exact dynamic program (items x budget); larger
+// problems fall back to greedy selection by score per token.
+const maxPackCells = 1 << 22
+
+// PackItem is one unit of content competing for a token budget.
+type PackItem struct {
+	Kind   string
+	ID     string
+	Tokens int
+	Score  float64 // value of including the item; negative counts as 0
+}
+
+// DroppedItem reports an item Pack left out.
+type DroppedItem struct {
+	Kind   string  `json:"kind"`
+	ID     string  `json:"id"`
+	Tokens int     `json:"tokens"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// PackResult is the outcome of Pack.
+type PackResult struct {
+	// Selected holds indices into the input, in input order.
+	Selected []int
+
+	// Dropped lists the items not selected, in input order.
+	Dropped []DroppedItem
+
+	// Tokens is the summed cost of the selected items.
+	Tokens int
+
+	// Score is the summed score of the selected items.
+	Score float64
+}
+
+// Pack selects whole items maximizing total score with total tokens at most
+// budget (0/1 knapsack). Items are never split. When the problem is small
+// enough the selection is optimal; otherwise items are taken greedily by
+// score per token.
+func Pack(items []PackItem, budget int) PackResult {
+	if budget < 0 {
+		budget = 0
+	}
+
+	var keep []bool
+	if len(items)*(budget+1) <= maxPackCells {
+		keep = packExact(items, budget)
+	} else {
+		keep = packGreedy(items, budget)
+	}
+
+	var res PackResult
+	for i, item := range items {
+		if keep[i] {
+			res.Selected = append(res.Selected, i)
+			res.Tokens += item.Tokens
+			res.Score += packValue(item)
+			continue
+		}
+		reason := DropBudget
+		if item.Tokens > budget {
+			reason = DropTooLarge
+		}
+		res.Dropped = append(res.Dropped, DroppedItem{
+			Kind: item.Kind, ID: item.ID, Tokens: item.Tokens, Score: item.Score, Reason: reason,
+		})
+	}
+	return res
+}
+
+// packValue clamps an item's score to be non-negative.
+func packValue(item PackItem) float64 {
+	if item.Score < 0 {
+		return 0
+	}
+	return item.Score
+}
+
+// packExact solves the knapsack by dynamic programming over token budgets.
+// Ties prefer earlier items, so equal-value inputs keep their ranking.
+func packExact(items []PackItem, budget int) []bool {
+	n := len(items)
+	best := make([]float64, budget+1)
+	take := make([][]bool, n)
+
+	// Iterate items last-to-first so that, on reconstruction front-to-back,
+	// an earlier item is taken whenever doing so is still optimal.
+	for i := n - 1; i >= 0; i-- {
+		take[i] = make([]bool, budget+1)
+		w, v := items[i].Tokens, packValue(items[i])
+		if w < 0 {
+			w = 0
+		}
+		for b := budget; b >= w; b-- {
+			if cand := best[b-w] + v; cand >= best[b] {
+				best[b] = cand
+				take[i][b] = true
+			}
+		}
+	}
+
+	keep := make([]bool, n)
+	b := budget
+	for i := 0; i < n; i++ {
+		if take[i][b] {
+			keep[i] = true
+			if w := items[i].Tokens; w > 0 {
+				b -= w
+			}
+		}
+	}
+	return keep
+}
+
+// packGreedy takes items in order of score per token while they fit.
+func packGreedy(items []PackItem, budget int) []bool {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	density := func(i int) float64 {
+		if items[i].Tokens <= 0 {
+			return packValue(items[i]) * 1e9
+		}
+		return packValue(items[i]) / float64(items[i].Tokens)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return density(order[a]) > density(order[b])
+	})
+
+	keep := make([]bool, len(items))
+	remaining := budget
+	for _, i := range order {
+		if w := items[i].Tokens; w <= remaining {
+			keep[i] = true
+			if w > 0 {
+				remaining -= w
+			}
+		}
+	}
+	return keep
+}
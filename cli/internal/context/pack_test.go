@@ -0,0 +1,52 @@
+package context
+
+import "testing"
+
+func TestPackOptimal(t *testing.T) {
+	// Greedy by score would take "big" (score 5) and have no room left;
+	// the two small items together are worth more.
+	items := []PackItem{
+		{Kind: "learning", ID: "big", Tokens: 10, Score: 5},
+		{Kind: "learning", ID: "a", Tokens: 5, Score: 3},
+		{Kind: "pattern", ID: "b", Tokens: 5, Score: 3},
+	}
+	res := Pack(items, 10)
+	if len(res.Selected) != 2 || res.Selected[0] != 1 || res.Selected[1] != 2 {
+		t.Fatalf("Selected = %v, want [1 2]", res.Selected)
+	}
+	if res.Tokens != 10 || res.Score != 6 {
+		t.Errorf("Tokens = %d, Score = %v", res.Tokens, res.Score)
+	}
+	if len(res.Dropped) != 1 || res.Dropped[0].ID != "big" || res.Dropped[0].Reason != DropBudget {
+		t.Errorf("Dropped = %+v", res.Dropped)
+	}
+}
+
+func TestPackTooLarge(t *testing.T) {
+	res := Pack([]PackItem{{ID: "huge", Tokens: 50, Score: 1}, {ID: "ok", Tokens: 3, Score: 0.1}}, 20)
+	if len(res.Selected) != 1 || res.Selected[0] != 1 {
+		t.Fatalf("Selected = %v", res.Selected)
+	}
+	if res.Dropped[0].Reason != DropTooLarge {
+		t.Errorf("Reason = %q, want %q", res.Dropped[0].Reason, DropTooLarge)
+	}
+}
+
+func TestPackEverythingFits(t *testing.T) {
+	items := []PackItem{{ID: "a", Tokens: 2, Score: -1}, {ID: "b", Tokens: 2, Score: 0}, {ID: "c", Tokens: 2, Score: 1}}
+	res := Pack(items, 100)
+	if len(res.Selected) != 3 || len(res.Dropped) != 0 {
+		t.Errorf("expected all items selected, got %+v", res)
+	}
+}
+
+func TestPackGreedyFallback(t *testing.T) {
+	items := []PackItem{
+		{ID: "dense", Tokens: 10, Score: 10},
+		{ID: "sparse", Tokens: 1 << 21, Score: 11},
+	}
+	res := Pack(items, 1<<21) // 2 * (2^21+1) cells exceeds maxPackCells
+	if len(res.Selected) != 1 || res.Selected[0] != 0 {
+		t.Errorf("greedy should take the dense item, got %v", res.Selected)
+	}
+}
@@ -0,0 +1,292 @@
+package context
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Tokenizer counts how many tokens text costs in a model context.
+type Tokenizer interface {
+	// Name identifies the tokenizer in budget reports.
+	Name() string
+
+	// Count returns the number of tokens in text.
+	Count(text string) int
+}
+
+// CharsPerToken is the ratio HeuristicTokenizer assumes.
+const CharsPerToken = 4
+
+// HeuristicTokenizer is the legacy length/4 estimate. It is cheap and needs
+// no vocabulary, but is off by 20-30% on prose and worse on code.
+type HeuristicTokenizer struct{}
+
+// Name implements Tokenizer.
+func (HeuristicTokenizer) Name() string { return "chars/4" }
+
+// Count implements Tokenizer.
+func (HeuristicTokenizer) Count(text string) int { return len(text) / CharsPerToken }
+
+// bundledMerges is the BPE merge table shipped with ao, trained on the
+// AgentOps docs, skills and sources (see TestRegenerateBundledVocab).
+//
+//go:embed vocab/bpe-merges.txt
+var bundledMerges string
+
+// bundledName identifies the embedded vocabulary.
+const bundledName = "agentops-bpe-v1"
+
+// maxPieceRunes bounds a pre-token before BPE; longer runs (base64 blobs,
+// minified JSON) are cut into chunks so merging stays cheap.
+const maxPieceRunes = 128
+
+// maxCacheEntries bounds the per-tokenizer piece cache.
+const maxCacheEntries = 1 << 16
+
+// BPETokenizer counts tokens with byte-pair-encoding merges over runes.
+// Text is first split into pre-tokens (a word with its leading space, a
+// digit group, a punctuation run, a whitespace run); each pre-token starts
+// as one symbol per rune and adjacent symbols are merged in merge-rank order
+// until no ranked pair remains. The token count is the number of symbols
+// left, so the count is exact for the vocabulary in use.
+type BPETokenizer struct {
+	name  string
+	ranks map[string]int
+
+	mu    sync.Mutex
+	cache map[string]int
+}
+
+var (
+	bundledOnce sync.Once
+	bundled     *BPETokenizer
+)
+
+// BundledTokenizer returns the BPE tokenizer for the embedded vocabulary.
+func BundledTokenizer() *BPETokenizer {
+	bundledOnce.Do(func() {
+		t, err := LoadBPETokenizer(bundledName, strings.NewReader(bundledMerges))
+		if err != nil {
+			panic(fmt.Sprintf("bundled BPE vocabulary: %v", err)) // build artifact, cannot fail at runtime
+		}
+		bundled = t
+	})
+	return bundled
+}
+
+// LoadBPETokenizer reads a merge table: one merge per line as two
+// space-separated symbols, highest priority first. Lines starting with # are
+// comments. In symbols, Ġ stands for a space, Ċ for a newline and ĉ for a tab.
+func LoadBPETokenizer(name string, r io.Reader) (*BPETokenizer, error) {
+	t := &BPETokenizer{name: name, ranks: make(map[string]int), cache: make(map[string]int)}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		left, right, ok := strings.Cut(line, " ")
+		if !ok || left == "" || right == "" {
+			return nil, fmt.Errorf("line %d: want two symbols, got %q", lineNo, line)
+		}
+		key := pairKey(unescapeSymbol(left), unescapeSymbol(right))
+		if _, dup := t.ranks[key]; !dup {
+			t.ranks[key] = len(t.ranks)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read merges: %w", err)
+	}
+	if len(t.ranks) == 0 {
+		return nil, fmt.Errorf("no merges found")
+	}
+	return t, nil
+}
+
+// LoadBPEFile loads a merge table from path; the tokenizer is named after it.
+func LoadBPEFile(path string) (*BPETokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open vocabulary: %w", err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only, close best-effort
+	}()
+	return LoadBPETokenizer("bpe:"+path, f)
+}
+
+// NewTokenizer returns the tokenizer named by spec: "" or "bpe" for the
+// bundled vocabulary, "chars" for the length/4 heuristic, or a path to a
+// merge table in LoadBPETokenizer's format.
+func NewTokenizer(spec string) (Tokenizer, error) {
+	switch spec {
+	case "", "bpe":
+		return BundledTokenizer(), nil
+	case "chars", "heuristic":
+		return HeuristicTokenizer{}, nil
+	default:
+		return LoadBPEFile(spec)
+	}
+}
+
+// Name implements Tokenizer.
+func (t *BPETokenizer) Name() string { return t.name }
+
+// Count implements Tokenizer.
+func (t *BPETokenizer) Count(text string) int {
+	n := 0
+	for _, piece := range splitPieces(text) {
+		n += t.countPiece(piece)
+	}
+	return n
+}
+
+// countPiece returns the number of symbols piece reduces to, memoised.
+func (t *BPETokenizer) countPiece(piece string) int {
+	t.mu.Lock()
+	n, ok := t.cache[piece]
+	t.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	n = len(t.merge(piece))
+
+	t.mu.Lock()
+	if len(t.cache) >= maxCacheEntries {
+		t.cache = make(map[string]int)
+	}
+	t.cache[piece] = n
+	t.mu.Unlock()
+	return n
+}
+
+// merge applies the merge table to one pre-token and returns its symbols.
+func (t *BPETokenizer) merge(piece string) []string {
+	symbols := make([]string, 0, len(piece))
+	for _, r := range piece {
+		symbols = append(symbols, string(r))
+	}
+
+	for len(symbols) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i+1 < len(symbols); i++ {
+			if rank, ok := t.ranks[pairKey(symbols[i], symbols[i+1])]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		left, right := symbols[best], symbols[best+1]
+		merged := symbols[:0]
+		for i := 0; i < len(symbols); i++ {
+			if i+1 < len(symbols) && symbols[i] == left && symbols[i+1] == right {
+				merged = append(merged, left+right)
+				i++
+				continue
+			}
+			merged = append(merged, symbols[i])
+		}
+		symbols = merged
+	}
+	return symbols
+}
+
+// pairKey joins two symbols into a rank-table key.
+func pairKey(left, right string) string {
+	return left + "\x00" + right
+}
+
+var symbolEscaper = strings.NewReplacer(" ", "Ġ", "\n", "Ċ", "\t", "ĉ")
+var symbolUnescaper = strings.NewReplacer("Ġ", " ", "Ċ", "\n", "ĉ", "\t")
+
+func escapeSymbol(s string) string   { return symbolEscaper.Replace(s) }
+func unescapeSymbol(s string) string { return symbolUnescaper.Replace(s) }
+
+// runeClass groups runes for pre-tokenization.
+type runeClass int
+
+const (
+	classSpace runeClass = iota
+	classLetter
+	classDigit
+	classOther
+)
+
+func classify(r rune) runeClass {
+	switch {
+	case unicode.IsSpace(r):
+		return classSpace
+	case unicode.IsLetter(r) || unicode.IsMark(r):
+		return classLetter
+	case unicode.IsDigit(r):
+		return classDigit
+	default:
+		return classOther
+	}
+}
+
+// splitPieces splits text into pre-tokens: letter runs and punctuation runs
+// (each taking one preceding space), digit groups of at most three, newline
+// runs, and the remaining space runs. Pieces never exceed maxPieceRunes.
+func splitPieces(text string) []string {
+	runes := []rune(text)
+	var pieces []string
+	emit := func(start, end int) {
+		for end-start > maxPieceRunes {
+			pieces = append(pieces, string(runes[start:start+maxPieceRunes]))
+			start += maxPieceRunes
+		}
+		if end > start {
+			pieces = append(pieces, string(runes[start:end]))
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		start := i
+		c := classify(runes[i])
+
+		if c == classSpace {
+			if runes[i] == ' ' && i+1 < len(runes) && classify(runes[i+1]) != classSpace && classify(runes[i+1]) != classDigit {
+				// A single space binds to the following word or punctuation.
+				i++
+				c = classify(runes[i])
+			} else {
+				newline := runes[i] == '\n' || runes[i] == '\r'
+				for i < len(runes) && classify(runes[i]) == classSpace && (runes[i] == '\n' || runes[i] == '\r') == newline {
+					i++
+				}
+				// Leave a trailing space to prefix the next word.
+				if !newline && i < len(runes) && i-start > 1 && runes[i-1] == ' ' && classify(runes[i]) != classDigit {
+					i--
+				}
+				emit(start, i)
+				continue
+			}
+		}
+
+		switch c {
+		case classDigit:
+			for n := 0; i < len(runes) && n < 3 && classify(runes[i]) == classDigit; n++ {
+				i++
+			}
+		default:
+			for i < len(runes) && classify(runes[i]) == c {
+				i++
+			}
+		}
+		emit(start, i)
+	}
+	return pieces
+}
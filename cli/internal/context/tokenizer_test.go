@@ -0,0 +1,88 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitPieces(t *testing.T) {
+	got := splitPieces("Hello, world!  12345\n\n  x")
+	want := []string{"Hello", ",", " world", "!", "  ", "123", "45", "\n\n", " ", " x"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitPieces = %q, want %q", got, want)
+	}
+	if strings.Join(got, "") != "Hello, world!  12345\n\n  x" {
+		t.Error("pieces must reassemble to the input")
+	}
+}
+
+func TestBPETokenizerMerges(t *testing.T) {
+	tok, err := LoadBPETokenizer("test", strings.NewReader("# comment\nl o\nlo w\nĠ lo\n"))
+	if err != nil {
+		t.Fatalf("LoadBPETokenizer: %v", err)
+	}
+	tests := map[string]int{
+		"low":     1, // l+o, lo+w
+		"lower":   3, // low, e, r
+		" lo":     1,
+		"":        0,
+		"low low": 3, // low, " lo", w
+	}
+	for text, want := range tests {
+		if got := tok.Count(text); got != want {
+			t.Errorf("Count(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestLoadBPETokenizerErrors(t *testing.T) {
+	if _, err := LoadBPETokenizer("bad", strings.NewReader("onlyone\n")); err == nil {
+		t.Error("expected error for malformed merge line")
+	}
+	if _, err := LoadBPETokenizer("empty", strings.NewReader("# nothing\n")); err == nil {
+		t.Error("expected error for empty merge table")
+	}
+}
+
+func TestBundledTokenizer(t *testing.T) {
+	tok := BundledTokenizer()
+	if tok.Name() != bundledName {
+		t.Errorf("Name() = %q", tok.Name())
+	}
+	text := "Acquire mutexes in a fixed order to avoid deadlock."
+	n := tok.Count(text)
+	// Common English words should mostly be single tokens.
+	if n < 9 || n > 20 {
+		t.Errorf("Count(%q) = %d, want roughly one token per word", text, n)
+	}
+	// Pre-tokens never span a newline, so counts add across lines.
+	if got := tok.Count(text + "\n" + text); got != 2*n+1 {
+		t.Errorf("Count of two lines = %d, want %d", got, 2*n+1)
+	}
+}
+
+func TestNewTokenizer(t *testing.T) {
+	if tok, err := NewTokenizer(""); err != nil || tok.Name() != bundledName {
+		t.Errorf("default tokenizer = %v, %v", tok, err)
+	}
+	if tok, err := NewTokenizer("chars"); err != nil || tok.Count("abcdefgh") != 2 {
+		t.Errorf("chars tokenizer = %v, %v", tok, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "merges.txt")
+	if err := os.WriteFile(path, []byte("a b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := NewTokenizer(path)
+	if err != nil {
+		t.Fatalf("NewTokenizer(path): %v", err)
+	}
+	if tok.Count("ab") != 1 {
+		t.Errorf("custom vocabulary not applied")
+	}
+	if _, err := NewTokenizer(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected error for missing vocabulary file")
+	}
+}
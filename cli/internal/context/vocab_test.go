@@ -0,0 +1,201 @@
+package context
+
+import (
+	"container/heap"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var updateVocab = flag.Bool("update-vocab", false, "retrain vocab/bpe-merges.txt from the repository sources")
+
+// bundledMergeCount is how many merges the bundled vocabulary is trained to.
+const bundledMergeCount = 12000
+
+// TestRegenerateBundledVocab retrains the bundled merge table from the
+// repository's markdown, Go, YAML and shell sources:
+//
+//	go test ./internal/context -run TestRegenerateBundledVocab -update-vocab
+func TestRegenerateBundledVocab(t *testing.T) {
+	if !*updateVocab {
+		t.Skip("pass -update-vocab to retrain the bundled vocabulary")
+	}
+
+	root, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if name := info.Name(); name == ".git" || name == "vocab" || name == "testdata" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".md", ".go", ".yaml", ".yml", ".sh":
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, piece := range splitPieces(string(data)) {
+			if !strings.ContainsAny(piece, "ĠĊĉ") {
+				counts[piece]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merges := trainBPE(counts, bundledMergeCount)
+
+	var sb strings.Builder
+	sb.WriteString("# " + bundledName + ": rune-level BPE merges, highest priority first.\n")
+	sb.WriteString("# Regenerate: go test ./internal/context -run TestRegenerateBundledVocab -update-vocab\n")
+	for _, m := range merges {
+		sb.WriteString(escapeSymbol(m[0]) + " " + escapeSymbol(m[1]) + "\n")
+	}
+	if err := os.WriteFile(filepath.Join("vocab", "bpe-merges.txt"), []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("wrote %d merges from %d distinct pieces", len(merges), len(counts))
+}
+
+// trainBPE learns up to n merges from pre-token frequencies, always merging
+// the most frequent adjacent pair (ties broken by the pair text).
+func trainBPE(counts map[string]int, n int) [][2]string {
+	pieces := make([]string, 0, len(counts))
+	for p := range counts {
+		pieces = append(pieces, p)
+	}
+	sort.Strings(pieces)
+
+	words := make([][]string, len(pieces))
+	freqs := make([]int, len(pieces))
+	pairFreq := make(map[string]int)
+	pairWords := make(map[string]map[int]bool)
+	for i, p := range pieces {
+		for _, r := range p {
+			words[i] = append(words[i], string(r))
+		}
+		freqs[i] = counts[p]
+		for j := 0; j+1 < len(words[i]); j++ {
+			key := pairKey(words[i][j], words[i][j+1])
+			pairFreq[key] += freqs[i]
+			if pairWords[key] == nil {
+				pairWords[key] = make(map[int]bool)
+			}
+			pairWords[key][i] = true
+		}
+	}
+
+	h := &pairHeap{}
+	for key, f := range pairFreq {
+		*h = append(*h, pairCount{key: key, count: f})
+	}
+	heap.Init(h)
+
+	var merges [][2]string
+	for len(merges) < n && h.Len() > 0 {
+		top := heap.Pop(h).(pairCount)
+		if pairFreq[top.key] != top.count {
+			continue // stale entry
+		}
+		if top.count < 2 {
+			break
+		}
+		left, right, _ := strings.Cut(top.key, "\x00")
+		merges = append(merges, [2]string{left, right})
+
+		touched := make(map[string]bool)
+		for wi := range pairWords[top.key] {
+			w := words[wi]
+			for j := 0; j+1 < len(w); j++ {
+				key := pairKey(w[j], w[j+1])
+				pairFreq[key] -= freqs[wi]
+				touched[key] = true
+			}
+			merged := w[:0:0]
+			for j := 0; j < len(w); j++ {
+				if j+1 < len(w) && w[j] == left && w[j+1] == right {
+					merged = append(merged, left+right)
+					j++
+					continue
+				}
+				merged = append(merged, w[j])
+			}
+			words[wi] = merged
+			for j := 0; j+1 < len(merged); j++ {
+				key := pairKey(merged[j], merged[j+1])
+				pairFreq[key] += freqs[wi]
+				touched[key] = true
+				if pairWords[key] == nil {
+					pairWords[key] = make(map[int]bool)
+				}
+				pairWords[key][wi] = true
+			}
+		}
+		delete(pairWords, top.key)
+		delete(pairFreq, top.key)
+		delete(touched, top.key)
+
+		keys := make([]string, 0, len(touched))
+		for k := range touched {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if f := pairFreq[k]; f > 0 {
+				heap.Push(h, pairCount{key: k, count: f})
+			} else {
+				delete(pairFreq, k)
+			}
+		}
+	}
+	return merges
+}
+
+type pairCount struct {
+	key   string
+	count int
+}
+
+// pairHeap is a max-heap on count, then min on key, for deterministic output.
+type pairHeap []pairCount
+
+func (h pairHeap) Len() int { return len(h) }
+func (h pairHeap) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count > h[j].count
+	}
+	return h[i].key < h[j].key
+}
+func (h pairHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pairHeap) Push(x interface{}) { *h = append(*h, x.(pairCount)) }
+func (h *pairHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func TestTrainBPE(t *testing.T) {
+	merges := trainBPE(map[string]int{"low": 5, "lower": 2, "newest": 6, "widest": 3}, 3)
+	got := fmt.Sprint(merges)
+	if got != "[[e s] [es t] [l o]]" {
+		t.Errorf("merges = %s", got)
+	}
+}
@@ -0,0 +1,147 @@
+package fsutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// AppendLines appends each line, newline-terminated, to the JSONL file at
+// path under an exclusive lock and fsyncs the file. Lines must not contain
+// newlines.
+func AppendLines(path string, perm os.FileMode, lines ...[]byte) error {
+	return WithLock(path, func() error {
+		return AppendLinesLocked(path, perm, lines...)
+	})
+}
+
+// AppendJSONL appends each value as a JSON line to path under an exclusive
+// lock and fsyncs the file.
+func AppendJSONL(path string, perm os.FileMode, values ...interface{}) error {
+	lines, err := marshalLines(values)
+	if err != nil {
+		return err
+	}
+	return AppendLines(path, perm, lines...)
+}
+
+// AppendJSONLLocked is AppendJSONL for callers already holding the lock.
+func AppendJSONLLocked(path string, perm os.FileMode, values ...interface{}) error {
+	lines, err := marshalLines(values)
+	if err != nil {
+		return err
+	}
+	return AppendLinesLocked(path, perm, lines...)
+}
+
+// AppendLinesLocked is AppendLines for callers already holding the lock on
+// path (see WithLock).
+//
+// If a previous writer crashed mid-line, the file ends without a newline.
+// A torn tail that is not valid JSON is truncated away; one that is valid
+// JSON is kept and terminated. Either way the new lines start on a line of
+// their own. All lines go out in one write, so readers never see part of a
+// batch interleaved with another writer's.
+func AppendLinesLocked(path string, perm os.FileMode, lines ...[]byte) (err error) {
+	if len(lines) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, perm)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	end, prefix, err := repairTail(f)
+	if err != nil {
+		return fmt.Errorf("repair %s: %w", filepath.Base(path), err)
+	}
+
+	size := len(prefix)
+	for _, line := range lines {
+		size += len(line) + 1
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, prefix...)
+	for _, line := range lines {
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+	if _, err := f.WriteAt(buf, end); err != nil {
+		return fmt.Errorf("append to %s: %w", filepath.Base(path), err)
+	}
+	return f.Sync()
+}
+
+// maxTornLine bounds how far back repairTail looks for the start of an
+// unterminated last line.
+const maxTornLine = 16 << 20
+
+// repairTail inspects the end of f and returns the offset to write at and
+// a prefix to write first: a newline to terminate a valid last line, or
+// nothing after truncating a torn one.
+func repairTail(f *os.File) (int64, []byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return 0, nil, nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, size-1); err != nil {
+		return 0, nil, err
+	}
+	if last[0] == '\n' {
+		return size, nil, nil
+	}
+
+	start := size - maxTornLine
+	if start < 0 {
+		start = 0
+	}
+	tail := make([]byte, size-start)
+	if _, err := f.ReadAt(tail, start); err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	lineStart := int64(-1)
+	for i := len(tail) - 1; i >= 0; i-- {
+		if tail[i] == '\n' {
+			lineStart = start + int64(i) + 1
+			break
+		}
+	}
+	if lineStart < 0 && start == 0 {
+		lineStart = 0
+	}
+	// A line longer than the window cannot be judged; keep it.
+	if lineStart < 0 || json.Valid(tail[lineStart-start:]) {
+		return size, []byte{'\n'}, nil
+	}
+	if err := f.Truncate(lineStart); err != nil {
+		return 0, nil, err
+	}
+	return lineStart, nil, nil
+}
+
+func marshalLines(values []interface{}) ([][]byte, error) {
+	lines := make([][]byte, 0, len(values))
+	for _, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshal json: %w", err)
+		}
+		lines = append(lines, data)
+	}
+	return lines, nil
+}
@@ -0,0 +1,84 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// WriteFileAtomic replaces path with data: it writes a temp file in the
+// same directory, fsyncs it, renames it over path and fsyncs the directory,
+// so readers and crashes see either the old or the new content. It does
+// not lock; wrap read-modify-write sequences in WithLock or use Update.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()        //nolint:errcheck // cleanup in error path
+			_ = os.Remove(tmpPath) //nolint:errcheck // cleanup in error path
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("fsync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return SyncDir(dir)
+}
+
+// SyncDir fsyncs a directory so a rename within it survives a crash.
+func SyncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open directory for fsync: %w", err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only handle
+	}()
+	if err := f.Sync(); err != nil {
+		// Some filesystems do not support fsync on directories.
+		if errors.Is(err, syscall.EINVAL) {
+			return nil
+		}
+		return fmt.Errorf("fsync directory: %w", err)
+	}
+	return nil
+}
+
+// Update rewrites path under an exclusive lock: fn receives the current
+// content (nil if the file does not exist) and returns the new content,
+// which is written with WriteFileAtomic.
+func Update(path string, perm os.FileMode, fn func(data []byte) ([]byte, error)) error {
+	return WithLock(path, func() error {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("read %s: %w", filepath.Base(path), err)
+		}
+		updated, err := fn(data)
+		if err != nil {
+			return err
+		}
+		return WriteFileAtomic(path, updated, perm)
+	})
+}
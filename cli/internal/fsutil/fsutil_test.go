@@ -0,0 +1,139 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type record struct {
+	N int `json:"n"`
+}
+
+func TestAppendJSONL_RepairsTornTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{"empty", "", `{"n":1}` + "\n"},
+		{"terminated", `{"n":0}` + "\n", `{"n":0}` + "\n" + `{"n":1}` + "\n"},
+		{"torn", `{"n":0}` + "\n" + `{"n":`, `{"n":0}` + "\n" + `{"n":1}` + "\n"},
+		{"torn only line", `{"n`, `{"n":1}` + "\n"},
+		{"unterminated but valid", `{"n":0}`, `{"n":0}` + "\n" + `{"n":1}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "log.jsonl")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := AppendJSONL(path, 0600, record{N: 1}); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := os.ReadFile(path)
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadJSONL_SkipsTornLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	content := `{"n":1}` + "\n\n" + `{"n":` + "\n" + `"not a record"` + "\n" + `{"n":2}` + "\n" + `{"n":3`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	records, skipped, err := LoadJSONL[record](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].N != 1 || records[1].N != 2 || skipped != 3 {
+		t.Errorf("records = %+v, skipped = %d", records, skipped)
+	}
+
+	records, skipped, err = LoadJSONL[record](filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil || records != nil || skipped != 0 {
+		t.Errorf("missing file: %v %d %v", records, skipped, err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "counter")
+	for i := 0; i < 3; i++ {
+		err := Update(path, 0640, func(data []byte) ([]byte, error) {
+			n, _ := strconv.Atoi(string(data))
+			return []byte(strconv.Itoa(n + 1)), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "3" {
+		t.Errorf("counter = %q, want 3", got)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".tmp-*"))
+	if len(leftovers) != 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestLockFile_Excludes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.jsonl")
+	held, err := LockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		l, err := RLockFile(path)
+		if err == nil {
+			_ = l.Unlock()
+		}
+		close(acquired)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-acquired:
+		t.Fatal("shared lock acquired while an exclusive lock was held")
+	default:
+	}
+	if err := held.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	<-acquired
+	if _, err := os.Stat(LockPath(path)); err != nil {
+		t.Errorf("lock file: %v", err)
+	}
+}
+
+func TestTryLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slot")
+	held, err := TryLockFile(path)
+	if err != nil || held == nil {
+		t.Fatalf("first TryLockFile = %v, %v", held, err)
+	}
+	if l, err := TryLockFile(path); err != nil || l != nil {
+		t.Errorf("TryLockFile on a held lock = %v, %v, want nil, nil", l, err)
+	}
+	if err := held.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	l, err := TryLockFile(path)
+	if err != nil || l == nil {
+		t.Fatalf("TryLockFile after unlock = %v, %v", l, err)
+	}
+	_ = l.Unlock()
+}
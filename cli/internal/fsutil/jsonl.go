@@ -0,0 +1,67 @@
+package fsutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ReadJSONL calls fn with each complete JSON line of path, in order. Blank
+// lines are ignored. Lines that are not valid JSON, including a torn last
+// line from a writer that crashed or is still writing, are skipped and
+// counted. A missing file has no lines. Reading takes no lock: appends and
+// atomic rewrites never expose a partial line other than the last.
+func ReadJSONL(path string, fn func(line []byte) error) (skipped int, err error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only, close error non-fatal
+	}()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return skipped, fmt.Errorf("read %s: %w", filepath.Base(path), readErr)
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(bytes.TrimSpace(line)) > 0 {
+			if json.Valid(line) {
+				if err := fn(line); err != nil {
+					return skipped, err
+				}
+			} else {
+				skipped++
+			}
+		}
+		if readErr != nil {
+			return skipped, nil
+		}
+	}
+}
+
+// LoadJSONL decodes every valid line of path into a T. Lines that do not
+// decode into T are counted as skipped, like torn lines.
+func LoadJSONL[T any](path string) (records []T, skipped int, err error) {
+	undecodable := 0
+	skipped, err = ReadJSONL(path, func(line []byte) error {
+		var v T
+		if err := json.Unmarshal(line, &v); err != nil {
+			undecodable++
+			return nil
+		}
+		records = append(records, v)
+		return nil
+	})
+	return records, skipped + undecodable, err
+}
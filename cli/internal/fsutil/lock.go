@@ -0,0 +1,101 @@
+// Package fsutil provides crash-safe, multi-process file primitives for the
+// shared files under .agents/: advisory locks, appends that fsync and repair
+// a torn last line, atomic rewrites, and JSONL reads that tolerate torn
+// lines left by a crashed writer.
+//
+// Locks are flock(2) locks on a sidecar "<path>.lock" file rather than on
+// the data file itself, so they keep working when the data file is
+// replaced by an atomic rename.
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// LockSuffix is appended to a data file path to name its lock file.
+const LockSuffix = ".lock"
+
+// Lock is a held advisory lock on a data file.
+type Lock struct {
+	f *os.File
+}
+
+// LockPath returns the lock file guarding path.
+func LockPath(path string) string {
+	return path + LockSuffix
+}
+
+// LockFile takes an exclusive lock on path, blocking until it is free.
+// The lock is per open file, so it also excludes other goroutines of the
+// same process that lock path; it is not reentrant.
+func LockFile(path string) (*Lock, error) {
+	return lock(path, syscall.LOCK_EX)
+}
+
+// RLockFile takes a shared lock on path, blocking while an exclusive lock
+// is held.
+func RLockFile(path string) (*Lock, error) {
+	return lock(path, syscall.LOCK_SH)
+}
+
+// TryLockFile takes an exclusive lock on path without blocking. It returns
+// a nil Lock and no error when another holder has it.
+func TryLockFile(path string) (*Lock, error) {
+	l, err := lock(path, syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return nil, nil
+	}
+	return l, err
+}
+
+func lock(path string, how int) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	f, err := os.OpenFile(LockPath(path), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		_ = f.Close() //nolint:errcheck // already failing
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	_ = syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN) //nolint:errcheck // closing releases the lock anyway
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// WithLock runs fn while holding an exclusive lock on path. Use it for
+// read-modify-write sequences; inside fn, use the *Locked variants and
+// WriteFileAtomic rather than functions that lock path again.
+func WithLock(path string, fn func() error) (err error) {
+	l, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if uerr := l.Unlock(); uerr != nil && err == nil {
+			err = uerr
+		}
+	}()
+	return fn()
+}
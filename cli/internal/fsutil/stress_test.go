@@ -0,0 +1,134 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The stress test re-runs this test binary as worker processes that hammer
+// the same files. Scale it with AO_STRESS_PROCS and AO_STRESS_OPS, e.g.
+//
+//	AO_STRESS_PROCS=64 AO_STRESS_OPS=2000 go test ./internal/fsutil -run Stress
+const stressWorkerEnv = "AO_FSUTIL_STRESS_WORKER"
+
+type stressRecord struct {
+	Worker int    `json:"worker"`
+	Seq    int    `json:"seq"`
+	Pad    string `json:"pad"`
+}
+
+func envInt(name string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+func TestStress_MultiProcess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	procs := envInt("AO_STRESS_PROCS", 8)
+	ops := envInt("AO_STRESS_OPS", 150)
+	dir := t.TempDir()
+
+	cmds := make([]*exec.Cmd, procs)
+	for w := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestStressWorker$")
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s,%d,%d", stressWorkerEnv, dir, w, ops))
+		var out strings.Builder
+		cmd.Stdout, cmd.Stderr = &out, &out
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[w] = cmd
+		t.Cleanup(func() {
+			if t.Failed() && out.Len() > 0 {
+				t.Logf("worker output:\n%s", out.String())
+			}
+		})
+	}
+
+	// Read concurrently with the writers: no complete line may be corrupt.
+	logPath := filepath.Join(dir, "log.jsonl")
+	done := make(chan error, 1)
+	go func() {
+		for w, cmd := range cmds {
+			if err := cmd.Wait(); err != nil {
+				done <- fmt.Errorf("worker %d: %w", w, err)
+				return
+			}
+		}
+		done <- nil
+	}()
+	for reading := true; reading; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			reading = false
+		default:
+			// Only the line being written may be incomplete.
+			if _, skipped, err := LoadJSONL[stressRecord](logPath); err != nil || skipped > 1 {
+				t.Fatalf("concurrent read: %d corrupt lines, %v", skipped, err)
+			}
+		}
+	}
+
+	records, skipped, err := LoadJSONL[stressRecord](logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 0 {
+		t.Errorf("%d corrupt lines after all writers finished", skipped)
+	}
+	if len(records) != procs*ops {
+		t.Errorf("got %d records, want %d", len(records), procs*ops)
+	}
+	next := make([]int, procs)
+	for _, r := range records {
+		if r.Seq != next[r.Worker] {
+			t.Fatalf("worker %d: record %d out of order or duplicated (want %d)", r.Worker, r.Seq, next[r.Worker])
+		}
+		next[r.Worker]++
+	}
+
+	counter, _ := os.ReadFile(filepath.Join(dir, "counter"))
+	if want := strconv.Itoa(procs * ops); string(counter) != want {
+		t.Errorf("counter = %s, want %s (lost updates)", counter, want)
+	}
+}
+
+// TestStressWorker is the worker process body; it does nothing unless
+// started by TestStress_MultiProcess.
+func TestStressWorker(t *testing.T) {
+	spec := os.Getenv(stressWorkerEnv)
+	if spec == "" {
+		t.Skip("only runs as a stress worker process")
+	}
+	parts := strings.Split(spec, ",")
+	dir := parts[0]
+	worker, _ := strconv.Atoi(parts[1])
+	ops, _ := strconv.Atoi(parts[2])
+
+	for i := 0; i < ops; i++ {
+		// Records larger than PIPE_BUF would interleave without the lock.
+		pad := strings.Repeat(string(rune('a'+worker%26)), 100+(i*37)%8000)
+		if err := AppendJSONL(filepath.Join(dir, "log.jsonl"), 0600, stressRecord{Worker: worker, Seq: i, Pad: pad}); err != nil {
+			t.Fatal(err)
+		}
+		err := Update(filepath.Join(dir, "counter"), 0600, func(data []byte) ([]byte, error) {
+			n, _ := strconv.Atoi(string(data))
+			return []byte(strconv.Itoa(n + 1)), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
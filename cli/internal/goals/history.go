@@ -6,6 +6,8 @@ import (
 	"errors"
 	"os"
 	"time"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 )
 
 // HistoryEntry records aggregate goal status at a point in time.
@@ -22,19 +24,7 @@ type HistoryEntry struct {
 // AppendHistory appends a single history entry as a JSON line to the given file.
 // Creates the file if it does not exist.
 func AppendHistory(entry HistoryEntry, path string) error {
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	data, err := json.Marshal(entry)
-	if err != nil {
-		return err
-	}
-	data = append(data, '\n')
-	_, err = f.Write(data)
-	return err
+	return fsutil.AppendJSONL(path, 0644, entry)
 }
 
 // LoadHistory reads all history entries from a JSON-lines file.
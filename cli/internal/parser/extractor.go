@@ -10,6 +10,9 @@ import (
 
 // ExtractionPattern defines a pattern for identifying knowledge types.
 type ExtractionPattern struct {
+	// Name identifies the pattern in configuration and diagnostics.
+	Name string
+
 	// Type is the knowledge type this pattern identifies.
 	Type types.KnowledgeType
 
@@ -21,11 +24,16 @@ type ExtractionPattern struct {
 
 	// MinScore is the minimum confidence for extraction (0.0-1.0).
 	MinScore float64
+
+	// MinContext is how many characters of text must follow a match for it
+	// to count, so a bare marker like "gotcha:" does not fire on its own.
+	MinContext int
 }
 
 // DefaultPatterns provides the standard extraction patterns.
 var DefaultPatterns = []ExtractionPattern{
 	{
+		Name: "decision",
 		Type: types.KnowledgeTypeDecision,
 		Keywords: []string{
 			// Explicit markers
@@ -68,6 +76,7 @@ var DefaultPatterns = []ExtractionPattern{
 		MinScore: 0.6,
 	},
 	{
+		Name: "solution",
 		Type: types.KnowledgeTypeSolution,
 		Keywords: []string{
 			"**Solution:**",
@@ -87,6 +96,7 @@ var DefaultPatterns = []ExtractionPattern{
 		MinScore: 0.7,
 	},
 	{
+		Name: "learning",
 		Type: types.KnowledgeTypeLearning,
 		Keywords: []string{
 			"**Learning:**",
@@ -106,6 +116,7 @@ var DefaultPatterns = []ExtractionPattern{
 		MinScore: 0.5,
 	},
 	{
+		Name: "failure",
 		Type: types.KnowledgeTypeFailure,
 		Keywords: []string{
 			"**Failure:**",
@@ -125,6 +136,7 @@ var DefaultPatterns = []ExtractionPattern{
 		MinScore: 0.6,
 	},
 	{
+		Name: "reference",
 		Type: types.KnowledgeTypeReference,
 		Keywords: []string{
 			"**Reference:**",
@@ -151,6 +163,9 @@ type ExtractionResult struct {
 	// Score is the extraction confidence (0.0-1.0).
 	Score float64
 
+	// PatternName is the name of the pattern that matched.
+	PatternName string
+
 	// MatchedKeyword is which keyword triggered the match.
 	MatchedKeyword string
 
@@ -184,52 +199,102 @@ func (e *Extractor) Extract(msg types.TranscriptMessage) []ExtractionResult {
 	}
 
 	content := msg.Content
+	lower := strings.ToLower(content)
 	results := make([]ExtractionResult, 0)
 
 	for _, pattern := range e.Patterns {
-		// Check keywords first (cheaper)
-		for _, keyword := range pattern.Keywords {
-			if idx := strings.Index(strings.ToLower(content), strings.ToLower(keyword)); idx >= 0 {
-				results = append(results, ExtractionResult{
-					Type:           pattern.Type,
-					Score:          pattern.MinScore + 0.1, // Keyword match bonus
-					MatchedKeyword: keyword,
-					StartIndex:     idx,
-					EndIndex:       idx + len(keyword),
-				})
-				break // One match per pattern type
-			}
-		}
+		// One keyword and one regex match per pattern
+		results = append(results, pattern.match(content, lower, false)...)
+	}
 
-		// Check regex patterns for stronger matches
-		for _, re := range pattern.Patterns {
-			if loc := re.FindStringIndex(content); loc != nil {
-				results = append(results, ExtractionResult{
-					Type:           pattern.Type,
-					Score:          pattern.MinScore + 0.2, // Pattern match bonus
-					MatchedPattern: re.String(),
-					StartIndex:     loc[0],
-					EndIndex:       loc[1],
-				})
-				break // One match per pattern type
-			}
+	// Deduplicate by type, keeping highest score, in pattern order
+	seen := make(map[types.KnowledgeType]int)
+	final := make([]ExtractionResult, 0, len(results))
+	for _, r := range results {
+		i, ok := seen[r.Type]
+		if !ok {
+			seen[r.Type] = len(final)
+			final = append(final, r)
+		} else if r.Score > final[i].Score {
+			final[i] = r
 		}
 	}
 
-	// Deduplicate by type, keeping highest score
-	seen := make(map[types.KnowledgeType]ExtractionResult)
-	for _, r := range results {
-		if existing, ok := seen[r.Type]; !ok || r.Score > existing.Score {
-			seen[r.Type] = r
+	return final
+}
+
+// Explain returns every keyword and regex match in a message, in pattern
+// order, without the per-type deduplication Extract applies. It shows which
+// patterns fire where.
+func (e *Extractor) Explain(msg types.TranscriptMessage) []ExtractionResult {
+	if msg.Content == "" {
+		return nil
+	}
+	lower := strings.ToLower(msg.Content)
+	var results []ExtractionResult
+	for _, pattern := range e.Patterns {
+		results = append(results, pattern.match(msg.Content, lower, true)...)
+	}
+	return results
+}
+
+// match finds the pattern's keyword and regex matches in content; lower is
+// content lowercased. Unless all is set it stops at the first keyword and
+// the first regex that match.
+func (pattern ExtractionPattern) match(content, lower string, all bool) []ExtractionResult {
+	var results []ExtractionResult
+
+	// Check keywords first (cheaper)
+	for _, keyword := range pattern.Keywords {
+		idx := strings.Index(lower, strings.ToLower(keyword))
+		if idx < 0 || !pattern.hasContext(content, idx+len(keyword)) {
+			continue
+		}
+		results = append(results, ExtractionResult{
+			Type:           pattern.Type,
+			Score:          pattern.MinScore + 0.1, // Keyword match bonus
+			PatternName:    pattern.Name,
+			MatchedKeyword: keyword,
+			StartIndex:     idx,
+			EndIndex:       idx + len(keyword),
+		})
+		if !all {
+			break // One match per pattern type
 		}
 	}
 
-	final := make([]ExtractionResult, 0, len(seen))
-	for _, r := range seen {
-		final = append(final, r)
+	// Check regex patterns for stronger matches
+	for _, re := range pattern.Patterns {
+		loc := re.FindStringIndex(content)
+		if loc == nil || !pattern.hasContext(content, loc[1]) {
+			continue
+		}
+		results = append(results, ExtractionResult{
+			Type:           pattern.Type,
+			Score:          pattern.MinScore + 0.2, // Pattern match bonus
+			PatternName:    pattern.Name,
+			MatchedPattern: re.String(),
+			StartIndex:     loc[0],
+			EndIndex:       loc[1],
+		})
+		if !all {
+			break // One match per pattern type
+		}
 	}
 
-	return final
+	return results
+}
+
+// hasContext reports whether at least MinContext non-blank characters of
+// content follow end.
+func (pattern ExtractionPattern) hasContext(content string, end int) bool {
+	if pattern.MinContext <= 0 {
+		return true
+	}
+	if end > len(content) {
+		end = len(content)
+	}
+	return len(strings.TrimSpace(content[end:])) >= pattern.MinContext
 }
 
 // ExtractBest returns the single best extraction from a message, or nil if none.
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// Names of the built-in transcript formats.
+const (
+	// FormatAuto selects a format by sniffing the file.
+	FormatAuto = "auto"
+
+	// FormatClaude is the Claude Code JSONL transcript.
+	FormatClaude = "claude"
+
+	// FormatCodex is the Codex CLI rollout JSONL.
+	FormatCodex = "codex"
+
+	// FormatAider is the Aider chat history markdown.
+	FormatAider = "aider"
+
+	// FormatOpenAI is an OpenAI-style chat completion JSON document.
+	FormatOpenAI = "openai"
+)
+
+// detectSampleSize is how much of a file DetectFile reads for sniffing.
+const detectSampleSize = 64 * 1024
+
+// TranscriptFormat decodes one agent's transcript layout into
+// TranscriptMessages.
+type TranscriptFormat interface {
+	// Name is the identifier used with --format.
+	Name() string
+
+	// Detect reports whether a file looks like this format, given its path
+	// and up to the first 64KB of its content.
+	Detect(path string, head []byte) bool
+
+	// Decode reads a transcript from r and calls emit for each message, in
+	// transcript order. It honours p.SkipMalformed and truncates text with
+	// p.truncate.
+	Decode(p *Parser, r io.Reader, emit func(types.TranscriptMessage)) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   []TranscriptFormat
+)
+
+func init() {
+	RegisterFormat(claudeFormat{})
+	RegisterFormat(codexFormat{})
+	RegisterFormat(openAIFormat{})
+	RegisterFormat(aiderFormat{})
+}
+
+// RegisterFormat adds a transcript format to the registry. Detection tries
+// formats in registration order; registering a name again replaces the
+// earlier format in place.
+func RegisterFormat(f TranscriptFormat) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for i, existing := range formats {
+		if existing.Name() == f.Name() {
+			formats[i] = f
+			return
+		}
+	}
+	formats = append(formats, f)
+}
+
+// Formats returns the registered formats in detection order.
+func Formats() []TranscriptFormat {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	return append([]TranscriptFormat(nil), formats...)
+}
+
+// FormatNames returns the registered format names, sorted.
+func FormatNames() []string {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, f.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatByName returns the registered format with the given name.
+func FormatByName(name string) (TranscriptFormat, error) {
+	for _, f := range Formats() {
+		if f.Name() == name {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown transcript format %q (available: %s, %s)",
+		name, FormatAuto, strings.Join(FormatNames(), ", "))
+}
+
+// DetectFormat returns the first registered format that recognizes the
+// content, falling back to Claude Code JSONL.
+func DetectFormat(path string, head []byte) TranscriptFormat {
+	for _, f := range Formats() {
+		if f.Detect(path, head) {
+			return f
+		}
+	}
+	return claudeFormat{}
+}
+
+// DetectFile sniffs the start of the file at path and returns its format.
+func DetectFile(path string) (TranscriptFormat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only sniff, close error non-fatal
+	}()
+
+	head := make([]byte, detectSampleSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return DetectFormat(path, head[:n]), nil
+}
+
+// ResolveFormat returns the format called name, or detects it from the file
+// at path when name is empty or FormatAuto.
+func ResolveFormat(name, path string) (TranscriptFormat, error) {
+	if name == "" || name == FormatAuto {
+		return DetectFile(path)
+	}
+	return FormatByName(name)
+}
+
+// firstLine returns the first non-blank line of head.
+func firstLine(head []byte) []byte {
+	for _, line := range strings.Split(string(head), "\n") {
+		if strings.TrimSpace(line) != "" {
+			return []byte(strings.TrimSpace(line))
+		}
+	}
+	return nil
+}
+
+// claudeFormat is the Claude Code JSONL transcript: one JSON object per
+// line with type, sessionId and a message whose content is a string or an
+// array of text, tool_use and tool_result blocks.
+type claudeFormat struct{}
+
+func (claudeFormat) Name() string { return FormatClaude }
+
+func (claudeFormat) Detect(_ string, head []byte) bool {
+	var probe struct {
+		Type      string `json:"type"`
+		SessionID string `json:"sessionId"`
+		UUID      string `json:"uuid"`
+	}
+	if err := json.Unmarshal(firstLine(head), &probe); err != nil {
+		return false
+	}
+	return probe.Type != "" && (probe.SessionID != "" || probe.UUID != "")
+}
+
+func (claudeFormat) Decode(p *Parser, r io.Reader, emit func(types.TranscriptMessage)) error {
+	return decodeLines(p, r, emit)
+}
+
+func (claudeFormat) DecodeLine(p *Parser, line []byte, pos *Position) (*types.TranscriptMessage, error) {
+	return p.parseLine(line, pos.Line)
+}
+
+// decodeArguments parses a JSON-encoded tool argument string into a map.
+// Arguments that are not a JSON object are kept under "input".
+func decodeArguments(args string) map[string]interface{} {
+	if strings.TrimSpace(args) == "" {
+		return nil
+	}
+	var input map[string]interface{}
+	if err := json.Unmarshal([]byte(args), &input); err == nil {
+		return input
+	}
+	return map[string]interface{}{"input": args}
+}
+
+// textParts joins the text of OpenAI-style content parts
+// ({"type": "text" | "input_text" | "output_text", "text": ...}).
+func textParts(parts []contentPart) string {
+	var texts []string
+	for _, part := range parts {
+		switch part.Type {
+		case "text", "input_text", "output_text":
+			if part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// contentPart is one element of an array-valued message content.
+type contentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// aiderFormat is Aider's chat history markdown (.aider.chat.history.md).
+// Each run starts with "# aider chat started at <time>"; user input lines
+// start with "#### ", tool and status output with "> ", and everything
+// else is the assistant's reply. "> Applied edit to <file>" marks an edit.
+type aiderFormat struct{}
+
+const (
+	aiderSessionPrefix = "# aider chat started at "
+	aiderHistoryName   = ".aider.chat.history.md"
+	aiderTimeLayout    = "2006-01-02 15:04:05"
+)
+
+func (aiderFormat) Name() string { return FormatAider }
+
+func (aiderFormat) Detect(path string, head []byte) bool {
+	return strings.HasSuffix(filepath.Base(path), aiderHistoryName) ||
+		bytes.HasPrefix(bytes.TrimSpace(head), []byte(aiderSessionPrefix))
+}
+
+func (aiderFormat) Decode(p *Parser, r io.Reader, emit func(types.TranscriptMessage)) error {
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	var (
+		sessionID string
+		started   time.Time
+		cur       *types.TranscriptMessage
+		text      []string
+		inFence   bool
+	)
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		content := strings.TrimSpace(strings.Join(text, "\n"))
+		if cur.Type == "tool_result" {
+			cur.Tools = append(cur.Tools, types.ToolCall{Name: "tool_result", Output: p.truncate(content)})
+		} else {
+			cur.Content = p.truncate(content)
+		}
+		if content != "" || len(cur.Tools) > 0 {
+			emit(*cur)
+		}
+		cur, text = nil, nil
+	}
+	start := func(kind string, lineNum int) {
+		if cur != nil && cur.Type == kind {
+			return
+		}
+		flush()
+		role := kind
+		if kind == "tool_result" {
+			role = "tool"
+		}
+		cur = &types.TranscriptMessage{
+			Type:         kind,
+			Role:         role,
+			Timestamp:    started,
+			SessionID:    sessionID,
+			MessageIndex: lineNum,
+		}
+	}
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		switch {
+		case !inFence && strings.HasPrefix(line, aiderSessionPrefix):
+			flush()
+			stamp := strings.TrimSpace(strings.TrimPrefix(line, aiderSessionPrefix))
+			started, _ = time.ParseInLocation(aiderTimeLayout, stamp, time.Local) //nolint:errcheck // zero time if unparseable
+			sessionID = aiderSessionID(started, lineNum)
+
+		case !inFence && strings.HasPrefix(line, "#### "):
+			start("user", lineNum)
+			text = append(text, strings.TrimPrefix(line, "#### "))
+
+		case !inFence && (strings.HasPrefix(line, "> ") || line == ">"):
+			start("tool_result", lineNum)
+			out := strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+			if file, ok := strings.CutPrefix(out, "Applied edit to "); ok {
+				cur.Tools = append(cur.Tools, types.ToolCall{
+					Name:  "edit",
+					Input: map[string]interface{}{"file_path": strings.TrimSpace(file)},
+				})
+			}
+			text = append(text, out)
+
+		case strings.TrimSpace(line) == "" && !inFence:
+			if cur != nil && cur.Type == "assistant" {
+				text = append(text, "")
+			} else {
+				flush()
+			}
+
+		default:
+			start("assistant", lineNum)
+			text = append(text, line)
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanner error: %w", err)
+	}
+	return nil
+}
+
+// aiderSessionID derives a session ID from a chat start time, falling back
+// to the line number when the time does not parse.
+func aiderSessionID(started time.Time, lineNum int) string {
+	if started.IsZero() {
+		return fmt.Sprintf("aider-line%d", lineNum)
+	}
+	return "aider-" + started.Format("20060102-150405")
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// codexFormat is the Codex CLI rollout JSONL
+// (~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl). Current rollouts wrap
+// every record as {"timestamp", "type", "payload"} with a leading
+// session_meta record; older rollouts start with a bare {"id", "timestamp"}
+// header followed by unwrapped response items.
+type codexFormat struct{}
+
+// codexLine is one rollout record, in either layout.
+type codexLine struct {
+	Timestamp  string          `json:"timestamp"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	ID         string          `json:"id"`
+	RecordType string          `json:"record_type"`
+}
+
+// codexItem is a response item (message, tool call or tool output) or the
+// session_meta payload.
+type codexItem struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Timestamp string          `json:"timestamp"`
+	Role      string          `json:"role"`
+	Content   []contentPart   `json:"content"`
+	Name      string          `json:"name"`
+	Arguments string          `json:"arguments"`
+	Input     string          `json:"input"`
+	Output    json.RawMessage `json:"output"`
+}
+
+func (codexFormat) Name() string { return FormatCodex }
+
+func (codexFormat) Detect(path string, head []byte) bool {
+	var probe codexLine
+	if err := json.Unmarshal(firstLine(head), &probe); err != nil {
+		return false
+	}
+	if probe.Type == "session_meta" || len(probe.Payload) > 0 {
+		return true
+	}
+	// Legacy header: {"id": ..., "timestamp": ..., "instructions": ...}.
+	return probe.Type == "" && probe.ID != "" && probe.Timestamp != "" &&
+		strings.HasPrefix(filepath.Base(path), "rollout-")
+}
+
+func (codexFormat) Decode(p *Parser, r io.Reader, emit func(types.TranscriptMessage)) error {
+	return decodeLines(p, r, emit)
+}
+
+// DecodeLine decodes one rollout record; the session ID from the
+// session_meta record or legacy header is carried in pos.
+func (codexFormat) DecodeLine(p *Parser, line []byte, pos *Position) (*types.TranscriptMessage, error) {
+	var rec codexLine
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var item codexItem
+	switch {
+	case len(rec.Payload) > 0:
+		if rec.Type != "session_meta" && rec.Type != "response_item" {
+			return nil, nil // event_msg and turn_context repeat response items
+		}
+		if err := json.Unmarshal(rec.Payload, &item); err != nil {
+			return nil, fmt.Errorf("invalid payload: %w", err)
+		}
+		if rec.Type == "session_meta" {
+			pos.SessionID = item.ID
+			return nil, nil
+		}
+	case rec.RecordType != "":
+		return nil, nil // legacy state snapshots
+	case rec.Type == "" && rec.ID != "":
+		pos.SessionID = rec.ID // legacy header
+		return nil, nil
+	default:
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, nil
+		}
+	}
+
+	ts := rec.Timestamp
+	if ts == "" {
+		ts = item.Timestamp
+	}
+	msg := &types.TranscriptMessage{
+		Timestamp:    parseTimestamp(ts),
+		SessionID:    pos.SessionID,
+		MessageIndex: pos.Line,
+	}
+	if !p.codexMessage(item, msg) {
+		return nil, nil
+	}
+	return msg, nil
+}
+
+// codexMessage fills msg from a response item; false means the item carries
+// no conversation content (reasoning, system prompts).
+func (p *Parser) codexMessage(item codexItem, msg *types.TranscriptMessage) bool {
+	switch item.Type {
+	case "message":
+		if item.Role != "user" && item.Role != "assistant" {
+			return false
+		}
+		msg.Type = item.Role
+		msg.Role = item.Role
+		msg.Content = p.truncate(textParts(item.Content))
+
+	case "function_call", "custom_tool_call", "local_shell_call":
+		msg.Type = "assistant"
+		msg.Role = "assistant"
+		args := item.Arguments
+		if args == "" {
+			args = item.Input
+		}
+		msg.Tools = codexToolCalls(item.Name, args)
+
+	case "function_call_output", "custom_tool_call_output":
+		msg.Type = "tool_result"
+		msg.Role = "tool"
+		msg.Tools = []types.ToolCall{p.codexToolResult(item.Output)}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// codexToolCalls converts a Codex tool call into ToolCalls. Patches (the
+// apply_patch tool, or a shell command carrying one) yield one call per
+// touched file with its path as file_path, so forge sees edited files.
+func codexToolCalls(name, args string) []types.ToolCall {
+	if name == "" {
+		name = "shell"
+	}
+	input := decodeArguments(args)
+
+	patch := args
+	if cmd, ok := input["command"].([]interface{}); ok {
+		var parts []string
+		for _, c := range cmd {
+			if s, ok := c.(string); ok {
+				parts = append(parts, s)
+			}
+		}
+		patch = strings.Join(parts, " ")
+		input["command"] = patch
+	}
+	if files := patchFiles(patch); len(files) > 0 {
+		calls := make([]types.ToolCall, 0, len(files))
+		for _, f := range files {
+			calls = append(calls, types.ToolCall{
+				Name:  "apply_patch",
+				Input: map[string]interface{}{"file_path": f},
+			})
+		}
+		return calls
+	}
+	return []types.ToolCall{{Name: name, Input: input}}
+}
+
+// patchFiles lists the files named in an apply_patch envelope.
+func patchFiles(patch string) []string {
+	if !strings.Contains(patch, "*** Begin Patch") {
+		return nil
+	}
+	var files []string
+	for _, line := range strings.Split(patch, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"*** Add File: ", "*** Update File: ", "*** Delete File: ", "*** Move to: "} {
+			if strings.HasPrefix(line, prefix) {
+				files = append(files, strings.TrimSpace(strings.TrimPrefix(line, prefix)))
+			}
+		}
+	}
+	return files
+}
+
+// codexToolResult decodes a tool output, which is either a string or (for
+// older shell calls) a JSON string of {"output", "metadata": {"exit_code"}}.
+func (p *Parser) codexToolResult(raw json.RawMessage) types.ToolCall {
+	result := types.ToolCall{Name: "tool_result"}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		text = string(raw)
+	}
+	var structured struct {
+		Output   string `json:"output"`
+		Metadata struct {
+			ExitCode *int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(text), &structured); err == nil && structured.Metadata.ExitCode != nil {
+		text = structured.Output
+		if code := *structured.Metadata.ExitCode; code != 0 {
+			result.Error = fmt.Sprintf("exit code %d", code)
+		}
+	}
+	result.Output = p.truncate(text)
+	return result
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// openAIFormat is a generic OpenAI-style chat transcript: a JSON array of
+// messages, or an object with a "messages" array and optional "id",
+// "session_id" and "created" (Unix seconds). Message content is a string or
+// an array of text parts; assistant tool_calls and role "tool" replies map
+// to tool calls and tool results.
+type openAIFormat struct{}
+
+// openAIMessage is one chat message.
+type openAIMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCallID string          `json:"tool_call_id"`
+	ToolCalls  []struct {
+		Function struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function"`
+	} `json:"tool_calls"`
+}
+
+// openAIDocument is the object form of the transcript.
+type openAIDocument struct {
+	ID        string          `json:"id"`
+	SessionID string          `json:"session_id"`
+	Created   float64         `json:"created"`
+	Messages  []openAIMessage `json:"messages"`
+}
+
+func (openAIFormat) Name() string { return FormatOpenAI }
+
+func (openAIFormat) Detect(_ string, head []byte) bool {
+	trimmed := bytes.TrimSpace(head)
+	if !bytes.Contains(trimmed, []byte(`"role"`)) {
+		return false
+	}
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		return true
+	}
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return false
+	}
+	// A JSONL transcript's first line is a complete object without a
+	// messages array; a chat document either spans lines or has one.
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(firstLine(head), &probe); err == nil {
+		_, ok := probe["messages"]
+		return ok
+	}
+	return bytes.Contains(trimmed, []byte(`"messages"`))
+}
+
+func (openAIFormat) Decode(p *Parser, r io.Reader, emit func(types.TranscriptMessage)) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read transcript: %w", err)
+	}
+
+	var doc openAIDocument
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		err = json.Unmarshal(trimmed, &doc.Messages)
+	} else {
+		err = json.Unmarshal(trimmed, &doc)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	sessionID := doc.SessionID
+	if sessionID == "" {
+		sessionID = doc.ID
+	}
+	var created time.Time
+	if doc.Created > 0 {
+		created = time.Unix(int64(doc.Created), 0).UTC()
+	}
+
+	for i, m := range doc.Messages {
+		msg := types.TranscriptMessage{
+			Role:         m.Role,
+			Timestamp:    created,
+			SessionID:    sessionID,
+			MessageIndex: i + 1,
+		}
+		content := p.truncate(openAIContent(m.Content))
+
+		switch m.Role {
+		case "user":
+			msg.Type = "user"
+			msg.Content = content
+		case "assistant":
+			msg.Type = "assistant"
+			msg.Content = content
+			for _, call := range m.ToolCalls {
+				msg.Tools = append(msg.Tools, types.ToolCall{
+					Name:  call.Function.Name,
+					Input: decodeArguments(call.Function.Arguments),
+				})
+			}
+		case "tool", "function":
+			msg.Type = "tool_result"
+			msg.Tools = []types.ToolCall{{Name: "tool_result", Output: content}}
+		default:
+			continue // system and developer prompts
+		}
+		emit(msg)
+	}
+	return nil
+}
+
+// openAIContent returns the text of a string or content-part array.
+func openAIContent(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var parts []contentPart
+	if err := json.Unmarshal(raw, &parts); err == nil {
+		return textParts(parts)
+	}
+	return ""
+}
@@ -0,0 +1,231 @@
+package parser
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+const fixtureDir = "../../testdata/transcripts"
+
+func parseFixture(t *testing.T, name string) (TranscriptFormat, []types.TranscriptMessage) {
+	t.Helper()
+	path := filepath.Join(fixtureDir, name)
+	format, err := DetectFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewParser()
+	p.Format = format
+	result, err := p.ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile(%s): %v", name, err)
+	}
+	return format, result.Messages
+}
+
+func TestDetectFile(t *testing.T) {
+	tests := map[string]string{
+		"simple-decision.jsonl":        FormatClaude,
+		"tool-heavy.jsonl":             FormatClaude,
+		"codex-rollout.jsonl":          FormatCodex,
+		"sample.aider.chat.history.md": FormatAider,
+		"openai-chat.json":             FormatOpenAI,
+	}
+	for name, want := range tests {
+		format, err := DetectFile(filepath.Join(fixtureDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format.Name() != want {
+			t.Errorf("DetectFile(%s) = %s, want %s", name, format.Name(), want)
+		}
+	}
+}
+
+func TestDetectFormat_ContentOnly(t *testing.T) {
+	tests := []struct {
+		head string
+		want string
+	}{
+		{`[{"role":"user","content":"hi"}]`, FormatOpenAI},
+		{"{\n  \"messages\": [\n    {\"role\": \"user\"", FormatOpenAI},
+		{"# aider chat started at 2026-01-01 10:00:00\n", FormatAider},
+		{`{"timestamp":"2026-01-01T00:00:00Z","type":"response_item","payload":{"type":"message"}}`, FormatCodex},
+		{"not a transcript", FormatClaude},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat("transcript", []byte(tt.head)).Name(); got != tt.want {
+			t.Errorf("DetectFormat(%.30q) = %s, want %s", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestResolveFormat(t *testing.T) {
+	f, err := ResolveFormat(FormatAider, "whatever.jsonl")
+	if err != nil || f.Name() != FormatAider {
+		t.Errorf("ResolveFormat(aider) = %v, %v", f, err)
+	}
+	if _, err := ResolveFormat("vim", "x"); err == nil || !strings.Contains(err.Error(), "available") {
+		t.Errorf("expected unknown-format error listing formats, got %v", err)
+	}
+}
+
+func TestCodexFormat(t *testing.T) {
+	_, msgs := parseFixture(t, "codex-rollout.jsonl")
+
+	// user, update_plan, shell, shell output, patch, patch output, update_plan, assistant
+	if len(msgs) != 8 {
+		t.Fatalf("got %d messages, want 8: %+v", len(msgs), msgs)
+	}
+	for _, m := range msgs {
+		if m.SessionID != "0199a1b2-c3d4-7e5f-8a9b-0c1d2e3f4a5b" {
+			t.Fatalf("session ID = %q", m.SessionID)
+		}
+	}
+	if msgs[0].Type != "user" || !strings.Contains(msgs[0].Content, "flaky") {
+		t.Errorf("first message = %+v", msgs[0])
+	}
+	if msgs[0].Timestamp.IsZero() {
+		t.Error("timestamp not parsed")
+	}
+
+	shell := msgs[2].Tools[0]
+	if shell.Name != "shell" || shell.Input["command"] != "bash -lc go test ./auth -run TestLogin -count=20" {
+		t.Errorf("shell call = %+v", shell)
+	}
+	if out := msgs[3].Tools[0]; out.Error != "exit code 1" || !strings.Contains(out.Output, "FAIL") {
+		t.Errorf("shell output = %+v", out)
+	}
+	if patch := msgs[4].Tools[0]; patch.Name != "apply_patch" || patch.Input["file_path"] != "auth/session.go" {
+		t.Errorf("patch call = %+v", patch)
+	}
+	if last := msgs[7]; last.Type != "assistant" || !strings.Contains(last.Content, "sync.Once") {
+		t.Errorf("last message = %+v", last)
+	}
+}
+
+func TestCodexFormat_Legacy(t *testing.T) {
+	rollout := `{"id":"legacy-1","timestamp":"2025-06-01T10:00:00Z","instructions":""}
+{"record_type":"state"}
+{"type":"message","role":"user","content":[{"type":"input_text","text":"hello"}]}
+{"type":"function_call","name":"shell","arguments":"{\"command\":[\"ls\"]}","call_id":"c1"}
+`
+	p := NewParser()
+	p.Format = DetectFormat("rollout-2025-06-01T10-00-00-legacy-1.jsonl", []byte(rollout))
+	if p.Format.Name() != FormatCodex {
+		t.Fatalf("detected %s", p.Format.Name())
+	}
+	result, err := p.Parse(strings.NewReader(rollout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Messages) != 2 || result.Messages[0].SessionID != "legacy-1" || result.Messages[1].Tools[0].Name != "shell" {
+		t.Errorf("messages = %+v", result.Messages)
+	}
+	if result.TotalLines != 4 || result.Checksum == "" {
+		t.Errorf("TotalLines = %d, Checksum = %q", result.TotalLines, result.Checksum)
+	}
+}
+
+func TestAiderFormat(t *testing.T) {
+	_, msgs := parseFixture(t, "sample.aider.chat.history.md")
+
+	var users, edits []string
+	sessions := make(map[string]bool)
+	for _, m := range msgs {
+		sessions[m.SessionID] = true
+		if m.Type == "user" {
+			users = append(users, m.Content)
+		}
+		for _, tool := range m.Tools {
+			if tool.Name == "edit" {
+				edits = append(edits, tool.Input["file_path"].(string))
+			}
+		}
+	}
+	if len(sessions) != 2 || !sessions["aider-20260303-140510"] {
+		t.Errorf("sessions = %v", sessions)
+	}
+	if len(users) != 3 || !strings.HasPrefix(users[0], "Why does the cache") {
+		t.Errorf("user messages = %q", users)
+	}
+	if strings.Join(edits, ",") != "cache.go,cache_test.go" {
+		t.Errorf("edits = %v", edits)
+	}
+
+	var reply string
+	for _, m := range msgs {
+		if m.Type == "assistant" {
+			reply = m.Content
+			break
+		}
+	}
+	if !strings.Contains(reply, "The fix is to invalidate") || !strings.Contains(reply, ">>>>>>> REPLACE") {
+		t.Errorf("assistant reply should keep the prose and the edit block, got %q", reply)
+	}
+}
+
+func TestOpenAIFormat(t *testing.T) {
+	_, msgs := parseFixture(t, "openai-chat.json")
+
+	if len(msgs) != 4 {
+		t.Fatalf("got %d messages, want 4 (system skipped): %+v", len(msgs), msgs)
+	}
+	if msgs[0].SessionID != "chatcmpl-9xYz" || msgs[0].Timestamp.Year() != 2026 {
+		t.Errorf("first message = %+v", msgs[0])
+	}
+	if call := msgs[1].Tools[0]; call.Name != "edit_file" || call.Input["path"] != "config/load.go" {
+		t.Errorf("tool call = %+v", call)
+	}
+	if msgs[2].Type != "tool_result" || msgs[2].Tools[0].Output != "ok" {
+		t.Errorf("tool result = %+v", msgs[2])
+	}
+	if !strings.HasPrefix(msgs[3].Content, "Decided to rename") {
+		t.Errorf("content parts not joined: %q", msgs[3].Content)
+	}
+}
+
+func TestOpenAIFormat_Array(t *testing.T) {
+	p := NewParser()
+	p.Format = openAIFormat{}
+	msgCh, errCh := p.ParseChannel(strings.NewReader(`[{"role":"user","content":"one"},{"role":"assistant","content":"two"}]`))
+	var got []string
+	for m := range msgCh {
+		got = append(got, m.Content)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "one,two" {
+		t.Errorf("got %v", got)
+	}
+}
+
+type stubFormat struct{}
+
+func (stubFormat) Name() string                      { return "stub" }
+func (stubFormat) Detect(path string, _ []byte) bool { return strings.HasSuffix(path, ".stub") }
+func (stubFormat) Decode(_ *Parser, _ io.Reader, emit func(types.TranscriptMessage)) error {
+	emit(types.TranscriptMessage{Type: "user", Content: "stub"})
+	return nil
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat(stubFormat{})
+	defer func() {
+		formatsMu.Lock()
+		formats = formats[:len(formats)-1]
+		formatsMu.Unlock()
+	}()
+
+	if got := DetectFormat("x.stub", nil).Name(); got != "stub" {
+		t.Errorf("DetectFormat = %s, want stub", got)
+	}
+	if _, err := FormatByName("stub"); err != nil {
+		t.Error(err)
+	}
+}
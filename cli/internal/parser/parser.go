@@ -1,8 +1,11 @@
-// Package parser provides streaming JSONL parsing for Claude Code transcripts.
+// Package parser provides streaming parsing for coding agent transcripts.
+// Claude Code JSONL is the default; other layouts are TranscriptFormats
+// (see format.go) selected by name or detected from the file.
 package parser
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -28,6 +31,9 @@ type Parser struct {
 
 	// OnProgress is called with progress updates for large files.
 	OnProgress func(linesProcessed, totalLines int)
+
+	// Format decodes the transcript layout; nil means Claude Code JSONL.
+	Format TranscriptFormat
 }
 
 // NewParser creates a parser with default settings.
@@ -87,8 +93,22 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("line %d: %s (%s)", e.Line, e.Message, e.ErrorType)
 }
 
-// Parse reads JSONL from the reader and returns parsed messages.
+// format returns the configured transcript format.
+func (p *Parser) format() TranscriptFormat {
+	if p.Format == nil {
+		return claudeFormat{}
+	}
+	return p.Format
+}
+
+// Parse reads a transcript from the reader and returns parsed messages.
+// Line and malformed-line statistics are only collected for Claude Code
+// JSONL; other formats report decode failures as the returned error.
 func (p *Parser) Parse(r io.Reader) (*ParseResult, error) {
+	if _, ok := p.format().(claudeFormat); !ok {
+		return p.parseFormat(r)
+	}
+
 	result := &ParseResult{
 		Messages: make([]types.TranscriptMessage, 0),
 	}
@@ -152,6 +172,35 @@ func (p *Parser) Parse(r io.Reader) (*ParseResult, error) {
 	return result, nil
 }
 
+// parseFormat parses a non-JSONL transcript through p.Format.
+func (p *Parser) parseFormat(r io.Reader) (*ParseResult, error) {
+	result := &ParseResult{
+		Messages: make([]types.TranscriptMessage, 0),
+	}
+	hasher := sha256.New()
+	counter := &lineCounter{}
+	err := p.format().Decode(p, io.TeeReader(r, io.MultiWriter(hasher, counter)), func(msg types.TranscriptMessage) {
+		result.Messages = append(result.Messages, msg)
+	})
+	result.TotalLines = counter.lines
+	hash := hasher.Sum(nil)
+	result.Checksum = hex.EncodeToString(hash[:8])
+	result.ParsedAt = time.Now()
+	return result, err
+}
+
+// lineCounter counts bytes and newlines written to it.
+type lineCounter struct {
+	bytes int64
+	lines int
+}
+
+func (c *lineCounter) Write(b []byte) (int, error) {
+	c.bytes += int64(len(b))
+	c.lines += bytes.Count(b, []byte("\n"))
+	return len(b), nil
+}
+
 // classifyError determines the error type for structured reporting.
 func classifyError(err error) string {
 	errStr := err.Error()
@@ -358,34 +407,11 @@ func (p *Parser) ParseChannel(r io.Reader) (<-chan types.TranscriptMessage, <-ch
 		defer close(msgCh)
 		defer close(errCh)
 
-		scanner := bufio.NewScanner(r)
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, 1024*1024)
-
-		lineNum := 0
-		for scanner.Scan() {
-			lineNum++
-			line := scanner.Bytes()
-			if len(line) == 0 {
-				continue
-			}
-
-			msg, err := p.parseLine(line, lineNum)
-			if err != nil {
-				if !p.SkipMalformed {
-					errCh <- fmt.Errorf("line %d: %w", lineNum, err)
-					return
-				}
-				continue
-			}
-
-			if msg != nil {
-				msgCh <- *msg
-			}
-		}
-
-		if err := scanner.Err(); err != nil {
-			errCh <- fmt.Errorf("scanner error: %w", err)
+		err := p.format().Decode(p, r, func(msg types.TranscriptMessage) {
+			msgCh <- msg
+		})
+		if err != nil {
+			errCh <- err
 		}
 	}()
 
@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// PatternFileVersion is the supported extraction.yaml version.
+const PatternFileVersion = 1
+
+// PatternFile is the YAML form of extraction patterns:
+//
+//	version: 1
+//	replace: false          # true drops the built-in patterns
+//	patterns:
+//	  - name: root-cause
+//	    type: failure
+//	    regex: ['(?i)root cause:']
+//	    keywords: ["gotcha:"]
+//	    score: 0.7
+//	    context: 20
+//
+// A pattern named like a built-in one (decision, solution, learning,
+// failure, reference) replaces it; other patterns are added after them.
+type PatternFile struct {
+	Version  int           `yaml:"version"`
+	Replace  bool          `yaml:"replace,omitempty"`
+	Patterns []PatternSpec `yaml:"patterns"`
+}
+
+// PatternSpec is one pattern in a PatternFile.
+type PatternSpec struct {
+	// Name identifies the pattern; it must be unique within the file.
+	Name string `yaml:"name"`
+
+	// Type is the knowledge type: decision, solution, learning, failure or
+	// reference.
+	Type string `yaml:"type"`
+
+	// Regex lists regular expressions (Go RE2 syntax). Add (?i) for
+	// case-insensitive matching.
+	Regex []string `yaml:"regex,omitempty"`
+
+	// Keywords lists case-insensitive phrases.
+	Keywords []string `yaml:"keywords,omitempty"`
+
+	// Score is the base confidence (0.0-1.0); matches add a small bonus.
+	Score float64 `yaml:"score"`
+
+	// Context is how many characters must follow a match for it to count.
+	Context int `yaml:"context,omitempty"`
+}
+
+// validKnowledgeTypes lists the types a pattern may extract.
+var validKnowledgeTypes = map[types.KnowledgeType]bool{
+	types.KnowledgeTypeDecision:  true,
+	types.KnowledgeTypeSolution:  true,
+	types.KnowledgeTypeLearning:  true,
+	types.KnowledgeTypeFailure:   true,
+	types.KnowledgeTypeReference: true,
+}
+
+// LoadPatternFile reads and validates an extraction pattern file and returns
+// the effective pattern list: DefaultPatterns with the file's patterns
+// merged in, or only the file's patterns when it sets replace.
+func LoadPatternFile(path string) ([]ExtractionPattern, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pf PatternFile
+	if err := yaml.Unmarshal(data, &pf); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	patterns, err := pf.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if pf.Replace {
+		return patterns, nil
+	}
+	return MergePatterns(DefaultPatterns, patterns), nil
+}
+
+// Compile validates the file and compiles its patterns. Every problem is
+// reported, not just the first.
+func (pf *PatternFile) Compile() ([]ExtractionPattern, error) {
+	var errs []error
+	if pf.Version != PatternFileVersion {
+		errs = append(errs, fmt.Errorf("unsupported version %d (expected %d)", pf.Version, PatternFileVersion))
+	}
+	if pf.Replace && len(pf.Patterns) == 0 {
+		errs = append(errs, errors.New("replace is set but no patterns are defined"))
+	}
+
+	seen := make(map[string]bool)
+	patterns := make([]ExtractionPattern, 0, len(pf.Patterns))
+	for i, spec := range pf.Patterns {
+		label := spec.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+		fail := func(field, format string, args ...interface{}) {
+			errs = append(errs, fmt.Errorf("pattern %q field %q: %s", label, field, fmt.Sprintf(format, args...)))
+		}
+
+		if spec.Name == "" {
+			fail("name", "required")
+		} else if seen[spec.Name] {
+			fail("name", "duplicate")
+		}
+		seen[spec.Name] = true
+
+		kind := types.KnowledgeType(strings.ToLower(spec.Type))
+		if !validKnowledgeTypes[kind] {
+			fail("type", "invalid type %q", spec.Type)
+		}
+		if spec.Score < 0 || spec.Score > 1 {
+			fail("score", "must be between 0 and 1")
+		}
+		if spec.Context < 0 {
+			fail("context", "must not be negative")
+		}
+		if len(spec.Regex) == 0 && len(spec.Keywords) == 0 {
+			fail("regex", "a pattern needs at least one regex or keyword")
+		}
+
+		p := ExtractionPattern{
+			Name:       spec.Name,
+			Type:       kind,
+			MinScore:   spec.Score,
+			MinContext: spec.Context,
+		}
+		for _, kw := range spec.Keywords {
+			if strings.TrimSpace(kw) == "" {
+				fail("keywords", "empty keyword")
+				continue
+			}
+			p.Keywords = append(p.Keywords, kw)
+		}
+		for _, expr := range spec.Regex {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				fail("regex", "%v", err)
+				continue
+			}
+			if re.MatchString("") {
+				fail("regex", "%q matches empty text", expr)
+				continue
+			}
+			p.Patterns = append(p.Patterns, re)
+		}
+		patterns = append(patterns, p)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return patterns, nil
+}
+
+// MergePatterns returns base with each extra pattern replacing the base
+// pattern of the same name, and the rest appended in order.
+func MergePatterns(base, extra []ExtractionPattern) []ExtractionPattern {
+	merged := append([]ExtractionPattern(nil), base...)
+	for _, p := range extra {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name != "" && merged[i].Name == p.Name {
+				merged[i] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func writePatternFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "extraction.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPatternFile_Merge(t *testing.T) {
+	path := writePatternFile(t, `version: 1
+patterns:
+  - name: root-cause
+    type: failure
+    regex: ['(?i)root cause:']
+    score: 0.7
+    context: 10
+  - name: learning
+    type: learning
+    keywords: ["TIL", "gotcha:"]
+    score: 0.55
+`)
+	patterns, err := LoadPatternFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != len(DefaultPatterns)+1 {
+		t.Fatalf("got %d patterns, want %d", len(patterns), len(DefaultPatterns)+1)
+	}
+	if patterns[2].Name != "learning" || len(patterns[2].Keywords) != 2 || patterns[2].MinScore != 0.55 {
+		t.Errorf("built-in learning pattern not replaced in place: %+v", patterns[2])
+	}
+	if last := patterns[len(patterns)-1]; last.Name != "root-cause" || last.Type != types.KnowledgeTypeFailure || last.MinContext != 10 {
+		t.Errorf("custom pattern = %+v", last)
+	}
+
+	e := &Extractor{Patterns: patterns}
+	got := e.Explain(types.TranscriptMessage{Content: "Root cause: the cache key ignored the tenant ID."})
+	if len(got) != 1 || got[0].PatternName != "root-cause" || got[0].MatchedPattern == "" {
+		t.Errorf("Explain = %+v", got)
+	}
+	if got := e.Explain(types.TranscriptMessage{Content: "Root cause: tbd"}); len(got) != 0 {
+		t.Errorf("match without enough context should not fire: %+v", got)
+	}
+	if got := e.Extract(types.TranscriptMessage{Content: "gotcha: go test caches results unless -count=1"}); len(got) != 1 || got[0].Type != types.KnowledgeTypeLearning {
+		t.Errorf("Extract = %+v", got)
+	}
+}
+
+func TestLoadPatternFile_Replace(t *testing.T) {
+	path := writePatternFile(t, `version: 1
+replace: true
+patterns:
+  - name: til
+    type: Learning
+    keywords: ["TIL"]
+    score: 0.5
+`)
+	patterns, err := LoadPatternFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 1 || patterns[0].Type != types.KnowledgeTypeLearning {
+		t.Errorf("patterns = %+v", patterns)
+	}
+}
+
+func TestLoadPatternFile_Validation(t *testing.T) {
+	path := writePatternFile(t, `version: 2
+patterns:
+  - name: a
+    type: gossip
+    regex: ['(unclosed']
+    score: 1.5
+  - name: a
+    type: learning
+    score: 0.5
+  - type: decision
+    regex: ['.*']
+    context: -1
+`)
+	_, err := LoadPatternFile(path)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"unsupported version 2",
+		`pattern "a" field "type": invalid type "gossip"`,
+		`pattern "a" field "regex": error parsing regexp`,
+		`pattern "a" field "score"`,
+		`pattern "a" field "name": duplicate`,
+		"at least one regex or keyword",
+		`pattern "#3" field "name": required`,
+		`pattern "#3" field "context"`,
+		"matches empty text",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// MaxLineSize is the longest transcript line the line reader buffers. Longer
+// lines (inline images, huge tool dumps) are treated as malformed.
+const MaxLineSize = 16 * 1024 * 1024
+
+// Position is a resumable point in a transcript: the byte offset just past
+// the last consumed line, its line number, and decoder state that spans
+// lines. It is JSON-encoded in forge checkpoints.
+type Position struct {
+	Offset    int64  `json:"offset"`
+	Line      int    `json:"line"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// LineDecoder is implemented by formats with one self-contained record per
+// line. Such transcripts can be resumed at any line boundary with Stream.
+type LineDecoder interface {
+	// DecodeLine decodes the line numbered pos.Line. It returns nil for
+	// lines that carry no message and may update pos.SessionID.
+	DecodeLine(p *Parser, line []byte, pos *Position) (*types.TranscriptMessage, error)
+}
+
+// ErrLineTooLong is returned for a line longer than MaxLineSize.
+var ErrLineTooLong = errors.New("line exceeds maximum size")
+
+// Resumable reports whether the configured format can be resumed mid-file.
+func (p *Parser) Resumable() bool {
+	_, ok := p.format().(LineDecoder)
+	return ok
+}
+
+// Stream decodes a transcript from r, which must already be positioned at
+// from.Offset, calling emit with each message and the position after it.
+// An emit error stops the stream and is returned. Formats that are not
+// LineDecoders can only be streamed from the start; their messages report
+// the start position until the final one returned.
+func (p *Parser) Stream(r io.Reader, from Position, emit func(types.TranscriptMessage, Position) error) (Position, error) {
+	dec, ok := p.format().(LineDecoder)
+	if !ok {
+		if from.Offset > 0 {
+			return from, fmt.Errorf("%s transcripts cannot resume at offset %d", p.format().Name(), from.Offset)
+		}
+		return p.streamDocument(r, emit)
+	}
+
+	pos := from
+	br := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, n, err := readLine(br)
+		if n == 0 && err == io.EOF {
+			return pos, nil
+		}
+		pos.Offset += int64(n)
+		pos.Line++
+
+		var msg *types.TranscriptMessage
+		switch {
+		case errors.Is(err, ErrLineTooLong):
+			if !p.SkipMalformed {
+				return pos, fmt.Errorf("line %d: %w", pos.Line, err)
+			}
+		case err != nil && err != io.EOF:
+			return pos, fmt.Errorf("read line %d: %w", pos.Line, err)
+		case len(line) > 0:
+			var derr error
+			msg, derr = dec.DecodeLine(p, line, &pos)
+			if derr != nil && !p.SkipMalformed {
+				return pos, fmt.Errorf("line %d: %w", pos.Line, derr)
+			}
+		}
+
+		if msg != nil {
+			if eerr := emit(*msg, pos); eerr != nil {
+				return pos, eerr
+			}
+		}
+		if err == io.EOF {
+			return pos, nil
+		}
+	}
+}
+
+// streamDocument streams a format that must be decoded as a whole.
+func (p *Parser) streamDocument(r io.Reader, emit func(types.TranscriptMessage, Position) error) (Position, error) {
+	counter := &lineCounter{}
+	var pos Position
+	var emitErr error
+	err := p.format().Decode(p, io.TeeReader(r, counter), func(msg types.TranscriptMessage) {
+		if emitErr == nil {
+			emitErr = emit(msg, pos)
+		}
+	})
+	if emitErr != nil {
+		return pos, emitErr
+	}
+	if err != nil {
+		return pos, err
+	}
+	return Position{Offset: counter.bytes, Line: counter.lines}, nil
+}
+
+// decodeLines implements TranscriptFormat.Decode for a LineDecoder.
+func decodeLines(p *Parser, r io.Reader, emit func(types.TranscriptMessage)) error {
+	_, err := p.Stream(r, Position{}, func(msg types.TranscriptMessage, _ Position) error {
+		emit(msg)
+		return nil
+	})
+	return err
+}
+
+// readLine reads one newline-terminated line without the trailing "\n" or
+// "\r\n", returning the number of bytes consumed. Lines over MaxLineSize are
+// drained and reported with ErrLineTooLong.
+func readLine(br *bufio.Reader) ([]byte, int, error) {
+	var line []byte
+	n := 0
+	tooLong := false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		n += len(chunk)
+		if !tooLong {
+			if len(line)+len(chunk) > MaxLineSize {
+				tooLong, line = true, nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if tooLong && (err == nil || err == io.EOF) {
+			err = ErrLineTooLong
+		}
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			line = line[:len(line)-1]
+			if len(line) > 0 && line[len(line)-1] == '\r' {
+				line = line[:len(line)-1]
+			}
+		}
+		return line, n, err
+	}
+}
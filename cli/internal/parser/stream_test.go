@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestStreamResumesAtPosition(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(fixtureDir, "codex-rollout.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewParser()
+	p.Format = codexFormat{}
+
+	var all []types.TranscriptMessage
+	var positions []Position
+	end, err := p.Stream(strings.NewReader(string(data)), Position{}, func(msg types.TranscriptMessage, pos Position) error {
+		all = append(all, msg)
+		positions = append(positions, pos)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end.Offset != int64(len(data)) {
+		t.Errorf("end offset = %d, want %d", end.Offset, len(data))
+	}
+
+	// Stop after the third message, then resume from its position.
+	stop := errors.New("stop")
+	n := 0
+	var at Position
+	_, err = p.Stream(strings.NewReader(string(data)), Position{}, func(_ types.TranscriptMessage, pos Position) error {
+		n++
+		at = pos
+		if n == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || at != positions[2] {
+		t.Fatalf("stopped at %+v with %v", at, err)
+	}
+
+	var rest []types.TranscriptMessage
+	if _, err := p.Stream(strings.NewReader(string(data[at.Offset:])), at, func(msg types.TranscriptMessage, _ Position) error {
+		rest = append(rest, msg)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != len(all)-3 {
+		t.Fatalf("resumed with %d messages, want %d", len(rest), len(all)-3)
+	}
+	for i, msg := range rest {
+		want := all[i+3]
+		if msg.SessionID != want.SessionID || msg.MessageIndex != want.MessageIndex || msg.Content != want.Content {
+			t.Errorf("resumed message %d = %+v, want %+v", i, msg, want)
+		}
+	}
+}
+
+func TestStreamSkipsOversizedLines(t *testing.T) {
+	huge := `{"type":"user","sessionId":"s","message":{"role":"user","content":"` + strings.Repeat("x", MaxLineSize) + `"}}`
+	input := huge + "\n" + `{"type":"user","sessionId":"s","message":{"role":"user","content":"after"}}` + "\n"
+
+	p := NewParser()
+	var got []string
+	end, err := p.Stream(strings.NewReader(input), Position{}, func(msg types.TranscriptMessage, _ Position) error {
+		got = append(got, msg.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "after" || end.Line != 2 || end.Offset != int64(len(input)) {
+		t.Errorf("got %q ending at %+v", got, end)
+	}
+
+	p.SkipMalformed = false
+	if _, err := p.Stream(strings.NewReader(input), Position{}, func(types.TranscriptMessage, Position) error { return nil }); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("expected ErrLineTooLong, got %v", err)
+	}
+}
+
+func TestStreamDocumentFormatsStartOver(t *testing.T) {
+	p := NewParser()
+	p.Format = openAIFormat{}
+	if p.Resumable() {
+		t.Error("openai transcripts should not be resumable")
+	}
+	if _, err := p.Stream(strings.NewReader("[]"), Position{Offset: 10}, nil); err == nil {
+		t.Error("expected an error resuming a document format mid-file")
+	}
+	input := `[{"role":"user","content":"one"}]` + "\n"
+	end, err := p.Stream(strings.NewReader(input), Position{}, func(_ types.TranscriptMessage, pos Position) error {
+		if pos.Offset != 0 {
+			t.Errorf("document message position = %+v, want start", pos)
+		}
+		return nil
+	})
+	if err != nil || end.Offset != int64(len(input)) {
+		t.Errorf("end = %+v, err = %v", end, err)
+	}
+}
@@ -3,7 +3,6 @@
 package pool
 
 import (
-	"bufio"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
@@ -16,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -81,7 +81,7 @@ type ChainEvent struct {
 	// Timestamp is when the event occurred.
 	Timestamp time.Time `json:"timestamp"`
 
-	// Operation is the action taken (add, stage, promote, reject).
+	// Operation is the action taken (see the Op constants).
 	Operation string `json:"operation"`
 
 	// CandidateID is the affected candidate.
@@ -101,6 +101,26 @@ type ChainEvent struct {
 
 	// ArtifactPath is the destination path for promotions.
 	ArtifactPath string `json:"artifact_path,omitempty"`
+
+	// SourcePath is the transcript a candidate was extracted from (add only).
+	SourcePath string `json:"source_path,omitempty"`
+
+	// SessionID is the session a candidate was extracted from (add only).
+	SessionID string `json:"session_id,omitempty"`
+
+	// ID identifies the event; undo events refer to it.
+	ID string `json:"id,omitempty"`
+
+	// Before is the entry as it was before the operation (nil for add).
+	Before *types.PoolEntry `json:"before,omitempty"`
+
+	// After is the entry as the operation left it (nil once it left the
+	// pool, as on promote). Before and After make an event reversible and
+	// let the pool be rebuilt from the chain alone.
+	After *types.PoolEntry `json:"after,omitempty"`
+
+	// Reverts is the ID of the event an undo reverses.
+	Reverts string `json:"reverts,omitempty"`
 }
 
 // Pool manages the candidate pool.
@@ -151,6 +171,10 @@ type ListOptions struct {
 
 	// Limit caps the number of results.
 	Limit int
+
+	// Match, if set, keeps only entries for which it returns true. It is
+	// applied before pagination, so Total counts matching entries.
+	Match func(PoolEntry) bool
 }
 
 // ListResult contains pool entries and pagination metadata.
@@ -213,6 +237,16 @@ func (p *Pool) ListPaginated(opts ListOptions) (*ListResult, error) {
 		entries = filtered
 	}
 
+	if opts.Match != nil {
+		filtered := make([]PoolEntry, 0, len(entries))
+		for _, e := range entries {
+			if opts.Match(e) {
+				filtered = append(filtered, e)
+			}
+		}
+		entries = filtered
+	}
+
 	// Sort by added time (newest first)
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].AddedAt.After(entries[j].AddedAt)
@@ -340,6 +374,7 @@ func (p *Pool) Stage(candidateID string, minTier types.Tier) error {
 	if !isAboveThreshold(entry.Candidate.Tier, minTier) {
 		return fmt.Errorf("candidate tier %s below minimum %s", entry.Candidate.Tier, minTier)
 	}
+	before := entry.PoolEntry
 
 	// Move file atomically
 	newPath := filepath.Join(p.PoolPath, StagedDir, filepath.Base(entry.FilePath))
@@ -359,10 +394,12 @@ func (p *Pool) Stage(candidateID string, minTier types.Tier) error {
 	// Record chain event
 	if err := p.recordEvent(ChainEvent{
 		Timestamp:   time.Now(),
-		Operation:   "stage",
+		Operation:   OpStage,
 		CandidateID: candidateID,
-		FromStatus:  types.PoolStatusPending,
+		FromStatus:  before.Status,
 		ToStatus:    types.PoolStatusStaged,
+		Before:      &before,
+		After:       &entry.PoolEntry,
 	}); err != nil {
 		// Non-fatal, continue
 		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
@@ -426,11 +463,12 @@ func (p *Pool) Promote(candidateID string) (string, error) {
 	// Record chain event
 	if err := p.recordEvent(ChainEvent{
 		Timestamp:    time.Now(),
-		Operation:    "promote",
+		Operation:    OpPromote,
 		CandidateID:  candidateID,
 		FromStatus:   entry.Status,
 		ToStatus:     types.PoolStatusArchived,
 		ArtifactPath: artifactPath,
+		Before:       &entry.PoolEntry,
 	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
 	}
@@ -449,6 +487,7 @@ func (p *Pool) Reject(candidateID, reason, reviewer string) error {
 	if err != nil {
 		return err
 	}
+	before := entry.PoolEntry
 
 	// Move to rejected directory atomically
 	newPath := filepath.Join(p.PoolPath, RejectedDir, filepath.Base(entry.FilePath))
@@ -474,12 +513,14 @@ func (p *Pool) Reject(candidateID, reason, reviewer string) error {
 	// Record chain event
 	if err := p.recordEvent(ChainEvent{
 		Timestamp:   time.Now(),
-		Operation:   "reject",
+		Operation:   OpReject,
 		CandidateID: candidateID,
-		FromStatus:  entry.Status,
+		FromStatus:  before.Status,
 		ToStatus:    types.PoolStatusRejected,
 		Reason:      reason,
 		Reviewer:    reviewer,
+		Before:      &before,
+		After:       &entry.PoolEntry,
 	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
 	}
@@ -503,6 +544,7 @@ func (p *Pool) Approve(candidateID, note, reviewer string) error {
 	if entry.HumanReview != nil && entry.HumanReview.Reviewed {
 		return fmt.Errorf("already reviewed by %s", entry.HumanReview.Reviewer)
 	}
+	before := entry.PoolEntry
 
 	// Update entry with review
 	entry.HumanReview = &types.HumanReview{
@@ -521,10 +563,53 @@ func (p *Pool) Approve(candidateID, note, reviewer string) error {
 	// Record chain event
 	if err := p.recordEvent(ChainEvent{
 		Timestamp:   time.Now(),
-		Operation:   "approve",
+		Operation:   OpApprove,
 		CandidateID: candidateID,
 		Reason:      note,
 		Reviewer:    reviewer,
+		Before:      &before,
+		After:       &entry.PoolEntry,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
+	}
+
+	return nil
+}
+
+// Rescore replaces a candidate's scoring, tier and confidence, for
+// example after the rubric weights or tier thresholds change. The entry
+// keeps its status and directory.
+func (p *Pool) Rescore(candidateID string, scoring types.Scoring, confidence float64) error {
+	entry, err := p.Get(candidateID)
+	if err != nil {
+		return err
+	}
+	before := entry.PoolEntry
+
+	entry.ScoringResult = scoring
+	entry.Candidate.RawScore = scoring.RawScore
+	entry.Candidate.Tier = scoring.TierAssignment
+	entry.Candidate.Confidence = confidence
+	entry.UpdatedAt = time.Now()
+
+	// Follow the new tier's human gate; a completed review is kept
+	if scoring.GateRequired && entry.HumanReview == nil {
+		entry.HumanReview = &types.HumanReview{Reviewed: false}
+	} else if !scoring.GateRequired && entry.HumanReview != nil && !entry.HumanReview.Reviewed {
+		entry.HumanReview = nil
+	}
+
+	if err := p.writeEntry(entry.FilePath, entry); err != nil {
+		return fmt.Errorf("write rescored entry: %w", err)
+	}
+
+	if err := p.recordEvent(ChainEvent{
+		Timestamp:   time.Now(),
+		Operation:   OpRescore,
+		CandidateID: candidateID,
+		Reason:      fmt.Sprintf("%s → %s", before.Candidate.Tier, scoring.TierAssignment),
+		Before:      &before,
+		After:       &entry.PoolEntry,
 	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
 	}
@@ -532,20 +617,20 @@ func (p *Pool) Approve(candidateID, note, reviewer string) error {
 	return nil
 }
 
-// ListPendingReview returns bronze candidates awaiting human review.
+// ListPendingReview returns pending candidates awaiting human review: those
+// whose tier required a human gate when they were scored, in any tier.
 func (p *Pool) ListPendingReview() ([]PoolEntry, error) {
 	entries, err := p.List(ListOptions{
-		Tier:   types.TierBronze,
 		Status: types.PoolStatusPending,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Filter to only those without review
+	// Filter to gated candidates not yet reviewed
 	var pending []PoolEntry
 	for _, e := range entries {
-		if e.HumanReview == nil || !e.HumanReview.Reviewed {
+		if e.HumanReview != nil && !e.HumanReview.Reviewed {
 			pending = append(pending, e)
 		}
 	}
@@ -572,7 +657,8 @@ var ErrThresholdTooLow = fmt.Errorf("threshold must be >= 1h")
 // ErrReasonTooLong is returned when reason/note exceeds MaxReasonLength.
 var ErrReasonTooLong = fmt.Errorf("reason/note exceeds maximum length of %d characters", MaxReasonLength)
 
-// BulkApprove approves all silver candidates older than threshold.
+// BulkApprove approves all silver candidates older than threshold, except
+// those awaiting the human gate, which need an individual review.
 // Returns ErrThresholdTooLow if olderThan < 1h to prevent accidental mass approval.
 func (p *Pool) BulkApprove(olderThan time.Duration, reviewer string, dryRun bool) ([]string, error) {
 	if olderThan < MinBulkApproveThreshold {
@@ -589,6 +675,9 @@ func (p *Pool) BulkApprove(olderThan time.Duration, reviewer string, dryRun bool
 
 	var approved []string
 	for _, entry := range entries {
+		if entry.HumanReview != nil && !entry.HumanReview.Reviewed {
+			continue
+		}
 		if entry.Age >= olderThan {
 			if dryRun {
 				approved = append(approved, entry.Candidate.ID)
@@ -650,9 +739,12 @@ func (p *Pool) AddAt(candidate types.Candidate, scoring types.Scoring, addedAt t
 	// Record chain event
 	if err := p.recordEvent(ChainEvent{
 		Timestamp:   time.Now(),
-		Operation:   "add",
+		Operation:   OpAdd,
 		CandidateID: candidate.ID,
 		ToStatus:    types.PoolStatusPending,
+		After:       &entry,
+		SourcePath:  candidate.Source.TranscriptPath,
+		SessionID:   candidate.Source.SessionID,
 	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to record event: %v\n", err)
 	}
@@ -660,13 +752,13 @@ func (p *Pool) AddAt(candidate types.Candidate, scoring types.Scoring, addedAt t
 	return nil
 }
 
-// writeEntry writes a pool entry to JSON file.
+// writeEntry atomically writes a pool entry to JSON file.
 func (p *Pool) writeEntry(path string, entry *PoolEntry) error {
 	data, err := json.MarshalIndent(entry, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0600)
+	return fsutil.WriteFileAtomic(path, data, 0600)
 }
 
 // writeArtifact writes a promoted candidate as markdown.
@@ -729,59 +821,25 @@ func (p *Pool) writeArtifact(path string, entry *PoolEntry) error {
 	content.WriteString(fmt.Sprintf("- **Message**: %d\n", entry.Candidate.Source.MessageIndex))
 	content.WriteString("\n")
 
-	return os.WriteFile(path, []byte(content.String()), 0600)
+	return fsutil.WriteFileAtomic(path, []byte(content.String()), 0600)
 }
 
 // recordEvent appends an event to the chain file.
-func (p *Pool) recordEvent(event ChainEvent) (err error) {
+func (p *Pool) recordEvent(event ChainEvent) error {
 	chainPath := filepath.Join(p.PoolPath, ChainFile)
-
-	data, err := json.Marshal(event)
-	if err != nil {
-		return err
+	if event.ID == "" {
+		event.ID = newEventID()
 	}
 
-	f, err := os.OpenFile(chainPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if cerr := f.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
-
-	_, err = f.Write(append(data, '\n'))
-	return err
+	// Locked, fsynced append; several agents may share the pool
+	return fsutil.AppendJSONL(chainPath, 0600, event)
 }
 
-// GetChain returns all chain events.
-func (p *Pool) GetChain() (events []ChainEvent, err error) {
-	chainPath := filepath.Join(p.PoolPath, ChainFile)
-
-	f, err := os.Open(chainPath)
-	if os.IsNotExist(err) {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if cerr := f.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		var event ChainEvent
-		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
-			continue
-		}
-		events = append(events, event)
-	}
-
-	return events, scanner.Err()
+// GetChain returns all chain events. Torn lines left by a crashed writer
+// are skipped.
+func (p *Pool) GetChain() ([]ChainEvent, error) {
+	events, _, err := fsutil.LoadJSONL[ChainEvent](filepath.Join(p.PoolPath, ChainFile))
+	return events, err
 }
 
 // isAboveThreshold checks if a tier meets the minimum.
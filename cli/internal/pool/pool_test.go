@@ -1361,3 +1361,32 @@ func TestPoolListPendingReviewFiltersReviewed(t *testing.T) {
 		t.Errorf("expected review-pending, got %s", pending[0].Candidate.ID)
 	}
 }
+
+func TestPoolListMatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	p := NewPool(tmpDir)
+
+	for _, c := range []types.Candidate{
+		{ID: "high", Tier: types.TierGold, Content: "useful", Utility: 0.9},
+		{ID: "low-1", Tier: types.TierBronze, Content: "meh", Utility: 0.2},
+		{ID: "low-2", Tier: types.TierBronze, Content: "meh", Utility: 0.1},
+	} {
+		if err := p.Add(c, types.Scoring{}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	result, err := p.ListPaginated(ListOptions{
+		Limit: 1,
+		Match: func(e PoolEntry) bool { return e.Candidate.Utility < 0.5 },
+	})
+	if err != nil {
+		t.Fatalf("ListPaginated failed: %v", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("expected Total to count matching entries (2), got %d", result.Total)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Candidate.ID == "high" {
+		t.Errorf("unexpected entries: %+v", result.Entries)
+	}
+}
@@ -0,0 +1,276 @@
+package pool
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// Chain operations.
+const (
+	OpAdd     = "add"
+	OpStage   = "stage"
+	OpPromote = "promote"
+	OpReject  = "reject"
+	OpApprove = "approve"
+	OpRescore = "rescore"
+
+	// OpUndo reverses the event named by ChainEvent.Reverts.
+	OpUndo = "undo"
+)
+
+// ErrNotUndoable is returned for events that cannot be reversed: undo
+// events themselves, events already undone, and events recorded before
+// the chain carried entry snapshots.
+var ErrNotUndoable = errors.New("event cannot be undone")
+
+// newEventID returns a unique chain event ID.
+func newEventID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("evt-%d", time.Now().UnixNano())
+	}
+	return "evt-" + hex.EncodeToString(b[:])
+}
+
+// statusDir returns the pool directory holding entries with status.
+func statusDir(status types.PoolStatus) string {
+	switch status {
+	case types.PoolStatusStaged:
+		return StagedDir
+	case types.PoolStatusRejected:
+		return RejectedDir
+	case types.PoolStatusArchived:
+		return ValidatedDir
+	default:
+		return PendingDir
+	}
+}
+
+// replayable reports whether e carries the snapshots needed to apply or
+// reverse it.
+func (e ChainEvent) replayable() bool {
+	switch e.Operation {
+	case OpAdd:
+		return e.After != nil
+	case OpPromote:
+		return e.Before != nil
+	case OpUndo:
+		return e.Reverts != ""
+	default:
+		return e.Before != nil && e.After != nil
+	}
+}
+
+// Undoable returns the events that can still be undone, newest first.
+func (p *Pool) Undoable() ([]ChainEvent, error) {
+	events, err := p.GetChain()
+	if err != nil {
+		return nil, err
+	}
+	reverted := make(map[string]bool)
+	for _, e := range events {
+		if e.Operation == OpUndo {
+			reverted[e.Reverts] = true
+		}
+	}
+	var undoable []ChainEvent
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if e.Operation != OpUndo && e.ID != "" && !reverted[e.ID] && e.replayable() {
+			undoable = append(undoable, e)
+		}
+	}
+	return undoable, nil
+}
+
+// Undo reverses a recorded operation: the entry is put back as it was
+// before the event (or removed, for an add), and a promoted artifact is
+// deleted. The candidate must still be as the event left it, so later
+// operations on it have to be undone first. The undo is itself recorded.
+func (p *Pool) Undo(eventID, reviewer string) (*ChainEvent, error) {
+	undoable, err := p.Undoable()
+	if err != nil {
+		return nil, err
+	}
+	var event *ChainEvent
+	for i := range undoable {
+		if undoable[i].ID == eventID {
+			event = &undoable[i]
+			break
+		}
+	}
+	if event == nil {
+		return nil, fmt.Errorf("%s: %w", eventID, ErrNotUndoable)
+	}
+
+	// Refuse if the candidate moved on since the event
+	current, err := p.Get(event.CandidateID)
+	if err != nil {
+		current = nil
+	}
+	switch {
+	case event.After == nil && current != nil:
+		return nil, fmt.Errorf("undo %s %s: candidate is back in the pool as %s; undo later events first", event.Operation, event.CandidateID, current.Status)
+	case event.After != nil && current == nil:
+		return nil, fmt.Errorf("undo %s %s: candidate is no longer in the pool; undo later events first", event.Operation, event.CandidateID)
+	case event.After != nil && (current.Status != event.After.Status || !current.UpdatedAt.Equal(event.After.UpdatedAt)):
+		return nil, fmt.Errorf("undo %s %s: candidate changed since (now %s); undo later events first", event.Operation, event.CandidateID, current.Status)
+	}
+
+	if event.Before != nil {
+		path := filepath.Join(p.PoolPath, statusDir(event.Before.Status), event.CandidateID+".json")
+		if err := p.writeEntry(path, &PoolEntry{PoolEntry: *event.Before}); err != nil {
+			return nil, fmt.Errorf("restore entry: %w", err)
+		}
+		if current != nil && current.FilePath != path {
+			if err := os.Remove(current.FilePath); err != nil {
+				return nil, fmt.Errorf("remove entry: %w", err)
+			}
+		}
+	} else if current != nil {
+		if err := os.Remove(current.FilePath); err != nil {
+			return nil, fmt.Errorf("remove entry: %w", err)
+		}
+	}
+
+	if event.Operation == OpPromote && event.ArtifactPath != "" {
+		if err := os.Remove(event.ArtifactPath); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove promoted artifact: %w", err)
+		}
+	}
+
+	undo := ChainEvent{
+		Timestamp:    time.Now(),
+		Operation:    OpUndo,
+		CandidateID:  event.CandidateID,
+		Reviewer:     reviewer,
+		Reason:       "undo " + event.Operation,
+		ArtifactPath: event.ArtifactPath,
+		Reverts:      event.ID,
+		Before:       event.After,
+		After:        event.Before,
+	}
+	if event.After != nil {
+		undo.FromStatus = event.After.Status
+	}
+	if event.Before != nil {
+		undo.ToStatus = event.Before.Status
+	}
+	if err := p.recordEvent(undo); err != nil {
+		return nil, fmt.Errorf("record undo: %w", err)
+	}
+	return &undo, nil
+}
+
+// Drift is one difference between the pool on disk and the pool rebuilt
+// from the chain.
+type Drift struct {
+	CandidateID string `json:"candidate_id"`
+
+	// Expected is the status according to the chain ("" if absent).
+	Expected types.PoolStatus `json:"expected,omitempty"`
+
+	// Actual is the status on disk ("" if absent).
+	Actual types.PoolStatus `json:"actual,omitempty"`
+
+	Detail string `json:"detail"`
+}
+
+// ReplayReport summarizes a chain replay.
+type ReplayReport struct {
+	// Events is the number of chain events read.
+	Events int `json:"events"`
+
+	// Entries is the number of entries the chain says are in the pool.
+	Entries int `json:"entries"`
+
+	// Unreplayable counts events recorded without entry snapshots. Their
+	// candidates are left out of the drift comparison.
+	Unreplayable int `json:"unreplayable,omitempty"`
+
+	Drift []Drift `json:"drift,omitempty"`
+}
+
+// Replay rebuilds the pool purely from the chain and compares it with the
+// pool on disk. If outDir is set, the rebuilt entries are written there in
+// the pool's directory layout.
+func (p *Pool) Replay(outDir string) (*ReplayReport, error) {
+	events, err := p.GetChain()
+	if err != nil {
+		return nil, err
+	}
+	report := &ReplayReport{Events: len(events)}
+
+	expected := make(map[string]*types.PoolEntry)
+	legacy := make(map[string]bool)
+	for _, e := range events {
+		if !e.replayable() {
+			report.Unreplayable++
+			legacy[e.CandidateID] = true
+			continue
+		}
+		if e.After == nil {
+			delete(expected, e.CandidateID)
+		} else {
+			expected[e.CandidateID] = e.After
+		}
+	}
+	report.Entries = len(expected)
+
+	if outDir != "" {
+		for id, entry := range expected {
+			path := filepath.Join(outDir, statusDir(entry.Status), id+".json")
+			if err := p.writeEntry(path, &PoolEntry{PoolEntry: *entry}); err != nil {
+				return nil, fmt.Errorf("write rebuilt entry: %w", err)
+			}
+		}
+	}
+
+	onDisk, err := p.List(ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	actual := make(map[string]*types.PoolEntry, len(onDisk))
+	for i := range onDisk {
+		actual[onDisk[i].Candidate.ID] = &onDisk[i].PoolEntry
+	}
+
+	ids := make(map[string]bool)
+	for id := range expected {
+		ids[id] = true
+	}
+	for id := range actual {
+		ids[id] = true
+	}
+	for id := range ids {
+		if legacy[id] {
+			continue
+		}
+		want, have := expected[id], actual[id]
+		switch {
+		case have == nil:
+			report.Drift = append(report.Drift, Drift{CandidateID: id, Expected: want.Status, Detail: "missing from pool"})
+		case want == nil:
+			report.Drift = append(report.Drift, Drift{CandidateID: id, Actual: have.Status, Detail: "not in chain"})
+		case want.Status != have.Status:
+			report.Drift = append(report.Drift, Drift{CandidateID: id, Expected: want.Status, Actual: have.Status, Detail: "status differs"})
+		default:
+			wantJSON, _ := json.Marshal(want)
+			haveJSON, _ := json.Marshal(have)
+			if string(wantJSON) != string(haveJSON) {
+				report.Drift = append(report.Drift, Drift{CandidateID: id, Expected: want.Status, Actual: have.Status, Detail: "content differs"})
+			}
+		}
+	}
+	sort.Slice(report.Drift, func(i, j int) bool { return report.Drift[i].CandidateID < report.Drift[j].CandidateID })
+	return report, nil
+}
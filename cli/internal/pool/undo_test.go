@@ -0,0 +1,181 @@
+package pool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func addUndoCandidate(t *testing.T, p *Pool, id string, tier types.Tier) {
+	t.Helper()
+	c := types.Candidate{ID: id, Type: types.KnowledgeTypeLearning, Tier: tier, Content: "content " + id}
+	if err := p.Add(c, types.Scoring{RawScore: 0.6}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func undoNewest(t *testing.T, p *Pool) *ChainEvent {
+	t.Helper()
+	undoable, err := p.Undoable()
+	if err != nil || len(undoable) == 0 {
+		t.Fatalf("Undoable = %v, %v", undoable, err)
+	}
+	undo, err := p.Undo(undoable[0].ID, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return undo
+}
+
+func TestUndo_ReversesEachOperation(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addUndoCandidate(t, p, "c1", types.TierSilver)
+
+	// approve → undo restores the unreviewed entry
+	if err := p.Approve("c1", "ok", "tester"); err != nil {
+		t.Fatal(err)
+	}
+	undoNewest(t, p)
+	if e, err := p.Get("c1"); err != nil || e.HumanReview != nil {
+		t.Fatalf("after undo approve: %+v %v", e, err)
+	}
+
+	// stage → undo moves it back to pending
+	if err := p.Stage("c1", types.TierBronze); err != nil {
+		t.Fatal(err)
+	}
+	undoNewest(t, p)
+	if e, err := p.Get("c1"); err != nil || e.Status != types.PoolStatusPending || filepath.Base(filepath.Dir(e.FilePath)) != PendingDir {
+		t.Fatalf("after undo stage: %+v %v", e, err)
+	}
+	if _, err := os.Stat(filepath.Join(p.PoolPath, StagedDir, "c1.json")); !os.IsNotExist(err) {
+		t.Error("staged copy left behind")
+	}
+
+	// reject → undo
+	if err := p.Reject("c1", "dup", "tester"); err != nil {
+		t.Fatal(err)
+	}
+	undoNewest(t, p)
+	if e, err := p.Get("c1"); err != nil || e.Status != types.PoolStatusPending {
+		t.Fatalf("after undo reject: %+v %v", e, err)
+	}
+
+	// promote → undo restores the entry and deletes the artifact
+	artifact, err := p.Promote("c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	undo := undoNewest(t, p)
+	if undo.Reverts == "" || undo.Operation != OpUndo {
+		t.Errorf("undo event = %+v", undo)
+	}
+	if _, err := os.Stat(artifact); !os.IsNotExist(err) {
+		t.Error("promoted artifact not removed")
+	}
+	if _, err := p.Get("c1"); err != nil {
+		t.Fatalf("after undo promote: %v", err)
+	}
+
+	// add → undo removes the candidate
+	for {
+		undoable, _ := p.Undoable()
+		if len(undoable) == 0 {
+			break
+		}
+		if _, err := p.Undo(undoable[0].ID, "tester"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := p.Get("c1"); err == nil {
+		t.Error("candidate still in pool after undoing its add")
+	}
+
+	report, err := p.Replay("")
+	if err != nil || len(report.Drift) != 0 || report.Entries != 0 {
+		t.Errorf("replay after undo: %+v %v", report, err)
+	}
+}
+
+func TestUndo_RefusesOutOfOrder(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addUndoCandidate(t, p, "c1", types.TierSilver)
+	if err := p.Stage("c1", types.TierBronze); err != nil {
+		t.Fatal(err)
+	}
+
+	undoable, _ := p.Undoable()
+	add := undoable[len(undoable)-1]
+	if _, err := p.Undo(add.ID, "tester"); err == nil {
+		t.Fatal("undoing add under a later stage should fail")
+	}
+
+	undoNewest(t, p)
+	undo := undoNewest(t, p)
+	if _, err := p.Undo(undo.Reverts, "tester"); !errors.Is(err, ErrNotUndoable) {
+		t.Errorf("second undo of the same event: %v", err)
+	}
+}
+
+func TestReplay_DetectsDrift(t *testing.T) {
+	p := NewPool(t.TempDir())
+	for _, id := range []string{"c1", "c2", "c3", "c4"} {
+		addUndoCandidate(t, p, id, types.TierSilver)
+	}
+	if err := p.Stage("c2", types.TierBronze); err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	report, err := p.Replay(out)
+	if err != nil || len(report.Drift) != 0 || report.Entries != 4 {
+		t.Fatalf("clean replay: %+v %v", report, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, StagedDir, "c2.json")); err != nil {
+		t.Errorf("rebuilt pool missing staged entry: %v", err)
+	}
+
+	// Tamper outside ao: delete, move, edit, and add an unknown entry
+	pending := filepath.Join(p.PoolPath, PendingDir)
+	_ = os.Remove(filepath.Join(pending, "c1.json"))
+	_ = os.Rename(filepath.Join(pending, "c3.json"), filepath.Join(p.PoolPath, RejectedDir, "c3.json"))
+	_ = os.WriteFile(filepath.Join(pending, "stray.json"), []byte(`{"candidate":{"id":"stray"},"status":"pending"}`), 0600)
+	e4, _ := p.Get("c4")
+	e4.Candidate.Content = "edited by hand"
+	if err := p.writeEntry(e4.FilePath, e4); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = p.Replay("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"c1": "missing from pool", "c3": "status differs", "c4": "content differs", "stray": "not in chain"}
+	if len(report.Drift) != len(want) {
+		t.Fatalf("drift = %+v", report.Drift)
+	}
+	for _, d := range report.Drift {
+		if want[d.CandidateID] != d.Detail {
+			t.Errorf("drift %s = %q, want %q", d.CandidateID, d.Detail, want[d.CandidateID])
+		}
+	}
+}
+
+func TestReplay_SkipsLegacyEvents(t *testing.T) {
+	p := NewPool(t.TempDir())
+	addUndoCandidate(t, p, "c1", types.TierSilver)
+	if err := p.recordEvent(ChainEvent{Operation: OpStage, CandidateID: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	report, err := p.Replay("")
+	if err != nil || report.Unreplayable != 1 || len(report.Drift) != 0 {
+		t.Errorf("replay: %+v %v", report, err)
+	}
+	undoable, _ := p.Undoable()
+	if len(undoable) != 1 || undoable[0].CandidateID != "c1" {
+		t.Errorf("legacy event should not be undoable: %+v", undoable)
+	}
+}
@@ -0,0 +1,456 @@
+package provenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// Node kinds in a lineage graph.
+const (
+	// NodeTranscript is a source transcript file.
+	NodeTranscript = "transcript"
+
+	// NodeArtifact is any other file: session summaries, learnings, patterns.
+	NodeArtifact = "artifact"
+
+	// NodeCandidate is a pool candidate, identified by candidate ID.
+	NodeCandidate = "candidate"
+
+	// NodeSession is a conversation, identified by session ID.
+	NodeSession = "session"
+)
+
+// Edge kinds in a lineage graph. Edges point from input to output, so an
+// artifact's ancestors are what produced it and its descendants are what
+// depends on it.
+const (
+	// EdgeDerived links a provenance record's source to its artifact.
+	EdgeDerived = "derived"
+
+	// EdgeExtracted links a transcript to a candidate extracted from it.
+	EdgeExtracted = "extracted"
+
+	// EdgePromoted links a pool candidate to the artifact it became.
+	EdgePromoted = "promoted"
+
+	// EdgeCited links an artifact to a session that cited it.
+	EdgeCited = "cited"
+
+	// EdgeRecorded links a session to its transcript.
+	EdgeRecorded = "recorded"
+)
+
+// Direction selects which way lineage queries walk.
+type Direction int
+
+const (
+	// Upstream walks from an artifact to what produced it.
+	Upstream Direction = iota
+
+	// Downstream walks from an artifact to what depends on it.
+	Downstream
+
+	// Both walks in both directions.
+	Both
+)
+
+// Node is a vertex in the lineage graph.
+type Node struct {
+	// ID is unique within the graph: an absolute path for files,
+	// "candidate:<id>" or "session:<id>" otherwise.
+	ID string `json:"id"`
+
+	// Kind is one of the Node* constants.
+	Kind string `json:"kind"`
+
+	// Label is a short display name.
+	Label string `json:"label"`
+
+	// Type is the artifact type from provenance records, if known.
+	Type string `json:"type,omitempty"`
+}
+
+// Edge is a directed link from an input node to an output node.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Kind is one of the Edge* constants.
+	Kind string `json:"kind"`
+
+	// Ref identifies the record behind the edge (provenance ID, etc).
+	Ref string `json:"ref,omitempty"`
+}
+
+// Lineage is a DAG over provenance records, citations and pool events.
+// Cycles (a session citing an artifact its own transcript produced) are
+// tolerated: every walk tracks visited nodes.
+type Lineage struct {
+	nodes map[string]*Node
+	out   map[string][]Edge
+	in    map[string][]Edge
+	seen  map[Edge]bool
+}
+
+// NewLineage returns an empty lineage graph.
+func NewLineage() *Lineage {
+	return &Lineage{
+		nodes: make(map[string]*Node),
+		out:   make(map[string][]Edge),
+		in:    make(map[string][]Edge),
+		seen:  make(map[Edge]bool),
+	}
+}
+
+// AddRecord adds a provenance record: source → artifact, plus
+// session → transcript when the source is a transcript of a known session.
+func (l *Lineage) AddRecord(r Record) {
+	if r.ArtifactPath == "" || r.SourcePath == "" {
+		return
+	}
+	sourceKind := NodeArtifact
+	if r.SourceType == NodeTranscript {
+		sourceKind = NodeTranscript
+	}
+	source := l.fileNode(r.SourcePath, sourceKind, r.SourceType)
+	artifact := l.fileNode(r.ArtifactPath, NodeArtifact, r.ArtifactType)
+	l.addEdge(source.ID, artifact.ID, EdgeDerived, r.ID)
+	if sourceKind == NodeTranscript && r.SessionID != "" {
+		l.addEdge(l.sessionNode(r.SessionID).ID, source.ID, EdgeRecorded, "")
+	}
+}
+
+// AddCitation adds artifact → session for a citation event.
+func (l *Lineage) AddCitation(c types.CitationEvent) {
+	if c.ArtifactPath == "" || c.SessionID == "" {
+		return
+	}
+	artifact := l.fileNode(c.ArtifactPath, NodeArtifact, "")
+	l.addEdge(artifact.ID, l.sessionNode(c.SessionID).ID, EdgeCited, c.CitationType)
+}
+
+// AddCandidate adds transcript → candidate for a pool candidate.
+func (l *Lineage) AddCandidate(candidateID, transcriptPath, sessionID string) {
+	if candidateID == "" {
+		return
+	}
+	candidate := l.candidateNode(candidateID)
+	if transcriptPath == "" {
+		return
+	}
+	transcript := l.fileNode(transcriptPath, NodeTranscript, NodeTranscript)
+	l.addEdge(transcript.ID, candidate.ID, EdgeExtracted, "")
+	if sessionID != "" {
+		l.addEdge(l.sessionNode(sessionID).ID, transcript.ID, EdgeRecorded, "")
+	}
+}
+
+// AddPromotion adds candidate → artifact for a pool promotion.
+func (l *Lineage) AddPromotion(candidateID, artifactPath string) {
+	if candidateID == "" || artifactPath == "" {
+		return
+	}
+	artifact := l.fileNode(artifactPath, NodeArtifact, "")
+	l.addEdge(l.candidateNode(candidateID).ID, artifact.ID, EdgePromoted, "")
+}
+
+// Node returns the node with the given ID.
+func (l *Lineage) Node(id string) (*Node, bool) {
+	n, ok := l.nodes[id]
+	return n, ok
+}
+
+// Nodes returns all nodes sorted by ID.
+func (l *Lineage) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(l.nodes))
+	for _, n := range l.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+// Edges returns all edges sorted by endpoints.
+func (l *Lineage) Edges() []Edge {
+	var edges []Edge
+	for _, out := range l.out {
+		edges = append(edges, out...)
+	}
+	sortEdges(edges)
+	return edges
+}
+
+// Inputs returns the edges into id, sorted.
+func (l *Lineage) Inputs(id string) []Edge {
+	edges := append([]Edge(nil), l.in[id]...)
+	sortEdges(edges)
+	return edges
+}
+
+// Outputs returns the edges out of id, sorted.
+func (l *Lineage) Outputs(id string) []Edge {
+	edges := append([]Edge(nil), l.out[id]...)
+	sortEdges(edges)
+	return edges
+}
+
+// Resolve finds the nodes a user query refers to: a node ID, a file path,
+// a candidate or session ID, or (failing those) a file name.
+func (l *Lineage) Resolve(query string) []*Node {
+	for _, id := range []string{query, fileID(query), candidatePrefix + query, sessionPrefix + query} {
+		if n, ok := l.nodes[id]; ok {
+			return []*Node{n}
+		}
+	}
+	var matches []*Node
+	base := filepath.Base(query)
+	for _, n := range l.Nodes() {
+		if (n.Kind == NodeArtifact || n.Kind == NodeTranscript) && filepath.Base(n.ID) == base {
+			matches = append(matches, n)
+		}
+	}
+	return matches
+}
+
+// Ancestors returns every node id transitively depends on, nearest first.
+func (l *Lineage) Ancestors(id string) []*Node {
+	return l.walk(id, Upstream)
+}
+
+// Dependents returns every node that transitively depends on id, nearest
+// first: what is affected if id is deleted.
+func (l *Lineage) Dependents(id string) []*Node {
+	return l.walk(id, Downstream)
+}
+
+// Sources returns the transcripts that ultimately produced id.
+func (l *Lineage) Sources(id string) []*Node {
+	var sources []*Node
+	for _, n := range l.Ancestors(id) {
+		if n.Kind == NodeTranscript {
+			sources = append(sources, n)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].ID < sources[j].ID })
+	return sources
+}
+
+// Subgraph returns the lineage reachable from ids in the given direction,
+// including the ids themselves.
+func (l *Lineage) Subgraph(ids []string, dir Direction) *Lineage {
+	keep := make(map[string]bool)
+	for _, id := range ids {
+		if _, ok := l.nodes[id]; !ok {
+			continue
+		}
+		keep[id] = true
+		if dir == Upstream || dir == Both {
+			for _, n := range l.walk(id, Upstream) {
+				keep[n.ID] = true
+			}
+		}
+		if dir == Downstream || dir == Both {
+			for _, n := range l.walk(id, Downstream) {
+				keep[n.ID] = true
+			}
+		}
+	}
+
+	sub := NewLineage()
+	for id := range keep {
+		n := *l.nodes[id]
+		sub.nodes[id] = &n
+	}
+	for _, e := range l.Edges() {
+		if keep[e.From] && keep[e.To] {
+			sub.addEdge(e.From, e.To, e.Kind, e.Ref)
+		}
+	}
+	return sub
+}
+
+// walk returns the nodes reachable from id (excluding id) breadth-first.
+func (l *Lineage) walk(id string, dir Direction) []*Node {
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+	var result []*Node
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		edges := l.Outputs(cur)
+		if dir == Upstream {
+			edges = l.Inputs(cur)
+		}
+		for _, e := range edges {
+			next := e.To
+			if dir == Upstream {
+				next = e.From
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			result = append(result, l.nodes[next])
+			queue = append(queue, next)
+		}
+	}
+	return result
+}
+
+// LineageExport is the JSON form of a lineage graph.
+type LineageExport struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+}
+
+// WriteJSON writes the graph as indented JSON.
+func (l *Lineage) WriteJSON(w io.Writer) error {
+	export := LineageExport{Nodes: l.Nodes(), Edges: l.Edges()}
+	if export.Edges == nil {
+		export.Edges = []Edge{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// dotShapes styles each node kind in Graphviz output.
+var dotShapes = map[string]string{
+	NodeTranscript: "note",
+	NodeArtifact:   "box",
+	NodeCandidate:  "ellipse",
+	NodeSession:    "diamond",
+}
+
+// WriteDOT writes the graph in Graphviz DOT format.
+func (l *Lineage) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph lineage {\n  rankdir=LR;\n")
+	for _, n := range l.Nodes() {
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(n.Label), dotShapes[n.Kind])
+	}
+	for _, e := range l.Edges() {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Kind))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart.
+func (l *Lineage) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := make(map[string]string, len(l.nodes))
+	for i, n := range l.Nodes() {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		label := mermaidEscape(n.Label)
+		switch n.Kind {
+		case NodeTranscript:
+			fmt.Fprintf(&b, "  %s[/\"%s\"/]\n", ids[n.ID], label)
+		case NodeCandidate:
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", ids[n.ID], label)
+		case NodeSession:
+			fmt.Fprintf(&b, "  %s{{\"%s\"}}\n", ids[n.ID], label)
+		default:
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[n.ID], label)
+		}
+	}
+	for _, e := range l.Edges() {
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[e.From], e.Kind, ids[e.To])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+const (
+	candidatePrefix = "candidate:"
+	sessionPrefix   = "session:"
+)
+
+// fileID normalizes a path so the same file recorded as relative and
+// absolute maps to one node.
+func fileID(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+func (l *Lineage) fileNode(path, kind, typ string) *Node {
+	id := fileID(path)
+	n, ok := l.nodes[id]
+	if !ok {
+		n = &Node{ID: id, Kind: kind, Label: filepath.Base(path)}
+		l.nodes[id] = n
+	}
+	// A file first seen as an output may later turn out to be a transcript
+	if kind == NodeTranscript {
+		n.Kind = NodeTranscript
+	}
+	if n.Type == "" {
+		n.Type = typ
+	}
+	return n
+}
+
+func (l *Lineage) candidateNode(candidateID string) *Node {
+	return l.idNode(candidatePrefix+candidateID, NodeCandidate, candidateID)
+}
+
+func (l *Lineage) sessionNode(sessionID string) *Node {
+	return l.idNode(sessionPrefix+sessionID, NodeSession, "session "+sessionID)
+}
+
+func (l *Lineage) idNode(id, kind, label string) *Node {
+	n, ok := l.nodes[id]
+	if !ok {
+		n = &Node{ID: id, Kind: kind, Label: label}
+		l.nodes[id] = n
+	}
+	return n
+}
+
+func (l *Lineage) addEdge(from, to, kind, ref string) {
+	if from == to {
+		return
+	}
+	e := Edge{From: from, To: to, Kind: kind, Ref: ref}
+	if l.seen[e] {
+		return
+	}
+	l.seen[e] = true
+	l.out[from] = append(l.out[from], e)
+	l.in[to] = append(l.in[to], e)
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Ref < b.Ref
+	})
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
@@ -0,0 +1,129 @@
+package provenance
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// testLineage builds: t1 → cand-1 → L1 (promoted), L1 cited by sess-2,
+// sess-2 recorded in t2, t2 → session summary S2 and → cand-2 → P1.
+func testLineage() *Lineage {
+	l := NewLineage()
+	l.AddCandidate("cand-1", "/in/t1.jsonl", "sess-1")
+	l.AddPromotion("cand-1", "/out/learnings/L1.md")
+	l.AddCitation(types.CitationEvent{ArtifactPath: "/out/learnings/L1.md", SessionID: "sess-2", CitationType: "retrieved"})
+	l.AddRecord(Record{ID: "prov-2", ArtifactPath: "/out/sessions/S2.md", ArtifactType: "session", SourcePath: "/in/t2.jsonl", SourceType: "transcript", SessionID: "sess-2"})
+	l.AddCandidate("cand-2", "/in/t2.jsonl", "sess-2")
+	l.AddPromotion("cand-2", "/out/patterns/P1.md")
+	return l
+}
+
+func ids(nodes []*Node) []string {
+	out := make([]string, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.ID
+	}
+	return out
+}
+
+func TestLineage_SourcesAcrossHops(t *testing.T) {
+	l := testLineage()
+	got := ids(l.Sources("/out/patterns/P1.md"))
+	want := []string{"/in/t1.jsonl", "/in/t2.jsonl"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Sources = %v, want %v", got, want)
+	}
+
+	ancestors := ids(l.Ancestors("/out/patterns/P1.md"))
+	if ancestors[0] != "candidate:cand-2" {
+		t.Errorf("nearest ancestor = %s, want candidate:cand-2", ancestors[0])
+	}
+}
+
+func TestLineage_Dependents(t *testing.T) {
+	l := testLineage()
+	got := strings.Join(ids(l.Dependents("/out/learnings/L1.md")), ",")
+	for _, want := range []string{"session:sess-2", "/in/t2.jsonl", "/out/sessions/S2.md", "candidate:cand-2", "/out/patterns/P1.md"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Dependents = %s, missing %s", got, want)
+		}
+	}
+	if strings.Contains(got, "/in/t1.jsonl") {
+		t.Errorf("Dependents = %s, must not include upstream t1", got)
+	}
+
+	if deps := l.Dependents("/out/patterns/P1.md"); len(deps) != 0 {
+		t.Errorf("leaf has dependents: %v", ids(deps))
+	}
+}
+
+func TestLineage_CycleTerminates(t *testing.T) {
+	l := NewLineage()
+	l.AddRecord(Record{ID: "p", ArtifactPath: "/out/A.md", SourcePath: "/in/t.jsonl", SourceType: "transcript", SessionID: "s"})
+	l.AddCitation(types.CitationEvent{ArtifactPath: "/out/A.md", SessionID: "s"})
+	if got := len(l.Ancestors("/out/A.md")); got != 2 {
+		t.Errorf("Ancestors = %d nodes, want 2", got)
+	}
+}
+
+func TestLineage_Resolve(t *testing.T) {
+	l := testLineage()
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"/out/learnings/L1.md", "/out/learnings/L1.md"},
+		{"L1.md", "/out/learnings/L1.md"},
+		{"cand-2", "candidate:cand-2"},
+		{"sess-2", "session:sess-2"},
+	}
+	for _, tt := range tests {
+		got := l.Resolve(tt.query)
+		if len(got) != 1 || got[0].ID != tt.want {
+			t.Errorf("Resolve(%q) = %v, want %s", tt.query, ids(got), tt.want)
+		}
+	}
+	if got := l.Resolve("missing.md"); len(got) != 0 {
+		t.Errorf("Resolve(missing) = %v", ids(got))
+	}
+}
+
+func TestLineage_Export(t *testing.T) {
+	sub := testLineage().Subgraph([]string{"/out/learnings/L1.md"}, Upstream)
+	if got := len(sub.Nodes()); got != 4 {
+		t.Fatalf("upstream subgraph has %d nodes, want 4 (L1, cand-1, t1, sess-1)", got)
+	}
+
+	var dot bytes.Buffer
+	if err := sub.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(dot.String(), "digraph lineage {") ||
+		!strings.Contains(dot.String(), `"candidate:cand-1" -> "/out/learnings/L1.md" [label="promoted"];`) {
+		t.Errorf("DOT output:\n%s", dot.String())
+	}
+
+	var mermaid bytes.Buffer
+	if err := sub.WriteMermaid(&mermaid); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mermaid.String(), "flowchart LR\n") || !strings.Contains(mermaid.String(), "-->|promoted|") {
+		t.Errorf("Mermaid output:\n%s", mermaid.String())
+	}
+
+	var out bytes.Buffer
+	if err := sub.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var export LineageExport
+	if err := json.Unmarshal(out.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Nodes) != 4 || len(export.Edges) != 3 {
+		t.Errorf("JSON export has %d nodes, %d edges", len(export.Nodes), len(export.Edges))
+	}
+}
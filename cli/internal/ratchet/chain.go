@@ -2,14 +2,17 @@ package ratchet
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"syscall"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 )
 
 const (
@@ -42,8 +45,10 @@ func LoadChain(startDir string) (*Chain, error) {
 
 	// Try new location first
 	chainPath := filepath.Join(agentsDir, "ao", ChainFile)
+	keyDir := filepath.Join(agentsDir, "ao", KeyDir)
 	if chain, err := loadJSONLChain(chainPath); err == nil {
 		chain.path = chainPath
+		chain.keyDir = keyDir
 		return chain, nil
 	}
 
@@ -51,6 +56,7 @@ func LoadChain(startDir string) (*Chain, error) {
 	legacyPath := filepath.Join(agentsDir, "provenance", LegacyChainFile)
 	if chain, err := loadLegacyYAMLChain(legacyPath); err == nil {
 		chain.path = chainPath // Will write to new location
+		chain.keyDir = keyDir
 		fmt.Fprintf(os.Stderr, "Note: Migrating chain from %s to %s\n", legacyPath, chainPath)
 		return chain, nil
 	}
@@ -61,6 +67,7 @@ func LoadChain(startDir string) (*Chain, error) {
 		Started: time.Now(),
 		Entries: []ChainEntry{},
 		path:    chainPath,
+		keyDir:  keyDir,
 	}, nil
 }
 
@@ -177,117 +184,67 @@ func loadLegacyYAMLChain(path string) (*Chain, error) {
 }
 
 // Save writes the chain to disk using JSONL format with file locking.
+// The file is replaced atomically, so a crash leaves the old chain intact.
 func (c *Chain) Save() error {
 	if c.path == "" {
 		return fmt.Errorf("chain has no path set")
 	}
 
-	// Ensure directory exists
-	dir := filepath.Dir(c.path)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("create chain directory: %w", err)
-	}
-
-	// Open file with exclusive lock
-	f, err := os.OpenFile(c.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("open chain file: %w", err)
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // sync already done via lock release
-	}()
-
-	// Acquire exclusive lock
-	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
-		return fmt.Errorf("lock chain file: %w", err)
-	}
-	defer func() {
-		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) //nolint:errcheck // unlock best-effort
-	}()
-
-	// Write chain metadata on first line
-	meta := struct {
-		ID      string    `json:"id"`
-		Started time.Time `json:"started"`
-		EpicID  string    `json:"epic_id,omitempty"`
-	}{
-		ID:      c.ID,
-		Started: c.Started,
-		EpicID:  c.EpicID,
-	}
-	metaLine, _ := json.Marshal(meta)
-	if _, err := f.Write(append(metaLine, '\n')); err != nil {
-		return fmt.Errorf("write chain metadata: %w", err)
-	}
-
-	// Write each entry
+	// Metadata on the first line, then each entry
+	var buf bytes.Buffer
+	buf.Write(c.metaLine())
+	buf.WriteByte('\n')
 	for _, entry := range c.Entries {
 		line, err := json.Marshal(entry)
 		if err != nil {
 			continue
 		}
-		if _, err := f.Write(append(line, '\n')); err != nil {
-			return fmt.Errorf("write chain entry: %w", err)
-		}
+		buf.Write(line)
+		buf.WriteByte('\n')
 	}
 
-	return nil
+	return fsutil.WithLock(c.path, func() error {
+		if err := fsutil.WriteFileAtomic(c.path, buf.Bytes(), 0600); err != nil {
+			return fmt.Errorf("write chain: %w", err)
+		}
+		return nil
+	})
 }
 
 // Append adds a new entry to the chain with file locking.
-// This is atomic and safe for concurrent access.
+// This is atomic and safe for concurrent access. The entry is hash-linked
+// to the last one on disk and signed if a signing key exists.
 func (c *Chain) Append(entry ChainEntry) error {
 	if c.path == "" {
 		return fmt.Errorf("chain has no path set")
 	}
 
-	// Ensure directory exists
-	dir := filepath.Dir(c.path)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("create chain directory: %w", err)
-	}
-
-	// Open file for append with exclusive lock
-	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	key, err := c.signingKey()
 	if err != nil {
-		return fmt.Errorf("open chain file: %w", err)
+		return err
 	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // sync already done via lock release
-	}()
-
-	// Acquire exclusive lock
-	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
-		return fmt.Errorf("lock chain file: %w", err)
-	}
-	defer func() {
-		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN) //nolint:errcheck // unlock best-effort
-	}()
 
-	// Check if file is empty (needs metadata)
-	stat, _ := f.Stat()
-	if stat.Size() == 0 {
-		meta := struct {
-			ID      string    `json:"id"`
-			Started time.Time `json:"started"`
-			EpicID  string    `json:"epic_id,omitempty"`
-		}{
-			ID:      c.ID,
-			Started: c.Started,
-			EpicID:  c.EpicID,
+	err = fsutil.WithLock(c.path, func() error {
+		prev, err := chainHead(c.path, c.metaLine())
+		if err != nil {
+			return fmt.Errorf("read chain head: %w", err)
 		}
-		metaLine, _ := json.Marshal(meta)
-		if _, err := f.Write(append(metaLine, '\n')); err != nil {
-			return fmt.Errorf("write chain metadata: %w", err)
+		if err := sealEntry(&entry, prev, key); err != nil {
+			return err
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("marshal entry: %w", err)
 		}
-	}
 
-	// Write entry
-	line, err := json.Marshal(entry)
+		// An empty file needs the metadata line first
+		lines := [][]byte{line}
+		if info, err := os.Stat(c.path); err != nil || info.Size() == 0 {
+			lines = [][]byte{c.metaLine(), line}
+		}
+		return fsutil.AppendLinesLocked(c.path, 0600, lines...)
+	})
 	if err != nil {
-		return fmt.Errorf("marshal entry: %w", err)
-	}
-	if _, err := f.Write(append(line, '\n')); err != nil {
 		return fmt.Errorf("write entry: %w", err)
 	}
 
@@ -296,6 +253,38 @@ func (c *Chain) Append(entry ChainEntry) error {
 	return nil
 }
 
+// signingKey returns the chain's signing key, or nil if none was created.
+func (c *Chain) signingKey() (ed25519.PrivateKey, error) {
+	if c.keyDir == "" {
+		return nil, nil
+	}
+	key, err := LoadSigningKey(c.keyDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load signing key: %w", err)
+	}
+	return key, nil
+}
+
+// metaLine returns the chain metadata written as the first line.
+func (c *Chain) metaLine() []byte {
+	meta := struct {
+		ID       string    `json:"id"`
+		Started  time.Time `json:"started"`
+		EpicID   string    `json:"epic_id,omitempty"`
+		Workflow string    `json:"workflow,omitempty"`
+	}{
+		ID:       c.ID,
+		Started:  c.Started,
+		EpicID:   c.EpicID,
+		Workflow: c.Workflow,
+	}
+	data, _ := json.Marshal(meta) //nolint:errcheck // plain struct always marshals
+	return data
+}
+
 // GetLatest returns the most recent entry for a given step.
 func (c *Chain) GetLatest(step Step) *ChainEntry {
 	for i := len(c.Entries) - 1; i >= 0; i-- {
@@ -306,10 +295,16 @@ func (c *Chain) GetLatest(step Step) *ChainEntry {
 	return nil
 }
 
-// IsLocked returns true if the given step has been locked.
+// IsLocked returns true if the given step has been locked, either directly
+// or because a locked step depends on it. Skipped steps are not locked.
 func (c *Chain) IsLocked(step Step) bool {
-	entry := c.GetLatest(step)
-	return entry != nil && entry.Locked
+	switch c.GetStatus(step) {
+	case StatusLocked:
+		return true
+	case StatusSkipped:
+		return false
+	}
+	return c.workflow().locked(c)[step]
 }
 
 // StepStatus returns the status of a step in the chain.
@@ -337,20 +332,42 @@ func (c *Chain) GetStatus(step Step) StepStatus {
 	return StatusInProgress
 }
 
-// GetAllStatus returns status for all steps.
+// GetAllStatus returns status for every workflow step and any other step
+// recorded in the chain. A step that was never locked itself reports
+// locked once a step depending on it is.
 func (c *Chain) GetAllStatus() map[Step]StepStatus {
-	status := make(map[Step]StepStatus)
-	for _, step := range AllSteps() {
-		status[step] = c.GetStatus(step)
+	status := c.workflow().Status(c)
+	for _, e := range c.Entries {
+		if _, ok := status[e.Step]; !ok {
+			status[e.Step] = c.GetStatus(e.Step)
+		}
 	}
 	return status
 }
 
+// SetWorkflow sets the workflow whose dependencies the chain follows.
+func (c *Chain) SetWorkflow(w *Workflow) {
+	c.graph = w
+}
+
+// workflow returns the chain's workflow, or the default.
+func (c *Chain) workflow() *Workflow {
+	if c.graph != nil {
+		return c.graph
+	}
+	return DefaultWorkflow()
+}
+
 // Path returns the file path where the chain is stored.
 func (c *Chain) Path() string {
 	return c.path
 }
 
+// KeyDir returns the directory holding the chain's signing key.
+func (c *Chain) KeyDir() string {
+	return c.keyDir
+}
+
 // SetPath sets the file path for the chain.
 func (c *Chain) SetPath(path string) {
 	c.path = path
@@ -389,6 +389,29 @@ func TestIsLocked(t *testing.T) {
 	}
 }
 
+func TestIsLockedSkippedSteps(t *testing.T) {
+	// Skips are recorded locked, but a skipped step is not locked and does
+	// not lock the steps it needs
+	chain := &Chain{
+		Entries: []ChainEntry{
+			{Step: StepResearch, Locked: true},
+			{Step: StepPlan, Locked: true, Skipped: true},
+		},
+	}
+	if chain.IsLocked(StepPlan) {
+		t.Error("skipped plan should not be locked")
+	}
+	if chain.IsLocked(StepPreMortem) {
+		t.Error("pre-mortem should not be locked through a skipped plan")
+	}
+	if !chain.IsLocked(StepResearch) {
+		t.Error("research should stay locked")
+	}
+	if !chain.workflow().Done(chain)[StepPreMortem] {
+		t.Error("a skipped plan should still complete pre-mortem")
+	}
+}
+
 func TestGetStatus(t *testing.T) {
 	chain := &Chain{
 		Entries: []ChainEntry{
@@ -749,3 +772,91 @@ func TestAppendMultipleEntries(t *testing.T) {
 		t.Errorf("expected 4 entries on disk, got %d", len(loaded.Entries))
 	}
 }
+
+func TestChainDAG_ParallelSteps(t *testing.T) {
+	// research and pre-mortem run in parallel; plan fans in from both
+	w, err := ParseWorkflow([]byte(`name: swarm
+steps:
+  - name: research
+  - name: pre-mortem
+    needs: []
+  - name: plan
+    needs: [research, pre-mortem]
+  - name: implement
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := &Chain{Entries: []ChainEntry{}}
+	chain.SetWorkflow(w)
+
+	names := func(steps []*WorkflowStep) []Step {
+		var out []Step
+		for _, s := range steps {
+			out = append(out, s.Name)
+		}
+		return out
+	}
+	if got := names(w.Ready(chain)); len(got) != 2 || got[0] != StepResearch || got[1] != StepPreMortem {
+		t.Fatalf("Ready = %v, want [research pre-mortem]", got)
+	}
+
+	chain.Entries = append(chain.Entries, ChainEntry{Step: StepPreMortem, Locked: true, Needs: []Step{}})
+	if got := names(w.Ready(chain)); len(got) != 1 || got[0] != StepResearch {
+		t.Errorf("Ready = %v, want plan blocked on research", got)
+	}
+
+	chain.Entries = append(chain.Entries, ChainEntry{Step: StepResearch, Locked: true})
+	if got := names(w.Ready(chain)); len(got) != 1 || got[0] != StepPlan {
+		t.Errorf("Ready = %v, want [plan]", got)
+	}
+}
+
+func TestChainDAG_LockedThroughDependents(t *testing.T) {
+	// The default workflow is linear: locking plan locks what it needs
+	chain := &Chain{Entries: []ChainEntry{{Step: StepPlan, Locked: true}}}
+	if !chain.IsLocked(StepResearch) || !chain.IsLocked(StepPreMortem) {
+		t.Error("steps plan depends on should be locked")
+	}
+	if chain.IsLocked(StepImplement) {
+		t.Error("implement should not be locked")
+	}
+	status := chain.GetAllStatus()
+	if status[StepResearch] != StatusLocked || status[StepImplement] != StatusPending {
+		t.Errorf("status = %v", status)
+	}
+
+	// A plan recorded as depending only on research leaves pre-mortem open
+	chain.Entries[0].Needs = []Step{StepResearch}
+	if !chain.IsLocked(StepResearch) || chain.IsLocked(StepPreMortem) {
+		t.Error("recorded needs should override the workflow's")
+	}
+
+	// crank stands in for implement
+	chain.Entries = append(chain.Entries, ChainEntry{Step: StepCrank, Locked: true})
+	if !chain.IsLocked(StepImplement) {
+		t.Error("crank should lock implement")
+	}
+
+	// Steps outside the workflow are still reported
+	chain.Entries = append(chain.Entries, ChainEntry{Step: "deploy"})
+	if got := chain.GetAllStatus()["deploy"]; got != StatusInProgress {
+		t.Errorf("deploy = %q, want in_progress", got)
+	}
+}
+
+func TestChainEntryNeedsRoundTrip(t *testing.T) {
+	for _, needs := range [][]Step{nil, {}, {StepResearch}} {
+		data, err := json.Marshal(ChainEntry{Step: StepPlan, Needs: needs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ChainEntry
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if (got.Needs == nil) != (needs == nil) || len(got.Needs) != len(needs) {
+			t.Errorf("Needs %#v round-tripped as %#v (%s)", needs, got.Needs, data)
+		}
+	}
+}
@@ -52,6 +52,76 @@ func (g *GateChecker) Check(step Step) (*GateResult, error) {
 	}
 }
 
+// CheckStep validates the gate for a workflow step: the built-in gate it
+// names, or else its dependencies in chain and its inputs.
+func (g *GateChecker) CheckStep(w *Workflow, step *WorkflowStep, chain *Chain) (*GateResult, error) {
+	if step.Gate != "" {
+		result, err := g.Check(Step(step.Gate))
+		if result != nil {
+			result.Step = step.Name
+		}
+		return result, err
+	}
+
+	done := w.Done(chain)
+	var waiting []string
+	for _, n := range w.Needs(chain)[step.Name] {
+		if !done[n] {
+			waiting = append(waiting, string(n))
+		}
+	}
+	if len(waiting) > 0 {
+		return &GateResult{
+			Step:    step.Name,
+			Passed:  false,
+			Message: fmt.Sprintf("Waiting on %s. Record or skip %s first.", strings.Join(waiting, ", "), plural(len(waiting), "it", "them")),
+		}, nil
+	}
+
+	result := &GateResult{Step: step.Name, Passed: true}
+	for _, input := range step.Inputs {
+		patterns := ArtifactPatterns(input)
+		if len(patterns) == 0 {
+			continue
+		}
+		found := false
+		for _, pattern := range patterns {
+			if path, loc, err := g.locator.FindFirst(pattern); err == nil {
+				if result.Input == "" {
+					result.Input, result.Location = path, string(loc)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &GateResult{
+				Step:    step.Name,
+				Passed:  false,
+				Message: fmt.Sprintf("Required input not found: %s", input),
+			}, nil
+		}
+	}
+
+	switch {
+	case result.Input != "":
+		result.Message = fmt.Sprintf("Prerequisites met, input found: %s", result.Input)
+	case len(step.Needs) > 0:
+		result.Message = "Prerequisites met"
+	default:
+		result.Message = fmt.Sprintf("%s has no prerequisites", step.Name)
+	}
+	return result, nil
+}
+
+// plural returns one when n is 1 and many otherwise.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
 // checkResearchGate - No gate (chaos phase, always passes).
 func (g *GateChecker) checkResearchGate() (*GateResult, error) {
 	return &GateResult{
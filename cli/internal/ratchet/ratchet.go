@@ -3,6 +3,7 @@
 package ratchet
 
 import (
+	"encoding/json"
 	"strings"
 	"time"
 )
@@ -160,6 +161,37 @@ type ChainEntry struct {
 
 	// ParentEpic is the epic ID from the prior RPI cycle (empty for first cycle).
 	ParentEpic string `json:"parent_epic,omitempty"`
+
+	// Needs lists the steps this one built on, making the chain a DAG:
+	// steps recorded with disjoint needs ran in parallel. When nil, the
+	// workflow's dependencies apply; empty means none.
+	Needs []Step `json:"needs,omitempty"`
+
+	// PrevHash is the hash of the entry before this one, linking the chain.
+	PrevHash string `json:"prev_hash,omitempty"`
+
+	// Hash covers the entry's fields including PrevHash.
+	Hash string `json:"hash,omitempty"`
+
+	// KeyID identifies the key that signed the entry (empty if unsigned).
+	KeyID string `json:"key_id,omitempty"`
+
+	// Signature is the base64 ed25519 signature of Hash.
+	Signature string `json:"signature,omitempty"`
+}
+
+// MarshalJSON writes an empty Needs as [] so that it survives a round
+// trip, while entries without Needs keep their legacy form.
+func (e ChainEntry) MarshalJSON() ([]byte, error) {
+	type plain ChainEntry
+	aux := struct {
+		plain
+		Needs *[]Step `json:"needs,omitempty"`
+	}{plain: plain(e)}
+	if e.Needs != nil {
+		aux.Needs = &e.Needs
+	}
+	return json.Marshal(aux)
 }
 
 // Chain represents the full ratchet chain state for a workflow.
@@ -176,8 +208,18 @@ type Chain struct {
 	// EpicID is the associated beads epic (if any).
 	EpicID string `json:"epic_id,omitempty"`
 
+	// Workflow is the workflow the chain tracks (empty for the default).
+	Workflow string `json:"workflow,omitempty"`
+
 	// path is the file path where the chain is stored.
 	path string
+
+	// graph is the workflow whose dependencies the chain follows (the
+	// default workflow when nil).
+	graph *Workflow
+
+	// keyDir holds the signing key for new entries, if there is one.
+	keyDir string
 }
 
 // GateResult contains the result of a gate check.
@@ -212,6 +254,10 @@ type ValidationResult struct {
 	// Warnings lists non-blocking concerns.
 	Warnings []string `json:"warnings,omitempty"`
 
+	// Findings are the rule violations behind Issues and Warnings, with
+	// the line each is on.
+	Findings []Finding `json:"findings,omitempty"`
+
 	// Tier is the assessed quality tier.
 	Tier *Tier `json:"tier,omitempty"`
 
@@ -0,0 +1,500 @@
+package ratchet
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule severities. An error makes the artifact invalid; a warning only
+// lowers its tier.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Frontmatter field types accepted by FieldRule.Type.
+var fieldTypes = map[string]bool{
+	"string": true, "int": true, "number": true, "bool": true, "date": true, "list": true,
+}
+
+//go:embed validators.yaml
+var builtinValidatorsYAML []byte
+
+// StepRules are declarative checks on a step's output artifact.
+type StepRules struct {
+	// Severity applies to rules that don't set their own (default error).
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+
+	// Sections are text (usually markdown headings) the artifact must
+	// contain.
+	Sections []SectionRule `yaml:"sections,omitempty" json:"sections,omitempty"`
+
+	// Frontmatter lists fields the artifact's frontmatter must set.
+	Frontmatter []FieldRule `yaml:"frontmatter,omitempty" json:"frontmatter,omitempty"`
+
+	// Citations sets how many citations (links, URLs and the like) the
+	// artifact must contain.
+	Citations *CountRule `yaml:"citations,omitempty" json:"citations,omitempty"`
+
+	// Patterns are regular expressions the artifact must, or must not,
+	// match.
+	Patterns []PatternRule `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+
+	// Words bounds the length of the artifact body.
+	Words *WordBounds `yaml:"words,omitempty" json:"words,omitempty"`
+}
+
+// SectionRule requires one of Any to appear in the artifact. In YAML a
+// plain string is a section with no alternatives.
+type SectionRule struct {
+	Any      []string `yaml:"any" json:"any"`
+	Message  string   `yaml:"message,omitempty" json:"message,omitempty"`
+	Severity string   `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// FieldRule requires a frontmatter field, optionally of a type (string,
+// int, number, bool, date, list) or one of Values. In YAML a plain string
+// is a required field of any type.
+type FieldRule struct {
+	Name     string   `yaml:"name" json:"name"`
+	Type     string   `yaml:"type,omitempty" json:"type,omitempty"`
+	Values   []string `yaml:"values,omitempty" json:"values,omitempty"`
+	Required *bool    `yaml:"required,omitempty" json:"required,omitempty"`
+	Message  string   `yaml:"message,omitempty" json:"message,omitempty"`
+	Severity string   `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// CountRule requires at least Min matches of Pattern (by default, URLs,
+// markdown links and [[wiki links]]). Message may use {count} and {min}.
+type CountRule struct {
+	Min      int    `yaml:"min" json:"min"`
+	Pattern  string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Message  string `yaml:"message,omitempty" json:"message,omitempty"`
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// PatternRule requires Regex to match the artifact, or with Forbid, to
+// match no line of it; each forbidden line is reported.
+type PatternRule struct {
+	Regex    string `yaml:"regex" json:"regex"`
+	Forbid   bool   `yaml:"forbid,omitempty" json:"forbid,omitempty"`
+	Message  string `yaml:"message,omitempty" json:"message,omitempty"`
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// WordBounds limits the number of words in the artifact body (after the
+// frontmatter). Zero means no bound. Message may use {count}, {min} and
+// {max}.
+type WordBounds struct {
+	Min      int    `yaml:"min,omitempty" json:"min,omitempty"`
+	Max      int    `yaml:"max,omitempty" json:"max,omitempty"`
+	Message  string `yaml:"message,omitempty" json:"message,omitempty"`
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// Finding is one broken rule.
+type Finding struct {
+	// Rule is the kind of rule: section, frontmatter, citations, pattern
+	// or words.
+	Rule string `json:"rule"`
+
+	// Severity is error or warning.
+	Severity string `json:"severity"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+
+	// Line is the 1-based line the problem is on (0 for the whole file).
+	Line int `json:"line,omitempty"`
+}
+
+// String formats the finding as an issue or warning.
+func (f Finding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s (line %d)", f.Message, f.Line)
+	}
+	return f.Message
+}
+
+// defaultCitationPattern matches URLs, markdown links and [[wiki links]].
+const defaultCitationPattern = `https?://[^\s)>\]]+|\[[^\]]+\]\([^)]+\)|\[\[[^\]]+\]\]`
+
+// UnmarshalYAML accepts a plain string as a single section.
+func (r *SectionRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Any = []string{node.Value}
+		return nil
+	}
+	type plain SectionRule
+	return node.Decode((*plain)(r))
+}
+
+// UnmarshalYAML accepts a plain string as a field name.
+func (r *FieldRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Name = node.Value
+		return nil
+	}
+	type plain FieldRule
+	return node.Decode((*plain)(r))
+}
+
+var (
+	builtinRulesOnce sync.Once
+	builtinRules     map[string]*StepRules
+)
+
+// BuiltinRules returns the rules of the named built-in validator (see
+// validators.yaml), or nil. They are parsed and validated once and shared,
+// so callers must not modify them.
+func BuiltinRules(name string) *StepRules {
+	builtinRulesOnce.Do(func() {
+		all, err := parseBuiltinRules(builtinValidatorsYAML)
+		if err != nil {
+			panic(fmt.Sprintf("built-in validators: %v", err))
+		}
+		builtinRules = all
+	})
+	return builtinRules[name]
+}
+
+// parseBuiltinRules parses and validates a validators.yaml document.
+func parseBuiltinRules(data []byte) (map[string]*StepRules, error) {
+	var all map[string]*StepRules
+	if err := yaml.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if all[name] == nil {
+			return nil, fmt.Errorf("%s: no rules", name)
+		}
+		if err := all[name].validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return all, nil
+}
+
+// validate checks the rules. Every problem is reported, not just the
+// first.
+func (r *StepRules) validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	severity := func(where, s string) {
+		if s != "" && s != SeverityError && s != SeverityWarning {
+			fail("%s: severity must be %s or %s, not %q", where, SeverityError, SeverityWarning, s)
+		}
+	}
+	pattern := func(where, p string) {
+		if _, err := regexp.Compile(p); err != nil {
+			fail("%s: %v", where, err)
+		}
+	}
+
+	severity("rules", r.Severity)
+	for i, s := range r.Sections {
+		where := fmt.Sprintf("sections[%d]", i)
+		if len(s.Any) == 0 {
+			fail("%s: no section given", where)
+		}
+		severity(where, s.Severity)
+	}
+	for i, f := range r.Frontmatter {
+		where := fmt.Sprintf("frontmatter[%d]", i)
+		if f.Name == "" {
+			fail("%s: name is required", where)
+		}
+		if f.Type != "" && !fieldTypes[f.Type] {
+			fail("%s: unknown type %q (want string, int, number, bool, date or list)", where, f.Type)
+		}
+		severity(where, f.Severity)
+	}
+	if c := r.Citations; c != nil {
+		if c.Min < 0 {
+			fail("citations: min must not be negative")
+		}
+		if c.Pattern != "" {
+			pattern("citations.pattern", c.Pattern)
+		}
+		severity("citations", c.Severity)
+	}
+	for i, p := range r.Patterns {
+		where := fmt.Sprintf("patterns[%d]", i)
+		if p.Regex == "" {
+			fail("%s: regex is required", where)
+		}
+		pattern(where, p.Regex)
+		severity(where, p.Severity)
+	}
+	if w := r.Words; w != nil {
+		if w.Min < 0 || w.Max < 0 {
+			fail("words: bounds must not be negative")
+		}
+		if w.Max > 0 && w.Min > w.Max {
+			fail("words: min %d is above max %d", w.Min, w.Max)
+		}
+		severity("words", w.Severity)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Check applies the rules to an artifact's text.
+func (r *StepRules) Check(text string) []Finding {
+	var findings []Finding
+	add := func(rule, severity, message string, line int) {
+		if severity == "" {
+			severity = r.Severity
+		}
+		if severity == "" {
+			severity = SeverityError
+		}
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Message: message, Line: line})
+	}
+
+	for _, s := range r.Sections {
+		if !containsAny(text, s.Any) {
+			msg := s.Message
+			if msg == "" {
+				msg = "Missing required section: " + strings.Join(s.Any, " or ")
+				if s.Severity == SeverityWarning || (s.Severity == "" && r.Severity == SeverityWarning) {
+					msg = "Missing recommended section: " + strings.Join(s.Any, " or ")
+				}
+			}
+			add("section", s.Severity, msg, 0)
+		}
+	}
+
+	if len(r.Frontmatter) > 0 {
+		fields, line, err := parseFrontmatter(text)
+		if err != nil {
+			add("frontmatter", r.Frontmatter[0].Severity, "Invalid frontmatter: "+err.Error(), line)
+		} else {
+			for _, f := range r.Frontmatter {
+				if problem, line := f.check(fields); problem != "" {
+					if f.Message != "" {
+						problem = f.Message
+					}
+					add("frontmatter", f.Severity, problem, line)
+				}
+			}
+		}
+	}
+
+	if c := r.Citations; c != nil {
+		p := c.Pattern
+		if p == "" {
+			p = defaultCitationPattern
+		}
+		count := len(regexp.MustCompile(p).FindAllStringIndex(text, -1))
+		if count < c.Min {
+			msg := c.Message
+			if msg == "" {
+				msg = "Too few citations: {count} (minimum {min})"
+			}
+			add("citations", c.Severity, expand(msg, count, c.Min, 0), 0)
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	for _, p := range r.Patterns {
+		re := regexp.MustCompile(p.Regex)
+		if p.Forbid {
+			msg := p.Message
+			if msg == "" {
+				msg = "Forbidden pattern " + p.Regex
+			}
+			for i, line := range lines {
+				if re.MatchString(line) {
+					add("pattern", p.Severity, msg, i+1)
+				}
+			}
+			continue
+		}
+		if !re.MatchString(text) {
+			msg := p.Message
+			if msg == "" {
+				msg = "Missing required pattern " + p.Regex
+			}
+			add("pattern", p.Severity, msg, 0)
+		}
+	}
+
+	if w := r.Words; w != nil {
+		count := len(strings.Fields(stripFrontmatter(text)))
+		switch {
+		case w.Min > 0 && count < w.Min:
+			msg := w.Message
+			if msg == "" {
+				msg = "Too short: {count} words (minimum {min})"
+			}
+			add("words", w.Severity, expand(msg, count, w.Min, w.Max), 0)
+		case w.Max > 0 && count > w.Max:
+			msg := w.Message
+			if msg == "" {
+				msg = "Too long: {count} words (maximum {max})"
+			}
+			add("words", w.Severity, expand(msg, count, w.Min, w.Max), 0)
+		}
+	}
+
+	return findings
+}
+
+// check returns the problem with the field, if any, and its line.
+func (f FieldRule) check(fields map[string]*yaml.Node) (string, int) {
+	v, ok := fields[f.Name]
+	if !ok || v.Tag == "!!null" {
+		if f.Required != nil && !*f.Required {
+			return "", 0
+		}
+		return "Missing frontmatter field: " + f.Name, 0
+	}
+
+	line := v.Line
+	switch f.Type {
+	case "string":
+		if v.Kind != yaml.ScalarNode || v.Tag != "!!str" {
+			return fmt.Sprintf("Frontmatter field %s must be a string", f.Name), line
+		}
+	case "int":
+		if v.Tag != "!!int" {
+			return fmt.Sprintf("Frontmatter field %s must be an integer", f.Name), line
+		}
+	case "number":
+		if v.Tag != "!!int" && v.Tag != "!!float" {
+			return fmt.Sprintf("Frontmatter field %s must be a number", f.Name), line
+		}
+	case "bool":
+		if v.Tag != "!!bool" {
+			return fmt.Sprintf("Frontmatter field %s must be true or false", f.Name), line
+		}
+	case "date":
+		if !isDate(v) {
+			return fmt.Sprintf("Frontmatter field %s must be a date (YYYY-MM-DD)", f.Name), line
+		}
+	case "list":
+		if v.Kind != yaml.SequenceNode {
+			return fmt.Sprintf("Frontmatter field %s must be a list", f.Name), line
+		}
+	}
+
+	if len(f.Values) > 0 && (v.Kind != yaml.ScalarNode || !containsString(f.Values, v.Value)) {
+		return fmt.Sprintf("Frontmatter field %s must be one of %s", f.Name, strings.Join(f.Values, ", ")), line
+	}
+	return "", 0
+}
+
+// parseFrontmatter returns the top-level frontmatter fields, with lines
+// relative to the whole file. An artifact without frontmatter has no
+// fields. On error it returns the line of the problem, if known.
+func parseFrontmatter(text string) (map[string]*yaml.Node, int, error) {
+	fields := make(map[string]*yaml.Node)
+	block, ok := frontmatterBlock(text)
+	if !ok {
+		return fields, 0, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(block), &doc); err != nil {
+		return nil, 1, err
+	}
+	if len(doc.Content) == 0 {
+		return fields, 0, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, root.Line + 1, fmt.Errorf("not a mapping")
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		value := *root.Content[i+1]
+		value.Line++ // the opening ---
+		fields[root.Content[i].Value] = &value
+	}
+	return fields, 0, nil
+}
+
+// frontmatterBlock returns the text between an artifact's opening and
+// closing --- lines.
+func frontmatterBlock(text string) (string, bool) {
+	block, _, ok := splitFrontmatter(text)
+	return block, ok
+}
+
+// stripFrontmatter returns the artifact body after its frontmatter.
+func stripFrontmatter(text string) string {
+	if _, body, ok := splitFrontmatter(text); ok {
+		return body
+	}
+	return text
+}
+
+// splitFrontmatter splits an artifact into its frontmatter block and body.
+func splitFrontmatter(text string) (block, body string, ok bool) {
+	lines := strings.Split(text, "\n")
+	if strings.TrimSpace(lines[0]) != "---" {
+		return "", text, false
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return strings.Join(lines[1:i], "\n"), strings.Join(lines[i+1:], "\n"), true
+		}
+	}
+	return "", text, false
+}
+
+// isDate reports whether a frontmatter value is a date or timestamp.
+func isDate(v *yaml.Node) bool {
+	if v.Kind != yaml.ScalarNode {
+		return false
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if _, err := time.Parse(layout, v.Value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// expand fills in the {count}, {min} and {max} placeholders of a message.
+func expand(msg string, count, lo, hi int) string {
+	return strings.NewReplacer(
+		"{count}", strconv.Itoa(count),
+		"{min}", strconv.Itoa(lo),
+		"{max}", strconv.Itoa(hi),
+	).Replace(msg)
+}
+
+func containsAny(text string, needles []string) bool {
+	for _, n := range needles {
+		if strings.Contains(text, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
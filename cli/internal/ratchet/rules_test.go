@@ -0,0 +1,235 @@
+package ratchet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func parseRules(t *testing.T, src string) *StepRules {
+	t.Helper()
+	var r StepRules
+	if err := yaml.Unmarshal([]byte(src), &r); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	return &r
+}
+
+func TestStepRules_Check(t *testing.T) {
+	rules := parseRules(t, `
+sections:
+  - "## Impact"
+  - any: ["## Timeline", "## Events"]
+    message: Missing timeline
+frontmatter:
+  - owner
+  - {name: severity, values: [sev1, sev2, sev3]}
+  - {name: date, type: date}
+  - {name: services, type: list}
+  - {name: paged, type: bool, required: false}
+citations: {min: 2}
+patterns:
+  - {regex: "TODO", forbid: true, severity: warning}
+  - {regex: "(?m)^Root cause:", message: State the root cause}
+words: {min: 5, max: 40}
+`)
+
+	text := `---
+owner: ops
+severity: sev9
+date: yesterday
+services: api
+---
+## Impact
+Writes failed. TODO confirm.
+See https://status.example.com for details.
+TODO add graphs
+`
+	byRule := make(map[string][]Finding)
+	for _, f := range rules.Check(text) {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+
+	if got := byRule["section"]; len(got) != 1 || got[0].Message != "Missing timeline" {
+		t.Errorf("section findings = %+v", got)
+	}
+	fm := byRule["frontmatter"]
+	if len(fm) != 3 {
+		t.Fatalf("frontmatter findings = %+v, want severity, date and services", fm)
+	}
+	for i, line := range []int{3, 4, 5} {
+		if fm[i].Line != line || fm[i].Severity != SeverityError {
+			t.Errorf("frontmatter finding %d = %+v, want line %d", i, fm[i], line)
+		}
+	}
+	if got := byRule["citations"]; len(got) != 1 || got[0].Message != "Too few citations: 1 (minimum 2)" {
+		t.Errorf("citation findings = %+v", got)
+	}
+	pat := byRule["pattern"]
+	if len(pat) != 3 || pat[0].Line != 8 || pat[1].Line != 10 || pat[0].Severity != SeverityWarning {
+		t.Errorf("pattern findings = %+v, want TODO on lines 8 and 10", pat)
+	}
+	if pat[2].Message != "State the root cause" || pat[2].Line != 0 {
+		t.Errorf("required pattern finding = %+v", pat[2])
+	}
+	if len(byRule["words"]) != 0 {
+		t.Errorf("word findings = %+v", byRule["words"])
+	}
+
+	good := `---
+owner: ops
+severity: sev2
+date: 2026-02-10
+services: [api, db]
+paged: true
+---
+## Impact
+Writes failed for [ten minutes](https://status.example.com/1).
+## Timeline
+Root cause: a bad migration, see [[db-migrations]].
+`
+	if got := rules.Check(good); len(got) != 0 {
+		t.Errorf("good artifact findings = %+v", got)
+	}
+}
+
+func TestStepRules_WordBounds(t *testing.T) {
+	rules := parseRules(t, `words: {min: 3, max: 5, message: "{count} words, want {min}-{max}"}`)
+	body := "---\nschema_version: 1\nextra: words here\n---\n"
+	if got := rules.Check(body + "one two"); len(got) != 1 || got[0].Message != "2 words, want 3-5" {
+		t.Errorf("short = %+v", got)
+	}
+	if got := rules.Check(body + "one two three"); len(got) != 0 {
+		t.Errorf("frontmatter should not count toward the body: %+v", got)
+	}
+	if got := rules.Check(body + "a b c d e f"); len(got) != 1 {
+		t.Errorf("long = %+v", got)
+	}
+}
+
+func TestStepRules_InvalidFrontmatter(t *testing.T) {
+	rules := parseRules(t, "frontmatter: [owner]")
+	got := rules.Check("---\nowner: [unclosed\n---\nbody\n")
+	if len(got) != 1 || !strings.HasPrefix(got[0].Message, "Invalid frontmatter") {
+		t.Errorf("findings = %+v", got)
+	}
+	if got := rules.Check("no frontmatter\n"); len(got) != 1 || got[0].Message != "Missing frontmatter field: owner" {
+		t.Errorf("findings = %+v", got)
+	}
+}
+
+func TestStepRules_Validate(t *testing.T) {
+	var r StepRules
+	if err := yaml.Unmarshal([]byte(`
+severity: fatal
+sections: [{message: nothing}]
+frontmatter: [{name: x, type: uuid}]
+citations: {min: -1}
+patterns: [{regex: "("}]
+words: {min: 10, max: 5}
+`), &r); err != nil {
+		t.Fatal(err)
+	}
+	err := r.validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`severity must be error or warning, not "fatal"`,
+		"sections[0]: no section given",
+		`frontmatter[0]: unknown type "uuid"`,
+		"citations: min must not be negative",
+		"patterns[0]: error parsing regexp",
+		"words: min 10 is above max 5",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestBuiltinRules(t *testing.T) {
+	for name := range builtinValidators {
+		r := BuiltinRules(name)
+		if r == nil {
+			t.Errorf("validator %s has no rules in validators.yaml", name)
+			continue
+		}
+		if err := r.validate(); err != nil {
+			t.Errorf("validator %s: %v", name, err)
+		}
+		if r.Severity != SeverityWarning {
+			t.Errorf("validator %s: built-in rules should only warn", name)
+		}
+	}
+}
+
+func TestParseBuiltinRules(t *testing.T) {
+	all, err := parseBuiltinRules(builtinValidatorsYAML)
+	if err != nil {
+		t.Fatalf("embedded validators.yaml: %v", err)
+	}
+	if len(all) != len(builtinValidators) {
+		t.Errorf("validators.yaml has %d validators, want %d", len(all), len(builtinValidators))
+	}
+	if BuiltinRules(string(StepResearch)) != BuiltinRules(string(StepResearch)) {
+		t.Error("BuiltinRules should return the rules parsed on first use")
+	}
+
+	if _, err := parseBuiltinRules([]byte("research:\n  severity: fatal\n")); err == nil || !strings.Contains(err.Error(), "research: rules: severity") {
+		t.Errorf("invalid rules err = %v", err)
+	}
+	if _, err := parseBuiltinRules([]byte("research:\n")); err == nil {
+		t.Error("a validator without rules should fail")
+	}
+}
+
+func TestValidateWorkflowStep_RuleLines(t *testing.T) {
+	w, err := ParseWorkflow([]byte(`name: strict
+steps:
+  - name: research
+    validator: research
+    rules:
+      frontmatter: [{name: schema_version, type: int}]
+      patterns: [{regex: "TBD", forbid: true}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, dir := helperNewValidator(t)
+	path := filepath.Join(dir, "r.md")
+	if err := os.WriteFile(path, []byte("---\nschema_version: one\n---\n## Summary\nTBD\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.ValidateWorkflowStep(w.Step("research"), path, &ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid {
+		t.Error("rule errors should make the artifact invalid")
+	}
+	want := []string{
+		"Frontmatter field schema_version must be an integer (line 2)",
+		"Forbidden pattern TBD (line 5)",
+	}
+	if strings.Join(result.Issues, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues = %q, want %q", result.Issues, want)
+	}
+	// The built-in research warnings are findings too
+	var warnings int
+	for _, f := range result.Findings {
+		if f.Severity == SeverityWarning {
+			warnings++
+		}
+	}
+	if warnings == 0 || warnings != len(result.Warnings) {
+		t.Errorf("findings = %+v, warnings = %v", result.Findings, result.Warnings)
+	}
+}
@@ -0,0 +1,359 @@
+package ratchet
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
+)
+
+const (
+	// KeyDir holds the chain signing key, under .agents/ao.
+	KeyDir = "keys"
+
+	// SigningKeyFile is the ed25519 private key (PKCS#8 PEM).
+	SigningKeyFile = "ratchet_ed25519"
+
+	// PublicKeyFile is the matching public key (PKIX PEM).
+	PublicKeyFile = "ratchet_ed25519.pub"
+)
+
+// sealEntry links entry to the one before it (prev is that entry's seal)
+// and, with a key, signs it.
+func sealEntry(entry *ChainEntry, prev string, key ed25519.PrivateKey) error {
+	entry.PrevHash = prev
+	entry.Hash, entry.KeyID, entry.Signature = "", "", ""
+	if key != nil {
+		entry.KeyID = KeyID(key.Public().(ed25519.PublicKey))
+	}
+	hash, err := entryHash(*entry)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+	if key != nil {
+		entry.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(hash)))
+	}
+	return nil
+}
+
+// entryHash returns the SHA-256 of the entry without its hash and
+// signature. PrevHash is covered, which links the chain.
+func entryHash(entry ChainEntry) (string, error) {
+	entry.Hash, entry.Signature = "", ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("marshal entry: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// metaSeal returns the prev_hash of the first sealed entry: the hash of the
+// chain's metadata line and of the seal of the legacy entry before it, if
+// any. Folding the metadata in keeps its ID, start time and workflow from
+// being edited unnoticed.
+func metaSeal(meta []byte, prev string) string {
+	h := sha256.New()
+	h.Write(meta)
+	h.Write([]byte{'\n'})
+	h.Write([]byte(prev))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// entrySeal returns the hash the next entry links to: the entry's own
+// hash, or for a legacy entry without one, its computed hash.
+func entrySeal(entry ChainEntry) (string, error) {
+	if entry.Hash != "" {
+		return entry.Hash, nil
+	}
+	return entryHash(entry)
+}
+
+// KeyID returns a short fingerprint of a public key.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// GenerateSigningKey creates an ed25519 key pair in dir unless one exists.
+// It returns the public key and whether it was created.
+func GenerateSigningKey(dir string) (ed25519.PublicKey, bool, error) {
+	if key, err := LoadSigningKey(dir); err == nil {
+		return key.Public().(ed25519.PublicKey), false, nil
+	} else if !os.IsNotExist(err) {
+		return nil, false, err
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, false, fmt.Errorf("generate key: %w", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, false, fmt.Errorf("encode private key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, false, fmt.Errorf("encode public key: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, false, fmt.Errorf("create key directory: %w", err)
+	}
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, SigningKeyFile), privPEM, 0600); err != nil {
+		return nil, false, fmt.Errorf("write private key: %w", err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, PublicKeyFile), pubPEM, 0644); err != nil {
+		return nil, false, fmt.Errorf("write public key: %w", err)
+	}
+	return pub, true, nil
+}
+
+// LoadSigningKey reads the private key from dir. A missing key is an
+// os.IsNotExist error.
+func LoadSigningKey(dir string) (ed25519.PrivateKey, error) {
+	path := filepath.Join(dir, SigningKeyFile)
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// LoadPublicKey reads a public key written by GenerateSigningKey.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s: no %s PEM block", path, blockType)
+	}
+	return block.Bytes, nil
+}
+
+// VerifyOptions controls chain verification.
+type VerifyOptions struct {
+	// PublicKey checks entry signatures. Without it, signed entries are
+	// hash-checked only. Entries recorded before the chain's first signed
+	// entry may be unsigned; every entry after it must be signed.
+	PublicKey ed25519.PublicKey
+
+	// RequireSigned fails on any entry without a hash or a signature,
+	// including entries recorded before a signing key existed.
+	RequireSigned bool
+}
+
+// VerifyResult is the outcome of verifying a chain file.
+type VerifyResult struct {
+	// Pass is true if no entry was found tampered with.
+	Pass bool `json:"pass"`
+
+	// EntryCount is the number of entries read.
+	EntryCount int `json:"entry_count"`
+
+	// Unsealed counts leading legacy entries recorded before hashing;
+	// only the last of them is covered by the chain.
+	Unsealed int `json:"unsealed"`
+
+	// Signed counts entries with a signature.
+	Signed int `json:"signed"`
+
+	// SignaturesChecked is true if signatures were verified against a
+	// public key.
+	SignaturesChecked bool `json:"signatures_checked"`
+
+	// FirstBrokenIndex is the 1-based index of the first bad entry (-1
+	// if none) and FirstBrokenLine its line in the file.
+	FirstBrokenIndex int `json:"first_broken_index"`
+	FirstBrokenLine  int `json:"first_broken_line,omitempty"`
+
+	// Message explains the failure.
+	Message string `json:"message,omitempty"`
+
+	// Head is the seal of the last entry. Record it elsewhere to detect
+	// entries later removed from the end of the chain.
+	Head string `json:"head,omitempty"`
+}
+
+// VerifyChainFile checks the hash links (and signatures) of a chain file.
+// Edited entries fail their hash; removed, inserted or reordered entries
+// break the link of the entry after them. An edited metadata line breaks
+// the link of the first sealed entry.
+func VerifyChainFile(path string, opts VerifyOptions) (*VerifyResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck // read-only
+
+	result := &VerifyResult{Pass: true, FirstBrokenIndex: -1, SignaturesChecked: opts.PublicKey != nil}
+	fail := func(index, line int, format string, args ...interface{}) (*VerifyResult, error) {
+		result.Pass = false
+		result.FirstBrokenIndex = index
+		result.FirstBrokenLine = line
+		result.Message = fmt.Sprintf(format, args...)
+		return result, nil
+	}
+
+	// Without RequireSigned, stripping hash, prev_hash and signature (or
+	// stripping signatures and recomputing the keyless hashes) would pass as
+	// a legacy or unsigned chain. A public key alone does not imply it:
+	// chains keep the unsigned entries recorded before their key existed.
+	strict := opts.RequireSigned
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNum := 0
+	var meta []byte
+	prev := ""
+	sealed, signed := false, false
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNum == 1 {
+			meta = []byte(line)
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		result.EntryCount++
+		index := result.EntryCount
+
+		var entry ChainEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return fail(index, lineNum, "malformed entry: %v", err)
+		}
+
+		if entry.Hash == "" {
+			if sealed {
+				return fail(index, lineNum, "entry has no hash after sealed entries (hash removed or entry inserted)")
+			}
+			if strict {
+				return fail(index, lineNum, "entry has no hash")
+			}
+			result.Unsealed++
+		} else {
+			if !sealed {
+				prev = metaSeal(meta, prev)
+			}
+			if entry.PrevHash != prev {
+				if !sealed {
+					return fail(index, lineNum, "prev_hash mismatch: the chain metadata or an entry before this one was changed")
+				}
+				return fail(index, lineNum, "prev_hash mismatch: an entry before this one was removed, inserted or moved")
+			}
+			sealed = true
+			hash, err := entryHash(entry)
+			if err != nil {
+				return fail(index, lineNum, "%v", err)
+			}
+			if hash != entry.Hash {
+				return fail(index, lineNum, "hash mismatch: entry was edited")
+			}
+
+			if entry.Signature == "" {
+				if signed {
+					return fail(index, lineNum, "signature missing after signed entries")
+				}
+				if strict {
+					return fail(index, lineNum, "entry is not signed")
+				}
+			} else {
+				signed = true
+				result.Signed++
+				if opts.PublicKey != nil {
+					if entry.KeyID != KeyID(opts.PublicKey) {
+						return fail(index, lineNum, "signed by unknown key %s", entry.KeyID)
+					}
+					sig, err := base64.StdEncoding.DecodeString(entry.Signature)
+					if err != nil || !ed25519.Verify(opts.PublicKey, []byte(entry.Hash), sig) {
+						return fail(index, lineNum, "bad signature")
+					}
+				}
+			}
+		}
+
+		if prev, err = entrySeal(entry); err != nil {
+			return fail(index, lineNum, "%v", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read chain: %w", err)
+	}
+
+	result.Head = prev
+	return result, nil
+}
+
+// chainHead returns the prev_hash of the next entry appended to the chain
+// file at path: the last entry's hash, or for the first sealed entry, the
+// seal of the metadata line (meta when the file is empty) and of any
+// legacy entry before it.
+func chainHead(path string, meta []byte) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || len(data) == 0 {
+		return metaSeal(meta, ""), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	chain, err := loadJSONLChain(path)
+	if err != nil {
+		return "", err
+	}
+	if len(chain.Entries) == 0 {
+		return metaSeal([]byte(strings.TrimSpace(first)), ""), nil
+	}
+	last := chain.Entries[len(chain.Entries)-1]
+	if last.Hash != "" {
+		return last.Hash, nil
+	}
+	legacy, err := entrySeal(last)
+	if err != nil {
+		return "", err
+	}
+	return metaSeal([]byte(strings.TrimSpace(first)), legacy), nil
+}
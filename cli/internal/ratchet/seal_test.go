@@ -0,0 +1,343 @@
+package ratchet
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sealedChain writes a chain with the given steps into a new .agents
+// directory and returns the chain and the file's lines.
+func sealedChain(t *testing.T, sign bool, steps ...Step) (*Chain, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".agents", "ao"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if sign {
+		if _, _, err := GenerateSigningKey(filepath.Join(dir, ".agents", "ao", KeyDir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain, err := LoadChain(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range steps {
+		entry := ChainEntry{Step: s, Timestamp: time.Unix(int64(1000+i), 0).UTC(), Output: string(s) + ".md", Locked: true}
+		if err := chain.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return chain, readLines(t, chain.Path())
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func writeLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyChainFile_Intact(t *testing.T) {
+	chain, _ := sealedChain(t, false, StepResearch, StepPreMortem, StepPlan)
+	result, err := VerifyChainFile(chain.Path(), VerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Pass || result.EntryCount != 3 || result.Signed != 0 || result.FirstBrokenIndex != -1 {
+		t.Errorf("result = %+v", result)
+	}
+	if result.Head != chain.Entries[2].Hash {
+		t.Errorf("head = %s, want the last entry's hash", result.Head)
+	}
+	if chain.Entries[1].PrevHash != chain.Entries[0].Hash {
+		t.Error("entries are not linked")
+	}
+
+	// A reloaded chain keeps linking from the last entry on disk
+	reloaded, err := LoadChain(filepath.Dir(filepath.Dir(filepath.Dir(chain.Path()))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Append(ChainEntry{Step: StepImplement, Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{RequireSigned: true}); result.Pass || result.FirstBrokenIndex != 1 {
+		t.Errorf("--require-signed on an unsigned chain = %+v", result)
+	}
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{}); !result.Pass || result.EntryCount != 4 {
+		t.Errorf("after append = %+v", result)
+	}
+}
+
+func TestVerifyChainFile_Tampering(t *testing.T) {
+	tests := []struct {
+		name      string
+		tamper    func(lines []string) []string
+		wantIndex int
+		wantMsg   string
+	}{
+		{
+			name: "edit",
+			tamper: func(l []string) []string {
+				l[2] = strings.Replace(l[2], "pre-mortem.md", "other.md", 1)
+				return l
+			},
+			wantIndex: 2,
+			wantMsg:   "hash mismatch",
+		},
+		{
+			name: "reorder",
+			tamper: func(l []string) []string {
+				l[2], l[3] = l[3], l[2]
+				return l
+			},
+			wantIndex: 2,
+			wantMsg:   "prev_hash mismatch",
+		},
+		{
+			name: "delete",
+			tamper: func(l []string) []string {
+				return append(l[:2], l[3:]...)
+			},
+			wantIndex: 2,
+			wantMsg:   "prev_hash mismatch",
+		},
+		{
+			name: "insert unsealed",
+			tamper: func(l []string) []string {
+				extra := `{"step":"plan","timestamp":"2026-01-01T00:00:00Z","output":"x","locked":true}`
+				return append(l[:3], append([]string{extra}, l[3:]...)...)
+			},
+			wantIndex: 3,
+			wantMsg:   "no hash",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, lines := sealedChain(t, false, StepResearch, StepPreMortem, StepPlan, StepImplement)
+			writeLines(t, chain.Path(), tt.tamper(lines))
+
+			result, err := VerifyChainFile(chain.Path(), VerifyOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Pass || result.FirstBrokenIndex != tt.wantIndex || result.FirstBrokenLine != tt.wantIndex+1 {
+				t.Errorf("result = %+v, want first broken entry %d", result, tt.wantIndex)
+			}
+			if !strings.Contains(result.Message, tt.wantMsg) {
+				t.Errorf("message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestVerifyChainFile_Signatures(t *testing.T) {
+	chain, lines := sealedChain(t, true, StepResearch, StepPlan)
+	pub, err := LoadPublicKey(filepath.Join(chain.KeyDir(), PublicKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.Entries[0].Signature == "" || chain.Entries[0].KeyID != KeyID(pub) {
+		t.Fatalf("entry not signed: %+v", chain.Entries[0])
+	}
+
+	result, err := VerifyChainFile(chain.Path(), VerifyOptions{PublicKey: pub, RequireSigned: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Pass || result.Signed != 2 || !result.SignaturesChecked {
+		t.Errorf("result = %+v", result)
+	}
+
+	// Another key's signatures are rejected
+	other, _, err := GenerateSigningKey(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{PublicKey: other}); result.Pass || !strings.Contains(result.Message, "unknown key") {
+		t.Errorf("other key = %+v", result)
+	}
+
+	// Stripping a signature is caught once the chain is signed
+	stripped := strings.Replace(lines[2], `"signature":"`+chain.Entries[1].Signature+`"`, `"signature":""`, 1)
+	writeLines(t, chain.Path(), []string{lines[0], lines[1], stripped})
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{}); result.Pass || result.FirstBrokenIndex != 2 {
+		t.Errorf("stripped signature = %+v", result)
+	}
+
+	// A forged signature fails with the key
+	forged := strings.Replace(lines[2], `"signature":"`+chain.Entries[1].Signature+`"`, `"signature":"`+chain.Entries[0].Signature+`"`, 1)
+	writeLines(t, chain.Path(), []string{lines[0], lines[1], forged})
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{PublicKey: pub}); result.Pass || result.Message != "bad signature" {
+		t.Errorf("forged signature = %+v", result)
+	}
+}
+
+// rewriteEntries decodes each entry line, applies fn and re-encodes it.
+func rewriteEntries(t *testing.T, lines []string, fn func(e *ChainEntry)) []string {
+	t.Helper()
+	out := []string{lines[0]}
+	for _, line := range lines[1:] {
+		var e ChainEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		fn(&e)
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, string(data))
+	}
+	return out
+}
+
+func TestVerifyChainFile_StrippedSeals(t *testing.T) {
+	chain, lines := sealedChain(t, true, StepResearch, StepPlan)
+	pub, err := LoadPublicKey(filepath.Join(chain.KeyDir(), PublicKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing every hash, link and signature turns the chain "legacy"
+	writeLines(t, chain.Path(), rewriteEntries(t, lines, func(e *ChainEntry) {
+		e.Hash, e.PrevHash, e.KeyID, e.Signature = "", "", "", ""
+	}))
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{}); !result.Pass {
+		t.Fatalf("stripped chain should read as legacy without options: %+v", result)
+	}
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{RequireSigned: true}); result.Pass || result.FirstBrokenIndex != 1 || result.Message != "entry has no hash" {
+		t.Errorf("--require-signed on a stripped chain = %+v", result)
+	}
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{PublicKey: pub}); !result.Pass || result.Unsealed != 2 {
+		t.Errorf("a public key alone should accept a legacy chain: %+v", result)
+	}
+
+	// Dropping signatures and recomputing keyless hashes keeps the links intact
+	prev := metaSeal([]byte(lines[0]), "")
+	writeLines(t, chain.Path(), rewriteEntries(t, lines, func(e *ChainEntry) {
+		if err := sealEntry(e, prev, nil); err != nil {
+			t.Fatal(err)
+		}
+		prev = e.Hash
+	}))
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{}); !result.Pass {
+		t.Fatalf("resealed chain should hash-check without a key: %+v", result)
+	}
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{PublicKey: pub}); !result.Pass || result.Signed != 0 {
+		t.Errorf("a public key alone should accept an unsigned chain: %+v", result)
+	}
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{PublicKey: pub, RequireSigned: true}); result.Pass || result.FirstBrokenIndex != 1 || result.Message != "entry is not signed" {
+		t.Errorf("--require-signed on a resealed unsigned chain = %+v", result)
+	}
+}
+
+func TestVerifyChainFile_Metadata(t *testing.T) {
+	chain, lines := sealedChain(t, false, StepResearch, StepPlan)
+
+	edited := strings.Replace(lines[0], chain.ID, "forged", 1)
+	if edited == lines[0] {
+		t.Fatalf("metadata line has no chain ID: %s", lines[0])
+	}
+	writeLines(t, chain.Path(), append([]string{edited}, lines[1:]...))
+	result, err := VerifyChainFile(chain.Path(), VerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Pass || result.FirstBrokenIndex != 1 || !strings.Contains(result.Message, "metadata") {
+		t.Errorf("edited metadata = %+v", result)
+	}
+}
+
+func TestVerifyChainFile_KeyAddedLater(t *testing.T) {
+	chain, _ := sealedChain(t, false, StepResearch)
+	if _, _, err := GenerateSigningKey(chain.KeyDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Append(ChainEntry{Step: StepPlan, Timestamp: time.Now(), Output: "p.md"}); err != nil {
+		t.Fatal(err)
+	}
+	pub, err := LoadPublicKey(filepath.Join(chain.KeyDir(), PublicKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := VerifyChainFile(chain.Path(), VerifyOptions{PublicKey: pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Pass || result.Signed != 1 {
+		t.Errorf("entries recorded before the key should still verify: %+v", result)
+	}
+	if result, _ := VerifyChainFile(chain.Path(), VerifyOptions{PublicKey: pub, RequireSigned: true}); result.Pass || result.FirstBrokenIndex != 1 || result.Message != "entry is not signed" {
+		t.Errorf("--require-signed with an unsigned first entry = %+v", result)
+	}
+}
+
+func TestVerifyChainFile_LegacyPrefix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".agents", "ao", ChainFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeLines(t, path, []string{
+		`{"id":"legacy","started":"2026-01-01T00:00:00Z"}`,
+		`{"step":"research","timestamp":"2026-01-01T00:00:00Z","output":"r.md","locked":true}`,
+	})
+	chain, err := LoadChain(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Append(ChainEntry{Step: StepPlan, Timestamp: time.Now(), Output: "p.md"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := VerifyChainFile(path, VerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Pass || result.Unsealed != 1 || result.EntryCount != 2 {
+		t.Errorf("result = %+v", result)
+	}
+
+	// The first sealed entry covers the legacy entry before it
+	lines := readLines(t, path)
+	lines[1] = strings.Replace(lines[1], "r.md", "x.md", 1)
+	writeLines(t, path, lines)
+	if result, _ := VerifyChainFile(path, VerifyOptions{}); result.Pass || result.FirstBrokenIndex != 2 {
+		t.Errorf("edited legacy entry = %+v", result)
+	}
+}
+
+func TestGenerateSigningKey_Idempotent(t *testing.T) {
+	dir := t.TempDir()
+	pub, created, err := GenerateSigningKey(dir)
+	if err != nil || !created {
+		t.Fatalf("created = %v, err = %v", created, err)
+	}
+	again, created, err := GenerateSigningKey(dir)
+	if err != nil || created || KeyID(again) != KeyID(pub) {
+		t.Errorf("second call created = %v, key changed = %v, err = %v", created, KeyID(again) != KeyID(pub), err)
+	}
+	info, err := os.Stat(filepath.Join(dir, SigningKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("private key mode = %v, want 0600", info.Mode().Perm())
+	}
+}
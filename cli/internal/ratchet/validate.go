@@ -2,7 +2,6 @@ package ratchet
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 	"github.com/boshu2/agentops/cli/internal/types"
 )
 
@@ -123,6 +123,31 @@ func (v *Validator) Validate(step Step, artifactPath string) (*ValidationResult,
 
 // ValidateWithOptions checks artifacts with lenient mode support.
 func (v *Validator) ValidateWithOptions(step Step, artifactPath string, opts *ValidateOptions) (*ValidationResult, error) {
+	return v.validate(step, artifactPath, opts, func(result *ValidationResult) {
+		v.validateStep(step, artifactPath, result)
+	})
+}
+
+// ValidateWorkflowStep checks an artifact against a workflow step's
+// built-in validator and rules.
+func (v *Validator) ValidateWorkflowStep(step *WorkflowStep, artifactPath string, opts *ValidateOptions) (*ValidationResult, error) {
+	return v.validate(step.Name, artifactPath, opts, func(result *ValidationResult) {
+		if step.Validator == "" && step.Rules == nil {
+			result.Warnings = append(result.Warnings,
+				"No validation rules for step: "+string(step.Name))
+			return
+		}
+		if step.Validator != "" {
+			v.validateStep(Step(step.Validator), artifactPath, result)
+		}
+		if step.Rules != nil {
+			v.applyRules(step.Rules, artifactPath, result)
+		}
+	})
+}
+
+// validate runs the checks common to every step, then check.
+func (v *Validator) validate(step Step, artifactPath string, opts *ValidateOptions, check func(*ValidationResult)) (*ValidationResult, error) {
 	if opts == nil {
 		opts = &ValidateOptions{}
 	}
@@ -154,7 +179,7 @@ func (v *Validator) ValidateWithOptions(step Step, artifactPath string, opts *Va
 	}
 
 	// Run step-specific validation
-	v.validateStep(step, artifactPath, result)
+	check(result)
 
 	// Assess tier based on validation results
 	tier := v.assessTier(result)
@@ -183,79 +208,40 @@ func (v *Validator) validateStep(step Step, artifactPath string, result *Validat
 	}
 }
 
-// validateResearch checks research artifact quality.
-func (v *Validator) validateResearch(path string, result *ValidationResult) {
+// applyRules checks an artifact against declarative rules. Findings of
+// error severity are issues and make the artifact invalid; warnings are
+// warnings.
+func (v *Validator) applyRules(rules *StepRules, path string, result *ValidationResult) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		result.Valid = false
 		result.Issues = append(result.Issues, "Cannot read file: "+err.Error())
 		return
 	}
+	v.applyRulesToText(rules, string(content), result)
+}
 
-	text := string(content)
-
-	// Check for schema_version field (lenient mode: warning only)
-	if !v.hasFrontmatterField(text, "schema_version") {
-		result.Warnings = append(result.Warnings,
-			"Missing schema_version field in frontmatter - new artifacts should include schema_version: 1")
-	}
-
-	// Check for required sections
-	requiredSections := []string{
-		"## Summary",
-		"## Key Findings",
-		"## Recommendations",
-	}
-
-	for _, section := range requiredSections {
-		if !strings.Contains(text, section) {
-			result.Warnings = append(result.Warnings,
-				"Missing recommended section: "+section)
+// applyRulesToText checks artifact text against declarative rules.
+func (v *Validator) applyRulesToText(rules *StepRules, text string, result *ValidationResult) {
+	for _, f := range rules.Check(text) {
+		result.Findings = append(result.Findings, f)
+		if f.Severity == SeverityWarning {
+			result.Warnings = append(result.Warnings, f.String())
+			continue
 		}
+		result.Valid = false
+		result.Issues = append(result.Issues, f.String())
 	}
+}
 
-	// Check minimum length
-	wordCount := len(strings.Fields(text))
-	if wordCount < 100 {
-		result.Warnings = append(result.Warnings,
-			"Research seems short ("+string(rune(wordCount))+" words), consider adding more detail")
-	}
-
-	// Check for citations/sources
-	if !strings.Contains(text, "Source") && !strings.Contains(text, "Reference") && !strings.Contains(text, "http") {
-		result.Warnings = append(result.Warnings,
-			"No sources or references found")
-	}
+// validateResearch checks research artifact quality.
+func (v *Validator) validateResearch(path string, result *ValidationResult) {
+	v.applyRules(BuiltinRules(string(StepResearch)), path, result)
 }
 
 // validatePreMortem checks pre-mortem/spec artifact quality.
 func (v *Validator) validatePreMortem(path string, result *ValidationResult) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		result.Valid = false
-		result.Issues = append(result.Issues, "Cannot read file: "+err.Error())
-		return
-	}
-
-	text := string(content)
-
-	// Check for schema_version field (lenient mode: warning only)
-	if !v.hasFrontmatterField(text, "schema_version") {
-		result.Warnings = append(result.Warnings,
-			"Missing schema_version field in frontmatter - new artifacts should include schema_version: 1")
-	}
-
-	// Check for pre-mortem findings table
-	if !strings.Contains(text, "Finding") && !strings.Contains(text, "| ID |") {
-		result.Warnings = append(result.Warnings,
-			"Missing findings table - pre-mortem should identify failure modes")
-	}
-
-	// Check for mitigations
-	if !strings.Contains(text, "Mitigation") && !strings.Contains(text, "Fix") {
-		result.Warnings = append(result.Warnings,
-			"Missing mitigations - each finding should have a fix")
-	}
+	v.applyRules(BuiltinRules(string(StepPreMortem)), path, result)
 
 	// Check version indicator
 	versionPattern := regexp.MustCompile(`-v\d+\.md$`)
@@ -289,63 +275,12 @@ func (v *Validator) validatePlan(path string, result *ValidationResult) {
 		return
 	}
 
-	// Check for schema_version field (lenient mode: warning only)
-	if !v.hasFrontmatterField(text, "schema_version") {
-		result.Warnings = append(result.Warnings,
-			"Missing schema_version field in frontmatter - new artifacts should include schema_version: 1")
-	}
-
-	// Check for required plan elements
-	if !strings.Contains(text, "## Objective") && !strings.Contains(text, "## Goal") {
-		result.Warnings = append(result.Warnings,
-			"Missing objective/goal section")
-	}
-
-	if !strings.Contains(text, "## Tasks") && !strings.Contains(text, "## Issues") {
-		result.Warnings = append(result.Warnings,
-			"Missing tasks/issues breakdown")
-	}
-
-	if !strings.Contains(text, "## Success Criteria") && !strings.Contains(text, "## Acceptance") {
-		result.Warnings = append(result.Warnings,
-			"Missing success criteria")
-	}
+	v.applyRulesToText(BuiltinRules(string(StepPlan)), text, result)
 }
 
 // validatePostMortem checks post-mortem/retro artifact quality.
 func (v *Validator) validatePostMortem(path string, result *ValidationResult) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		result.Valid = false
-		result.Issues = append(result.Issues, "Cannot read file: "+err.Error())
-		return
-	}
-
-	text := string(content)
-
-	// Check for schema_version field (lenient mode: warning only)
-	if !v.hasFrontmatterField(text, "schema_version") {
-		result.Warnings = append(result.Warnings,
-			"Missing schema_version field in frontmatter - new artifacts should include schema_version: 1")
-	}
-
-	// Check for learnings section
-	if !strings.Contains(text, "## Learnings") && !strings.Contains(text, "## Key Learnings") {
-		result.Warnings = append(result.Warnings,
-			"Missing learnings section - retros should capture what was learned")
-	}
-
-	// Check for patterns section
-	if !strings.Contains(text, "## Patterns") && !strings.Contains(text, "## Reusable Patterns") {
-		result.Warnings = append(result.Warnings,
-			"Consider adding patterns section for reusable workflows")
-	}
-
-	// Check for next steps
-	if !strings.Contains(text, "## Next") && !strings.Contains(text, "## Follow-up") {
-		result.Warnings = append(result.Warnings,
-			"Missing next steps/follow-up section")
-	}
+	v.applyRules(BuiltinRules(string(StepPostMortem)), path, result)
 }
 
 // validateEpicIssue validates an epic via beads CLI.
@@ -794,39 +729,54 @@ func ValidateCloseReason(closeReason string) []string {
 // CitationsFilePath is the relative path to the citations JSONL file.
 const CitationsFilePath = ".agents/ao/citations.jsonl"
 
+// CitationStore keeps citation events somewhere other than the JSONL log,
+// such as the SQLite storage backend.
+type CitationStore interface {
+	WriteCitation(event types.CitationEvent) error
+	ListCitations() ([]types.CitationEvent, error)
+	ReplaceCitations(events []types.CitationEvent) error
+	Close() error
+}
+
+// CitationOption configures where the citation functions read and write.
+type CitationOption func(*citationOptions)
+
+type citationOptions struct {
+	store CitationStore
+}
+
+// WithCitationStore keeps citations in store instead of the JSONL log at
+// CitationsFilePath. A nil store means the log. The caller owns the store
+// and closes it.
+func WithCitationStore(store CitationStore) CitationOption {
+	return func(o *citationOptions) {
+		o.store = store
+	}
+}
+
+func applyCitationOptions(opts []CitationOption) citationOptions {
+	var o citationOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // RecordCitation appends a citation event to the citations log.
 // Creates the file and parent directories if they don't exist.
-func RecordCitation(baseDir string, event types.CitationEvent) error {
+func RecordCitation(baseDir string, event types.CitationEvent, opts ...CitationOption) error {
 	// Ensure citation has timestamp
 	if event.CitedAt.IsZero() {
 		event.CitedAt = time.Now()
 	}
 
-	// Build full path
-	citationsPath := filepath.Join(baseDir, CitationsFilePath)
-
-	// Create parent directories
-	if err := os.MkdirAll(filepath.Dir(citationsPath), 0700); err != nil {
-		return fmt.Errorf("create citations directory: %w", err)
-	}
-
-	// Open file for append (create if doesn't exist)
-	f, err := os.OpenFile(citationsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return fmt.Errorf("open citations file: %w", err)
+	if store := applyCitationOptions(opts).store; store != nil {
+		return store.WriteCitation(event)
 	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // write already complete, close best-effort
-	}()
 
-	// Marshal event to JSON
-	data, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("marshal citation: %w", err)
-	}
-
-	// Write JSONL line
-	if _, err := f.Write(append(data, '\n')); err != nil {
+	// Locked, fsynced append; safe against concurrent sessions
+	citationsPath := filepath.Join(baseDir, CitationsFilePath)
+	if err := fsutil.AppendJSONL(citationsPath, 0600, event); err != nil {
 		return fmt.Errorf("write citation: %w", err)
 	}
 
@@ -834,51 +784,24 @@ func RecordCitation(baseDir string, event types.CitationEvent) error {
 }
 
 // LoadCitations reads all citation events from the citations log.
-func LoadCitations(baseDir string) ([]types.CitationEvent, error) {
-	citationsPath := filepath.Join(baseDir, CitationsFilePath)
-
-	f, err := os.Open(citationsPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // No citations yet
-		}
-		return nil, fmt.Errorf("open citations file: %w", err)
+func LoadCitations(baseDir string, opts ...CitationOption) ([]types.CitationEvent, error) {
+	if store := applyCitationOptions(opts).store; store != nil {
+		return store.ListCitations()
 	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // read-only, close error non-fatal
-	}()
-
-	var citations []types.CitationEvent
-	scanner := bufio.NewScanner(f)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
 
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-
-		var event types.CitationEvent
-		if err := json.Unmarshal(line, &event); err != nil {
-			// Skip malformed lines
-			continue
-		}
-		citations = append(citations, event)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return citations, fmt.Errorf("scan citations: %w", err)
+	// Malformed and torn lines are skipped
+	citationsPath := filepath.Join(baseDir, CitationsFilePath)
+	citations, _, err := fsutil.LoadJSONL[types.CitationEvent](citationsPath)
+	if err != nil {
+		return citations, fmt.Errorf("read citations: %w", err)
 	}
 
 	return citations, nil
 }
 
 // CountCitationsForArtifact returns the number of times an artifact has been cited.
-func CountCitationsForArtifact(baseDir, artifactPath string) (int, error) {
-	citations, err := LoadCitations(baseDir)
+func CountCitationsForArtifact(baseDir, artifactPath string, opts ...CitationOption) (int, error) {
+	citations, err := LoadCitations(baseDir, opts...)
 	if err != nil {
 		return 0, err
 	}
@@ -893,8 +816,8 @@ func CountCitationsForArtifact(baseDir, artifactPath string) (int, error) {
 }
 
 // GetCitationsSince returns citations after a given time.
-func GetCitationsSince(baseDir string, since time.Time) ([]types.CitationEvent, error) {
-	allCitations, err := LoadCitations(baseDir)
+func GetCitationsSince(baseDir string, since time.Time, opts ...CitationOption) ([]types.CitationEvent, error) {
+	allCitations, err := LoadCitations(baseDir, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -909,8 +832,8 @@ func GetCitationsSince(baseDir string, since time.Time) ([]types.CitationEvent,
 }
 
 // GetUniqueCitedArtifacts returns unique artifact paths that were cited in a period.
-func GetUniqueCitedArtifacts(baseDir string, since, until time.Time) ([]string, error) {
-	allCitations, err := LoadCitations(baseDir)
+func GetUniqueCitedArtifacts(baseDir string, since, until time.Time, opts ...CitationOption) ([]string, error) {
+	allCitations, err := LoadCitations(baseDir, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -995,8 +918,8 @@ func TierFromValidation(result *ValidationResult) Tier {
 }
 
 // GetCitationsForSession returns citations for a specific session.
-func GetCitationsForSession(baseDir, sessionID string) ([]types.CitationEvent, error) {
-	allCitations, err := LoadCitations(baseDir)
+func GetCitationsForSession(baseDir, sessionID string, opts ...CitationOption) ([]types.CitationEvent, error) {
+	allCitations, err := LoadCitations(baseDir, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,520 @@
+package ratchet
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultWorkflowName is the workflow used when none is named.
+	DefaultWorkflowName = "rpi"
+
+	// WorkflowDir holds workflow definitions, under .agents/ao.
+	WorkflowDir = "workflows"
+
+	// WorkflowChainDir holds the chains of workflows other than the
+	// default, under .agents/ao. The default workflow keeps chain.jsonl.
+	WorkflowChainDir = "chains"
+)
+
+//go:embed workflows/rpi.yaml
+var defaultWorkflowYAML []byte
+
+// Workflow is a named set of ratchet steps. Steps are listed in the order
+// they are suggested; each depends on the steps it needs.
+type Workflow struct {
+	// Name identifies the workflow (the file name without .yaml).
+	Name string `yaml:"name" json:"name"`
+
+	// Description explains what the workflow is for.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Steps are the workflow steps in suggested order.
+	Steps []WorkflowStep `yaml:"steps" json:"steps"`
+
+	// Source is the file the workflow was loaded from (empty if built in).
+	Source string `yaml:"-" json:"source,omitempty"`
+}
+
+// WorkflowStep is one step of a Workflow.
+type WorkflowStep struct {
+	// Name is the canonical step name recorded in the chain.
+	Name Step `yaml:"name" json:"name"`
+
+	// Aliases are other names accepted on the command line.
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+
+	// Skill is the command suggested to run the step.
+	Skill string `yaml:"skill,omitempty" json:"skill,omitempty"`
+
+	// Needs lists the steps that must be done first. Omitted, a step needs
+	// the step before it; an empty list means no dependencies.
+	Needs []Step `yaml:"needs,omitempty" json:"needs,omitempty"`
+
+	// Satisfies names a step this one can stand in for: completing either
+	// completes both.
+	Satisfies Step `yaml:"satisfies,omitempty" json:"satisfies,omitempty"`
+
+	// Inputs are artifact patterns (relative to .agents) that must exist
+	// before the step can run. "a OR b" accepts either; entries with a
+	// scheme such as epic: or issue: are descriptive and not checked.
+	Inputs []string `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+
+	// Outputs are artifact patterns (relative to .agents) the step
+	// produces; ao ratchet validate checks the first match by default.
+	Outputs []string `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+
+	// Gate names a built-in gate (one of the RPI steps) to use instead of
+	// checking Needs and Inputs.
+	Gate string `yaml:"gate,omitempty" json:"gate,omitempty"`
+
+	// Validator names a built-in validator (research, pre-mortem, plan,
+	// post-mortem) to run on the step's output.
+	Validator string `yaml:"validator,omitempty" json:"validator,omitempty"`
+
+	// Rules are additional checks on the step's output (see StepRules).
+	Rules *StepRules `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// builtinGates are the gate names a workflow step may use.
+var builtinGates = map[string]bool{
+	string(StepResearch): true, string(StepPreMortem): true, string(StepPlan): true,
+	string(StepImplement): true, string(StepVibe): true, string(StepPostMortem): true,
+}
+
+// builtinValidators are the validator names a workflow step may use.
+var builtinValidators = map[string]bool{
+	string(StepResearch): true, string(StepPreMortem): true, string(StepPlan): true,
+	string(StepPostMortem): true,
+}
+
+// ParseWorkflow parses and validates a workflow definition.
+func ParseWorkflow(data []byte) (*Workflow, error) {
+	var w Workflow
+	if err := yaml.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("parse workflow: %w", err)
+	}
+	for i := range w.Steps {
+		s := &w.Steps[i]
+		s.Name = Step(strings.ToLower(strings.TrimSpace(string(s.Name))))
+		if s.Needs == nil && i > 0 {
+			s.Needs = []Step{w.Steps[i-1].Name}
+		}
+	}
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+
+	// Dependencies may use aliases; store canonical names
+	for i := range w.Steps {
+		s := &w.Steps[i]
+		for j, n := range s.Needs {
+			s.Needs[j] = w.Step(string(n)).Name
+		}
+		if s.Satisfies != "" {
+			s.Satisfies = w.Step(string(s.Satisfies)).Name
+		}
+	}
+	return &w, nil
+}
+
+// validate checks the definition. Every problem is reported, not just the
+// first.
+func (w *Workflow) validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if w.Name == "" {
+		fail("name is required")
+	}
+	if len(w.Steps) == 0 {
+		fail("no steps defined")
+	}
+
+	names := make(map[string]Step)
+	for _, s := range w.Steps {
+		if s.Name == "" {
+			fail("step with no name")
+			continue
+		}
+		for _, n := range append([]string{string(s.Name)}, s.Aliases...) {
+			n = strings.ToLower(strings.TrimSpace(n))
+			if other, ok := names[n]; ok {
+				fail("step %q: name %q already used by %q", s.Name, n, other)
+				continue
+			}
+			names[n] = s.Name
+		}
+	}
+
+	for _, s := range w.Steps {
+		for _, n := range s.Needs {
+			if w.Step(string(n)) == nil {
+				fail("step %q needs unknown step %q", s.Name, n)
+			}
+		}
+		if s.Satisfies != "" && (w.Step(string(s.Satisfies)) == nil || s.Satisfies == s.Name) {
+			fail("step %q satisfies unknown step %q", s.Name, s.Satisfies)
+		}
+		if s.Gate != "" && !builtinGates[s.Gate] {
+			fail("step %q: unknown gate %q", s.Name, s.Gate)
+		}
+		if s.Validator != "" && !builtinValidators[s.Validator] {
+			fail("step %q: unknown validator %q", s.Name, s.Validator)
+		}
+		if s.Rules != nil {
+			if err := s.Rules.validate(); err != nil {
+				fail("step %q rules: %w", s.Name, err)
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		if cycle := w.findCycle(); cycle != nil {
+			fail("dependency cycle: %s", strings.Join(cycle, " → "))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("workflow %q: %w", w.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// findCycle returns the steps of a dependency cycle, or nil.
+func (w *Workflow) findCycle() []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[Step]int)
+	var path []string
+	var visit func(s *WorkflowStep) []string
+	visit = func(s *WorkflowStep) []string {
+		state[s.Name] = visiting
+		path = append(path, string(s.Name))
+		for _, n := range s.Needs {
+			dep := w.Step(string(n))
+			switch state[dep.Name] {
+			case visiting:
+				for i, p := range path {
+					if p == string(dep.Name) {
+						return append(path[i:], p)
+					}
+				}
+			case 0:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[s.Name] = visited
+		return nil
+	}
+	for i := range w.Steps {
+		if state[w.Steps[i].Name] == 0 {
+			if cycle := visit(&w.Steps[i]); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	defaultWorkflowOnce sync.Once
+	defaultWorkflow     *Workflow
+)
+
+// DefaultWorkflow returns the built-in RPI workflow. It is parsed once and
+// shared, so callers must not modify it.
+func DefaultWorkflow() *Workflow {
+	defaultWorkflowOnce.Do(func() {
+		w, err := ParseWorkflow(defaultWorkflowYAML)
+		if err != nil {
+			panic(fmt.Sprintf("built-in workflow: %v", err))
+		}
+		defaultWorkflow = w
+	})
+	return defaultWorkflow
+}
+
+// LoadWorkflow returns the named workflow from .agents/ao/workflows, or
+// the built-in RPI workflow for "rpi" (or "") when no file overrides it.
+func LoadWorkflow(startDir, name string) (*Workflow, error) {
+	if name == "" {
+		name = DefaultWorkflowName
+	}
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid workflow name %q", name)
+	}
+
+	if agentsDir, err := findAgentsDir(startDir); err == nil {
+		path := filepath.Join(agentsDir, "ao", WorkflowDir, name+".yaml")
+		data, err := os.ReadFile(path)
+		if err == nil {
+			w, err := ParseWorkflow(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if w.Name != name {
+				return nil, fmt.Errorf("%s: workflow is named %q, expected %q", path, w.Name, name)
+			}
+			w.Source = path
+			return w, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("read workflow: %w", err)
+		}
+	}
+
+	if name == DefaultWorkflowName {
+		return DefaultWorkflow(), nil
+	}
+	return nil, fmt.Errorf("unknown workflow %q (define it in .agents/ao/%s/%s.yaml)", name, WorkflowDir, name)
+}
+
+// ListWorkflows returns the built-in workflow and every workflow defined
+// in .agents/ao/workflows, sorted by name. A file that fails to load is
+// an error.
+func ListWorkflows(startDir string) ([]*Workflow, error) {
+	names := map[string]bool{DefaultWorkflowName: true}
+	if agentsDir, err := findAgentsDir(startDir); err == nil {
+		files, _ := filepath.Glob(filepath.Join(agentsDir, "ao", WorkflowDir, "*.yaml"))
+		for _, f := range files {
+			names[strings.TrimSuffix(filepath.Base(f), ".yaml")] = true
+		}
+	}
+
+	var workflows []*Workflow
+	for name := range names {
+		w, err := LoadWorkflow(startDir, name)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, w)
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].Name < workflows[j].Name })
+	return workflows, nil
+}
+
+// LoadWorkflowChain loads the chain of workflow w. The default workflow
+// uses .agents/ao/chain.jsonl (see LoadChain); others use
+// .agents/ao/chains/<name>.jsonl.
+func LoadWorkflowChain(startDir string, w *Workflow) (*Chain, error) {
+	name := w.Name
+	if name == DefaultWorkflowName {
+		chain, err := LoadChain(startDir)
+		if err != nil {
+			return nil, err
+		}
+		chain.graph = w
+		return chain, nil
+	}
+
+	chain := &Chain{
+		ID:       generateChainID(),
+		Started:  time.Now(),
+		Workflow: name,
+		Entries:  []ChainEntry{},
+		graph:    w,
+	}
+	agentsDir, err := findAgentsDir(startDir)
+	if err != nil {
+		return chain, nil
+	}
+
+	path := filepath.Join(agentsDir, "ao", WorkflowChainDir, name+".jsonl")
+	keyDir := filepath.Join(agentsDir, "ao", KeyDir)
+	loaded, err := loadJSONLChain(path)
+	if err == nil {
+		loaded.Workflow = name
+		loaded.graph = w
+		loaded.keyDir = keyDir
+		return loaded, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("load %s chain: %w", name, err)
+	}
+	chain.path = path
+	chain.keyDir = keyDir
+	return chain, nil
+}
+
+// Step returns the step with the given name or alias (case-insensitive),
+// or nil.
+func (w *Workflow) Step(name string) *WorkflowStep {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i := range w.Steps {
+		s := &w.Steps[i]
+		if string(s.Name) == name {
+			return s
+		}
+		for _, alias := range s.Aliases {
+			if strings.ToLower(alias) == name {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// StepNames returns the canonical step names in order.
+func (w *Workflow) StepNames() []Step {
+	names := make([]Step, len(w.Steps))
+	for i, s := range w.Steps {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Status returns the chain status of every step in the workflow. Steps
+// completed through a dependent step report locked.
+func (w *Workflow) Status(c *Chain) map[Step]StepStatus {
+	done := w.Done(c)
+	status := make(map[Step]StepStatus, len(w.Steps))
+	for _, s := range w.Steps {
+		st := c.GetStatus(s.Name)
+		if done[s.Name] && (st == StatusPending || st == StatusInProgress) {
+			st = StatusLocked
+		}
+		status[s.Name] = st
+	}
+	return status
+}
+
+// Needs returns the steps each step depends on in chain c: those recorded
+// with the step's latest entry, or else the workflow's.
+func (w *Workflow) Needs(c *Chain) map[Step][]Step {
+	needs := make(map[Step][]Step, len(w.Steps))
+	for _, s := range w.Steps {
+		needs[s.Name] = s.Needs
+		if e := c.GetLatest(s.Name); e != nil && e.Needs != nil {
+			needs[s.Name] = e.Needs
+		}
+	}
+	return needs
+}
+
+// Done reports which steps are complete. A step is complete when it is
+// locked or skipped, when a step that stands in for it is, or when a step
+// depending on it is: the ratchet only moves forward.
+func (w *Workflow) Done(c *Chain) map[Step]bool {
+	return w.reach(c, func(st StepStatus) bool {
+		return st == StatusLocked || st == StatusSkipped
+	})
+}
+
+// locked reports which steps are locked: directly, through a step that
+// stands in for them, or through a locked step depending on them. Unlike
+// Done, skipped steps lock nothing.
+func (w *Workflow) locked(c *Chain) map[Step]bool {
+	return w.reach(c, func(st StepStatus) bool { return st == StatusLocked })
+}
+
+// reach marks the steps whose own status passes seed, then everything they
+// need or stand in for, and the steps standing in for any marked step.
+func (w *Workflow) reach(c *Chain, seed func(StepStatus) bool) map[Step]bool {
+	needs := w.Needs(c)
+	done := make(map[Step]bool, len(w.Steps))
+	for _, s := range w.Steps {
+		done[s.Name] = seed(c.GetStatus(s.Name))
+	}
+
+	for changed := true; changed; {
+		changed = false
+		mark := func(s Step) {
+			if !done[s] {
+				done[s] = true
+				changed = true
+			}
+		}
+		for _, s := range w.Steps {
+			if !done[s.Name] {
+				continue
+			}
+			for _, n := range needs[s.Name] {
+				mark(n)
+			}
+			if s.Satisfies != "" {
+				mark(s.Satisfies)
+			}
+		}
+		for _, s := range w.Steps {
+			if s.Satisfies != "" && done[s.Satisfies] {
+				mark(s.Name)
+			}
+		}
+	}
+	return done
+}
+
+// Next returns the first step that is not done and whose dependencies
+// are, or nil once the workflow is complete.
+func (w *Workflow) Next(c *Chain) *WorkflowStep {
+	if ready := w.Ready(c); len(ready) > 0 {
+		return ready[0]
+	}
+	return nil
+}
+
+// Ready returns every step that is not done and whose dependencies are:
+// the steps that can run now, in parallel. A step that stands in for a
+// ready step is left out; running either completes both.
+func (w *Workflow) Ready(c *Chain) []*WorkflowStep {
+	done := w.Done(c)
+	needs := w.Needs(c)
+	var ready []*WorkflowStep
+	included := make(map[Step]bool)
+	for i := range w.Steps {
+		s := &w.Steps[i]
+		if done[s.Name] || included[s.Satisfies] {
+			continue
+		}
+		blocked := false
+		for _, n := range needs[s.Name] {
+			if !done[n] {
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			ready = append(ready, s)
+			included[s.Name] = true
+		}
+	}
+	return ready
+}
+
+// ArtifactPatterns splits an Inputs or Outputs entry into its alternatives.
+// Descriptive entries (epic:, issue: and the like) yield nil.
+func ArtifactPatterns(entry string) []string {
+	var patterns []string
+	for _, alt := range strings.Split(entry, " OR ") {
+		alt = strings.TrimSpace(alt)
+		if alt == "" || isDescriptiveArtifact(alt) {
+			continue
+		}
+		patterns = append(patterns, strings.TrimPrefix(alt, ".agents/"))
+	}
+	return patterns
+}
+
+// isDescriptiveArtifact reports whether an artifact reference names
+// something other than a file, such as epic:<id>.
+func isDescriptiveArtifact(ref string) bool {
+	i := strings.Index(ref, ":")
+	return i > 0 && !strings.ContainsAny(ref[:i], `/\.*`)
+}
@@ -0,0 +1,253 @@
+package ratchet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const incidentWorkflow = `name: incident
+description: Incident response
+steps:
+  - name: triage
+    aliases: [assess]
+    skill: /research
+    outputs: [incidents/*.md]
+    rules:
+      sections: ["## Impact"]
+      frontmatter: [severity]
+  - name: mitigate
+    skill: /implement
+    inputs: [incidents/*.md]
+  - name: comms
+    skill: /notify
+    needs: [triage]
+  - name: retro
+    needs: [mitigate, comms]
+    validator: post-mortem
+`
+
+func chainOf(steps ...Step) *Chain {
+	c := &Chain{Entries: []ChainEntry{}}
+	for _, s := range steps {
+		c.Entries = append(c.Entries, ChainEntry{Step: s, Timestamp: time.Now(), Locked: true})
+	}
+	return c
+}
+
+func TestDefaultWorkflow_MatchesParseStep(t *testing.T) {
+	w := DefaultWorkflow()
+	if got := len(w.Steps); got != len(AllSteps()) {
+		t.Fatalf("default workflow has %d steps, want %d", got, len(AllSteps()))
+	}
+	for i, s := range AllSteps() {
+		if w.Steps[i].Name != s {
+			t.Errorf("step %d = %s, want %s", i, w.Steps[i].Name, s)
+		}
+	}
+	for alias, want := range stepAliases {
+		if s := w.Step(alias); s == nil || s.Name != want {
+			t.Errorf("Step(%q) = %v, want %s", alias, s, want)
+		}
+	}
+}
+
+func TestDefaultWorkflow_ParsedOnce(t *testing.T) {
+	if DefaultWorkflow() != DefaultWorkflow() {
+		t.Error("DefaultWorkflow should return the workflow parsed on first use")
+	}
+}
+
+func TestDefaultWorkflow_Next(t *testing.T) {
+	w := DefaultWorkflow()
+	tests := []struct {
+		chain *Chain
+		want  Step
+	}{
+		{chainOf(), StepResearch},
+		{chainOf(StepResearch), StepPreMortem},
+		{chainOf(StepPlan), StepImplement},
+		{chainOf(StepCrank), StepVibe},
+		{chainOf(StepImplement), StepVibe},
+		{chainOf(StepResearch, StepVibe), StepPostMortem},
+		{chainOf(StepPostMortem), ""},
+	}
+	for _, tt := range tests {
+		var got Step
+		if s := w.Next(tt.chain); s != nil {
+			got = s.Name
+		}
+		if got != tt.want {
+			t.Errorf("Next(%v) = %q, want %q", tt.chain.Entries, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultWorkflow_ResearchAndPreMortemInParallel(t *testing.T) {
+	w := DefaultWorkflow()
+	var ready []Step
+	for _, s := range w.Ready(chainOf()) {
+		ready = append(ready, s.Name)
+	}
+	if len(ready) != 2 || ready[0] != StepResearch || ready[1] != StepPreMortem {
+		t.Errorf("Ready(empty chain) = %v, want [research pre-mortem]", ready)
+	}
+	if next := w.Next(chainOf(StepPreMortem)); next == nil || next.Name != StepResearch {
+		t.Errorf("Next after pre-mortem alone = %v, want research", next)
+	}
+}
+
+func TestParseWorkflow_Dependencies(t *testing.T) {
+	w, err := ParseWorkflow([]byte(incidentWorkflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Step("mitigate").Needs; len(got) != 1 || got[0] != "triage" {
+		t.Errorf("mitigate needs %v, want the step before it", got)
+	}
+	if w.Step("triage").Needs != nil {
+		t.Error("first step should need nothing")
+	}
+	if w.Step("ASSESS") == nil {
+		t.Error("aliases should match case-insensitively")
+	}
+
+	// retro waits for both branches
+	c := chainOf("triage", "mitigate")
+	if next := w.Next(c); next == nil || next.Name != "comms" {
+		t.Errorf("Next = %v, want comms", next)
+	}
+	done := w.Done(chainOf("retro"))
+	for _, s := range w.StepNames() {
+		if !done[s] {
+			t.Errorf("locking retro should complete %s", s)
+		}
+	}
+}
+
+func TestParseWorkflow_ReportsEveryProblem(t *testing.T) {
+	_, err := ParseWorkflow([]byte(`name: broken
+steps:
+  - name: a
+    aliases: [b]
+    gate: deploy
+  - name: b
+    needs: [missing]
+    validator: lint
+`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`name "b" already used by "a"`,
+		`unknown gate "deploy"`,
+		`needs unknown step "missing"`,
+		`unknown validator "lint"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestParseWorkflow_Cycle(t *testing.T) {
+	_, err := ParseWorkflow([]byte(`name: loop
+steps:
+  - name: start
+    needs: []
+  - name: a
+    needs: [c]
+  - name: b
+  - name: c
+`))
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: a → c → b → a") {
+		t.Errorf("err = %v, want the cycle a → c → b → a", err)
+	}
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	dir := t.TempDir()
+	wfDir := filepath.Join(dir, ".agents", "ao", WorkflowDir)
+	if err := os.MkdirAll(wfDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(wfDir, "incident.yaml"), []byte(incidentWorkflow), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := LoadWorkflow(dir, "incident")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Source == "" {
+		t.Error("Source not set")
+	}
+	if w, err := LoadWorkflow(dir, ""); err != nil || w.Name != DefaultWorkflowName {
+		t.Errorf("default workflow = %v, %v", w, err)
+	}
+	if _, err := LoadWorkflow(dir, "deploy"); err == nil {
+		t.Error("expected an error for an undefined workflow")
+	}
+
+	all, err := ListWorkflows(dir)
+	if err != nil || len(all) != 2 || all[0].Name != "incident" || all[1].Name != "rpi" {
+		t.Errorf("ListWorkflows = %v, %v", all, err)
+	}
+
+	// Each workflow keeps its own chain
+	c, err := LoadWorkflowChain(dir, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Append(ChainEntry{Step: "triage", Timestamp: time.Now(), Locked: true}); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".agents", "ao", WorkflowChainDir, "incident.jsonl"); c.Path() != want {
+		t.Errorf("chain path = %s, want %s", c.Path(), want)
+	}
+	reloaded, err := LoadWorkflowChain(dir, w)
+	if err != nil || len(reloaded.Entries) != 1 || reloaded.Workflow != "incident" {
+		t.Errorf("reloaded chain = %+v, %v", reloaded, err)
+	}
+	if rpi, _ := LoadChain(dir); len(rpi.Entries) != 0 {
+		t.Error("incident entries leaked into the RPI chain")
+	}
+}
+
+func TestValidateWorkflowStep_Rules(t *testing.T) {
+	dir := t.TempDir()
+	w, err := ParseWorkflow([]byte(incidentWorkflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewValidator(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "db.md")
+	if err := os.WriteFile(path, []byte("---\nschema_version: 1\n---\n# DB outage\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, err := v.ValidateWorkflowStep(w.Step("triage"), path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || len(result.Issues) != 2 {
+		t.Errorf("result = %+v, want the missing section and field", result)
+	}
+
+	if err := os.WriteFile(path, []byte("---\nschema_version: 1\nseverity: sev2\n---\n# DB outage\n\n## Impact\nWrites failed.\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := v.ValidateWorkflowStep(w.Step("triage"), path, nil); !result.Valid {
+		t.Errorf("result = %+v, want valid", result)
+	}
+
+	result, _ = v.ValidateWorkflowStep(w.Step("comms"), path, nil)
+	if len(result.Warnings) == 0 || !strings.Contains(result.Warnings[len(result.Warnings)-1], "No validation rules") {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+}
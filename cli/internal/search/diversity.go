@@ -0,0 +1,77 @@
+package search
+
+import "sort"
+
+// MMR selects up to k items by maximal marginal relevance: each pick
+// maximizes lambda*relevance - (1-lambda)*(max similarity to the items
+// already picked). relevance should be scaled to [0, 1] so it is comparable
+// with cosine similarity; vectors[i] is item i's embedding. lambda = 1 ranks
+// by relevance alone; lower values trade relevance for diversity. The
+// returned indices are in pick order.
+func MMR(relevance []float64, vectors [][]float32, lambda float64, k int) []int {
+	n := len(relevance)
+	if k <= 0 || k > n {
+		k = n
+	}
+
+	picked := make([]int, 0, k)
+	used := make([]bool, n)
+	maxSim := make([]float64, n) // max similarity to any picked item
+
+	for len(picked) < k {
+		best, bestScore := -1, 0.0
+		for i := 0; i < n; i++ {
+			if used[i] {
+				continue
+			}
+			score := lambda * relevance[i]
+			if len(picked) > 0 {
+				score -= (1 - lambda) * maxSim[i]
+			}
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		used[best] = true
+		picked = append(picked, best)
+		for i := 0; i < n; i++ {
+			if !used[i] {
+				if sim := Cosine(vectors[i], vectors[best]); sim > maxSim[i] {
+					maxSim[i] = sim
+				}
+			}
+		}
+	}
+	return picked
+}
+
+// ClusterNearDuplicates groups items whose embeddings have cosine
+// similarity of at least threshold. Items are visited in descending
+// relevance; each joins the first cluster whose leader it resembles, or
+// leads a new one. Clusters are returned leader first, ordered by their
+// leader's relevance.
+func ClusterNearDuplicates(relevance []float64, vectors [][]float32, threshold float64) [][]int {
+	order := make([]int, len(relevance))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return relevance[order[a]] > relevance[order[b]]
+	})
+
+	var clusters [][]int
+	for _, i := range order {
+		joined := false
+		for c := range clusters {
+			if Cosine(vectors[i], vectors[clusters[c][0]]) >= threshold {
+				clusters[c] = append(clusters[c], i)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			clusters = append(clusters, []int{i})
+		}
+	}
+	return clusters
+}
@@ -0,0 +1,35 @@
+package search
+
+import "testing"
+
+func TestMMRPrefersNovelItems(t *testing.T) {
+	// 0 and 1 are identical; 2 is different but slightly less relevant.
+	vectors := [][]float32{{1, 0}, {1, 0}, {0, 1}}
+	relevance := []float64{1, 0.95, 0.8}
+
+	if got := MMR(relevance, vectors, 1, 2); got[0] != 0 || got[1] != 1 {
+		t.Errorf("lambda=1 should rank by relevance, got %v", got)
+	}
+	if got := MMR(relevance, vectors, 0.5, 2); got[0] != 0 || got[1] != 2 {
+		t.Errorf("lambda=0.5 should skip the duplicate, got %v", got)
+	}
+	if got := MMR(relevance, vectors, 0.5, 0); len(got) != 3 {
+		t.Errorf("k<=0 should return all items, got %v", got)
+	}
+}
+
+func TestClusterNearDuplicates(t *testing.T) {
+	vectors := [][]float32{{0, 1}, {1, 0}, {0.99, 0.14}, {0, 1}}
+	relevance := []float64{0.2, 0.9, 0.5, 0.1}
+
+	clusters := ClusterNearDuplicates(relevance, vectors, 0.9)
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %v", clusters)
+	}
+	if clusters[0][0] != 1 || len(clusters[0]) != 2 || clusters[0][1] != 2 {
+		t.Errorf("first cluster = %v, want [1 2]", clusters[0])
+	}
+	if clusters[1][0] != 0 || len(clusters[1]) != 2 || clusters[1][1] != 3 {
+		t.Errorf("second cluster = %v, want [0 3]", clusters[1])
+	}
+}
@@ -0,0 +1,217 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Embedder turns text into dense vectors for semantic search.
+type Embedder interface {
+	// Name identifies the embedding model. Vectors produced under different
+	// names are not comparable, so stores keyed by one name are discarded
+	// when another is used.
+	Name() string
+
+	// Embed returns one vector per input text, in order.
+	Embed(texts []string) ([][]float32, error)
+}
+
+// DefaultHashDims is the vector width used by NewHashEmbedder when dims <= 0.
+const DefaultHashDims = 512
+
+// HashEmbedder is an offline embedder that projects word unigrams, word
+// bigrams and character trigrams into a fixed-width vector with the hashing
+// trick. It needs no model download and is deterministic, which makes it a
+// reasonable default: it captures shared vocabulary and spelling variants
+// (mutex/mutexes) rather than true synonymy.
+type HashEmbedder struct {
+	Dims int
+}
+
+// NewHashEmbedder creates a HashEmbedder with the given width.
+func NewHashEmbedder(dims int) *HashEmbedder {
+	if dims <= 0 {
+		dims = DefaultHashDims
+	}
+	return &HashEmbedder{Dims: dims}
+}
+
+// Name implements Embedder.
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("hash-ngram-%d", e.Dims)
+}
+
+// Embed implements Embedder.
+func (e *HashEmbedder) Embed(texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = e.embedOne(text)
+	}
+	return out, nil
+}
+
+// embedOne builds a sublinear-TF weighted, L2-normalised feature vector.
+func (e *HashEmbedder) embedOne(text string) []float32 {
+	counts := make(map[string]float64)
+	tokens := tokenStream(text)
+	for i, tok := range tokens {
+		counts["w:"+tok]++
+		if i > 0 {
+			counts["b:"+tokens[i-1]+" "+tok] += 0.5
+		}
+		padded := "^" + tok + "$"
+		for j := 0; j+3 <= len(padded); j++ {
+			counts["c:"+padded[j:j+3]] += 0.25
+		}
+	}
+
+	vec := make([]float32, e.Dims)
+	for feature, count := range counts {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(feature))
+		sum := h.Sum64()
+		weight := 1 + math.Log1p(count)
+		if sum>>63 == 1 {
+			weight = -weight // signed hashing keeps collisions unbiased
+		}
+		vec[sum%uint64(e.Dims)] += float32(weight)
+	}
+	normalize(vec)
+	return vec
+}
+
+// HTTPEmbedder calls a local embedding server. It speaks the
+// OpenAI-compatible /v1/embeddings shape ({"model", "input"} in,
+// {"data": [{"embedding"}]} out) served by llama.cpp, LM Studio, vLLM and
+// Ollama, and also accepts Ollama's native /api/embed response
+// ({"embeddings": [[...]]}).
+type HTTPEmbedder struct {
+	URL    string
+	Model  string
+	Client *http.Client
+}
+
+// NewHTTPEmbedder creates an HTTPEmbedder with a 30s request timeout.
+func NewHTTPEmbedder(url, model string) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		URL:    url,
+		Model:  model,
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name implements Embedder.
+func (e *HTTPEmbedder) Name() string {
+	if e.Model != "" {
+		return "http:" + e.Model
+	}
+	return "http:" + e.URL
+}
+
+// Embed implements Embedder.
+func (e *HTTPEmbedder) Embed(texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"model": e.Model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal embed request: %w", err)
+	}
+
+	resp, err := e.Client.Post(e.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("embed request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close() //nolint:errcheck // HTTP response body close best-effort
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embed server error: %s", resp.Status)
+	}
+
+	var parsed struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("parse embed response: %w", err)
+	}
+
+	vectors := parsed.Embeddings
+	if len(parsed.Data) > 0 {
+		vectors = make([][]float32, len(parsed.Data))
+		for i, d := range parsed.Data {
+			pos := d.Index
+			if pos < 0 || pos >= len(vectors) {
+				pos = i
+			}
+			vectors[pos] = d.Embedding
+		}
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embed server returned %d vectors for %d inputs", len(vectors), len(texts))
+	}
+	for _, v := range vectors {
+		normalize(v)
+	}
+	return vectors, nil
+}
+
+// Cosine returns the cosine similarity of a and b. Vectors produced by the
+// embedders in this package are already unit length, so this is a dot
+// product; mismatched widths yield 0.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+// normalize scales v to unit length in place.
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	inv := float32(1 / math.Sqrt(sum))
+	for i := range v {
+		v[i] *= inv
+	}
+}
+
+// NewEmbedder returns the embedder called kind: "hash" (default, offline) or
+// "http" (requires url).
+func NewEmbedder(kind, url, model string) (Embedder, error) {
+	switch strings.ToLower(kind) {
+	case "", "hash":
+		return NewHashEmbedder(DefaultHashDims), nil
+	case "http":
+		if url == "" {
+			return nil, fmt.Errorf("http embedder requires an endpoint URL")
+		}
+		return NewHTTPEmbedder(url, model), nil
+	default:
+		return nil, fmt.Errorf("unknown embedder %q (want hash or http)", kind)
+	}
+}
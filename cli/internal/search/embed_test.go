@@ -0,0 +1,117 @@
+package search
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHashEmbedderDeterministicUnitVectors(t *testing.T) {
+	e := NewHashEmbedder(0)
+	if e.Dims != DefaultHashDims {
+		t.Fatalf("Dims = %d, want default %d", e.Dims, DefaultHashDims)
+	}
+
+	vecs, err := e.Embed([]string{"mutex guards shared state", "mutex guards shared state"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if got := Cosine(vecs[0], vecs[1]); math.Abs(got-1) > 1e-5 {
+		t.Errorf("identical texts: cosine = %v, want 1", got)
+	}
+
+	empty, _ := e.Embed([]string{""})
+	if got := Cosine(empty[0], vecs[0]); got != 0 {
+		t.Errorf("empty text: cosine = %v, want 0", got)
+	}
+}
+
+func TestHashEmbedderSimilarity(t *testing.T) {
+	e := NewHashEmbedder(DefaultHashDims)
+	vecs, _ := e.Embed([]string{
+		"acquire mutexes in a fixed order to avoid deadlock",
+		"deadlocks happen when mutex acquisition order differs",
+		"configure the oauth token refresh interval",
+	})
+
+	related := Cosine(vecs[0], vecs[1])
+	unrelated := Cosine(vecs[0], vecs[2])
+	if related <= unrelated {
+		t.Errorf("related cosine %.3f should exceed unrelated %.3f", related, unrelated)
+	}
+}
+
+func TestHTTPEmbedder(t *testing.T) {
+	tests := []struct {
+		name     string
+		response interface{}
+	}{
+		{
+			name: "openai shape, out of order",
+			response: map[string]interface{}{
+				"data": []map[string]interface{}{
+					{"index": 1, "embedding": []float32{0, 2}},
+					{"index": 0, "embedding": []float32{3, 0}},
+				},
+			},
+		},
+		{
+			name:     "ollama shape",
+			response: map[string]interface{}{"embeddings": [][]float32{{3, 0}, {0, 2}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Model string   `json:"model"`
+					Input []string `json:"input"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "nomic" || len(req.Input) != 2 {
+					http.Error(w, "bad request", http.StatusBadRequest)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer srv.Close()
+
+			e := NewHTTPEmbedder(srv.URL, "nomic")
+			if e.Name() != "http:nomic" {
+				t.Errorf("Name = %q", e.Name())
+			}
+			vecs, err := e.Embed([]string{"a", "b"})
+			if err != nil {
+				t.Fatalf("Embed: %v", err)
+			}
+			// Vectors come back normalised and in input order.
+			if vecs[0][0] != 1 || vecs[1][1] != 1 {
+				t.Errorf("vectors = %v, want [[1 0] [0 1]]", vecs)
+			}
+		})
+	}
+
+	t.Run("server error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+		if _, err := NewHTTPEmbedder(srv.URL, "m").Embed([]string{"a"}); err == nil {
+			t.Error("expected error on HTTP 500")
+		}
+	})
+}
+
+func TestNewEmbedder(t *testing.T) {
+	if e, err := NewEmbedder("", "", ""); err != nil || e.Name() != "hash-ngram-512" {
+		t.Errorf("NewEmbedder(default) = %v, %v", e, err)
+	}
+	if _, err := NewEmbedder("http", "", ""); err == nil {
+		t.Error("expected error for http embedder without URL")
+	}
+	if _, err := NewEmbedder("word2vec", "", ""); err == nil {
+		t.Error("expected error for unknown embedder")
+	}
+}
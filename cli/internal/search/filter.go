@@ -0,0 +1,308 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FilterQuery is a parsed search query: free text for ranking plus field
+// filters and excluded terms evaluated against document metadata.
+//
+// Syntax, with terms separated by whitespace:
+//
+//	word, "exact phrase"    free text (kept in Text for ranking)
+//	-word, -"a phrase"      exclude documents containing it
+//	field:value             filter; prefix with - to negate
+//
+// Fields: type, maturity, tier, status and tag compare strings
+// case-insensitively; utility and confidence take an optional comparison
+// (utility:>0.6, confidence:<=0.3); since and until take a relative age
+// (30d, 2w, 12h) or a date (2026-01-31). A word whose prefix is not a known
+// field (e.g. "error:") stays free text.
+type FilterQuery struct {
+	// Text is the free-text part, with phrase quotes preserved.
+	Text string
+
+	// Filters are the field filters, all of which must match.
+	Filters []Filter
+
+	// Excluded are lowercase terms or phrases that must not appear.
+	Excluded []string
+}
+
+// Filter is one field:value condition.
+type Filter struct {
+	Field  string
+	Op     string // "=", ">", ">=", "<", "<="
+	Value  string
+	Negate bool
+
+	num  float64   // parsed value for numeric fields
+	date time.Time // resolved bound for since/until; until's is exclusive
+}
+
+// Fields is the metadata a FilterQuery is evaluated against. Callers fill in
+// what their documents carry; filters on fields a document lacks (empty
+// strings, zero dates) do not match.
+type Fields struct {
+	Type       string
+	Maturity   string
+	Tier       string
+	Status     string
+	Tags       []string
+	Utility    float64
+	Confidence float64
+	Date       time.Time
+
+	// Text is the searchable content, used for excluded terms.
+	Text string
+}
+
+// filterFields lists the recognised field names.
+var filterFields = map[string]bool{
+	"type": true, "maturity": true, "tier": true, "status": true,
+	"tag": true, "tags": true, "utility": true, "confidence": true,
+	"since": true, "until": true,
+}
+
+// ParseFilterQuery parses query, resolving relative dates against now.
+func ParseFilterQuery(query string, now time.Time) (*FilterQuery, error) {
+	q := &FilterQuery{}
+	var text []string
+
+	for _, tok := range splitQueryTokens(query) {
+		negate := len(tok) > 1 && tok[0] == '-'
+		body := tok
+		if negate {
+			body = tok[1:]
+		}
+
+		if field, value, ok := strings.Cut(body, ":"); ok && !strings.HasPrefix(body, `"`) && filterFields[strings.ToLower(field)] {
+			f, err := parseFilter(strings.ToLower(field), value, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", tok, err)
+			}
+			f.Negate = negate
+			q.Filters = append(q.Filters, f)
+			continue
+		}
+
+		if negate {
+			if term := strings.ToLower(strings.Trim(body, `"`)); term != "" {
+				q.Excluded = append(q.Excluded, term)
+			}
+			continue
+		}
+		text = append(text, tok)
+	}
+
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// AddFilter adds a field:value filter, as if it were part of the query.
+func (q *FilterQuery) AddFilter(field, value string, now time.Time) error {
+	f, err := parseFilter(strings.ToLower(field), value, now)
+	if err != nil {
+		return fmt.Errorf("invalid filter %q: %w", field+":"+value, err)
+	}
+	q.Filters = append(q.Filters, f)
+	return nil
+}
+
+// HasFilters reports whether the query constrains anything beyond free text.
+func (q *FilterQuery) HasFilters() bool {
+	return len(q.Filters) > 0 || len(q.Excluded) > 0
+}
+
+// Match reports whether f satisfies every filter and contains no excluded
+// term. Free text is not checked; rank or test it with MatchText.
+func (q *FilterQuery) Match(f Fields) bool {
+	for _, filter := range q.Filters {
+		if filter.match(f) == filter.Negate {
+			return false
+		}
+	}
+	if len(q.Excluded) > 0 {
+		lower := strings.ToLower(f.Text)
+		for _, term := range q.Excluded {
+			if strings.Contains(lower, term) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MatchText reports whether text contains every free-text word and phrase
+// of the query, case-insensitively. A word ending in * matches any word
+// with that prefix. An empty Text matches everything.
+func (q *FilterQuery) MatchText(text string) bool {
+	lower := strings.ToLower(text)
+	var words []string
+	for _, tok := range splitQueryTokens(q.Text) {
+		if prefix, ok := strings.CutSuffix(tok, "*"); ok && !strings.HasPrefix(tok, `"`) {
+			if words == nil {
+				words = tokenize(text)
+			}
+			if !hasWordPrefix(words, strings.ToLower(prefix)) {
+				return false
+			}
+			continue
+		}
+		if !strings.Contains(lower, strings.ToLower(strings.Trim(tok, `"`))) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasWordPrefix reports whether any of words starts with prefix.
+func hasWordPrefix(words []string, prefix string) bool {
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFilter validates value for field and pre-parses numbers and dates.
+func parseFilter(field, value string, now time.Time) (Filter, error) {
+	if field == "tags" {
+		field = "tag"
+	}
+	f := Filter{Field: field, Op: "="}
+
+	switch field {
+	case "utility", "confidence":
+		for _, op := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(value, op) {
+				f.Op = op
+				value = value[len(op):]
+				break
+			}
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return f, fmt.Errorf("%s needs a number, got %q", field, value)
+		}
+		f.num = n
+	case "since", "until":
+		t, err := parseDateBound(value, now)
+		if err != nil {
+			return f, err
+		}
+		if _, dateErr := time.Parse("2006-01-02", value); field == "until" && dateErr == nil {
+			t = t.AddDate(0, 0, 1) // until a date includes the whole day
+		}
+		f.date = t
+	default:
+		if value == "" {
+			return f, fmt.Errorf("%s needs a value", field)
+		}
+	}
+
+	f.Value = value
+	return f, nil
+}
+
+// match evaluates the filter, ignoring Negate.
+func (f Filter) match(fields Fields) bool {
+	switch f.Field {
+	case "type":
+		t := strings.ToLower(fields.Type)
+		v := strings.ToLower(f.Value)
+		return t != "" && (t == v || t+"s" == v || t == v+"s")
+	case "maturity":
+		return strings.EqualFold(fields.Maturity, f.Value)
+	case "tier":
+		return strings.EqualFold(fields.Tier, f.Value)
+	case "status":
+		return strings.EqualFold(fields.Status, f.Value)
+	case "tag":
+		for _, tag := range fields.Tags {
+			if strings.EqualFold(strings.TrimPrefix(tag, "#"), strings.TrimPrefix(f.Value, "#")) {
+				return true
+			}
+		}
+		return false
+	case "utility":
+		return compareFloat(fields.Utility, f.Op, f.num)
+	case "confidence":
+		return compareFloat(fields.Confidence, f.Op, f.num)
+	case "since":
+		return !fields.Date.IsZero() && !fields.Date.Before(f.date)
+	case "until":
+		return !fields.Date.IsZero() && fields.Date.Before(f.date)
+	}
+	return false
+}
+
+// compareFloat applies op to a and b; "=" tolerates float rounding.
+func compareFloat(a float64, op string, b float64) bool {
+	switch op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	default:
+		d := a - b
+		return d < 1e-9 && d > -1e-9
+	}
+}
+
+// parseDateBound accepts a relative age (30d, 2w, 12h) measured back from
+// now, or an absolute YYYY-MM-DD date.
+func parseDateBound(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if len(value) >= 2 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			switch value[len(value)-1] {
+			case 'h':
+				return now.Add(-time.Duration(n) * time.Hour), nil
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("want an age like 30d, 2w or 12h, or a date like 2026-01-31, got %q", value)
+}
+
+// splitQueryTokens splits on whitespace, keeping double-quoted runs (and any
+// prefix such as - or field:) together with their quotes.
+func splitQueryTokens(query string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuote:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
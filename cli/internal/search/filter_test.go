@@ -0,0 +1,152 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFilterQuery(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	q, err := ParseFilterQuery(`maturity:established utility:>0.6 tag:auth type:learning since:30d -deprecated "exact phrase" token`, now)
+	if err != nil {
+		t.Fatalf("ParseFilterQuery: %v", err)
+	}
+
+	if q.Text != `"exact phrase" token` {
+		t.Errorf("Text = %q", q.Text)
+	}
+	if len(q.Filters) != 5 {
+		t.Fatalf("expected 5 filters, got %+v", q.Filters)
+	}
+	if f := q.Filters[1]; f.Field != "utility" || f.Op != ">" || f.num != 0.6 {
+		t.Errorf("utility filter = %+v", f)
+	}
+	if want := now.AddDate(0, 0, -30); !q.Filters[4].date.Equal(want) {
+		t.Errorf("since bound = %v, want %v", q.Filters[4].date, want)
+	}
+	if len(q.Excluded) != 1 || q.Excluded[0] != "deprecated" {
+		t.Errorf("Excluded = %v", q.Excluded)
+	}
+}
+
+func TestParseFilterQueryUnknownFieldIsText(t *testing.T) {
+	q, err := ParseFilterQuery("error: timeout http://host", time.Now())
+	if err != nil {
+		t.Fatalf("ParseFilterQuery: %v", err)
+	}
+	if q.HasFilters() || q.Text != "error: timeout http://host" {
+		t.Errorf("expected plain text, got %+v", q)
+	}
+}
+
+func TestParseFilterQueryErrors(t *testing.T) {
+	for _, query := range []string{"utility:>high", "since:soon", "maturity:", "confidence:"} {
+		if _, err := ParseFilterQuery(query, time.Now()); err == nil {
+			t.Errorf("expected error for %q", query)
+		}
+	}
+}
+
+func TestFilterQueryMatch(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	doc := Fields{
+		Type:     "learning",
+		Maturity: "established",
+		Tags:     []string{"auth", "security"},
+		Utility:  0.8,
+		Date:     now.AddDate(0, 0, -3),
+		Text:     "Refresh tokens before expiry",
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"maturity:established", true},
+		{"maturity:provisional", false},
+		{"type:learnings", true},
+		{"tag:AUTH", true},
+		{"tag:db", false},
+		{"-tag:auth", false},
+		{"utility:>0.6", true},
+		{"utility:>=0.8", true},
+		{"utility:<0.5", false},
+		{"since:7d", true},
+		{"since:1d", false},
+		{"until:2026-02-01", false},
+		{"-deprecated", true},
+		{"-expiry", false},
+		{`-"refresh tokens"`, false},
+		{"confidence:>0", false},
+	}
+	for _, tt := range tests {
+		q, err := ParseFilterQuery(tt.query, now)
+		if err != nil {
+			t.Fatalf("ParseFilterQuery(%q): %v", tt.query, err)
+		}
+		if got := q.Match(doc); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFilterQueryUntilIncludesTheDay(t *testing.T) {
+	doc := Fields{Date: time.Date(2026, 1, 31, 15, 4, 0, 0, time.UTC)}
+	for query, want := range map[string]bool{
+		"until:2026-01-31": true,
+		"until:2026-01-30": false,
+		"since:2026-01-31": true,
+		"since:2026-02-01": false,
+	} {
+		q, err := ParseFilterQuery(query, time.Now())
+		if err != nil {
+			t.Fatalf("ParseFilterQuery(%q): %v", query, err)
+		}
+		if got := q.Match(doc); got != want {
+			t.Errorf("Match(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestFilterQueryAddFilter(t *testing.T) {
+	q, err := ParseFilterQuery("tokens", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.AddFilter("type", "decisions", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !q.HasFilters() || !q.Match(Fields{Type: "decision"}) || q.Match(Fields{Type: "session"}) {
+		t.Errorf("type filter not applied: %+v", q.Filters)
+	}
+	if err := q.AddFilter("utility", ">high", time.Now()); err == nil {
+		t.Error("expected an error for a bad filter value")
+	}
+}
+
+func TestFilterQueryMatchText(t *testing.T) {
+	q, err := ParseFilterQuery(`tag:x "lock ordering" deadlock`, time.Now())
+	if err != nil {
+		t.Fatalf("ParseFilterQuery: %v", err)
+	}
+	if !q.MatchText("Deadlock avoided by consistent LOCK ORDERING") {
+		t.Error("expected text match")
+	}
+	if q.MatchText("deadlock: ordering of locks") {
+		t.Error("phrase should require adjacency")
+	}
+
+	prefix, _ := ParseFilterQuery("migrat* tag:x", time.Now())
+	if !prefix.MatchText("Schema Migrations run at startup") {
+		t.Error("migrat* should match migrations")
+	}
+	if prefix.MatchText("emigrate the data") {
+		t.Error("migrat* should only match at the start of a word")
+	}
+
+	empty, _ := ParseFilterQuery("tag:x", time.Now())
+	if !empty.MatchText(strings.Repeat("anything ", 3)) {
+		t.Error("empty text should match everything")
+	}
+}
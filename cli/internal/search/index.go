@@ -1,11 +1,13 @@
-// Package search provides an inverted index for fast keyword-based search
-// across AgentOps session and knowledge files.
+// Package search provides a positional inverted index with BM25 ranking for
+// fast keyword search across AgentOps session and knowledge files.
 package search
 
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -13,111 +15,185 @@ import (
 	"unicode"
 )
 
-// Index is an in-memory inverted index mapping lowercase terms to the
-// set of document paths that contain them.
+// IndexVersion is the on-disk format version written by SaveIndex.
+// LoadIndex rejects files written with any other version.
+const IndexVersion = 2
+
+// ErrIndexVersion is returned by LoadIndex when the index file was written in
+// an unsupported (usually older) format and must be rebuilt.
+var ErrIndexVersion = errors.New("unsupported search index version")
+
+// Index is an in-memory positional inverted index. For every lowercase term
+// it records which documents contain the term and at which token positions,
+// which is enough for BM25 scoring and phrase matching.
 type Index struct {
-	// Terms maps each lowercase term to a set of document paths.
-	Terms map[string]map[string]bool `json:"-"`
-}
+	// Terms maps each lowercase term to its postings: document path to the
+	// ascending token positions of the term within that document.
+	Terms map[string]map[string][]int `json:"-"`
+
+	// DocLengths maps each document path to its length in tokens.
+	DocLengths map[string]int `json:"-"`
+
+	// docTerms is the forward map (document path to the distinct terms it
+	// contains) so RemoveDocument touches only that document's postings.
+	docTerms map[string][]string
 
-// IndexEntry is the JSONL-serialised form: one line per term.
-type IndexEntry struct {
-	Term  string   `json:"term"`
-	Paths []string `json:"paths"`
+	// totalLength is the sum of DocLengths, kept for the BM25 average.
+	totalLength int
 }
 
 // IndexResult is returned by Search.
 type IndexResult struct {
 	Path  string
-	Score int // number of query terms matched
+	Score float64 // BM25 relevance score
+}
+
+// indexRecord is the JSONL-serialised form. The first line carries only the
+// format version; it is followed by one line per document (Doc, Length) and
+// one line per term (Term, Postings).
+type indexRecord struct {
+	Version  int              `json:"version,omitempty"`
+	Doc      string           `json:"doc,omitempty"`
+	Length   int              `json:"length,omitempty"`
+	Term     string           `json:"term,omitempty"`
+	Postings map[string][]int `json:"postings,omitempty"`
 }
 
 // NewIndex creates an empty index.
 func NewIndex() *Index {
-	return &Index{Terms: make(map[string]map[string]bool)}
+	return &Index{
+		Terms:      make(map[string]map[string][]int),
+		DocLengths: make(map[string]int),
+		docTerms:   make(map[string][]string),
+	}
+}
+
+// DocCount returns the number of documents in the index.
+func (idx *Index) DocCount() int {
+	return len(idx.DocLengths)
+}
+
+// avgDocLength returns the mean document length in tokens.
+func (idx *Index) avgDocLength() float64 {
+	if len(idx.DocLengths) == 0 {
+		return 0
+	}
+	return float64(idx.totalLength) / float64(len(idx.DocLengths))
+}
+
+// AddDocument indexes text under path, replacing any previous content
+// indexed for the same path.
+func (idx *Index) AddDocument(path, text string) {
+	idx.RemoveDocument(path)
+	positions := make(map[string][]int)
+	n := appendPositions(positions, text, 0)
+	idx.addPostings(path, positions, n)
+}
+
+// RemoveDocument drops every posting for path from the index. It costs
+// O(distinct terms in the document), not O(vocabulary).
+func (idx *Index) RemoveDocument(path string) {
+	length, ok := idx.DocLengths[path]
+	if !ok {
+		return
+	}
+	for _, term := range idx.docTerms[path] {
+		docs := idx.Terms[term]
+		delete(docs, path)
+		if len(docs) == 0 {
+			delete(idx.Terms, term)
+		}
+	}
+	delete(idx.docTerms, path)
+	delete(idx.DocLengths, path)
+	idx.totalLength -= length
+}
+
+// HasDocument reports whether path is indexed.
+func (idx *Index) HasDocument(path string) bool {
+	_, ok := idx.DocLengths[path]
+	return ok
+}
+
+// Documents returns the indexed document paths in sorted order.
+func (idx *Index) Documents() []string {
+	docs := make([]string, 0, len(idx.DocLengths))
+	for doc := range idx.DocLengths {
+		docs = append(docs, doc)
+	}
+	sort.Strings(docs)
+	return docs
+}
+
+// addPostings records the term positions of a freshly tokenized document.
+func (idx *Index) addPostings(path string, positions map[string][]int, length int) {
+	terms := make([]string, 0, len(positions))
+	for term, pos := range positions {
+		if idx.Terms[term] == nil {
+			idx.Terms[term] = make(map[string][]int)
+		}
+		idx.Terms[term][path] = pos
+		terms = append(terms, term)
+	}
+	idx.docTerms[path] = terms
+	idx.DocLengths[path] = length
+	idx.totalLength += length
 }
 
 // BuildIndex scans all .md and .jsonl files under dir (recursively) and
 // builds an inverted index from their content.
 func BuildIndex(dir string) (*Index, error) {
+	files, err := IndexableFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
 	idx := NewIndex()
+	for _, path := range files {
+		if err := indexFile(idx, path); err != nil {
+			// Non-fatal: skip files we cannot read
+			continue
+		}
+	}
 
+	return idx, nil
+}
+
+// IndexableFiles lists the .md and .jsonl files under dir (recursively) in
+// walk order. Unreadable entries are skipped.
+func IndexableFiles(dir string) ([]string, error) {
+	var files []string
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // skip unreadable entries
 		}
-		if info.IsDir() {
-			return nil
-		}
-		ext := filepath.Ext(path)
-		if ext != ".md" && ext != ".jsonl" {
-			return nil
-		}
-		if err := indexFile(idx, path); err != nil {
-			// Non-fatal: skip files we cannot read
+		if info.IsDir() || !IsIndexable(path) {
 			return nil
 		}
+		files = append(files, path)
 		return nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("walk %s: %w", dir, err)
 	}
+	return files, nil
+}
 
-	return idx, nil
+// IsIndexable reports whether path has an extension BuildIndex indexes.
+func IsIndexable(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".md" || ext == ".jsonl"
 }
 
 // UpdateIndex adds or re-indexes a single file in the index.
 // It first removes any existing entries for the path, then re-scans.
 func UpdateIndex(idx *Index, path string) error {
-	// Remove old entries for this path
-	for _, docs := range idx.Terms {
-		delete(docs, path)
-	}
+	idx.RemoveDocument(path)
 	return indexFile(idx, path)
 }
 
-// Search finds documents matching the query and returns up to limit results
-// sorted by descending score (number of matching query terms).
-func Search(idx *Index, query string, limit int) []IndexResult {
-	queryTerms := tokenize(query)
-	if len(queryTerms) == 0 {
-		return nil
-	}
-
-	// Count how many query terms each document matches
-	scores := make(map[string]int)
-	for _, term := range queryTerms {
-		if docs, ok := idx.Terms[term]; ok {
-			for doc := range docs {
-				scores[doc]++
-			}
-		}
-	}
-
-	if len(scores) == 0 {
-		return nil
-	}
-
-	results := make([]IndexResult, 0, len(scores))
-	for path, score := range scores {
-		results = append(results, IndexResult{Path: path, Score: score})
-	}
-
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Score != results[j].Score {
-			return results[i].Score > results[j].Score
-		}
-		return results[i].Path < results[j].Path
-	})
-
-	if limit > 0 && len(results) > limit {
-		results = results[:limit]
-	}
-
-	return results
-}
-
-// SaveIndex writes the index to a JSONL file (one line per term).
+// SaveIndex writes the index to a versioned JSONL file: a header line,
+// one line per document, then one line per term.
 func SaveIndex(idx *Index, path string) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("create index dir: %w", err)
@@ -132,42 +208,49 @@ func SaveIndex(idx *Index, path string) error {
 	}()
 
 	w := bufio.NewWriter(f)
+	writeRecord := func(rec indexRecord) error {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		_, err = w.WriteString("\n")
+		return err
+	}
+
+	if err := writeRecord(indexRecord{Version: IndexVersion}); err != nil {
+		return fmt.Errorf("write index header: %w", err)
+	}
+
+	// Sort documents and terms for deterministic output
+	for _, doc := range idx.Documents() {
+		if err := writeRecord(indexRecord{Doc: doc, Length: idx.DocLengths[doc]}); err != nil {
+			return fmt.Errorf("write document %q: %w", doc, err)
+		}
+	}
 
-	// Sort terms for deterministic output
 	terms := make([]string, 0, len(idx.Terms))
 	for term := range idx.Terms {
 		terms = append(terms, term)
 	}
 	sort.Strings(terms)
-
 	for _, term := range terms {
-		docs := idx.Terms[term]
-		if len(docs) == 0 {
+		postings := idx.Terms[term]
+		if len(postings) == 0 {
 			continue
 		}
-		paths := make([]string, 0, len(docs))
-		for p := range docs {
-			paths = append(paths, p)
-		}
-		sort.Strings(paths)
-
-		entry := IndexEntry{Term: term, Paths: paths}
-		data, err := json.Marshal(entry)
-		if err != nil {
-			return fmt.Errorf("marshal term %q: %w", term, err)
-		}
-		if _, err := w.Write(data); err != nil {
-			return err
-		}
-		if _, err := w.WriteString("\n"); err != nil {
-			return err
+		if err := writeRecord(indexRecord{Term: term, Postings: postings}); err != nil {
+			return fmt.Errorf("write term %q: %w", term, err)
 		}
 	}
 
 	return w.Flush()
 }
 
-// LoadIndex reads an index from a JSONL file.
+// LoadIndex reads an index written by SaveIndex. Files in any other format
+// version yield an error wrapping ErrIndexVersion.
 func LoadIndex(path string) (*Index, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -177,35 +260,46 @@ func LoadIndex(path string) (*Index, error) {
 		_ = f.Close() //nolint:errcheck // read-only, close best-effort
 	}()
 
-	idx := NewIndex()
-	scanner := bufio.NewScanner(f)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	// A streaming decoder avoids the line-length cap of bufio.Scanner;
+	// postings for common terms can grow large.
+	dec := json.NewDecoder(bufio.NewReader(f))
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
-		var entry IndexEntry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			continue // skip malformed lines
-		}
-		docs := make(map[string]bool, len(entry.Paths))
-		for _, p := range entry.Paths {
-			docs[p] = true
+	var header indexRecord
+	if err := dec.Decode(&header); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("read index: %w: empty file", ErrIndexVersion)
 		}
-		idx.Terms[entry.Term] = docs
+		return nil, fmt.Errorf("read index header: %w", err)
+	}
+	if header.Version != IndexVersion {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrIndexVersion, header.Version, IndexVersion)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("read index: %w", err)
+	idx := NewIndex()
+	for {
+		var rec indexRecord
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("read index: %w", err)
+		}
+		switch {
+		case rec.Doc != "":
+			idx.DocLengths[rec.Doc] = rec.Length
+			idx.totalLength += rec.Length
+		case rec.Term != "" && len(rec.Postings) > 0:
+			idx.Terms[rec.Term] = rec.Postings
+			for doc := range rec.Postings {
+				idx.docTerms[doc] = append(idx.docTerms[doc], rec.Term)
+			}
+		}
 	}
 
 	return idx, nil
 }
 
-// indexFile reads a file and adds its terms to the index.
+// indexFile reads a file and adds its terms and positions to the index.
 func indexFile(idx *Index, path string) error {
 	f, err := os.Open(path)
 	if err != nil {
@@ -219,43 +313,63 @@ func indexFile(idx *Index, path string) error {
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
 
-	seen := make(map[string]bool) // dedupe terms per file
-
+	positions := make(map[string][]int)
+	n := 0
 	for scanner.Scan() {
-		line := scanner.Text()
-		terms := tokenize(line)
-		for _, term := range terms {
-			if seen[term] {
-				continue
-			}
-			seen[term] = true
-			if idx.Terms[term] == nil {
-				idx.Terms[term] = make(map[string]bool)
-			}
-			idx.Terms[term][path] = true
-		}
+		n = appendPositions(positions, scanner.Text(), n)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
 	}
 
-	return scanner.Err()
+	idx.addPostings(path, positions, n)
+	return nil
 }
 
-// tokenize splits text into lowercase word tokens.
-// Strips punctuation and filters out very short (< 2 char) tokens.
-func tokenize(text string) []string {
+// appendPositions tokenizes text and records each token's position, counting
+// from offset. It returns the position after the last token.
+func appendPositions(positions map[string][]int, text string, offset int) int {
+	for _, term := range tokenStream(text) {
+		positions[term] = append(positions[term], offset)
+		offset++
+	}
+	return offset
+}
+
+// tokenStream splits text into lowercase word tokens in document order,
+// keeping repeats. Strips punctuation and drops very short (< 2 char) tokens.
+func tokenStream(text string) []string {
 	lower := strings.ToLower(text)
 	words := strings.FieldsFunc(lower, func(r rune) bool {
 		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
 	})
 
-	// Filter short tokens and deduplicate within this call
+	result := words[:0]
+	for _, w := range words {
+		if len(w) < 2 {
+			continue
+		}
+		result = append(result, w)
+	}
+	return result
+}
+
+// tokenize splits text into unique lowercase word tokens in order of first
+// appearance.
+func tokenize(text string) []string {
+	words := tokenStream(text)
+
 	result := make([]string, 0, len(words))
 	seen := make(map[string]bool, len(words))
 	for _, w := range words {
-		if len(w) < 2 || seen[w] {
+		if seen[w] {
 			continue
 		}
 		seen[w] = true
 		result = append(result, w)
 	}
+	if len(result) == 0 {
+		return nil
+	}
 	return result
 }
@@ -1,6 +1,7 @@
 package search
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -70,7 +71,7 @@ func TestSearch(t *testing.T) {
 		t.Fatalf("BuildIndex: %v", err)
 	}
 
-	// Search for "mutex pattern" — a.md matches both terms (score 2), b.md matches one (score 1)
+	// Search for "mutex pattern" — a.md matches both terms, b.md matches only one
 	results := Search(idx, "mutex pattern", 10)
 	if len(results) < 2 {
 		t.Fatalf("expected at least 2 results, got %d", len(results))
@@ -169,12 +170,12 @@ func TestUpdateIndex(t *testing.T) {
 	}
 
 	// "alpha" should be gone (or at least not point to docPath)
-	if docs, ok := idx.Terms["alpha"]; ok && docs[docPath] {
+	if docs, ok := idx.Terms["alpha"]; ok && len(docs[docPath]) > 0 {
 		t.Error("expected 'alpha' to be removed for docPath after update")
 	}
 
 	// "beta" should be present
-	if docs, ok := idx.Terms["beta"]; !ok || !docs[docPath] {
+	if docs, ok := idx.Terms["beta"]; !ok || len(docs[docPath]) == 0 {
 		t.Error("expected 'beta' to be present after update")
 	}
 }
@@ -232,3 +233,73 @@ func writeFile(t *testing.T, path, content string) {
 		t.Fatalf("write %s: %v", path, err)
 	}
 }
+
+func TestLoadIndexRejectsLegacyFormat(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.jsonl")
+	writeFile(t, path, `{"term":"mutex","paths":["/tmp/a.md"]}`+"\n")
+
+	_, err := LoadIndex(path)
+	if !errors.Is(err, ErrIndexVersion) {
+		t.Fatalf("LoadIndex(legacy) error = %v, want ErrIndexVersion", err)
+	}
+}
+
+func TestSaveAndLoadIndexPreservesPositions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "doc.md"), "worktree merge conflict\nmerge worktree again")
+
+	idx, err := BuildIndex(dir)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	indexPath := filepath.Join(dir, "out", "index.jsonl")
+	if err := SaveIndex(idx, indexPath); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+	loaded, err := LoadIndex(indexPath)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+
+	doc := filepath.Join(dir, "doc.md")
+	if got := loaded.DocLengths[doc]; got != 6 {
+		t.Errorf("DocLengths[doc] = %d, want 6", got)
+	}
+	if got := loaded.Terms["merge"][doc]; len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("positions of 'merge' = %v, want [1 3]", got)
+	}
+}
+
+func TestRemoveDocumentAfterLoad(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.md")
+	drop := filepath.Join(dir, "drop.md")
+	writeFile(t, keep, "shared term")
+	writeFile(t, drop, "shared unique")
+
+	idx, err := BuildIndex(dir)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	indexPath := filepath.Join(dir, "out", "index.jsonl")
+	if err := SaveIndex(idx, indexPath); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+	loaded, err := LoadIndex(indexPath)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+
+	// The forward map is rebuilt on load, so removal still finds every term.
+	loaded.RemoveDocument(drop)
+	if _, ok := loaded.Terms["unique"]; ok {
+		t.Error("expected 'unique' to be dropped with its only document")
+	}
+	if docs := loaded.Terms["shared"]; len(docs) != 1 || len(docs[keep]) == 0 {
+		t.Errorf("'shared' postings = %v, want only keep.md", docs)
+	}
+	if loaded.HasDocument(drop) || loaded.DocCount() != 1 {
+		t.Errorf("drop.md still counted: docs=%v", loaded.Documents())
+	}
+}
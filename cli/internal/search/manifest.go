@@ -0,0 +1,197 @@
+package search
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestVersion is the on-disk format version written by SaveManifest.
+const ManifestVersion = 1
+
+// FileState is what the manifest remembers about an indexed file.
+type FileState struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Hash    string    `json:"sha256"`
+}
+
+// Manifest records the state of every file at the time it was last indexed,
+// so index maintenance can skip files that have not changed.
+type Manifest struct {
+	Version int                  `json:"version"`
+	Files   map[string]FileState `json:"files"`
+}
+
+// Changes summarises how a set of files differs from a manifest.
+type Changes struct {
+	Added     []string
+	Modified  []string
+	Removed   []string
+	Unchanged int
+}
+
+// Stale returns the added and modified paths: the files that must be
+// (re)processed.
+func (c Changes) Stale() []string {
+	stale := make([]string, 0, len(c.Added)+len(c.Modified))
+	stale = append(stale, c.Added...)
+	stale = append(stale, c.Modified...)
+	sort.Strings(stale)
+	return stale
+}
+
+// Empty reports whether nothing needs to be reprocessed or removed.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// NewManifest creates an empty manifest.
+func NewManifest() *Manifest {
+	return &Manifest{Version: ManifestVersion, Files: make(map[string]FileState)}
+}
+
+// LoadManifest reads a manifest written by SaveManifest. A missing file, or
+// one in a different format version, yields an empty manifest so the caller
+// simply treats every file as new.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewManifest(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if m.Version != ManifestVersion || m.Files == nil {
+		return NewManifest(), nil
+	}
+	return &m, nil
+}
+
+// SaveManifest writes the manifest to path, replacing it atomically.
+func SaveManifest(m *Manifest, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create manifest dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replace manifest: %w", err)
+	}
+	return nil
+}
+
+// Check reports whether path differs from its manifest entry and returns the
+// file's current state. Size and mtime are compared first; the content hash
+// is only computed when they differ, so a touched-but-identical file is
+// still reported unchanged.
+func (m *Manifest) Check(path string) (state FileState, changed bool, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileState{}, false, err
+	}
+	state = FileState{Size: info.Size(), ModTime: info.ModTime()}
+
+	prev, known := m.Files[path]
+	if known && prev.Size == state.Size && prev.ModTime.Equal(state.ModTime) {
+		state.Hash = prev.Hash
+		return state, false, nil
+	}
+
+	state.Hash, err = hashFile(path)
+	if err != nil {
+		return FileState{}, false, err
+	}
+	return state, !known || prev.Hash != state.Hash, nil
+}
+
+// Diff compares the current files against the manifest. When complete is
+// true, files is taken to be the whole corpus and manifest entries missing
+// from it are reported as removed; otherwise removals are not considered.
+//
+// Diff does not modify the manifest. It returns the fresh state of every
+// file it could stat, which the caller records with Record once the file has
+// been processed successfully.
+func (m *Manifest) Diff(files []string, complete bool) (Changes, map[string]FileState) {
+	var changes Changes
+	states := make(map[string]FileState, len(files))
+	present := make(map[string]bool, len(files))
+
+	for _, path := range files {
+		present[path] = true
+		state, changed, err := m.Check(path)
+		if err != nil {
+			continue // unreadable: leave for the next run
+		}
+		states[path] = state
+		_, known := m.Files[path]
+		switch {
+		case !changed:
+			changes.Unchanged++
+		case known:
+			changes.Modified = append(changes.Modified, path)
+		default:
+			changes.Added = append(changes.Added, path)
+		}
+	}
+
+	if complete {
+		for path := range m.Files {
+			if !present[path] {
+				changes.Removed = append(changes.Removed, path)
+			}
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Modified)
+	sort.Strings(changes.Removed)
+	return changes, states
+}
+
+// Record stores the state of a successfully processed file.
+func (m *Manifest) Record(path string, state FileState) {
+	m.Files[path] = state
+}
+
+// Forget drops path from the manifest.
+func (m *Manifest) Forget(path string) {
+	delete(m.Files, path)
+}
+
+// hashFile returns the hex SHA-256 of a file's content.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only, close best-effort
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifestDiff(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.md")
+	edited := filepath.Join(dir, "edited.md")
+	gone := filepath.Join(dir, "gone.md")
+	writeFile(t, kept, "stable")
+	writeFile(t, edited, "before")
+	writeFile(t, gone, "soon deleted")
+
+	m := NewManifest()
+	_, states := m.Diff([]string{kept, edited, gone}, true)
+	for path, state := range states {
+		m.Record(path, state)
+	}
+
+	writeFile(t, edited, "after, and longer")
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	added := filepath.Join(dir, "added.md")
+	writeFile(t, added, "new")
+
+	changes, _ := m.Diff([]string{kept, edited, added}, true)
+	if len(changes.Added) != 1 || changes.Added[0] != added {
+		t.Errorf("Added = %v, want [%s]", changes.Added, added)
+	}
+	if len(changes.Modified) != 1 || changes.Modified[0] != edited {
+		t.Errorf("Modified = %v, want [%s]", changes.Modified, edited)
+	}
+	if len(changes.Removed) != 1 || changes.Removed[0] != gone {
+		t.Errorf("Removed = %v, want [%s]", changes.Removed, gone)
+	}
+	if changes.Unchanged != 1 {
+		t.Errorf("Unchanged = %d, want 1", changes.Unchanged)
+	}
+
+	// Partial diffs never report removals.
+	if partial, _ := m.Diff([]string{kept}, false); len(partial.Removed) != 0 {
+		t.Errorf("partial Diff reported removals: %v", partial.Removed)
+	}
+}
+
+func TestManifestCheckIgnoresTouch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.md")
+	writeFile(t, path, "content")
+
+	m := NewManifest()
+	state, changed, err := m.Check(path)
+	if err != nil || !changed {
+		t.Fatalf("Check(new) = changed %v, err %v; want changed", changed, err)
+	}
+	m.Record(path, state)
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	touched, changed, err := m.Check(path)
+	if err != nil || changed {
+		t.Fatalf("Check(touched) = changed %v, err %v; want unchanged", changed, err)
+	}
+	if !touched.ModTime.Equal(later) || touched.Hash != state.Hash {
+		t.Errorf("touched state = %+v, want new mtime and same hash", touched)
+	}
+}
+
+func TestSaveAndLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index", "manifest.json")
+
+	m := NewManifest()
+	m.Record("/k/a.md", FileState{Size: 3, ModTime: time.Unix(1700000000, 0).UTC(), Hash: "abc"})
+	if err := SaveManifest(m, path); err != nil {
+		t.Fatalf("SaveManifest: %v", err)
+	}
+
+	loaded, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	if got := loaded.Files["/k/a.md"]; got.Hash != "abc" || got.Size != 3 {
+		t.Errorf("loaded state = %+v", got)
+	}
+
+	missing, err := LoadManifest(filepath.Join(dir, "nope.json"))
+	if err != nil || len(missing.Files) != 0 {
+		t.Errorf("LoadManifest(missing) = %+v, %v; want empty manifest", missing, err)
+	}
+}
@@ -0,0 +1,228 @@
+package search
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultShingleSize is the number of consecutive words per shingle.
+	DefaultShingleSize = 3
+
+	// DefaultMinHashes is the MinHash signature length.
+	DefaultMinHashes = 128
+
+	// DefaultMinHashBands is the number of LSH bands a signature is split
+	// into; with 128 hashes, 32 bands of 4 rows make pairs with Jaccard
+	// similarity around 0.4 and above likely to collide in some band.
+	DefaultMinHashBands = 32
+)
+
+// Shingles returns the distinct hashed word k-shingles of text, sorted.
+// Words come from the same tokenizer as the BM25 index, so case and
+// punctuation do not matter. Text shorter than k words yields a single
+// shingle of all its words; text with no words yields nil.
+func Shingles(text string, k int) []uint64 {
+	if k < 1 {
+		k = 1
+	}
+	words := tokenStream(text)
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) < k {
+		k = len(words)
+	}
+
+	seen := make(map[uint64]bool, len(words)-k+1)
+	for i := 0; i+k <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+k], " "))) //nolint:errcheck // hash.Hash.Write never fails
+		seen[h.Sum64()] = true
+	}
+
+	out := make([]uint64, 0, len(seen))
+	for s := range seen {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Jaccard returns the exact Jaccard similarity of two sorted shingle sets.
+func Jaccard(a, b []uint64) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			shared++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// MinHasher computes fixed-length MinHash signatures of shingle sets.
+type MinHasher struct {
+	seeds []uint64
+}
+
+// NewMinHasher returns a MinHasher producing signatures of n hashes. Seeds
+// are deterministic, so signatures are comparable across runs.
+func NewMinHasher(n int) *MinHasher {
+	if n < 1 {
+		n = DefaultMinHashes
+	}
+	seeds := make([]uint64, n)
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(state)
+	}
+	return &MinHasher{seeds: seeds}
+}
+
+// Signature returns the MinHash signature of a shingle set: for each hash
+// function, the minimum hash over the set. An empty set has a nil signature.
+func (m *MinHasher) Signature(shingles []uint64) []uint64 {
+	if len(shingles) == 0 {
+		return nil
+	}
+	sig := make([]uint64, len(m.seeds))
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for _, s := range shingles {
+		for i, seed := range m.seeds {
+			if h := mix64(s ^ seed); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// EstimateJaccard estimates the Jaccard similarity of the sets behind two
+// signatures as the fraction of positions where they agree.
+func EstimateJaccard(a, b []uint64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	agree := 0
+	for i := range a {
+		if a[i] == b[i] {
+			agree++
+		}
+	}
+	return float64(agree) / float64(len(a))
+}
+
+// GroupNearDuplicates groups items whose signatures have an estimated
+// Jaccard similarity of at least threshold. Candidate pairs come from
+// locality-sensitive hashing over bands of each signature, so the cost
+// stays near linear; each candidate pair is verified before it links two
+// items, and links are transitive. Only groups of two or more are
+// returned, each sorted by index and ordered by its smallest index. Items
+// with a nil signature never group.
+func GroupNearDuplicates(sigs [][]uint64, bands int, threshold float64) [][]int {
+	parent := make([]int, len(sigs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for band, rows := range bandRanges(sigs, bands) {
+		buckets := make(map[uint64][]int)
+		for i, sig := range sigs {
+			if len(sig) == 0 {
+				continue
+			}
+			h := fnv.New64a()
+			var buf [8]byte
+			binary.LittleEndian.PutUint64(buf[:], uint64(band))
+			h.Write(buf[:]) //nolint:errcheck // hash.Hash.Write never fails
+			for _, v := range sig[rows[0]:rows[1]] {
+				binary.LittleEndian.PutUint64(buf[:], v)
+				h.Write(buf[:]) //nolint:errcheck // hash.Hash.Write never fails
+			}
+			key := h.Sum64()
+			for _, j := range buckets[key] {
+				if find(i) != find(j) && EstimateJaccard(sigs[i], sigs[j]) >= threshold {
+					parent[find(i)] = find(j)
+				}
+			}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range sigs {
+		r := find(i)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], i)
+	}
+	var groups [][]int
+	for _, r := range roots {
+		if len(members[r]) > 1 {
+			groups = append(groups, members[r])
+		}
+	}
+	return groups
+}
+
+// bandRanges splits the signature length into bands [start, end) row ranges.
+func bandRanges(sigs [][]uint64, bands int) [][2]int {
+	n := 0
+	for _, sig := range sigs {
+		if len(sig) > 0 {
+			n = len(sig)
+			break
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	if bands < 1 || bands > n {
+		bands = n
+	}
+	rows := n / bands
+	ranges := make([][2]int, 0, bands)
+	for b := 0; b < bands; b++ {
+		end := (b + 1) * rows
+		if b == bands-1 {
+			end = n
+		}
+		ranges = append(ranges, [2]int{b * rows, end})
+	}
+	return ranges
+}
+
+// mix64 is the splitmix64 finalizer, a fast well-distributed 64-bit hash.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,63 @@
+package search
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShinglesAndJaccard(t *testing.T) {
+	a := Shingles("Always run the tests before you push.", 3)
+	b := Shingles("always RUN the tests before you push!", 3)
+	if Jaccard(a, b) != 1 {
+		t.Errorf("case and punctuation should not matter, Jaccard = %v", Jaccard(a, b))
+	}
+	if got := len(Shingles("one two", 3)); got != 1 {
+		t.Errorf("short text should yield one shingle, got %d", got)
+	}
+	if Shingles("  ...  ", 3) != nil {
+		t.Error("text without words should yield nil")
+	}
+
+	c := Shingles("always run the tests before you merge", 3)
+	// 5 shingles each, 4 shared: 4 / 6.
+	if got := Jaccard(a, c); math.Abs(got-4.0/6.0) > 1e-9 {
+		t.Errorf("Jaccard = %v, want %v", got, 4.0/6.0)
+	}
+}
+
+func TestMinHashEstimatesJaccard(t *testing.T) {
+	m := NewMinHasher(256)
+	a := Shingles("the index is rebuilt incrementally from a manifest of file hashes and sizes so unchanged files are skipped", 2)
+	b := Shingles("the index is rebuilt incrementally from a manifest of file hashes so unchanged files are skipped quickly", 2)
+	exact := Jaccard(a, b)
+	est := EstimateJaccard(m.Signature(a), m.Signature(b))
+	if math.Abs(exact-est) > 0.15 {
+		t.Errorf("estimate %v too far from exact %v", est, exact)
+	}
+	if m.Signature(nil) != nil {
+		t.Error("empty set should have nil signature")
+	}
+}
+
+func TestGroupNearDuplicates(t *testing.T) {
+	texts := []string{
+		"Use atomic writes with a temp file and rename to avoid torn files on crash",
+		"Prefer table driven tests for parsers so new cases are one line each",
+		"Use atomic writes with a temp file and rename to avoid torn files after a crash",
+		"",
+		"Use atomic writes with a temp file then rename to avoid torn files on crash",
+	}
+	m := NewMinHasher(DefaultMinHashes)
+	sigs := make([][]uint64, len(texts))
+	for i, text := range texts {
+		sigs[i] = m.Signature(Shingles(text, DefaultShingleSize))
+	}
+
+	groups := GroupNearDuplicates(sigs, DefaultMinHashBands, 0.5)
+	if len(groups) != 1 {
+		t.Fatalf("expected one group, got %v", groups)
+	}
+	if g := groups[0]; len(g) != 3 || g[0] != 0 || g[1] != 2 || g[2] != 4 {
+		t.Errorf("group = %v, want [0 2 4]", groups[0])
+	}
+}
@@ -0,0 +1,217 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// BM25 tuning parameters (the common Lucene/Okapi defaults).
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// clause is one unit of a parsed query: a single term, a quoted phrase of
+// several terms that must appear consecutively, or a prefix (`merg*`).
+type clause struct {
+	terms  []string
+	prefix bool
+}
+
+// key returns a canonical string used to drop duplicate clauses.
+func (c clause) key() string {
+	k := strings.Join(c.terms, " ")
+	if c.prefix {
+		k += "*"
+	}
+	return k
+}
+
+// parseQuery splits a query into clauses. Double-quoted text becomes a phrase
+// clause; a trailing '*' on a bare word makes its last token a prefix match;
+// every other token is an independent term clause.
+func parseQuery(query string) []clause {
+	var clauses []clause
+	seen := make(map[string]bool)
+	add := func(c clause) {
+		if len(c.terms) == 0 || seen[c.key()] {
+			return
+		}
+		seen[c.key()] = true
+		clauses = append(clauses, c)
+	}
+
+	rest := query
+	for rest != "" {
+		open := strings.IndexByte(rest, '"')
+		if open < 0 {
+			addWords(rest, add)
+			break
+		}
+		addWords(rest[:open], add)
+		rest = rest[open+1:]
+		end := strings.IndexByte(rest, '"')
+		if end < 0 {
+			end = len(rest) // unterminated quote runs to end of query
+		}
+		add(clause{terms: tokenStream(rest[:end])})
+		if end == len(rest) {
+			break
+		}
+		rest = rest[end+1:]
+	}
+
+	return clauses
+}
+
+// addWords turns unquoted query text into term and prefix clauses.
+func addWords(text string, add func(clause)) {
+	for _, word := range strings.Fields(text) {
+		prefix := strings.HasSuffix(word, "*")
+		tokens := tokenStream(strings.TrimRight(word, "*"))
+		for i, tok := range tokens {
+			add(clause{terms: []string{tok}, prefix: prefix && i == len(tokens)-1})
+		}
+	}
+}
+
+// QueryTerms returns the distinct plain terms referenced by query, with
+// quotes and prefix markers removed. Callers use it to highlight matches.
+func QueryTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, c := range parseQuery(query) {
+		for _, t := range c.terms {
+			if !seen[t] {
+				seen[t] = true
+				terms = append(terms, t)
+			}
+		}
+	}
+	return terms
+}
+
+// Search ranks documents against query with BM25 and returns up to limit
+// results sorted by descending score. Documents must match at least one
+// clause; documents matching more (or rarer) clauses score higher, and
+// shorter documents beat longer ones at equal term frequency.
+//
+// Query syntax: bare words are terms, "double quoted" words form a phrase
+// that must appear in order, and a trailing * matches any term with that
+// prefix. A limit <= 0 returns all matches.
+func Search(idx *Index, query string, limit int) []IndexResult {
+	clauses := parseQuery(query)
+	if len(clauses) == 0 || idx.DocCount() == 0 {
+		return nil
+	}
+
+	n := float64(idx.DocCount())
+	avgLen := idx.avgDocLength()
+	scores := make(map[string]float64)
+
+	for _, c := range clauses {
+		freqs := idx.clauseFrequencies(c)
+		if len(freqs) == 0 {
+			continue
+		}
+		df := float64(len(freqs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for doc, tf := range freqs {
+			norm := 1 - bm25B
+			if avgLen > 0 {
+				norm += bm25B * float64(idx.DocLengths[doc]) / avgLen
+			}
+			f := float64(tf)
+			scores[doc] += idf * f * (bm25K1 + 1) / (f + bm25K1*norm)
+		}
+	}
+
+	if len(scores) == 0 {
+		return nil
+	}
+
+	results := make([]IndexResult, 0, len(scores))
+	for path, score := range scores {
+		results = append(results, IndexResult{Path: path, Score: score})
+	}
+
+	sortResults(results)
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// sortResults orders results by descending score, then path.
+func sortResults(results []IndexResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+}
+
+// clauseFrequencies returns, for every document matching c, how many times
+// the clause occurs in it.
+func (idx *Index) clauseFrequencies(c clause) map[string]int {
+	freqs := make(map[string]int)
+	switch {
+	case c.prefix:
+		want := c.terms[0]
+		for term, docs := range idx.Terms {
+			if !strings.HasPrefix(term, want) {
+				continue
+			}
+			for doc, pos := range docs {
+				freqs[doc] += len(pos)
+			}
+		}
+	case len(c.terms) == 1:
+		for doc, pos := range idx.Terms[c.terms[0]] {
+			freqs[doc] = len(pos)
+		}
+	default:
+		for doc, pos := range idx.Terms[c.terms[0]] {
+			if count := idx.phraseCount(doc, pos, c.terms[1:]); count > 0 {
+				freqs[doc] = count
+			}
+		}
+	}
+	return freqs
+}
+
+// phraseCount counts positions p in starts such that rest[i] occurs in doc at
+// position p+1+i for every i.
+func (idx *Index) phraseCount(doc string, starts []int, rest []string) int {
+	following := make([]map[int]bool, len(rest))
+	for i, term := range rest {
+		pos, ok := idx.Terms[term][doc]
+		if !ok {
+			return 0
+		}
+		set := make(map[int]bool, len(pos))
+		for _, p := range pos {
+			set[p] = true
+		}
+		following[i] = set
+	}
+
+	count := 0
+	for _, p := range starts {
+		match := true
+		for i, set := range following {
+			if !set[p+1+i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			count++
+		}
+	}
+	return count
+}
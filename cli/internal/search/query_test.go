@@ -0,0 +1,94 @@
+package search
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchPrefersShortDocuments(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "learning.md"), "mutex guards shared state")
+	writeFile(t, filepath.Join(dir, "retro.md"), "mutex guards shared state\n"+strings.Repeat("unrelated retro narrative line\n", 100))
+
+	idx, err := BuildIndex(dir)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+
+	results := Search(idx, "mutex", 10)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if filepath.Base(results[0].Path) != "learning.md" {
+		t.Errorf("expected short learning first, got %s", results[0].Path)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("expected strictly higher score for short doc, got %.3f <= %.3f", results[0].Score, results[1].Score)
+	}
+}
+
+func TestSearchTermFrequency(t *testing.T) {
+	idx := NewIndex()
+	idx.AddDocument("once.md", "deadlock fixed by ordering locks")
+	idx.AddDocument("thrice.md", "deadlock deadlock deadlock in worker")
+
+	results := Search(idx, "deadlock", 10)
+	if len(results) != 2 || results[0].Path != "thrice.md" {
+		t.Fatalf("expected thrice.md first, got %+v", results)
+	}
+}
+
+func TestSearchPhrase(t *testing.T) {
+	idx := NewIndex()
+	idx.AddDocument("phrase.md", "resolve the worktree merge before pushing")
+	idx.AddDocument("apart.md", "merge the branch then clean the worktree")
+
+	results := Search(idx, `"worktree merge"`, 10)
+	if len(results) != 1 || results[0].Path != "phrase.md" {
+		t.Fatalf("phrase query: got %+v, want only phrase.md", results)
+	}
+
+	// Unquoted, both documents match.
+	if results := Search(idx, "worktree merge", 10); len(results) != 2 {
+		t.Errorf("term query: got %d results, want 2", len(results))
+	}
+}
+
+func TestSearchPrefix(t *testing.T) {
+	idx := NewIndex()
+	idx.AddDocument("a.md", "merging branches")
+	idx.AddDocument("b.md", "merged upstream")
+	idx.AddDocument("c.md", "emergency fix")
+
+	results := Search(idx, "merg*", 10)
+	if len(results) != 2 {
+		t.Fatalf("prefix query: got %+v, want a.md and b.md", results)
+	}
+	for _, r := range results {
+		if r.Path == "c.md" {
+			t.Errorf("prefix must anchor at term start, matched %s", r.Path)
+		}
+	}
+}
+
+func TestAddDocumentReplaces(t *testing.T) {
+	idx := NewIndex()
+	idx.AddDocument("doc.md", "alpha alpha alpha")
+	idx.AddDocument("doc.md", "beta")
+
+	if _, ok := idx.Terms["alpha"]; ok {
+		t.Error("expected 'alpha' to be dropped after re-adding doc")
+	}
+	if idx.DocLengths["doc.md"] != 1 || idx.totalLength != 1 {
+		t.Errorf("lengths not updated: doc=%d total=%d", idx.DocLengths["doc.md"], idx.totalLength)
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	got := QueryTerms(`"worktree merge" conflict* merge`)
+	want := []string{"worktree", "merge", "conflict"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("QueryTerms = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,289 @@
+package search
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VectorStoreVersion is the on-disk format version written by Save.
+const VectorStoreVersion = 1
+
+// maxEmbedBytes caps how much of each file is sent to the embedder; local
+// model servers typically reject inputs beyond a few thousand tokens.
+const maxEmbedBytes = 16 * 1024
+
+// embedBatchSize is how many documents are embedded per Embed call.
+const embedBatchSize = 32
+
+// VectorStore holds one embedding per document, tagged with the content hash
+// it was computed from so unchanged files are never re-embedded.
+type VectorStore struct {
+	// Model is the Embedder name the vectors were produced with.
+	Model string
+
+	vectors map[string]storedVector
+}
+
+type storedVector struct {
+	Hash string
+	Vec  []float32
+}
+
+// vectorRecord is the JSONL-serialised form: a header line (Version, Model)
+// followed by one line per document.
+type vectorRecord struct {
+	Version int       `json:"version,omitempty"`
+	Model   string    `json:"model,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	Hash    string    `json:"sha256,omitempty"`
+	Vector  []float32 `json:"vector,omitempty"`
+}
+
+// NewVectorStore creates an empty store for model.
+func NewVectorStore(model string) *VectorStore {
+	return &VectorStore{Model: model, vectors: make(map[string]storedVector)}
+}
+
+// Len returns the number of stored vectors.
+func (s *VectorStore) Len() int {
+	return len(s.vectors)
+}
+
+// LoadVectorStore reads a store written by Save. A missing file, a different
+// format version or vectors from another model yield an empty store for
+// model, so every document is embedded afresh.
+func LoadVectorStore(path, model string) (*VectorStore, error) {
+	store := NewVectorStore(model)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open vector store: %w", err)
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only, close best-effort
+	}()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var header vectorRecord
+	if err := dec.Decode(&header); err != nil {
+		if err == io.EOF {
+			return store, nil
+		}
+		return nil, fmt.Errorf("read vector store header: %w", err)
+	}
+	if header.Version != VectorStoreVersion || header.Model != model {
+		return store, nil
+	}
+
+	for {
+		var rec vectorRecord
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("read vector store: %w", err)
+		}
+		if rec.Path != "" && len(rec.Vector) > 0 {
+			store.vectors[rec.Path] = storedVector{Hash: rec.Hash, Vec: rec.Vector}
+		}
+	}
+	return store, nil
+}
+
+// Save writes the store to path as JSONL, replacing it atomically.
+func (s *VectorStore) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create vector store dir: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create vector store: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	writeErr := enc.Encode(vectorRecord{Version: VectorStoreVersion, Model: s.Model})
+	for _, p := range s.paths() {
+		if writeErr != nil {
+			break
+		}
+		v := s.vectors[p]
+		writeErr = enc.Encode(vectorRecord{Path: p, Hash: v.Hash, Vector: v.Vec})
+	}
+	if writeErr == nil {
+		writeErr = w.Flush()
+	}
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write vector store: %w", writeErr)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replace vector store: %w", err)
+	}
+	return nil
+}
+
+// Sync embeds every file whose content hash differs from the stored one and
+// drops vectors for files under dir that are not in files. Vectors synced
+// from other directories are kept, so one store can serve several. It
+// returns how many documents were embedded or removed, so callers can skip
+// saving when nothing changed.
+func (s *VectorStore) Sync(e Embedder, dir string, files []string) (int, error) {
+	present := make(map[string]bool, len(files))
+	var stalePaths []string
+	var staleHashes []string
+	var staleTexts []string
+
+	for _, path := range files {
+		present[path] = true
+		hash, err := hashFile(path)
+		if err != nil {
+			continue // unreadable: skip until next sync
+		}
+		if v, ok := s.vectors[path]; ok && v.Hash == hash {
+			continue
+		}
+		text, err := readPrefix(path, maxEmbedBytes)
+		if err != nil {
+			continue
+		}
+		stalePaths = append(stalePaths, path)
+		staleHashes = append(staleHashes, hash)
+		staleTexts = append(staleTexts, text)
+	}
+
+	changed := 0
+	for path := range s.vectors {
+		if !present[path] && within(path, dir) {
+			delete(s.vectors, path)
+			changed++
+		}
+	}
+
+	for start := 0; start < len(staleTexts); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(staleTexts) {
+			end = len(staleTexts)
+		}
+		vecs, err := e.Embed(staleTexts[start:end])
+		if err != nil {
+			return changed, fmt.Errorf("embed with %s: %w", e.Name(), err)
+		}
+		for i, vec := range vecs {
+			s.vectors[stalePaths[start+i]] = storedVector{Hash: staleHashes[start+i], Vec: vec}
+			changed++
+		}
+	}
+
+	return changed, nil
+}
+
+// Search returns stored documents under dir ranked by cosine similarity to
+// query, keeping only positive similarities. A limit <= 0 returns all of
+// them.
+func (s *VectorStore) Search(query []float32, dir string, limit int) []IndexResult {
+	var results []IndexResult
+	for path, v := range s.vectors {
+		if !within(path, dir) {
+			continue
+		}
+		if sim := Cosine(query, v.Vec); sim > 0 {
+			results = append(results, IndexResult{Path: path, Score: sim})
+		}
+	}
+	sortResults(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// MergeHybrid combines keyword and semantic results. Each list is scaled so
+// its best score is 1, then a document's hybrid score is
+// alpha*semantic + (1-alpha)*keyword (a missing side counts as 0).
+func MergeHybrid(keyword, semantic []IndexResult, alpha float64, limit int) []IndexResult {
+	combined := make(map[string]float64)
+	for path, score := range scaleToMax(keyword) {
+		combined[path] += (1 - alpha) * score
+	}
+	for path, score := range scaleToMax(semantic) {
+		combined[path] += alpha * score
+	}
+
+	results := make([]IndexResult, 0, len(combined))
+	for path, score := range combined {
+		results = append(results, IndexResult{Path: path, Score: score})
+	}
+	sortResults(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// scaleToMax maps each result's path to its score divided by the top score.
+func scaleToMax(results []IndexResult) map[string]float64 {
+	scaled := make(map[string]float64, len(results))
+	var maxScore float64
+	for _, r := range results {
+		if r.Score > maxScore {
+			maxScore = r.Score
+		}
+	}
+	if maxScore <= 0 {
+		return scaled
+	}
+	for _, r := range results {
+		scaled[r.Path] = r.Score / maxScore
+	}
+	return scaled
+}
+
+// paths returns the stored document paths in sorted order.
+func (s *VectorStore) paths() []string {
+	paths := make([]string, 0, len(s.vectors))
+	for p := range s.vectors {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// readPrefix reads at most n bytes of a file as text.
+func readPrefix(path string, n int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close() //nolint:errcheck // read-only, close best-effort
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(f, n))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,131 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// countingEmbedder wraps HashEmbedder and records how many texts it embedded.
+type countingEmbedder struct {
+	*HashEmbedder
+	calls int
+}
+
+func (c *countingEmbedder) Embed(texts []string) ([][]float32, error) {
+	c.calls += len(texts)
+	return c.HashEmbedder.Embed(texts)
+}
+
+func TestVectorStoreSyncAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	writeFile(t, a, "acquire mutexes in a fixed order to avoid deadlock")
+	writeFile(t, b, "configure the oauth token refresh interval")
+
+	e := &countingEmbedder{HashEmbedder: NewHashEmbedder(DefaultHashDims)}
+	store := NewVectorStore(e.Name())
+	if _, err := store.Sync(e, dir, []string{a, b}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if e.calls != 2 || store.Len() != 2 {
+		t.Fatalf("first sync embedded %d, stored %d; want 2, 2", e.calls, store.Len())
+	}
+
+	q, _ := e.HashEmbedder.Embed([]string{"deadlock mutex order"})
+	results := store.Search(q[0], dir, 1)
+	if len(results) != 1 || results[0].Path != a {
+		t.Fatalf("Search = %+v, want a.md first", results)
+	}
+
+	// Persist, reload, and sync again: nothing is re-embedded.
+	path := filepath.Join(dir, "index", "vectors.jsonl")
+	if err := store.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadVectorStore(path, e.Name())
+	if err != nil {
+		t.Fatalf("LoadVectorStore: %v", err)
+	}
+	e.calls = 0
+	changed, err := loaded.Sync(e, dir, []string{a, b})
+	if err != nil || changed != 0 || e.calls != 0 {
+		t.Errorf("resync: changed=%d calls=%d err=%v; want no work", changed, e.calls, err)
+	}
+
+	// Edits re-embed only the edited file; missing files are dropped.
+	writeFile(t, a, "lock ordering prevents deadlock")
+	changed, err = loaded.Sync(e, dir, []string{a})
+	if err != nil || changed != 2 || e.calls != 1 || loaded.Len() != 1 {
+		t.Errorf("edit sync: changed=%d calls=%d len=%d err=%v", changed, e.calls, loaded.Len(), err)
+	}
+
+	// A different model discards stored vectors.
+	other, err := LoadVectorStore(path, "http:other")
+	if err != nil || other.Len() != 0 {
+		t.Errorf("LoadVectorStore(other model) len=%d err=%v; want empty", other.Len(), err)
+	}
+}
+
+func TestVectorStoreKeepsOtherDirs(t *testing.T) {
+	root := t.TempDir()
+	sessions := filepath.Join(root, "sessions")
+	learnings := filepath.Join(root, "learnings")
+	for _, dir := range []string{sessions, learnings} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := filepath.Join(sessions, "a.md")
+	b := filepath.Join(learnings, "b.md")
+	writeFile(t, a, "acquire mutexes in a fixed order to avoid deadlock")
+	writeFile(t, b, "mutex ordering learned the hard way")
+
+	e := &countingEmbedder{HashEmbedder: NewHashEmbedder(DefaultHashDims)}
+	store := NewVectorStore(e.Name())
+	if _, err := store.Sync(e, sessions, []string{a}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Sync(e, learnings, []string{b}); err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 2 {
+		t.Fatalf("store holds %d documents, want both dirs' 2", store.Len())
+	}
+
+	// Syncing one dir again embeds nothing and keeps the other's vectors
+	e.calls = 0
+	if changed, err := store.Sync(e, sessions, []string{a}); err != nil || changed != 0 || store.Len() != 2 {
+		t.Errorf("resync: changed=%d len=%d err=%v; want no change", changed, store.Len(), err)
+	}
+
+	// Searches only see their own dir, even one sharing a name prefix
+	q, _ := e.HashEmbedder.Embed([]string{"mutex"})
+	for dir, want := range map[string]string{sessions: a, learnings: b} {
+		if results := store.Search(q[0], dir, 0); len(results) != 1 || results[0].Path != want {
+			t.Errorf("Search(%s) = %+v, want only %s", filepath.Base(dir), results, filepath.Base(want))
+		}
+	}
+	if results := store.Search(q[0], sessions+"-old", 0); len(results) != 0 {
+		t.Errorf("Search of a sibling dir = %+v, want none", results)
+	}
+}
+
+func TestMergeHybrid(t *testing.T) {
+	keyword := []IndexResult{{Path: "kw-only", Score: 10}, {Path: "both", Score: 5}}
+	semantic := []IndexResult{{Path: "both", Score: 0.9}, {Path: "sem-only", Score: 0.3}}
+
+	results := MergeHybrid(keyword, semantic, 0.5, 0)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	// both: 0.5*0.5 + 0.5*1 = 0.75; kw-only: 0.5; sem-only: 0.5*(0.3/0.9) ≈ 0.167
+	if results[0].Path != "both" || results[1].Path != "kw-only" || results[2].Path != "sem-only" {
+		t.Errorf("order = %v, want both, kw-only, sem-only", results)
+	}
+
+	if limited := MergeHybrid(keyword, semantic, 0.5, 1); len(limited) != 1 {
+		t.Errorf("limit 1: got %d results", len(limited))
+	}
+}
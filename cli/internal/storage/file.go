@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
 )
 
 const (
@@ -146,13 +148,15 @@ func (fs *FileStorage) WriteIndex(entry *IndexEntry) error {
 
 	indexPath := filepath.Join(fs.BaseDir, IndexDir, IndexFile)
 
-	// Check for duplicate by session ID
-	if fs.hasIndexEntry(indexPath, entry.SessionID) {
-		// Already indexed, skip
-		return nil
-	}
-
-	return fs.appendJSONL(indexPath, entry)
+	// Check and append under the file lock so concurrent forges in other
+	// processes cannot both index the same session
+	return fsutil.WithLock(indexPath, func() error {
+		if fs.hasIndexEntry(indexPath, entry.SessionID) {
+			// Already indexed, skip
+			return nil
+		}
+		return fsutil.AppendJSONLLocked(indexPath, 0600, entry)
+	})
 }
 
 // WriteProvenance records provenance information.
@@ -294,37 +298,10 @@ func (fs *FileStorage) atomicWrite(path string, writeFunc func(io.Writer) error)
 	return nil
 }
 
-// appendJSONL appends a JSON line to a file atomically.
+// appendJSONL appends a JSON line to a file under its lock, repairing a
+// torn last line and fsyncing.
 func (fs *FileStorage) appendJSONL(path string, v interface{}) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return err
-	}
-
-	// For append, we can't use pure atomic write, but we can:
-	// 1. Write to temp
-	// 2. Append temp content to main file
-	// This ensures partial writes don't corrupt the main file
-
-	data, err := json.Marshal(v)
-	if err != nil {
-		return fmt.Errorf("marshal json: %w", err)
-	}
-
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
-	if err != nil {
-		return fmt.Errorf("open file: %w", err)
-	}
-	defer func() {
-		_ = f.Close() //nolint:errcheck // sync already called, close best-effort
-	}()
-
-	// Write with newline
-	if _, err := f.Write(append(data, '\n')); err != nil {
-		return fmt.Errorf("write line: %w", err)
-	}
-
-	return f.Sync()
+	return fsutil.AppendJSONL(path, 0600, v)
 }
 
 // hasIndexEntry checks if a session ID already exists in the index.
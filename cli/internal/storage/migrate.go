@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/boshu2/agentops/cli/internal/fsutil"
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// CitationsFile is the citation log under BaseDir used by the files backend.
+const CitationsFile = "citations.jsonl"
+
+// MigrateReport summarizes a conversion between storage backends.
+type MigrateReport struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Sessions     int    `json:"sessions"`
+	IndexEntries int    `json:"index_entries"`
+	Provenance   int    `json:"provenance"`
+	Citations    int    `json:"citations"`
+
+	// Skipped counts malformed JSONL lines that could not be converted.
+	Skipped int  `json:"skipped,omitempty"`
+	DryRun  bool `json:"dry_run,omitempty"`
+}
+
+// sessionRow is a session record with the file it belongs in.
+type sessionRow struct {
+	file string
+	data []byte
+}
+
+// migrateData holds every record of a backend as raw JSON, so conversion
+// never re-encodes (and so never alters) a record.
+type migrateData struct {
+	sessions   []sessionRow
+	index      [][]byte
+	provenance [][]byte
+	citations  [][]byte
+}
+
+// Migrate copies sessions, the index, provenance and citations under
+// baseDir into the given backend, replacing whatever that backend held.
+// The source backend is left untouched. With dryRun nothing is written.
+func Migrate(baseDir, to string, dryRun bool) (*MigrateReport, error) {
+	report := &MigrateReport{To: to, DryRun: dryRun}
+	var (
+		data *migrateData
+		err  error
+	)
+	switch to {
+	case BackendSQLite:
+		report.From = BackendFiles
+		data, err = readFileBackend(baseDir, report)
+	case BackendFiles:
+		report.From = BackendSQLite
+		data, err = readSQLiteBackend(baseDir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected %s or %s)", to, BackendFiles, BackendSQLite)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report.Sessions = len(data.sessions)
+	report.IndexEntries = len(data.index)
+	report.Provenance = len(data.provenance)
+	report.Citations = len(data.citations)
+	if dryRun {
+		return report, nil
+	}
+
+	if to == BackendSQLite {
+		err = writeSQLiteBackend(baseDir, data)
+	} else {
+		err = writeFileBackend(baseDir, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// readFileBackend collects the records kept in JSONL files. Lines that
+// are not valid JSON are skipped and counted in report.Skipped.
+func readFileBackend(baseDir string, report *MigrateReport) (*migrateData, error) {
+	data := &migrateData{}
+	var err error
+	sources := []struct {
+		path string
+		dst  *[][]byte
+	}{
+		{filepath.Join(baseDir, IndexDir, IndexFile), &data.index},
+		{filepath.Join(baseDir, ProvenanceDir, ProvenanceFile), &data.provenance},
+		{filepath.Join(baseDir, CitationsFile), &data.citations},
+	}
+	for _, src := range sources {
+		if *src.dst, err = readJSONLLines(src.path, report); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := filepath.Glob(filepath.Join(baseDir, SessionsDir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	sort.Strings(records)
+	for _, path := range records {
+		lines, err := readJSONLLines(path, report)
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		data.sessions = append(data.sessions, sessionRow{file: path, data: lines[0]})
+	}
+	return data, nil
+}
+
+// readJSONLLines returns the valid JSON lines of a file, without their
+// newline. A missing file has no lines.
+func readJSONLLines(path string, report *MigrateReport) (lines [][]byte, err error) {
+	skipped, err := fsutil.ReadJSONL(path, func(line []byte) error {
+		lines = append(lines, append([]byte(nil), line...))
+		return nil
+	})
+	report.Skipped += skipped
+	return lines, err
+}
+
+// readSQLiteBackend collects the records kept in the database.
+func readSQLiteBackend(baseDir string) (*migrateData, error) {
+	s := NewSQLiteStorage(WithBaseDir(baseDir))
+	if _, err := os.Stat(s.Path()); err != nil {
+		return nil, fmt.Errorf("no SQLite database at %s: %w", s.Path(), err)
+	}
+	defer func() {
+		_ = s.Close() //nolint:errcheck // read-only, close error non-fatal
+	}()
+
+	data := &migrateData{}
+	tables := []struct {
+		query string
+		dst   *[][]byte
+	}{
+		{`SELECT data FROM session_index ORDER BY seq`, &data.index},
+		{`SELECT data FROM provenance ORDER BY seq`, &data.provenance},
+		{`SELECT data FROM citations ORDER BY seq`, &data.citations},
+	}
+	for _, table := range tables {
+		dst := table.dst
+		if err := s.scanData(table.query, nil, func(row []byte) error {
+			*dst = append(*dst, row)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT file, data FROM sessions ORDER BY file`)
+	if err != nil {
+		return nil, fmt.Errorf("query sessions: %w", err)
+	}
+	defer func() {
+		_ = rows.Close() //nolint:errcheck // read-only, close error non-fatal
+	}()
+	for rows.Next() {
+		var file, row string
+		if err := rows.Scan(&file, &row); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		data.sessions = append(data.sessions, sessionRow{file: file, data: []byte(row)})
+	}
+	return data, rows.Err()
+}
+
+// writeSQLiteBackend replaces the database contents in one transaction.
+func writeSQLiteBackend(baseDir string, data *migrateData) (err error) {
+	s := NewSQLiteStorage(WithBaseDir(baseDir))
+	defer func() {
+		if cerr := s.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() //nolint:errcheck // no-op after commit
+	}()
+
+	for _, table := range []string{"sessions", "session_index", "provenance", "citations"} {
+		if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+	}
+
+	for _, row := range data.sessions {
+		var session Session
+		if err := json.Unmarshal(row.data, &session); err != nil {
+			return fmt.Errorf("decode session %s: %w", row.file, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO sessions (id, date, file, data) VALUES (?, ?, ?, ?)`,
+			session.ID, formatTime(session.Date), row.file, string(row.data)); err != nil {
+			return fmt.Errorf("insert session: %w", err)
+		}
+	}
+	for _, line := range data.index {
+		var entry IndexEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("decode index entry: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO session_index (session_id, date, session_path, data) VALUES (?, ?, ?, ?)`,
+			entry.SessionID, formatTime(entry.Date), entry.SessionPath, string(line)); err != nil {
+			return fmt.Errorf("insert index entry: %w", err)
+		}
+	}
+	for _, line := range data.provenance {
+		var record ProvenanceRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("decode provenance: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO provenance (id, artifact_path, source_path, session_id, data) VALUES (?, ?, ?, ?, ?)`,
+			record.ID, record.ArtifactPath, record.SourcePath, record.SessionID, string(line)); err != nil {
+			return fmt.Errorf("insert provenance: %w", err)
+		}
+	}
+	for _, line := range data.citations {
+		var event types.CitationEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return fmt.Errorf("decode citation: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO citations (artifact_path, session_id, cited_at, data) VALUES (?, ?, ?, ?)`,
+			event.ArtifactPath, event.SessionID, formatTime(event.CitedAt), string(line)); err != nil {
+			return fmt.Errorf("insert citation: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// writeFileBackend replaces the JSONL files atomically and writes each
+// session record to its file.
+func writeFileBackend(baseDir string, data *migrateData) error {
+	fs := NewFileStorage(WithBaseDir(baseDir))
+	if err := fs.Init(); err != nil {
+		return err
+	}
+	files := []struct {
+		path  string
+		lines [][]byte
+	}{
+		{filepath.Join(baseDir, IndexDir, IndexFile), data.index},
+		{filepath.Join(baseDir, ProvenanceDir, ProvenanceFile), data.provenance},
+		{filepath.Join(baseDir, CitationsFile), data.citations},
+	}
+	for _, file := range files {
+		write := func() error { return fs.atomicWrite(file.path, writeLines(file.lines)) }
+		if err := fsutil.WithLock(file.path, write); err != nil {
+			return fmt.Errorf("write %s: %w", file.path, err)
+		}
+	}
+	for _, row := range data.sessions {
+		if err := fs.atomicWrite(row.file, writeLines([][]byte{row.data})); err != nil {
+			return fmt.Errorf("write %s: %w", row.file, err)
+		}
+	}
+	return nil
+}
+
+// writeLines returns an atomicWrite callback writing one line per record.
+func writeLines(lines [][]byte) func(io.Writer) error {
+	return func(w io.Writer) error {
+		for _, line := range lines {
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	// Pure-Go SQLite driver, registered as "sqlite".
+	_ "modernc.org/sqlite"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+const (
+	// SQLiteFile is the database file of the SQLite backend, under BaseDir.
+	SQLiteFile = "ao.db"
+
+	// sqliteSchemaVersion is stored in PRAGMA user_version.
+	sqliteSchemaVersion = 1
+)
+
+// sqliteSchema creates the tables. Every row keeps the record's JSON in
+// data, byte for byte, so conversion to and from the JSONL files is
+// lossless; the other columns are indexed copies of the fields queried.
+// Sessions are keyed by ID and file together: the files backend can hold
+// the same session ID in several files, and each must survive migration.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id    TEXT NOT NULL,
+	date  TEXT NOT NULL,
+	file  TEXT NOT NULL,
+	data  TEXT NOT NULL,
+	PRIMARY KEY (id, file)
+);
+CREATE INDEX IF NOT EXISTS sessions_date ON sessions(date);
+
+CREATE TABLE IF NOT EXISTS session_index (
+	seq          INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id   TEXT NOT NULL,
+	date         TEXT NOT NULL,
+	session_path TEXT NOT NULL,
+	data         TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS session_index_session ON session_index(session_id);
+CREATE INDEX IF NOT EXISTS session_index_date ON session_index(date);
+
+CREATE TABLE IF NOT EXISTS provenance (
+	seq           INTEGER PRIMARY KEY AUTOINCREMENT,
+	id            TEXT NOT NULL,
+	artifact_path TEXT NOT NULL,
+	source_path   TEXT NOT NULL,
+	session_id    TEXT NOT NULL,
+	data          TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS provenance_artifact ON provenance(artifact_path);
+CREATE INDEX IF NOT EXISTS provenance_source ON provenance(source_path);
+CREATE INDEX IF NOT EXISTS provenance_session ON provenance(session_id);
+
+CREATE TABLE IF NOT EXISTS citations (
+	seq           INTEGER PRIMARY KEY AUTOINCREMENT,
+	artifact_path TEXT NOT NULL,
+	session_id    TEXT NOT NULL,
+	cited_at      TEXT NOT NULL,
+	data          TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS citations_artifact ON citations(artifact_path);
+CREATE INDEX IF NOT EXISTS citations_session ON citations(session_id);
+`
+
+// SQLiteStorage implements Storage with an embedded SQLite database for the
+// session records, index, provenance graph and citation log. Session
+// documents (markdown and friends) are still rendered to the sessions
+// directory, since search and inject read them as files.
+type SQLiteStorage struct {
+	files *FileStorage
+
+	mu sync.Mutex
+	db *sql.DB
+}
+
+// NewSQLiteStorage creates SQLite-backed storage. The options are those of
+// FileStorage: the base directory holds ao.db and the session documents.
+func NewSQLiteStorage(opts ...FileStorageOption) *SQLiteStorage {
+	return &SQLiteStorage{files: NewFileStorage(opts...)}
+}
+
+// Path returns the database file path.
+func (s *SQLiteStorage) Path() string {
+	return filepath.Join(s.files.BaseDir, SQLiteFile)
+}
+
+// Init creates the directory structure and the database schema.
+func (s *SQLiteStorage) Init() error {
+	if err := s.files.Init(); err != nil {
+		return err
+	}
+	_, err := s.open()
+	return err
+}
+
+// open opens the database on first use and applies the schema.
+func (s *SQLiteStorage) open() (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	if err := os.MkdirAll(s.files.BaseDir, 0700); err != nil {
+		return nil, fmt.Errorf("create directory %s: %w", s.files.BaseDir, err)
+	}
+	dsn := "file:" + s.Path() + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", s.Path(), err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close() //nolint:errcheck // already failing
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteSchemaVersion)); err != nil {
+		_ = db.Close() //nolint:errcheck // already failing
+		return nil, fmt.Errorf("set schema version: %w", err)
+	}
+	s.db = db
+	return db, nil
+}
+
+// WriteSession renders the session documents and stores the session record.
+func (s *SQLiteStorage) WriteSession(session *Session) (string, error) {
+	path, err := s.files.WriteSession(session)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", fmt.Errorf("marshal session: %w", err)
+	}
+	db, err := s.open()
+	if err != nil {
+		return "", err
+	}
+	if _, err := db.Exec(`INSERT OR REPLACE INTO sessions (id, date, file, data) VALUES (?, ?, ?, ?)`,
+		session.ID, formatTime(session.Date), sessionRecordPath(path), string(data)); err != nil {
+		return "", fmt.Errorf("insert session: %w", err)
+	}
+	return path, nil
+}
+
+// WriteIndex adds an entry to the session index unless the session is
+// already indexed.
+func (s *SQLiteStorage) WriteIndex(entry *IndexEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal index entry: %w", err)
+	}
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO session_index (session_id, date, session_path, data)
+		SELECT ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM session_index WHERE session_id = ?)`,
+		entry.SessionID, formatTime(entry.Date), entry.SessionPath, string(data), entry.SessionID)
+	if err != nil {
+		return fmt.Errorf("insert index entry: %w", err)
+	}
+	return nil
+}
+
+// WriteProvenance records provenance information.
+func (s *SQLiteStorage) WriteProvenance(record *ProvenanceRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal provenance: %w", err)
+	}
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`INSERT INTO provenance (id, artifact_path, source_path, session_id, data) VALUES (?, ?, ?, ?, ?)`,
+		record.ID, record.ArtifactPath, record.SourcePath, record.SessionID, string(data)); err != nil {
+		return fmt.Errorf("insert provenance: %w", err)
+	}
+	return nil
+}
+
+// ReadSession retrieves a session by ID. When several records share the
+// ID, the first one written is returned, as the files backend returns the
+// first indexed.
+func (s *SQLiteStorage) ReadSession(sessionID string) (*Session, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	var data string
+	err = db.QueryRow(`SELECT data FROM sessions WHERE id = ? ORDER BY rowid LIMIT 1`, sessionID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query session: %w", err)
+	}
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", sessionID, err)
+	}
+	return &session, nil
+}
+
+// ListSessions returns all session index entries, oldest write first.
+func (s *SQLiteStorage) ListSessions() ([]IndexEntry, error) {
+	var entries []IndexEntry
+	err := s.scanData(`SELECT data FROM session_index ORDER BY seq`, nil, func(data []byte) error {
+		var entry IndexEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil // Skip malformed rows, as the JSONL reader does
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// QueryProvenance finds provenance records for an artifact.
+func (s *SQLiteStorage) QueryProvenance(artifactPath string) ([]ProvenanceRecord, error) {
+	var records []ProvenanceRecord
+	err := s.scanData(`SELECT data FROM provenance WHERE artifact_path = ? ORDER BY seq`, []interface{}{artifactPath}, func(data []byte) error {
+		var record ProvenanceRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil
+		}
+		records = append(records, record)
+		return nil
+	})
+	return records, err
+}
+
+// ListProvenance returns the whole provenance graph in the order recorded.
+func (s *SQLiteStorage) ListProvenance() ([]ProvenanceRecord, error) {
+	var records []ProvenanceRecord
+	err := s.scanData(`SELECT data FROM provenance ORDER BY seq`, nil, func(data []byte) error {
+		var record ProvenanceRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil
+		}
+		records = append(records, record)
+		return nil
+	})
+	return records, err
+}
+
+// WriteCitation appends a citation event.
+func (s *SQLiteStorage) WriteCitation(event types.CitationEvent) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	return insertCitation(db, event)
+}
+
+// ListCitations returns every citation event in the order recorded.
+func (s *SQLiteStorage) ListCitations() ([]types.CitationEvent, error) {
+	var events []types.CitationEvent
+	err := s.scanData(`SELECT data FROM citations ORDER BY seq`, nil, func(data []byte) error {
+		var event types.CitationEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil
+		}
+		events = append(events, event)
+		return nil
+	})
+	return events, err
+}
+
+// ReplaceCitations rewrites the citation log, e.g. after feedback is
+// recorded on existing events.
+func (s *SQLiteStorage) ReplaceCitations(events []types.CitationEvent) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() //nolint:errcheck // no-op after commit
+	}()
+	if _, err := tx.Exec(`DELETE FROM citations`); err != nil {
+		return fmt.Errorf("clear citations: %w", err)
+	}
+	for _, event := range events {
+		if err := insertCitation(tx, event); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close releases the database handle.
+func (s *SQLiteStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+// scanData runs a query selecting one data column and calls fn per row.
+func (s *SQLiteStorage) scanData(query string, args []interface{}, fn func(data []byte) error) (err error) {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return fmt.Errorf("scan row: %w", err)
+		}
+		if err := fn([]byte(data)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertCitation inserts one citation event.
+func insertCitation(db execer, event types.CitationEvent) error {
+	if event.CitedAt.IsZero() {
+		event.CitedAt = time.Now()
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal citation: %w", err)
+	}
+	if _, err := db.Exec(`INSERT INTO citations (artifact_path, session_id, cited_at, data) VALUES (?, ?, ?, ?)`,
+		event.ArtifactPath, event.SessionID, formatTime(event.CitedAt), string(data)); err != nil {
+		return fmt.Errorf("insert citation: %w", err)
+	}
+	return nil
+}
+
+// formatTime renders a time for an indexed column.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// sessionRecordPath returns the JSONL session record written next to the
+// primary session document at path.
+func sessionRecordPath(path string) string {
+	return path[:len(path)-len(filepath.Ext(path))] + ".jsonl"
+}
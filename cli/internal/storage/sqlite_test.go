@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func TestSQLiteStorage_RoundTrip(t *testing.T) {
+	s := NewSQLiteStorage(WithBaseDir(t.TempDir()), WithFormatters(&jsonlFormatter{}))
+	defer func() { _ = s.Close() }()
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	session := &Session{ID: "abc1234567", Date: date, Summary: "sqlite backend", Decisions: []string{"use WAL"}}
+	path, err := s.WriteSession(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("session document not rendered: %v", err)
+	}
+
+	entry := &IndexEntry{SessionID: session.ID, Date: date, SessionPath: path, Summary: session.Summary}
+	for i := 0; i < 2; i++ {
+		if err := s.WriteIndex(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := s.ListSessions()
+	if err != nil || len(entries) != 1 || entries[0].SessionPath != path {
+		t.Fatalf("ListSessions = %+v, %v (want one deduplicated entry)", entries, err)
+	}
+
+	got, err := s.ReadSession(session.ID)
+	if err != nil || got.Summary != session.Summary || len(got.Decisions) != 1 {
+		t.Fatalf("ReadSession = %+v, %v", got, err)
+	}
+	if _, err := s.ReadSession("missing"); err == nil {
+		t.Error("ReadSession of unknown ID should fail")
+	}
+
+	for _, artifact := range []string{path, "other.md"} {
+		if err := s.WriteProvenance(&ProvenanceRecord{ID: "prov-" + artifact, ArtifactPath: artifact, SourcePath: "t.jsonl", SessionID: session.ID, CreatedAt: date}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := s.QueryProvenance(path)
+	if err != nil || len(records) != 1 || records[0].SourcePath != "t.jsonl" {
+		t.Errorf("QueryProvenance = %+v, %v", records, err)
+	}
+	if all, _ := s.ListProvenance(); len(all) != 2 {
+		t.Errorf("ListProvenance returned %d records, want 2", len(all))
+	}
+}
+
+func TestSQLiteStorage_Citations(t *testing.T) {
+	s := NewSQLiteStorage(WithBaseDir(t.TempDir()))
+	defer func() { _ = s.Close() }()
+
+	for _, id := range []string{"s1", "s2"} {
+		if err := s.WriteCitation(types.CitationEvent{ArtifactPath: "/a.md", SessionID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, err := s.ListCitations()
+	if err != nil || len(events) != 2 || events[0].CitedAt.IsZero() {
+		t.Fatalf("ListCitations = %+v, %v", events, err)
+	}
+
+	events[1].FeedbackGiven = true
+	if err := s.ReplaceCitations(events[1:]); err != nil {
+		t.Fatal(err)
+	}
+	events, _ = s.ListCitations()
+	if len(events) != 1 || events[0].SessionID != "s2" || !events[0].FeedbackGiven {
+		t.Errorf("after ReplaceCitations = %+v", events)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	for backend, want := range map[string]string{"": "*storage.FileStorage", BackendFiles: "*storage.FileStorage", BackendSQLite: "*storage.SQLiteStorage"} {
+		s, err := Open(backend, dir)
+		if err != nil {
+			t.Fatalf("Open(%q): %v", backend, err)
+		}
+		if got := fmt.Sprintf("%T", s); got != want {
+			t.Errorf("Open(%q) = %s, want %s", backend, got, want)
+		}
+		_ = s.Close()
+	}
+	if _, err := Open("postgres", dir); err == nil {
+		t.Error("unknown backend should fail")
+	}
+}
+
+func TestMigrate_RoundTrip(t *testing.T) {
+	baseDir := t.TempDir()
+	fs := NewFileStorage(WithBaseDir(baseDir), WithFormatters(&jsonlFormatter{}))
+	if err := fs.Init(); err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, id := range []string{"aaaaaaa1", "bbbbbbb2"} {
+		path, err := fs.WriteSession(&Session{ID: id, Date: date, Summary: "session " + id, ToolCalls: map[string]int{"Bash": 3}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.WriteIndex(&IndexEntry{SessionID: id, Date: date, SessionPath: path, Tags: []string{"x"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.WriteProvenance(&ProvenanceRecord{ID: "prov-" + id, ArtifactPath: path, SourcePath: "t.jsonl", Metadata: map[string]interface{}{"n": 1.5}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	citations := `{"artifact_path":"/a.md","session_id":"s1","cited_at":"2026-03-01T12:00:00.123456789+02:00","unknown_field":true}` + "\n" +
+		"not json\n"
+	if err := os.WriteFile(filepath.Join(baseDir, CitationsFile), []byte(citations), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []string{
+		filepath.Join(baseDir, IndexDir, IndexFile),
+		filepath.Join(baseDir, ProvenanceDir, ProvenanceFile),
+	}
+	sessionFiles, _ := filepath.Glob(filepath.Join(baseDir, SessionsDir, "*.jsonl"))
+	files = append(files, sessionFiles...)
+	before := make(map[string][]byte)
+	for _, f := range files {
+		before[f], _ = os.ReadFile(f)
+	}
+
+	dry, err := Migrate(baseDir, BackendSQLite, true)
+	if err != nil || !dry.DryRun || dry.Sessions != 2 {
+		t.Fatalf("dry run = %+v, %v", dry, err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, SQLiteFile)); !os.IsNotExist(err) {
+		t.Error("dry run should not create the database")
+	}
+
+	report, err := Migrate(baseDir, BackendSQLite, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Sessions != 2 || report.IndexEntries != 2 || report.Provenance != 2 || report.Citations != 1 || report.Skipped != 1 {
+		t.Errorf("to sqlite = %+v", report)
+	}
+
+	s := NewSQLiteStorage(WithBaseDir(baseDir))
+	if got, err := s.ReadSession("bbbbbbb2"); err != nil || got.ToolCalls["Bash"] != 3 {
+		t.Errorf("migrated ReadSession = %+v, %v", got, err)
+	}
+	_ = s.Close()
+
+	// Remove the file backend entirely and rebuild it from the database.
+	for _, f := range append(files, filepath.Join(baseDir, CitationsFile)) {
+		if err := os.Remove(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report, err = Migrate(baseDir, BackendFiles, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.From != BackendSQLite || report.Sessions != 2 || report.Citations != 1 {
+		t.Errorf("to files = %+v", report)
+	}
+	for _, f := range files {
+		after, err := os.ReadFile(f)
+		if err != nil || !bytes.Equal(after, before[f]) {
+			t.Errorf("%s not restored byte for byte:\nbefore %q\nafter  %q (%v)", filepath.Base(f), before[f], after, err)
+		}
+	}
+	after, _ := os.ReadFile(filepath.Join(baseDir, CitationsFile))
+	if want := citations[:len(citations)-len("not json\n")]; string(after) != want {
+		t.Errorf("citations = %q, want %q", after, want)
+	}
+}
+
+func TestMigrate_RepeatedSessionID(t *testing.T) {
+	baseDir := t.TempDir()
+	fs := NewFileStorage(WithBaseDir(baseDir), WithFormatters(&jsonlFormatter{}))
+	if err := fs.Init(); err != nil {
+		t.Fatal(err)
+	}
+	// The same session forged on two days lands in two files
+	for day := 1; day <= 2; day++ {
+		date := time.Date(2026, 3, day, 12, 0, 0, 0, time.UTC)
+		if _, err := fs.WriteSession(&Session{ID: "dupdupdup1", Date: date, Summary: fmt.Sprintf("day %d", day)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessionFiles, _ := filepath.Glob(filepath.Join(baseDir, SessionsDir, "*.jsonl"))
+	if len(sessionFiles) != 2 {
+		t.Fatalf("session files = %v, want 2", sessionFiles)
+	}
+	before := make(map[string][]byte)
+	for _, f := range sessionFiles {
+		before[f], _ = os.ReadFile(f)
+	}
+
+	report, err := Migrate(baseDir, BackendSQLite, false)
+	if err != nil || report.Sessions != 2 {
+		t.Fatalf("to sqlite = %+v, %v", report, err)
+	}
+	s := NewSQLiteStorage(WithBaseDir(baseDir))
+	if got, err := s.ReadSession("dupdupdup1"); err != nil || got.Summary != "day 1" {
+		t.Errorf("ReadSession = %+v, %v (want the first record)", got, err)
+	}
+	_ = s.Close()
+
+	for _, f := range sessionFiles {
+		if err := os.Remove(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report, err = Migrate(baseDir, BackendFiles, false)
+	if err != nil || report.Sessions != 2 {
+		t.Fatalf("to files = %+v, %v", report, err)
+	}
+	for _, f := range sessionFiles {
+		after, err := os.ReadFile(f)
+		if err != nil || !bytes.Equal(after, before[f]) {
+			t.Errorf("%s not restored byte for byte:\nbefore %q\nafter  %q (%v)", filepath.Base(f), before[f], after, err)
+		}
+	}
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	if _, err := Migrate(t.TempDir(), BackendFiles, false); err == nil {
+		t.Error("migrating to files without a database should fail")
+	}
+	if _, err := Migrate(t.TempDir(), "mongo", false); err == nil {
+		t.Error("unknown backend should fail")
+	}
+}
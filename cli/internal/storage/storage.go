@@ -3,6 +3,7 @@
 package storage
 
 import (
+	"fmt"
 	"io"
 	"time"
 )
@@ -129,3 +130,26 @@ type Formatter interface {
 	// Extension returns the file extension for this format.
 	Extension() string
 }
+
+// Storage backends selectable with the storage.backend config setting.
+const (
+	// BackendFiles keeps everything in JSONL files under BaseDir.
+	BackendFiles = "files"
+
+	// BackendSQLite keeps records in an embedded SQLite database.
+	BackendSQLite = "sqlite"
+)
+
+// Open returns the storage for a backend name. An empty name selects
+// BackendFiles.
+func Open(backend, baseDir string, formatters ...Formatter) (Storage, error) {
+	opts := []FileStorageOption{WithBaseDir(baseDir), WithFormatters(formatters...)}
+	switch backend {
+	case "", BackendFiles:
+		return NewFileStorage(opts...), nil
+	case BackendSQLite:
+		return NewSQLiteStorage(opts...), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected %s or %s)", backend, BackendFiles, BackendSQLite)
+	}
+}
@@ -0,0 +1,249 @@
+package taxonomy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+// ConfigFileVersion is the supported taxonomy.yaml version.
+const ConfigFileVersion = 1
+
+// ConfigFile is the YAML form of taxonomy overrides:
+//
+//	version: 1
+//	weights:                  # must sum to 1.0
+//	  specificity: 0.45
+//	  actionability: 0.20
+//	  novelty: 0.15
+//	  context: 0.10
+//	  confidence: 0.10
+//	tiers:
+//	  gold:   {min_score: 0.90}
+//	  bronze: {min_score: 0.55, human_gate: true, sample_rate: 0.10}
+//	base_scores:
+//	  reference: 0.4
+//
+// Anything omitted keeps its default. A tier's upper bound is the next
+// tier's min_score.
+type ConfigFile struct {
+	Version    int                 `yaml:"version"`
+	Weights    *WeightsSpec        `yaml:"weights,omitempty"`
+	Tiers      map[string]TierSpec `yaml:"tiers,omitempty"`
+	BaseScores map[string]float64  `yaml:"base_scores,omitempty"`
+}
+
+// WeightsSpec overrides rubric weights in a ConfigFile.
+type WeightsSpec struct {
+	Specificity   *float64 `yaml:"specificity,omitempty"`
+	Actionability *float64 `yaml:"actionability,omitempty"`
+	Novelty       *float64 `yaml:"novelty,omitempty"`
+	Context       *float64 `yaml:"context,omitempty"`
+	Confidence    *float64 `yaml:"confidence,omitempty"`
+}
+
+// TierSpec overrides one tier in a ConfigFile.
+type TierSpec struct {
+	// MinScore is the lowest composite score in the tier (0.0-1.0).
+	MinScore *float64 `yaml:"min_score,omitempty"`
+
+	// Confidence is stored on candidates in the tier (0.0-1.0).
+	Confidence *float64 `yaml:"confidence,omitempty"`
+
+	// HumanGate requires human review before promotion.
+	HumanGate *bool `yaml:"human_gate,omitempty"`
+
+	// SampleRate is the fraction of entries sampled for review (0.0-1.0).
+	SampleRate *float64 `yaml:"sample_rate,omitempty"`
+}
+
+// Taxonomy is an effective set of scoring settings: the defaults with any
+// configured overrides applied.
+type Taxonomy struct {
+	Weights    RubricWeights
+	Tiers      map[types.Tier]TierConfig
+	BaseScores map[types.KnowledgeType]float64
+}
+
+// Default returns the compiled-in taxonomy.
+func Default() *Taxonomy {
+	t := &Taxonomy{
+		Weights:    DefaultRubricWeights,
+		Tiers:      make(map[types.Tier]TierConfig, len(DefaultTierConfigs)),
+		BaseScores: make(map[types.KnowledgeType]float64, len(KnowledgeTypes)),
+	}
+	for tier, cfg := range DefaultTierConfigs {
+		t.Tiers[tier] = cfg
+	}
+	for kt, info := range KnowledgeTypes {
+		t.BaseScores[kt] = info.BaseScore
+	}
+	return t
+}
+
+// LoadConfigFile reads and validates a taxonomy file and returns the
+// defaults with its overrides applied.
+func LoadConfigFile(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cf ConfigFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	t, err := cf.Apply(Default())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Apply returns base with the file's overrides applied. Every problem is
+// reported, not just the first.
+func (cf *ConfigFile) Apply(base *Taxonomy) (*Taxonomy, error) {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	unit := func(field string, v *float64) {
+		if v != nil && (*v < 0 || *v > 1) {
+			fail("%s: must be between 0 and 1", field)
+		}
+	}
+
+	if cf.Version != ConfigFileVersion {
+		fail("unsupported version %d (expected %d)", cf.Version, ConfigFileVersion)
+	}
+
+	t := &Taxonomy{
+		Weights:    base.Weights,
+		Tiers:      make(map[types.Tier]TierConfig, len(base.Tiers)),
+		BaseScores: make(map[types.KnowledgeType]float64, len(base.BaseScores)),
+	}
+	for tier, cfg := range base.Tiers {
+		t.Tiers[tier] = cfg
+	}
+	for kt, score := range base.BaseScores {
+		t.BaseScores[kt] = score
+	}
+
+	if w := cf.Weights; w != nil {
+		for _, f := range []struct {
+			name string
+			spec *float64
+			dst  *float64
+		}{
+			{"specificity", w.Specificity, &t.Weights.Specificity},
+			{"actionability", w.Actionability, &t.Weights.Actionability},
+			{"novelty", w.Novelty, &t.Weights.Novelty},
+			{"context", w.Context, &t.Weights.Context},
+			{"confidence", w.Confidence, &t.Weights.Confidence},
+		} {
+			unit("weights."+f.name, f.spec)
+			if f.spec != nil {
+				*f.dst = *f.spec
+			}
+		}
+		if !t.Weights.ValidateWeights() {
+			fail("weights: must sum to 1.0, got %.2f", t.Weights.Sum())
+		}
+	}
+
+	for name, spec := range cf.Tiers {
+		tier := types.Tier(strings.ToLower(name))
+		cfg, ok := t.Tiers[tier]
+		if !ok {
+			fail("tiers: unknown tier %q", name)
+			continue
+		}
+		field := "tiers." + name
+		unit(field+".min_score", spec.MinScore)
+		unit(field+".confidence", spec.Confidence)
+		unit(field+".sample_rate", spec.SampleRate)
+		if spec.MinScore != nil {
+			if tier == types.TierDiscard && *spec.MinScore != 0 {
+				fail("%s.min_score: discard always starts at 0", field)
+			}
+			cfg.MinScore = *spec.MinScore
+		}
+		if spec.Confidence != nil {
+			cfg.Confidence = *spec.Confidence
+		}
+		if spec.HumanGate != nil {
+			cfg.HumanGateRequired = *spec.HumanGate
+		}
+		if spec.SampleRate != nil {
+			cfg.HumanGateSampleRate = *spec.SampleRate
+		}
+		t.Tiers[tier] = cfg
+	}
+
+	// Thresholds must fall strictly from gold to discard; each tier ends
+	// where the one above begins
+	upper := DefaultTierConfigs[types.TierGold].MaxScore
+	prev := types.Tier("")
+	for _, tier := range TierOrder {
+		cfg, ok := t.Tiers[tier]
+		if !ok {
+			continue
+		}
+		if prev != "" && cfg.MinScore >= t.Tiers[prev].MinScore {
+			fail("tiers.%s.min_score: %.2f must be below %s (%.2f)", tier, cfg.MinScore, prev, t.Tiers[prev].MinScore)
+		}
+		cfg.MaxScore = upper
+		t.Tiers[tier] = cfg
+		upper, prev = cfg.MinScore, tier
+	}
+
+	for name, score := range cf.BaseScores {
+		kt := types.KnowledgeType(strings.ToLower(name))
+		if _, ok := KnowledgeTypes[kt]; !ok {
+			fail("base_scores: unknown knowledge type %q", name)
+			continue
+		}
+		unit("base_scores."+name, &score)
+		t.BaseScores[kt] = score
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return t, nil
+}
+
+// AssignTier returns the tier for a composite score.
+func (t *Taxonomy) AssignTier(score float64) types.Tier {
+	return AssignTier(score, t.Tiers)
+}
+
+// BaseScore returns the base score for a knowledge type.
+func (t *Taxonomy) BaseScore(kt types.KnowledgeType) float64 {
+	if score, ok := t.BaseScores[kt]; ok {
+		return score
+	}
+	return GetBaseScore(kt)
+}
+
+// Confidence returns the confidence stored for a tier.
+func (t *Taxonomy) Confidence(tier types.Tier) float64 {
+	return GetConfidence(tier, t.Tiers)
+}
+
+// RequiresHumanGate checks if a tier requires human review.
+func (t *Taxonomy) RequiresHumanGate(tier types.Tier) bool {
+	return RequiresHumanGate(tier, t.Tiers)
+}
+
+// Score returns the composite score for rubric scores of a knowledge type:
+// the mean of the type's base score and the weighted rubric.
+func (t *Taxonomy) Score(kt types.KnowledgeType, r types.RubricScores) float64 {
+	return (t.BaseScore(kt) + t.Weights.Weigh(r)) / 2.0
+}
@@ -0,0 +1,109 @@
+package taxonomy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boshu2/agentops/cli/internal/types"
+)
+
+func writeTaxonomy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "taxonomy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile_Overrides(t *testing.T) {
+	path := writeTaxonomy(t, `version: 1
+weights:
+  specificity: 0.45
+  actionability: 0.20
+  novelty: 0.15
+  context: 0.10
+  confidence: 0.10
+tiers:
+  gold: {min_score: 0.90}
+  bronze: {min_score: 0.55, human_gate: false, sample_rate: 0.1}
+base_scores:
+  Reference: 0.4
+`)
+	tax, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tax.Weights.Specificity != 0.45 || tax.Weights.Actionability != 0.20 {
+		t.Errorf("weights = %+v", tax.Weights)
+	}
+	if tax.AssignTier(0.88) != types.TierSilver || tax.AssignTier(0.90) != types.TierGold {
+		t.Error("gold threshold not applied")
+	}
+	if tax.AssignTier(0.52) != types.TierDiscard {
+		t.Error("bronze threshold not applied")
+	}
+	if tax.Tiers[types.TierSilver].MaxScore != 0.90 || tax.Tiers[types.TierDiscard].MaxScore != 0.55 {
+		t.Errorf("upper bounds not derived: %+v", tax.Tiers)
+	}
+	if tax.RequiresHumanGate(types.TierBronze) || tax.Tiers[types.TierBronze].HumanGateSampleRate != 0.1 {
+		t.Errorf("bronze gate = %+v", tax.Tiers[types.TierBronze])
+	}
+	if tax.BaseScore(types.KnowledgeTypeReference) != 0.4 || tax.BaseScore(types.KnowledgeTypeSolution) != 0.9 {
+		t.Errorf("base scores = %v", tax.BaseScores)
+	}
+
+	// The package defaults are untouched
+	if DefaultTierConfigs[types.TierGold].MinScore != 0.85 || DefaultRubricWeights.Specificity != 0.30 {
+		t.Error("loading a file modified the defaults")
+	}
+}
+
+func TestLoadConfigFile_ReportsEveryProblem(t *testing.T) {
+	path := writeTaxonomy(t, `version: 2
+weights:
+  specificity: 0.9
+tiers:
+  platinum: {min_score: 0.99}
+  silver: {min_score: 0.9, confidence: 1.5}
+  discard: {min_score: 0.1}
+base_scores:
+  opinion: 0.3
+`)
+	_, err := LoadConfigFile(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"unsupported version 2",
+		"weights: must sum to 1.0",
+		`unknown tier "platinum"`,
+		"tiers.silver.confidence: must be between 0 and 1",
+		"tiers.silver.min_score: 0.90 must be below gold",
+		"tiers.discard.min_score: discard always starts at 0",
+		`unknown knowledge type "opinion"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestTaxonomyScore(t *testing.T) {
+	rubric := types.RubricScores{Specificity: 1, Actionability: 0.5, Novelty: 0.5, Context: 0.5, Confidence: 0.5}
+	def := Default()
+	got := def.Score(types.KnowledgeTypeLearning, rubric)
+	want := (0.7 + DefaultRubricWeights.Weigh(rubric)) / 2
+	if got != want {
+		t.Errorf("Score = %v, want %v", got, want)
+	}
+
+	heavy := Default()
+	heavy.Weights = RubricWeights{Specificity: 0.6, Actionability: 0.1, Novelty: 0.1, Context: 0.1, Confidence: 0.1}
+	if heavy.Score(types.KnowledgeTypeLearning, rubric) <= got {
+		t.Error("weighting specificity higher should raise a specific candidate's score")
+	}
+}
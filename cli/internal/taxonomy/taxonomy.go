@@ -25,6 +25,9 @@
 //   - Novelty (20%): Uniqueness vs. common knowledge
 //   - Context (15%): Quality of surrounding context
 //   - Confidence (10%): Assertion strength
+//
+// These are the defaults. A project can override weights, tier thresholds,
+// human-gate settings and base scores with a ConfigFile; see Taxonomy.
 package taxonomy
 
 import "github.com/boshu2/agentops/cli/internal/types"
@@ -92,7 +95,7 @@ type TierConfig struct {
 }
 
 // DefaultTierConfigs provides the default tier thresholds.
-// These can be overridden via configuration.
+// These can be overridden via configuration (see ConfigFile).
 var DefaultTierConfigs = map[types.Tier]TierConfig{
 	types.TierGold: {
 		Tier:                types.TierGold,
@@ -163,11 +166,25 @@ var DefaultRubricWeights = RubricWeights{
 
 // ValidateWeights checks that rubric weights sum to 1.0.
 func (w RubricWeights) ValidateWeights() bool {
-	sum := w.Specificity + w.Actionability + w.Novelty + w.Context + w.Confidence
+	sum := w.Sum()
 	// Allow small floating point variance
 	return sum >= 0.99 && sum <= 1.01
 }
 
+// Sum returns the total of all weights.
+func (w RubricWeights) Sum() float64 {
+	return w.Specificity + w.Actionability + w.Novelty + w.Context + w.Confidence
+}
+
+// Weigh returns the weighted sum of rubric scores.
+func (w RubricWeights) Weigh(r types.RubricScores) float64 {
+	return r.Specificity*w.Specificity +
+		r.Actionability*w.Actionability +
+		r.Novelty*w.Novelty +
+		r.Context*w.Context +
+		r.Confidence*w.Confidence
+}
+
 // TierOrder provides the tier ordering from highest to lowest quality.
 var TierOrder = []types.Tier{
 	types.TierGold,